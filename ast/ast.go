@@ -81,6 +81,23 @@ type Rule struct {
 	DisplayName *StringLit
 	Expr        Expression
 
+	// Params is the list of parameters of a parameterized rule. Such a
+	// rule is a template that is instantiated for each distinct set of
+	// arguments it is called with, see InstantiateRules.
+	Params []*Identifier
+
+	// Macro is true if the rule is a macro, whose expression is inlined
+	// in place of the references to it, see ExpandMacros.
+	Macro bool
+
+	// Doc is the documentation of the rule, the text of the line comments
+	// that immediately precede it in the grammar.
+	Doc string
+
+	// EndLine is the line of the grammar on which the rule ends, or 0 if
+	// it is unknown. The rule starts on the line of its position.
+	EndLine int
+
 	// Fields below to work with left recursion.
 	Visited       bool
 	Nullable      bool
@@ -101,6 +118,14 @@ func (r *Rule) Pos() Pos { return r.p }
 
 // String returns the textual representation of a node.
 func (r *Rule) String() string {
+	if r.Macro {
+		return fmt.Sprintf("%s: %T{Name: %v, Macro: true, Expr: %v}",
+			r.p, r, r.Name, r.Expr)
+	}
+	if len(r.Params) > 0 {
+		return fmt.Sprintf("%s: %T{Name: %v, Params: %v, DisplayName: %v, Expr: %v}",
+			r.p, r, r.Name, r.Params, r.DisplayName, r.Expr)
+	}
 	return fmt.Sprintf("%s: %T{Name: %v, DisplayName: %v, Expr: %v}",
 		r.p, r, r.Name, r.DisplayName, r.Expr)
 }
@@ -305,10 +330,14 @@ func (a *ActionExpr) InitialNames() map[string]struct{} {
 }
 
 // ThrowExpr is an expression that throws an FailureLabel to be caught by a
-// RecoveryChoiceExpr.
+// RecoveryChoiceExpr. Payload is the optional code block whose value is
+// thrown with the label, see the TypedThrows option of the builder.
 type ThrowExpr struct {
-	p     Pos
-	Label string
+	p       Pos
+	Label   string
+	Payload *CodeBlock
+
+	FuncIx int
 }
 
 var _ Expression = (*ThrowExpr)(nil)
@@ -323,6 +352,9 @@ func (t *ThrowExpr) Pos() Pos { return t.p }
 
 // String returns the textual representation of a node.
 func (t *ThrowExpr) String() string {
+	if t.Payload != nil {
+		return fmt.Sprintf("%s: %T{Label: %v, Payload: %v}", t.p, t, t.Label, t.Payload)
+	}
 	return fmt.Sprintf("%s: %T{Label: %v}", t.p, t, t.Label)
 }
 
@@ -516,6 +548,126 @@ func (n *NotExpr) InitialNames() map[string]struct{} {
 	return make(map[string]struct{})
 }
 
+// LookbehindExpr is a zero-length matcher that is considered a match if
+// the input that immediately precedes the current position matches the
+// expression it contains, e.g. (?<= '$' ). The expression must match a
+// fixed number of characters.
+type LookbehindExpr struct {
+	p    Pos
+	Expr Expression
+}
+
+var _ Expression = (*LookbehindExpr)(nil)
+
+// NewLookbehindExpr creates a new lookbehind (?<= ) expression at the
+// specified position.
+func NewLookbehindExpr(p Pos) *LookbehindExpr {
+	return &LookbehindExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (l *LookbehindExpr) Pos() Pos { return l.p }
+
+// String returns the textual representation of a node.
+func (l *LookbehindExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v}", l.p, l, l.Expr)
+}
+
+// NullableVisit recursively determines whether an object is nullable.
+func (l *LookbehindExpr) NullableVisit(rules map[string]*Rule) bool {
+	return true
+}
+
+// IsNullable returns the nullable attribute of the node.
+func (l *LookbehindExpr) IsNullable() bool {
+	return true
+}
+
+// InitialNames returns names of nodes with which an expression can begin.
+func (l *LookbehindExpr) InitialNames() map[string]struct{} {
+	return make(map[string]struct{})
+}
+
+// ScanLimitExpr is an alternative of a choice expression that gives up as
+// soon as it reaches a number of bytes past its starting position, unless
+// that is the end of the input, e.g. @64 'a'* 'b'.
+type ScanLimitExpr struct {
+	p     Pos
+	Expr  Expression
+	Limit int
+}
+
+var _ Expression = (*ScanLimitExpr)(nil)
+
+// NewScanLimitExpr creates a new scan limit expression at the specified
+// position.
+func NewScanLimitExpr(p Pos) *ScanLimitExpr {
+	return &ScanLimitExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (s *ScanLimitExpr) Pos() Pos { return s.p }
+
+// String returns the textual representation of a node.
+func (s *ScanLimitExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v, Limit: %d}", s.p, s, s.Expr, s.Limit)
+}
+
+// NullableVisit recursively determines whether an object is nullable.
+func (s *ScanLimitExpr) NullableVisit(rules map[string]*Rule) bool {
+	return s.Expr.NullableVisit(rules)
+}
+
+// IsNullable returns the nullable attribute of the node.
+func (s *ScanLimitExpr) IsNullable() bool {
+	return s.Expr.IsNullable()
+}
+
+// InitialNames returns names of nodes with which an expression can begin.
+func (s *ScanLimitExpr) InitialNames() map[string]struct{} {
+	return s.Expr.InitialNames()
+}
+
+// UntilExpr is an expression that matches any input up to, but not
+// including, the first match of the terminator expression it contains,
+// e.g. ~'*/'. It is equivalent to ( !Term . )*, and its value is the
+// matched input.
+type UntilExpr struct {
+	p    Pos
+	Expr Expression
+}
+
+var _ Expression = (*UntilExpr)(nil)
+
+// NewUntilExpr creates a new until (~) expression at the specified
+// position.
+func NewUntilExpr(p Pos) *UntilExpr {
+	return &UntilExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (u *UntilExpr) Pos() Pos { return u.p }
+
+// String returns the textual representation of a node.
+func (u *UntilExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v}", u.p, u, u.Expr)
+}
+
+// NullableVisit recursively determines whether an object is nullable.
+func (u *UntilExpr) NullableVisit(rules map[string]*Rule) bool {
+	return true
+}
+
+// IsNullable returns the nullable attribute of the node.
+func (u *UntilExpr) IsNullable() bool {
+	return true
+}
+
+// InitialNames returns names of nodes with which an expression can begin.
+func (u *UntilExpr) InitialNames() map[string]struct{} {
+	return make(map[string]struct{})
+}
+
 // ZeroOrOneExpr is an expression that can be matched zero or one time.
 type ZeroOrOneExpr struct {
 	p    Pos
@@ -631,6 +783,9 @@ func (o *OneOrMoreExpr) InitialNames() map[string]struct{} {
 type RuleRefExpr struct {
 	p    Pos
 	Name *Identifier
+	// Args are the arguments passed at match time to the referenced rule,
+	// e.g. Items(count=n), nil if it has none.
+	Args []*RuleArg
 
 	Nullable bool
 }
@@ -648,6 +803,15 @@ func (r *RuleRefExpr) Pos() Pos { return r.p }
 
 // String returns the textual representation of a node.
 func (r *RuleRefExpr) String() string {
+	if len(r.Args) > 0 {
+		var buf bytes.Buffer
+		buf.WriteString(fmt.Sprintf("%s: %T{Name: %v, Args: [\n", r.p, r, r.Name))
+		for _, arg := range r.Args {
+			buf.WriteString(fmt.Sprintf("%v=%v,\n", arg.Name, arg.Code))
+		}
+		buf.WriteString("]}")
+		return buf.String()
+	}
 	return fmt.Sprintf("%s: %T{Name: %v}", r.p, r, r.Name)
 }
 
@@ -673,6 +837,77 @@ func (r *RuleRefExpr) InitialNames() map[string]struct{} {
 	return map[string]struct{}{r.Name.Val: {}}
 }
 
+// RuleArg is an argument passed at match time by a rule reference to the
+// rule it references, whose code blocks get its value with c.param. The
+// value is the one returned by its code block, which gets the labels in
+// scope of the reference like an action, e.g. { return n, nil } for the
+// argument count=n.
+type RuleArg struct {
+	Name   *Identifier
+	Code   *CodeBlock
+	FuncIx int
+}
+
+// copyRuleArgs returns a copy of args, so that the function indices of
+// the copies are assigned independently of those of args.
+func copyRuleArgs(args []*RuleArg) []*RuleArg {
+	if args == nil {
+		return nil
+	}
+	res := make([]*RuleArg, 0, len(args))
+	for _, arg := range args {
+		cp := *arg
+		res = append(res, &cp)
+	}
+	return res
+}
+
+// RuleCallExpr is an expression that calls a parameterized rule with a
+// list of arguments. It only exists until the rules are instantiated.
+type RuleCallExpr struct {
+	p    Pos
+	Name *Identifier
+	Args []Expression
+}
+
+var _ Expression = (*RuleCallExpr)(nil)
+
+// NewRuleCallExpr creates a new parameterized rule call expression at the
+// specified position.
+func NewRuleCallExpr(p Pos) *RuleCallExpr {
+	return &RuleCallExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (r *RuleCallExpr) Pos() Pos { return r.p }
+
+// String returns the textual representation of a node.
+func (r *RuleCallExpr) String() string {
+	var buf bytes.Buffer
+
+	buf.WriteString(fmt.Sprintf("%s: %T{Name: %v, Args: [\n", r.p, r, r.Name))
+	for _, e := range r.Args {
+		buf.WriteString(fmt.Sprintf("%s,\n", e))
+	}
+	buf.WriteString("]}")
+	return buf.String()
+}
+
+// NullableVisit recursively determines whether an object is nullable.
+func (r *RuleCallExpr) NullableVisit(rules map[string]*Rule) bool {
+	return false
+}
+
+// IsNullable returns the nullable attribute of the node.
+func (r *RuleCallExpr) IsNullable() bool {
+	return false
+}
+
+// InitialNames returns names of nodes with which an expression can begin.
+func (r *RuleCallExpr) InitialNames() map[string]struct{} {
+	return map[string]struct{}{r.Name.Val: {}}
+}
+
 // StateCodeExpr is an expression which can modify the internal state of the parser.
 type StateCodeExpr struct {
 	p      Pos
@@ -789,6 +1024,167 @@ func (n *NotCodeExpr) InitialNames() map[string]struct{} {
 	return make(map[string]struct{})
 }
 
+// BalancedExpr is a matcher of an open delimiter followed by any input up
+// to the matching close delimiter, taking the nested pairs of delimiters
+// into account, e.g. < '{' '}' >. Its value is the input between the
+// outermost delimiters.
+type BalancedExpr struct {
+	p     Pos
+	Open  *LitMatcher
+	Close *LitMatcher
+}
+
+var _ Expression = (*BalancedExpr)(nil)
+
+// NewBalancedExpr creates a new balanced delimiters expression at the
+// specified position.
+func NewBalancedExpr(p Pos) *BalancedExpr {
+	return &BalancedExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (b *BalancedExpr) Pos() Pos { return b.p }
+
+// String returns the textual representation of a node.
+func (b *BalancedExpr) String() string {
+	return fmt.Sprintf("%s: %T{Open: %v, Close: %v}", b.p, b, b.Open, b.Close)
+}
+
+// NullableVisit recursively determines whether an object is nullable.
+func (b *BalancedExpr) NullableVisit(rules map[string]*Rule) bool {
+	return false
+}
+
+// IsNullable returns the nullable attribute of the node.
+func (b *BalancedExpr) IsNullable() bool {
+	return false
+}
+
+// InitialNames returns names of nodes with which an expression can begin.
+func (b *BalancedExpr) InitialNames() map[string]struct{} {
+	return make(map[string]struct{})
+}
+
+// CaseInsensitiveExpr is a region of the grammar where the literal and
+// character class matchers are case-insensitive, as if they all had the
+// "i" flag, e.g. (?i: "select" / "from" ). It does not apply to the rules
+// referenced in the region.
+type CaseInsensitiveExpr struct {
+	p    Pos
+	Expr Expression
+}
+
+var _ Expression = (*CaseInsensitiveExpr)(nil)
+
+// NewCaseInsensitiveExpr creates a new case-insensitive expression at the
+// specified position.
+func NewCaseInsensitiveExpr(p Pos) *CaseInsensitiveExpr {
+	return &CaseInsensitiveExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (c *CaseInsensitiveExpr) Pos() Pos { return c.p }
+
+// String returns the textual representation of a node.
+func (c *CaseInsensitiveExpr) String() string {
+	return fmt.Sprintf("%s: %T{Expr: %v}", c.p, c, c.Expr)
+}
+
+// NullableVisit recursively determines whether an object is nullable.
+func (c *CaseInsensitiveExpr) NullableVisit(rules map[string]*Rule) bool {
+	return c.Expr.NullableVisit(rules)
+}
+
+// IsNullable returns the nullable attribute of the node.
+func (c *CaseInsensitiveExpr) IsNullable() bool {
+	return c.Expr.IsNullable()
+}
+
+// InitialNames returns names of nodes with which an expression can begin.
+func (c *CaseInsensitiveExpr) InitialNames() map[string]struct{} {
+	return c.Expr.InitialNames()
+}
+
+// LineStartExpr is a zero-length matcher that is considered a match if the
+// current position is at the start of the input or immediately after a
+// newline character.
+type LineStartExpr struct {
+	p Pos
+}
+
+var _ Expression = (*LineStartExpr)(nil)
+
+// NewLineStartExpr creates a new line start (^) expression at the specified
+// position.
+func NewLineStartExpr(p Pos) *LineStartExpr {
+	return &LineStartExpr{p: p}
+}
+
+// Pos returns the starting position of the node.
+func (l *LineStartExpr) Pos() Pos { return l.p }
+
+// String returns the textual representation of a node.
+func (l *LineStartExpr) String() string {
+	return fmt.Sprintf("%s: %T{}", l.p, l)
+}
+
+// NullableVisit recursively determines whether an object is nullable.
+func (l *LineStartExpr) NullableVisit(rules map[string]*Rule) bool {
+	return true
+}
+
+// IsNullable returns the nullable attribute of the node.
+func (l *LineStartExpr) IsNullable() bool {
+	return true
+}
+
+// InitialNames returns names of nodes with which an expression can begin.
+func (l *LineStartExpr) InitialNames() map[string]struct{} {
+	return make(map[string]struct{})
+}
+
+// AssertionExpr is a zero-length matcher for a builtin assertion on the
+// characters around the current position. Name is the assertion, `\b` for
+// a word boundary, between a word character (an ASCII letter, digit or
+// underscore) and another character or the start or end of the input, or
+// "$" for a line end, at the end of the input or right before a newline
+// character.
+type AssertionExpr struct {
+	p    Pos
+	Name string
+}
+
+var _ Expression = (*AssertionExpr)(nil)
+
+// NewAssertionExpr creates a new assertion (\b or $) expression at the
+// specified position.
+func NewAssertionExpr(p Pos, name string) *AssertionExpr {
+	return &AssertionExpr{p: p, Name: name}
+}
+
+// Pos returns the starting position of the node.
+func (a *AssertionExpr) Pos() Pos { return a.p }
+
+// String returns the textual representation of a node.
+func (a *AssertionExpr) String() string {
+	return fmt.Sprintf("%s: %T{Name: %q}", a.p, a, a.Name)
+}
+
+// NullableVisit recursively determines whether an object is nullable.
+func (a *AssertionExpr) NullableVisit(rules map[string]*Rule) bool {
+	return true
+}
+
+// IsNullable returns the nullable attribute of the node.
+func (a *AssertionExpr) IsNullable() bool {
+	return true
+}
+
+// InitialNames returns names of nodes with which an expression can begin.
+func (a *AssertionExpr) InitialNames() map[string]struct{} {
+	return make(map[string]struct{})
+}
+
 // LitMatcher is a string literal matcher. The value to match may be a
 // double-quoted string, a single-quoted single character, or a back-tick
 // quoted raw string.
@@ -0,0 +1,97 @@
+package ast
+
+import "fmt"
+
+// ExtendGrammar merges the rules of the base grammar into the grammar g,
+// so that a family of grammars can share a base grammar and override only
+// some of its rules. The rules of g replace the rules of base with the
+// same name, in place, and the other rules of g follow the rules of base.
+// The entrypoint is thus the first rule of base, unless it is replaced.
+// The initializer of g replaces the one of base if it has one. The rules
+// of base are copied, base is not modified and can be used to extend
+// other grammars.
+//
+// It returns an error if the merged grammar has no rule, if its
+// entrypoint is a parameterized rule or a macro, or if a rule references
+// a rule that is not defined.
+func ExtendGrammar(g, base *Grammar) error {
+	overrides := make(map[string]*Rule, len(g.Rules))
+	for _, r := range g.Rules {
+		overrides[r.Name.Val] = r
+	}
+
+	// copy the expressions of the base rules, the builder modifies them
+	me := &macroExpander{}
+	rules := make([]*Rule, 0, len(base.Rules)+len(g.Rules))
+	inBase := make(map[string]bool, len(base.Rules))
+	for _, r := range base.Rules {
+		inBase[r.Name.Val] = true
+		if o, ok := overrides[r.Name.Val]; ok {
+			rules = append(rules, o)
+			continue
+		}
+		expr, err := me.expand(r.Expr)
+		if err != nil {
+			return err
+		}
+		cp := *r
+		cp.Expr = expr
+		rules = append(rules, &cp)
+	}
+	for _, r := range g.Rules {
+		if !inBase[r.Name.Val] {
+			rules = append(rules, r)
+		}
+	}
+
+	if len(rules) == 0 {
+		return fmt.Errorf("%s: extended grammar has no rule", g.Pos())
+	}
+	if entry := rules[0]; len(entry.Params) > 0 || entry.Macro {
+		return fmt.Errorf("%s: entrypoint rule %s of the extended grammar cannot be a parameterized rule or a macro",
+			entry.Pos(), entry.Name.Val)
+	}
+	if err := checkRuleRefs(rules); err != nil {
+		return err
+	}
+
+	g.Rules = rules
+	if g.Init == nil {
+		g.Init = base.Init
+	}
+	return nil
+}
+
+// checkRuleRefs returns an error if a rule references a rule that is not
+// in rules, nor a parameter of the referencing rule.
+func checkRuleRefs(rules []*Rule) error {
+	names := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		names[r.Name.Val] = true
+	}
+
+	var err error
+	for _, r := range rules {
+		params := make(map[string]bool, len(r.Params))
+		for _, p := range r.Params {
+			params[p.Val] = true
+		}
+		Inspect(r.Expr, func(expr Expression) bool {
+			var name *Identifier
+			switch expr := expr.(type) {
+			case *RuleRefExpr:
+				name = expr.Name
+			case *RuleCallExpr:
+				name = expr.Name
+			}
+			if name != nil && err == nil && !names[name.Val] && !params[name.Val] {
+				err = fmt.Errorf("%s: rule %s references undefined rule %s", name.Pos(), r.Name.Val, name.Val)
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtendGrammar(t *testing.T) {
+	base := NewGrammar(Pos{})
+	base.Init = NewCodeBlock(Pos{}, "{ package base }")
+	base.Rules = []*Rule{
+		rule("A", seq(ref("B"), ref("C"))),
+		rule("B", lit("b")),
+		rule("C", callRule("List", ref("B"))),
+		rule("List", seq(ref("E"), lit(",")), "E"),
+	}
+
+	g := NewGrammar(Pos{})
+	g.Rules = []*Rule{
+		rule("D", lit("d")),
+		rule("B", ref("D")),
+	}
+	if err := ExtendGrammar(g, base); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, r := range g.Rules {
+		names = append(names, r.Name.Val)
+	}
+	if got, want := strings.Join(names, " "), "A B C List D"; got != want {
+		t.Errorf("want rules %s, got %s", want, got)
+	}
+	if g.Rules[1].Expr.(*RuleRefExpr).Name.Val != "D" {
+		t.Errorf("want rule B overridden, got %s", g.Rules[1].Expr)
+	}
+	if g.Init != base.Init {
+		t.Errorf("want the initializer of the base grammar, got %v", g.Init)
+	}
+
+	// the rules of the base grammar are copies
+	if g.Rules[0] == base.Rules[0] || g.Rules[0].Expr == base.Rules[0].Expr {
+		t.Error("want a copy of rule A")
+	}
+	if base.Rules[1].Expr.(*LitMatcher).Val != "b" {
+		t.Errorf("want rule B of the base grammar unchanged, got %s", base.Rules[1].Expr)
+	}
+
+	init := NewCodeBlock(Pos{}, "{ package derived }")
+	g = NewGrammar(Pos{})
+	g.Init = init
+	if err := ExtendGrammar(g, base); err != nil {
+		t.Fatal(err)
+	}
+	if g.Init != init || len(g.Rules) != len(base.Rules) {
+		t.Errorf("want the initializer of the grammar and the rules of the base grammar, got %v and %d rules", g.Init, len(g.Rules))
+	}
+}
+
+func TestExtendGrammarErrors(t *testing.T) {
+	cases := []struct {
+		base, rules []*Rule
+		err         string
+	}{
+		{nil, nil, "extended grammar has no rule"},
+		{[]*Rule{rule("List", ref("E"), "E")}, []*Rule{rule("A", callRule("List", lit("a")))}, "entrypoint rule List of the extended grammar cannot be a parameterized rule"},
+		{[]*Rule{rule("A", ref("B")), rule("B", lit("b"))}, []*Rule{rule("B", ref("X"))}, "rule B references undefined rule X"},
+		{[]*Rule{rule("A", callRule("List", lit("a")))}, []*Rule{rule("B", lit("b"))}, "rule A references undefined rule List"},
+	}
+
+	for i, tc := range cases {
+		base := NewGrammar(Pos{})
+		base.Rules = tc.base
+		g := NewGrammar(Pos{})
+		g.Rules = tc.rules
+		err := ExtendGrammar(g, base)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: want error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ruleInstantiator replaces the calls of parameterized rules with
+// references to concrete rules, one per distinct set of arguments.
+type ruleInstantiator struct {
+	templates map[string]*Rule
+	names     map[string]struct{}
+	instances map[string]string
+	counts    map[string]int
+	// instances, in order of creation
+	pending []*Rule
+}
+
+// InstantiateRules removes the parameterized rules of the grammar and
+// replaces each call of such a rule, e.g. List(Elem, ','), with a reference
+// to a concrete rule generated for this set of arguments. The arguments
+// must be rule references or literals, and they are substituted for the
+// references to the parameters in the body of the rule. Calls with the
+// same arguments share the same concrete rule, which is appended to the
+// grammar and named after the parameterized rule followed by an
+// instance number (e.g. List_1). It is a no-op if the grammar has no
+// parameterized rule or call.
+//
+// A call with a single argument of a rule that has no parameters keeps
+// the meaning it had before the parameterized rules, a reference followed
+// by a parenthesized expression, e.g. B(C) is B (C): the label and prefix
+// operator of the call apply to B, its suffix operator to C.
+func InstantiateRules(g *Grammar) error {
+	ri := &ruleInstantiator{
+		templates: make(map[string]*Rule),
+		names:     make(map[string]struct{}, len(g.Rules)),
+		instances: make(map[string]string),
+		counts:    make(map[string]int),
+	}
+
+	hasCall := false
+	Inspect(g, func(expr Expression) bool {
+		if _, ok := expr.(*RuleCallExpr); ok {
+			hasCall = true
+		}
+		return !hasCall
+	})
+
+	rules := make([]*Rule, 0, len(g.Rules))
+	for _, r := range g.Rules {
+		ri.names[r.Name.Val] = struct{}{}
+		if len(r.Params) > 0 {
+			ri.templates[r.Name.Val] = r
+			continue
+		}
+		rules = append(rules, r)
+	}
+	if !hasCall && len(ri.templates) == 0 {
+		return nil
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("%s: grammar has only parameterized rules", g.Pos())
+	}
+
+	for _, r := range rules {
+		expr, err := ri.instantiate(r.Expr, nil)
+		if err != nil {
+			return err
+		}
+		r.Expr = expr
+	}
+	g.Rules = append(rules, ri.pending...)
+	return nil
+}
+
+// instantiate returns a copy of expr where the references to parameters
+// are replaced by their argument in args, and the calls of parameterized
+// rules are replaced by references to their instance.
+func (ri *ruleInstantiator) instantiate(expr Expression, args map[string]Expression) (Expression, error) {
+	if head, tail, ok := ri.splitGroup(expr); ok {
+		seq := NewSeqExpr(expr.Pos())
+		seq.Exprs = []Expression{head, tail}
+		return ri.instantiate(seq, args)
+	}
+
+	var err error
+	switch expr := expr.(type) {
+	case *ActionExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *AndExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *CaseInsensitiveExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *ChoiceExpr:
+		e := *expr
+		e.Alternatives, err = ri.instantiateList(expr.Alternatives, args)
+		return &e, err
+	case *LabeledExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *LookbehindExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *NotExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *OneOrMoreExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *RecoveryExpr:
+		e := *expr
+		if e.Expr, err = ri.instantiate(expr.Expr, args); err != nil {
+			return nil, err
+		}
+		e.RecoverExpr, err = ri.instantiate(expr.RecoverExpr, args)
+		return &e, err
+	case *RuleCallExpr:
+		return ri.call(expr, args)
+	case *RuleRefExpr:
+		if arg, ok := args[expr.Name.Val]; ok {
+			if len(expr.Args) > 0 {
+				return nil, fmt.Errorf("%s: parameter %s referenced with match-time arguments", expr.Pos(), expr.Name.Val)
+			}
+			return ri.instantiate(arg, nil)
+		}
+		if _, ok := ri.templates[expr.Name.Val]; ok {
+			return nil, fmt.Errorf("%s: parameterized rule %s referenced without arguments", expr.Pos(), expr.Name.Val)
+		}
+		e := *expr
+		e.Args = copyRuleArgs(expr.Args)
+		return &e, nil
+	case *ScanLimitExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *SeqExpr:
+		e := *expr
+		exprs := make([]Expression, 0, len(expr.Exprs))
+		for _, sub := range expr.Exprs {
+			if head, tail, ok := ri.splitGroup(sub); ok {
+				exprs = append(exprs, head, tail)
+				continue
+			}
+			exprs = append(exprs, sub)
+		}
+		e.Exprs, err = ri.instantiateList(exprs, args)
+		return &e, err
+	case *UntilExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *ZeroOrMoreExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *ZeroOrOneExpr:
+		e := *expr
+		e.Expr, err = ri.instantiate(expr.Expr, args)
+		return &e, err
+	case *AndCodeExpr:
+		e := *expr
+		return &e, nil
+	case *NotCodeExpr:
+		e := *expr
+		return &e, nil
+	case *StateCodeExpr:
+		e := *expr
+		return &e, nil
+	case *ThrowExpr:
+		e := *expr
+		return &e, nil
+	}
+	// matchers have no child and are never modified
+	return expr, nil
+}
+
+func (ri *ruleInstantiator) instantiateList(exprs []Expression, args map[string]Expression) ([]Expression, error) {
+	res := make([]Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		e, err := ri.instantiate(expr, args)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+// call returns the reference to the instance of the parameterized rule
+// called by call, creating the instance if needed.
+func (ri *ruleInstantiator) call(call *RuleCallExpr, args map[string]Expression) (Expression, error) {
+	tpl, ok := ri.templates[call.Name.Val]
+	if !ok {
+		return nil, fmt.Errorf("%s: rule %s is not a parameterized rule", call.Pos(), call.Name.Val)
+	}
+	if len(call.Args) != len(tpl.Params) {
+		return nil, fmt.Errorf("%s: rule %s expects %d arguments, got %d",
+			call.Pos(), call.Name.Val, len(tpl.Params), len(call.Args))
+	}
+
+	// resolve the arguments in the scope of the caller
+	callArgs := make([]Expression, 0, len(call.Args))
+	sig := make([]string, 0, len(call.Args))
+	for _, arg := range call.Args {
+		if ref, ok := arg.(*RuleRefExpr); ok {
+			if a, ok := args[ref.Name.Val]; ok {
+				arg = a
+			}
+		}
+		switch arg := arg.(type) {
+		case *RuleRefExpr:
+			if _, ok := ri.templates[arg.Name.Val]; ok {
+				return nil, fmt.Errorf("%s: parameterized rule %s used as argument", arg.Pos(), arg.Name.Val)
+			}
+			sig = append(sig, arg.Name.Val)
+		case *LitMatcher:
+			s := strconv.Quote(arg.Val)
+			if arg.IgnoreCase {
+				s += "i"
+			}
+			sig = append(sig, s)
+		default:
+			return nil, fmt.Errorf("%s: argument of rule %s must be a rule reference or a literal", arg.Pos(), call.Name.Val)
+		}
+		callArgs = append(callArgs, arg)
+	}
+
+	key := tpl.Name.Val + "(" + strings.Join(sig, ", ") + ")"
+	name, ok := ri.instances[key]
+	if !ok {
+		name = ri.newName(tpl.Name.Val)
+		ri.instances[key] = name
+
+		bindings := make(map[string]Expression, len(tpl.Params))
+		for i, p := range tpl.Params {
+			bindings[p.Val] = callArgs[i]
+		}
+		r := NewRule(tpl.Pos(), NewIdentifier(tpl.Name.Pos(), name))
+		r.DisplayName = tpl.DisplayName
+		r.Doc = tpl.Doc
+		r.EndLine = tpl.EndLine
+		if r.DisplayName == nil {
+			display := "`" + key + "`"
+			if strings.Contains(key, "`") {
+				display = strconv.Quote(key)
+			}
+			r.DisplayName = NewStringLit(tpl.Pos(), display)
+		}
+		ri.pending = append(ri.pending, r)
+
+		expr, err := ri.instantiate(tpl.Expr, bindings)
+		if err != nil {
+			return nil, err
+		}
+		r.Expr = expr
+	}
+
+	ref := NewRuleRefExpr(call.Pos())
+	ref.Name = NewIdentifier(call.Name.Pos(), name)
+	return ref, nil
+}
+
+// splitGroup returns the reference and the parenthesized expression that
+// expr, an element of a sequence, stands for if it is the call of a rule
+// that has no parameters with a single argument, possibly labeled and
+// with a prefix or suffix operator. The label and prefix operator apply
+// to the reference, the suffix operator to the parenthesized expression.
+func (ri *ruleInstantiator) splitGroup(expr Expression) (head, tail Expression, ok bool) {
+	switch e := expr.(type) {
+	case *LabeledExpr:
+		if head, tail, ok = ri.splitGroup(e.Expr); ok {
+			lab := *e
+			lab.Expr = head
+			return &lab, tail, true
+		}
+	case *AndExpr:
+		if head, tail, ok = ri.splitGroup(e.Expr); ok {
+			and := *e
+			and.Expr = head
+			return &and, tail, true
+		}
+	case *NotExpr:
+		if head, tail, ok = ri.splitGroup(e.Expr); ok {
+			not := *e
+			not.Expr = head
+			return &not, tail, true
+		}
+	case *UntilExpr:
+		if head, tail, ok = ri.splitGroup(e.Expr); ok {
+			until := *e
+			until.Expr = head
+			return &until, tail, true
+		}
+	case *ZeroOrOneExpr:
+		if head, tail, ok = ri.splitGroup(e.Expr); ok {
+			opt := *e
+			opt.Expr = tail
+			return head, &opt, true
+		}
+	case *ZeroOrMoreExpr:
+		if head, tail, ok = ri.splitGroup(e.Expr); ok {
+			rep := *e
+			rep.Expr = tail
+			return head, &rep, true
+		}
+	case *OneOrMoreExpr:
+		if head, tail, ok = ri.splitGroup(e.Expr); ok {
+			rep := *e
+			rep.Expr = tail
+			return head, &rep, true
+		}
+	case *RuleCallExpr:
+		if _, isTpl := ri.templates[e.Name.Val]; isTpl || len(e.Args) != 1 {
+			return nil, nil, false
+		}
+		ref := NewRuleRefExpr(e.Pos())
+		ref.Name = e.Name
+		return ref, e.Args[0], true
+	}
+	return nil, nil, false
+}
+
+// newName returns a rule name for a new instance of the rule tpl that
+// does not conflict with the name of an existing rule.
+func (ri *ruleInstantiator) newName(tpl string) string {
+	for {
+		ri.counts[tpl]++
+		name := tpl + "_" + strconv.Itoa(ri.counts[tpl])
+		if _, ok := ri.names[name]; !ok {
+			ri.names[name] = struct{}{}
+			return name
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func ref(name string) *RuleRefExpr {
+	r := NewRuleRefExpr(Pos{})
+	r.Name = NewIdentifier(Pos{}, name)
+	return r
+}
+
+func lit(val string) *LitMatcher {
+	return NewLitMatcher(Pos{}, val)
+}
+
+func callRule(name string, args ...Expression) *RuleCallExpr {
+	c := NewRuleCallExpr(Pos{})
+	c.Name = NewIdentifier(Pos{}, name)
+	c.Args = args
+	return c
+}
+
+func rule(name string, expr Expression, params ...string) *Rule {
+	r := NewRule(Pos{}, NewIdentifier(Pos{}, name))
+	r.Expr = expr
+	for _, p := range params {
+		r.Params = append(r.Params, NewIdentifier(Pos{}, p))
+	}
+	return r
+}
+
+func seq(exprs ...Expression) *SeqExpr {
+	s := NewSeqExpr(Pos{})
+	s.Exprs = exprs
+	return s
+}
+
+func TestInstantiateRules(t *testing.T) {
+	g := NewGrammar(Pos{})
+	g.Rules = []*Rule{
+		rule("List", seq(ref("E"), ref("S"), ref("E")), "E", "S"),
+		rule("A", seq(callRule("List", ref("X"), lit(",")), callRule("List", ref("X"), lit(",")))),
+		rule("B", callRule("List", ref("Y"), lit(";"))),
+		rule("List_1", lit("x")),
+	}
+	if err := InstantiateRules(g); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, r := range g.Rules {
+		names = append(names, r.Name.Val)
+	}
+	if got, want := strings.Join(names, " "), "A B List_1 List_2 List_3"; got != want {
+		t.Fatalf("want rules %q, got %q", want, got)
+	}
+
+	a := g.Rules[0].Expr.(*SeqExpr)
+	for _, e := range a.Exprs {
+		if got := e.(*RuleRefExpr).Name.Val; got != "List_2" {
+			t.Errorf("want reference to List_2, got %s", got)
+		}
+	}
+	if got := g.Rules[1].Expr.(*RuleRefExpr).Name.Val; got != "List_3" {
+		t.Errorf("want reference to List_3, got %s", got)
+	}
+
+	inst := g.Rules[3]
+	if got, want := inst.DisplayName.Val, "`List(X, \",\")`"; got != want {
+		t.Errorf("want display name %q, got %q", want, got)
+	}
+	body := inst.Expr.(*SeqExpr)
+	if got := body.Exprs[0].(*RuleRefExpr).Name.Val; got != "X" {
+		t.Errorf("want first element X, got %s", got)
+	}
+	if got := body.Exprs[1].(*LitMatcher).Val; got != "," {
+		t.Errorf("want separator %q, got %q", ",", got)
+	}
+}
+
+func TestInstantiateRulesErrors(t *testing.T) {
+	tpl := rule("List", seq(ref("E"), ref("S")), "E", "S")
+	cases := []struct {
+		rules []*Rule
+		err   string
+	}{
+		{[]*Rule{tpl}, "grammar has only parameterized rules"},
+		{[]*Rule{tpl, rule("A", callRule("B", ref("X"), ref("Y"))), rule("B", lit("b"))}, "rule B is not a parameterized rule"},
+		{[]*Rule{tpl, rule("A", callRule("List", ref("X")))}, "rule List expects 2 arguments, got 1"},
+		{[]*Rule{tpl, rule("A", ref("List"))}, "parameterized rule List referenced without arguments"},
+		{[]*Rule{tpl, rule("A", callRule("List", ref("List"), lit(",")))}, "parameterized rule List used as argument"},
+		{[]*Rule{tpl, rule("A", callRule("List", seq(ref("X")), lit(",")))}, "argument of rule List must be a rule reference or a literal"},
+	}
+
+	for i, tc := range cases {
+		g := NewGrammar(Pos{})
+		g.Rules = tc.rules
+		err := InstantiateRules(g)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: want error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package ast
+
+import "fmt"
+
+// macroExpander replaces the references to macros with a copy of their
+// expression.
+type macroExpander struct {
+	macros map[string]*Rule
+	// macros being expanded, to detect recursion
+	expanding map[string]bool
+}
+
+// ExpandMacros removes the macros of the grammar and replaces each
+// reference to a macro with a copy of its expression, so that it is
+// inlined in the referencing rule instead of being generated as a rule of
+// its own. A macro can reference rules and other macros, but a macro that
+// references itself, directly or through other macros, cannot be inlined
+// and is an error. It is a no-op if the grammar has no macro.
+func ExpandMacros(g *Grammar) error {
+	me := &macroExpander{
+		macros:    make(map[string]*Rule),
+		expanding: make(map[string]bool),
+	}
+
+	rules := make([]*Rule, 0, len(g.Rules))
+	for _, r := range g.Rules {
+		if r.Macro {
+			me.macros[r.Name.Val] = r
+			continue
+		}
+		rules = append(rules, r)
+	}
+	if len(me.macros) == 0 {
+		return nil
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("%s: grammar has only macros", g.Pos())
+	}
+
+	// expand the macros first, in order, so that recursive macros are
+	// reported even if they are not used.
+	for _, r := range g.Rules {
+		if r.Macro {
+			if _, err := me.expandMacro(r, r.Pos()); err != nil {
+				return err
+			}
+		}
+	}
+	for _, r := range rules {
+		expr, err := me.expand(r.Expr)
+		if err != nil {
+			return err
+		}
+		r.Expr = expr
+	}
+	g.Rules = rules
+	return nil
+}
+
+// expandMacro returns a copy of the expression of the macro m, referenced
+// at position p.
+func (me *macroExpander) expandMacro(m *Rule, p Pos) (Expression, error) {
+	if me.expanding[m.Name.Val] {
+		return nil, fmt.Errorf("%s: macro %s is recursive and cannot be inlined", p, m.Name.Val)
+	}
+	me.expanding[m.Name.Val] = true
+	defer delete(me.expanding, m.Name.Val)
+	return me.expand(m.Expr)
+}
+
+// expand returns a copy of expr where the references to macros are
+// replaced by a copy of the expression of the macro.
+func (me *macroExpander) expand(expr Expression) (Expression, error) {
+	var err error
+	switch expr := expr.(type) {
+	case *ActionExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *AndExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *CaseInsensitiveExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *ChoiceExpr:
+		e := *expr
+		e.Alternatives, err = me.expandList(expr.Alternatives)
+		return &e, err
+	case *LabeledExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *LookbehindExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *NotExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *OneOrMoreExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *RecoveryExpr:
+		e := *expr
+		if e.Expr, err = me.expand(expr.Expr); err != nil {
+			return nil, err
+		}
+		e.RecoverExpr, err = me.expand(expr.RecoverExpr)
+		return &e, err
+	case *RuleCallExpr:
+		e := *expr
+		e.Args, err = me.expandList(expr.Args)
+		return &e, err
+	case *RuleRefExpr:
+		if m, ok := me.macros[expr.Name.Val]; ok {
+			if len(expr.Args) > 0 {
+				return nil, fmt.Errorf("%s: macro %s referenced with match-time arguments", expr.Pos(), expr.Name.Val)
+			}
+			return me.expandMacro(m, expr.Pos())
+		}
+		e := *expr
+		e.Args = copyRuleArgs(expr.Args)
+		return &e, nil
+	case *ScanLimitExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *SeqExpr:
+		e := *expr
+		e.Exprs, err = me.expandList(expr.Exprs)
+		return &e, err
+	case *UntilExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *ZeroOrMoreExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *ZeroOrOneExpr:
+		e := *expr
+		e.Expr, err = me.expand(expr.Expr)
+		return &e, err
+	case *AndCodeExpr:
+		e := *expr
+		return &e, nil
+	case *NotCodeExpr:
+		e := *expr
+		return &e, nil
+	case *StateCodeExpr:
+		e := *expr
+		return &e, nil
+	case *ThrowExpr:
+		e := *expr
+		return &e, nil
+	}
+	// matchers have no child and are never modified
+	return expr, nil
+}
+
+func (me *macroExpander) expandList(exprs []Expression) ([]Expression, error) {
+	res := make([]Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		e, err := me.expand(expr)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func macro(name string, expr Expression) *Rule {
+	r := rule(name, expr)
+	r.Macro = true
+	return r
+}
+
+func TestExpandMacros(t *testing.T) {
+	g := NewGrammar(Pos{})
+	g.Rules = []*Rule{
+		rule("A", seq(ref("M"), ref("B"))),
+		macro("M", seq(lit("m"), ref("N"))),
+		rule("B", ref("M")),
+		macro("N", ref("B")),
+	}
+	if err := ExpandMacros(g); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, r := range g.Rules {
+		names = append(names, r.Name.Val)
+	}
+	if got, want := strings.Join(names, " "), "A B"; got != want {
+		t.Fatalf("want rules %q, got %q", want, got)
+	}
+
+	a := g.Rules[0].Expr.(*SeqExpr)
+	m := a.Exprs[0].(*SeqExpr)
+	if got := m.Exprs[0].(*LitMatcher).Val; got != "m" {
+		t.Errorf("want inlined literal %q, got %q", "m", got)
+	}
+	if got := m.Exprs[1].(*RuleRefExpr).Name.Val; got != "B" {
+		t.Errorf("want inlined reference to B, got %s", got)
+	}
+	if got := a.Exprs[1].(*RuleRefExpr).Name.Val; got != "B" {
+		t.Errorf("want reference to B, got %s", got)
+	}
+
+	// each reference gets its own copy of the expression of the macro
+	b := g.Rules[1].Expr.(*SeqExpr)
+	if b == m {
+		t.Error("want distinct copies of the macro expression")
+	}
+	if got := b.Exprs[1].(*RuleRefExpr).Name.Val; got != "B" {
+		t.Errorf("want inlined reference to B, got %s", got)
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	cases := []struct {
+		rules []*Rule
+		err   string
+	}{
+		{[]*Rule{macro("M", lit("m"))}, "grammar has only macros"},
+		{[]*Rule{rule("A", ref("M")), macro("M", seq(lit("m"), ref("M")))}, "macro M is recursive"},
+		{[]*Rule{rule("A", lit("a")), macro("M", ref("N")), macro("N", ref("M"))}, "macro M is recursive"},
+	}
+
+	for i, tc := range cases {
+		g := NewGrammar(Pos{})
+		g.Rules = tc.rules
+		err := ExpandMacros(g)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: want error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
@@ -52,10 +52,30 @@ func (r *grammarOptimizer) init(expr Expression) Visitor {
 		// Fill ruleUsesRules and ruleUsedByRules for every RuleRefExpr
 		set(r.ruleUsesRules, r.rule, expr.Name.Val)
 		set(r.ruleUsedByRules, expr.Name.Val, r.rule)
+		if len(expr.Args) > 0 {
+			// The references with arguments are never inlined, the
+			// arguments are passed to the referenced rule when it is called.
+			r.protectedRules[expr.Name.Val] = struct{}{}
+		}
+	case *CaseInsensitiveExpr:
+		// The references in a case-insensitive region are never inlined,
+		// so the rules they reference must be kept.
+		Walk(ruleRefProtector(r.protectedRules), expr.Expr)
 	}
 	return r
 }
 
+// ruleRefProtector is a Visitor that adds the rules referenced by the
+// expressions it visits to the set of protected rules.
+type ruleRefProtector map[string]struct{}
+
+func (p ruleRefProtector) Visit(expr Expression) Visitor {
+	if ref, ok := expr.(*RuleRefExpr); ok {
+		p[ref.Name.Val] = struct{}{}
+	}
+	return p
+}
+
 // Add element to map of maps, initialize the inner map
 // if necessary.
 func set(m map[string]map[string]struct{}, src, dst string) {
@@ -74,6 +94,10 @@ func (r *grammarOptimizer) optimize(expr0 Expression) Visitor {
 		expr.Expr = r.optimizeRule(expr.Expr)
 	case *AndExpr:
 		expr.Expr = r.optimizeRule(expr.Expr)
+	case *CaseInsensitiveExpr:
+		// The case-insensitive region is applied by the builder, do not
+		// optimize it so that no rule gets inlined in it.
+		return nil
 	case *ChoiceExpr:
 		expr.Alternatives = r.optimizeRules(expr.Alternatives)
 
@@ -173,6 +197,8 @@ func (r *grammarOptimizer) optimize(expr0 Expression) Visitor {
 		}
 	case *LabeledExpr:
 		expr.Expr = r.optimizeRule(expr.Expr)
+	case *LookbehindExpr:
+		expr.Expr = r.optimizeRule(expr.Expr)
 	case *NotExpr:
 		expr.Expr = r.optimizeRule(expr.Expr)
 	case *OneOrMoreExpr:
@@ -180,6 +206,8 @@ func (r *grammarOptimizer) optimize(expr0 Expression) Visitor {
 	case *Rule:
 		r.rule = expr.Name.Val
 		expr.Expr = r.optimizeRule(expr.Expr)
+	case *ScanLimitExpr:
+		expr.Expr = r.optimizeRule(expr.Expr)
 	case *SeqExpr:
 		expr.Exprs = r.optimizeRules(expr.Exprs)
 
@@ -211,6 +239,8 @@ func (r *grammarOptimizer) optimize(expr0 Expression) Visitor {
 			}
 		}
 
+	case *UntilExpr:
+		expr.Expr = r.optimizeRule(expr.Expr)
 	case *ZeroOrMoreExpr:
 		expr.Expr = r.optimizeRule(expr.Expr)
 	case *ZeroOrOneExpr:
@@ -228,7 +258,7 @@ func (r *grammarOptimizer) optimizeRules(exprs []Expression) []Expression {
 
 func (r *grammarOptimizer) optimizeRule(expr Expression) Expression {
 	// Optimize RuleRefExpr
-	if ruleRef, ok := expr.(*RuleRefExpr); ok {
+	if ruleRef, ok := expr.(*RuleRefExpr); ok && len(ruleRef.Args) == 0 {
 		if _, ok := r.ruleUsesRules[ruleRef.Name.Val]; !ok {
 			r.optimized = true
 			delete(r.ruleUsedByRules[ruleRef.Name.Val], r.rule)
@@ -286,6 +316,11 @@ func cloneExpr(expr Expression) Expression {
 			FuncIx: expr.FuncIx,
 			p:      expr.p,
 		}
+	case *CaseInsensitiveExpr:
+		return &CaseInsensitiveExpr{
+			Expr: cloneExpr(expr.Expr),
+			p:    expr.p,
+		}
 	case *CharClassMatcher:
 		return &CharClassMatcher{
 			Chars:          append([]rune{}, expr.Chars...),
@@ -310,6 +345,11 @@ func cloneExpr(expr Expression) Expression {
 			Label: expr.Label,
 			p:     expr.p,
 		}
+	case *LookbehindExpr:
+		return &LookbehindExpr{
+			Expr: cloneExpr(expr.Expr),
+			p:    expr.p,
+		}
 	case *NotExpr:
 		return &NotExpr{
 			Expr: cloneExpr(expr.Expr),
@@ -326,6 +366,12 @@ func cloneExpr(expr Expression) Expression {
 			Expr: cloneExpr(expr.Expr),
 			p:    expr.p,
 		}
+	case *ScanLimitExpr:
+		return &ScanLimitExpr{
+			Expr:  cloneExpr(expr.Expr),
+			Limit: expr.Limit,
+			p:     expr.p,
+		}
 	case *SeqExpr:
 		exprs := make([]Expression, 0, len(expr.Exprs))
 		for i := 0; i < len(expr.Exprs); i++ {
@@ -341,6 +387,18 @@ func cloneExpr(expr Expression) Expression {
 			Code:   expr.Code,
 			FuncIx: expr.FuncIx,
 		}
+	case *ThrowExpr:
+		return &ThrowExpr{
+			p:       expr.p,
+			Label:   expr.Label,
+			Payload: expr.Payload,
+			FuncIx:  expr.FuncIx,
+		}
+	case *UntilExpr:
+		return &UntilExpr{
+			Expr: cloneExpr(expr.Expr),
+			p:    expr.p,
+		}
 	case *ZeroOrMoreExpr:
 		return &ZeroOrMoreExpr{
 			Expr: cloneExpr(expr.Expr),
@@ -449,6 +507,8 @@ func escapeRune(r rune) string {
 //   - resolve nested sequences expression
 //   - resolve sequence expressions with only one element
 //   - combine character class matcher and literal matcher, where possible
+//
+// The expressions in case-insensitive regions are not optimized.
 func Optimize(g *Grammar, alternateEntrypoints ...string) {
 	entrypoints := alternateEntrypoints
 	if len(g.Rules) > 0 {
@@ -360,3 +360,31 @@ func TestOptimize(t *testing.T) {
 		}
 	}
 }
+
+func TestOptimizeCaseInsensitive(t *testing.T) {
+	ci := NewCaseInsensitiveExpr(Pos{})
+	ci.Expr = seq(ref("C"), lit("d"))
+	g := NewGrammar(Pos{})
+	g.Rules = []*Rule{
+		rule("A", seq(ref("B"), ci)),
+		rule("B", lit("b")),
+		rule("C", lit("c")),
+	}
+	Optimize(g)
+
+	var names []string
+	for _, r := range g.Rules {
+		names = append(names, r.Name.Val)
+	}
+	if !reflect.DeepEqual(names, []string{"A", "C"}) {
+		t.Fatalf("want rules [A C], got %v", names)
+	}
+	exprs := g.Rules[0].Expr.(*SeqExpr).Exprs
+	if got := exprs[0].(*LitMatcher).Val; got != "b" {
+		t.Errorf("want B inlined, got %q", got)
+	}
+	region := exprs[1].(*CaseInsensitiveExpr).Expr.(*SeqExpr)
+	if got := region.Exprs[0].(*RuleRefExpr).Name.Val; got != "C" {
+		t.Errorf("want reference to C in the region, got %s", got)
+	}
+}
@@ -0,0 +1,695 @@
+package ast
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// serializeMagic starts the binary encoding of a grammar, it is followed by
+// the version of the encoding.
+const (
+	serializeMagic   = "PEGG"
+	serializeVersion = 1
+)
+
+// maxSerializedValue is the maximum value of an integer in the binary
+// encoding of a grammar, e.g. the length of a string, to fail early on
+// corrupted data.
+const maxSerializedValue = 1 << 30
+
+// The tags of the nodes in the binary encoding of a grammar. The values
+// are part of the encoding, new tags must be added at the end.
+const (
+	tagNil = iota
+	tagAction
+	tagAnd
+	tagAndCode
+	tagAny
+	tagBalanced
+	tagCaseInsensitive
+	tagCharClass
+	tagChoice
+	tagLabeled
+	tagLineStart
+	tagLit
+	tagLookbehind
+	tagNot
+	tagNotCode
+	tagOneOrMore
+	tagRecovery
+	tagRuleCall
+	tagRuleRef
+	tagScanLimit
+	tagSeq
+	tagStateCode
+	tagThrow
+	tagUntil
+	tagZeroOrMore
+	tagZeroOrOne
+	tagTypedThrow
+	tagRuleRefArgs
+	tagAssertion
+)
+
+// SerializeGrammar writes the binary encoding of the grammar g to w. The
+// encoding is compact, the strings such as the file names of the positions
+// are written once, so that it can be used to store or transmit a grammar
+// without its source, and DeserializeGrammar reads it back. The attributes
+// computed from the grammar, such as the nullable and left recursion
+// attributes, are not encoded.
+func SerializeGrammar(w io.Writer, g *Grammar) error {
+	e := &grammarEncoder{w: bufio.NewWriter(w), strs: make(map[string]int)}
+	e.w.WriteString(serializeMagic)
+	e.writeUint(serializeVersion)
+	e.writePos(g.p)
+	e.writeCode(g.Init)
+	e.writeUint(len(g.Rules))
+	for _, r := range g.Rules {
+		e.writeRule(r)
+	}
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// DeserializeGrammar reads a grammar from r, as written by SerializeGrammar.
+func DeserializeGrammar(r io.Reader) (*Grammar, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	d := &grammarDecoder{r: br}
+
+	magic := make([]byte, len(serializeMagic))
+	for i := range magic {
+		magic[i] = d.readByte()
+	}
+	if d.err == nil && string(magic) != serializeMagic {
+		return nil, errors.New("deserialize grammar: not a serialized grammar")
+	}
+	if v := d.readUint(); d.err == nil && v != serializeVersion {
+		return nil, fmt.Errorf("deserialize grammar: unsupported version %d", v)
+	}
+	g := NewGrammar(d.readPos())
+	g.Init = d.readCode()
+	n := d.readUint()
+	for i := 0; i < n && d.err == nil; i++ {
+		g.Rules = append(g.Rules, d.readRule())
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("deserialize grammar: %w", d.err)
+	}
+	return g, nil
+}
+
+// grammarEncoder writes the binary encoding of a grammar. The first error
+// is recorded and the writes after it are no-ops.
+type grammarEncoder struct {
+	w   *bufio.Writer
+	err error
+	// index of the strings already written, by value
+	strs map[string]int
+	buf  [binary.MaxVarintLen64]byte
+}
+
+func (e *grammarEncoder) writeUint(v int) {
+	if e.err != nil {
+		return
+	}
+	n := binary.PutUvarint(e.buf[:], uint64(v))
+	_, e.err = e.w.Write(e.buf[:n])
+}
+
+func (e *grammarEncoder) writeBool(v bool) {
+	if v {
+		e.writeUint(1)
+		return
+	}
+	e.writeUint(0)
+}
+
+// writeString writes s, or the index of s if it is already written.
+func (e *grammarEncoder) writeString(s string) {
+	if ix, ok := e.strs[s]; ok {
+		e.writeUint(ix + 1)
+		return
+	}
+	e.strs[s] = len(e.strs)
+	e.writeUint(0)
+	e.writeUint(len(s))
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+func (e *grammarEncoder) writePos(p Pos) {
+	e.writeString(p.Filename)
+	e.writeUint(p.Line)
+	e.writeUint(p.Col)
+	e.writeUint(p.Off)
+}
+
+// writeRunes writes rs, which may be nil, as opposed to empty.
+func (e *grammarEncoder) writeRunes(rs []rune) {
+	e.writeLen(rs == nil, len(rs))
+	for _, r := range rs {
+		e.writeUint(int(r))
+	}
+}
+
+// writeLen writes the length n of a slice, or that the slice is nil.
+func (e *grammarEncoder) writeLen(isNil bool, n int) {
+	if isNil {
+		e.writeUint(0)
+		return
+	}
+	e.writeUint(n + 1)
+}
+
+// writeIdent writes id, which may be nil.
+func (e *grammarEncoder) writeIdent(id *Identifier) {
+	e.writeBool(id != nil)
+	if id != nil {
+		e.writePos(id.p)
+		e.writeString(id.Val)
+	}
+}
+
+// writeCode writes the code block c, which may be nil.
+func (e *grammarEncoder) writeCode(c *CodeBlock) {
+	e.writeBool(c != nil)
+	if c != nil {
+		e.writePos(c.p)
+		e.writeString(c.Val)
+	}
+}
+
+// writeLit writes the literal matcher l, which may be nil.
+func (e *grammarEncoder) writeLit(l *LitMatcher) {
+	e.writeBool(l != nil)
+	if l != nil {
+		e.writePos(l.p)
+		e.writeString(l.Val)
+		e.writeBool(l.IgnoreCase)
+	}
+}
+
+func (e *grammarEncoder) writeRule(r *Rule) {
+	e.writePos(r.p)
+	e.writeIdent(r.Name)
+	e.writeBool(r.DisplayName != nil)
+	if r.DisplayName != nil {
+		e.writePos(r.DisplayName.p)
+		e.writeString(r.DisplayName.Val)
+	}
+	e.writeExpr(r.Expr)
+	e.writeUint(len(r.Params))
+	for _, id := range r.Params {
+		e.writeIdent(id)
+	}
+	e.writeBool(r.Macro)
+	e.writeString(r.Doc)
+	e.writeUint(r.EndLine)
+}
+
+func (e *grammarEncoder) writeExprs(exprs []Expression) {
+	e.writeUint(len(exprs))
+	for _, expr := range exprs {
+		e.writeExpr(expr)
+	}
+}
+
+func (e *grammarEncoder) writeExpr(expr Expression) {
+	if expr == nil {
+		e.writeUint(tagNil)
+		return
+	}
+
+	switch expr := expr.(type) {
+	case *ActionExpr:
+		e.writeUint(tagAction)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+		e.writeCode(expr.Code)
+		e.writeUint(expr.FuncIx)
+	case *AndCodeExpr:
+		e.writeUint(tagAndCode)
+		e.writePos(expr.p)
+		e.writeCode(expr.Code)
+		e.writeUint(expr.FuncIx)
+	case *AndExpr:
+		e.writeUint(tagAnd)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+	case *AnyMatcher:
+		e.writeUint(tagAny)
+		e.writePos(expr.p)
+		e.writeString(expr.Val)
+	case *AssertionExpr:
+		e.writeUint(tagAssertion)
+		e.writePos(expr.p)
+		e.writeString(expr.Name)
+	case *BalancedExpr:
+		e.writeUint(tagBalanced)
+		e.writePos(expr.p)
+		e.writeLit(expr.Open)
+		e.writeLit(expr.Close)
+	case *CaseInsensitiveExpr:
+		e.writeUint(tagCaseInsensitive)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+	case *CharClassMatcher:
+		e.writeUint(tagCharClass)
+		e.writePos(expr.p)
+		e.writeString(expr.Val)
+		e.writeBool(expr.IgnoreCase)
+		e.writeBool(expr.Inverted)
+		e.writeRunes(expr.Chars)
+		e.writeRunes(expr.Ranges)
+		e.writeLen(expr.UnicodeClasses == nil, len(expr.UnicodeClasses))
+		for _, cl := range expr.UnicodeClasses {
+			e.writeString(cl)
+		}
+	case *ChoiceExpr:
+		e.writeUint(tagChoice)
+		e.writePos(expr.p)
+		e.writeExprs(expr.Alternatives)
+	case *LabeledExpr:
+		e.writeUint(tagLabeled)
+		e.writePos(expr.p)
+		e.writeIdent(expr.Label)
+		e.writeExpr(expr.Expr)
+	case *LineStartExpr:
+		e.writeUint(tagLineStart)
+		e.writePos(expr.p)
+	case *LitMatcher:
+		e.writeUint(tagLit)
+		e.writePos(expr.p)
+		e.writeString(expr.Val)
+		e.writeBool(expr.IgnoreCase)
+	case *LookbehindExpr:
+		e.writeUint(tagLookbehind)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+	case *NotCodeExpr:
+		e.writeUint(tagNotCode)
+		e.writePos(expr.p)
+		e.writeCode(expr.Code)
+		e.writeUint(expr.FuncIx)
+	case *NotExpr:
+		e.writeUint(tagNot)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+	case *OneOrMoreExpr:
+		e.writeUint(tagOneOrMore)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+	case *RecoveryExpr:
+		e.writeUint(tagRecovery)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+		e.writeExpr(expr.RecoverExpr)
+		e.writeUint(len(expr.Labels))
+		for _, label := range expr.Labels {
+			e.writeString(string(label))
+		}
+	case *RuleCallExpr:
+		e.writeUint(tagRuleCall)
+		e.writePos(expr.p)
+		e.writeIdent(expr.Name)
+		e.writeExprs(expr.Args)
+	case *RuleRefExpr:
+		if len(expr.Args) > 0 {
+			e.writeUint(tagRuleRefArgs)
+			e.writePos(expr.p)
+			e.writeIdent(expr.Name)
+			e.writeUint(len(expr.Args))
+			for _, arg := range expr.Args {
+				e.writeIdent(arg.Name)
+				e.writeCode(arg.Code)
+				e.writeUint(arg.FuncIx)
+			}
+			break
+		}
+		e.writeUint(tagRuleRef)
+		e.writePos(expr.p)
+		e.writeIdent(expr.Name)
+	case *ScanLimitExpr:
+		e.writeUint(tagScanLimit)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+		e.writeUint(expr.Limit)
+	case *SeqExpr:
+		e.writeUint(tagSeq)
+		e.writePos(expr.p)
+		e.writeExprs(expr.Exprs)
+	case *StateCodeExpr:
+		e.writeUint(tagStateCode)
+		e.writePos(expr.p)
+		e.writeCode(expr.Code)
+		e.writeUint(expr.FuncIx)
+	case *ThrowExpr:
+		if expr.Payload != nil {
+			e.writeUint(tagTypedThrow)
+			e.writePos(expr.p)
+			e.writeString(expr.Label)
+			e.writeCode(expr.Payload)
+			e.writeUint(expr.FuncIx)
+			break
+		}
+		e.writeUint(tagThrow)
+		e.writePos(expr.p)
+		e.writeString(expr.Label)
+	case *UntilExpr:
+		e.writeUint(tagUntil)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+	case *ZeroOrMoreExpr:
+		e.writeUint(tagZeroOrMore)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+	case *ZeroOrOneExpr:
+		e.writeUint(tagZeroOrOne)
+		e.writePos(expr.p)
+		e.writeExpr(expr.Expr)
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("serialize grammar: %s: unsupported expression %T", expr.Pos(), expr)
+		}
+	}
+}
+
+// grammarDecoder reads the binary encoding of a grammar. The first error
+// is recorded and the reads after it return zero values.
+type grammarDecoder struct {
+	r   io.ByteReader
+	err error
+	// strings already read, by index
+	strs []string
+}
+
+func (d *grammarDecoder) readByte() byte {
+	if d.err != nil {
+		return 0
+	}
+	b, err := d.r.ReadByte()
+	if err != nil {
+		d.setErr(err)
+	}
+	return b
+}
+
+func (d *grammarDecoder) setErr(err error) {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	d.err = err
+}
+
+func (d *grammarDecoder) readUint() int {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.setErr(err)
+		return 0
+	}
+	if v > maxSerializedValue {
+		d.err = fmt.Errorf("invalid value %d", v)
+		return 0
+	}
+	return int(v)
+}
+
+func (d *grammarDecoder) readBool() bool {
+	return d.readUint() != 0
+}
+
+func (d *grammarDecoder) readString() string {
+	ix := d.readUint()
+	if ix > 0 {
+		if ix > len(d.strs) {
+			if d.err == nil {
+				d.err = fmt.Errorf("invalid string index %d", ix)
+			}
+			return ""
+		}
+		return d.strs[ix-1]
+	}
+
+	n := d.readUint()
+	var buf []byte
+	for i := 0; i < n && d.err == nil; i++ {
+		buf = append(buf, d.readByte())
+	}
+	if d.err != nil {
+		return ""
+	}
+	s := string(buf)
+	d.strs = append(d.strs, s)
+	return s
+}
+
+func (d *grammarDecoder) readPos() Pos {
+	var p Pos
+	p.Filename = d.readString()
+	p.Line = d.readUint()
+	p.Col = d.readUint()
+	p.Off = d.readUint()
+	return p
+}
+
+func (d *grammarDecoder) readRunes() []rune {
+	isNil, n := d.readLen()
+	if isNil {
+		return nil
+	}
+	rs := []rune{}
+	for i := 0; i < n && d.err == nil; i++ {
+		rs = append(rs, rune(d.readUint()))
+	}
+	return rs
+}
+
+// readLen reads the length of a slice written by writeLen.
+func (d *grammarDecoder) readLen() (isNil bool, n int) {
+	n = d.readUint()
+	if n == 0 {
+		return true, 0
+	}
+	return false, n - 1
+}
+
+func (d *grammarDecoder) readIdent() *Identifier {
+	if !d.readBool() {
+		return nil
+	}
+	p := d.readPos()
+	return NewIdentifier(p, d.readString())
+}
+
+func (d *grammarDecoder) readCode() *CodeBlock {
+	if !d.readBool() {
+		return nil
+	}
+	p := d.readPos()
+	return NewCodeBlock(p, d.readString())
+}
+
+func (d *grammarDecoder) readLit() *LitMatcher {
+	if !d.readBool() {
+		return nil
+	}
+	p := d.readPos()
+	l := NewLitMatcher(p, d.readString())
+	l.IgnoreCase = d.readBool()
+	return l
+}
+
+func (d *grammarDecoder) readRule() *Rule {
+	p := d.readPos()
+	r := NewRule(p, d.readIdent())
+	if d.readBool() {
+		p := d.readPos()
+		r.DisplayName = NewStringLit(p, d.readString())
+	}
+	r.Expr = d.readExpr()
+	n := d.readUint()
+	for i := 0; i < n && d.err == nil; i++ {
+		r.Params = append(r.Params, d.readIdent())
+	}
+	r.Macro = d.readBool()
+	r.Doc = d.readString()
+	r.EndLine = d.readUint()
+	return r
+}
+
+func (d *grammarDecoder) readExprs() []Expression {
+	var exprs []Expression
+	n := d.readUint()
+	for i := 0; i < n && d.err == nil; i++ {
+		exprs = append(exprs, d.readExpr())
+	}
+	return exprs
+}
+
+func (d *grammarDecoder) readExpr() Expression {
+	tag := d.readUint()
+	if d.err != nil || tag == tagNil {
+		return nil
+	}
+
+	p := d.readPos()
+	switch tag {
+	case tagAction:
+		expr := NewActionExpr(p)
+		expr.Expr = d.readExpr()
+		expr.Code = d.readCode()
+		expr.FuncIx = d.readUint()
+		return expr
+	case tagAndCode:
+		expr := NewAndCodeExpr(p)
+		expr.Code = d.readCode()
+		expr.FuncIx = d.readUint()
+		return expr
+	case tagAnd:
+		expr := NewAndExpr(p)
+		expr.Expr = d.readExpr()
+		return expr
+	case tagAny:
+		return NewAnyMatcher(p, d.readString())
+	case tagAssertion:
+		return NewAssertionExpr(p, d.readString())
+	case tagBalanced:
+		expr := NewBalancedExpr(p)
+		expr.Open = d.readLit()
+		expr.Close = d.readLit()
+		return expr
+	case tagCaseInsensitive:
+		expr := NewCaseInsensitiveExpr(p)
+		expr.Expr = d.readExpr()
+		return expr
+	case tagCharClass:
+		// the attributes are read as written rather than parsed from the
+		// raw value, they may have been modified after the parsing.
+		expr := &CharClassMatcher{posValue: posValue{p: p, Val: d.readString()}}
+		expr.IgnoreCase = d.readBool()
+		expr.Inverted = d.readBool()
+		expr.Chars = d.readRunes()
+		expr.Ranges = d.readRunes()
+		isNil, n := d.readLen()
+		if !isNil {
+			expr.UnicodeClasses = []string{}
+		}
+		for i := 0; i < n && d.err == nil; i++ {
+			expr.UnicodeClasses = append(expr.UnicodeClasses, d.readString())
+		}
+		return expr
+	case tagChoice:
+		expr := NewChoiceExpr(p)
+		expr.Alternatives = d.readExprs()
+		return expr
+	case tagLabeled:
+		expr := NewLabeledExpr(p)
+		expr.Label = d.readIdent()
+		expr.Expr = d.readExpr()
+		return expr
+	case tagLineStart:
+		return NewLineStartExpr(p)
+	case tagLit:
+		expr := NewLitMatcher(p, d.readString())
+		expr.IgnoreCase = d.readBool()
+		return expr
+	case tagLookbehind:
+		expr := NewLookbehindExpr(p)
+		expr.Expr = d.readExpr()
+		return expr
+	case tagNotCode:
+		expr := NewNotCodeExpr(p)
+		expr.Code = d.readCode()
+		expr.FuncIx = d.readUint()
+		return expr
+	case tagNot:
+		expr := NewNotExpr(p)
+		expr.Expr = d.readExpr()
+		return expr
+	case tagOneOrMore:
+		expr := NewOneOrMoreExpr(p)
+		expr.Expr = d.readExpr()
+		return expr
+	case tagRecovery:
+		expr := NewRecoveryExpr(p)
+		expr.Expr = d.readExpr()
+		expr.RecoverExpr = d.readExpr()
+		n := d.readUint()
+		for i := 0; i < n && d.err == nil; i++ {
+			expr.Labels = append(expr.Labels, FailureLabel(d.readString()))
+		}
+		return expr
+	case tagRuleCall:
+		expr := NewRuleCallExpr(p)
+		expr.Name = d.readIdent()
+		expr.Args = d.readExprs()
+		return expr
+	case tagRuleRef:
+		expr := NewRuleRefExpr(p)
+		expr.Name = d.readIdent()
+		return expr
+	case tagRuleRefArgs:
+		expr := NewRuleRefExpr(p)
+		expr.Name = d.readIdent()
+		n := d.readUint()
+		for i := 0; i < n && d.err == nil; i++ {
+			arg := &RuleArg{Name: d.readIdent()}
+			arg.Code = d.readCode()
+			arg.FuncIx = d.readUint()
+			expr.Args = append(expr.Args, arg)
+		}
+		return expr
+	case tagScanLimit:
+		expr := NewScanLimitExpr(p)
+		expr.Expr = d.readExpr()
+		expr.Limit = d.readUint()
+		return expr
+	case tagSeq:
+		expr := NewSeqExpr(p)
+		expr.Exprs = d.readExprs()
+		return expr
+	case tagStateCode:
+		expr := NewStateCodeExpr(p)
+		expr.Code = d.readCode()
+		expr.FuncIx = d.readUint()
+		return expr
+	case tagThrow:
+		expr := NewThrowExpr(p)
+		expr.Label = d.readString()
+		return expr
+	case tagTypedThrow:
+		expr := NewThrowExpr(p)
+		expr.Label = d.readString()
+		expr.Payload = d.readCode()
+		expr.FuncIx = d.readUint()
+		return expr
+	case tagUntil:
+		expr := NewUntilExpr(p)
+		expr.Expr = d.readExpr()
+		return expr
+	case tagZeroOrMore:
+		expr := NewZeroOrMoreExpr(p)
+		expr.Expr = d.readExpr()
+		return expr
+	case tagZeroOrOne:
+		expr := NewZeroOrOneExpr(p)
+		expr.Expr = d.readExpr()
+		return expr
+	}
+	if d.err == nil {
+		d.err = fmt.Errorf("%s: invalid expression tag %d", p, tag)
+	}
+	return nil
+}
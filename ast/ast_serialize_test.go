@@ -0,0 +1,129 @@
+package ast
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSerializeGrammar(t *testing.T) {
+	pos := func(line int) Pos {
+		return Pos{Filename: "test.peg", Line: line, Col: 3, Off: line * 10}
+	}
+	code := func(line int, val string) *CodeBlock {
+		return NewCodeBlock(pos(line), val)
+	}
+
+	act := NewActionExpr(pos(1))
+	act.Expr = seq(lit("a"), ref("B"))
+	act.Code = code(1, "{ return nil, nil }")
+	act.FuncIx = 3
+	and := NewAndExpr(pos(2))
+	and.Expr = NewAnyMatcher(pos(2), ".")
+	andCode := NewAndCodeExpr(pos(3))
+	andCode.Code = code(3, "{ return true, nil }")
+	bal := NewBalancedExpr(pos(4))
+	bal.Open, bal.Close = lit("{"), lit("}")
+	ci := NewCaseInsensitiveExpr(pos(5))
+	ci.Expr = NewCharClassMatcher(pos(5), `[^a-z\pL_]i`)
+	ch := NewChoiceExpr(pos(6))
+	ch.Alternatives = []Expression{act, and, andCode, bal, ci}
+	lbl := NewLabeledExpr(pos(7))
+	lbl.Label = NewIdentifier(pos(7), "x")
+	lbl.Expr = NewLineStartExpr(pos(7))
+	ilit := NewLitMatcher(pos(8), "abc")
+	ilit.IgnoreCase = true
+	lb := NewLookbehindExpr(pos(8))
+	lb.Expr = ilit
+	not := NewNotExpr(pos(9))
+	not.Expr = lb
+	notCode := NewNotCodeExpr(pos(10))
+	notCode.Code = code(10, "{ return false, nil }")
+	oom := NewOneOrMoreExpr(pos(11))
+	oom.Expr = notCode
+	rec := NewRecoveryExpr(pos(12))
+	rec.Expr = NewThrowExpr(pos(12))
+	rec.Expr.(*ThrowExpr).Label = "err"
+	rec.RecoverExpr = ref("B")
+	typedThrow := NewThrowExpr(pos(12))
+	typedThrow.Label = "typed"
+	typedThrow.Payload = code(12, "{ return 1, nil }")
+	typedThrow.FuncIx = 2
+	rec.Labels = []FailureLabel{"err", "other"}
+	sl := NewScanLimitExpr(pos(13))
+	sl.Expr = callRule("List", ref("B"), lit(","))
+	sl.Limit = 64
+	state := NewStateCodeExpr(pos(14))
+	state.Code = code(14, "{ return nil }")
+	until := NewUntilExpr(pos(15))
+	until.Expr = lit("*/")
+	zom := NewZeroOrMoreExpr(pos(16))
+	zom.Expr = until
+	zoo := NewZeroOrOneExpr(pos(17))
+	zoo.Expr = state
+	argRef := ref("B")
+	argRef.Args = []*RuleArg{
+		{Name: NewIdentifier(pos(17), "n"), Code: code(17, "{ return 2, nil }"), FuncIx: 4},
+	}
+
+	wb := NewAssertionExpr(pos(17), `\b`)
+
+	start := rule("Start", seq(ch, lbl, not, oom, rec, typedThrow, sl, zom, zoo, argRef, wb))
+	start.DisplayName = NewStringLit(pos(18), "start")
+	start.Doc = "Start is the first rule."
+	start.EndLine = 20
+	g := NewGrammar(pos(1))
+	g.Init = code(1, "{\npackage test\n}")
+	g.Rules = []*Rule{
+		start,
+		rule("List", seq(ref("E"), ref("S")), "E", "S"),
+		macro("B", NewCharClassMatcher(Pos{Filename: "other.peg"}, "[0-9]")),
+	}
+
+	var buf bytes.Buffer
+	if err := SerializeGrammar(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DeserializeGrammar(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, g) {
+		t.Errorf("want\n%s\ngot\n%s", g, got)
+	}
+}
+
+func TestDeserializeGrammarErrors(t *testing.T) {
+	g := NewGrammar(Pos{})
+	g.Rules = []*Rule{rule("A", seq(lit("a"), ref("A")))}
+	var buf bytes.Buffer
+	if err := SerializeGrammar(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	cases := []struct {
+		data []byte
+		err  string
+	}{
+		{[]byte("peg"), "unexpected EOF"},
+		{[]byte("grammar"), "not a serialized grammar"},
+		{append([]byte(serializeMagic), 2), "unsupported version 2"},
+		{data[:len(data)-1], "unexpected EOF"},
+		{append(data[:len(data):len(data)], 0xff), ""},
+	}
+	for i, tc := range cases {
+		_, err := DeserializeGrammar(bytes.NewReader(tc.data))
+		if tc.err == "" {
+			// the trailing data is not read
+			if err != nil {
+				t.Errorf("%d: want no error, got %v", i, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: want error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
@@ -29,6 +29,12 @@ func Walk(v Visitor, expr Expression) {
 		Walk(v, expr.Expr)
 	case *AnyMatcher:
 		// Nothing to do
+	case *AssertionExpr:
+		// Nothing to do
+	case *BalancedExpr:
+		// Nothing to do
+	case *CaseInsensitiveExpr:
+		Walk(v, expr.Expr)
 	case *CharClassMatcher:
 		// Nothing to do
 	case *ChoiceExpr:
@@ -41,24 +47,41 @@ func Walk(v Visitor, expr Expression) {
 		}
 	case *LabeledExpr:
 		Walk(v, expr.Expr)
+	case *LineStartExpr:
+		// Nothing to do
 	case *LitMatcher:
 		// Nothing to do
+	case *LookbehindExpr:
+		Walk(v, expr.Expr)
 	case *NotCodeExpr:
 		// Nothing to do
 	case *NotExpr:
 		Walk(v, expr.Expr)
 	case *OneOrMoreExpr:
 		Walk(v, expr.Expr)
+	case *RecoveryExpr:
+		Walk(v, expr.Expr)
+		Walk(v, expr.RecoverExpr)
 	case *Rule:
 		Walk(v, expr.Expr)
+	case *RuleCallExpr:
+		for _, e := range expr.Args {
+			Walk(v, e)
+		}
 	case *RuleRefExpr:
 		// Nothing to do
+	case *ScanLimitExpr:
+		Walk(v, expr.Expr)
 	case *SeqExpr:
 		for _, e := range expr.Exprs {
 			Walk(v, e)
 		}
 	case *StateCodeExpr:
 		// Nothing to do
+	case *ThrowExpr:
+		// Nothing to do
+	case *UntilExpr:
+		Walk(v, expr.Expr)
 	case *ZeroOrMoreExpr:
 		Walk(v, expr.Expr)
 	case *ZeroOrOneExpr:
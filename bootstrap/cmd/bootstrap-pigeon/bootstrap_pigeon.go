@@ -31,8 +31,8 @@ var g = &grammar{
 					pos: position{line: 5, col: 11, offset: 30},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 5, col: 11, offset: 30},
-							name: "__",
+							pos:    position{line: 5, col: 11, offset: 30},
+							offset: 52,
 						},
 						&labeledExpr{
 							pos:   position{line: 5, col: 14, offset: 33},
@@ -43,12 +43,12 @@ var g = &grammar{
 									pos: position{line: 5, col: 28, offset: 47},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 28, offset: 47},
-											name: "Initializer",
+											pos:    position{line: 5, col: 28, offset: 47},
+											offset: 1,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 40, offset: 59},
-											name: "__",
+											pos:    position{line: 5, col: 40, offset: 59},
+											offset: 52,
 										},
 									},
 								},
@@ -63,12 +63,12 @@ var g = &grammar{
 									pos: position{line: 5, col: 54, offset: 73},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 54, offset: 73},
-											name: "Rule",
+											pos:    position{line: 5, col: 54, offset: 73},
+											offset: 2,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 59, offset: 78},
-											name: "__",
+											pos:    position{line: 5, col: 59, offset: 78},
+											offset: 52,
 										},
 									},
 								},
@@ -91,13 +91,13 @@ var g = &grammar{
 							pos:   position{line: 24, col: 15, offset: 525},
 							label: "code",
 							expr: &ruleRefExpr{
-								pos:  position{line: 24, col: 20, offset: 530},
-								name: "CodeBlock",
+								pos:    position{line: 24, col: 20, offset: 530},
+								offset: 50,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 24, col: 30, offset: 540},
-							name: "EOS",
+							pos:    position{line: 24, col: 30, offset: 540},
+							offset: 56,
 						},
 					},
 				},
@@ -116,13 +116,13 @@ var g = &grammar{
 							pos:   position{line: 28, col: 8, offset: 579},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 28, col: 13, offset: 584},
-								name: "IdentifierName",
+								pos:    position{line: 28, col: 13, offset: 584},
+								offset: 23,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 28, offset: 599},
-							name: "__",
+							pos:    position{line: 28, col: 28, offset: 599},
+							offset: 52,
 						},
 						&labeledExpr{
 							pos:   position{line: 28, col: 31, offset: 602},
@@ -133,36 +133,36 @@ var g = &grammar{
 									pos: position{line: 28, col: 41, offset: 612},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 28, col: 41, offset: 612},
-											name: "StringLiteral",
+											pos:    position{line: 28, col: 41, offset: 612},
+											offset: 27,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 28, col: 55, offset: 626},
-											name: "__",
+											pos:    position{line: 28, col: 55, offset: 626},
+											offset: 52,
 										},
 									},
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 61, offset: 632},
-							name: "RuleDefOp",
+							pos:    position{line: 28, col: 61, offset: 632},
+							offset: 16,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 71, offset: 642},
-							name: "__",
+							pos:    position{line: 28, col: 71, offset: 642},
+							offset: 52,
 						},
 						&labeledExpr{
 							pos:   position{line: 28, col: 74, offset: 645},
 							label: "expr",
 							expr: &ruleRefExpr{
-								pos:  position{line: 28, col: 79, offset: 650},
-								name: "Expression",
+								pos:    position{line: 28, col: 79, offset: 650},
+								offset: 3,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 90, offset: 661},
-							name: "EOS",
+							pos:    position{line: 28, col: 90, offset: 661},
+							offset: 56,
 						},
 					},
 				},
@@ -172,8 +172,8 @@ var g = &grammar{
 			name: "Expression",
 			pos:  position{line: 41, col: 1, offset: 943},
 			expr: &ruleRefExpr{
-				pos:  position{line: 41, col: 14, offset: 958},
-				name: "ChoiceExpr",
+				pos:    position{line: 41, col: 14, offset: 958},
+				offset: 4,
 			},
 		},
 		{
@@ -189,8 +189,8 @@ var g = &grammar{
 							pos:   position{line: 43, col: 14, offset: 985},
 							label: "first",
 							expr: &ruleRefExpr{
-								pos:  position{line: 43, col: 20, offset: 991},
-								name: "ActionExpr",
+								pos:    position{line: 43, col: 20, offset: 991},
+								offset: 5,
 							},
 						},
 						&labeledExpr{
@@ -202,8 +202,8 @@ var g = &grammar{
 									pos: position{line: 43, col: 38, offset: 1009},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 38, offset: 1009},
-											name: "__",
+											pos:    position{line: 43, col: 38, offset: 1009},
+											offset: 52,
 										},
 										&litMatcher{
 											pos:        position{line: 43, col: 41, offset: 1012},
@@ -212,12 +212,12 @@ var g = &grammar{
 											want:       "\"/\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 45, offset: 1016},
-											name: "__",
+											pos:    position{line: 43, col: 45, offset: 1016},
+											offset: 52,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 48, offset: 1019},
-											name: "ActionExpr",
+											pos:    position{line: 43, col: 48, offset: 1019},
+											offset: 5,
 										},
 									},
 								},
@@ -240,8 +240,8 @@ var g = &grammar{
 							pos:   position{line: 58, col: 14, offset: 1429},
 							label: "expr",
 							expr: &ruleRefExpr{
-								pos:  position{line: 58, col: 19, offset: 1434},
-								name: "SeqExpr",
+								pos:    position{line: 58, col: 19, offset: 1434},
+								offset: 6,
 							},
 						},
 						&labeledExpr{
@@ -253,12 +253,12 @@ var g = &grammar{
 									pos: position{line: 58, col: 34, offset: 1449},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 58, col: 34, offset: 1449},
-											name: "__",
+											pos:    position{line: 58, col: 34, offset: 1449},
+											offset: 52,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 58, col: 37, offset: 1452},
-											name: "CodeBlock",
+											pos:    position{line: 58, col: 37, offset: 1452},
+											offset: 50,
 										},
 									},
 								},
@@ -281,8 +281,8 @@ var g = &grammar{
 							pos:   position{line: 72, col: 11, offset: 1728},
 							label: "first",
 							expr: &ruleRefExpr{
-								pos:  position{line: 72, col: 17, offset: 1734},
-								name: "LabeledExpr",
+								pos:    position{line: 72, col: 17, offset: 1734},
+								offset: 7,
 							},
 						},
 						&labeledExpr{
@@ -294,12 +294,12 @@ var g = &grammar{
 									pos: position{line: 72, col: 36, offset: 1753},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 72, col: 36, offset: 1753},
-											name: "__",
+											pos:    position{line: 72, col: 36, offset: 1753},
+											offset: 52,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 72, col: 39, offset: 1756},
-											name: "LabeledExpr",
+											pos:    position{line: 72, col: 39, offset: 1756},
+											offset: 7,
 										},
 									},
 								},
@@ -325,13 +325,13 @@ var g = &grammar{
 									pos:   position{line: 85, col: 15, offset: 2113},
 									label: "label",
 									expr: &ruleRefExpr{
-										pos:  position{line: 85, col: 21, offset: 2119},
-										name: "Identifier",
+										pos:    position{line: 85, col: 21, offset: 2119},
+										offset: 22,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 85, col: 32, offset: 2130},
-									name: "__",
+									pos:    position{line: 85, col: 32, offset: 2130},
+									offset: 52,
 								},
 								&litMatcher{
 									pos:        position{line: 85, col: 35, offset: 2133},
@@ -340,23 +340,23 @@ var g = &grammar{
 									want:       "\":\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 85, col: 39, offset: 2137},
-									name: "__",
+									pos:    position{line: 85, col: 39, offset: 2137},
+									offset: 52,
 								},
 								&labeledExpr{
 									pos:   position{line: 85, col: 42, offset: 2140},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 85, col: 47, offset: 2145},
-										name: "PrefixedExpr",
+										pos:    position{line: 85, col: 47, offset: 2145},
+										offset: 8,
 									},
 								},
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 91, col: 5, offset: 2318},
-						name: "PrefixedExpr",
+						pos:    position{line: 91, col: 5, offset: 2318},
+						offset: 8,
 					},
 				},
 			},
@@ -377,28 +377,28 @@ var g = &grammar{
 									pos:   position{line: 93, col: 16, offset: 2349},
 									label: "op",
 									expr: &ruleRefExpr{
-										pos:  position{line: 93, col: 19, offset: 2352},
-										name: "PrefixedOp",
+										pos:    position{line: 93, col: 19, offset: 2352},
+										offset: 9,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 93, col: 30, offset: 2363},
-									name: "__",
+									pos:    position{line: 93, col: 30, offset: 2363},
+									offset: 52,
 								},
 								&labeledExpr{
 									pos:   position{line: 93, col: 33, offset: 2366},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 93, col: 38, offset: 2371},
-										name: "SuffixedExpr",
+										pos:    position{line: 93, col: 38, offset: 2371},
+										offset: 10,
 									},
 								},
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 104, col: 5, offset: 2653},
-						name: "SuffixedExpr",
+						pos:    position{line: 104, col: 5, offset: 2653},
+						offset: 10,
 					},
 				},
 			},
@@ -444,28 +444,28 @@ var g = &grammar{
 									pos:   position{line: 110, col: 16, offset: 2749},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 110, col: 21, offset: 2754},
-										name: "PrimaryExpr",
+										pos:    position{line: 110, col: 21, offset: 2754},
+										offset: 12,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 110, col: 33, offset: 2766},
-									name: "__",
+									pos:    position{line: 110, col: 33, offset: 2766},
+									offset: 52,
 								},
 								&labeledExpr{
 									pos:   position{line: 110, col: 36, offset: 2769},
 									label: "op",
 									expr: &ruleRefExpr{
-										pos:  position{line: 110, col: 39, offset: 2772},
-										name: "SuffixedOp",
+										pos:    position{line: 110, col: 39, offset: 2772},
+										offset: 11,
 									},
 								},
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 129, col: 5, offset: 3302},
-						name: "PrimaryExpr",
+						pos:    position{line: 129, col: 5, offset: 3302},
+						offset: 12,
 					},
 				},
 			},
@@ -508,24 +508,24 @@ var g = &grammar{
 				pos: position{line: 135, col: 15, offset: 3403},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 135, col: 15, offset: 3403},
-						name: "LitMatcher",
+						pos:    position{line: 135, col: 15, offset: 3403},
+						offset: 26,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 135, col: 28, offset: 3416},
-						name: "CharClassMatcher",
+						pos:    position{line: 135, col: 28, offset: 3416},
+						offset: 42,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 135, col: 47, offset: 3435},
-						name: "AnyMatcher",
+						pos:    position{line: 135, col: 47, offset: 3435},
+						offset: 49,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 135, col: 60, offset: 3448},
-						name: "RuleRefExpr",
+						pos:    position{line: 135, col: 60, offset: 3448},
+						offset: 13,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 135, col: 74, offset: 3462},
-						name: "SemanticPredExpr",
+						pos:    position{line: 135, col: 74, offset: 3462},
+						offset: 14,
 					},
 					&actionExpr{
 						pos: position{line: 135, col: 93, offset: 3481},
@@ -540,20 +540,20 @@ var g = &grammar{
 									want:       "\"(\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 135, col: 97, offset: 3485},
-									name: "__",
+									pos:    position{line: 135, col: 97, offset: 3485},
+									offset: 52,
 								},
 								&labeledExpr{
 									pos:   position{line: 135, col: 100, offset: 3488},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 135, col: 105, offset: 3493},
-										name: "Expression",
+										pos:    position{line: 135, col: 105, offset: 3493},
+										offset: 3,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 135, col: 116, offset: 3504},
-									name: "__",
+									pos:    position{line: 135, col: 116, offset: 3504},
+									offset: 52,
 								},
 								&litMatcher{
 									pos:        position{line: 135, col: 119, offset: 3507},
@@ -580,8 +580,8 @@ var g = &grammar{
 							pos:   position{line: 138, col: 15, offset: 3552},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 138, col: 20, offset: 3557},
-								name: "IdentifierName",
+								pos:    position{line: 138, col: 20, offset: 3557},
+								offset: 23,
 							},
 						},
 						&notExpr{
@@ -590,8 +590,8 @@ var g = &grammar{
 								pos: position{line: 138, col: 38, offset: 3575},
 								exprs: []any{
 									&ruleRefExpr{
-										pos:  position{line: 138, col: 38, offset: 3575},
-										name: "__",
+										pos:    position{line: 138, col: 38, offset: 3575},
+										offset: 52,
 									},
 									&zeroOrOneExpr{
 										pos: position{line: 138, col: 43, offset: 3580},
@@ -599,19 +599,19 @@ var g = &grammar{
 											pos: position{line: 138, col: 43, offset: 3580},
 											exprs: []any{
 												&ruleRefExpr{
-													pos:  position{line: 138, col: 43, offset: 3580},
-													name: "StringLiteral",
+													pos:    position{line: 138, col: 43, offset: 3580},
+													offset: 27,
 												},
 												&ruleRefExpr{
-													pos:  position{line: 138, col: 57, offset: 3594},
-													name: "__",
+													pos:    position{line: 138, col: 57, offset: 3594},
+													offset: 52,
 												},
 											},
 										},
 									},
 									&ruleRefExpr{
-										pos:  position{line: 138, col: 63, offset: 3600},
-										name: "RuleDefOp",
+										pos:    position{line: 138, col: 63, offset: 3600},
+										offset: 16,
 									},
 								},
 							},
@@ -633,20 +633,20 @@ var g = &grammar{
 							pos:   position{line: 143, col: 20, offset: 3737},
 							label: "op",
 							expr: &ruleRefExpr{
-								pos:  position{line: 143, col: 23, offset: 3740},
-								name: "SemanticPredOp",
+								pos:    position{line: 143, col: 23, offset: 3740},
+								offset: 15,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 143, col: 38, offset: 3755},
-							name: "__",
+							pos:    position{line: 143, col: 38, offset: 3755},
+							offset: 52,
 						},
 						&labeledExpr{
 							pos:   position{line: 143, col: 41, offset: 3758},
 							label: "code",
 							expr: &ruleRefExpr{
-								pos:  position{line: 143, col: 46, offset: 3763},
-								name: "CodeBlock",
+								pos:    position{line: 143, col: 46, offset: 3763},
+								offset: 50,
 							},
 						},
 					},
@@ -725,12 +725,12 @@ var g = &grammar{
 				pos: position{line: 161, col: 11, offset: 4186},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 161, col: 11, offset: 4186},
-						name: "MultiLineComment",
+						pos:    position{line: 161, col: 11, offset: 4186},
+						offset: 19,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 161, col: 30, offset: 4205},
-						name: "SingleLineComment",
+						pos:    position{line: 161, col: 30, offset: 4205},
+						offset: 21,
 					},
 				},
 			},
@@ -762,8 +762,8 @@ var g = &grammar{
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 162, col: 33, offset: 4257},
-									name: "SourceChar",
+									pos:    position{line: 162, col: 33, offset: 4257},
+									offset: 17,
 								},
 							},
 						},
@@ -806,15 +806,15 @@ var g = &grammar{
 												want:       "\"*/\"",
 											},
 											&ruleRefExpr{
-												pos:  position{line: 163, col: 53, offset: 4330},
-												name: "EOL",
+												pos:    position{line: 163, col: 53, offset: 4330},
+												offset: 55,
 											},
 										},
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 163, col: 59, offset: 4336},
-									name: "SourceChar",
+									pos:    position{line: 163, col: 59, offset: 4336},
+									offset: 17,
 								},
 							},
 						},
@@ -848,13 +848,13 @@ var g = &grammar{
 								&notExpr{
 									pos: position{line: 164, col: 28, offset: 4384},
 									expr: &ruleRefExpr{
-										pos:  position{line: 164, col: 29, offset: 4385},
-										name: "EOL",
+										pos:    position{line: 164, col: 29, offset: 4385},
+										offset: 55,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 164, col: 33, offset: 4389},
-									name: "SourceChar",
+									pos:    position{line: 164, col: 33, offset: 4389},
+									offset: 17,
 								},
 							},
 						},
@@ -866,8 +866,8 @@ var g = &grammar{
 			name: "Identifier",
 			pos:  position{line: 166, col: 1, offset: 4404},
 			expr: &ruleRefExpr{
-				pos:  position{line: 166, col: 14, offset: 4419},
-				name: "IdentifierName",
+				pos:    position{line: 166, col: 14, offset: 4419},
+				offset: 23,
 			},
 		},
 		{
@@ -880,14 +880,14 @@ var g = &grammar{
 					pos: position{line: 167, col: 18, offset: 4453},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 167, col: 18, offset: 4453},
-							name: "IdentifierStart",
+							pos:    position{line: 167, col: 18, offset: 4453},
+							offset: 24,
 						},
 						&zeroOrMoreExpr{
 							pos: position{line: 167, col: 34, offset: 4469},
 							expr: &ruleRefExpr{
-								pos:  position{line: 167, col: 34, offset: 4469},
-								name: "IdentifierPart",
+								pos:    position{line: 167, col: 34, offset: 4469},
+								offset: 25,
 							},
 						},
 					},
@@ -913,8 +913,8 @@ var g = &grammar{
 				pos: position{line: 171, col: 18, offset: 4598},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 171, col: 18, offset: 4598},
-						name: "IdentifierStart",
+						pos:    position{line: 171, col: 18, offset: 4598},
+						offset: 24,
 					},
 					&charClassMatcher{
 						pos:        position{line: 171, col: 36, offset: 4616},
@@ -939,8 +939,8 @@ var g = &grammar{
 							pos:   position{line: 173, col: 14, offset: 4638},
 							label: "lit",
 							expr: &ruleRefExpr{
-								pos:  position{line: 173, col: 18, offset: 4642},
-								name: "StringLiteral",
+								pos:    position{line: 173, col: 18, offset: 4642},
+								offset: 27,
 							},
 						},
 						&labeledExpr{
@@ -981,8 +981,8 @@ var g = &grammar{
 								&zeroOrMoreExpr{
 									pos: position{line: 183, col: 23, offset: 4913},
 									expr: &ruleRefExpr{
-										pos:  position{line: 183, col: 23, offset: 4913},
-										name: "DoubleStringChar",
+										pos:    position{line: 183, col: 23, offset: 4913},
+										offset: 28,
 									},
 								},
 								&litMatcher{
@@ -1003,8 +1003,8 @@ var g = &grammar{
 									want:       "\"'\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 183, col: 51, offset: 4941},
-									name: "SingleStringChar",
+									pos:    position{line: 183, col: 51, offset: 4941},
+									offset: 29,
 								},
 								&litMatcher{
 									pos:        position{line: 183, col: 68, offset: 4958},
@@ -1026,8 +1026,8 @@ var g = &grammar{
 								&zeroOrMoreExpr{
 									pos: position{line: 183, col: 78, offset: 4968},
 									expr: &ruleRefExpr{
-										pos:  position{line: 183, col: 78, offset: 4968},
-										name: "RawStringChar",
+										pos:    position{line: 183, col: 78, offset: 4968},
+										offset: 30,
 									},
 								},
 								&litMatcher{
@@ -1069,15 +1069,15 @@ var g = &grammar{
 											want:       "\"\\\\\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 186, col: 36, offset: 5091},
-											name: "EOL",
+											pos:    position{line: 186, col: 36, offset: 5091},
+											offset: 55,
 										},
 									},
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 186, col: 42, offset: 5097},
-								name: "SourceChar",
+								pos:    position{line: 186, col: 42, offset: 5097},
+								offset: 17,
 							},
 						},
 					},
@@ -1091,8 +1091,8 @@ var g = &grammar{
 								want:       "\"\\\\\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 186, col: 60, offset: 5115},
-								name: "DoubleStringEscape",
+								pos:    position{line: 186, col: 60, offset: 5115},
+								offset: 31,
 							},
 						},
 					},
@@ -1126,15 +1126,15 @@ var g = &grammar{
 											want:       "\"\\\\\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 187, col: 36, offset: 5171},
-											name: "EOL",
+											pos:    position{line: 187, col: 36, offset: 5171},
+											offset: 55,
 										},
 									},
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 187, col: 42, offset: 5177},
-								name: "SourceChar",
+								pos:    position{line: 187, col: 42, offset: 5177},
+								offset: 17,
 							},
 						},
 					},
@@ -1148,8 +1148,8 @@ var g = &grammar{
 								want:       "\"\\\\\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 187, col: 60, offset: 5195},
-								name: "SingleStringEscape",
+								pos:    position{line: 187, col: 60, offset: 5195},
+								offset: 32,
 							},
 						},
 					},
@@ -1172,8 +1172,8 @@ var g = &grammar{
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 188, col: 22, offset: 5237},
-						name: "SourceChar",
+						pos:    position{line: 188, col: 22, offset: 5237},
+						offset: 17,
 					},
 				},
 			},
@@ -1191,8 +1191,8 @@ var g = &grammar{
 						want:       "\"\\\"\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 190, col: 28, offset: 5278},
-						name: "CommonEscapeSequence",
+						pos:    position{line: 190, col: 28, offset: 5278},
+						offset: 33,
 					},
 				},
 			},
@@ -1210,8 +1210,8 @@ var g = &grammar{
 						want:       "\"'\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 191, col: 28, offset: 5328},
-						name: "CommonEscapeSequence",
+						pos:    position{line: 191, col: 28, offset: 5328},
+						offset: 33,
 					},
 				},
 			},
@@ -1223,24 +1223,24 @@ var g = &grammar{
 				pos: position{line: 193, col: 24, offset: 5375},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 193, col: 24, offset: 5375},
-						name: "SingleCharEscape",
+						pos:    position{line: 193, col: 24, offset: 5375},
+						offset: 34,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 193, col: 43, offset: 5394},
-						name: "OctalEscape",
+						pos:    position{line: 193, col: 43, offset: 5394},
+						offset: 35,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 193, col: 57, offset: 5408},
-						name: "HexEscape",
+						pos:    position{line: 193, col: 57, offset: 5408},
+						offset: 36,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 193, col: 69, offset: 5420},
-						name: "LongUnicodeEscape",
+						pos:    position{line: 193, col: 69, offset: 5420},
+						offset: 37,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 193, col: 89, offset: 5440},
-						name: "ShortUnicodeEscape",
+						pos:    position{line: 193, col: 89, offset: 5440},
+						offset: 38,
 					},
 				},
 			},
@@ -1309,16 +1309,16 @@ var g = &grammar{
 				pos: position{line: 195, col: 15, offset: 5543},
 				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 195, col: 15, offset: 5543},
-						name: "OctalDigit",
+						pos:    position{line: 195, col: 15, offset: 5543},
+						offset: 39,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 195, col: 26, offset: 5554},
-						name: "OctalDigit",
+						pos:    position{line: 195, col: 26, offset: 5554},
+						offset: 39,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 195, col: 37, offset: 5565},
-						name: "OctalDigit",
+						pos:    position{line: 195, col: 37, offset: 5565},
+						offset: 39,
 					},
 				},
 			},
@@ -1336,12 +1336,12 @@ var g = &grammar{
 						want:       "\"x\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 196, col: 17, offset: 5594},
-						name: "HexDigit",
+						pos:    position{line: 196, col: 17, offset: 5594},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 196, col: 26, offset: 5603},
-						name: "HexDigit",
+						pos:    position{line: 196, col: 26, offset: 5603},
+						offset: 41,
 					},
 				},
 			},
@@ -1359,36 +1359,36 @@ var g = &grammar{
 						want:       "\"U\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 197, col: 25, offset: 5638},
-						name: "HexDigit",
+						pos:    position{line: 197, col: 25, offset: 5638},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 197, col: 34, offset: 5647},
-						name: "HexDigit",
+						pos:    position{line: 197, col: 34, offset: 5647},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 197, col: 43, offset: 5656},
-						name: "HexDigit",
+						pos:    position{line: 197, col: 43, offset: 5656},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 197, col: 52, offset: 5665},
-						name: "HexDigit",
+						pos:    position{line: 197, col: 52, offset: 5665},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 197, col: 61, offset: 5674},
-						name: "HexDigit",
+						pos:    position{line: 197, col: 61, offset: 5674},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 197, col: 70, offset: 5683},
-						name: "HexDigit",
+						pos:    position{line: 197, col: 70, offset: 5683},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 197, col: 79, offset: 5692},
-						name: "HexDigit",
+						pos:    position{line: 197, col: 79, offset: 5692},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 197, col: 88, offset: 5701},
-						name: "HexDigit",
+						pos:    position{line: 197, col: 88, offset: 5701},
+						offset: 41,
 					},
 				},
 			},
@@ -1406,20 +1406,20 @@ var g = &grammar{
 						want:       "\"u\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 198, col: 26, offset: 5737},
-						name: "HexDigit",
+						pos:    position{line: 198, col: 26, offset: 5737},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 198, col: 35, offset: 5746},
-						name: "HexDigit",
+						pos:    position{line: 198, col: 35, offset: 5746},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 198, col: 44, offset: 5755},
-						name: "HexDigit",
+						pos:    position{line: 198, col: 44, offset: 5755},
+						offset: 41,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 198, col: 53, offset: 5764},
-						name: "HexDigit",
+						pos:    position{line: 198, col: 53, offset: 5764},
+						offset: 41,
 					},
 				},
 			},
@@ -1478,12 +1478,12 @@ var g = &grammar{
 								pos: position{line: 204, col: 26, offset: 5869},
 								alternatives: []any{
 									&ruleRefExpr{
-										pos:  position{line: 204, col: 26, offset: 5869},
-										name: "ClassCharRange",
+										pos:    position{line: 204, col: 26, offset: 5869},
+										offset: 43,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 204, col: 43, offset: 5886},
-										name: "ClassChar",
+										pos:    position{line: 204, col: 43, offset: 5886},
+										offset: 44,
 									},
 									&seqExpr{
 										pos: position{line: 204, col: 55, offset: 5898},
@@ -1495,8 +1495,8 @@ var g = &grammar{
 												want:       "\"\\\\\"",
 											},
 											&ruleRefExpr{
-												pos:  position{line: 204, col: 60, offset: 5903},
-												name: "UnicodeClassEscape",
+												pos:    position{line: 204, col: 60, offset: 5903},
+												offset: 46,
 											},
 										},
 									},
@@ -1529,8 +1529,8 @@ var g = &grammar{
 				pos: position{line: 209, col: 18, offset: 6053},
 				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 209, col: 18, offset: 6053},
-						name: "ClassChar",
+						pos:    position{line: 209, col: 18, offset: 6053},
+						offset: 44,
 					},
 					&litMatcher{
 						pos:        position{line: 209, col: 28, offset: 6063},
@@ -1539,8 +1539,8 @@ var g = &grammar{
 						want:       "\"-\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 209, col: 32, offset: 6067},
-						name: "ClassChar",
+						pos:    position{line: 209, col: 32, offset: 6067},
+						offset: 44,
 					},
 				},
 			},
@@ -1572,15 +1572,15 @@ var g = &grammar{
 											want:       "\"\\\\\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 210, col: 29, offset: 6107},
-											name: "EOL",
+											pos:    position{line: 210, col: 29, offset: 6107},
+											offset: 55,
 										},
 									},
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 210, col: 35, offset: 6113},
-								name: "SourceChar",
+								pos:    position{line: 210, col: 35, offset: 6113},
+								offset: 17,
 							},
 						},
 					},
@@ -1594,8 +1594,8 @@ var g = &grammar{
 								want:       "\"\\\\\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 210, col: 53, offset: 6131},
-								name: "CharClassEscape",
+								pos:    position{line: 210, col: 53, offset: 6131},
+								offset: 45,
 							},
 						},
 					},
@@ -1615,8 +1615,8 @@ var g = &grammar{
 						want:       "\"]\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 211, col: 25, offset: 6173},
-						name: "CommonEscapeSequence",
+						pos:    position{line: 211, col: 25, offset: 6173},
+						offset: 33,
 					},
 				},
 			},
@@ -1637,8 +1637,8 @@ var g = &grammar{
 						pos: position{line: 213, col: 28, offset: 6224},
 						alternatives: []any{
 							&ruleRefExpr{
-								pos:  position{line: 213, col: 28, offset: 6224},
-								name: "SingleCharUnicodeClass",
+								pos:    position{line: 213, col: 28, offset: 6224},
+								offset: 47,
 							},
 							&seqExpr{
 								pos: position{line: 213, col: 53, offset: 6249},
@@ -1650,8 +1650,8 @@ var g = &grammar{
 										want:       "\"{\"",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 213, col: 57, offset: 6253},
-										name: "UnicodeClass",
+										pos:    position{line: 213, col: 57, offset: 6253},
+										offset: 48,
 									},
 									&litMatcher{
 										pos:        position{line: 213, col: 70, offset: 6266},
@@ -1722,8 +1722,8 @@ var g = &grammar{
 							want:       "\"{\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 222, col: 17, offset: 6444},
-							name: "Code",
+							pos:    position{line: 222, col: 17, offset: 6444},
+							offset: 51,
 						},
 						&litMatcher{
 							pos:        position{line: 222, col: 22, offset: 6449},
@@ -1759,8 +1759,8 @@ var g = &grammar{
 										},
 									},
 									&ruleRefExpr{
-										pos:  position{line: 228, col: 18, offset: 6566},
-										name: "SourceChar",
+										pos:    position{line: 228, col: 18, offset: 6566},
+										offset: 17,
 									},
 								},
 							},
@@ -1775,8 +1775,8 @@ var g = &grammar{
 									want:       "\"{\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 228, col: 38, offset: 6586},
-									name: "Code",
+									pos:    position{line: 228, col: 38, offset: 6586},
+									offset: 51,
 								},
 								&litMatcher{
 									pos:        position{line: 228, col: 43, offset: 6591},
@@ -1799,16 +1799,16 @@ var g = &grammar{
 					pos: position{line: 230, col: 8, offset: 6608},
 					alternatives: []any{
 						&ruleRefExpr{
-							pos:  position{line: 230, col: 8, offset: 6608},
-							name: "Whitespace",
+							pos:    position{line: 230, col: 8, offset: 6608},
+							offset: 54,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 230, col: 21, offset: 6621},
-							name: "EOL",
+							pos:    position{line: 230, col: 21, offset: 6621},
+							offset: 55,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 230, col: 27, offset: 6627},
-							name: "Comment",
+							pos:    position{line: 230, col: 27, offset: 6627},
+							offset: 18,
 						},
 					},
 				},
@@ -1823,12 +1823,12 @@ var g = &grammar{
 					pos: position{line: 231, col: 7, offset: 6646},
 					alternatives: []any{
 						&ruleRefExpr{
-							pos:  position{line: 231, col: 7, offset: 6646},
-							name: "Whitespace",
+							pos:    position{line: 231, col: 7, offset: 6646},
+							offset: 54,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 231, col: 20, offset: 6659},
-							name: "MultiLineCommentNoLineTerminator",
+							pos:    position{line: 231, col: 20, offset: 6659},
+							offset: 20,
 						},
 					},
 				},
@@ -1865,8 +1865,8 @@ var g = &grammar{
 						pos: position{line: 235, col: 7, offset: 6740},
 						exprs: []any{
 							&ruleRefExpr{
-								pos:  position{line: 235, col: 7, offset: 6740},
-								name: "__",
+								pos:    position{line: 235, col: 7, offset: 6740},
+								offset: 52,
 							},
 							&litMatcher{
 								pos:        position{line: 235, col: 10, offset: 6743},
@@ -1880,19 +1880,19 @@ var g = &grammar{
 						pos: position{line: 235, col: 16, offset: 6749},
 						exprs: []any{
 							&ruleRefExpr{
-								pos:  position{line: 235, col: 16, offset: 6749},
-								name: "_",
+								pos:    position{line: 235, col: 16, offset: 6749},
+								offset: 53,
 							},
 							&zeroOrOneExpr{
 								pos: position{line: 235, col: 18, offset: 6751},
 								expr: &ruleRefExpr{
-									pos:  position{line: 235, col: 18, offset: 6751},
-									name: "SingleLineComment",
+									pos:    position{line: 235, col: 18, offset: 6751},
+									offset: 21,
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 235, col: 37, offset: 6770},
-								name: "EOL",
+								pos:    position{line: 235, col: 37, offset: 6770},
+								offset: 55,
 							},
 						},
 					},
@@ -1900,12 +1900,12 @@ var g = &grammar{
 						pos: position{line: 235, col: 43, offset: 6776},
 						exprs: []any{
 							&ruleRefExpr{
-								pos:  position{line: 235, col: 43, offset: 6776},
-								name: "__",
+								pos:    position{line: 235, col: 43, offset: 6776},
+								offset: 52,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 235, col: 46, offset: 6779},
-								name: "EOF",
+								pos:    position{line: 235, col: 46, offset: 6779},
+								offset: 57,
 							},
 						},
 					},
@@ -2256,6 +2256,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -2275,6 +2279,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -2526,8 +2543,8 @@ type (
 )
 
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 type stateCodeExpr struct {
@@ -2710,8 +2727,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -2724,6 +2741,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -2738,6 +2757,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -2823,6 +2845,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -2843,7 +2870,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -2973,11 +3000,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 func (p *parser) parse(g *grammar) (val any, err error) {
@@ -2987,7 +3018,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -3009,36 +3040,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -3046,6 +3063,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -3312,8 +3353,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -3342,12 +3383,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -3457,19 +3498,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
@@ -0,0 +1,213 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// autoMarks are the labels of the expressions from which the result of an
+// alternative without action is built, by kind.
+var autoMarks = map[string]byte{
+	"item":  'i',
+	"key":   'k',
+	"value": 'v',
+	"text":  't',
+}
+
+// autoValueLabel is the label of the value of an alternative whose result
+// is built from its marked expressions.
+const autoValueLabel = "autoValue"
+
+// autoMark is a marked expression of an alternative and the path to its
+// value in the value of the alternative, see the autoMark type of the
+// generated parser.
+type autoMark struct {
+	expr *ast.LabeledExpr
+	kind byte
+	path []int
+}
+
+// autoMapper collects the marked expressions of an alternative.
+type autoMapper struct {
+	rule  string
+	marks []autoMark
+	// the key and value marks that are paired in a sequence
+	paired map[*ast.LabeledExpr]bool
+}
+
+// autoMapResults adds an action to the alternatives of the rules that have
+// no action but have marked expressions, i.e. expressions labeled item, key,
+// value or text. The action builds the result of the alternative from the
+// values of those expressions: a map[string]any of the values keyed by the
+// preceding keys, a []any of the items, or the text matched by the text
+// expression. The marked expressions must be in sequences, repetitions or
+// optional expressions of the alternative, as the values of the other
+// expressions don't have the values of their sub-expressions.
+func autoMapResults(g *ast.Grammar) error {
+	for _, rule := range g.Rules {
+		if ch, ok := rule.Expr.(*ast.ChoiceExpr); ok {
+			for i, alt := range ch.Alternatives {
+				expr, err := autoMapAlternative(rule.Name.Val, alt)
+				if err != nil {
+					return err
+				}
+				ch.Alternatives[i] = expr
+			}
+			continue
+		}
+		expr, err := autoMapAlternative(rule.Name.Val, rule.Expr)
+		if err != nil {
+			return err
+		}
+		rule.Expr = expr
+	}
+	return nil
+}
+
+// autoMapAlternative returns alt with an action building its result if it
+// has marked expressions, or alt itself.
+func autoMapAlternative(rule string, alt ast.Expression) (ast.Expression, error) {
+	if _, ok := alt.(*ast.ActionExpr); ok {
+		return alt, nil
+	}
+	if sl, ok := alt.(*ast.ScanLimitExpr); ok {
+		// the result of the bounded alternative is built inside the bound
+		expr, err := autoMapAlternative(rule, sl.Expr)
+		if err != nil {
+			return nil, err
+		}
+		sl.Expr = expr
+		return sl, nil
+	}
+	am := &autoMapper{rule: rule, paired: make(map[*ast.LabeledExpr]bool)}
+	if err := am.collect(alt, nil); err != nil {
+		return nil, err
+	}
+	if len(am.marks) == 0 {
+		return alt, nil
+	}
+
+	var kinds string
+	for _, m := range am.marks {
+		if (m.kind == 'k' || m.kind == 'v') && !am.paired[m.expr] {
+			return nil, fmt.Errorf("%s: rule %s: label %s is not paired in a sequence, a key must be followed by a value",
+				m.expr.Pos(), rule, m.expr.Label.Val)
+		}
+		if m.kind == 'v' {
+			continue
+		}
+		if !strings.ContainsRune(kinds, rune(m.kind)) {
+			kinds += string(m.kind)
+		}
+	}
+	if len(kinds) > 1 || (kinds == "t" && len(am.marks) > 1) {
+		return nil, fmt.Errorf("%s: rule %s: an alternative has either item labels, key and value labels or a single text label",
+			alt.Pos(), rule)
+	}
+
+	var buf strings.Builder
+	buf.WriteString("{\n\treturn autoResult(" + autoValueLabel + ", []autoMark{")
+	for i, m := range am.marks {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "{%q, []int{", m.kind)
+		for j, ix := range m.path {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(&buf, "%d", ix)
+		}
+		buf.WriteString("}}")
+		if m.kind == 't' {
+			// the value of the text expression is the text it matched
+			act := ast.NewActionExpr(m.expr.Expr.Pos())
+			act.Expr = m.expr.Expr
+			act.Code = ast.NewCodeBlock(m.expr.Pos(), "{\n\treturn string(c.text), nil\n}")
+			m.expr.Expr = act
+		}
+	}
+	buf.WriteString("})\n}")
+
+	labeled := ast.NewLabeledExpr(alt.Pos())
+	labeled.Label = ast.NewIdentifier(alt.Pos(), autoValueLabel)
+	labeled.Expr = alt
+	act := ast.NewActionExpr(alt.Pos())
+	act.Expr = labeled
+	act.Code = ast.NewCodeBlock(alt.Pos(), buf.String())
+	return act, nil
+}
+
+// collect records the marked expressions of expr, whose value is at path in
+// the value of the alternative.
+func (am *autoMapper) collect(expr ast.Expression, path []int) error {
+	// the path of the sub-expressions is extended, not shared
+	path = path[:len(path):len(path)]
+
+	switch expr := expr.(type) {
+	case *ast.CaseInsensitiveExpr:
+		return am.collect(expr.Expr, path)
+	case *ast.LabeledExpr:
+		kind, ok := autoMarks[expr.Label.Val]
+		if !ok {
+			return am.collect(expr.Expr, path)
+		}
+		if err := am.check(expr.Expr, "in another marked expression"); err != nil {
+			return err
+		}
+		am.marks = append(am.marks, autoMark{expr: expr, kind: kind, path: path})
+	case *ast.OneOrMoreExpr:
+		return am.collect(expr.Expr, append(path, -1))
+	case *ast.SeqExpr:
+		var key *ast.LabeledExpr
+		for i, sub := range expr.Exprs {
+			if err := am.collect(sub, append(path, i)); err != nil {
+				return err
+			}
+			if l, ok := sub.(*ast.LabeledExpr); ok {
+				switch autoMarks[l.Label.Val] {
+				case 'k':
+					key = l
+				case 'v':
+					if key != nil {
+						am.paired[key], am.paired[l] = true, true
+						key = nil
+					}
+				}
+			}
+		}
+	case *ast.ZeroOrMoreExpr:
+		return am.collect(expr.Expr, append(path, -1))
+	case *ast.ZeroOrOneExpr:
+		return am.collect(expr.Expr, append(path, -2))
+	default:
+		return am.check(expr, fmt.Sprintf("in a %s", exprKind(expr)))
+	}
+	return nil
+}
+
+// check returns an error if expr has a marked expression, which cannot be
+// collected where expr is.
+func (am *autoMapper) check(expr ast.Expression, where string) error {
+	var err error
+	ast.Inspect(expr, func(expr ast.Expression) bool {
+		if l, ok := expr.(*ast.LabeledExpr); ok && err == nil {
+			if _, ok := autoMarks[l.Label.Val]; ok {
+				err = fmt.Errorf("%s: rule %s: label %s cannot be collected %s",
+					l.Pos(), am.rule, l.Label.Val, where)
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// exprKind returns a short description of the kind of expr.
+func exprKind(expr ast.Expression) string {
+	name := fmt.Sprintf("%T", expr)
+	name = strings.TrimPrefix(name, "*ast.")
+	name = strings.TrimSuffix(name, "Expr")
+	return strings.ToLower(name) + " expression"
+}
@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/ast"
+	"github.com/mna/pigeon/bootstrap"
+)
+
+func TestAutoMapResults(t *testing.T) {
+	src := `
+list = '[' ( item:elem ( ',' item:elem )* )? ']'
+pair = key:elem '=' value:elem / elem { return nil, nil }
+elem = text:[a-z]+
+plain = elem elem
+`
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := autoMapResults(g); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"list": "{'i', []int{1, -2, 0}}, {'i', []int{1, -2, 1, -1, 1}}",
+		"pair": "{'k', []int{0}}, {'v', []int{2}}",
+		"elem": "{'t', []int{}}",
+	}
+	for _, r := range g.Rules {
+		expr := r.Expr
+		if ch, ok := expr.(*ast.ChoiceExpr); ok {
+			if _, ok := ch.Alternatives[1].(*ast.ActionExpr).Expr.(*ast.RuleRefExpr); !ok {
+				t.Errorf("rule %s: want the action of the second alternative unchanged", r.Name.Val)
+			}
+			expr = ch.Alternatives[0]
+		}
+		act, ok := expr.(*ast.ActionExpr)
+		marks, hasMarks := want[r.Name.Val]
+		if ok != hasMarks {
+			t.Errorf("rule %s: want action %t, got %t", r.Name.Val, hasMarks, ok)
+			continue
+		}
+		if ok && !strings.Contains(act.Code.Val, "[]autoMark{"+marks+"}") {
+			t.Errorf("rule %s: want marks %s, got code %s", r.Name.Val, marks, act.Code.Val)
+		}
+	}
+}
+
+func TestAutoMapResultsErrors(t *testing.T) {
+	cases := []struct {
+		src, err string
+	}{
+		{`a = item:"a" key:"b" '=' value:"c"`, "either item labels, key and value labels or a single text label"},
+		{`a = text:"a" text:"b"`, "either item labels, key and value labels or a single text label"},
+		{`a = key:"a" '='`, "label key is not paired in a sequence"},
+		{`a = ( key:"a" )? '=' value:"b"`, "label key is not paired in a sequence"},
+		{`a = ( item:"a" / "b" )*`, "label item cannot be collected in a choice expression"},
+		{`a = !( item:"a" ) "b"`, "label item cannot be collected in a not expression"},
+		{`a = item:( "a" item:"b" )`, "label item cannot be collected in another marked expression"},
+	}
+
+	for _, tc := range cases {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(tc.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = autoMapResults(g)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%q: want error containing %q, got %v", tc.src, tc.err, err)
+		}
+	}
+}
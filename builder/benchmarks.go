@@ -0,0 +1,377 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"unicode"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// benchSizes are the names of the synthesized inputs of the benchmarks, by
+// the number of repetitions of their outermost repeated expressions.
+var benchSizes = []struct {
+	name string
+	reps int
+}{
+	{"small", 1},
+	{"medium", 10},
+	{"large", 100},
+}
+
+// maxSynthDepth is the depth of the rules past which the synthesized
+// inputs take the shortest path out of the rules.
+const maxSynthDepth = 8
+
+// classCandidates are the characters tried, in order, to match a character
+// class in a synthesized input.
+var classCandidates = []rune("a0A_ -.,:;=+*/()[]{}<>\"'!?#$%&@^|~`\\\t\néπ日")
+
+// BuildBenchmarks writes to w the benchmark test file of the parser
+// generated by BuildParser with the same options, if the EmitBenchmarks
+// option is set, and nothing otherwise. The file has a benchmark for the
+// entrypoint of the grammar that parses inputs of increasing sizes
+// synthesized from the grammar, and the files of the BenchmarkInputs
+// option.
+//
+// The inputs are synthesized without running the code blocks nor
+// checking the predicates of the grammar, so they may not match it. The
+// benchmark skips those that do not, the files must match.
+func BuildBenchmarks(w io.Writer, g *ast.Grammar, opts ...Option) error {
+	b := &builder{w: w, warnw: os.Stderr, recvName: "c"}
+	b.setOptions(opts)
+	if !b.emitBenchmarks {
+		return nil
+	}
+	return b.writeBenchmarks(g)
+}
+
+func (b *builder) writeBenchmarks(g *ast.Grammar) error {
+	if len(g.Rules) == 0 {
+		return errors.New("emit benchmarks: the grammar has no rule")
+	}
+	pkg := grammarPackage(g)
+	if pkg == "" {
+		return errors.New("emit benchmarks: the grammar has no package clause")
+	}
+
+	entry := g.Rules[0].Name.Val
+	parse := "Parse"
+	if b.methodReceiver != "" {
+		parse = fmt.Sprintf("new(%s).Parse", b.methodReceiver)
+	}
+	s := newInputSynth(g)
+
+	b.writeln("// Code generated by pigeon; DO NOT EDIT.")
+	b.writeln("")
+	b.writelnf("package %s", pkg)
+	b.writeln("")
+	b.writeln("import (")
+	if len(b.benchInputs) > 0 {
+		b.writeln("\t\"os\"")
+	}
+	b.writeln("\t\"testing\"")
+	b.writeln(")")
+	b.writeln("")
+	b.writelnf("// benchInputs are the inputs of Benchmark%s, synthesized from the", exportedName(entry))
+	b.writeln("// grammar or read from a file.")
+	b.writeln("var benchInputs = []struct {")
+	b.writeln("\tname string")
+	b.writeln("\tdata string")
+	b.writeln("\tfile string")
+	b.writeln("}{")
+	for _, size := range benchSizes {
+		b.writelnf("\t{name: %q, data: %s},", size.name, strconv.Quote(s.synthesize(g.Rules[0], size.reps)))
+	}
+	for _, file := range b.benchInputs {
+		b.writelnf("\t{name: %q, file: %q},", filepath.Base(file), filepath.ToSlash(file))
+	}
+	b.writeln("}")
+	b.writeln("")
+	b.writelnf("func Benchmark%s(b *testing.B) {", exportedName(entry))
+	b.writeln("\tfor _, in := range benchInputs {")
+	b.writeln("\t\tin := in")
+	b.writeln("\t\tb.Run(in.name, func(b *testing.B) {")
+	b.writeln("\t\t\tdata := []byte(in.data)")
+	if len(b.benchInputs) > 0 {
+		b.writeln("\t\t\tif in.file != \"\" {")
+		b.writeln("\t\t\t\tvar err error")
+		b.writeln("\t\t\t\tif data, err = os.ReadFile(in.file); err != nil {")
+		b.writeln("\t\t\t\t\tb.Fatal(err)")
+		b.writeln("\t\t\t\t}")
+		b.writeln("\t\t\t}")
+	}
+	b.writelnf("\t\t\tif _, err := %s(in.name, data); err != nil {", parse)
+	b.writeln("\t\t\t\tif in.file == \"\" {")
+	b.writeln("\t\t\t\t\tb.Skipf(\"synthesized input does not match the grammar: %v\", err)")
+	b.writeln("\t\t\t\t}")
+	b.writeln("\t\t\t\tb.Fatal(err)")
+	b.writeln("\t\t\t}")
+	b.writeln("\t\t\tb.SetBytes(int64(len(data)))")
+	b.writeln("\t\t\tb.ReportAllocs()")
+	b.writeln("\t\t\tb.ResetTimer()")
+	b.writeln("\t\t\tfor i := 0; i < b.N; i++ {")
+	b.writelnf("\t\t\t\tif _, err := %s(in.name, data); err != nil {", parse)
+	b.writeln("\t\t\t\t\tb.Fatal(err)")
+	b.writeln("\t\t\t\t}")
+	b.writeln("\t\t\t}")
+	b.writeln("\t\t})")
+	b.writeln("\t}")
+	b.writeln("}")
+	return b.err
+}
+
+// exportedName returns the rule name nm with its first letter in upper
+// case, so that it can follow "Benchmark" in the name of a benchmark.
+func exportedName(nm string) string {
+	for i, rn := range nm {
+		return string(unicode.ToUpper(rn)) + nm[i+len(string(rn)):]
+	}
+	return nm
+}
+
+// inputSynth synthesizes inputs from the expressions of a grammar. The
+// outermost repetitions of the inputs, the repetitions that are not in
+// another one, are repeated reps times, except those of at most a single
+// character, such as whitespace or digits, that are matched once like the
+// other repetitions. The choices take their first alternative that does
+// not reference a rule being synthesized, so that the recursive rules end,
+// and the optional expressions and repetitions that do are left out. Past
+// maxSynthDepth rules, the choices take their shortest alternative and the
+// optional expressions are left out.
+type inputSynth struct {
+	rules  map[string]*ast.Rule
+	minLen map[string]int
+	active map[string]bool
+	reps   int
+	depth  int
+	nested int
+}
+
+// infLen is the minimum length of the rules that cannot be synthesized,
+// e.g. the rules that call themselves without an alternative.
+const infLen = 1 << 30
+
+func newInputSynth(g *ast.Grammar) *inputSynth {
+	s := &inputSynth{
+		rules:  make(map[string]*ast.Rule, len(g.Rules)),
+		minLen: make(map[string]int, len(g.Rules)),
+		active: make(map[string]bool),
+	}
+	for _, r := range g.Rules {
+		s.rules[r.Name.Val] = r
+		s.minLen[r.Name.Val] = infLen
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range g.Rules {
+			if n := s.minLenOf(r.Expr); n < s.minLen[r.Name.Val] {
+				s.minLen[r.Name.Val] = n
+				changed = true
+			}
+		}
+	}
+	return s
+}
+
+// synthesize returns the input synthesized for rule with its outermost
+// repetitions repeated reps times.
+func (s *inputSynth) synthesize(rule *ast.Rule, reps int) string {
+	s.reps, s.depth, s.nested = reps, 0, 0
+	s.active[rule.Name.Val] = true
+	defer delete(s.active, rule.Name.Val)
+	var buf []rune
+	s.expr(rule.Expr, &buf)
+	return string(buf)
+}
+
+func (s *inputSynth) expr(expr ast.Expression, buf *[]rune) {
+	switch expr := expr.(type) {
+	case *ast.ActionExpr:
+		s.expr(expr.Expr, buf)
+	case *ast.AnyMatcher:
+		*buf = append(*buf, 'a')
+	case *ast.BalancedExpr:
+		s.expr(expr.Open, buf)
+		s.expr(expr.Close, buf)
+	case *ast.CaseInsensitiveExpr:
+		s.expr(expr.Expr, buf)
+	case *ast.CharClassMatcher:
+		if rn, ok := classSample(expr); ok {
+			*buf = append(*buf, rn)
+		}
+	case *ast.ChoiceExpr:
+		var alt ast.Expression
+		if s.depth < maxSynthDepth {
+			for _, a := range expr.Alternatives {
+				if !s.recurses(a) {
+					alt = a
+					break
+				}
+			}
+		}
+		if alt == nil {
+			alt = expr.Alternatives[0]
+			for _, a := range expr.Alternatives[1:] {
+				if s.minLenOf(a) < s.minLenOf(alt) {
+					alt = a
+				}
+			}
+		}
+		s.expr(alt, buf)
+	case *ast.LabeledExpr:
+		s.expr(expr.Expr, buf)
+	case *ast.LitMatcher:
+		*buf = append(*buf, []rune(expr.Val)...)
+	case *ast.OneOrMoreExpr:
+		s.repeat(expr.Expr, 1, buf)
+	case *ast.RecoveryExpr:
+		s.expr(expr.Expr, buf)
+	case *ast.RuleRefExpr:
+		r := s.rules[expr.Name.Val]
+		if r == nil || s.depth >= 4*maxSynthDepth {
+			return
+		}
+		prev := s.active[r.Name.Val]
+		s.active[r.Name.Val] = true
+		s.depth++
+		s.expr(r.Expr, buf)
+		s.depth--
+		s.active[r.Name.Val] = prev
+	case *ast.ScanLimitExpr:
+		s.expr(expr.Expr, buf)
+	case *ast.SeqExpr:
+		for _, e := range expr.Exprs {
+			s.expr(e, buf)
+		}
+	case *ast.UntilExpr:
+		s.expr(expr.Expr, buf)
+	case *ast.ZeroOrMoreExpr:
+		s.repeat(expr.Expr, 0, buf)
+	case *ast.ZeroOrOneExpr:
+		if s.depth < maxSynthDepth && !s.recurses(expr.Expr) {
+			s.expr(expr.Expr, buf)
+		}
+	}
+	// the predicates, code blocks, throws and assertions match no input
+}
+
+// repeat synthesizes the repetition of expr, with at least min
+// repetitions.
+func (s *inputSynth) repeat(expr ast.Expression, min int, buf *[]rune) {
+	n := 1
+	if s.nested == 0 && s.minLenOf(expr) > 1 {
+		n = s.reps
+	}
+	if s.depth >= maxSynthDepth || s.recurses(expr) {
+		n = min
+	}
+	s.nested++
+	for i := 0; i < n; i++ {
+		s.expr(expr, buf)
+	}
+	s.nested--
+}
+
+// recurses returns true if expr references a rule being synthesized.
+func (s *inputSynth) recurses(expr ast.Expression) bool {
+	var found bool
+	ast.Inspect(expr, func(expr ast.Expression) bool {
+		if ref, ok := expr.(*ast.RuleRefExpr); ok && s.active[ref.Name.Val] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// minLenOf returns the number of characters of the shortest input
+// synthesized for expr, with the minimum lengths of the rules computed so
+// far.
+func (s *inputSynth) minLenOf(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.ActionExpr:
+		return s.minLenOf(expr.Expr)
+	case *ast.AnyMatcher, *ast.CharClassMatcher:
+		return 1
+	case *ast.BalancedExpr:
+		return len([]rune(expr.Open.Val)) + len([]rune(expr.Close.Val))
+	case *ast.CaseInsensitiveExpr:
+		return s.minLenOf(expr.Expr)
+	case *ast.ChoiceExpr:
+		n := infLen
+		for _, alt := range expr.Alternatives {
+			if l := s.minLenOf(alt); l < n {
+				n = l
+			}
+		}
+		return n
+	case *ast.LabeledExpr:
+		return s.minLenOf(expr.Expr)
+	case *ast.LitMatcher:
+		return len([]rune(expr.Val))
+	case *ast.OneOrMoreExpr:
+		return s.minLenOf(expr.Expr)
+	case *ast.RecoveryExpr:
+		return s.minLenOf(expr.Expr)
+	case *ast.RuleRefExpr:
+		if n, ok := s.minLen[expr.Name.Val]; ok {
+			return n
+		}
+		return infLen
+	case *ast.ScanLimitExpr:
+		return s.minLenOf(expr.Expr)
+	case *ast.SeqExpr:
+		n := 0
+		for _, e := range expr.Exprs {
+			n += s.minLenOf(e)
+			if n >= infLen {
+				return infLen
+			}
+		}
+		return n
+	case *ast.UntilExpr:
+		return s.minLenOf(expr.Expr)
+	}
+	return 0
+}
+
+// classSample returns the first of classCandidates that matches the
+// character class ch.
+func classSample(ch *ast.CharClassMatcher) (rune, bool) {
+	for _, rn := range classCandidates {
+		if classMatches(ch, rn) {
+			return rn, true
+		}
+	}
+	return 0, false
+}
+
+func classMatches(ch *ast.CharClassMatcher, rn rune) bool {
+	if ch.IgnoreCase {
+		rn = unicode.ToLower(rn)
+	}
+	in := false
+	for _, c := range ch.Chars {
+		if c == rn {
+			in = true
+		}
+	}
+	for i := 0; i+1 < len(ch.Ranges); i += 2 {
+		if rn >= ch.Ranges[i] && rn <= ch.Ranges[i+1] {
+			in = true
+		}
+	}
+	for _, class := range ch.UnicodeClasses {
+		for _, tables := range []map[string]*unicode.RangeTable{unicode.Categories, unicode.Properties, unicode.Scripts} {
+			if rt, ok := tables[class]; ok && unicode.Is(rt, rn) {
+				in = true
+			}
+		}
+	}
+	return in != ch.Inverted
+}
@@ -4,19 +4,30 @@ package builder
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
 	"io"
+	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/mna/pigeon/ast"
 )
 
 const codeGeneratedComment = "// Code generated by pigeon; DO NOT EDIT.\n\n"
 
+// ruleIfaceImportName is the name of the import of the package of the
+// interface set by the RuleInterface option.
+const ruleIfaceImportName = "pigeonruleiface"
+
 // generated function templates
 var (
 	onFuncTemplate = `func (%s *current) %s(%s) (any, error) {
@@ -37,6 +48,15 @@ var (
 	return p.cur.%[1]s(%s)
 }
 `
+	callOverridableFuncTemplate = `func (p *parser) call%s() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.actions[%[1]q](&p.cur, %s)
+}
+`
+	actionFuncTemplate = `	%q: func(c *current, args ...any) (any, error) {
+		return c.%[1]s(%s)
+	},`
 	callPredFuncTemplate = `func (p *parser) call%s() (bool, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
@@ -110,69 +130,1963 @@ func BasicLatinLookupTable(basicLatinLookupTable bool) Option {
 	}
 }
 
-// BuildParser builds the PEG parser using the provider grammar. The code is
-// written to the specified w.
-func BuildParser(w io.Writer, g *ast.Grammar, opts ...Option) error {
-	b := &builder{w: w, recvName: "c"}
-	b.setOptions(opts)
-	return b.buildParser(g)
+// RuleErrorMessages returns an option that specifies custom error messages
+// for rules, keyed by rule name. If a rule listed in m fails at the farthest
+// position reached by the parser, its message is reported instead of the
+// list of expected tokens. Every key must be the name of a rule in the
+// grammar.
+func RuleErrorMessages(m map[string]string) Option {
+	return func(b *builder) Option {
+		prev := b.ruleErrorMessages
+		b.ruleErrorMessages = m
+		return RuleErrorMessages(prev)
+	}
 }
 
-type builder struct {
-	w   io.Writer
-	err error
+// FuzzyMatch returns an option that specifies the maximum number of
+// single-character edits (insertion, deletion, substitution or
+// transposition of adjacent characters) that the generated parser may
+// apply to literal matchers when the input does not parse as-is. The
+// parser first tries an exact parse, then retries with up to 1, 2, ...
+// maxErrors edits, and the corrections applied by the successful attempt
+// are available via the Corrections parser option. A value of 0 disables
+// fuzzy matching.
+func FuzzyMatch(maxErrors int) Option {
+	return func(b *builder) Option {
+		prev := b.fuzzyMatch
+		b.fuzzyMatch = maxErrors
+		return FuzzyMatch(prev)
+	}
+}
 
-	// options
-	recvName              string
-	optimize              bool
-	basicLatinLookupTable bool
-	globalState           bool
-	nolint                bool
-	supportLeftRecursion  bool
-	haveLeftRecursion     bool
+// NumericHelpers returns an option that specifies the numericHelpers option.
+// If numericHelpers is true, the int, float and uint methods are generated
+// on the current type. They parse the text of the current match (c.text)
+// and return the value and the strconv error, if any.
+func NumericHelpers(numericHelpers bool) Option {
+	return func(b *builder) Option {
+		prev := b.numericHelpers
+		b.numericHelpers = numericHelpers
+		return NumericHelpers(prev)
+	}
+}
 
-	ruleName    string
-	ruleOffsets map[string]int
-	exprIndex   int
-	argsStack   [][]string
+// LabelSpans returns an option that specifies the labelSpans option.
+// If labelSpans is true, the generated parser records the extent of the
+// input matched by each labeled expression, and action code blocks can
+// retrieve it with c.labelSpan(label).
+func LabelSpans(labelSpans bool) Option {
+	return func(b *builder) Option {
+		prev := b.labelSpans
+		b.labelSpans = labelSpans
+		return LabelSpans(prev)
+	}
+}
 
-	rangeTable bool
+// RunCharClass returns an option that specifies the runCharClass option.
+// If runCharClass is true, a one-or-more or zero-or-more repetition that
+// directly wraps a character class is matched in a single loop instead of
+// one expression per character. The value of such a repetition is then
+// the matched []byte instead of a slice of values.
+func RunCharClass(runCharClass bool) Option {
+	return func(b *builder) Option {
+		prev := b.runCharClass
+		b.runCharClass = runCharClass
+		return RunCharClass(prev)
+	}
 }
 
-func (b *builder) setOptions(opts []Option) {
-	for _, opt := range opts {
-		opt(b)
+// GuardOptimization returns an option that specifies the guardOptimization
+// option. If guardOptimization is true, an and (&) or not (!) predicate
+// that directly wraps a literal or a character class peeks at the input
+// instead of going through the general matching and backtracking code.
+func GuardOptimization(guardOptimization bool) Option {
+	return func(b *builder) Option {
+		prev := b.guardOptimization
+		b.guardOptimization = guardOptimization
+		return GuardOptimization(prev)
 	}
 }
 
-func (b *builder) buildParser(grammar *ast.Grammar) error {
-	haveLeftRecursion, err := PrepareGrammar(grammar)
-	if err != nil {
-		return fmt.Errorf("incorrect grammar: %w", err)
+// LongestMatchDiagnostics returns an option that specifies the
+// longestMatchDiagnostics option. If longestMatchDiagnostics is true, the
+// generated parser, when run in debug mode, also tries the alternatives
+// that follow the one that matched in an ordered choice, and logs those
+// that would have matched a strictly longer input. It has no effect if the
+// parser is optimized, as the debug mode is then not available.
+func LongestMatchDiagnostics(longestMatchDiagnostics bool) Option {
+	return func(b *builder) Option {
+		prev := b.longestMatchDiagnostics
+		b.longestMatchDiagnostics = longestMatchDiagnostics
+		return LongestMatchDiagnostics(prev)
 	}
-	if !b.supportLeftRecursion && haveLeftRecursion {
-		return fmt.Errorf("incorrect grammar: %w", ErrHaveLeftRecursion)
+}
+
+// SortFunctions returns an option that specifies the sortFunctions option.
+// If sortFunctions is true, the functions generated for the code blocks
+// are written sorted by name after the code of all the rules, instead of
+// in the order the rules and expressions are visited, so that the output
+// does not depend on the traversal order of the grammar.
+func SortFunctions(sortFunctions bool) Option {
+	return func(b *builder) Option {
+		prev := b.sortFunctions
+		b.sortFunctions = sortFunctions
+		return SortFunctions(prev)
 	}
-	b.haveLeftRecursion = haveLeftRecursion
+}
 
-	b.writeInit(grammar.Init)
-	b.writeGrammar(grammar)
-	for _, rule := range grammar.Rules {
-		b.writeRuleCode(rule)
+// MemoKeyHook returns an option that specifies the memoKeyHook option.
+// If memoKeyHook is true, the generated parser has a MemoKey option to set
+// a function that adds a discriminator, e.g. a parser mode, to the key of
+// the memoized results. The optimized parser, which does not memoize, is
+// not supported.
+func MemoKeyHook(memoKeyHook bool) Option {
+	return func(b *builder) Option {
+		prev := b.memoKeyHook
+		b.memoKeyHook = memoKeyHook
+		return MemoKeyHook(prev)
 	}
-	b.writeStaticCode()
+}
 
-	return b.err
+// WarnEmptyRepetition returns an option that specifies the
+// warnEmptyRepetition option. If warnEmptyRepetition is true, a warning is
+// written to the warning writer for each repetition of an expression that
+// can match the empty string, see EmptyRepetitionWarnings.
+func WarnEmptyRepetition(warnEmptyRepetition bool) Option {
+	return func(b *builder) Option {
+		prev := b.warnEmptyRepetition
+		b.warnEmptyRepetition = warnEmptyRepetition
+		return WarnEmptyRepetition(prev)
+	}
 }
 
-func (b *builder) writeInit(init *ast.CodeBlock) {
-	if init == nil {
-		return
+// WarnShadowedRecovery returns an option that specifies the
+// warnShadowedRecovery option. If warnShadowedRecovery is true, a warning is
+// written to the warning writer for each recovery expression that can match
+// the same input as the expression it recovers, see
+// ShadowedRecoveryWarnings.
+func WarnShadowedRecovery(warnShadowedRecovery bool) Option {
+	return func(b *builder) Option {
+		prev := b.warnShadowedRecovery
+		b.warnShadowedRecovery = warnShadowedRecovery
+		return WarnShadowedRecovery(prev)
 	}
+}
 
-	// remove opening and closing braces
-	val := codeGeneratedComment + init.Val[1:len(init.Val)-1]
-	b.writelnf("%s", val)
+// WarnShadowedClasses returns an option that specifies the
+// warnShadowedClasses option. If warnShadowedClasses is true, a warning is
+// written to the warning writer for each alternative of a choice expression
+// that can only start with characters that an earlier alternative starting
+// with a character class can also start with, see ShadowedClassWarnings.
+func WarnShadowedClasses(warnShadowedClasses bool) Option {
+	return func(b *builder) Option {
+		prev := b.warnShadowedClasses
+		b.warnShadowedClasses = warnShadowedClasses
+		return WarnShadowedClasses(prev)
+	}
+}
+
+// WarnUnusedLabels returns an option that specifies the warnUnusedLabels
+// option. If warnUnusedLabels is true, a warning is written to the warning
+// writer for each labeled expression whose label is not used by a code
+// block, see UnusedLabelWarnings.
+func WarnUnusedLabels(warnUnusedLabels bool) Option {
+	return func(b *builder) Option {
+		prev := b.warnUnusedLabels
+		b.warnUnusedLabels = warnUnusedLabels
+		return WarnUnusedLabels(prev)
+	}
+}
+
+// WarningWriter returns an option that specifies the writer of the
+// warnings of the Warn options, os.Stderr by default.
+func WarningWriter(w io.Writer) Option {
+	return func(b *builder) Option {
+		prev := b.warnw
+		b.warnw = w
+		return WarningWriter(prev)
+	}
+}
+
+// OverridableActions returns an option that specifies the
+// overridableActions option. If overridableActions is true, the action
+// code blocks are called through a map of functions keyed by the name of
+// their generated function, and the generated parser has a SetAction
+// option to replace them at runtime.
+func OverridableActions(overridableActions bool) Option {
+	return func(b *builder) Option {
+		prev := b.overridableActions
+		b.overridableActions = overridableActions
+		return OverridableActions(prev)
+	}
+}
+
+// RuleTiming returns an option that specifies the ruleTiming option. If
+// ruleTiming is true, the generated parser has a RuleTimes option to
+// accumulate the wall-clock time spent in each rule.
+func RuleTiming(ruleTiming bool) Option {
+	return func(b *builder) Option {
+		prev := b.ruleTiming
+		b.ruleTiming = ruleTiming
+		return RuleTiming(prev)
+	}
+}
+
+// ProgressCallback returns an option that specifies the progressCallback
+// option. If progressCallback is true, the generated parser has a Progress
+// option to report periodically how far in the input it has advanced.
+func ProgressCallback(progressCallback bool) Option {
+	return func(b *builder) Option {
+		prev := b.progressCallback
+		b.progressCallback = progressCallback
+		return ProgressCallback(prev)
+	}
+}
+
+// DedupeCharClasses returns an option that specifies the dedupeCharClasses
+// option. If dedupeCharClasses is true, identical character class matchers
+// are written once as package-level variables, shared by all the
+// expressions that use them.
+func DedupeCharClasses(dedupeCharClasses bool) Option {
+	return func(b *builder) Option {
+		prev := b.dedupeCharClasses
+		b.dedupeCharClasses = dedupeCharClasses
+		return DedupeCharClasses(prev)
+	}
+}
+
+// CaseScopes returns an option that specifies the caseScopes option. If
+// caseScopes is true, the grammar may have case-insensitive regions, e.g.
+// (?i: "select" / "from" ), where the literal and character class
+// matchers are case-insensitive without their own "i" flag.
+func CaseScopes(caseScopes bool) Option {
+	return func(b *builder) Option {
+		prev := b.caseScopes
+		b.caseScopes = caseScopes
+		return CaseScopes(prev)
+	}
+}
+
+// SinkResults returns an option that specifies the sinkResults option. If
+// sinkResults is true, the generated parser has a ParseTo function that
+// pushes each value of the top-level repetition of the entrypoint rule to
+// a sink function as soon as it is parsed.
+func SinkResults(sinkResults bool) Option {
+	return func(b *builder) Option {
+		prev := b.sinkResults
+		b.sinkResults = sinkResults
+		return SinkResults(prev)
+	}
+}
+
+// ExpandIgnoreCaseClasses returns an option that specifies the
+// expandIgnoreCaseClasses option. If expandIgnoreCaseClasses is true, the
+// case-insensitive character classes without Unicode classes are written
+// with all the case variants of their characters and ranges, so that they
+// are matched without converting the case of the input.
+func ExpandIgnoreCaseClasses(expandIgnoreCaseClasses bool) Option {
+	return func(b *builder) Option {
+		prev := b.expandIgnoreCaseClasses
+		b.expandIgnoreCaseClasses = expandIgnoreCaseClasses
+		return ExpandIgnoreCaseClasses(prev)
+	}
+}
+
+// MatcherInterface returns an option that specifies the matcherInterface
+// option. If matcherInterface is true, the expressions of the generated
+// parser implement a matcher interface, which the parser uses to parse
+// them instead of a type switch, so that custom expression types can be
+// added to the grammar. This is a bit slower.
+func MatcherInterface(matcherInterface bool) Option {
+	return func(b *builder) Option {
+		prev := b.matcherInterface
+		b.matcherInterface = matcherInterface
+		return MatcherInterface(prev)
+	}
+}
+
+// UntilEOF returns an option that specifies the untilEOF option. If
+// untilEOF is true, an until expression whose terminator is not found
+// matches up to the end of the input, otherwise it does not match.
+func UntilEOF(untilEOF bool) Option {
+	return func(b *builder) Option {
+		prev := b.untilEOF
+		b.untilEOF = untilEOF
+		return UntilEOF(prev)
+	}
+}
+
+// ComputeFollowSets returns an option that specifies the computeFollowSets
+// option. If computeFollowSets is true, the FIRST and FOLLOW sets of the
+// rules are computed and embedded in the generated parser, which uses them
+// to list the tokens expected at the farthest failure position when the
+// parsing fails.
+func ComputeFollowSets(computeFollowSets bool) Option {
+	return func(b *builder) Option {
+		prev := b.computeFollowSets
+		b.computeFollowSets = computeFollowSets
+		return ComputeFollowSets(prev)
+	}
+}
+
+// StructuredTrace returns an option that specifies the structuredTrace
+// option. If structuredTrace is true, the generated parser has a Trace
+// option to report the rules entered and exited to a TraceLogger
+// interface, e.g. to adapt them to a structured logger.
+func StructuredTrace(structuredTrace bool) Option {
+	return func(b *builder) Option {
+		prev := b.structuredTrace
+		b.structuredTrace = structuredTrace
+		return StructuredTrace(prev)
+	}
+}
+
+// ZeroCopyText returns an option that specifies the zeroCopyText option.
+// If zeroCopyText is true, the generated parser has a textBytes method on
+// the current type, which returns the text of the current match without
+// copying it, for the code blocks that don't need a string.
+func ZeroCopyText(zeroCopyText bool) Option {
+	return func(b *builder) Option {
+		prev := b.zeroCopyText
+		b.zeroCopyText = zeroCopyText
+		return ZeroCopyText(prev)
+	}
+}
+
+// MmapInput returns an option that specifies the mmapInput option. If
+// mmapInput is true, BuildMmap writes the file of the ParseMmap function of
+// the generated parser, which parses a file by mapping it in memory instead
+// of reading it. That file is only built for Unix platforms, as it uses
+// syscall.Mmap, and BuildMmapFallback writes the file of the others.
+func MmapInput(mmapInput bool) Option {
+	return func(b *builder) Option {
+		prev := b.mmapInput
+		b.mmapInput = mmapInput
+		return MmapInput(prev)
+	}
+}
+
+// BatchParse returns an option that specifies the batchParse option. If
+// batchParse is true, the generated parser has a ParseBatch function that
+// parses many inputs concurrently with a pool of goroutines.
+func BatchParse(batchParse bool) Option {
+	return func(b *builder) Option {
+		prev := b.batchParse
+		b.batchParse = batchParse
+		return BatchParse(prev)
+	}
+}
+
+// LosslessCST returns an option that specifies the losslessCST option. If
+// losslessCST is true, the generated parser has a ParseCST function that
+// returns the concrete syntax tree of the input, which retains all the
+// matched input. Its nodes implement io.WriterTo to write the tree back,
+// with the Text of the nodes that were replaced.
+func LosslessCST(losslessCST bool) Option {
+	return func(b *builder) Option {
+		prev := b.losslessCST
+		b.losslessCST = losslessCST
+		return LosslessCST(prev)
+	}
+}
+
+// EmitValidate returns an option that specifies the emitValidate option. If
+// emitValidate is true, the generated parser has a Validate function that
+// matches the input without building the values of the expressions.
+func EmitValidate(emitValidate bool) Option {
+	return func(b *builder) Option {
+		prev := b.emitValidate
+		b.emitValidate = emitValidate
+		return EmitValidate(prev)
+	}
+}
+
+// REPLMode returns an option that specifies the replMode option. If
+// replMode is true, the generated parser has a REPL type that accumulates
+// lines of input until they match the grammar, and reports whether the
+// input is complete, incomplete or in error.
+func REPLMode(replMode bool) Option {
+	return func(b *builder) Option {
+		prev := b.replMode
+		b.replMode = replMode
+		return REPLMode(prev)
+	}
+}
+
+// LocaleFold returns an option that specifies the locale of the case
+// folding of the case-insensitive matchers, in the generated parser and in
+// the case variants of the character classes written by the
+// ExpandIgnoreCaseClasses option. The supported locales are "tr" (Turkish)
+// and "az" (Azeri), where the dotted and dotless i are distinct letters. The
+// empty locale, the default, folds the case as defined by Unicode.
+func LocaleFold(locale string) Option {
+	return func(b *builder) Option {
+		prev := b.localeFold
+		b.localeFold = locale
+		return LocaleFold(prev)
+	}
+}
+
+// localeCases are the special case mappings of the locales supported by
+// the LocaleFold option, and the names of their unicode package variables.
+var localeCases = map[string]struct {
+	name    string
+	mapping unicode.SpecialCase
+}{
+	"az": {"AzeriCase", unicode.AzeriCase},
+	"tr": {"TurkishCase", unicode.TurkishCase},
+}
+
+// ErrorRepair returns an option that specifies the errorRepair option. If
+// errorRepair is true, the generated parser retries a failed parse
+// inserting the literals that are missing where the parsing failed, as if
+// they were in the input, and the insertions applied by the successful
+// attempt are available via the Repairs parser option.
+func ErrorRepair(errorRepair bool) Option {
+	return func(b *builder) Option {
+		prev := b.errorRepair
+		b.errorRepair = errorRepair
+		return ErrorRepair(prev)
+	}
+}
+
+// ErrorSpans returns an option that specifies the errorSpans option. If
+// errorSpans is true, the errors of the generated parser have a Span method
+// that returns the start and end offsets of the input delimiting the error.
+func ErrorSpans(errorSpans bool) Option {
+	return func(b *builder) Option {
+		prev := b.errorSpans
+		b.errorSpans = errorSpans
+		return ErrorSpans(prev)
+	}
+}
+
+// ExpvarMetrics returns an option that specifies the namespace of the
+// parser metrics. If namespace is not empty, the generated parser publishes
+// the number of parses, of errors, of memoized results looked up and found,
+// their total duration and the average duration and memo hit rate derived
+// from them with the expvar package, in a map with that name.
+func ExpvarMetrics(namespace string) Option {
+	return func(b *builder) Option {
+		prev := b.expvarMetrics
+		b.expvarMetrics = namespace
+		return ExpvarMetrics(prev)
+	}
+}
+
+// OptimizeRules returns an option that specifies the rules of the grammar
+// that are optimized. The generated parser matches those rules without
+// debug output nor memoization, even if the Debug or Memoize options are
+// set when parsing, while the other rules keep their instrumentation. Every
+// name must be the name of a rule in the grammar. It has no effect if the
+// Optimize option is set, as the whole parser is optimized.
+func OptimizeRules(names []string) Option {
+	return func(b *builder) Option {
+		prev := b.optimizeRules
+		b.optimizeRules = names
+		return OptimizeRules(prev)
+	}
+}
+
+// OptimizeRulesExcept returns an option that specifies the
+// optimizeRulesExcept option. If optimizeRulesExcept is true, the rules
+// listed with the OptimizeRules option are the only rules that are not
+// optimized.
+func OptimizeRulesExcept(optimizeRulesExcept bool) Option {
+	return func(b *builder) Option {
+		prev := b.optimizeRulesExcept
+		b.optimizeRulesExcept = optimizeRulesExcept
+		return OptimizeRulesExcept(prev)
+	}
+}
+
+// AutoMapResults returns an option that specifies the autoMapResults
+// option. If autoMapResults is true, the result of the alternatives of the
+// rules that have no action is built from their expressions labeled item,
+// key, value or text: a []any of the items, a map[string]any of the values
+// keyed by the keys, or the string matched by the text expression.
+func AutoMapResults(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.autoMapResults
+		b.autoMapResults = enable
+		return AutoMapResults(prev)
+	}
+}
+
+// EmitRuleDocs returns an option that specifies the emitRuleDocs option.
+// If emitRuleDocs is true, the documentation of the rules, the line
+// comments that precede them in the grammar, is added to the generated
+// parser and returned by its RuleDoc function.
+func EmitRuleDocs(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.emitRuleDocs
+		b.emitRuleDocs = enable
+		return EmitRuleDocs(prev)
+	}
+}
+
+// EmitReprinter returns an option that specifies the emitReprinter option.
+// If emitReprinter is true, the generated parser has a Reprint function
+// that returns the exact input matched by a node of a syntax tree that
+// knows its span in the input, such as the nodes of the concrete syntax
+// tree built with the LosslessCST option.
+func EmitReprinter(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.emitReprinter
+		b.emitReprinter = enable
+		return EmitReprinter(prev)
+	}
+}
+
+// TableDriven returns an option that specifies the tableDriven option. If
+// tableDriven is true, the expressions of the rules are compiled to a flat
+// table of instructions that the generated parser interprets, instead of a
+// tree of expression structs. The table-driven parser does not support the
+// recovery, throw, until, lookbehind, balanced, scan limit, line start and
+// assertion expressions.
+func TableDriven(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.tableDriven
+		b.tableDriven = enable
+		return TableDriven(prev)
+	}
+}
+
+// ModeAwareMemo returns an option that specifies the modeAwareMemo option.
+// If modeAwareMemo is true, a state code block that runs starts a new mode
+// generation, and the memoized results are keyed by the generation in which
+// they were parsed, so that those memoized before a change of the mode of
+// the parser are ignored. The generation is stored in the state, so it is
+// restored with it when the parser backtracks. The optimized parser, which
+// does not memoize, is not supported.
+func ModeAwareMemo(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.modeAwareMemo
+		b.modeAwareMemo = enable
+		return ModeAwareMemo(prev)
+	}
+}
+
+// GrammarLineMap returns an option that specifies the grammarLineMap
+// option. If grammarLineMap is true, the generated parser has a
+// GrammarLines function that returns the range of lines of each rule in
+// the grammar, e.g. to map the coverage of the rules back to the grammar.
+func GrammarLineMap(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.grammarLineMap
+		b.grammarLineMap = enable
+		return GrammarLineMap(prev)
+	}
+}
+
+// BoundedStream returns an option that specifies the size in bytes of the
+// window of input that the generated parser keeps behind its current
+// position when parsing a stream with the generated ParseStream function.
+// The input is read as the parsing progresses, and the parsing fails if it
+// must go back further than the window. A value of 0 disables the
+// ParseStream function.
+func BoundedStream(window int) Option {
+	return func(b *builder) Option {
+		prev := b.boundedStream
+		b.boundedStream = window
+		return BoundedStream(prev)
+	}
+}
+
+// ChoiceIndex returns an option that specifies the choiceIndex option. If
+// choiceIndex is true, the action code blocks of the generated parser can
+// call c.choiceIndex() to get the index of the alternative that matched in
+// the choice expression of the action.
+func ChoiceIndex(choiceIndex bool) Option {
+	return func(b *builder) Option {
+		prev := b.choiceIndex
+		b.choiceIndex = choiceIndex
+		return ChoiceIndex(prev)
+	}
+}
+
+// ValidateActions returns an option that specifies the validateActions
+// option. If validateActions is true, the code blocks of the grammar are
+// parsed as Go code when the parser is generated, and a syntax error is
+// reported at its position in the grammar, instead of when the generated
+// parser is compiled.
+func ValidateActions(validateActions bool) Option {
+	return func(b *builder) Option {
+		prev := b.validateActions
+		b.validateActions = validateActions
+		return ValidateActions(prev)
+	}
+}
+
+// CompactAST returns an option that specifies the compactAST option. If
+// compactAST is true, the generated parser has a ParseCompact function that
+// returns the concrete syntax tree of the input, with the nodes of the tree
+// built by the LosslessCST option, but stored in a single slice and linked
+// by index.
+func CompactAST(compactAST bool) Option {
+	return func(b *builder) Option {
+		prev := b.compactAST
+		b.compactAST = compactAST
+		return CompactAST(prev)
+	}
+}
+
+// BaseGrammar returns an option that specifies the grammar that the
+// grammar of the parser extends. If base is not nil, its rules are merged
+// with the rules of the grammar before the parser is generated, the rules
+// of the grammar overriding the rules of base with the same name, see
+// ast.ExtendGrammar.
+func BaseGrammar(base *ast.Grammar) Option {
+	return func(b *builder) Option {
+		prev := b.baseGrammar
+		b.baseGrammar = base
+		return BaseGrammar(prev)
+	}
+}
+
+// OptimizeGrammar returns an option that specifies the optimizeGrammar
+// option. If optimizeGrammar is true, the grammar is optimized with
+// ast.Optimize before the parser is generated, the rules of the
+// alternate entrypoints and of the prefix dispatch being kept.
+func OptimizeGrammar(optimizeGrammar bool) Option {
+	return func(b *builder) Option {
+		prev := b.optimizeGrammar
+		b.optimizeGrammar = optimizeGrammar
+		return OptimizeGrammar(prev)
+	}
+}
+
+// AlternateEntrypoints returns an option that specifies the rules that
+// may be used as entrypoints besides the first rule, which must exist in
+// the grammar and are not removed by the OptimizeGrammar option.
+func AlternateEntrypoints(rules []string) Option {
+	return func(b *builder) Option {
+		prev := b.altEntrypoints
+		b.altEntrypoints = rules
+		return AlternateEntrypoints(prev)
+	}
+}
+
+// MethodReceiver returns an option that specifies the methodReceiver
+// option. If typeName is not empty, the Parse, ParseFile and ParseReader
+// entrypoints of the generated parser (and ParseMmap and ParseStream if
+// they are generated) are methods of the pointer to the type typeName
+// instead of functions, so that the parsing behavior can be embedded in a
+// larger type. The type must be defined in the package of the parser, e.g.
+// in the initializer of the grammar. The code blocks get the receiver of
+// the method with c.recv, so that they can access its fields.
+func MethodReceiver(typeName string) Option {
+	return func(b *builder) Option {
+		prev := b.methodReceiver
+		b.methodReceiver = typeName
+		return MethodReceiver(prev)
+	}
+}
+
+// ReportMaxDepth returns an option that specifies the reportMaxDepth
+// option. If reportMaxDepth is true, the generated parser has a
+// ReachedDepth option that records the deepest nesting of rules and of the
+// value stack reached while parsing, to tune the MaxVStackDepth limit.
+func ReportMaxDepth(reportMaxDepth bool) Option {
+	return func(b *builder) Option {
+		prev := b.reportMaxDepth
+		b.reportMaxDepth = reportMaxDepth
+		return ReportMaxDepth(prev)
+	}
+}
+
+// SubParse returns an option that specifies the subParse option. If
+// subParse is true, the generated parser has a ParseSub function that
+// parses its input from an offset with a rule, without having to match
+// until the end of input, and returns the offset where the match ends.
+func SubParse(subParse bool) Option {
+	return func(b *builder) Option {
+		prev := b.subParse
+		b.subParse = subParse
+		return SubParse(prev)
+	}
+}
+
+// BacktrackHooks returns an option that specifies the backtrackHooks
+// option. If backtrackHooks is true, the actions of the generated parser
+// can register functions with c.onBacktrack, which the parser calls if it
+// backtracks over the match of the action, e.g. to release a resource
+// that the action acquired.
+func BacktrackHooks(backtrackHooks bool) Option {
+	return func(b *builder) Option {
+		prev := b.backtrackHooks
+		b.backtrackHooks = backtrackHooks
+		return BacktrackHooks(prev)
+	}
+}
+
+// SwitchDispatch returns an option that specifies the switchDispatch
+// option. If switchDispatch is true, each action, predicate and state code
+// block of the generated parser has an integer code, and the parser calls
+// its function by a switch on its code instead of through a method value
+// stored in the grammar.
+func SwitchDispatch(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.switchDispatch
+		b.switchDispatch = enable
+		return SwitchDispatch(prev)
+	}
+}
+
+// StreamBuffer returns an option that specifies the size of the buffer of
+// the channel of the values pushed by the generated ParseChan function. If
+// it is greater than 0, the generated parser has a ParseChan function that
+// runs ParseTo in a goroutine and sends the values to a channel with a
+// buffer of this size, so that a consumer slower than the parser blocks it
+// instead of the values accumulating in memory. It requires the
+// sinkResults option. A value of 0 disables the ParseChan function.
+func StreamBuffer(n int) Option {
+	return func(b *builder) Option {
+		prev := b.streamBuffer
+		b.streamBuffer = n
+		return StreamBuffer(prev)
+	}
+}
+
+// RuleAssertions returns an option that specifies assertions on the values
+// of rules, keyed by rule name. Each assertion is a Go boolean expression
+// over the value of the rule, named v, e.g. "v.(int) >= 0", that the
+// generated parser checks each time the rule matches. If it is false, the
+// parser adds an error with the assertion and the value, so that a grammar
+// change that breaks an invariant of its results is caught by its tests.
+// The assertions are not generated in the optimized parser. Every key must
+// be the name of a rule in the grammar.
+func RuleAssertions(m map[string]string) Option {
+	return func(b *builder) Option {
+		prev := b.ruleAssertions
+		b.ruleAssertions = m
+		return RuleAssertions(prev)
+	}
+}
+
+// EmitResultDiff returns an option that specifies the emitResultDiff
+// option. If emitResultDiff is true, the generated parser has a
+// DiffResults function that compares two parse results and returns their
+// differences, e.g. to see the impact of a change of the grammar on the
+// results of the parsing of the same inputs.
+func EmitResultDiff(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.emitResultDiff
+		b.emitResultDiff = enable
+		return EmitResultDiff(prev)
+	}
+}
+
+// BidiAware returns an option that specifies the bidiAware option. If
+// bidiAware is true, the errors of the generated parser have a Direction
+// method that returns the direction, left-to-right or right-to-left, of
+// the text around the position of the error, so that a tool displaying
+// the error in text mixing scripts of both directions, e.g. Latin and
+// Hebrew, places the caret on the correct side of the character. Only the
+// errors have a direction, which is given by the scripts of the letters
+// around the position instead of the Unicode bidirectional algorithm, so
+// that the generated parser only depends on the standard library.
+func BidiAware(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.bidiAware
+		b.bidiAware = enable
+		return BidiAware(prev)
+	}
+}
+
+// Preprocessors returns an option that specifies the preprocessors option.
+// If preprocessors is true, the generated parser has a Preprocess option
+// that registers preprocessing stages run on the input before parsing it,
+// e.g. to strip the comments, and each stage returns a map of the offsets
+// of its output to those of its input, so that the errors report their
+// position in the original input.
+func Preprocessors(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.preprocessors
+		b.preprocessors = enable
+		return Preprocessors(prev)
+	}
+}
+
+// EmitMustParse returns an option that specifies the emitMustParse option.
+// If emitMustParse is true, the generated parser has a MustParse function
+// that is like Parse but panics if the input cannot be parsed, like
+// regexp.MustCompile.
+func EmitMustParse(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.emitMustParse
+		b.emitMustParse = enable
+		return EmitMustParse(prev)
+	}
+}
+
+// ChromeTrace returns an option that specifies the chromeTrace option. If
+// chromeTrace is true, the generated parser has a ChromeTrace option to
+// write the rules entered and exited in the Chrome tracing JSON format,
+// for chrome://tracing or Perfetto.
+func ChromeTrace(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.chromeTrace
+		b.chromeTrace = enable
+		return ChromeTrace(prev)
+	}
+}
+
+// PrefixDispatch returns an option that specifies the entrypoints selected
+// by the prefix of the input, keyed by the prefix. If the input of the
+// generated parser starts with one of the prefixes, the parsing starts at
+// its rule instead of the first rule, the longest prefix winning. An
+// entrypoint set to another rule by the Entrypoint option of the generated
+// parser takes precedence.
+func PrefixDispatch(m map[string]string) Option {
+	return func(b *builder) Option {
+		prev := b.prefixDispatch
+		b.prefixDispatch = m
+		return PrefixDispatch(prev)
+	}
+}
+
+// ASCIIFastPath returns an option that specifies the asciiFastPath option.
+// If asciiFastPath is true, the generated parser checks whether the input
+// is only made of ASCII characters when it starts parsing, and if it is,
+// reads its runes byte by byte instead of decoding them, matches the
+// character classes with a lookup table and does not allocate the values
+// of the matches of a single character, which are shared and must not be
+// modified. Other inputs are read as usual, the positions are byte offsets
+// in both cases.
+func ASCIIFastPath(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.asciiFastPath
+		b.asciiFastPath = enable
+		return ASCIIFastPath(prev)
+	}
+}
+
+// TypedThrows returns an option that specifies the typedThrows option. If
+// typedThrows is true, the throw expressions of the grammar may have a
+// code block, e.g. %{ErrNum { return Diag{Msg: "bad number"}, nil }},
+// whose value is thrown with the failure label as the payload of a
+// ThrowError. The recovery expressions get the throw they recover with
+// c.thrown(), and a throw with a payload that is not recovered is reported
+// as a parsing error.
+func TypedThrows(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.typedThrows
+		b.typedThrows = enable
+		return TypedThrows(prev)
+	}
+}
+
+// EmitBenchmarks returns an option that specifies the emitBenchmarks
+// option. If emitBenchmarks is true, BuildBenchmarks writes a benchmark
+// test file for the generated parser, with a Benchmark function for its
+// entrypoint that parses small, medium and large inputs synthesized from
+// the grammar and the files of the BenchmarkInputs option. It has no
+// effect on BuildParser.
+func EmitBenchmarks(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.emitBenchmarks
+		b.emitBenchmarks = enable
+		return EmitBenchmarks(prev)
+	}
+}
+
+// BenchmarkInputs returns an option that specifies the files parsed by the
+// benchmark of the EmitBenchmarks option, in addition to the synthesized
+// inputs. The paths are read when the benchmark runs, relative to the
+// package of the generated parser.
+func BenchmarkInputs(paths []string) Option {
+	return func(b *builder) Option {
+		prev := b.benchInputs
+		b.benchInputs = paths
+		return BenchmarkInputs(prev)
+	}
+}
+
+// RuntimeParams returns an option that specifies the runtimeParams option.
+// If runtimeParams is true, the rule references of the grammar may pass
+// arguments to the rule they reference when it is matched, e.g.
+// Items(count=n) passes the value of the label n as the count argument,
+// and Items(count={ return n.(int) * 2, nil }) the value returned by the
+// code block. The code blocks of the referenced rule, and of the rules it
+// references in turn, get the value of an argument with c.param. The
+// results of the rules matched with arguments are not memoized.
+func RuntimeParams(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.runtimeParams
+		b.runtimeParams = enable
+		return RuntimeParams(prev)
+	}
+}
+
+// EmptyInputMode returns an option that specifies how the generated parser
+// handles an empty input. The supported modes are "error", where parsing an
+// empty input fails with the ErrEmptyInput error without matching the
+// entrypoint, "nil", where it returns a nil result and no error without
+// matching the entrypoint, and "normal", the default, where the empty input
+// is matched as any other input.
+func EmptyInputMode(mode string) Option {
+	return func(b *builder) Option {
+		prev := b.emptyInputMode
+		b.emptyInputMode = mode
+		return EmptyInputMode(prev)
+	}
+}
+
+// LegacyGoCompat returns an option that specifies the legacyGoCompat
+// option. If legacyGoCompat is true, the generated parser uses
+// interface{} instead of the predeclared any type, which was added in Go
+// 1.18, so that it compiles with older Go versions. The references to any
+// in the code blocks of the grammar are replaced too.
+func LegacyGoCompat(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.legacyGoCompat
+		b.legacyGoCompat = enable
+		return LegacyGoCompat(prev)
+	}
+}
+
+// LeftRecursiveRules returns an option that specifies the rules of the
+// grammar that are intentionally left-recursive. If names is not empty,
+// the generated parser grows the seed of the left recursion of those
+// rules only, as with the SupportLeftRecursion option, and the other
+// rules must not be left-recursive: the left recursion that is not
+// listed is an error, even if the SupportLeftRecursion option is set. A
+// listed rule that is not found to be left-recursive is matched as a
+// left-recursive rule anyway. Every name must be the name of a rule in the
+// grammar.
+func LeftRecursiveRules(names []string) Option {
+	return func(b *builder) Option {
+		prev := b.leftRecursiveRules
+		b.leftRecursiveRules = names
+		return LeftRecursiveRules(prev)
+	}
+}
+
+// ProtoResults returns an option that specifies the name of the protobuf
+// message of the parse results. If messageName is not empty, the
+// generated parser has a type of this name mirroring the message, which
+// describes the generic results of the expressions without action: the
+// []byte text of the matchers, the []any list of the results of the
+// sequences and repetitions, and nil. It also has the following functions
+// and methods:
+//
+//	func ToProto(v any) (*messageName, error)
+//	func FromProto(m *messageName) any
+//	func (m *messageName) MarshalBinary() ([]byte, error)
+//	func (m *messageName) UnmarshalBinary(data []byte) error
+//
+// ToProto and FromProto convert between the parse results and the
+// messages, and MarshalBinary and UnmarshalBinary encode the messages in
+// the protobuf wire format, so that the results can be exchanged with the
+// programs that use the .proto file written by BuildProto. The message
+// name must be an exported Go identifier.
+func ProtoResults(messageName string) Option {
+	return func(b *builder) Option {
+		prev := b.protoResults
+		b.protoResults = messageName
+		return ProtoResults(prev)
+	}
+}
+
+// GraphemeInput returns an option that specifies the graphemeInput
+// option. If graphemeInput is true, the generated parser has a ParseRunes
+// function that parses an already decoded []rune over its grapheme
+// clusters: the any matcher matches a whole user-perceived character,
+// e.g. an emoji with a skin tone modifier, and the columns of the
+// positions count the grapheme clusters. The other matchers still match
+// runes.
+func GraphemeInput(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.graphemeInput
+		b.graphemeInput = enable
+		return GraphemeInput(prev)
+	}
+}
+
+// EmbedSelfTest returns an option that specifies the inputs embedded in
+// the generated parser, and whether each of them must parse. If inputs is
+// not empty, the generated parser has a SelfTest function that parses
+// the inputs and returns an error if one of them does not parse or fail
+// as expected, e.g. to catch the regressions of the grammar against a
+// curated corpus baked into the binary.
+func EmbedSelfTest(inputs map[string]bool) Option {
+	return func(b *builder) Option {
+		prev := b.selfTestInputs
+		b.selfTestInputs = inputs
+		return EmbedSelfTest(prev)
+	}
+}
+
+// ExplicitStack returns an option that specifies the explicitStack
+// option. If explicitStack is true, the expressions of the rules are
+// compiled to the table of the TableDriven option, which is implied, and
+// the generated parser runs its instructions with an explicit work stack
+// instead of recursive calls, so that deeply nested inputs cannot overflow
+// the Go stack.
+//
+// It does not support the options that the table-driven parser does not
+// support, nor those that wrap the matching of the rules or depend on the
+// Go call stack: the left recursion, the optimized rules, the rule timing,
+// the Chrome and structured traces, the memo key hook, the mode-aware
+// memoization, the backtrack hooks and the runtime parameters. The parsers
+// generated with those options can limit the nesting of their inputs with
+// the MaxVStackDepth parser option.
+func ExplicitStack(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.explicitStack
+		b.explicitStack = enable
+		return ExplicitStack(prev)
+	}
+}
+
+// MemoReuseTrace returns an option that specifies the memoReuseTrace
+// option. If memoReuseTrace is true, the generated parser has a
+// MemoReuses option that records each memoized result reused by the
+// parser, with the rule and the position where it was reused, e.g. to see
+// where memoization saves work. It is not supported by the optimized
+// parser, which does not memoize the results.
+func MemoReuseTrace(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.memoReuseTrace
+		b.memoReuseTrace = enable
+		return MemoReuseTrace(prev)
+	}
+}
+
+// LineRecovery returns an option that specifies the lineRecovery option.
+// If lineRecovery is true, the generated parser has a ParseLines function
+// that parses its input as a sequence of items matched by the entrypoint
+// rule and, when an item fails, skips the input up to the next newline
+// and resumes parsing on the next line. It returns the values of the
+// items that matched and the errors of those that failed.
+func LineRecovery(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.lineRecovery
+		b.lineRecovery = enable
+		return LineRecovery(prev)
+	}
+}
+
+// WasmExports returns an option that specifies the wasmExports option. If
+// wasmExports is true, the generated parser has a ParseJSON function that
+// takes a JSON request and returns a JSON response, and BuildWasm writes
+// the file that exposes it to JavaScript when the parser is compiled to
+// WebAssembly.
+func WasmExports(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.wasmExports
+		b.wasmExports = enable
+		return WasmExports(prev)
+	}
+}
+
+// EnumerateParses returns an option that specifies the enumerateParses
+// option. If maxParses is greater than 0, the generated parser has a
+// ParseAll function that tries all the alternatives of the choices,
+// instead of only the first one that matches, and returns up to maxParses
+// distinct values of the successful parses, e.g. to find the ambiguities
+// of a grammar. The number of parses is exponential in the number of
+// choices, maxParses bounds the values returned but not the parses that
+// fail. A value of 0 disables the enumeration.
+func EnumerateParses(maxParses int) Option {
+	return func(b *builder) Option {
+		prev := b.enumerateParses
+		b.enumerateParses = maxParses
+		return EnumerateParses(prev)
+	}
+}
+
+// CompletionSupport returns an option that specifies the
+// completionSupport option. If completionSupport is true, the generated
+// parser has a Suggest function that returns the tokens expected at a
+// position of the input, e.g. to implement the autocompletion of an
+// editor. Combined with the ComputeFollowSets option, the tokens come from
+// the FIRST and FOLLOW sets of the rules that fail at that position.
+func CompletionSupport(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.completionSupport
+		b.completionSupport = enable
+		return CompletionSupport(prev)
+	}
+}
+
+// BuiltinAssertions returns an option that specifies the builtinAssertions
+// option. If builtinAssertions is true, the grammar may have the builtin
+// zero-width assertions \b, for a word boundary, and $, for a line end,
+// which the generated parser matches without consuming any input,
+// instead of predicates written in code blocks.
+func BuiltinAssertions(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.builtinAssertions
+		b.builtinAssertions = enable
+		return BuiltinAssertions(prev)
+	}
+}
+
+// DenseMemo returns an option that specifies the denseMemo option. If
+// denseMemo is true, the generated parser memoizes the results of the
+// rules in a dense table indexed by rule and input offset, instead of
+// maps, so that a lookup does not hash its key. The table has an entry
+// for each rule at each offset, the parser falls back to the maps when it
+// would have more than denseMemoMaxEntries entries, so it is meant for
+// grammars with few rules and moderate inputs. The results of the other
+// expressions are memoized in the maps.
+func DenseMemo(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.denseMemo
+		b.denseMemo = enable
+		return DenseMemo(prev)
+	}
+}
+
+// AssertEntrypointType returns an option that specifies the type of the
+// values returned by the entrypoint rule. If typeName is not empty, the
+// generated parser has a ParseTyped function that returns the result of
+// Parse as a typeName, and an error if the code blocks of the entrypoint
+// return a value of another type. The typeName is a Go type expression,
+// e.g. *Node or []Stmt, of the package of the parser.
+func AssertEntrypointType(typeName string) Option {
+	return func(b *builder) Option {
+		prev := b.entrypointType
+		b.entrypointType = typeName
+		return AssertEntrypointType(prev)
+	}
+}
+
+// RuleBoundaryPositions returns an option that specifies the
+// ruleBoundaryPositions option. If ruleBoundaryPositions is true, the
+// generated parser has a RulePositions option that stores the start and
+// end positions of the rules in the result of the parsing, but not of the
+// expressions inside the rules. The results are not memoized when the
+// positions are stored, and left recursion is not supported.
+func RuleBoundaryPositions(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.ruleBoundaryPositions
+		b.ruleBoundaryPositions = enable
+		return RuleBoundaryPositions(prev)
+	}
+}
+
+// LazyRuleInit returns an option that specifies the lazyRuleInit option.
+// If lazyRuleInit is true, the expressions of the rules of the generated
+// parser are built by a function of each rule the first time the rule is
+// matched, instead of when the program starts, so that the programs that
+// only use some of the rules of a large grammar start faster. The
+// table-driven parser is not supported.
+func LazyRuleInit(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.lazyRuleInit
+		b.lazyRuleInit = enable
+		return LazyRuleInit(prev)
+	}
+}
+
+// ExplainFailures returns an option that specifies the explainFailures
+// option. If explainFailures is true, the error of a parse that fails
+// because the input does not match has an Explanation method that
+// describes the farthest failure in prose, with the rules being matched,
+// the tokens expected and the rule matched just before. The explicit
+// stack and the bounded stream are not supported.
+func ExplainFailures(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.explainFailures
+		b.explainFailures = enable
+		return ExplainFailures(prev)
+	}
+}
+
+// PushParser returns an option that specifies the pushParser option. If
+// pushParser is true, the generated parser has a PushParser type whose
+// Feed method is called with the chunks of the input as they arrive, and
+// parses the items matched by the entrypoint rule as soon as they are
+// complete, while its Flush method ends the input and returns the values
+// of the items. The method receiver, the error spans and the bounded
+// stream are not supported.
+func PushParser(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.pushParser
+		b.pushParser = enable
+		return PushParser(prev)
+	}
+}
+
+// UserData returns an option that specifies the userData option. If
+// userData is true, the generated parser has a UserData option to attach
+// a value to the parse, e.g. its configuration, which the code blocks get
+// with c.data(), instead of reading it from a global variable or from the
+// receiver of the MethodReceiver option.
+func UserData(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.userData
+		b.userData = enable
+		return UserData(prev)
+	}
+}
+
+// OTelSpans returns an option that specifies the otelSpans option. If
+// otelSpans is true, the generated parser has a WithContext option to
+// parse in a context, and a ContextWithTracer function to add a tracer to
+// a context, so that the parses in this context are wrapped in spans
+// recording the size of the input and whether the parse failed. The
+// tracer is an interface of the generated parser, which an OpenTelemetry
+// tracer can be adapted to.
+func OTelSpans(enable bool) Option {
+	return func(b *builder) Option {
+		prev := b.otelSpans
+		b.otelSpans = enable
+		return OTelSpans(prev)
+	}
+}
+
+// RuleInterface returns an option that specifies the interface that the
+// generated rule type must implement, by the import path of its package and
+// its name. If importPath is not empty, the generated parser imports this
+// package, asserts that its rule type implements the interface, and has the
+// following methods on its rule type and function:
+//
+//	func (r *rule) Name() string
+//	func (r *rule) DisplayName() string
+//	func (r *rule) Position() (line, col, offset int)
+//	func Rules() []Interface
+//
+// If the EmitRuleDocs option is set, the rule type also has the method:
+//
+//	func (r *rule) Doc() string
+//
+// If the GrammarLineMap option is set, the rule type also has the method:
+//
+//	func (r *rule) Lines() (start, end int)
+//
+// The interface may have any subset of those methods.
+func RuleInterface(importPath, ifaceName string) Option {
+	return func(b *builder) Option {
+		prevPath, prevName := b.ruleIfacePath, b.ruleIfaceName
+		b.ruleIfacePath, b.ruleIfaceName = importPath, ifaceName
+		return RuleInterface(prevPath, prevName)
+	}
+}
+
+// BuildParser builds the PEG parser using the provider grammar. The code is
+// written to the specified w.
+func BuildParser(w io.Writer, g *ast.Grammar, opts ...Option) error {
+	b := &builder{w: w, warnw: os.Stderr, recvName: "c"}
+	b.setOptions(opts)
+	return b.buildParser(g)
+}
+
+type builder struct {
+	w     io.Writer
+	warnw io.Writer
+	err   error
+
+	// options
+	recvName                string
+	optimize                bool
+	basicLatinLookupTable   bool
+	globalState             bool
+	lineStart               bool
+	nolint                  bool
+	supportLeftRecursion    bool
+	haveLeftRecursion       bool
+	ruleErrorMessages       map[string]string
+	fuzzyMatch              int
+	numericHelpers          bool
+	labelSpans              bool
+	runCharClass            bool
+	runCharClassUsed        bool
+	guardOptimization       bool
+	guardExprUsed           bool
+	longestMatchDiagnostics bool
+	sortFunctions           bool
+	memoKeyHook             bool
+	warnEmptyRepetition     bool
+	warnUnusedLabels        bool
+	warnShadowedRecovery    bool
+	warnShadowedClasses     bool
+	overridableActions      bool
+	ruleTiming              bool
+	progressCallback        bool
+	dedupeCharClasses       bool
+	caseScopes              bool
+	sinkResults             bool
+	expandIgnoreCaseClasses bool
+	matcherInterface        bool
+	untilEOF                bool
+	untilExprUsed           bool
+	lookbehindExprUsed      bool
+	assertionExprUsed       bool
+	computeFollowSets       bool
+	structuredTrace         bool
+	zeroCopyText            bool
+	mmapInput               bool
+	batchParse              bool
+	losslessCST             bool
+	emitValidate            bool
+	validateValues          bool
+	replMode                bool
+	localeFold              string
+	errorSpans              bool
+	errorRepair             bool
+	expvarMetrics           string
+	optimizeRules           []string
+	optimizeRulesExcept     bool
+	autoMapResults          bool
+	emitRuleDocs            bool
+	emitReprinter           bool
+	tableDriven             bool
+	modeAwareMemo           bool
+	grammarLineMap          bool
+	boundedStream           int
+	choiceIndex             bool
+	validateActions         bool
+	compactAST              bool
+	baseGrammar             *ast.Grammar
+	optimizeGrammar         bool
+	altEntrypoints          []string
+	methodReceiver          string
+	reportMaxDepth          bool
+	subParse                bool
+	backtrackHooks          bool
+	switchDispatch          bool
+	streamBuffer            int
+	ruleAssertions          map[string]string
+	emitResultDiff          bool
+	bidiAware               bool
+	preprocessors           bool
+	emitMustParse           bool
+	chromeTrace             bool
+	prefixDispatch          map[string]string
+	asciiFastPath           bool
+	typedThrows             bool
+	emitBenchmarks          bool
+	benchInputs             []string
+	runtimeParams           bool
+	emptyInputMode          string
+	legacyGoCompat          bool
+	leftRecursiveRules      []string
+	protoResults            string
+	graphemeInput           bool
+	selfTestInputs          map[string]bool
+	explicitStack           bool
+	memoReuseTrace          bool
+	lineRecovery            bool
+	wasmExports             bool
+	enumerateParses         int
+	completionSupport       bool
+	builtinAssertions       bool
+	denseMemo               bool
+	entrypointType          string
+	ruleBoundaryPositions   bool
+	lazyRuleInit            bool
+	explainFailures         bool
+	pushParser              bool
+	userData                bool
+	otelSpans               bool
+	balancedExprUsed        bool
+	scanLimitUsed           bool
+	ruleIfacePath           string
+	ruleIfaceName           string
+
+	ruleName    string
+	ruleOffsets map[string]int
+	exprIndex   int
+	argsStack   [][]string
+	// true while writing the expressions of a case-insensitive region
+	ignoreCase bool
+	// special case mapping of the locale of the localeFold option, nil for
+	// the default case folding
+	localeCase unicode.SpecialCase
+	// rules matched without debug output nor memoization, by name, if
+	// optimizeRules is set
+	optimizedRules map[string]bool
+	// FIRST and FOLLOW sets of the rules, if computeFollowSets is set
+	ruleSets map[string]*ruleSets
+	// code of the generated functions, if sortFunctions is set
+	funcs []generatedFunc
+	// entries of the map of the action functions, if overridableActions
+	// is set
+	actionFuncs []generatedFunc
+	// variables of the shared character class matchers, and their names by
+	// key, if dedupeCharClasses is set
+	charClassVars  []generatedFunc
+	charClassNames map[string]string
+	// compiler of the instructions of the rules, if tableDriven is set
+	table *tableCompiler
+	// names of the functions of the actions, predicates and state code
+	// blocks by code, if switchDispatch is set
+	actionCodes []string
+	predCodes   []string
+	stateCodes  []string
+
+	rangeTable bool
+}
+
+func (b *builder) setOptions(opts []Option) {
+	for _, opt := range opts {
+		opt(b)
+	}
+}
+
+func (b *builder) buildParser(grammar *ast.Grammar) error {
+	if b.baseGrammar != nil {
+		if err := ast.ExtendGrammar(grammar, b.baseGrammar); err != nil {
+			return fmt.Errorf("incorrect grammar: %w", err)
+		}
+	}
+	if err := ast.InstantiateRules(grammar); err != nil {
+		return fmt.Errorf("incorrect grammar: %w", err)
+	}
+	if err := ast.ExpandMacros(grammar); err != nil {
+		return fmt.Errorf("incorrect grammar: %w", err)
+	}
+	if err := b.checkAlternateEntrypoints(grammar); err != nil {
+		return err
+	}
+	if b.optimizeGrammar {
+		// the rules selected by prefix are entrypoints too
+		entrypoints := append([]string{}, b.altEntrypoints...)
+		for _, rule := range b.prefixDispatch {
+			entrypoints = append(entrypoints, rule)
+		}
+		ast.Optimize(grammar, entrypoints...)
+	}
+	haveLeftRecursion, err := PrepareGrammar(grammar)
+	if err != nil {
+		return fmt.Errorf("incorrect grammar: %w", err)
+	}
+	if len(b.leftRecursiveRules) > 0 {
+		if err := b.markLeftRecursiveRules(grammar); err != nil {
+			return err
+		}
+		haveLeftRecursion = true
+	} else if !b.supportLeftRecursion && haveLeftRecursion {
+		return fmt.Errorf("incorrect grammar: %w", ErrHaveLeftRecursion)
+	}
+	b.haveLeftRecursion = haveLeftRecursion
+	if b.fuzzyMatch < 0 {
+		return fmt.Errorf("invalid fuzzy match maximum number of errors: %d", b.fuzzyMatch)
+	}
+	if b.errorRepair && b.fuzzyMatch > 0 {
+		return errors.New("error repair: the fuzzy match option is not supported")
+	}
+	if b.backtrackHooks {
+		// the left recursion relies on memoized results, and the
+		// diagnostics of the longest match run the actions of the choices
+		// again
+		if b.haveLeftRecursion {
+			return errors.New("backtrack hooks: left recursion is not supported")
+		}
+		if b.longestMatchDiagnostics {
+			return errors.New("backtrack hooks: the longest match diagnostics option is not supported")
+		}
+	}
+	if b.boundedStream < 0 {
+		return fmt.Errorf("invalid bounded stream window: %d", b.boundedStream)
+	}
+	if b.streamBuffer < 0 {
+		return fmt.Errorf("invalid stream buffer size: %d", b.streamBuffer)
+	}
+	if b.streamBuffer > 0 && !b.sinkResults {
+		return errors.New("stream buffer: the sink results option is required")
+	}
+	if err := b.checkRuleErrorMessages(grammar); err != nil {
+		return err
+	}
+	if err := b.checkRuleAssertions(grammar); err != nil {
+		return err
+	}
+	if err := b.checkPrefixDispatch(grammar); err != nil {
+		return err
+	}
+	if err := b.setOptimizedRules(grammar); err != nil {
+		return err
+	}
+	if b.localeFold != "" {
+		lc, ok := localeCases[b.localeFold]
+		if !ok {
+			return fmt.Errorf("locale fold: unsupported locale %q", b.localeFold)
+		}
+		b.localeCase = lc.mapping
+	}
+	if b.protoResults != "" {
+		if err := b.checkProtoResults(); err != nil {
+			return err
+		}
+	}
+	switch b.emptyInputMode {
+	case "", "normal", "error", "nil":
+	default:
+		return fmt.Errorf("empty input mode: unsupported mode %q", b.emptyInputMode)
+	}
+	if b.explicitStack {
+		// the work stack runs the instructions of the table-driven parser
+		b.tableDriven = true
+		if err := b.checkExplicitStack(); err != nil {
+			return err
+		}
+	}
+	if b.tableDriven {
+		if err := b.checkTableDriven(); err != nil {
+			return err
+		}
+	}
+	if b.memoKeyHook && b.optimize {
+		return errors.New("memo key hook: the optimize parser option is not supported")
+	}
+	if b.modeAwareMemo && b.optimize {
+		return errors.New("mode aware memo: the optimize parser option is not supported")
+	}
+	if b.memoReuseTrace && b.optimize {
+		return errors.New("memo reuse trace: the optimize parser option is not supported")
+	}
+	if b.pushParser && b.errorSpans {
+		return errors.New("push parser: the error spans option is not supported")
+	}
+	if b.entrypointType != "" {
+		if _, err := parser.ParseExpr(b.entrypointType); err != nil {
+			return fmt.Errorf("entrypoint type: invalid type %q", b.entrypointType)
+		}
+	}
+	if b.enumerateParses < 0 {
+		return fmt.Errorf("invalid enumerate parses maximum: %d", b.enumerateParses)
+	}
+	if b.enumerateParses > 0 && b.haveLeftRecursion {
+		// the left recursion relies on memoized results, which depend on
+		// the alternatives forced
+		return errors.New("enumerate parses: left recursion is not supported")
+	}
+	if b.ruleBoundaryPositions && b.haveLeftRecursion {
+		// the left recursion relies on memoized results, which carry the
+		// positions of the rules matched on another path
+		return errors.New("rule boundary positions: left recursion is not supported")
+	}
+	if b.methodReceiver != "" {
+		if err := b.checkMethodReceiver(); err != nil {
+			return err
+		}
+	}
+	if b.ruleIfacePath != "" && !token.IsExported(b.ruleIfaceName) {
+		return fmt.Errorf("rule interface: invalid interface name %q", b.ruleIfaceName)
+	}
+	if b.warnEmptyRepetition {
+		for _, warning := range EmptyRepetitionWarnings(grammar) {
+			fmt.Fprintln(b.warnw, "warning:", warning)
+		}
+	}
+	if b.warnUnusedLabels {
+		for _, warning := range UnusedLabelWarnings(grammar) {
+			fmt.Fprintln(b.warnw, "warning:", warning)
+		}
+	}
+	if b.warnShadowedRecovery {
+		for _, warning := range ShadowedRecoveryWarnings(grammar) {
+			fmt.Fprintln(b.warnw, "warning:", warning)
+		}
+	}
+	if b.warnShadowedClasses {
+		for _, warning := range ShadowedClassWarnings(grammar) {
+			fmt.Fprintln(b.warnw, "warning:", warning)
+		}
+	}
+	if b.autoMapResults {
+		if err := autoMapResults(grammar); err != nil {
+			return fmt.Errorf("auto map results: %w", err)
+		}
+	}
+
+	if b.computeFollowSets {
+		b.ruleSets = followSets(grammar)
+	}
+	if b.emitValidate {
+		// the code predicates, state code blocks, throw payloads and rule
+		// arguments may use the values of labeled expressions, Validate must
+		// then build the values.
+		for _, rule := range grammar.Rules {
+			ast.Inspect(rule.Expr, func(expr ast.Expression) bool {
+				switch expr := expr.(type) {
+				case *ast.AndCodeExpr, *ast.NotCodeExpr, *ast.StateCodeExpr:
+					b.validateValues = true
+				case *ast.ThrowExpr:
+					if expr.Payload != nil {
+						b.validateValues = true
+					}
+				case *ast.RuleRefExpr:
+					if len(expr.Args) > 0 {
+						b.validateValues = true
+					}
+				}
+				return !b.validateValues
+			})
+		}
+	}
+
+	w := b.w
+	var legacyBuf bytes.Buffer
+	if b.legacyGoCompat {
+		// the any type is replaced once the whole parser is written
+		b.w = &legacyBuf
+	}
+	b.writeInit(grammar.Init)
+	b.writeGrammar(grammar)
+	b.writeCharClassVars()
+	for _, rule := range grammar.Rules {
+		b.writeRuleCode(rule)
+	}
+	b.writeSortedFuncs()
+	b.writeActionFuncs()
+	if b.boundedStream > 0 {
+		// the expressions used are known once the grammar is written
+		if err := b.checkBoundedStream(); err != nil {
+			return err
+		}
+	}
+	if b.denseMemo {
+		if err := b.checkDenseMemo(); err != nil {
+			return err
+		}
+	}
+	b.writeStaticCode()
+	if b.legacyGoCompat {
+		b.w = w
+		if b.err == nil {
+			b.writeLegacyGo(legacyBuf.Bytes())
+		}
+	}
+
+	return b.err
+}
+
+// markLeftRecursiveRules returns an error if a rule listed by the
+// LeftRecursiveRules option is unknown or if a rule that is not listed is
+// left-recursive, and marks the listed rules as left-recursive.
+func (b *builder) markLeftRecursiveRules(g *ast.Grammar) error {
+	listed := make(map[string]bool, len(b.leftRecursiveRules))
+	for _, nm := range b.leftRecursiveRules {
+		listed[nm] = true
+	}
+	rules := make(map[string]*ast.Rule, len(g.Rules))
+	for _, r := range g.Rules {
+		rules[r.Name.Val] = r
+		if r.LeftRecursive && !listed[r.Name.Val] {
+			return fmt.Errorf("incorrect grammar: %s: rule %s: %w", r.Pos(), r.Name.Val, ErrHaveLeftRecursion)
+		}
+	}
+	for _, nm := range b.leftRecursiveRules {
+		r, ok := rules[nm]
+		if !ok {
+			return fmt.Errorf("left recursive rules: unknown rule %q", nm)
+		}
+		if !r.LeftRecursive {
+			r.LeftRecursive = true
+			r.Leader = true
+		}
+	}
+	return nil
+}
+
+func (b *builder) checkRuleErrorMessages(g *ast.Grammar) error {
+	if len(b.ruleErrorMessages) == 0 {
+		return nil
+	}
+	rules := make(map[string]struct{}, len(g.Rules))
+	for _, r := range g.Rules {
+		rules[r.Name.Val] = struct{}{}
+	}
+	names := make([]string, 0, len(b.ruleErrorMessages))
+	for nm := range b.ruleErrorMessages {
+		names = append(names, nm)
+	}
+	sort.Strings(names)
+	for _, nm := range names {
+		if _, ok := rules[nm]; !ok {
+			return fmt.Errorf("rule error messages: unknown rule %q", nm)
+		}
+	}
+	return nil
+}
+
+func (b *builder) checkRuleAssertions(g *ast.Grammar) error {
+	if len(b.ruleAssertions) == 0 {
+		return nil
+	}
+	rules := make(map[string]struct{}, len(g.Rules))
+	for _, r := range g.Rules {
+		rules[r.Name.Val] = struct{}{}
+	}
+	names := make([]string, 0, len(b.ruleAssertions))
+	for nm := range b.ruleAssertions {
+		names = append(names, nm)
+	}
+	sort.Strings(names)
+	for _, nm := range names {
+		if _, ok := rules[nm]; !ok {
+			return fmt.Errorf("rule assertions: unknown rule %q", nm)
+		}
+		if _, err := parser.ParseExpr(b.ruleAssertions[nm]); err != nil {
+			return fmt.Errorf("rule assertions: rule %s: invalid expression %q: %w", nm, b.ruleAssertions[nm], err)
+		}
+	}
+	return nil
+}
+
+func (b *builder) checkPrefixDispatch(g *ast.Grammar) error {
+	if len(b.prefixDispatch) == 0 {
+		return nil
+	}
+	rules := make(map[string]struct{}, len(g.Rules))
+	for _, r := range g.Rules {
+		rules[r.Name.Val] = struct{}{}
+	}
+	for _, prefix := range b.dispatchPrefixes() {
+		if prefix == "" {
+			return errors.New("prefix dispatch: empty prefix")
+		}
+		if _, ok := rules[b.prefixDispatch[prefix]]; !ok {
+			return fmt.Errorf("prefix dispatch: prefix %q: unknown rule %q", prefix, b.prefixDispatch[prefix])
+		}
+	}
+	return nil
+}
+
+// dispatchPrefixes returns the prefixes of the PrefixDispatch option in
+// the order in which they are tried, the longest first.
+func (b *builder) dispatchPrefixes() []string {
+	prefixes := make([]string, 0, len(b.prefixDispatch))
+	for prefix := range b.prefixDispatch {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if len(prefixes[i]) != len(prefixes[j]) {
+			return len(prefixes[i]) > len(prefixes[j])
+		}
+		return prefixes[i] < prefixes[j]
+	})
+	return prefixes
+}
+
+// checkAlternateEntrypoints returns an error if an alternate entrypoint
+// is not a rule of the grammar.
+func (b *builder) checkAlternateEntrypoints(grammar *ast.Grammar) error {
+	rules := make(map[string]struct{}, len(grammar.Rules))
+	for _, rule := range grammar.Rules {
+		rules[rule.Name.Val] = struct{}{}
+	}
+	for _, entrypoint := range b.altEntrypoints {
+		if entrypoint == "" {
+			continue
+		}
+		if _, ok := rules[entrypoint]; !ok {
+			return fmt.Errorf("unknown rule name %s used as alternate entrypoint", entrypoint)
+		}
+	}
+	return nil
+}
+
+// checkTableDriven returns an error if an option that the table-driven
+// parser does not support is set.
+func (b *builder) checkTableDriven() error {
+	opts := []struct {
+		name string
+		set  bool
+	}{
+		{"label spans", b.labelSpans},
+		{"longest match diagnostics", b.longestMatchDiagnostics && !b.optimize},
+		{"sink results", b.sinkResults},
+		{"lossless CST", b.losslessCST},
+		{"compact AST", b.compactAST},
+		{"enumerate parses", b.enumerateParses > 0},
+		{"lazy rule init", b.lazyRuleInit},
+	}
+	for _, opt := range opts {
+		if opt.set {
+			return fmt.Errorf("table-driven parser: the %s option is not supported", opt.name)
+		}
+	}
+	return nil
+}
+
+// checkExplicitStack returns an error if an option that the table-driven
+// parser does not support is set, or if an option that wraps the matching
+// of the rules is set.
+func (b *builder) checkExplicitStack() error {
+	if err := b.checkTableDriven(); err != nil {
+		return fmt.Errorf("explicit stack: %w", err)
+	}
+	opts := []struct {
+		name string
+		set  bool
+	}{
+		{"left recursion", b.haveLeftRecursion},
+		{"optimize rules", len(b.optimizedRules) > 0},
+		{"rule timing", b.ruleTiming},
+		{"chrome trace", b.chromeTrace},
+		{"structured trace", b.structuredTrace},
+		{"memo key hook", b.memoKeyHook},
+		{"mode-aware memoization", b.modeAwareMemo},
+		{"backtrack hooks", b.backtrackHooks},
+		{"runtime parameters", b.runtimeParams},
+	}
+	for _, opt := range opts {
+		if opt.set {
+			return fmt.Errorf("explicit stack: the %s option is not supported", opt.name)
+		}
+	}
+	return nil
+}
+
+// checkDenseMemo returns an error if an option that does not memoize the
+// results of the rules by rule, or that discards memoized results, is set.
+func (b *builder) checkDenseMemo() error {
+	opts := []struct {
+		name string
+		set  bool
+	}{
+		{"optimize parser option", b.optimize},
+		{"memo key hook option", b.memoKeyHook},
+		{"mode-aware memoization option", b.modeAwareMemo},
+		{"bounded stream option", b.boundedStream > 0},
+		{"scan limit expression", b.scanLimitUsed},
+	}
+	for _, opt := range opts {
+		if opt.set {
+			return fmt.Errorf("dense memo: the %s is not supported", opt.name)
+		}
+	}
+	return nil
+}
+
+func (b *builder) checkBoundedStream() error {
+	opts := []struct {
+		name string
+		set  bool
+	}{
+		{"line start option", b.lineStart},
+		{"fuzzy match option", b.fuzzyMatch > 0},
+		{"guard optimization option", b.guardExprUsed},
+		{"progress callback option", b.progressCallback},
+		{"lossless CST option", b.losslessCST},
+		{"compact AST option", b.compactAST},
+		{"error spans option", b.errorSpans},
+		{"bidi aware option", b.bidiAware},
+		{"preprocessors option", b.preprocessors},
+		{"prefix dispatch option", len(b.prefixDispatch) > 0},
+		{"ASCII fast path option", b.asciiFastPath},
+		{"until expression", b.untilExprUsed},
+		{"lookbehind expression", b.lookbehindExprUsed},
+		{"balanced expression", b.balancedExprUsed},
+		{"assertion expression", b.assertionExprUsed},
+		{"empty input mode option", b.emptyInputMode == "error" || b.emptyInputMode == "nil"},
+		{"explain failures option", b.explainFailures},
+		{"push parser option", b.pushParser},
+	}
+	for _, opt := range opts {
+		if opt.set {
+			return fmt.Errorf("bounded stream: the %s is not supported", opt.name)
+		}
+	}
+	return nil
+}
+
+// checkMethodReceiver returns an error if the type of the method receiver
+// is invalid, or if an option generates entrypoints that are not methods.
+func (b *builder) checkMethodReceiver() error {
+	if !token.IsIdentifier(b.methodReceiver) {
+		return fmt.Errorf("method receiver: invalid type name %q", b.methodReceiver)
+	}
+	opts := []struct {
+		name string
+		set  bool
+	}{
+		{"batch parse option", b.batchParse},
+		{"REPL mode option", b.replMode},
+		{"emit validate option", b.emitValidate},
+		{"lossless CST option", b.losslessCST},
+		{"compact AST option", b.compactAST},
+		{"sink results option", b.sinkResults},
+		{"sub parse option", b.subParse},
+		{"line recovery option", b.lineRecovery},
+		{"wasm exports option", b.wasmExports},
+		{"enumerate parses option", b.enumerateParses > 0},
+		{"completion support option", b.completionSupport},
+		{"push parser option", b.pushParser},
+	}
+	for _, opt := range opts {
+		if opt.set {
+			return fmt.Errorf("method receiver: the %s is not supported", opt.name)
+		}
+	}
+	return nil
+}
+
+func (b *builder) setOptimizedRules(g *ast.Grammar) error {
+	if len(b.optimizeRules) == 0 || b.optimize {
+		return nil
+	}
+	listed := make(map[string]bool, len(b.optimizeRules))
+	for _, nm := range b.optimizeRules {
+		listed[nm] = true
+	}
+	for _, r := range g.Rules {
+		if listed[r.Name.Val] != b.optimizeRulesExcept {
+			if b.optimizedRules == nil {
+				b.optimizedRules = make(map[string]bool)
+			}
+			b.optimizedRules[r.Name.Val] = true
+		}
+		delete(listed, r.Name.Val)
+	}
+	for _, nm := range b.optimizeRules {
+		if listed[nm] {
+			return fmt.Errorf("optimize rules: unknown rule %q", nm)
+		}
+	}
+	return nil
+}
+
+func (b *builder) writeInit(init *ast.CodeBlock) {
+	if init == nil {
+		return
+	}
+
+	// remove opening and closing braces
+	val := codeGeneratedComment + init.Val[1:len(init.Val)-1]
+	if b.ruleIfacePath != "" {
+		// the import must follow the package clause
+		_, off, err := packageClause(val)
+		if err != nil {
+			b.err = errors.New("rule interface: no package clause in the initializer")
+			return
+		}
+		val = val[:off] + fmt.Sprintf("\n\nimport %s %q\n", ruleIfaceImportName, b.ruleIfacePath) + val[off:]
+	}
+	b.writelnf("%s", val)
+}
+
+// packageClause parses the package clause of the Go source src, and
+// returns the name of the package and the offset of the end of the clause
+// in src. Unlike a regular expression, go/parser does not match the
+// package keyword in a comment.
+func packageClause(src string) (name string, end int, err error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly)
+	if err != nil {
+		return "", 0, err
+	}
+	return f.Name.Name, fset.Position(f.Name.End()).Offset, nil
+}
+
+// grammarPackage returns the name of the package of the initializer of g,
+// the empty string if it has no package clause.
+func grammarPackage(g *ast.Grammar) string {
+	if g.Init == nil {
+		return ""
+	}
+	name, _, err := packageClause(g.Init.Val[1 : len(g.Init.Val)-1])
+	if err != nil {
+		return ""
+	}
+	return name
 }
 
 func (b *builder) writeGrammar(g *ast.Grammar) {
@@ -189,11 +2103,98 @@ func (b *builder) writeGrammar(g *ast.Grammar) {
 		b.ruleOffsets[r.Name.Val] = counter
 		counter++
 	}
+	if b.tableDriven {
+		b.table = &tableCompiler{b: b}
+	}
 	for _, r := range g.Rules {
 		b.writeRule(r)
 	}
 	b.writelnf("\t},")
 	b.writelnf("}")
+	if b.table != nil {
+		b.writeTable(b.table)
+	}
+	if b.switchDispatch {
+		b.writeCodeDispatch()
+	}
+	if len(b.prefixDispatch) > 0 {
+		b.writePrefixRules()
+	}
+	if len(b.selfTestInputs) > 0 {
+		b.writeSelfTestInputs()
+	}
+}
+
+// writePrefixRules writes the variable of the entrypoints selected by the
+// prefix of the input, in the order in which they are tried.
+func (b *builder) writePrefixRules() {
+	b.writeln("")
+	b.writeln("var prefixRules = []prefixRule{")
+	for _, prefix := range b.dispatchPrefixes() {
+		b.writelnf("	{prefix: %q, rule: %q},", prefix, b.prefixDispatch[prefix])
+	}
+	b.writeln("}")
+}
+
+// writeSelfTestInputs writes the variable of the inputs of SelfTest,
+// sorted.
+func (b *builder) writeSelfTestInputs() {
+	inputs := make([]string, 0, len(b.selfTestInputs))
+	for in := range b.selfTestInputs {
+		inputs = append(inputs, in)
+	}
+	sort.Strings(inputs)
+
+	b.writeln("")
+	b.writeln("var selfTestInputs = []selfTestInput{")
+	for _, in := range inputs {
+		b.writelnf("\t{input: %q, ok: %t},", in, b.selfTestInputs[in])
+	}
+	b.writeln("}")
+}
+
+// actionCode returns the code of a new action whose function has the
+// index funcIx.
+func (b *builder) actionCode(funcIx int) int {
+	b.actionCodes = append(b.actionCodes, b.funcName(funcIx))
+	return len(b.actionCodes) - 1
+}
+
+// predCode returns the code of the predicate code block whose function has
+// the index funcIx.
+func (b *builder) predCode(funcIx int) int {
+	b.predCodes = append(b.predCodes, b.funcName(funcIx))
+	return len(b.predCodes) - 1
+}
+
+// stateCode returns the code of the state code block whose function has
+// the index funcIx.
+func (b *builder) stateCode(funcIx int) int {
+	b.stateCodes = append(b.stateCodes, b.funcName(funcIx))
+	return len(b.stateCodes) - 1
+}
+
+// writeCodeDispatch writes the methods of the parser that call the
+// function of an action, a predicate or a state code block by its code.
+func (b *builder) writeCodeDispatch() {
+	b.writeDispatch("runAction", "action", "(any, error)", b.actionCodes)
+	b.writeDispatch("runPredicate", "predicate", "(bool, error)", b.predCodes)
+	b.writeDispatch("runState", "state", "error", b.stateCodes)
+	b.actionCodes, b.predCodes, b.stateCodes = nil, nil, nil
+}
+
+func (b *builder) writeDispatch(method, kind, results string, names []string) {
+	b.writeln("")
+	b.writelnf("// %s calls the function of the %s code block with the code.", method, kind)
+	b.writelnf("func (p *parser) %s(code int) %s {", method, results)
+	b.writeln("\tswitch code {")
+	for code, name := range names {
+		b.writelnf("\tcase %d:", code)
+		b.writelnf("\t\treturn p.call%s()", name)
+	}
+	b.writeln("\t}")
+	b.writelnf("\tpanic(fmt.Sprintf(\"unknown %s code %%d\", code))", kind)
+	b.writeln("}")
 }
 
 func (b *builder) writeRule(r *ast.Rule) {
@@ -209,17 +2210,60 @@ func (b *builder) writeRule(r *ast.Rule) {
 	if r.DisplayName != nil && r.DisplayName.Val != "" {
 		b.writelnf("\tdisplayName: %q,", r.DisplayName.Val)
 	}
+	if msg, ok := b.ruleErrorMessages[r.Name.Val]; ok {
+		b.writelnf("\terrorMessage: %q,", msg)
+	}
+	if expr, ok := b.ruleAssertions[r.Name.Val]; ok && !b.optimize {
+		b.writelnf("\tassert: func(v any) bool { return %s },", expr)
+		b.writelnf("\tassertion: %q,", expr)
+	}
 	pos := r.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writef("\texpr: ")
-	b.writeExpr(r.Expr)
+	if b.table != nil {
+		b.writelnf("\texpr: tableExpr(%d),", b.table.compile(r.Expr))
+	} else if b.lazyRuleInit {
+		code := b.exprCode(func() { b.writeExpr(r.Expr) })
+		b.writelnf("\tnewExpr: func() any {\n\t\treturn %s\n\t},", code)
+	} else {
+		b.writef("\texpr: ")
+		b.writeExpr(r.Expr)
+	}
+	if sets, ok := b.ruleSets[r.Name.Val]; ok {
+		b.writelnf("\tfirst: %#v,", sets.first)
+		b.writelnf("\tfollow: %#v,", sets.follow)
+		if sets.nullable {
+			b.writelnf("\tnullable: true,")
+		}
+	}
 	if b.haveLeftRecursion {
 		b.writelnf("\tleader: %t,", r.Leader)
 		b.writelnf("\tleftRecursive: %t,", r.LeftRecursive)
 	}
+	if b.optimizedRules[r.Name.Val] {
+		b.writelnf("\toptimized: true,")
+	}
+	if b.emitRuleDocs && r.Doc != "" {
+		b.writelnf("\tdoc: %q,", r.Doc)
+	}
+	if b.grammarLineMap {
+		b.writelnf("\tendLine: %d,", r.EndLine)
+	}
+	if b.denseMemo {
+		b.writelnf("\tindex: %d,", b.ruleOffsets[r.Name.Val])
+	}
 	b.writelnf("},")
 }
 
+// exprCode returns the code written by write, without its trailing comma.
+func (b *builder) exprCode(write func()) string {
+	w := b.w
+	var buf bytes.Buffer
+	b.w = &buf
+	write()
+	b.w = w
+	return strings.TrimSuffix(buf.String(), ",\n")
+}
+
 func (b *builder) writeExpr(expr ast.Expression) {
 	b.exprIndex++
 	switch expr := expr.(type) {
@@ -231,14 +2275,24 @@ func (b *builder) writeExpr(expr ast.Expression) {
 		b.writeAndExpr(expr)
 	case *ast.AnyMatcher:
 		b.writeAnyMatcher(expr)
+	case *ast.AssertionExpr:
+		b.writeAssertionExpr(expr)
+	case *ast.BalancedExpr:
+		b.writeBalancedExpr(expr)
+	case *ast.CaseInsensitiveExpr:
+		b.writeCaseInsensitiveExpr(expr)
 	case *ast.CharClassMatcher:
 		b.writeCharClassMatcher(expr)
 	case *ast.ChoiceExpr:
 		b.writeChoiceExpr(expr)
 	case *ast.LabeledExpr:
 		b.writeLabeledExpr(expr)
+	case *ast.LineStartExpr:
+		b.writeLineStartExpr(expr)
 	case *ast.LitMatcher:
 		b.writeLitMatcher(expr)
+	case *ast.LookbehindExpr:
+		b.writeLookbehindExpr(expr)
 	case *ast.NotCodeExpr:
 		b.writeNotCodeExpr(expr)
 	case *ast.NotExpr:
@@ -249,12 +2303,16 @@ func (b *builder) writeExpr(expr ast.Expression) {
 		b.writeRecoveryExpr(expr)
 	case *ast.RuleRefExpr:
 		b.writeRuleRefExpr(expr)
+	case *ast.ScanLimitExpr:
+		b.writeScanLimitExpr(expr)
 	case *ast.SeqExpr:
 		b.writeSeqExpr(expr)
 	case *ast.StateCodeExpr:
 		b.writeStateCodeExpr(expr)
 	case *ast.ThrowExpr:
 		b.writeThrowExpr(expr)
+	case *ast.UntilExpr:
+		b.writeUntilExpr(expr)
 	case *ast.ZeroOrMoreExpr:
 		b.writeZeroOrMoreExpr(expr)
 	case *ast.ZeroOrOneExpr:
@@ -275,7 +2333,11 @@ func (b *builder) writeActionExpr(act *ast.ActionExpr) {
 	b.writelnf("&actionExpr{")
 	pos := act.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writelnf("\trun: (*parser).call%s,", b.funcName(act.FuncIx))
+	if b.switchDispatch {
+		b.writelnf("\tcode: %d,", b.actionCode(act.FuncIx))
+	} else {
+		b.writelnf("\trun: (*parser).call%s,", b.funcName(act.FuncIx))
+	}
 	b.writef("\texpr: ")
 	b.writeExpr(act.Expr)
 	b.writelnf("},")
@@ -292,7 +2354,11 @@ func (b *builder) writeAndCodeExpr(and *ast.AndCodeExpr) {
 		and.FuncIx = b.exprIndex
 	}
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writelnf("\trun: (*parser).call%s,", b.funcName(and.FuncIx))
+	if b.switchDispatch {
+		b.writelnf("\tcode: %d,", b.predCode(and.FuncIx))
+	} else {
+		b.writelnf("\trun: (*parser).call%s,", b.funcName(and.FuncIx))
+	}
 	b.writelnf("},")
 }
 
@@ -301,6 +2367,10 @@ func (b *builder) writeAndExpr(and *ast.AndExpr) {
 		b.writelnf("nil,")
 		return
 	}
+	if b.guardOptimization && isGuardable(and.Expr) {
+		b.writeGuardExpr(and.Pos(), false, and.Expr)
+		return
+	}
 	b.writelnf("&andExpr{")
 	pos := and.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
@@ -320,11 +2390,167 @@ func (b *builder) writeAnyMatcher(any *ast.AnyMatcher) {
 	b.writelnf("},")
 }
 
+func (b *builder) writeCaseInsensitiveExpr(ci *ast.CaseInsensitiveExpr) {
+	if ci == nil {
+		b.writelnf("nil,")
+		return
+	}
+	if !b.caseScopes {
+		b.err = fmt.Errorf("%s: case-insensitive region requires the CaseScopes option", ci.Pos())
+		return
+	}
+	// the region has no node in the generated parser, its matchers are
+	// written as case-insensitive
+	prev := b.ignoreCase
+	b.ignoreCase = true
+	b.writeExpr(ci.Expr)
+	b.ignoreCase = prev
+}
+
+// caseScoped returns ch, or a case-insensitive copy of ch if it is in a
+// case-insensitive region.
+func (b *builder) caseScoped(ch *ast.CharClassMatcher) *ast.CharClassMatcher {
+	if !b.ignoreCase || ch.IgnoreCase {
+		return ch
+	}
+	cp := *ch
+	cp.IgnoreCase = true
+	cp.Val += "i"
+	return &cp
+}
+
+// expandIgnoreCase returns ch, or a case-sensitive copy of ch that
+// matches the same characters if ch is case-insensitive: the case variants
+// of its characters and ranges, as defined by unicode.SimpleFold, are
+// added to them. If sc is not nil, the variants are instead the characters
+// with the same lowercase according to sc. A class with Unicode classes is
+// returned unchanged, its variants cannot be listed.
+func expandIgnoreCase(ch *ast.CharClassMatcher, sc unicode.SpecialCase) *ast.CharClassMatcher {
+	if !ch.IgnoreCase || len(ch.UnicodeClasses) > 0 {
+		return ch
+	}
+
+	in := func(rn rune) bool {
+		for _, c := range ch.Chars {
+			if c == rn {
+				return true
+			}
+		}
+		for i := 0; i < len(ch.Ranges); i += 2 {
+			if rn >= ch.Ranges[i] && rn <= ch.Ranges[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	variants := make(map[rune]struct{})
+	addVariants := func(rn rune) {
+		if sc != nil {
+			lower := sc.ToLower(rn)
+			candidates := []rune{lower, sc.ToUpper(rn), sc.ToTitle(rn)}
+			for f := unicode.SimpleFold(rn); f != rn; f = unicode.SimpleFold(f) {
+				candidates = append(candidates, f)
+			}
+			for _, f := range candidates {
+				if f != rn && sc.ToLower(f) == lower && !in(f) {
+					variants[f] = struct{}{}
+				}
+			}
+			return
+		}
+		for f := unicode.SimpleFold(rn); f != rn; f = unicode.SimpleFold(f) {
+			if !in(f) {
+				variants[f] = struct{}{}
+			}
+		}
+	}
+	for _, c := range ch.Chars {
+		addVariants(c)
+	}
+	for i := 0; i < len(ch.Ranges); i += 2 {
+		for rn := ch.Ranges[i]; rn <= ch.Ranges[i+1]; rn++ {
+			addVariants(rn)
+		}
+	}
+
+	sorted := make([]rune, 0, len(variants))
+	for rn := range variants {
+		sorted = append(sorted, rn)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	cp := *ch
+	cp.IgnoreCase = false
+	cp.Chars = append([]rune(nil), ch.Chars...)
+	cp.Ranges = append([]rune(nil), ch.Ranges...)
+	// consecutive variants are added as a range, the others as characters
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if j > i {
+			cp.Ranges = append(cp.Ranges, sorted[i], sorted[j])
+		} else {
+			cp.Chars = append(cp.Chars, sorted[i])
+		}
+		i = j + 1
+	}
+	return &cp
+}
+
 func (b *builder) writeCharClassMatcher(ch *ast.CharClassMatcher) {
 	if ch == nil {
 		b.writelnf("nil,")
 		return
 	}
+	ch = b.caseScoped(ch)
+	if b.expandIgnoreCaseClasses {
+		ch = expandIgnoreCase(ch, b.localeCase)
+	}
+	if b.dedupeCharClasses {
+		key := fmt.Sprintf("%q %q %q %q %t %t", ch.Val, ch.Chars, ch.Ranges, ch.UnicodeClasses, ch.IgnoreCase, ch.Inverted)
+		nm, ok := b.charClassNames[key]
+		if !ok {
+			if b.charClassNames == nil {
+				b.charClassNames = make(map[string]string)
+			}
+			nm = fmt.Sprintf("sharedCharClass%d", len(b.charClassVars)+1)
+			b.charClassNames[key] = nm
+
+			// write the matcher to a buffer, it is written by writeCharClassVars
+			w := b.w
+			var buf bytes.Buffer
+			b.w = &buf
+			b.writeCharClassLiteral(ch)
+			b.w = w
+			b.charClassVars = append(b.charClassVars, generatedFunc{name: nm, code: buf.String()})
+		}
+		b.writelnf("%s,", nm)
+		return
+	}
+	b.writeCharClassLiteral(ch)
+}
+
+// writeCharClassVars writes the variables of the shared character class
+// matchers. It is a no-op unless dedupeCharClasses is set.
+func (b *builder) writeCharClassVars() {
+	if len(b.charClassVars) == 0 {
+		return
+	}
+	b.writeln("")
+	b.writeln("// shared character class matchers, the position is the one of their")
+	b.writeln("// first use in the grammar.")
+	b.writeln("var (")
+	for _, v := range b.charClassVars {
+		b.writef("\t%s = %s", v.name, strings.TrimSuffix(v.code, ",\n"))
+		b.writeln("")
+	}
+	b.writeln(")")
+	b.charClassVars, b.charClassNames = nil, nil
+}
+
+func (b *builder) writeCharClassLiteral(ch *ast.CharClassMatcher) {
 	b.writelnf("&charClassMatcher{")
 	pos := ch.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
@@ -333,7 +2559,7 @@ func (b *builder) writeCharClassMatcher(ch *ast.CharClassMatcher) {
 		b.writef("\tchars: []rune{")
 		for _, rn := range ch.Chars {
 			if ch.IgnoreCase {
-				b.writef("%q,", unicode.ToLower(rn))
+				b.writef("%q,", b.toLower(rn))
 			} else {
 				b.writef("%q,", rn)
 			}
@@ -344,7 +2570,7 @@ func (b *builder) writeCharClassMatcher(ch *ast.CharClassMatcher) {
 		b.writef("\tranges: []rune{")
 		for _, rn := range ch.Ranges {
 			if ch.IgnoreCase {
-				b.writef("%q,", unicode.ToLower(rn))
+				b.writef("%q,", b.toLower(rn))
 			} else {
 				b.writef("%q,", rn)
 			}
@@ -359,8 +2585,12 @@ func (b *builder) writeCharClassMatcher(ch *ast.CharClassMatcher) {
 		}
 		b.writelnf("},")
 	}
-	if b.basicLatinLookupTable {
-		b.writelnf("\tbasicLatinChars: %#v,", BasicLatinLookup(ch.Chars, ch.Ranges, ch.UnicodeClasses, ch.IgnoreCase))
+	if b.basicLatinLookupTable || b.asciiFastPath {
+		if b.localeCase != nil && ch.IgnoreCase {
+			b.writelnf("\tbasicLatinChars: %#v,", localeBasicLatinLookup(ch.Chars, ch.Ranges, ch.UnicodeClasses, b.localeCase))
+		} else {
+			b.writelnf("\tbasicLatinChars: %#v,", BasicLatinLookup(ch.Chars, ch.Ranges, ch.UnicodeClasses, ch.IgnoreCase))
+		}
 	}
 	b.writelnf("\tignoreCase: %t,", ch.IgnoreCase)
 	b.writelnf("\tinverted: %t,", ch.Inverted)
@@ -407,6 +2637,52 @@ func BasicLatinLookup(chars, ranges []rune, unicodeClasses []string, ignoreCase
 	return
 }
 
+// localeBasicLatinLookup is BasicLatinLookup for a case-insensitive class
+// whose case is folded with the special case mapping sc: a character is
+// matched if its lowercase matches the lowercase characters and ranges of
+// the class, as in the generated parser.
+func localeBasicLatinLookup(chars, ranges []rune, unicodeClasses []string, sc unicode.SpecialCase) (basicLatinChars [128]bool) {
+	for r := rune(0); r < 128; r++ {
+		cur := sc.ToLower(r)
+		for _, rn := range chars {
+			if sc.ToLower(rn) == cur {
+				basicLatinChars[r] = true
+			}
+		}
+		for i := 0; i < len(ranges); i += 2 {
+			if cur >= sc.ToLower(ranges[i]) && cur <= sc.ToLower(ranges[i+1]) {
+				basicLatinChars[r] = true
+			}
+		}
+		for _, cl := range unicodeClasses {
+			if unicode.Is(rangeTable(cl), cur) {
+				basicLatinChars[r] = true
+			}
+		}
+	}
+	return
+}
+
+// toLower returns the lowercase of rn, according to the locale of the
+// localeFold option.
+func (b *builder) toLower(rn rune) rune {
+	if b.localeCase != nil {
+		return b.localeCase.ToLower(rn)
+	}
+	return unicode.ToLower(rn)
+}
+
+func (b *builder) writeCharClassRunExpr(pos ast.Pos, ch *ast.CharClassMatcher, min int) {
+	ch = b.caseScoped(ch)
+	b.runCharClassUsed = true
+	b.writelnf("&charClassRunExpr{")
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tmin: %d,", min)
+	b.writef("\tmatcher: ")
+	b.writeExpr(ch)
+	b.writelnf("},")
+}
+
 func (b *builder) writeChoiceExpr(ch *ast.ChoiceExpr) {
 	if ch == nil {
 		b.writelnf("nil,")
@@ -425,6 +2701,25 @@ func (b *builder) writeChoiceExpr(ch *ast.ChoiceExpr) {
 	b.writelnf("},")
 }
 
+// isGuardable returns true if expr can be matched by a guardExpr.
+func isGuardable(expr ast.Expression) bool {
+	switch expr.(type) {
+	case *ast.LitMatcher, *ast.CharClassMatcher:
+		return true
+	}
+	return false
+}
+
+func (b *builder) writeGuardExpr(pos ast.Pos, not bool, expr ast.Expression) {
+	b.guardExprUsed = true
+	b.writelnf("&guardExpr{")
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tnot: %t,", not)
+	b.writef("\texpr: ")
+	b.writeExpr(expr)
+	b.writelnf("},")
+}
+
 func (b *builder) writeLabeledExpr(lab *ast.LabeledExpr) {
 	if lab == nil {
 		b.writelnf("nil,")
@@ -436,20 +2731,176 @@ func (b *builder) writeLabeledExpr(lab *ast.LabeledExpr) {
 	if lab.Label != nil && lab.Label.Val != "" {
 		b.writelnf("\tlabel: %q,", lab.Label.Val)
 	}
+	if ref, ok := lab.Expr.(*ast.RuleRefExpr); ok && len(ref.Args) > 0 {
+		b.writelnf("\targsRef: true,")
+	}
 	b.writef("\texpr: ")
 	b.writeExpr(lab.Expr)
 	b.writelnf("},")
 }
 
+func (b *builder) writeBalancedExpr(bal *ast.BalancedExpr) {
+	if bal == nil {
+		b.writelnf("nil,")
+		return
+	}
+	pos := bal.Pos()
+	switch {
+	case bal.Open.IgnoreCase || bal.Close.IgnoreCase:
+		b.err = fmt.Errorf("%s: balanced expression delimiters cannot be case-insensitive", pos)
+		return
+	case bal.Open.Val == "" || bal.Close.Val == "":
+		b.err = fmt.Errorf("%s: balanced expression delimiters cannot be empty", pos)
+		return
+	case bal.Open.Val == bal.Close.Val:
+		b.err = fmt.Errorf("%s: balanced expression delimiters must be different", pos)
+		return
+	}
+	b.balancedExprUsed = true
+	b.writelnf("&balancedExpr{")
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\topen: []byte(%q),", bal.Open.Val)
+	b.writelnf("\tclose: []byte(%q),", bal.Close.Val)
+	b.writelnf("\twantOpen: %q,", strconv.Quote(bal.Open.Val))
+	b.writelnf("\twantClose: %q,", strconv.Quote(bal.Close.Val))
+	b.writelnf("},")
+}
+
+func (b *builder) writeUntilExpr(until *ast.UntilExpr) {
+	if until == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.untilExprUsed = true
+	b.writelnf("&untilExpr{")
+	pos := until.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	if lit, ok := until.Expr.(*ast.LitMatcher); ok && !lit.IgnoreCase && !b.ignoreCase && lit.Val != "" {
+		// a case-sensitive literal terminator is searched in the input
+		b.writelnf("\tlit: []byte(%q),", lit.Val)
+		b.writelnf("\twant: %q,", strconv.Quote(lit.Val))
+	} else {
+		b.writef("\texpr: ")
+		b.writeExpr(until.Expr)
+	}
+	b.writelnf("\teof: %t,", b.untilEOF)
+	b.writelnf("},")
+}
+
+func (b *builder) writeLookbehindExpr(lb *ast.LookbehindExpr) {
+	if lb == nil {
+		b.writelnf("nil,")
+		return
+	}
+	pos := lb.Pos()
+	runes, ok := fixedLength(lb.Expr)
+	if !ok {
+		b.err = fmt.Errorf("%s: lookbehind expression must match a fixed number of characters", pos)
+	}
+	b.lookbehindExprUsed = true
+	b.writelnf("&lookbehindExpr{")
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writef("\texpr: ")
+	b.writeExpr(lb.Expr)
+	b.writelnf("\trunes: %d,", runes)
+	b.writelnf("},")
+}
+
+func (b *builder) writeScanLimitExpr(sl *ast.ScanLimitExpr) {
+	if sl == nil {
+		b.writelnf("nil,")
+		return
+	}
+	pos := sl.Pos()
+	b.scanLimitUsed = true
+	b.writelnf("&scanLimitExpr{")
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writef("\texpr: ")
+	b.writeExpr(sl.Expr)
+	b.writelnf("\tlimit: %d,", sl.Limit)
+	b.writelnf("},")
+}
+
+// fixedLength returns the number of characters matched by expr, which
+// must be made of literals, character classes and any matchers, in
+// sequences or choices of alternatives of the same length. The ok result
+// is false otherwise.
+func fixedLength(expr ast.Expression) (n int, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.AnyMatcher, *ast.CharClassMatcher:
+		return 1, true
+	case *ast.CaseInsensitiveExpr:
+		return fixedLength(expr.Expr)
+	case *ast.ChoiceExpr:
+		for i, alt := range expr.Alternatives {
+			altN, ok := fixedLength(alt)
+			if !ok || i > 0 && altN != n {
+				return 0, false
+			}
+			n = altN
+		}
+		return n, true
+	case *ast.LitMatcher:
+		return utf8.RuneCountInString(expr.Val), true
+	case *ast.SeqExpr:
+		for _, item := range expr.Exprs {
+			itemN, ok := fixedLength(item)
+			if !ok {
+				return 0, false
+			}
+			n += itemN
+		}
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func (b *builder) writeLineStartExpr(ls *ast.LineStartExpr) {
+	if ls == nil {
+		b.writelnf("nil,")
+		return
+	}
+	b.lineStart = true
+	b.writelnf("&lineStartExpr{")
+	pos := ls.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("},")
+}
+
+func (b *builder) writeAssertionExpr(a *ast.AssertionExpr) {
+	if a == nil {
+		b.writelnf("nil,")
+		return
+	}
+	if !b.builtinAssertions {
+		b.err = fmt.Errorf("%s: assertion %s requires the BuiltinAssertions option", a.Pos(), a.Name)
+		return
+	}
+	b.assertionExprUsed = true
+	b.writelnf("&assertionExpr{")
+	pos := a.Pos()
+	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
+	b.writelnf("\tname: %q,", a.Name)
+	b.writelnf("},")
+}
+
 func (b *builder) writeLitMatcher(lit *ast.LitMatcher) {
 	if lit == nil {
 		b.writelnf("nil,")
 		return
 	}
+	if b.ignoreCase && !lit.IgnoreCase {
+		cp := *lit
+		cp.IgnoreCase = true
+		lit = &cp
+	}
 	b.writelnf("&litMatcher{")
 	pos := lit.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	if lit.IgnoreCase {
+	if lit.IgnoreCase && b.localeCase != nil {
+		b.writelnf("\tval: %q,", strings.ToLowerSpecial(b.localeCase, lit.Val))
+	} else if lit.IgnoreCase {
 		b.writelnf("\tval: %q,", strings.ToLower(lit.Val))
 	} else {
 		b.writelnf("\tval: %q,", lit.Val)
@@ -474,7 +2925,11 @@ func (b *builder) writeNotCodeExpr(not *ast.NotCodeExpr) {
 		not.FuncIx = b.exprIndex
 	}
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writelnf("\trun: (*parser).call%s,", b.funcName(not.FuncIx))
+	if b.switchDispatch {
+		b.writelnf("\tcode: %d,", b.predCode(not.FuncIx))
+	} else {
+		b.writelnf("\trun: (*parser).call%s,", b.funcName(not.FuncIx))
+	}
 	b.writelnf("},")
 }
 
@@ -483,6 +2938,10 @@ func (b *builder) writeNotExpr(not *ast.NotExpr) {
 		b.writelnf("nil,")
 		return
 	}
+	if b.guardOptimization && isGuardable(not.Expr) {
+		b.writeGuardExpr(not.Pos(), true, not.Expr)
+		return
+	}
 	b.writelnf("&notExpr{")
 	pos := not.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
@@ -496,6 +2955,10 @@ func (b *builder) writeOneOrMoreExpr(one *ast.OneOrMoreExpr) {
 		b.writelnf("nil,")
 		return
 	}
+	if ch, ok := one.Expr.(*ast.CharClassMatcher); ok && b.runCharClass {
+		b.writeCharClassRunExpr(one.Pos(), ch, 1)
+		return
+	}
 	b.writelnf("&oneOrMoreExpr{")
 	pos := one.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
@@ -539,6 +3002,25 @@ func (b *builder) writeRuleRefExpr(ref *ast.RuleRefExpr) {
 			b.writelnf("\toffset: %d,", offset)
 		}
 	}
+	if len(ref.Args) > 0 {
+		if !b.runtimeParams {
+			b.err = fmt.Errorf("%s: rule arguments require the RuntimeParams option", pos)
+			return
+		}
+		b.writelnf("\targs: []ruleArg{")
+		for i, arg := range ref.Args {
+			if arg.FuncIx == 0 {
+				// each argument has its own function
+				arg.FuncIx = b.exprIndex
+				if i > 0 {
+					b.exprIndex++
+					arg.FuncIx = b.exprIndex
+				}
+			}
+			b.writelnf("\t\t{name: %q, value: (*parser).call%s},", arg.Name.Val, b.funcName(arg.FuncIx))
+		}
+		b.writelnf("\t},")
+	}
 	b.writelnf("},")
 }
 
@@ -572,7 +3054,11 @@ func (b *builder) writeStateCodeExpr(state *ast.StateCodeExpr) {
 		state.FuncIx = b.exprIndex
 	}
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
-	b.writelnf("\trun: (*parser).call%s,", b.funcName(state.FuncIx))
+	if b.switchDispatch {
+		b.writelnf("\tcode: %d,", b.stateCode(state.FuncIx))
+	} else {
+		b.writelnf("\trun: (*parser).call%s,", b.funcName(state.FuncIx))
+	}
 	b.writelnf("},")
 }
 
@@ -585,6 +3071,16 @@ func (b *builder) writeThrowExpr(throw *ast.ThrowExpr) {
 	pos := throw.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
 	b.writelnf("\tlabel: %q,", throw.Label)
+	if throw.Payload != nil {
+		if !b.typedThrows {
+			b.err = fmt.Errorf("%s: throw payload requires the TypedThrows option", pos)
+			return
+		}
+		if throw.FuncIx == 0 {
+			throw.FuncIx = b.exprIndex
+		}
+		b.writelnf("\tpayload: (*parser).call%s,", b.funcName(throw.FuncIx))
+	}
 	b.writelnf("},")
 }
 
@@ -593,6 +3089,10 @@ func (b *builder) writeZeroOrMoreExpr(zero *ast.ZeroOrMoreExpr) {
 		b.writelnf("nil,")
 		return
 	}
+	if ch, ok := zero.Expr.(*ast.CharClassMatcher); ok && b.runCharClass {
+		b.writeCharClassRunExpr(zero.Pos(), ch, 0)
+		return
+	}
 	b.writelnf("&zeroOrMoreExpr{")
 	pos := zero.Pos()
 	b.writelnf("\tpos: position{line: %d, col: %d, offset: %d},", pos.Line, pos.Col, pos.Off)
@@ -653,6 +3153,12 @@ func (b *builder) writeExprCode(expr ast.Expression) {
 		b.writeAndCodeExprCode(expr)
 
 	case *ast.LabeledExpr:
+		if ref, ok := expr.Expr.(*ast.RuleRefExpr); ok && len(ref.Args) > 0 {
+			// the arguments get the labels in scope of the label
+			b.writeExprCode(ref)
+			b.addArg(expr.Label)
+			break
+		}
 		b.addArg(expr.Label)
 		b.pushArgsSet()
 		b.writeExprCode(expr.Expr)
@@ -666,6 +3172,9 @@ func (b *builder) writeExprCode(expr ast.Expression) {
 		b.writeExprCode(expr.Expr)
 		b.popArgsSet()
 
+	case *ast.CaseInsensitiveExpr:
+		b.writeExprCode(expr.Expr)
+
 	case *ast.ChoiceExpr:
 		for _, alt := range expr.Alternatives {
 			b.pushArgsSet()
@@ -678,6 +3187,16 @@ func (b *builder) writeExprCode(expr ast.Expression) {
 		b.writeExprCode(expr.Expr)
 		b.popArgsSet()
 
+	case *ast.UntilExpr:
+		b.pushArgsSet()
+		b.writeExprCode(expr.Expr)
+		b.popArgsSet()
+
+	case *ast.LookbehindExpr:
+		b.pushArgsSet()
+		b.writeExprCode(expr.Expr)
+		b.popArgsSet()
+
 	case *ast.OneOrMoreExpr:
 		b.pushArgsSet()
 		b.writeExprCode(expr.Expr)
@@ -689,14 +3208,23 @@ func (b *builder) writeExprCode(expr ast.Expression) {
 		b.writeExprCode(expr.RecoverExpr)
 		b.popArgsSet()
 
+	case *ast.ScanLimitExpr:
+		b.writeExprCode(expr.Expr)
+
 	case *ast.SeqExpr:
 		for _, sub := range expr.Exprs {
 			b.writeExprCode(sub)
 		}
 
+	case *ast.RuleRefExpr:
+		b.writeRuleRefExprCode(expr)
+
 	case *ast.StateCodeExpr:
 		b.writeStateCodeExprCode(expr)
 
+	case *ast.ThrowExpr:
+		b.writeThrowExprCode(expr)
+
 	case *ast.ZeroOrMoreExpr:
 		b.pushArgsSet()
 		b.writeExprCode(expr.Expr)
@@ -714,7 +3242,12 @@ func (b *builder) writeActionExprCode(act *ast.ActionExpr) {
 		return
 	}
 	if act.FuncIx > 0 {
-		b.writeFunc(act.FuncIx, act.Code, callFuncTemplate, onFuncTemplate)
+		if b.overridableActions {
+			b.writeFunc(act.FuncIx, act.Code, callOverridableFuncTemplate, onFuncTemplate)
+			b.addActionFunc(b.funcName(act.FuncIx))
+		} else {
+			b.writeFunc(act.FuncIx, act.Code, callFuncTemplate, onFuncTemplate)
+		}
 		act.FuncIx = 0 // already rendered, prevent duplicates
 	}
 }
@@ -749,6 +3282,28 @@ func (b *builder) writeStateCodeExprCode(state *ast.StateCodeExpr) {
 	}
 }
 
+func (b *builder) writeRuleRefExprCode(ref *ast.RuleRefExpr) {
+	if ref == nil {
+		return
+	}
+	for _, arg := range ref.Args {
+		if arg.FuncIx > 0 {
+			b.writeFunc(arg.FuncIx, arg.Code, callFuncTemplate, onFuncTemplate)
+			arg.FuncIx = 0 // already rendered, prevent duplicates
+		}
+	}
+}
+
+func (b *builder) writeThrowExprCode(throw *ast.ThrowExpr) {
+	if throw == nil {
+		return
+	}
+	if throw.FuncIx > 0 {
+		b.writeFunc(throw.FuncIx, throw.Payload, callFuncTemplate, onFuncTemplate)
+		throw.FuncIx = 0 // already rendered, prevent duplicates
+	}
+}
+
 func (b *builder) writeFunc(funcIx int, code *ast.CodeBlock, callTpl, funcTpl string) {
 	if code == nil {
 		return
@@ -775,6 +3330,19 @@ func (b *builder) writeFunc(funcIx int, code *ast.CodeBlock, callTpl, funcTpl st
 	}
 
 	fnNm := b.funcName(funcIx)
+	if b.validateActions && b.err == nil {
+		b.err = b.validateCode(code, val, fmt.Sprintf(funcTpl, b.recvName, fnNm, args.String(), val))
+	}
+	if b.sortFunctions {
+		// write to a buffer, the functions are written by writeSortedFuncs
+		w := b.w
+		var buf bytes.Buffer
+		b.w = &buf
+		defer func() {
+			b.w = w
+			b.funcs = append(b.funcs, generatedFunc{name: fnNm, code: buf.String()})
+		}()
+	}
 	b.writelnf(funcTpl, b.recvName, fnNm, args.String(), val)
 
 	args.Reset()
@@ -789,20 +3357,263 @@ func (b *builder) writeFunc(funcIx int, code *ast.CodeBlock, callTpl, funcTpl st
 	b.writelnf(callTpl, fnNm, args.String())
 }
 
+// validateCode parses fn, the function generated for the code block code
+// whose body is val, and returns an error at the position in the grammar
+// of its first syntax error, if any.
+func (b *builder) validateCode(code *ast.CodeBlock, val, fn string) error {
+	const pkg = "package p\n"
+	_, err := parser.ParseFile(token.NewFileSet(), "", pkg+fn, parser.SkipObjectResolution)
+	var errs scanner.ErrorList
+	if !errors.As(err, &errs) || len(errs) == 0 {
+		return err
+	}
+
+	// the body of the function is written last, followed by "\n}\n"
+	bodyOff := len(pkg) + len(fn) - len(val) - len("\n}\n")
+	rel := errs[0].Pos.Offset - bodyOff
+	if rel < 0 || rel > len(val) {
+		rel = len(val)
+	}
+	off := strings.Index(code.Val, val) + rel
+	pos := code.Pos()
+	pos.Off += off
+	if nl := strings.LastIndexByte(code.Val[:off], '\n'); nl >= 0 {
+		pos.Line += strings.Count(code.Val[:off], "\n")
+		pos.Col = utf8.RuneCountInString(code.Val[nl+1:off]) + 1
+	} else {
+		pos.Col += utf8.RuneCountInString(code.Val[:off])
+	}
+	return fmt.Errorf("%s: rule %s: invalid code block: %s", pos, b.ruleName, errs[0].Msg)
+}
+
+// addActionFunc records the default implementation of the action function
+// name, called with the labels of the current scope.
+func (b *builder) addActionFunc(name string) {
+	var args bytes.Buffer
+	if ix := len(b.argsStack) - 1; ix >= 0 {
+		for i := range b.argsStack[ix] {
+			if i > 0 {
+				args.WriteString(", ")
+			}
+			fmt.Fprintf(&args, "args[%d]", i)
+		}
+	}
+	b.actionFuncs = append(b.actionFuncs, generatedFunc{
+		name: name,
+		code: fmt.Sprintf(actionFuncTemplate, name, args.String()),
+	})
+}
+
+// writeActionFuncs writes the map of the default implementations of the
+// action functions, sorted by name. It is a no-op unless
+// overridableActions is set.
+func (b *builder) writeActionFuncs() {
+	if !b.overridableActions {
+		return
+	}
+	sort.Slice(b.actionFuncs, func(i, j int) bool {
+		return b.actionFuncs[i].name < b.actionFuncs[j].name
+	})
+	b.writeln("// actionFuncs are the generated implementations of the action code blocks.")
+	b.writeln("var actionFuncs = map[string]ActionFunc{")
+	for _, fn := range b.actionFuncs {
+		b.writeln(fn.code)
+	}
+	b.writeln("}")
+	b.writeln("")
+	b.actionFuncs = nil
+}
+
+// generatedFunc is the code of the functions generated for a code block.
+type generatedFunc struct {
+	name string
+	code string
+}
+
+// writeSortedFuncs writes the functions generated for the code blocks,
+// sorted by name. It is a no-op unless sortFunctions is set.
+func (b *builder) writeSortedFuncs() {
+	sort.Slice(b.funcs, func(i, j int) bool {
+		return b.funcs[i].name < b.funcs[j].name
+	})
+	for _, fn := range b.funcs {
+		b.writef("%s", fn.code)
+	}
+	b.funcs = nil
+}
+
 func (b *builder) writeStaticCode() {
 	buffer := bytes.NewBufferString("")
 	params := struct {
-		Optimize              bool
-		BasicLatinLookupTable bool
-		GlobalState           bool
-		LeftRecursion         bool
-		Nolint                bool
+		Optimize                bool
+		BasicLatinLookupTable   bool
+		GlobalState             bool
+		LeftRecursion           bool
+		LineStart               bool
+		Nolint                  bool
+		RuleErrorMessages       bool
+		FuzzyMatch              int
+		NumericHelpers          bool
+		LabelSpans              bool
+		RunCharClass            bool
+		GuardOptimization       bool
+		LongestMatchDiagnostics bool
+		MemoKeyHook             bool
+		OverridableActions      bool
+		RuleTiming              bool
+		ProgressCallback        bool
+		SinkResults             bool
+		MatcherInterface        bool
+		UntilExpr               bool
+		LookbehindExpr          bool
+		AssertionExpr           bool
+		DenseMemo               bool
+		EntrypointType          string
+		RuleBoundaryPositions   bool
+		LazyRuleInit            bool
+		ExplainFailures         bool
+		PushParser              bool
+		UserData                bool
+		OTelSpans               bool
+		BalancedExpr            bool
+		ScanLimits              bool
+		FollowSets              bool
+		StructuredTrace         bool
+		ZeroCopyText            bool
+		BatchParse              bool
+		LosslessCST             bool
+		EmitValidate            bool
+		ValidateValues          bool
+		REPLMode                bool
+		LocaleCase              string
+		ErrorSpans              bool
+		ErrorRepair             bool
+		ExpvarMetrics           string
+		OptimizeRules           bool
+		AutoMapResults          bool
+		RuleDocs                bool
+		Reprinter               bool
+		TableDriven             bool
+		ModeAwareMemo           bool
+		GrammarLineMap          bool
+		StreamWindow            int
+		ChoiceIndex             bool
+		CompactAST              bool
+		MethodReceiver          string
+		ReportMaxDepth          bool
+		SubParse                bool
+		BacktrackHooks          bool
+		SwitchDispatch          bool
+		StreamBuffer            int
+		RuleAssertions          bool
+		ResultDiff              bool
+		BidiAware               bool
+		Preprocessors           bool
+		MustParse               bool
+		ChromeTrace             bool
+		PrefixDispatch          bool
+		ASCIIFastPath           bool
+		TypedThrows             bool
+		RuntimeParams           bool
+		EmptyInput              string
+		ProtoResults            string
+		GraphemeInput           bool
+		SelfTest                bool
+		ExplicitStack           bool
+		MemoReuseTrace          bool
+		LineRecovery            bool
+		WasmExports             bool
+		EnumerateParses         int
+		CompletionSupport       bool
+		RuleInterface           string
 	}{
-		Optimize:              b.optimize,
-		BasicLatinLookupTable: b.basicLatinLookupTable,
-		GlobalState:           b.globalState,
-		LeftRecursion:         b.haveLeftRecursion,
-		Nolint:                b.nolint,
+		Optimize:                b.optimize,
+		BasicLatinLookupTable:   b.basicLatinLookupTable,
+		GlobalState:             b.globalState,
+		LineStart:               b.lineStart,
+		LeftRecursion:           b.haveLeftRecursion,
+		Nolint:                  b.nolint,
+		RuleErrorMessages:       len(b.ruleErrorMessages) > 0,
+		FuzzyMatch:              b.fuzzyMatch,
+		NumericHelpers:          b.numericHelpers,
+		LabelSpans:              b.labelSpans,
+		RunCharClass:            b.runCharClassUsed,
+		GuardOptimization:       b.guardExprUsed,
+		LongestMatchDiagnostics: b.longestMatchDiagnostics && !b.optimize,
+		MemoKeyHook:             b.memoKeyHook && (b.haveLeftRecursion || !b.optimize),
+		OverridableActions:      b.overridableActions,
+		RuleTiming:              b.ruleTiming,
+		ProgressCallback:        b.progressCallback,
+		SinkResults:             b.sinkResults,
+		MatcherInterface:        b.matcherInterface,
+		UntilExpr:               b.untilExprUsed,
+		LookbehindExpr:          b.lookbehindExprUsed,
+		AssertionExpr:           b.assertionExprUsed,
+		DenseMemo:               b.denseMemo,
+		EntrypointType:          b.entrypointType,
+		RuleBoundaryPositions:   b.ruleBoundaryPositions,
+		LazyRuleInit:            b.lazyRuleInit,
+		ExplainFailures:         b.explainFailures,
+		PushParser:              b.pushParser,
+		UserData:                b.userData,
+		OTelSpans:               b.otelSpans,
+		BalancedExpr:            b.balancedExprUsed,
+		ScanLimits:              b.scanLimitUsed,
+		FollowSets:              b.computeFollowSets,
+		StructuredTrace:         b.structuredTrace,
+		ZeroCopyText:            b.zeroCopyText,
+		BatchParse:              b.batchParse,
+		LosslessCST:             b.losslessCST,
+		EmitValidate:            b.emitValidate,
+		ValidateValues:          b.validateValues,
+		REPLMode:                b.replMode,
+		LocaleCase:              localeCases[b.localeFold].name,
+		ErrorSpans:              b.errorSpans,
+		ErrorRepair:             b.errorRepair,
+		OptimizeRules:           len(b.optimizedRules) > 0,
+		AutoMapResults:          b.autoMapResults,
+		RuleDocs:                b.emitRuleDocs,
+		Reprinter:               b.emitReprinter,
+		TableDriven:             b.tableDriven,
+		ModeAwareMemo:           b.modeAwareMemo && b.globalState && (b.haveLeftRecursion || !b.optimize),
+		GrammarLineMap:          b.grammarLineMap,
+		StreamWindow:            b.boundedStream,
+		ChoiceIndex:             b.choiceIndex,
+		CompactAST:              b.compactAST,
+		MethodReceiver:          b.methodReceiver,
+		ReportMaxDepth:          b.reportMaxDepth,
+		SubParse:                b.subParse,
+		BacktrackHooks:          b.backtrackHooks,
+		SwitchDispatch:          b.switchDispatch,
+		StreamBuffer:            b.streamBuffer,
+		RuleAssertions:          len(b.ruleAssertions) > 0 && !b.optimize,
+		ResultDiff:              b.emitResultDiff,
+		BidiAware:               b.bidiAware,
+		Preprocessors:           b.preprocessors,
+		MustParse:               b.emitMustParse,
+		ChromeTrace:             b.chromeTrace,
+		PrefixDispatch:          len(b.prefixDispatch) > 0,
+		ASCIIFastPath:           b.asciiFastPath,
+		TypedThrows:             b.typedThrows,
+		RuntimeParams:           b.runtimeParams,
+		ProtoResults:            b.protoResults,
+		GraphemeInput:           b.graphemeInput,
+		SelfTest:                len(b.selfTestInputs) > 0,
+		ExplicitStack:           b.explicitStack,
+		MemoReuseTrace:          b.memoReuseTrace,
+		LineRecovery:            b.lineRecovery,
+		WasmExports:             b.wasmExports,
+		EnumerateParses:         b.enumerateParses,
+		CompletionSupport:       b.completionSupport,
+	}
+	if b.emptyInputMode != "normal" {
+		params.EmptyInput = b.emptyInputMode
+	}
+	if b.ruleIfacePath != "" {
+		params.RuleInterface = ruleIfaceImportName + "." + b.ruleIfaceName
+	}
+	if b.expvarMetrics != "" {
+		params.ExpvarMetrics = strconv.Quote(b.expvarMetrics)
 	}
 	t := template.Must(template.New("static_code").Parse(staticCode))
 
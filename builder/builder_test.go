@@ -1,10 +1,14 @@
 package builder
 
 import (
+	"bytes"
 	"io"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/mna/pigeon/ast"
 	"github.com/mna/pigeon/bootstrap"
 )
 
@@ -38,3 +42,645 @@ func TestBuildParser(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestWarningWriter(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader("start = ( \"\"* ) 'x'\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings bytes.Buffer
+	if err := BuildParser(io.Discard, g, WarnEmptyRepetition(true), WarningWriter(&warnings)); err != nil {
+		t.Fatal(err)
+	}
+	want := "warning: 1:11 (10): rule start: repetition of an expression that can match the empty string\n"
+	if got := warnings.String(); got != want {
+		t.Errorf("want warnings %q, got %q", want, got)
+	}
+}
+
+func TestRuleErrorMessagesUnknownRule(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := map[string]string{"integer": "expected an integer", "nope": "expected nope"}
+	err = BuildParser(io.Discard, g, RuleErrorMessages(msgs))
+	if err == nil || !strings.Contains(err.Error(), `unknown rule "nope"`) {
+		t.Fatalf("want unknown rule error, got %v", err)
+	}
+}
+
+func TestOptimizeRulesUnknownRule(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = BuildParser(io.Discard, g, OptimizeRules([]string{"integer", "nope"}))
+	if err == nil || !strings.Contains(err.Error(), `unknown rule "nope"`) {
+		t.Fatalf("want unknown rule error, got %v", err)
+	}
+}
+
+func TestRuleInterfaceErrors(t *testing.T) {
+	cases := []struct {
+		path, name, err string
+	}{
+		{"example.com/grammars", "rule", "invalid interface name"},
+		{"example.com/grammars", "", "invalid interface name"},
+		{"example.com/grammars", "Rule", "no package clause"},
+	}
+
+	for i, tc := range cases {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(grammar))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = BuildParser(io.Discard, g, RuleInterface(tc.path, tc.name))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: want error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
+
+func TestCaseScopesDisabled(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ci := ast.NewCaseInsensitiveExpr(ast.Pos{Line: 1, Col: 1})
+	ci.Expr = g.Rules[0].Expr
+	g.Rules[0].Expr = ci
+	err = BuildParser(io.Discard, g)
+	if err == nil || !strings.Contains(err.Error(), "requires the CaseScopes option") {
+		t.Fatalf("want case scopes error, got %v", err)
+	}
+	if err := BuildParser(io.Discard, g, CaseScopes(true)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuiltinAssertionsDisabled(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := ast.NewSeqExpr(ast.Pos{Line: 1, Col: 1})
+	seq.Exprs = []ast.Expression{ast.NewAssertionExpr(ast.Pos{Line: 1, Col: 1}, "$"), g.Rules[0].Expr}
+	g.Rules[0].Expr = seq
+	err = BuildParser(io.Discard, g)
+	if err == nil || !strings.Contains(err.Error(), "assertion $ requires the BuiltinAssertions option") {
+		t.Fatalf("want builtin assertions error, got %v", err)
+	}
+	if err := BuildParser(io.Discard, g, BuiltinAssertions(true)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLookbehindLength(t *testing.T) {
+	lit := func(v string) *ast.LitMatcher { return ast.NewLitMatcher(ast.Pos{}, v) }
+	choice := func(alts ...ast.Expression) *ast.ChoiceExpr {
+		ch := ast.NewChoiceExpr(ast.Pos{})
+		ch.Alternatives = alts
+		return ch
+	}
+	cases := []struct {
+		expr ast.Expression
+		n    int
+		ok   bool
+	}{
+		{lit("$"), 1, true},
+		{lit("é€"), 2, true},
+		{choice(lit("ab"), ast.NewCharClassMatcher(ast.Pos{}, "[a-z]")), 0, false},
+		{choice(lit("ab"), lit("cd")), 2, true},
+		{ast.NewZeroOrOneExpr(ast.Pos{}), 0, false},
+	}
+	for i, tc := range cases {
+		n, ok := fixedLength(tc.expr)
+		if n != tc.n || ok != tc.ok {
+			t.Errorf("%d: want %d, %t, got %d, %t", i, tc.n, tc.ok, n, ok)
+		}
+	}
+
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := ast.NewLookbehindExpr(ast.Pos{Line: 1, Col: 1})
+	lb.Expr = &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "eof")}
+	seq := ast.NewSeqExpr(ast.Pos{})
+	seq.Exprs = []ast.Expression{lb, g.Rules[0].Expr}
+	g.Rules[0].Expr = seq
+	err = BuildParser(io.Discard, g)
+	if err == nil || !strings.Contains(err.Error(), "must match a fixed number of characters") {
+		t.Fatalf("want lookbehind length error, got %v", err)
+	}
+}
+
+func TestSortFunctions(t *testing.T) {
+	// same rules as grammar, in a different order
+	reordered := `
+{
+var test = "some string"
+
+func init() {
+	fmt.Println("this is inside the init")
+}
+}
+
+start = additive eof
+eof = !. { fmt.Println("eof") }
+space = ' '*
+integer "integer" = digits:[0123456789]+ space { fmt.Println(digits) }
+primary = integer / "(" space additive:additive ")" space { fmt.Println(additive) }
+multiplicative = left:primary op:"*" space right:multiplicative { fmt.Println(left, right, op) } / primary
+additive = left:multiplicative "+" space right:additive {
+	fmt.Println(left, right)
+} / mul:multiplicative { fmt.Println(mul) }
+`
+	want := []string{
+		"onadditive10", "callonadditive10",
+		"onadditive2", "callonadditive2",
+		"oneof1", "calloneof1",
+		"oninteger1", "calloninteger1",
+		"onmultiplicative2", "callonmultiplicative2",
+		"onprimary3", "callonprimary3",
+	}
+
+	funcRx := regexp.MustCompile(`(?m)^func \((?:c \*current|p \*parser)\) (\w+)\(`)
+	for _, src := range []string{grammar, reordered} {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := BuildParser(&buf, g, SortFunctions(true)); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, m := range funcRx.FindAllStringSubmatch(buf.String(), -1) {
+			if strings.HasPrefix(m[1], "on") || strings.HasPrefix(m[1], "callon") {
+				got = append(got, m[1])
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want functions\n%v\ngot\n%v", want, got)
+		}
+	}
+}
+
+func TestDedupeCharClasses(t *testing.T) {
+	src := `
+start = a b c d
+a = [0-9]+ 'a'
+b = 'b' [0-9]
+c = [0-9]* [a-z]i
+d = [0-9]i
+`
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, DedupeCharClasses(true)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	// [0-9] is shared by a, b and c, [a-z]i and [0-9]i are distinct
+	if got := strings.Count(out, "&charClassMatcher{"); got != 3 {
+		t.Errorf("want 3 character class matchers, got %d", got)
+	}
+	if got := strings.Count(out, "sharedCharClass1,"); got != 3 {
+		t.Errorf("want 3 uses of the shared [0-9] matcher, got %d", got)
+	}
+	if !strings.Contains(out, "sharedCharClass1 = &charClassMatcher{") {
+		t.Error("want the declaration of the shared [0-9] matcher")
+	}
+}
+
+func TestExpandIgnoreCase(t *testing.T) {
+	cases := []struct {
+		raw    string
+		chars  string
+		ranges string
+	}{
+		{"[a-f]i", "", "afAF"},
+		{"[A-F]i", "", "AFaf"},
+		{"[xyz]i", "xyz", "XZ"},
+		{"[a-cB]i", "BAC", "ac"},
+		{"[k]i", "kK\u212a", ""},
+		{"[0-9]i", "", "09"},
+	}
+	for _, tc := range cases {
+		ch := expandIgnoreCase(ast.NewCharClassMatcher(ast.Pos{}, tc.raw), nil)
+		if ch.IgnoreCase {
+			t.Errorf("%s: want case-sensitive class", tc.raw)
+		}
+		if got := string(ch.Chars); got != tc.chars {
+			t.Errorf("%s: want chars %q, got %q", tc.raw, tc.chars, got)
+		}
+		if got := string(ch.Ranges); got != tc.ranges {
+			t.Errorf("%s: want ranges %q, got %q", tc.raw, tc.ranges, got)
+		}
+	}
+
+	ch := ast.NewCharClassMatcher(ast.Pos{}, "[\\pLu]i")
+	if got := expandIgnoreCase(ch, nil); got != ch {
+		t.Errorf("want class with Unicode classes unchanged, got %v", got)
+	}
+}
+
+func TestTableDrivenErrors(t *testing.T) {
+	cases := []struct {
+		opt Option
+		err string
+	}{
+		{LabelSpans(true), "label spans option is not supported"},
+		{SinkResults(true), "sink results option is not supported"},
+		{LosslessCST(true), "lossless CST option is not supported"},
+	}
+	for i, tc := range cases {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(grammar))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = BuildParser(io.Discard, g, tc.opt, TableDriven(true))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: want error containing %q, got %v", i, tc.err, err)
+		}
+	}
+
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := ast.NewSeqExpr(ast.Pos{Line: 1, Col: 1})
+	seq.Exprs = []ast.Expression{g.Rules[0].Expr, ast.NewThrowExpr(ast.Pos{Line: 1, Col: 2})}
+	g.Rules[0].Expr = seq
+	err = BuildParser(io.Discard, g, TableDriven(true))
+	if err == nil || !strings.Contains(err.Error(), "throw expression is not supported") {
+		t.Fatalf("want throw expression error, got %v", err)
+	}
+	if err := BuildParser(io.Discard, g); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestThrowPayload(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	throw := ast.NewThrowExpr(ast.Pos{Line: 1, Col: 2})
+	throw.Label = "err"
+	throw.Payload = ast.NewCodeBlock(ast.Pos{Line: 1, Col: 5}, "{ return 1, nil }")
+	seq := ast.NewSeqExpr(ast.Pos{Line: 1, Col: 1})
+	seq.Exprs = []ast.Expression{g.Rules[0].Expr, throw}
+	g.Rules[0].Expr = seq
+
+	err = BuildParser(io.Discard, g)
+	if err == nil || !strings.Contains(err.Error(), "throw payload requires the TypedThrows option") {
+		t.Fatalf("want throw payload error, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, TypedThrows(true)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "return 1, nil") {
+		t.Error("want the code of the payload in the generated parser")
+	}
+}
+
+func TestRuleArgs(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := ast.NewRuleRefExpr(ast.Pos{Line: 1, Col: 2})
+	ref.Name = ast.NewIdentifier(ast.Pos{Line: 1, Col: 2}, "space")
+	ref.Args = []*ast.RuleArg{
+		{Name: ast.NewIdentifier(ast.Pos{Line: 1, Col: 8}, "n"), Code: ast.NewCodeBlock(ast.Pos{Line: 1, Col: 10}, "{ return 1, nil }")},
+		{Name: ast.NewIdentifier(ast.Pos{Line: 1, Col: 20}, "m"), Code: ast.NewCodeBlock(ast.Pos{Line: 1, Col: 22}, "{ return 2, nil }")},
+	}
+	seq := ast.NewSeqExpr(ast.Pos{Line: 1, Col: 1})
+	seq.Exprs = []ast.Expression{g.Rules[0].Expr, ref}
+	g.Rules[0].Expr = seq
+
+	err = BuildParser(io.Discard, g)
+	if err == nil || !strings.Contains(err.Error(), "rule arguments require the RuntimeParams option") {
+		t.Fatalf("want rule arguments error, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := BuildParser(&buf, g, RuntimeParams(true)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"return 1, nil", "return 2, nil", `{name: "n", value: (*parser).callonstart`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in the generated parser", want)
+		}
+	}
+}
+
+func TestOptionErrors(t *testing.T) {
+	cases := []struct {
+		opts []Option
+		err  string
+	}{
+		{[]Option{BoundedStream(-1)}, "invalid bounded stream window"},
+		{[]Option{BoundedStream(16), ErrorSpans(true)}, "error spans option is not supported"},
+		{[]Option{BoundedStream(16), FuzzyMatch(1)}, "fuzzy match option is not supported"},
+		{[]Option{BoundedStream(16), LosslessCST(true)}, "lossless CST option is not supported"},
+		{[]Option{BoundedStream(16), BidiAware(true)}, "bidi aware option is not supported"},
+		{[]Option{BoundedStream(16), Preprocessors(true)}, "preprocessors option is not supported"},
+		{[]Option{StreamBuffer(-1), SinkResults(true)}, "invalid stream buffer size"},
+		{[]Option{StreamBuffer(4)}, "stream buffer: the sink results option is required"},
+		{[]Option{RuleAssertions(map[string]string{"nope": "v != nil"})}, `rule assertions: unknown rule "nope"`},
+		{[]Option{RuleAssertions(map[string]string{"integer": "v.(int) >"})}, "rule assertions: rule integer: invalid expression"},
+		{[]Option{PrefixDispatch(map[string]string{"v1:": "nope"})}, `prefix dispatch: prefix "v1:": unknown rule "nope"`},
+		{[]Option{PrefixDispatch(map[string]string{"": "integer"})}, "prefix dispatch: empty prefix"},
+		{[]Option{AlternateEntrypoints([]string{"nope"})}, "unknown rule name nope used as alternate entrypoint"},
+		{[]Option{PrefixDispatch(map[string]string{"v1:": "integer"}), BoundedStream(64)}, "bounded stream: the prefix dispatch option is not supported"},
+		{[]Option{ASCIIFastPath(true), BoundedStream(64)}, "bounded stream: the ASCII fast path option is not supported"},
+		{[]Option{EmptyInputMode("empty")}, `empty input mode: unsupported mode "empty"`},
+		{[]Option{EmptyInputMode("nil"), BoundedStream(64)}, "bounded stream: the empty input mode option is not supported"},
+		{[]Option{ProtoResults("node")}, `proto results: invalid message name "node"`},
+		{[]Option{ExplicitStack(true), LabelSpans(true)}, "explicit stack: table-driven parser: the label spans option is not supported"},
+		{[]Option{ExplicitStack(true), RuleTiming(true)}, "explicit stack: the rule timing option is not supported"},
+		{[]Option{ExplicitStack(true), TableDriven(true), BacktrackHooks(true)}, "explicit stack: the backtrack hooks option is not supported"},
+		{[]Option{MemoKeyHook(true), Optimize(true)}, "memo key hook: the optimize parser option is not supported"},
+		{[]Option{ModeAwareMemo(true), Optimize(true)}, "mode aware memo: the optimize parser option is not supported"},
+		{[]Option{MemoReuseTrace(true), Optimize(true)}, "memo reuse trace: the optimize parser option is not supported"},
+		{[]Option{ErrorRepair(true), FuzzyMatch(1)}, "error repair: the fuzzy match option is not supported"},
+		{[]Option{BacktrackHooks(true), LongestMatchDiagnostics(true)}, "backtrack hooks: the longest match diagnostics option is not supported"},
+		{[]Option{MethodReceiver("*T")}, "method receiver: invalid type name"},
+		{[]Option{MethodReceiver("T"), BatchParse(true)}, "method receiver: the batch parse option is not supported"},
+		{[]Option{MethodReceiver("T"), LineRecovery(true)}, "method receiver: the line recovery option is not supported"},
+		{[]Option{EnumerateParses(-1)}, "invalid enumerate parses maximum: -1"},
+		{[]Option{EnumerateParses(4), TableDriven(true)}, "table-driven parser: the enumerate parses option is not supported"},
+		{[]Option{MethodReceiver("T"), CompletionSupport(true)}, "method receiver: the completion support option is not supported"},
+		{[]Option{DenseMemo(true), Optimize(true)}, "dense memo: the optimize parser option is not supported"},
+		{[]Option{DenseMemo(true), ModeAwareMemo(true)}, "dense memo: the mode-aware memoization option is not supported"},
+		{[]Option{AssertEntrypointType("[]")}, `entrypoint type: invalid type "[]"`},
+		{[]Option{LazyRuleInit(true), TableDriven(true)}, "table-driven parser: the lazy rule init option is not supported"},
+		{[]Option{ExplainFailures(true), BoundedStream(64)}, "bounded stream: the explain failures option is not supported"},
+		{[]Option{PushParser(true), ErrorSpans(true)}, "push parser: the error spans option is not supported"},
+	}
+	for i, tc := range cases {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(grammar))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = BuildParser(io.Discard, g, tc.opts...)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: want error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
+
+func TestBaseGrammar(t *testing.T) {
+	p := bootstrap.NewParser()
+	base, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want bytes.Buffer
+	if err := BuildParser(&want, base); err != nil {
+		t.Fatal(err)
+	}
+
+	// the derived grammar overrides the integer rule and inherits the rest,
+	// it is built twice from the same base grammar, which is not modified.
+	for i := 0; i < 2; i++ {
+		g, err := bootstrap.NewParser().Parse("", strings.NewReader("integer = hex / [0-9]+ space\nhex = \"0x\" [0-9a-f]+ space\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got bytes.Buffer
+		if err := BuildParser(&got, g, BaseGrammar(base)); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, r := range g.Rules {
+			names = append(names, r.Name.Val)
+		}
+		if want := "start additive multiplicative primary integer space eof hex"; strings.Join(names, " ") != want {
+			t.Errorf("%d: want rules %s, got %s", i, want, strings.Join(names, " "))
+		}
+		if !strings.Contains(got.String(), `name: "hex"`) || !strings.Contains(got.String(), "this is inside the init") {
+			t.Errorf("%d: want the hex rule and the initializer of the base grammar", i)
+		}
+	}
+
+	var rebuilt bytes.Buffer
+	if err := BuildParser(&rebuilt, base); err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt.String() != want.String() {
+		t.Error("the base grammar builds a different parser")
+	}
+
+	g, err := bootstrap.NewParser().Parse("", strings.NewReader("integer = digit+\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = BuildParser(io.Discard, g, BaseGrammar(base))
+	if want := "rule integer references undefined rule digit"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("want error containing %q, got %v", want, err)
+	}
+}
+
+func TestSerializedGrammar(t *testing.T) {
+	// the parser generated from the deserialized grammar is the same, so it
+	// parses the same inputs the same way.
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data bytes.Buffer
+	if err := ast.SerializeGrammar(&data, g); err != nil {
+		t.Fatal(err)
+	}
+	dg, err := ast.DeserializeGrammar(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got bytes.Buffer
+	if err := BuildParser(&want, g); err != nil {
+		t.Fatal(err)
+	}
+	if err := BuildParser(&got, dg); err != nil {
+		t.Fatal(err)
+	}
+	if want.String() != got.String() {
+		t.Error("want the same parser from the deserialized grammar")
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	cases := []struct {
+		grammar string
+		err     string
+	}{
+		{"A = 'a' { return nil, nil }", ""},
+		{"A = 'a' { return nil nil }", `1:22 (21): rule A: invalid code block: expected ';', found nil`},
+		{"A = 'a' {\n\tx := 1\n\treturn x, nil\n}\nB = 'b' {\n\treturn true,, nil\n}", `6:14 (58): rule B: invalid code block: expected operand, found ','`},
+		{"A = 'a' {\n\tif true {\n\t\treturn nil, nil\n\t}\n\telse {\n\t}\n}", `5:2 (43): rule A: invalid code block: expected statement, found 'else'`},
+	}
+	for i, tc := range cases {
+		p := bootstrap.NewParser()
+		g, err := p.Parse("", strings.NewReader(tc.grammar))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = BuildParser(io.Discard, g, ValidateActions(true))
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: want no error, got %v", i, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%d: want error %q, got %v", i, tc.err, err)
+		}
+	}
+
+	// the code blocks are not parsed by default
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(cases[1].grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := BuildParser(io.Discard, g); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildBenchmarks(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildBenchmarks(&buf, g); err != nil || buf.Len() != 0 {
+		t.Fatalf("want nothing without the option, got %q, %v", buf.String(), err)
+	}
+	err = BuildBenchmarks(&buf, g, EmitBenchmarks(true))
+	if err == nil || !strings.Contains(err.Error(), "no package clause") {
+		t.Fatalf("want package clause error, got %v", err)
+	}
+
+	g.Init.Val = "{\npackage calc\n" + strings.TrimPrefix(g.Init.Val, "{")
+	buf.Reset()
+	if err := BuildBenchmarks(&buf, g, EmitBenchmarks(true), MethodReceiver("Calc"), BenchmarkInputs([]string{"testdata/a.txt"})); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"package calc",
+		"func BenchmarkStart(b *testing.B) {",
+		`{name: "small", data: "0 "},`,
+		`{name: "a.txt", file: "testdata/a.txt"},`,
+		"new(Calc).Parse(in.name, data)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in the benchmarks, got\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestBuildMmap(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := BuildMmap(&buf, g); err != nil || buf.Len() != 0 {
+		t.Fatalf("want nothing without the option, got %q, %v", buf.String(), err)
+	}
+	err = BuildMmap(&buf, g, MmapInput(true))
+	if err == nil || !strings.Contains(err.Error(), "no package clause") {
+		t.Fatalf("want package clause error, got %v", err)
+	}
+
+	g.Init.Val = "{\npackage calc\n" + strings.TrimPrefix(g.Init.Val, "{")
+	buf.Reset()
+	if err := BuildMmap(&buf, g, MmapInput(true), MethodReceiver("Calc")); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"//go:build unix",
+		"package calc",
+		"func (recv *Calc) ParseMmap(filename string, opts ...Option) (i any, release func() error, err error) {",
+		"i, err = recv.Parse(filename, data, opts...)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in the mmap file, got\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := BuildMmapFallback(&buf, g, MmapInput(true), MethodReceiver("Calc")); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"//go:build !unix",
+		"package calc",
+		"func (recv *Calc) ParseMmap(filename string, opts ...Option) (i any, release func() error, err error) {",
+		"i, err = recv.ParseFile(filename, opts...)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in the mmap fallback file, got\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := BuildParser(&buf, g, MmapInput(true)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "go:build") || strings.Contains(buf.String(), "ParseMmap") {
+		t.Errorf("want no build constraint nor ParseMmap in the parser")
+	}
+}
+
+func TestGrammarPackage(t *testing.T) {
+	cases := []struct {
+		init string
+		want string
+	}{
+		{"{\npackage calc\n}", "calc"},
+		{"{\n// Package calc is a calculator.\npackage calc\n}", "calc"},
+		{"{\n/*\npackage example\n*/\npackage calc\n}", "calc"},
+		{"{\n/*\npackage example\n*/\n}", ""},
+		{"{\nvar x = 1\n}", ""},
+	}
+	for _, tc := range cases {
+		g := &ast.Grammar{Init: ast.NewCodeBlock(ast.Pos{}, tc.init)}
+		if got := grammarPackage(g); got != tc.want {
+			t.Errorf("%q: want %q, got %q", tc.init, tc.want, got)
+		}
+	}
+	if got := grammarPackage(&ast.Grammar{}); got != "" {
+		t.Errorf("want no package without initializer, got %q", got)
+	}
+}
@@ -0,0 +1,54 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// BuildParserDigest returns a digest of the parser that BuildParser
+// generates for the grammar g with the options opts, so that build tools
+// can skip the generation of a parser when its digest has not changed,
+// e.g. to name the generated file in a content-addressed cache. The digest
+// is the hex-encoded SHA-256 hash of the binary encoding of g and of the
+// base grammar, of the values of the options and of the code template of
+// the generated parser. The options that only write warnings are not
+// covered. The digest of a grammar must be computed before it is passed
+// to BuildParser, which modifies it.
+//
+// The digest only depends on the template of the generated parser, not
+// on the rest of the version of pigeon, so tools should also generate the
+// parser again when pigeon is upgraded.
+func BuildParserDigest(g *ast.Grammar, opts ...Option) (string, error) {
+	b := &builder{recvName: "c"}
+	b.setOptions(opts)
+
+	h := sha256.New()
+	io.WriteString(h, staticCode)
+	io.WriteString(h, rangeTable0)
+	if err := ast.SerializeGrammar(h, g); err != nil {
+		return "", fmt.Errorf("digest: %w", err)
+	}
+	if b.baseGrammar != nil {
+		if err := ast.SerializeGrammar(h, b.baseGrammar); err != nil {
+			return "", fmt.Errorf("digest: base grammar: %w", err)
+		}
+	}
+
+	// the fields that are not set by the options are zero values, so that
+	// new options are covered without listing them
+	v := reflect.ValueOf(b).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		if name == "baseGrammar" || strings.HasPrefix(name, "warn") {
+			continue
+		}
+		fmt.Fprintf(h, "%s=%#v\n", name, v.Field(i))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,59 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/ast"
+	"github.com/mna/pigeon/bootstrap"
+)
+
+func TestBuildParserDigest(t *testing.T) {
+	parse := func(src string) *ast.Grammar {
+		g, err := bootstrap.NewParser().Parse("", strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	digest := func(g *ast.Grammar, opts ...Option) string {
+		d, err := BuildParserDigest(g, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	want := digest(parse(grammar))
+	if len(want) != 64 {
+		t.Fatalf("want a SHA-256 hex digest, got %q", want)
+	}
+	same := []struct {
+		name string
+		d    string
+	}{
+		{"parsed again", digest(parse(grammar))},
+		{"default option", digest(parse(grammar), Optimize(false))},
+		{"warning option", digest(parse(grammar), WarnUnusedLabels(true))},
+	}
+	for _, tc := range same {
+		if tc.d != want {
+			t.Errorf("%s: want digest %s, got %s", tc.name, want, tc.d)
+		}
+	}
+
+	changed := []struct {
+		name string
+		d    string
+	}{
+		{"grammar", digest(parse(strings.Replace(grammar, `"+"`, `"-"`, 1)))},
+		{"option", digest(parse(grammar), Optimize(true))},
+		{"option value", digest(parse(grammar), RuleErrorMessages(map[string]string{"additive": "expected a sum"}))},
+		{"base grammar", digest(parse(grammar), BaseGrammar(parse("x = 'x'")))},
+	}
+	for _, tc := range changed {
+		if tc.d == want {
+			t.Errorf("%s: want a different digest, got %s", tc.name, tc.d)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package builder
+
+import (
+	"fmt"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// EmptyRepetitionWarnings returns a warning for each zero-or-more or
+// one-or-more repetition of the grammar whose expression can match the
+// empty string, e.g. ( "a"? )*. Such a repetition never stops once its
+// expression matches without consuming input, so the generated parser
+// loops forever. The warnings are in the order of the rules.
+func EmptyRepetitionWarnings(g *ast.Grammar) []string {
+	rules := make(map[string]*ast.Rule, len(g.Rules))
+	for _, rule := range g.Rules {
+		rules[rule.Name.Val] = rule
+	}
+	ComputeNullables(rules)
+
+	var warnings []string
+	for _, rule := range g.Rules {
+		ast.Inspect(rule.Expr, func(expr ast.Expression) bool {
+			var inner ast.Expression
+			switch expr := expr.(type) {
+			case *ast.ZeroOrMoreExpr:
+				inner = expr.Expr
+			case *ast.OneOrMoreExpr:
+				inner = expr.Expr
+			default:
+				return true
+			}
+			if inner.NullableVisit(rules) {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: rule %s: repetition of an expression that can match the empty string",
+					expr.Pos(), rule.Name.Val))
+			}
+			return true
+		})
+	}
+	return warnings
+}
@@ -0,0 +1,42 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/bootstrap"
+)
+
+func TestEmptyRepetitionWarnings(t *testing.T) {
+	src := `
+start = ( ""* ) a b+ c
+a = ( 'x'? )*
+b = opt
+opt = 'y' / &'z'
+c = ( 'z' 'w'* )+ 'v'*
+`
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"2:11 (11): rule start: repetition of an expression that can match the empty string",
+		"2:19 (19): rule start: repetition of an expression that can match the empty string",
+		"3:7 (30): rule a: repetition of an expression that can match the empty string",
+	}
+	got := EmptyRepetitionWarnings(g)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want warnings\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	g, err = p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := EmptyRepetitionWarnings(g); len(got) != 0 {
+		t.Errorf("want no warning, got %v", got)
+	}
+}
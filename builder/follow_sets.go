@@ -0,0 +1,240 @@
+package builder
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// ruleSets holds the FIRST and FOLLOW sets of a rule, as the sorted
+// expected values reported by the generated parser for the tokens of the
+// sets, e.g. "\"a\"" for a literal, "[0-9]" for a character class, "." for
+// any character and "!." for the end of the input.
+type ruleSets struct {
+	first    []string
+	follow   []string
+	nullable bool
+}
+
+// tokenSet is a set of expected values.
+type tokenSet map[string]struct{}
+
+func (s tokenSet) addAll(o tokenSet) bool {
+	changed := false
+	for k := range o {
+		if _, ok := s[k]; !ok {
+			s[k] = struct{}{}
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (s tokenSet) sorted() []string {
+	list := make([]string, 0, len(s))
+	for k := range s {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// followSets computes the FIRST and FOLLOW sets of the rules of the
+// grammar, by name. The sets are computed by fixpoint iteration: the FIRST
+// sets and the nullable attributes of the rules are grown until they don't
+// change, and then the FOLLOW sets. Predicates and code expressions match
+// no token, except the not predicate of any character, which matches the
+// end of the input.
+func followSets(g *ast.Grammar) map[string]*ruleSets {
+	c := newSetsComputer(g)
+	for c.changed = true; c.changed; {
+		c.changed = false
+		for _, rule := range g.Rules {
+			c.followOf(rule.Expr, c.follow[rule.Name.Val], false)
+		}
+	}
+
+	sets := make(map[string]*ruleSets, len(g.Rules))
+	for _, rule := range g.Rules {
+		nm := rule.Name.Val
+		sets[nm] = &ruleSets{
+			first:    c.first[nm].sorted(),
+			follow:   c.follow[nm].sorted(),
+			nullable: c.nullable[nm],
+		}
+	}
+	return sets
+}
+
+type setsComputer struct {
+	first    map[string]tokenSet
+	follow   map[string]tokenSet
+	nullable map[string]bool
+	changed  bool
+}
+
+// newSetsComputer returns a setsComputer with the FIRST sets and nullable
+// attributes of the rules of the grammar computed.
+func newSetsComputer(g *ast.Grammar) *setsComputer {
+	c := &setsComputer{
+		first:    make(map[string]tokenSet, len(g.Rules)),
+		follow:   make(map[string]tokenSet, len(g.Rules)),
+		nullable: make(map[string]bool, len(g.Rules)),
+	}
+	for _, rule := range g.Rules {
+		c.first[rule.Name.Val] = tokenSet{}
+		c.follow[rule.Name.Val] = tokenSet{}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range g.Rules {
+			first, nullable := c.firstOf(rule.Expr, false)
+			if c.first[rule.Name.Val].addAll(first) {
+				changed = true
+			}
+			if nullable && !c.nullable[rule.Name.Val] {
+				c.nullable[rule.Name.Val] = true
+				changed = true
+			}
+		}
+	}
+	return c
+}
+
+// firstOf returns the FIRST set of expr and whether it can match the
+// empty string, based on the sets of the rules computed so far. If
+// ignoreCase is true, expr is in a case-insensitive region.
+func (c *setsComputer) firstOf(expr ast.Expression, ignoreCase bool) (tokenSet, bool) {
+	switch expr := expr.(type) {
+	case *ast.ActionExpr:
+		return c.firstOf(expr.Expr, ignoreCase)
+	case *ast.AnyMatcher:
+		return tokenSet{".": {}}, false
+	case *ast.BalancedExpr:
+		return tokenSet{strconv.Quote(expr.Open.Val): {}}, false
+	case *ast.CaseInsensitiveExpr:
+		return c.firstOf(expr.Expr, true)
+	case *ast.CharClassMatcher:
+		val := expr.Val
+		if ignoreCase && !expr.IgnoreCase {
+			val += "i"
+		}
+		return tokenSet{val: {}}, false
+	case *ast.ChoiceExpr:
+		set, nullable := tokenSet{}, false
+		for _, alt := range expr.Alternatives {
+			first, null := c.firstOf(alt, ignoreCase)
+			set.addAll(first)
+			nullable = nullable || null
+		}
+		return set, nullable
+	case *ast.LabeledExpr:
+		return c.firstOf(expr.Expr, ignoreCase)
+	case *ast.LitMatcher:
+		if expr.Val == "" {
+			return tokenSet{}, true
+		}
+		want := strconv.Quote(expr.Val)
+		if expr.IgnoreCase || ignoreCase {
+			want += "i"
+		}
+		return tokenSet{want: {}}, false
+	case *ast.NotExpr:
+		if _, ok := expr.Expr.(*ast.AnyMatcher); ok {
+			return tokenSet{"!.": {}}, false
+		}
+		return tokenSet{}, true
+	case *ast.OneOrMoreExpr:
+		return c.firstOf(expr.Expr, ignoreCase)
+	case *ast.RecoveryExpr:
+		set, nullable := c.firstOf(expr.Expr, ignoreCase)
+		first, null := c.firstOf(expr.RecoverExpr, ignoreCase)
+		set.addAll(first)
+		return set, nullable || null
+	case *ast.RuleRefExpr:
+		set := tokenSet{}
+		set.addAll(c.first[expr.Name.Val])
+		return set, c.nullable[expr.Name.Val]
+	case *ast.ScanLimitExpr:
+		return c.firstOf(expr.Expr, ignoreCase)
+	case *ast.SeqExpr:
+		set := tokenSet{}
+		for _, item := range expr.Exprs {
+			first, nullable := c.firstOf(item, ignoreCase)
+			set.addAll(first)
+			if !nullable {
+				return set, false
+			}
+		}
+		return set, true
+	case *ast.ThrowExpr:
+		return tokenSet{}, false
+	case *ast.ZeroOrMoreExpr:
+		first, _ := c.firstOf(expr.Expr, ignoreCase)
+		return first, true
+	case *ast.ZeroOrOneExpr:
+		first, _ := c.firstOf(expr.Expr, ignoreCase)
+		return first, true
+	default:
+		// predicates, code expressions, until expressions and line starts
+		return tokenSet{}, true
+	}
+}
+
+// followOf adds the tokens that can follow expr, given the set of tokens
+// that can follow it in its parent expression, to the FOLLOW sets of the
+// rules referenced by expr.
+func (c *setsComputer) followOf(expr ast.Expression, follow tokenSet, ignoreCase bool) {
+	switch expr := expr.(type) {
+	case *ast.ActionExpr:
+		c.followOf(expr.Expr, follow, ignoreCase)
+	case *ast.AndExpr:
+		c.followOf(expr.Expr, follow, ignoreCase)
+	case *ast.CaseInsensitiveExpr:
+		c.followOf(expr.Expr, follow, true)
+	case *ast.ChoiceExpr:
+		for _, alt := range expr.Alternatives {
+			c.followOf(alt, follow, ignoreCase)
+		}
+	case *ast.LabeledExpr:
+		c.followOf(expr.Expr, follow, ignoreCase)
+	case *ast.OneOrMoreExpr:
+		c.followOf(expr.Expr, c.repeated(expr.Expr, follow, ignoreCase), ignoreCase)
+	case *ast.RecoveryExpr:
+		c.followOf(expr.Expr, follow, ignoreCase)
+		c.followOf(expr.RecoverExpr, follow, ignoreCase)
+	case *ast.RuleRefExpr:
+		if set, ok := c.follow[expr.Name.Val]; ok && set.addAll(follow) {
+			c.changed = true
+		}
+	case *ast.ScanLimitExpr:
+		c.followOf(expr.Expr, follow, ignoreCase)
+	case *ast.SeqExpr:
+		// the items are processed from the last one, which is followed by
+		// what follows the sequence.
+		for i := len(expr.Exprs) - 1; i >= 0; i-- {
+			item := expr.Exprs[i]
+			c.followOf(item, follow, ignoreCase)
+			first, nullable := c.firstOf(item, ignoreCase)
+			if nullable {
+				first.addAll(follow)
+			}
+			follow = first
+		}
+	case *ast.ZeroOrMoreExpr:
+		c.followOf(expr.Expr, c.repeated(expr.Expr, follow, ignoreCase), ignoreCase)
+	case *ast.ZeroOrOneExpr:
+		c.followOf(expr.Expr, follow, ignoreCase)
+	}
+}
+
+// repeated returns the set of tokens that can follow the expression of a
+// repetition: its own FIRST set, as it may be matched again, and what
+// follows the repetition.
+func (c *setsComputer) repeated(expr ast.Expression, follow tokenSet, ignoreCase bool) tokenSet {
+	set, _ := c.firstOf(expr, ignoreCase)
+	set.addAll(follow)
+	return set
+}
@@ -0,0 +1,38 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/bootstrap"
+)
+
+func TestFollowSets(t *testing.T) {
+	src := `
+start = list !.
+list = '(' items? ')'
+items = item ( ',' item )*
+item = sp ( [0-9]+ / list / "nil"i )
+sp = ' '*
+`
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*ruleSets{
+		"start": {first: []string{`"("`}, follow: []string{}},
+		"list":  {first: []string{`"("`}, follow: []string{"!.", `")"`, `","`}},
+		"items": {first: []string{`" "`, `"("`, `"nil"i`, "[0-9]"}, follow: []string{`")"`}},
+		"item":  {first: []string{`" "`, `"("`, `"nil"i`, "[0-9]"}, follow: []string{`")"`, `","`}},
+		"sp":    {first: []string{`" "`}, follow: []string{`"("`, `"nil"i`, "[0-9]"}, nullable: true},
+	}
+	got := followSets(g)
+	for nm, sets := range want {
+		if !reflect.DeepEqual(got[nm], sets) {
+			t.Errorf("%s: want %+v, got %+v", nm, *sets, *got[nm])
+		}
+	}
+}
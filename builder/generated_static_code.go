@@ -18,8 +18,54 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
+	// ==template== {{ if .SinkResults }}
+
+	// errSinkStop is used to signal that the sink function of ParseTo
+	// returned an error.
+	errSinkStop = errors.New("sink returned an error")
+	// {{ end }} ==template==
+	// ==template== {{ if .StreamBuffer }}
+
+	// errStreamDone is returned by ParseChan when its done channel is
+	// closed before the parsing ends.
+	errStreamDone = errors.New("stream results abandoned")
+	// {{ end }} ==template==
+	// ==template== {{ if .StreamWindow }}
+
+	// errStreamWindow is returned when the parser of a stream must go back
+	// to input that was evicted from its window.
+	errStreamWindow = errors.New("backtracking past the stream window")
+	// {{ end }} ==template==
+	// ==template== {{ if .SubParse }}
+
+	// errInvalidStart is returned when the start offset of ParseSub is
+	// outside of its input.
+	errInvalidStart = errors.New("invalid start offset")
+	// {{ end }} ==template==
+	// ==template== {{ if or .LineRecovery .PushParser }}
+
+	// errEmptyItem is returned by ParseLines and PushParser when an item
+	// matches no input.
+	errEmptyItem = errors.New("item matched no input")
+	// {{ end }} ==template==
+	// ==template== {{ if .ProtoResults }}
+
+	// errInvalidProto is returned when a message to unmarshal is not in
+	// the protobuf wire format.
+	errInvalidProto = errors.New("invalid protobuf message")
+	// {{ end }} ==template==
 )
 
+// ==template== {{ if eq .EmptyInput "error" }}
+// ErrEmptyInput is returned when the input to parse is empty.
+var ErrEmptyInput = errors.New("empty input")
+
+// {{ end }} ==template==
+
 // Option is a function that can set an option on the parser. It returns
 // the previous setting as an Option.
 type Option func(*parser) Option
@@ -37,6 +83,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -114,6 +173,355 @@ func Memoize(b bool) Option {
 
 // {{ end }} ==template==
 
+// ==template== {{ if .OverridableActions }}
+// ActionFunc is the implementation of an action code block. It is called
+// with the current match and the values of the labels in scope, in the
+// order they are defined, and returns the value of the action expression.
+type ActionFunc func(c *current, args ...any) (any, error)
+
+// SetAction creates an Option to replace the implementation of the action
+// code block whose generated function is named name (e.g. "onRule1") by
+// fn. If fn is nil, the generated implementation is restored.
+//
+// Actions are called through a map lookup and a variadic function call,
+// which is slower than the direct method call of a parser generated
+// without overridable actions.
+func SetAction(name string, fn ActionFunc) Option {
+	return func(p *parser) Option {
+		old := p.actions[name]
+		if fn == nil {
+			fn = actionFuncs[name]
+		}
+		// the map may be shared with the generated defaults, copy it
+		actions := make(map[string]ActionFunc, len(p.actions)+1)
+		for k, v := range p.actions {
+			actions[k] = v
+		}
+		actions[name] = fn
+		p.actions = actions
+		return SetAction(name, old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .RuleTiming }}
+// RuleTimes creates an Option to accumulate in dst the wall-clock time
+// spent in each rule, keyed by rule name. The time of a rule includes the
+// time of the rules it calls, but a rule that calls itself, directly or
+// not, is only timed for its outermost call. dst is updated even if
+// parsing fails.
+//
+// Timing reads the monotonic clock twice per call of a rule, which adds a
+// small overhead to each rule, noticeable for grammars with many short
+// rules.
+//
+// The default is to not time the rules.
+func RuleTimes(dst map[string]time.Duration) Option {
+	return func(p *parser) Option {
+		old := p.ruleTimes
+		p.ruleTimes = dst
+		return RuleTimes(old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ReportMaxDepth }}
+// Depth is the deepest nesting reached by the parsing of inputs, see the
+// ReachedDepth option.
+type Depth struct {
+	// Rules is the deepest nesting of rules being parsed, i.e. of rules
+	// called by the rules being parsed.
+	Rules int
+	// VStack is the deepest value stack, which the MaxVStackDepth option
+	// limits: the inputs are parsed with a limit of at least VStack.
+	VStack int
+}
+
+// ReachedDepth creates an Option to record in dst the deepest nesting
+// reached while parsing, e.g. to set the limit of the MaxVStackDepth
+// option with some margin over the nesting of the expected inputs. dst is
+// updated even if parsing fails, and it is not reset, so that it holds the
+// deepest nesting of all the inputs parsed with it.
+//
+// The default is to not record the depth.
+func ReachedDepth(dst *Depth) Option {
+	return func(p *parser) Option {
+		old := p.reached
+		p.reached = dst
+		return ReachedDepth(old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .StructuredTrace }}
+// TraceLogger receives the rules entered and exited by the parser, see the
+// Trace option. It can be implemented to adapt the trace to a structured
+// logger.
+type TraceLogger interface {
+	// Enter is called when the parser starts parsing the rule at the
+	// position.
+	Enter(rule string, line, col, offset int)
+	// Exit is called when the parser is done parsing the rule, with
+	// whether it matched.
+	Exit(rule string, matched bool)
+}
+
+// Trace creates an Option to call the methods of logger for each rule
+// entered and exited by the parser. The calls are balanced, except if the
+// parsing panics. Memoized results of rules are reported like parsed ones.
+//
+// The default is to not trace the rules.
+func Trace(logger TraceLogger) Option {
+	return func(p *parser) Option {
+		old := p.tracer
+		p.tracer = logger
+		return Trace(old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ChromeTrace }}
+// ChromeTrace creates an Option to record the rules entered and exited by
+// the parser as duration events, and to write them to w in the Chrome
+// tracing JSON format once parsing is done, even if it fails. The trace
+// can be loaded in chrome://tracing or Perfetto to see where the parsing
+// time goes. If the trace cannot be written and parsing succeeded, the
+// error of the writing is returned.
+//
+// Like the RuleTimes option, tracing reads the monotonic clock twice per
+// call of a rule, and it also keeps the events in memory until parsing is
+// done.
+//
+// The default is to not trace the rules.
+func ChromeTrace(w io.Writer) Option {
+	return func(p *parser) Option {
+		old := p.chromeTrace
+		p.chromeTrace = w
+		return ChromeTrace(old)
+	}
+}
+
+// traceEvent is a duration event of the Chrome tracing format, the begin
+// or end of the call of a rule at an offset.
+type traceEvent struct {
+	rule   string
+	ph     string
+	ts     time.Duration
+	offset int
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .OTelSpans }}
+// SpanTracer starts the spans of the parses, see ContextWithTracer. It
+// can be implemented by an adapter of an OpenTelemetry tracer, so that
+// the parser does not depend on OpenTelemetry.
+type SpanTracer interface {
+	// Start starts a span named name, as a child of the span of ctx if
+	// there is one.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a SpanTracer.
+type Span interface {
+	// SetAttribute sets the attribute key of the span to value, which is
+	// a string, an int or a bool.
+	SetAttribute(key string, value any)
+	// RecordError records err in the span and sets its status to error.
+	RecordError(err error)
+	// End ends the span.
+	End()
+}
+
+// spanTracerKey is the key of the SpanTracer of a context.
+type spanTracerKey struct{}
+
+// ContextWithTracer returns a copy of ctx that carries tracer, so that
+// the parses with the WithContext option of this context are wrapped in a
+// span started by tracer. The span is named "parse" and has the
+// attributes parse.filename, parse.entrypoint, parse.input_size in bytes
+// and parse.error, set if the parse failed or panicked.
+func ContextWithTracer(ctx context.Context, tracer SpanTracer) context.Context { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	return context.WithValue(ctx, spanTracerKey{}, tracer)
+}
+
+// WithContext creates an Option to parse in the context ctx, e.g. the
+// context of the request that the input comes with. If ctx carries a
+// SpanTracer, see ContextWithTracer, the parse is wrapped in a span.
+//
+// The default is to parse without a context.
+func WithContext(ctx context.Context) Option {
+	return func(p *parser) Option {
+		old := p.ctx
+		p.ctx = ctx
+		return WithContext(old)
+	}
+}
+
+// startSpan starts the span of the parse with the SpanTracer of the
+// context of the parser, or returns nil if there is none.
+func (p *parser) startSpan() Span {
+	if p.ctx == nil {
+		return nil
+	}
+	tracer, ok := p.ctx.Value(spanTracerKey{}).(SpanTracer)
+	if !ok {
+		return nil
+	}
+	_, span := tracer.Start(p.ctx, "parse")
+	span.SetAttribute("parse.filename", p.filename)
+	span.SetAttribute("parse.entrypoint", p.entrypoint)
+	span.SetAttribute("parse.input_size", len(p.data))
+	return span
+}
+
+// endSpan ends the span of the parse that returned the error *err, or
+// that panicked without the Recover option, in which case the span
+// records the panic as the error and the panic goes on.
+func (p *parser) endSpan(span Span, err *error) {
+	if e := recover(); e != nil {
+		perr, ok := e.(error)
+		if !ok {
+			perr = fmt.Errorf("%v", e)
+		}
+		span.SetAttribute("parse.error", true)
+		span.RecordError(perr)
+		span.End()
+		panic(e)
+	}
+
+	span.SetAttribute("parse.error", *err != nil)
+	if *err != nil {
+		span.RecordError(*err)
+	}
+	span.End()
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ProgressCallback }}
+// Progress creates an Option to call fn every time the parser advances by
+// at least every bytes in the input, with the offset reached and the
+// total length of the input. The offsets passed to fn are strictly
+// increasing, backtracking does not report a lower offset. If parsing
+// succeeds, fn is called a last time with an offset equal to total, unless
+// it has already been reported. If every is less than 1, fn is called
+// every time the parser advances.
+//
+// A larger value of every reduces the overhead of the calls to fn.
+//
+// The default is to not report the progress.
+func Progress(every int, fn func(offset, total int)) Option {
+	return func(p *parser) Option {
+		oldEvery, oldFn := p.progressEvery, p.onProgress
+		if every < 1 {
+			every = 1
+		}
+		p.progressEvery, p.onProgress = every, fn
+		return Progress(oldEvery, oldFn)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .MemoKeyHook }}
+// MemoKey creates an Option to set the function that returns a
+// discriminator added to the key of the memoized results, in addition to
+// the position in the input and the expression. It is called with the
+// current state before the expression is parsed, and the value it returns
+// must be comparable.
+//
+// Memoization is only sound if the result of an expression depends on
+// nothing but the input at its position and this discriminator. If
+// predicates or actions depend on a parser mode, e.g. a value of the
+// state set by a state code block, the discriminator must capture this
+// mode. Changes to the state made while parsing the expression are not
+// replayed when a memoized result is used.
+//
+// The default is to key the memoized results by position and expression
+// only.
+func MemoKey(fn func(c *current) any) Option {
+	return func(p *parser) Option {
+		old := p.memoKeyFunc
+		p.memoKeyFunc = fn
+		return MemoKey(old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .FuzzyMatch }}
+// Corrections creates an Option to store the corrections applied by
+// fuzzy matching in dst when parsing succeeds. dst is left untouched if
+// parsing fails or if the input matched without corrections.
+//
+// The default is to discard the corrections.
+func Corrections(dst *[]Correction) Option {
+	return func(p *parser) Option {
+		old := p.corrections
+		p.corrections = dst
+		return Corrections(old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ErrorRepair }}
+// Repairs creates an Option to store the literals inserted by error
+// repair in dst when parsing succeeds. dst is left untouched if parsing
+// fails or if the input matched without repairs.
+//
+// The default is to discard the repairs.
+func Repairs(dst *[]Repair) Option {
+	return func(p *parser) Option {
+		old := p.repairs
+		p.repairs = dst
+		return Repairs(old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .MemoReuseTrace }}
+// MemoReuses creates an Option to store in dst the memoized results that
+// the parser reused instead of matching their expression again, in the
+// order they were reused. dst is set even if parsing fails, and the
+// results are only memoized with the Memoize option.
+//
+// The default is to discard the reused results.
+func MemoReuses(dst *[]MemoReuse) Option {
+	return func(p *parser) Option {
+		old := p.memoReuses
+		p.memoReuses = dst
+		return MemoReuses(old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .RuleBoundaryPositions }}
+// RulePositions creates an Option to store in dst the positions of the
+// rules in the result of the parsing, in the order their matches end, so
+// that a rule follows the rules it contains. The rules matched on paths
+// that were backtracked are not stored, and dst is left untouched if
+// parsing fails. The results are not memoized when the positions are
+// stored.
+//
+// The default is to discard the positions.
+func RulePositions(dst *[]RulePosition) Option {
+	return func(p *parser) Option {
+		old := p.rulePositions
+		p.rulePositions = dst
+		return RulePositions(old)
+	}
+}
+
+// {{ end }} ==template==
+
 // AllowInvalidUTF8 creates an Option to allow invalid UTF-8 bytes.
 // Every invalid UTF-8 byte is treated as a utf8.RuneError (U+FFFD)
 // by character class matchers and is matched by the any matcher.
@@ -166,8 +574,65 @@ func InitState(key string, value any) Option {
 
 // {{ end }} ==template==
 
+// ==template== {{ if .UserData }}
+// UserData creates an Option to attach the value data to the parser, e.g.
+// the configuration of the parse, which the code blocks get with
+// c.data(). The data is never modified nor rolled back by the parser,
+// and it only lives as long as the parse, each parse starts with the
+// data of its options.
+//
+// The default is nil.
+func UserData(data any) Option {
+	return func(p *parser) Option {
+		old := p.cur.userData
+		p.cur.userData = data
+		return UserData(old)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .Preprocessors }}
+// PositionMap maps the byte offsets of the output of a preprocessing stage
+// to the byte offsets of its input. A nil PositionMap maps each offset to
+// itself, for the stages that do not move the text.
+type PositionMap func(offset int) int
+
+// Preprocessor is a preprocessing stage of the input, e.g. to strip the
+// comments or join the continued lines. It returns the preprocessed input
+// and the map of its offsets to the offsets of the input.
+type Preprocessor func(input []byte) ([]byte, PositionMap)
+
+// Preprocess creates an Option to run the preprocessing stages on the input
+// before parsing it, in order, each stage getting the output of the
+// previous one. The parser composes the position maps of the stages, so
+// that the errors report their position in the input before any stage.
+// The positions seen by the code blocks are in the preprocessed input.
+func Preprocess(stages ...Preprocessor) Option {
+	return func(p *parser) Option {
+		old := p.preprocessors
+		p.preprocessors = stages
+		return Preprocess(old...)
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .MethodReceiver }}
+// receiver returns an option that sets the value whose method parses the
+// input, which the code blocks get with c.recv.
+func receiver(recv *{{ .MethodReceiver }}) Option {
+	return func(p *parser) Option {
+		old := p.cur.recv
+		p.cur.recv = recv
+		return receiver(old)
+	}
+}
+
+// {{ end }} ==template==
+
 // ParseFile parses the file identified by filename.
-func ParseFile(filename string, opts ...Option) (i any, err error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+func {{ if .MethodReceiver }}(recv *{{ .MethodReceiver }}) {{ end }}ParseFile(filename string, opts ...Option) (i any, err error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
@@ -177,1192 +642,6497 @@ func ParseFile(filename string, opts ...Option) (i any, err error) { //{{ if .No
 			err = closeErr
 		}
 	}()
-	return ParseReader(filename, f, opts...)
+	return {{ if .MethodReceiver }}recv.{{ end }}ParseReader(filename, f, opts...)
 }
 
+// ==template== {{ if .StreamWindow }}
+
+// streamWindow is the number of bytes of input that the parser of a stream
+// keeps behind its current position.
+const streamWindow = {{ .StreamWindow }}
+
+// ParseStream parses the data from r using filename as information in the
+// error messages. Unlike ParseReader, r is not read in memory first: it is
+// read as the parsing progresses, and only the last streamWindow bytes of
+// input behind the current position are kept. The parsing fails if it
+// must go back further than that, i.e. if an alternative fails after it
+// matched more than streamWindow bytes. The text of the current match is
+// nil in the code blocks of the expressions whose match starts before the
+// window. This allows to parse unbounded streams such as network
+// connections, if the grammar only needs a bounded lookahead.
+func {{ if .MethodReceiver }}(recv *{{ .MethodReceiver }}) {{ end }}ParseStream(filename string, r io.Reader, opts ...Option) (val any, err error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	// ==template== {{ if .MethodReceiver }}
+	opts = append(opts[:len(opts):len(opts)], receiver(recv))
+	// {{ end }} ==template==
+	p := newParser(filename, make([]byte, 0, 2*streamWindow+utf8.UTFMax), opts...)
+	p.stream = r
+
+	defer func() {
+		// the window is exceeded by the grammar, not by a bug of the code
+		// blocks, so it is an error even if Recover(false) is set
+		if e := recover(); e != nil {
+			if e != errStreamWindow {
+				panic(e)
+			}
+			p.addErr(errStreamWindow)
+			val, err = nil, p.errs.err()
+		}
+	}()
+	return p.parse(g)
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .GraphemeInput }}
+// ParseRunes parses the already decoded runes rs using filename as
+// information in the error messages. Unlike Parse, the any matcher matches
+// a whole grapheme cluster, i.e. a user-perceived character such as a
+// letter with combining marks or an emoji with a skin tone modifier, and
+// the columns of the positions count the grapheme clusters. The offsets of
+// the positions are those of the UTF-8 encoding of rs, which is the text
+// of the matches.
+func {{ if .MethodReceiver }}(recv *{{ .MethodReceiver }}) {{ end }}ParseRunes(filename string, rs []rune, opts ...Option) (any, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	// ==template== {{ if .MethodReceiver }}
+	opts = append(opts[:len(opts):len(opts)], receiver(recv))
+	// {{ end }} ==template==
+	p := newParser(filename, []byte(string(rs)), opts...)
+	p.graphemes = true
+	return p.parse(g)
+}
+
+// {{ end }} ==template==
+
 // ParseReader parses the data from r using filename as information in the
 // error messages.
-func ParseReader(filename string, r io.Reader, opts ...Option) (any, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+func {{ if .MethodReceiver }}(recv *{{ .MethodReceiver }}) {{ end }}ParseReader(filename string, r io.Reader, opts ...Option) (any, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
 	b, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
 
-	return Parse(filename, b, opts...)
+	return {{ if .MethodReceiver }}recv.{{ end }}Parse(filename, b, opts...)
 }
 
 // Parse parses the data from b using filename as information in the
 // error messages.
-func Parse(filename string, b []byte, opts ...Option) (any, error) {
+func {{ if .MethodReceiver }}(recv *{{ .MethodReceiver }}) {{ end }}Parse(filename string, b []byte, opts ...Option) (any, error) {
+	// ==template== {{ if .MethodReceiver }}
+	opts = append(opts[:len(opts):len(opts)], receiver(recv))
+	// {{ end }} ==template==
+	// ==template== {{ if .FuzzyMatch }}
+	return parseFuzzy(filename, b, opts...)
+	// {{ else if .ErrorRepair }}
+	return parseRepair(filename, b, opts...)
+	// {{ else }}
 	return newParser(filename, b, opts...).parse(g)
+	// {{ end }} ==template==
 }
 
-// position records a position in the text.
-type position struct {
-	line, col, offset int
+// ==template== {{ if .MustParse }}
+// MustParse is like Parse but panics if the input cannot be parsed. It
+// simplifies the initialization of variables with the result of the
+// parsing of inputs known to be valid, e.g. in tests.
+func {{ if .MethodReceiver }}(recv *{{ .MethodReceiver }}) {{ end }}MustParse(b []byte, opts ...Option) any { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	v, err := {{ if .MethodReceiver }}recv.{{ end }}Parse("", b, opts...)
+	if err != nil {
+		panic("MustParse: " + err.Error())
+	}
+	return v
 }
 
-func (p position) String() string {
-	return strconv.Itoa(p.line) + ":" + strconv.Itoa(p.col) + " [" + strconv.Itoa(p.offset) + "]"
-}
+// {{ end }} ==template==
 
-// savepoint stores all state required to go back to this point in the
-// parser.
-type savepoint struct {
-	position
-	rn rune
-	w  int
+// ==template== {{ if .EntrypointType }}
+// ParseTyped is like Parse but returns the result as a {{ .EntrypointType }},
+// the type of the values returned by the entrypoint rule. It returns an
+// error if the code blocks of the entrypoint return a value of another
+// type, so that a mismatch between the actions of the grammar and the
+// callers surfaces at the parse, e.g. when a rule alternative returns the
+// wrong value. With the Entrypoint option, the rule must return the same
+// type.
+func {{ if .MethodReceiver }}(recv *{{ .MethodReceiver }}) {{ end }}ParseTyped(filename string, b []byte, opts ...Option) ({{ .EntrypointType }}, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	v, err := {{ if .MethodReceiver }}recv.{{ end }}Parse(filename, b, opts...)
+	t, ok := v.({{ .EntrypointType }})
+	if err == nil && !ok {
+		err = fmt.Errorf("entrypoint returned a value of type %T, want {{ .EntrypointType }}", v)
+	}
+	return t, err
 }
 
-type current struct {
-	pos  position // start position of the match
-	text []byte   // raw text of the match
+// {{ end }} ==template==
 
-	// ==template== {{ if or .GlobalState (not .Optimize) }}
+// ==template== {{ if .SelfTest }}
+// selfTestInput is an input embedded in the parser, and whether it must
+// parse.
+type selfTestInput struct {
+	input string
+	ok    bool
+}
 
-	// state is a store for arbitrary key,value pairs that the user wants to be
-	// tied to the backtracking of the parser.
-	// This is always rolled back if a parsing rule fails.
-	state storeDict
+// SelfTest parses each of the inputs embedded in the parser when it was
+// generated, and returns an error if an input that must parse fails to
+// parse, or if an input that must fail parses. The error reports all the
+// inputs that failed. It may be called at startup or in a test to catch
+// the regressions of the grammar.
+func {{ if .MethodReceiver }}(recv *{{ .MethodReceiver }}) {{ end }}SelfTest() error { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	var msgs []string
+	for _, in := range selfTestInputs {
+		_, err := {{ if .MethodReceiver }}recv.{{ end }}Parse("", []byte(in.input))
+		switch {
+		case in.ok && err != nil:
+			msgs = append(msgs, fmt.Sprintf("%q: want no error, got %v", in.input, err))
+		case !in.ok && err == nil:
+			msgs = append(msgs, fmt.Sprintf("%q: want an error", in.input))
+		}
+	}
+	if len(msgs) > 0 {
+		return fmt.Errorf("self test: %d of %d inputs failed:\n%s", len(msgs), len(selfTestInputs), strings.Join(msgs, "\n"))
+	}
+	return nil
+}
 
-	// {{ end }} ==template==
+// {{ end }} ==template==
 
-	// globalStore is a general store for the user to store arbitrary key-value
-	// pairs that they need to manage and that they do not want tied to the
-	// backtracking of the parser. This is only modified by the user and never
-	// rolled back by the parser. It is always up to the user to keep this in a
-	// consistent state.
-	globalStore storeDict
+// ==template== {{ if .SubParse }}
+// ParseSub parses input from the byte offset start with the rule named
+// rule, and returns its value and the offset of the end of its match, so
+// that a language embedded in another one, e.g. in a string, can be parsed
+// in place. The input that follows the match is not parsed, the rule does
+// not need to match until the end of input. As with the Entrypoint option,
+// the rule must be an alternate entrypoint if the grammar is optimized.
+// The positions of the errors are those in input. If the parsing fails,
+// end is start.
+func ParseSub(rule string, input []byte, start int, opts ...Option) (result any, end int, err error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	if start < 0 || start > len(input) {
+		return nil, start, errInvalidStart
+	}
+	p := newParser("", input, opts...)
+	p.entrypoint = rule
+
+	// the parser is at the rune that precedes start
+	for _, rn := range string(input[:start]) {
+		p.pt.col++
+		if rn == '\n' {
+			p.pt.line++
+			p.pt.col = 0
+		}
+	}
+	p.pt.offset = start
+
+	result, err = p.parse(g)
+	if err != nil {
+		return nil, start, err
+	}
+	return result, p.pt.offset, nil
 }
 
-type storeDict map[string]any
+// {{ end }} ==template==
 
-// the AST types...
+// ==template== {{ if .LineRecovery }}
+// ParseLines parses b as a sequence of items matched by the entrypoint
+// rule, each from the end of the previous one, up to the end of b. When
+// an item fails to match, or its code blocks return errors, the input is
+// skipped up to the next newline and the parsing resumes at the start of
+// the next line, so that a malformed line does not prevent parsing the
+// following ones. An item that matches no input fails, as parsing it
+// again would not progress. It returns the values of the items that
+// matched, in order, and the errors of the items that failed, nil if none
+// did.
+func ParseLines(filename string, b []byte, opts ...Option) ([]any, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	// the options are applied and the start rule is resolved once, each
+	// item is parsed by a copy of this parser
+	base := newParser(filename, b, opts...)
+	base.rules = g.rules
+	base.startRule = base.entrypointRule()
+	b = base.data
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type grammar struct {
-	pos   position
-	rules []*rule
-}
+	var (
+		vals []any
+		errs errList
+		// the position that precedes the rune at the start of the next item
+		pos = position{line: 1}
+	)
+	for pos.offset < len(b) {
+		p := base.itemParser(pos)
+		val, err := p.parse(g)
+		if err == nil && p.pt.offset == pos.offset {
+			p.addErr(errEmptyItem)
+			err = p.errs.err()
+		}
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type rule struct {
-	pos         position
-	name        string
-	displayName string
-	expr        any
+		end := p.pt.offset
+		if err != nil {
+			if list, ok := err.(errList); ok {
+				errs = append(errs, list...)
+			} else {
+				errs.add(err)
+			}
+			end = len(b)
+			if i := bytes.IndexByte(b[pos.offset:], '\n'); i >= 0 {
+				end = pos.offset + i + 1
+			}
+		} else {
+			vals = append(vals, val)
+		}
 
-	// ==template== {{ if .LeftRecursion }}
-	leader        bool
-	leftRecursive bool
+		for _, rn := range string(b[pos.offset:end]) {
+			pos.col++
+			if rn == '\n' {
+				pos.line++
+				pos.col = 0
+			}
+		}
+		pos.offset = end
+	}
+	return vals, errs.err()
+}
+
+// itemParser returns a parser of the item of ParseLines at pos, a copy of
+// p, which has not parsed yet, with its own errors and stores.
+func (p *parser) itemParser(pos position) *parser {
+	ip := *p
+	ip.errs = new(errList)
+	ip.maxFailExpected = make([]string, 0, cap(p.maxFailExpected))
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	ip.cur.state = make(storeDict, len(p.cur.state))
+	for k, v := range p.cur.state {
+		ip.cur.state[k] = v
+	}
 	// {{ end }} ==template==
+	ip.cur.globalStore = make(storeDict, len(p.cur.globalStore))
+	for k, v := range p.cur.globalStore {
+		ip.cur.globalStore[k] = v
+	}
+	ip.pt.position = pos
+	return &ip
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type choiceExpr struct {
-	pos          position
-	alternatives []any
+// {{ end }} ==template==
+
+// ==template== {{ if .WasmExports }}
+// jsonRequest is the request of ParseJSON, whose fields are decoded from
+// the lowercase keys of the JSON object.
+type jsonRequest struct {
+	Filename   string
+	Input      string
+	Entrypoint string
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type actionExpr struct {
-	pos  position
-	expr any
-	run  func(*parser) (any, error)
+// ParseJSON parses the input of the JSON request req and returns the JSON
+// response, so that the parser can be called with strings from the
+// WebAssembly hosts, e.g. with the JavaScript function set by the
+// generated _js_wasm.go file. The request is an object with the "input"
+// to parse and, optionally, its "filename" and the "entrypoint" rule. The
+// response is an object with the "value" returned by the parser, encoded
+// by encoding/json, and the "errors" messages, if any.
+func ParseJSON(req []byte) []byte { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	var (
+		in   jsonRequest
+		errs []string
+	)
+	if err := json.Unmarshal(req, &in); err != nil {
+		b, _ := json.Marshal(map[string]any{"value": nil, "errors": []string{"invalid request: " + err.Error()}})
+		return b
+	}
+
+	var opts []Option
+	if in.Entrypoint != "" {
+		opts = append(opts, Entrypoint(in.Entrypoint))
+	}
+	val, err := Parse(in.Filename, []byte(in.Input), opts...)
+	if list, ok := err.(errList); ok {
+		for _, e := range list {
+			errs = append(errs, e.Error())
+		}
+	} else if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	res := map[string]any{"value": val}
+	if len(errs) > 0 {
+		res["errors"] = errs
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		res["value"] = nil
+		res["errors"] = append(errs, "invalid value: "+err.Error())
+		b, _ = json.Marshal(res)
+	}
+	return b
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type recoveryExpr struct {
-	pos          position
-	expr         any
-	recoverExpr  any
-	failureLabel []string
+// {{ end }} ==template==
+
+// ==template== {{ if .EnumerateParses }}
+// maxParses is the maximum number of distinct values returned by ParseAll.
+const maxParses = {{ .EnumerateParses }}
+
+// choiceDecision is the alternative taken by a choice evaluated by a
+// parser of ParseAll, -1 if none matched, with its number of
+// alternatives.
+type choiceDecision struct {
+	alt int
+	n   int
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type seqExpr struct {
-	pos   position
-	exprs []any
+// ParseAll parses input as Parse does, but tries all the alternatives of
+// the choices instead of the first one that matches, and returns the
+// distinct values of the successful parses, compared with
+// reflect.DeepEqual, so that the ambiguities of a grammar can be
+// inspected. The first value is the one that Parse returns, if it
+// succeeds. Each parse runs a new parser with opts, with the alternatives
+// of the choices evaluated first forced to another combination, and the
+// code blocks run again on each parse. Only the choices on the path of a
+// parse are forced, not those evaluated by the alternatives that failed
+// before the one taken. The number of parses can be
+// exponential in the number of choices evaluated, ParseAll stops when
+// maxParses distinct values are found, but it may run many parses that
+// fail or return a value already found before that. It returns the error
+// of the first parse if no parse succeeds.
+func ParseAll(input []byte, opts ...Option) ([]any, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	var (
+		vals     []any
+		firstErr error
+		// the alternatives forced for the parses to run, as a stack
+		pending = [][]int{nil}
+	)
+	for len(pending) > 0 && len(vals) < maxParses {
+		forced := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		p := newParser("", input, opts...)
+		p.enumerate = true
+		p.forcedAlts = forced
+		// ==template== {{ if not .Optimize }}
+		// memoized results depend on the alternatives forced
+		p.memoize = false
+		// {{ end }} ==template==
+		val, err := p.parse(g)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+		} else if !containsValue(vals, val) {
+			vals = append(vals, val)
+		}
+
+		// the choices evaluated after the forced ones took the first
+		// alternative that matched, the following ones remain to be tried,
+		// pushed so that the parses run in the order of the alternatives
+		for k := len(forced); k < len(p.choiceAlts); k++ {
+			d := p.choiceAlts[k]
+			if d.alt < 0 {
+				continue
+			}
+			for alt := d.n - 1; alt > d.alt; alt-- {
+				next := make([]int, k+1)
+				for i, prev := range p.choiceAlts[:k] {
+					next[i] = prev.alt
+				}
+				next[k] = alt
+				pending = append(pending, next)
+			}
+		}
+	}
+	if len(vals) == 0 {
+		return nil, firstErr
+	}
+	return vals, nil
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type throwExpr struct {
-	pos   position
-	label string
+// containsValue returns true if val is deeply equal to one of vals.
+func containsValue(vals []any, val any) bool {
+	for _, v := range vals {
+		if reflect.DeepEqual(v, val) {
+			return true
+		}
+	}
+	return false
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type labeledExpr struct {
-	pos   position
-	label string
-	expr  any
+// {{ end }} ==template==
+
+// ==template== {{ if .CompletionSupport }}
+// Suggest returns the tokens that the grammar accepts at the offset pos
+// of input, e.g. to complete the input at the cursor position in an
+// editor. It parses input up to pos and returns the tokens expected at
+// pos, in the form of the parsing errors: the quoted literals, the
+// character classes and the display names of the rules, sorted, with EOF
+// last. The FIRST and FOLLOW sets of the rules that fail at pos are used
+// if the parser is generated with the follow sets. It returns nil if pos
+// is not in input or if the parsing fails before pos.
+func Suggest(input []byte, pos int, opts ...Option) []string { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	if pos < 0 || pos > len(input) {
+		return nil
+	}
+	p := newParser("", input[:pos], opts...)
+	// the tokens tried at pos are expected there even if the input up to
+	// pos matches
+	_, _ = p.parse(g)
+	if p.maxFailPos.offset != pos || len(p.maxFailExpected) == 0 {
+		return nil
+	}
+	return p.maxFailExpectedList()
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type expr struct {
-	pos  position
-	expr any
+// {{ end }} ==template==
+
+// ==template== {{ if .BatchParse }}
+// Result is the result of the parsing of an input by ParseBatch.
+type Result struct {
+	Value any
+	Err   error
 }
 
-type (
-	andExpr        expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-	notExpr        expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-	zeroOrOneExpr  expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-	zeroOrMoreExpr expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-	oneOrMoreExpr  expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-)
+// ParseBatch parses the inputs concurrently with a pool of workers
+// goroutines, and returns their results in the order of the inputs. Each
+// input is parsed by its own parser with opts, which must be safe to use
+// concurrently: e.g. a Statistics option would be shared by all the
+// parsers. If workers is less than 1, GOMAXPROCS workers are used. The
+// returned error is the error of the first input that failed, if any, the
+// results are complete even then.
+func ParseBatch(inputs [][]byte, workers int, opts ...Option) ([]Result, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type ruleRefExpr struct {
-	pos    position
-	offset int
+	results := make([]Result, len(inputs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for ix := range next {
+				results[ix].Value, results[ix].Err = Parse("", inputs[ix], opts...)
+			}
+		}()
+	}
+	for ix := range inputs {
+		next <- ix
+	}
+	close(next)
+	wg.Wait()
+
+	for _, r := range results {
+		if r.Err != nil {
+			return results, r.Err
+		}
+	}
+	return results, nil
 }
 
-// ==template== {{ if or .GlobalState (not .Optimize) }}
+// {{ end }} ==template==
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type stateCodeExpr struct {
-	pos position
-	run func(*parser) error
+// ==template== {{ if .ExpvarMetrics }}
+// metricsNamespace is the name of the expvar map of the parser metrics.
+const metricsNamespace = {{ .ExpvarMetrics }}
+
+// parserMetrics are the counters of the parses of the inputs, published
+// with expvar when the first parse starts along with the avg_duration_ns
+// and memo_hit_rate values computed from them.
+type parserMetrics struct {
+	parses      expvar.Int
+	errors      expvar.Int
+	durationNs  expvar.Int
+	memoLookups expvar.Int
+	memoHits    expvar.Int
+}
+
+var (
+	metricsOnce sync.Once
+	metrics     parserMetrics
+)
+
+// publishMetrics publishes the metrics in the expvar map named
+// metricsNamespace, which is created if it does not exist yet. The
+// metrics are not published if that name is used by another kind of
+// variable.
+func publishMetrics() {
+	m, ok := expvar.Get(metricsNamespace).(*expvar.Map)
+	if !ok {
+		if expvar.Get(metricsNamespace) != nil {
+			return
+		}
+		m = expvar.NewMap(metricsNamespace)
+	}
+	m.Set("parses", &metrics.parses)
+	m.Set("errors", &metrics.errors)
+	m.Set("duration_ns", &metrics.durationNs)
+	m.Set("avg_duration_ns", expvar.Func(func() any {
+		if n := metrics.parses.Value(); n > 0 {
+			return metrics.durationNs.Value() / n
+		}
+		return int64(0)
+	}))
+	m.Set("memo_lookups", &metrics.memoLookups)
+	m.Set("memo_hits", &metrics.memoHits)
+	m.Set("memo_hit_rate", expvar.Func(func() any {
+		if n := metrics.memoLookups.Value(); n > 0 {
+			return float64(metrics.memoHits.Value()) / float64(n)
+		}
+		return float64(0)
+	}))
+}
+
+// recordMetrics adds the parse that started at start and returned the
+// error *err to the metrics.
+func (p *parser) recordMetrics(start time.Time, err *error) {
+	metricsOnce.Do(publishMetrics)
+	metrics.parses.Add(1)
+	if *err != nil {
+		metrics.errors.Add(1)
+	}
+	metrics.durationNs.Add(int64(time.Since(start)))
+	metrics.memoLookups.Add(int64(p.memoLookups))
+	metrics.memoHits.Add(int64(p.memoHits))
 }
 
 // {{ end }} ==template==
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type andCodeExpr struct {
-	pos position
-	run func(*parser) (bool, error)
+// ==template== {{ if .LocaleCase }}
+// localeCase is the special case mapping of the locale that folds the case
+// of the input in case-insensitive matching.
+var localeCase = unicode.{{ .LocaleCase }}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .REPLMode }}
+// REPLStatus is the status of the input accumulated by a REPL.
+type REPLStatus int
+
+// List of the statuses of the input accumulated by a REPL.
+const (
+	// REPLComplete is the status of an input that matches the grammar.
+	REPLComplete REPLStatus = iota
+	// REPLIncomplete is the status of an input that failed to match at its
+	// end, so that more input may make it match.
+	REPLIncomplete
+	// REPLError is the status of an input that failed to match before its
+	// end, or whose code blocks returned errors, so that no more input can
+	// make it match.
+	REPLError
+)
+
+// REPL accumulates the lines of input of an interactive shell until they
+// match the grammar, to support the "continue" prompt of a
+// read-eval-print loop.
+type REPL struct {
+	opts []Option
+	buf  []byte
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type notCodeExpr struct {
-	pos position
-	run func(*parser) (bool, error)
+// NewREPL returns a REPL that parses its input with the options opts.
+func NewREPL(opts ...Option) *REPL { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	return &REPL{opts: opts}
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type litMatcher struct {
-	pos        position
-	val        string
-	ignoreCase bool
-	want       string
+// Feed adds line, with its line terminator if any, to the accumulated
+// input and parses it. If the input matches, it returns the value and
+// REPLComplete. If the input failed to match at its end, it returns
+// REPLIncomplete and the error, and the input is kept to be completed by
+// the next lines. Otherwise it returns REPLError and the error. The
+// accumulated input is cleared unless it is incomplete.
+func (r *REPL) Feed(line []byte) (any, REPLStatus, error) {
+	r.buf = append(r.buf, line...)
+	p := newParser("", r.buf, r.opts...)
+	val, err := p.parse(g)
+	switch {
+	case err == nil:
+		// the value may refer to the input, which must not be reused
+		r.buf = nil
+		return val, REPLComplete, nil
+	case !p.matched && p.maxFailPos.offset == len(r.buf):
+		return nil, REPLIncomplete, err
+	default:
+		r.buf = nil
+		return nil, REPLError, err
+	}
 }
 
-// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
-type charClassMatcher struct {
-	pos             position
-	val             string
-	basicLatinChars [128]bool
-	chars           []rune
-	ranges          []rune
-	classes         []*unicode.RangeTable
-	ignoreCase      bool
-	inverted        bool
+// Reset clears the accumulated input, e.g. when the user cancels an
+// incomplete input.
+func (r *REPL) Reset() {
+	r.buf = nil
 }
 
-type anyMatcher position //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+// {{ end }} ==template==
 
-// errList cumulates the errors found by the parser.
-type errList []error
+// ==template== {{ if .PushParser }}
+// PushParser parses an input that arrives in chunks, e.g. from a socket,
+// as a sequence of items matched by the entrypoint rule, each item
+// starting where the previous one ended. The entrypoint rule should not
+// match the end of the input, so that an item is complete as soon as the
+// input that follows it arrives. The positions of the errors are in the
+// whole input, those of the matches in the code blocks are relative to
+// the start of their item.
+type PushParser struct {
+	opts    []Option
+	buf     []byte
+	results []any
+	err     error
+	// position of the end of the input of the items parsed
+	base pushBase
+}
 
-func (e *errList) add(err error) {
-	*e = append(*e, err)
+// pushBase is the position of the start of an item in the input of a
+// PushParser, as the numbers of bytes before it, of lines before its line
+// and of runes before it on its line.
+type pushBase struct {
+	offset, lines, col int
 }
 
-func (e errList) err() error {
-	if len(e) == 0 {
-		return nil
+// position returns the position in the whole input of the position pos
+// in the input of the item starting at b.
+func (b pushBase) position(pos position) position {
+	if pos.line == 1 {
+		pos.col += b.col
 	}
-	e.dedupe()
-	return e
+	pos.line += b.lines
+	pos.offset += b.offset
+	return pos
 }
 
-func (e *errList) dedupe() {
-	var cleaned []error
-	set := make(map[string]bool)
-	for _, err := range *e {
-		if msg := err.Error(); !set[msg] {
-			set[msg] = true
-			cleaned = append(cleaned, err)
+// NewPushParser returns a PushParser that parses its input with the
+// options opts.
+func NewPushParser(opts ...Option) *PushParser { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	return &PushParser{opts: opts}
+}
+
+// Feed adds chunk to the buffered input and parses the items it
+// completes. An item is complete once it matched without the parser
+// reaching the end of the buffered input, since more input could extend
+// it otherwise. After an error, the input is ignored until Flush returns
+// the error.
+func (pp *PushParser) Feed(chunk []byte) {
+	if pp.err != nil {
+		return
+	}
+	pp.buf = append(pp.buf, chunk...)
+	for len(pp.buf) > 0 {
+		val, n, atEnd, err := pp.parseItem()
+		if atEnd {
+			// more input may extend the item, or make it match
+			return
+		}
+		if err != nil {
+			pp.err = err
+			pp.buf = nil
+			return
 		}
+		pp.consume(n, val)
 	}
-	*e = cleaned
 }
 
-func (e errList) Error() string {
-	switch len(e) {
-	case 0:
-		return ""
-	case 1:
-		return e[0].Error()
-	default:
-		var buf bytes.Buffer
-
-		for i, err := range e {
-			if i > 0 {
-				buf.WriteRune('\n')
-			}
-			buf.WriteString(err.Error())
+// Flush parses the rest of the buffered input as the end of the input,
+// and returns the values of the items parsed since the last call to Flush
+// and the first error. The PushParser can then parse a new input.
+func (pp *PushParser) Flush() ([]any, error) {
+	for pp.err == nil && len(pp.buf) > 0 {
+		val, n, _, err := pp.parseItem()
+		if err != nil {
+			pp.err = err
+			break
 		}
-		return buf.String()
+		pp.consume(n, val)
 	}
+	results, err := pp.results, pp.err
+	*pp = PushParser{opts: pp.opts}
+	return results, err
 }
 
-// parserError wraps an error with a prefix indicating the rule in which
-// the error occurred. The original error is stored in the Inner field.
-type parserError struct {
-	Inner    error
-	pos      position
-	prefix   string
-	expected []string
+// parseItem parses an item at the start of the buffered input, and
+// returns its value and length, and whether the parser reached the end of
+// the buffered input.
+func (pp *PushParser) parseItem() (any, int, bool, error) {
+	p := newParser("", pp.buf, pp.opts...)
+	p.pushBase = pp.base
+	val, err := p.parse(g)
+	if err == nil && p.pt.offset == 0 {
+		// the item would be matched again and again
+		p.addErrAt(errEmptyItem, p.pt.position, []string{})
+		err = p.errs.err()
+	}
+	return val, p.pt.offset, p.atEnd, err
 }
 
-// Error returns the error message.
-func (p *parserError) Error() string {
-	return p.prefix + ": " + p.Inner.Error()
+// consume drops the n bytes of the item with value val from the buffered
+// input.
+func (pp *PushParser) consume(n int, val any) {
+	pp.results = append(pp.results, val)
+	for _, rn := range string(pp.buf[:n]) {
+		pp.base.col++
+		if rn == '\n' {
+			pp.base.lines++
+			pp.base.col = 0
+		}
+	}
+	pp.base.offset += n
+	// the value may refer to the input, the next chunks are appended after it
+	pp.buf = pp.buf[n:]
 }
 
-// newParser creates a parser with the specified input source and options.
-func newParser(filename string, b []byte, opts ...Option) *parser {
-	stats := Stats{
-		ChoiceAltCnt: make(map[string]map[string]int),
-	}
+// {{ end }} ==template==
 
-	p := &parser{
-		filename: filename,
-		errs:     new(errList),
-		data:     b,
-		pt:       savepoint{position: position{line: 1}},
-		recover:  true,
-		cur: current{
-			// ==template== {{ if or .GlobalState (not .Optimize) }}
-			state: make(storeDict),
-			// {{ end }} ==template==
-			globalStore: make(storeDict),
-		},
-		maxFailPos:      position{col: 1, line: 1},
-		maxFailExpected: make([]string, 0, 20),
-		Stats:           &stats,
-		// start rule is rule [0] unless an alternate entrypoint is specified
-		entrypoint: g.rules[0].name,
-	}
-	p.setOptions(opts)
+// ==template== {{ if .EmitValidate }}
+// Validate parses input and returns nil if it matches the grammar, or the
+// parsing errors otherwise. It matches the input as Parse does, but without
+// building the values of the expressions: the action code blocks are not
+// run, so the errors they could return are not reported.
+// ==template== {{ if .ValidateValues }}
+// The grammar has code predicates or state code blocks, which may use the
+// values of labeled expressions, so the values are built nonetheless.
+// {{ end }} ==template==
+func Validate(input []byte, opts ...Option) error { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	p := newParser("", input, opts...)
+	// ==template== {{ if not .ValidateValues }}
+	p.validate = true
+	// {{ end }} ==template==
+	_, err := p.parse(g)
+	return err
+}
 
-	if p.maxExprCnt == 0 {
-		p.maxExprCnt = math.MaxUint64
+// {{ end }} ==template==
+
+// ==template== {{ if .LosslessCST }}
+// CSTNode is a node of the lossless concrete syntax tree built by
+// ParseCST. Rule is the name of the rule matched by an inner node, and is
+// empty for a leaf. Offset is the byte offset of the node in the input and
+// Text is the input it matched, which for an inner node is the
+// concatenation of the Text of its children.
+type CSTNode struct {
+	Rule     string
+	Offset   int
+	Text     []byte
+	Children []*CSTNode
+
+	// Text of an inner node as built by ParseCST, to tell if it was replaced
+	orig []byte
+}
+
+var _ io.WriterTo = (*CSTNode)(nil)
+
+// WriteTo writes the tree rooted at n to w, so that the tree can be
+// transformed and written back. A leaf writes its Text, and an inner node
+// the Text of its children, unless its Text was replaced, in which case it
+// writes its Text instead, e.g. to replace the input matched by a rule
+// without building the nodes of the replacement. The nodes that are not
+// modified thus write the input they matched, and writing a tree that is
+// not modified reproduces the input byte for byte. The Text of the
+// ancestors of a modified node is not updated.
+func (n *CSTNode) WriteTo(w io.Writer) (int64, error) {
+	if len(n.Children) == 0 || !sameBytes(n.Text, n.orig) {
+		nw, err := w.Write(n.Text)
+		return int64(nw), err
+	}
+	var total int64
+	for _, c := range n.Children {
+		nw, err := c.WriteTo(w)
+		total += nw
+		if err != nil {
+			return total, err
+		}
 	}
+	return total, nil
+}
 
-	return p
+// sameBytes returns true if a and b are the same slice of the same array.
+func sameBytes(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
 }
 
-// setOptions applies the options to the parser.
-func (p *parser) setOptions(opts []Option) {
-	for _, opt := range opts {
-		opt(p)
+// ParseCST parses the data from b using filename as information in the
+// error messages, and returns the concrete syntax tree of the input matched
+// by the entrypoint rule. Each rule that matched some input is a node of
+// the tree, and each literal, character class and any matcher is a leaf
+// holding the bytes it consumed. The input consumed by other expressions,
+// or by the rules whose result is memoized, as with the Memoize option and
+// in left recursion, is covered by leaves too, so that writing the tree
+// reproduces the input byte for byte.
+func ParseCST(filename string, b []byte, opts ...Option) (*CSTNode, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	p := newParser(filename, b, opts...)
+	root := &CSTNode{}
+	p.cst = []*CSTNode{root}
+	if _, err := p.parse(g); err != nil {
+		return nil, err
 	}
+	return root.Children[len(root.Children)-1], nil
 }
 
-// {{ if .Nolint }} nolint: structcheck,deadcode {{else}} ==template== {{ end }}
-type resultTuple struct {
-	v   any
-	b   bool
-	end savepoint
+// ==template== {{ if .Reprinter }}
+
+// Span returns the start and end offsets of the input matched by n.
+func (n *CSTNode) Span() (start, end int) {
+	return n.Offset, n.Offset + len(n.Text)
 }
 
-// {{ if .Nolint }} nolint: varcheck {{else}} ==template== {{ end }}
-const choiceNoMatch = -1
+// {{ end }} ==template==
 
-// Stats stores some statistics, gathered during parsing
-type Stats struct {
-	// ExprCnt counts the number of expressions processed during parsing
-	// This value is compared to the maximum number of expressions allowed
-	// (set by the MaxExpressions option).
-	ExprCnt uint64
+// {{ end }} ==template==
 
-	// ChoiceAltCnt is used to count for each ordered choice expression,
-	// which alternative is used how may times.
-	// These numbers allow to optimize the order of the ordered choice expression
-	// to increase the performance of the parser
-	//
-	// The outer key of ChoiceAltCnt is composed of the name of the rule as well
-	// as the line and the column of the ordered choice.
-	// The inner key of ChoiceAltCnt is the number (one-based) of the matching alternative.
-	// For each alternative the number of matches are counted. If an ordered choice does not
-	// match, a special counter is incremented. The name of this counter is set with
-	// the parser option Statistics.
-	// For an alternative to be included in ChoiceAltCnt, it has to match at least once.
-	ChoiceAltCnt map[string]map[string]int
+// ==template== {{ if .CompactAST }}
+// CompactTree is a concrete syntax tree built by ParseCompact, with the same
+// nodes as the tree of ParseCST but stored in a single slice, the children
+// being referenced by index instead of by pointer. This saves most of the
+// allocations of the nodes and keeps them close in memory for large inputs.
+// The nodes are in post-order: the children of a node precede it, and Root
+// is the index of the last node.
+type CompactTree struct {
+	Nodes []CompactNode
+	Root  int
+	input []byte
 }
 
-// ==template== {{ if .LeftRecursion }}
-type ruleWithExpsStack struct {
-	rule   *rule
-	estack []any
+// CompactNode is a node of a CompactTree. Rule is the name of the rule
+// matched by an inner node, and is empty for a leaf. Start and End are the
+// byte offsets of the input matched by the node.
+type CompactNode struct {
+	Rule       string
+	Start, End int
+
+	// index of the first node of the subtree rooted at the node, of its
+	// first child and of its next sibling, -1 if none.
+	first, child, next int
 }
 
-// {{ end }} ==template==
+// ParseCompact parses the data from b using filename as information in the
+// error messages, and returns the concrete syntax tree of the input matched
+// by the entrypoint rule as a CompactTree, see ParseCST for its nodes.
+func ParseCompact(filename string, b []byte, opts ...Option) (*CompactTree, error) { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	p := newParser(filename, b, opts...)
+	p.compactStack = make([]compactRule, 1)
+	// the matchers of single characters add a leaf per byte, so there are
+	// often about as many nodes as bytes
+	p.compact = make([]CompactNode, 0, len(b))
+	if _, err := p.parse(g); err != nil {
+		return nil, err
+	}
+	t := &CompactTree{Nodes: p.compact, Root: len(p.compact) - 1, input: b}
+	for i := range t.Nodes {
+		n := &t.Nodes[i]
+		n.child, n.next = -1, -1
+		for j := i - 1; j >= n.first; j = t.Nodes[j].first - 1 {
+			t.Nodes[j].next = n.child
+			n.child = j
+		}
+	}
+	return t, nil
+}
 
-// {{ if .Nolint }} nolint: structcheck,maligned {{else}} ==template== {{ end }}
-type parser struct {
-	filename string
-	pt       savepoint
-	cur      current
+// FirstChild returns the index of the first child of the node at index n,
+// or -1 if it is a leaf.
+func (t *CompactTree) FirstChild(n int) int {
+	return t.Nodes[n].child
+}
 
-	data []byte
-	errs *errList
+// NextSibling returns the index of the node that follows the node at index
+// n in the children of its parent, or -1 if it is the last one.
+func (t *CompactTree) NextSibling(n int) int {
+	return t.Nodes[n].next
+}
 
-	depth   int
-	recover bool
-	// ==template== {{ if not .Optimize }}
-	debug bool
+// Text returns the input matched by the node at index n.
+func (t *CompactTree) Text(n int) []byte {
+	return t.input[t.Nodes[n].Start:t.Nodes[n].End]
+}
 
-	memoize bool
-	// {{ end }} ==template==
-	// ==template== {{ if or .LeftRecursion (not .Optimize) }}
-	// memoization table for the packrat algorithm:
-	// map[offset in source] map[expression or rule] {value, match}
-	memo map[int]map[any]resultTuple
-	// {{ end }} ==template==
+// compactRule is a rule being matched by a parser building a CompactTree.
+type compactRule struct {
+	// index of the first node of the children of the rule, and offset of
+	// the start of the rule
+	mark, start int
+}
 
-	// rules table, maps the rule offset to the rule node
-	rules []*rule
-	// variables stack, map of label to value
-	vstack []map[string]any
-	// rule stack, allows identification of the current rule in errors
-	rstack []*rule
+// {{ end }} ==template==
 
-	// parse fail
-	maxFailPos            position
-	maxFailExpected       []string
-	maxFailInvertExpected bool
+// ==template== {{ if .Reprinter }}
 
-	// max number of expressions to be parsed
-	maxExprCnt uint64
-	// entrypoint for the parser
-	entrypoint string
+// Spanner is implemented by the nodes of a syntax tree that know the
+// extent of the input they matched, as byte offsets.
+type Spanner interface {
+	Span() (start, end int)
+}
 
-	allowInvalidUTF8 bool
+// Reprint returns the exact input matched by node, whitespace and comments
+// included, as a subslice of input, which must be the input of the parse
+// that produced node. It returns nil if the span of node is not in input.
+// This allows to rewrite only a part of the input, e.g. by replacing the
+// text of a node and copying the rest unchanged. The nodes of a tree built
+// by the action code blocks can record their span with c.span().
+func Reprint(node Spanner, input []byte) []byte { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	start, end := node.Span()
+	if start < 0 || start > end || end > len(input) {
+		return nil
+	}
+	return input[start:end:end]
+}
 
-	*Stats
+// span returns the start and end offsets in the input of the text of the
+// current match, to be returned by the Span method of a node of the
+// syntax tree.
+func (c *current) span() (start, end int) {
+	return c.pos.offset, c.pos.offset + len(c.text)
+}
 
-	choiceNoMatch string
-	// recovery expression stack, keeps track of the currently available recovery expression, these are traversed in reverse
-	recoveryStack []map[string]any
+// {{ end }} ==template==
+
+// ==template== {{ if .ResultDiff }}
+
+// Diff is a difference between two parse results, reported by DiffResults.
+type Diff struct {
+	// Path is the location of the difference in the results, e.g. "[1]"
+	// for the second element of a slice, ".Name" for a field of a struct
+	// or "[\"key\"]" for the value of a key of a map. It is empty for the
+	// results themselves.
+	Path string
+
+	// Kind is the kind of the difference: "type" if the values have
+	// different types, "value" if they are different leaf values, e.g.
+	// strings or numbers, "length" if they have a different number of
+	// elements, and "missing" if a key of a map is only in one result.
+	Kind string
+
+	// A and B are the values at Path in each result, nil if the value is
+	// missing or cannot be accessed, e.g. an unexported field of a struct.
+	A, B any
 }
 
-// push a variable set on the vstack.
-func (p *parser) pushV() {
-	if cap(p.vstack) == len(p.vstack) {
-		// create new empty slot in the stack
-		p.vstack = append(p.vstack, nil)
-	} else {
-		// slice to 1 more
-		p.vstack = p.vstack[:len(p.vstack)+1]
+// String returns a description of the difference.
+func (d Diff) String() string {
+	path := d.Path
+	if path == "" {
+		path = "result"
 	}
-
-	// get the last args set
-	m := p.vstack[len(p.vstack)-1]
-	if m != nil && len(m) == 0 {
-		// empty map, all good
-		return
+	if d.Kind == "type" {
+		return fmt.Sprintf("%s: type differs: %T != %T", path, d.A, d.B)
 	}
-
-	m = make(map[string]any)
-	p.vstack[len(p.vstack)-1] = m
+	return fmt.Sprintf("%s: %s differs: %#v != %#v", path, d.Kind, d.A, d.B)
 }
 
-// pop a variable set from the vstack.
-func (p *parser) popV() {
-	// if the map is not empty, clear it
-	m := p.vstack[len(p.vstack)-1]
-	if len(m) > 0 {
-		// GC that map
-		p.vstack[len(p.vstack)-1] = nil
-	}
-	p.vstack = p.vstack[:len(p.vstack)-1]
+// DiffResults compares the results a and b of two parses, e.g. of the same
+// input with two versions of a grammar, and returns their differences in
+// depth-first order, or nil if they are equal. The elements of slices,
+// arrays and maps, the fields of structs and the values of pointers and
+// interfaces are compared recursively, so that a difference in a leaf of
+// the results is reported once, at its path, and the elements common to
+// slices of different lengths are still compared.
+func DiffResults(a, b any) []Diff { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	d := &resultDiffer{visited: make(map[[2]uintptr]bool)}
+	d.diff("", reflect.ValueOf(a), reflect.ValueOf(b))
+	return d.diffs
 }
 
-// push a recovery expression with its labels to the recoveryStack
-func (p *parser) pushRecovery(labels []string, expr any) {
-	if cap(p.recoveryStack) == len(p.recoveryStack) {
-		// create new empty slot in the stack
-		p.recoveryStack = append(p.recoveryStack, nil)
-	} else {
-		// slice to 1 more
-		p.recoveryStack = p.recoveryStack[:len(p.recoveryStack)+1]
-	}
+// resultDiffer accumulates the differences found by DiffResults.
+type resultDiffer struct {
+	diffs []Diff
 
-	m := make(map[string]any, len(labels))
-	for _, fl := range labels {
-		m[fl] = expr
-	}
-	p.recoveryStack[len(p.recoveryStack)-1] = m
+	// pairs of pointers already compared, so that cycles terminate
+	visited map[[2]uintptr]bool
 }
 
-// pop a recovery expression from the recoveryStack
-func (p *parser) popRecovery() {
-	// GC that map
-	p.recoveryStack[len(p.recoveryStack)-1] = nil
-
-	p.recoveryStack = p.recoveryStack[:len(p.recoveryStack)-1]
+func (d *resultDiffer) add(path, kind string, a, b reflect.Value) {
+	d.diffs = append(d.diffs, Diff{Path: path, Kind: kind, A: diffValue(a), B: diffValue(b)})
 }
 
-// ==template== {{ if not .Optimize }}
-func (p *parser) print(prefix, s string) string {
-	if !p.debug {
-		return s
+func (d *resultDiffer) diff(path string, a, b reflect.Value) {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			d.add(path, "type", a, b)
+		}
+		return
+	}
+	if a.Type() != b.Type() {
+		d.add(path, "type", a, b)
+		return
 	}
 
-	fmt.Printf("%s %d:%d:%d: %s [%#U]\n",
-		prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
-	return s
-}
+	switch a.Kind() {
+	case reflect.Interface:
+		d.diff(path, a.Elem(), b.Elem())
 
-func (p *parser) printIndent(mark string, s string) string {
-	return p.print(strings.Repeat(" ", p.depth)+mark, s)
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(path, "value", a, b)
+			}
+			return
+		}
+		key := [2]uintptr{a.Pointer(), b.Pointer()}
+		if key[0] == key[1] || d.visited[key] {
+			return
+		}
+		d.visited[key] = true
+		d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice && a.Type().Elem().Kind() == reflect.Uint8 {
+			// a slice of bytes is text, compared as a leaf
+			if !bytes.Equal(a.Bytes(), b.Bytes()) {
+				d.add(path, "value", a, b)
+			}
+			return
+		}
+		n := a.Len()
+		if a.Len() != b.Len() {
+			d.add(path, "length", a, b)
+			if b.Len() < n {
+				n = b.Len()
+			}
+		}
+		for i := 0; i < n; i++ {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+		}
+
+	case reflect.Map:
+		keys := a.MapKeys()
+		for _, k := range b.MapKeys() {
+			if !a.MapIndex(k).IsValid() {
+				keys = append(keys, k)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, k := range keys {
+			kpath := fmt.Sprintf("%s[%#v]", path, k)
+			va, vb := a.MapIndex(k), b.MapIndex(k)
+			if !va.IsValid() || !vb.IsValid() {
+				d.add(kpath, "missing", va, vb)
+				continue
+			}
+			d.diff(kpath, va, vb)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			d.diff(path+"."+a.Type().Field(i).Name, a.Field(i), b.Field(i))
+		}
+
+	default:
+		if !leafEqual(a, b) {
+			d.add(path, "value", a, b)
+		}
+	}
 }
 
-func (p *parser) in(s string) string {
-	res := p.printIndent(">", s)
-	p.depth++
-	return res
+// leafEqual returns true if the values a and b of the same type, which is
+// not a composite type, are equal. Channels, functions and unsafe pointers
+// are equal if they are the same.
+func leafEqual(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() == b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() == b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() == b.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.String:
+		return a.String() == b.String()
+	}
+	return a.Pointer() == b.Pointer()
 }
 
-func (p *parser) out(s string) string {
-	p.depth--
-	return p.printIndent("<", s)
+// diffValue returns the value of v, or nil if it is invalid or cannot be
+// accessed.
+func diffValue(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
 }
 
 // {{ end }} ==template==
 
-func (p *parser) addErr(err error) {
-	p.addErrAt(err, p.pt.position, []string{})
+// ==template== {{ if .ProtoResults }}
+// {{ .ProtoResults }} is a parse result in the form of the {{ .ProtoResults }}
+// message of the .proto file generated with the parser. It describes the
+// generic results of the expressions without action: the []byte text
+// matched by the literals, character classes and any matchers, the []any
+// list of the results of the sequences and repetitions, and nil. At most
+// one of Text and List is not nil, neither is for a nil result.
+type {{ .ProtoResults }} struct {
+	// Text is the text matched, it is not nil if the result is a []byte.
+	Text []byte
+
+	// List is the list of the results of a sequence or a repetition, it is
+	// not nil if the result is a []any.
+	List []*{{ .ProtoResults }}
 }
 
-func (p *parser) addErrAt(err error, pos position, expected []string) {
-	var buf bytes.Buffer
-	if p.filename != "" {
-		buf.WriteString(p.filename)
-	}
-	if buf.Len() > 0 {
-		buf.WriteString(":")
+// ToProto converts the parse result v to a {{ .ProtoResults }} message. It
+// returns an error if v, or one of the items of its lists, is not a
+// generic result, e.g. the value returned by an action.
+func ToProto(v any) (*{{ .ProtoResults }}, error) {
+	switch v := v.(type) {
+	case nil:
+		return &{{ .ProtoResults }}{}, nil
+	case []byte:
+		if v == nil {
+			v = []byte{}
+		}
+		return &{{ .ProtoResults }}{Text: v}, nil
+	case []any:
+		m := &{{ .ProtoResults }}{List: make([]*{{ .ProtoResults }}, 0, len(v))}
+		for i, item := range v {
+			im, err := ToProto(item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			m.List = append(m.List, im)
+		}
+		return m, nil
 	}
-	buf.WriteString(fmt.Sprintf("%d:%d (%d)", pos.line, pos.col, pos.offset))
-	if len(p.rstack) > 0 {
-		if buf.Len() > 0 {
-			buf.WriteString(": ")
+	return nil, fmt.Errorf("unsupported result type %T", v)
+}
+
+// FromProto converts the {{ .ProtoResults }} message m to a parse result,
+// it is the reverse of ToProto. An empty list is converted to a nil []any,
+// like the result of a repetition that matches nothing.
+func FromProto(m *{{ .ProtoResults }}) any {
+	switch {
+	case m == nil:
+		return nil
+	case m.List != nil:
+		var list []any
+		for _, item := range m.List {
+			list = append(list, FromProto(item))
 		}
-		rule := p.rstack[len(p.rstack)-1]
-		if rule.displayName != "" {
-			buf.WriteString("rule " + rule.displayName)
-		} else {
-			buf.WriteString("rule " + rule.name)
+		return list
+	case m.Text != nil:
+		return m.Text
+	}
+	return nil
+}
+
+// MarshalBinary returns the encoding of m in the protobuf wire format.
+func (m *{{ .ProtoResults }}) MarshalBinary() ([]byte, error) {
+	return m.appendProto(nil), nil
+}
+
+func (m *{{ .ProtoResults }}) appendProto(b []byte) []byte {
+	switch {
+	case m == nil:
+	case m.List != nil:
+		// the list is the field 2, a message with the items as field 1
+		var list []byte
+		for _, item := range m.List {
+			list = protoAppendBytes(list, 1, item.appendProto(nil))
 		}
+		b = protoAppendBytes(b, 2, list)
+	case m.Text != nil:
+		b = protoAppendBytes(b, 1, m.Text)
 	}
-	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return b
 }
 
-func (p *parser) failAt(fail bool, pos position, want string) {
-	// process fail if parsing fails and not inverted or parsing succeeds and invert is set
-	if fail == p.maxFailInvertExpected {
-		if pos.offset < p.maxFailPos.offset {
-			return
+// UnmarshalBinary decodes m from data in the protobuf wire format. The
+// unknown fields are skipped.
+func (m *{{ .ProtoResults }}) UnmarshalBinary(data []byte) error {
+	m.Text, m.List = nil, nil
+	return protoFields(data, func(num int, v []byte) error {
+		switch num {
+		case 1:
+			m.Text, m.List = append([]byte{}, v...), nil
+		case 2:
+			// the occurrences of the list are merged
+			if m.List == nil {
+				m.Text, m.List = nil, make([]*{{ .ProtoResults }}, 0)
+			}
+			return protoFields(v, func(num int, v []byte) error {
+				if num != 1 {
+					return nil
+				}
+				item := &{{ .ProtoResults }}{}
+				if err := item.UnmarshalBinary(v); err != nil {
+					return err
+				}
+				m.List = append(m.List, item)
+				return nil
+			})
 		}
+		return nil
+	})
+}
 
-		if pos.offset > p.maxFailPos.offset {
-			p.maxFailPos = pos
-			p.maxFailExpected = p.maxFailExpected[:0]
+// protoAppendBytes appends to b the length-delimited field num of value v
+// in the protobuf wire format.
+func protoAppendBytes(b []byte, num int, v []byte) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(num)<<3|2)]...)
+	b = append(b, buf[:binary.PutUvarint(buf[:], uint64(len(v)))]...)
+	return append(b, v...)
+}
+
+// protoFields calls fn with the number and the value of each
+// length-delimited field of the protobuf message data, in order, and skips
+// its other fields.
+func protoFields(data []byte, fn func(num int, v []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errInvalidProto
 		}
+		data = data[n:]
 
-		if p.maxFailInvertExpected {
-			want = "!" + want
+		switch key & 7 {
+		case 0: // varint
+			if _, n = binary.Uvarint(data); n <= 0 {
+				return errInvalidProto
+			}
+			data = data[n:]
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return errInvalidProto
+			}
+			data = data[8:]
+		case 2: // length-delimited
+			l, n := binary.Uvarint(data)
+			if n <= 0 || l > uint64(len(data)-n) {
+				return errInvalidProto
+			}
+			if err := fn(int(key>>3), data[n:n+int(l)]); err != nil {
+				return err
+			}
+			data = data[n+int(l):]
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return errInvalidProto
+			}
+			data = data[4:]
+		default:
+			return errInvalidProto
 		}
-		p.maxFailExpected = append(p.maxFailExpected, want)
 	}
+	return nil
 }
 
-// read advances the parser to the next rune.
-func (p *parser) read() {
-	p.pt.offset += p.pt.w
-	rn, n := utf8.DecodeRune(p.data[p.pt.offset:])
-	p.pt.rn = rn
-	p.pt.w = n
-	p.pt.col++
-	if rn == '\n' {
-		p.pt.line++
-		p.pt.col = 0
-	}
+// {{ end }} ==template==
 
-	if rn == utf8.RuneError && n == 1 { // see utf8.DecodeRune
-		if !p.allowInvalidUTF8 {
-			p.addErr(errInvalidEncoding)
+// ==template== {{ if .SinkResults }}
+// ParseTo parses the data from b using filename as information in the
+// error messages, and pushes each value of the top-level repetition of the
+// entrypoint rule to sink as soon as it is parsed. The top-level
+// repetition is the first zero-or-more or one-or-more expression of the
+// rule, either directly or in its top-level sequence, e.g. Item* in
+// File <- Item* EOF. If the rule has no such repetition, its value is
+// pushed once it is parsed.
+//
+// The values are pushed even if the parsing fails afterwards, and they
+// are also part of the value of the repetition as with Parse. If sink
+// returns an error, parsing stops and ParseTo returns this error.
+func ParseTo(filename string, b []byte, sink func(any) error, opts ...Option) (err error) {
+	p := newParser(filename, b, opts...)
+	p.sink = sink
+	for _, r := range g.rules {
+		if r.name == p.entrypoint {
+			// ==template== {{ if .LazyRuleInit }}
+			p.sinkExpr = sinkRepetition(r.expression())
+			// {{ else }}
+			p.sinkExpr = sinkRepetition(r.expr)
+			// {{ end }} ==template==
+			break
 		}
 	}
-}
 
-// restore parser position to the savepoint pt.
-func (p *parser) restore(pt savepoint) {
-	// ==template== {{ if not .Optimize }}
-	if p.debug {
-		defer p.out(p.in("restore"))
+	defer func() {
+		// the panic is not recovered by the parser if Recover(false) is set
+		if e := recover(); e != nil {
+			if e != errSinkStop {
+				panic(e)
+			}
+			err = p.sinkErr
+		}
+	}()
+	val, err := p.parse(g)
+	if p.sinkErr != nil {
+		return p.sinkErr
 	}
-	// {{ end }} ==template==
-	if pt.offset == p.pt.offset {
-		return
+	if err == nil && p.sinkExpr == nil {
+		p.pushSink(val)
 	}
-	p.pt = pt
+	return err
 }
 
-// ==template== {{ if or .GlobalState (not .Optimize) }}
-
-// Cloner is implemented by any value that has a Clone method, which returns a
-// copy of the value. This is mainly used for types which are not passed by
-// value (e.g map, slice, chan) or structs that contain such types.
+// ==template== {{ if .StreamBuffer }}
+// ParseChan parses the data from b like ParseTo in a new goroutine, and
+// sends the values pushed to the sink to the returned values channel. The
+// channel has a buffer of {{ .StreamBuffer }} values, so the parsing blocks
+// while the buffer is full, until the consumer receives a value. The
+// values channel is closed when the parsing ends, and the error of the
+// parsing, nil if it succeeds, is then sent to the error channel.
 //
-// This is used in conjunction with the global state feature to create proper
-// copies of the state to allow the parser to properly restore the state in
-// the case of backtracking.
-type Cloner interface {
-	Clone() any
+// If the consumer stops receiving the values before the end, it must close
+// done so that the parsing stops and its goroutine exits. The parsing then
+// fails with an error.
+func ParseChan(filename string, b []byte, done <-chan struct{}, opts ...Option) (<-chan any, <-chan error) {
+	vals := make(chan any, {{ .StreamBuffer }})
+	errc := make(chan error, 1)
+	go func() {
+		err := ParseTo(filename, b, func(val any) error {
+			select {
+			case vals <- val:
+				return nil
+			case <-done:
+				return errStreamDone
+			}
+		}, opts...)
+		close(vals)
+		errc <- err
+		close(errc)
+	}()
+	return vals, errc
 }
 
-var statePool = &sync.Pool{
-	New: func() any { return make(storeDict) },
+// {{ end }} ==template==
+// sinkRepetition returns the top-level repetition of expr, or nil if there
+// is none.
+func sinkRepetition(expr any) any {
+	switch expr := expr.(type) {
+	case *actionExpr:
+		return sinkRepetition(expr.expr)
+	case *labeledExpr:
+		return sinkRepetition(expr.expr)
+	case *seqExpr:
+		for _, e := range expr.exprs {
+			if rep := sinkRepetition(e); rep != nil {
+				return rep
+			}
+		}
+	case *zeroOrMoreExpr, *oneOrMoreExpr:
+		return expr
+	}
+	return nil
 }
 
-func (sd storeDict) Discard() {
-	for k := range sd {
-		delete(sd, k)
+// pushSink pushes val to the sink function of ParseTo, and stops parsing
+// if it returns an error.
+func (p *parser) pushSink(val any) {
+	if err := p.sink(val); err != nil {
+		p.sinkErr = err
+		panic(errSinkStop)
 	}
-	statePool.Put(sd)
 }
 
-// clone and return parser current state.
-func (p *parser) cloneState() storeDict {
-	// ==template== {{ if not .Optimize }}
-	if p.debug {
-		defer p.out(p.in("cloneState"))
-	}
-	// {{ end }} ==template==
+// {{ end }} ==template==
 
-	state := statePool.Get().(storeDict)
-	for k, v := range p.cur.state {
-		if c, ok := v.(Cloner); ok {
-			state[k] = c.Clone()
-		} else {
-			state[k] = v
+// ==template== {{ if .FuzzyMatch }}
+// parseFuzzy parses the data from b exactly and, if that fails, parses it
+// again allowing an increasing number of corrections, up to the maximum
+// set when the parser was generated. The error of the exact parse is
+// returned if no attempt succeeds. Code blocks run again on each attempt.
+func parseFuzzy(filename string, b []byte, opts ...Option) (any, error) {
+	p := newParser(filename, b, opts...)
+	val, err := p.parse(g)
+	for budget := 1; err != nil && budget <= p.fuzzyMaxErrors; budget++ {
+		fp := newParser(filename, b, opts...)
+		fp.fuzzyBudget = budget
+		// ==template== {{ if not .Optimize }}
+		// memoized results may carry corrections from another path
+		fp.memoize = false
+		// {{ end }} ==template==
+		fval, ferr := fp.parse(g)
+		if ferr == nil {
+			p, val, err = fp, fval, nil
 		}
 	}
-	return state
-}
 
-// restore parser current state to the state storeDict.
-// every restoreState should applied only one time for every cloned state
-func (p *parser) restoreState(state storeDict) {
-	// ==template== {{ if not .Optimize }}
-	if p.debug {
-		defer p.out(p.in("restoreState"))
+	if err == nil && p.corrections != nil && p.pt.fuzzy != nil {
+		var corrections []Correction
+		for e := p.pt.fuzzy; e != nil; e = e.prev {
+			corrections = append(corrections, e.corr)
+		}
+		for i, j := 0, len(corrections)-1; i < j; i, j = i+1, j-1 {
+			corrections[i], corrections[j] = corrections[j], corrections[i]
+		}
+		*p.corrections = corrections
 	}
-	// {{ end }} ==template==
-	p.cur.state.Discard()
-	p.cur.state = state
+	return val, err
 }
 
-// {{ end }} ==template==
+// Correction records a literal that was matched approximately by fuzzy
+// matching.
+type Correction struct {
+	// Line, Col and Offset are the position of the corrected input.
+	Line, Col, Offset int
+	// Want is the literal expected by the grammar.
+	Want string
+	// Got is the input text matched in its place.
+	Got string
+	// Edits is the number of single-character insertions, deletions,
+	// substitutions or transpositions that turn Got into Want.
+	Edits int
+}
 
-// get the slice of bytes from the savepoint start to the current position.
-func (p *parser) sliceFrom(start savepoint) []byte {
-	return p.data[start.position.offset:p.pt.position.offset]
+// fuzzyEdit is a node of the immutable list of corrections applied up to
+// a savepoint, so that restoring a savepoint drops later corrections.
+type fuzzyEdit struct {
+	prev  *fuzzyEdit
+	edits int
+	corr  Correction
 }
 
-// ==template== {{ if or .LeftRecursion (not .Optimize) }}
-func (p *parser) getMemoized(node any) (resultTuple, bool) {
-	if len(p.memo) == 0 {
-		return resultTuple{}, false
+// {{ end }} ==template==
+
+// ==template== {{ if .ErrorRepair }}
+// parseRepair parses the data from b and, if that fails, parses it again
+// inserting the literals expected at the position where the last attempt
+// failed, as if they were in the input, until an attempt succeeds or fails
+// at a position where the literals are already inserted. A literal is
+// inserted at most once at a given position. The error of the first parse
+// is returned if no attempt succeeds. Code blocks run again on each
+// attempt.
+func parseRepair(filename string, b []byte, opts ...Option) (any, error) {
+	p := newParser(filename, b, opts...)
+	val, err := p.parse(g)
+	last := p
+	repairAt := make(map[int]bool)
+	for err != nil && !repairAt[last.maxFailPos.offset] {
+		repairAt[last.maxFailPos.offset] = true
+		rp := newParser(filename, b, opts...)
+		rp.repairAt = repairAt
+		// ==template== {{ if not .Optimize }}
+		// memoized results may carry repairs from another path
+		rp.memoize = false
+		// {{ end }} ==template==
+		rval, rerr := rp.parse(g)
+		if rerr == nil {
+			p, val, err = rp, rval, nil
+		}
+		last = rp
 	}
-	m := p.memo[p.pt.offset]
-	if len(m) == 0 {
-		return resultTuple{}, false
+
+	if err == nil && p.repairs != nil && p.pt.repair != nil {
+		var repairs []Repair
+		for e := p.pt.repair; e != nil; e = e.prev {
+			repairs = append(repairs, e.repair)
+		}
+		for i, j := 0, len(repairs)-1; i < j; i, j = i+1, j-1 {
+			repairs[i], repairs[j] = repairs[j], repairs[i]
+		}
+		*p.repairs = repairs
 	}
-	res, ok := m[node]
-	return res, ok
+	return val, err
 }
 
-func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
-	if p.memo == nil {
-		p.memo = make(map[int]map[any]resultTuple)
-	}
-	m := p.memo[pt.offset]
-	if m == nil {
-		m = make(map[any]resultTuple)
-		p.memo[pt.offset] = m
-	}
-	m[node] = tuple
+// Repair records a literal that was missing in the input and that error
+// repair inserted so that the parsing could proceed.
+type Repair struct {
+	// Line, Col and Offset are the position of the insertion.
+	Line, Col, Offset int
+	// Inserted is the literal expected by the grammar.
+	Inserted string
+}
+
+// repairEdit is a node of the immutable list of repairs applied up to a
+// savepoint, so that restoring a savepoint drops later repairs.
+type repairEdit struct {
+	prev   *repairEdit
+	repair Repair
 }
 
 // {{ end }} ==template==
 
-// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
-func (p *parser) parse(g *grammar) (val any, err error) {
-	if len(g.rules) == 0 {
-		p.addErr(errNoRule)
-		return nil, p.errs.err()
-	}
+// position records a position in the text.
+type position struct {
+	line, col, offset int
+}
 
-	// TODO : not super critical but this could be generated
-	p.rules = g.rules
+func (p position) String() string {
+	return strconv.Itoa(p.line) + ":" + strconv.Itoa(p.col) + " [" + strconv.Itoa(p.offset) + "]"
+}
 
-	if p.recover {
-		// panic can be used in action code to stop parsing immediately
-		// and return the panic as an error.
-		defer func() {
-			if e := recover(); e != nil {
-				// ==template== {{ if not .Optimize }}
-				if p.debug {
-					defer p.out(p.in("panic handler"))
-				}
-				// {{ end }} ==template==
-				val = nil
-				switch e := e.(type) {
-				case error:
-					p.addErr(e)
-				default:
-					p.addErr(fmt.Errorf("%v", e))
-				}
-				err = p.errs.err()
-			}
-		}()
-	}
+// savepoint stores all state required to go back to this point in the
+// parser.
+type savepoint struct {
+	position
+	rn rune
+	w  int
+	// ==template== {{ if .FuzzyMatch }}
+	fuzzy *fuzzyEdit
+	// {{ end }} ==template==
+	// ==template== {{ if .ErrorRepair }}
+	repair *repairEdit
+	// {{ end }} ==template==
+	// ==template== {{ if .BacktrackHooks }}
+	undo *undoHook
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleBoundaryPositions }}
+	rulePos *rulePosNode
+	// {{ end }} ==template==
+	// ==template== {{ if .GraphemeInput }}
+	// set if the rune continues the grapheme cluster of the previous rune
+	ext bool
+	// set if the rune is a regional indicator that starts a pair
+	riOpen bool
+	// {{ end }} ==template==
+}
 
-	var startRule *rule
-	for _, r := range p.rules {
-		if r.name == p.entrypoint {
-			startRule = r
-			break
-		}
-	}
-	if startRule == nil {
-		p.addErr(errInvalidEntrypoint)
-		return nil, p.errs.err()
-	}
+type current struct {
+	pos  position // start position of the match
+	text []byte   // raw text of the match
 
-	p.read() // advance to first rune
-	var ok bool
-	val, ok = p.parseRuleWrap(startRule)
-	if !ok {
-		if len(*p.errs) == 0 {
-			// If parsing fails, but no errors have been recorded, the expected values
-			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
-		}
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
 
-		return nil, p.errs.err()
-	}
-	return val, p.errs.err()
-}
+	// state is a store for arbitrary key,value pairs that the user wants to be
+	// tied to the backtracking of the parser.
+	// This is always rolled back if a parsing rule fails.
+	state storeDict
 
-func listJoin(list []string, sep string, lastSep string) string {
-	switch len(list) {
-	case 0:
-		return ""
-	case 1:
-		return list[0]
-	default:
-		return strings.Join(list[:len(list)-1], sep) + " " + lastSep + " " + list[len(list)-1]
-	}
-}
+	// {{ end }} ==template==
 
-// ==template== {{ if .LeftRecursion }}
+	// globalStore is a general store for the user to store arbitrary key-value
+	// pairs that they need to manage and that they do not want tied to the
+	// backtracking of the parser. This is only modified by the user and never
+	// rolled back by the parser. It is always up to the user to keep this in a
+	// consistent state.
+	globalStore storeDict
+	// ==template== {{ if .LabelSpans }}
 
-func (p *parser) parseRuleRecursiveLeader(rule *rule) (any, bool) {
-	result, ok := p.getMemoized(rule)
-	if ok {
-		p.restore(result.end)
-		return result.v, result.b
-	}
+	// spans maps the labels visible to the current code block to the extent
+	// of the input they matched.
+	spans map[string]labelSpan
+	// {{ end }} ==template==
+	// ==template== {{ if .BacktrackHooks }}
 
-	// ==template== {{ if not .Optimize }}
-	if p.debug {
-		defer p.out(p.in("recursive " + rule.name))
-	}
+	// backtrack holds the functions registered by the current action with
+	// onBacktrack.
+	backtrack []func()
 	// {{ end }} ==template==
+	// ==template== {{ if .MethodReceiver }}
 
-	var (
-		depth      = 0
-		startMark  = p.pt
-		lastResult = resultTuple{nil, false, startMark}
-		lastErrors = *p.errs
-	)
+	// recv is the value whose method parses the input.
+	recv *{{ .MethodReceiver }}
+	// {{ end }} ==template==
+	// ==template== {{ if .ChoiceIndex }}
 
-	for {
-		// ==template== {{ if or .GlobalState (not .Optimize) }}
-		lastState := p.cloneState()
-		// {{ end }} ==template==
-		p.setMemoized(startMark, rule, lastResult)
-		val, ok := p.parseRule(rule)
-		endMark := p.pt
-		// ==template== {{ if not .Optimize }}
-		if p.debug {
-			p.printIndent("RECURSIVE", fmt.Sprintf(
-				"Rule %s depth %d: %t -> %s",
-				rule.name, depth, ok, string(p.sliceFrom(startMark))))
-		}
-		// {{ end }} ==template==
-		if (!ok) || (endMark.offset <= lastResult.end.offset && depth != 0) {
-			// ==template== {{ if or .GlobalState (not .Optimize) }}
-			p.restoreState(lastState)
-			// {{ end }} ==template==
-			*p.errs = lastErrors
-			break
-		}
-		lastResult = resultTuple{val, ok, endMark}
-		lastErrors = *p.errs
-		p.restore(startMark)
-		depth++
-	}
+	// choiceIx is the index of the alternative that matched in the choice
+	// expression of the current action, -1 if it has none.
+	choiceIx int
+	// {{ end }} ==template==
+	// ==template== {{ if .TypedThrows }}
 
-	p.restore(lastResult.end)
-	p.setMemoized(startMark, rule, lastResult)
-	return lastResult.v, lastResult.b
+	// recovering is the throw being recovered, nil if there is none.
+	recovering *ThrowError
+	// {{ end }} ==template==
+	// ==template== {{ if .RuntimeParams }}
+
+	// params is the stack of the arguments of the rules being matched.
+	params *paramFrame
+	// {{ end }} ==template==
+	// ==template== {{ if .UserData }}
+	// the user data set with the UserData option
+	userData any
+	// {{ end }} ==template==
 }
 
-func (p *parser) parseRuleRecursiveNoLeader(rule *rule) (any, bool) {
-	return p.parseRule(rule)
+type storeDict map[string]any
+
+// ==template== {{ if .BacktrackHooks }}
+// onBacktrack registers fn to be called if the match of the current action
+// is abandoned, i.e. if an enclosing expression fails or is a predicate so
+// that the parser backtracks to a position before the action, e.g. to
+// release a resource acquired by the action. The functions of the
+// abandoned matches are called in the reverse order of their registration,
+// the last registered first, as soon as the parser backtracks.
+//
+// The functions must be cheap and must not panic: they run in the middle
+// of the parsing, and those of the matches that are kept are never called.
+// The results of the rules whose actions register functions are not
+// memoized, as their actions must run again to register them again.
+func (c *current) onBacktrack(fn func()) {
+	c.backtrack = append(c.backtrack, fn)
+}
+
+// undoHook is a node of the immutable list of the functions registered by
+// the actions up to a savepoint, so that restoring a savepoint calls the
+// functions registered since.
+type undoHook struct {
+	prev *undoHook
+	fn   func()
 }
 
 // {{ end }} ==template==
 
-// ==template== {{ if not .Optimize }}
-func (p *parser) parseRuleMemoize(rule *rule) (any, bool) {
-	res, ok := p.getMemoized(rule)
-	if ok {
-		p.restore(res.end)
-		return res.v, res.b
-	}
+// ==template== {{ if .TypedThrows }}
+// ThrowError is the throw of a failure label with a payload, the value of
+// the code block of its throw expression. If no recovery expression
+// recovers the throw and the parse fails, the farthest ThrowError is
+// reported as a parsing error at the position of the throw, otherwise the
+// code blocks of the recovery expression get it with c.thrown().
+type ThrowError struct {
+	// Label is the failure label thrown.
+	Label string
+	// Payload is the value returned by the code block of the throw
+	// expression.
+	Payload any
+}
 
-	startMark := p.pt
+// Error implements the error interface.
+func (e *ThrowError) Error() string {
+	return fmt.Sprintf("uncaught throw %s: %v", e.Label, e.Payload)
+}
+
+// thrown returns the throw recovered by the recovery expression that the
+// current code block is part of, or nil if there is none. The Payload of
+// the throw is nil if its throw expression has no payload.
+func (c *current) thrown() *ThrowError {
+	return c.recovering
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .RuntimeParams }}
+// param returns the value of the argument name passed to the rule that the
+// current code block is part of, or to the innermost rule being matched
+// that has this argument, e.g. the value of n for Items(count=n) in the
+// code blocks of Items and of the rules it references. It returns nil if
+// no rule being matched has this argument.
+func (c *current) param(name string) any {
+	for f := c.params; f != nil; f = f.prev {
+		if v, ok := f.args[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .UserData }}
+// data returns the user data of the parse set with the UserData option,
+// or nil if it is not set.
+func (c *current) data() any {
+	return c.userData
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ChoiceIndex }}
+// choiceIndex returns the index of the alternative that matched in the
+// choice expression of the current action, e.g. 1 if "b" matched in
+// ( "a" / "b" ) { ... }, or -1 if the expression of the action is not a
+// choice expression.
+func (c *current) choiceIndex() int {
+	return c.choiceIx
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .LabelSpans }}
+// labelSpan is the extent of the input matched by a labeled expression.
+type labelSpan struct {
+	start, end int
+}
+
+// labelSpan returns the start and end offsets in the input of the text
+// matched by the labeled expression named label. The matched text is
+// data[start:end]. The ok result is false if label did not match.
+func (c *current) labelSpan(label string) (start, end int, ok bool) {
+	span, ok := c.spans[label]
+	return span.start, span.end, ok
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ZeroCopyText }}
+// textBytes returns the text of the current match as a subslice of the
+// input, without copying it, unlike string(c.text). It is only valid
+// during the call of the code block: it must not be modified, nor kept if
+// the input is modified after the parsing. Its capacity is its length, so
+// that appending to it does not overwrite the input.
+func (c *current) textBytes() []byte {
+	return c.text[:len(c.text):len(c.text)]
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .NumericHelpers }}
+// int parses the text of the current match as a base 10 int.
+func (c *current) int() (int, error) {
+	return strconv.Atoi(string(c.text))
+}
+
+// float parses the text of the current match as a float64.
+func (c *current) float() (float64, error) {
+	return strconv.ParseFloat(string(c.text), 64)
+}
+
+// uint parses the text of the current match as a uint64 in the given base,
+// as accepted by strconv.ParseUint.
+func (c *current) uint(base int) (uint64, error) {
+	return strconv.ParseUint(string(c.text), base, 64)
+}
+
+// {{ end }} ==template==
+
+// the AST types...
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type grammar struct {
+	pos   position
+	rules []*rule
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type rule struct {
+	pos         position
+	name        string
+	displayName string
+	expr        any
+
+	// ==template== {{ if .RuleErrorMessages }}
+	errorMessage string
+	// {{ end }} ==template==
+
+	// ==template== {{ if .RuleAssertions }}
+	assert    func(v any) bool
+	assertion string
+	// {{ end }} ==template==
+
+	// ==template== {{ if .FollowSets }}
+	first    []string
+	follow   []string
+	nullable bool
+	// {{ end }} ==template==
+
+	// ==template== {{ if .DenseMemo }}
+	// index of the rule in the rules of the grammar, its row in the dense
+	// memoization table
+	index int
+	// {{ end }} ==template==
+
+	// ==template== {{ if .LeftRecursion }}
+	leader        bool
+	leftRecursive bool
+	// {{ end }} ==template==
+
+	// ==template== {{ if .OptimizeRules }}
+	optimized bool
+	// {{ end }} ==template==
+
+	// ==template== {{ if .RuleDocs }}
+	doc string
+	// {{ end }} ==template==
+
+	// ==template== {{ if .GrammarLineMap }}
+	endLine int
+	// {{ end }} ==template==
+
+	// ==template== {{ if .LazyRuleInit }}
+	// builds expr the first time the rule is matched
+	newExpr  func() any
+	exprOnce sync.Once
+	// {{ end }} ==template==
+}
+
+// ==template== {{ if .LazyRuleInit }}
+// expression returns the expression of the rule, built the first time it
+// is called.
+func (r *rule) expression() any {
+	r.exprOnce.Do(func() {
+		r.expr = r.newExpr()
+	})
+	return r.expr
+}
+
+// {{ end }} ==template==
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type choiceExpr struct {
+	pos          position
+	alternatives []any
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type actionExpr struct {
+	pos  position
+	expr any
+	// ==template== {{ if .SwitchDispatch }}
+	code int
+	// {{ else }}
+	run func(*parser) (any, error)
+	// {{ end }} ==template==
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type recoveryExpr struct {
+	pos          position
+	expr         any
+	recoverExpr  any
+	failureLabel []string
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type seqExpr struct {
+	pos   position
+	exprs []any
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type throwExpr struct {
+	pos   position
+	label string
+	// ==template== {{ if .TypedThrows }}
+	// function of the code block of the payload, nil if it has none
+	payload func(*parser) (any, error)
+	// {{ end }} ==template==
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type labeledExpr struct {
+	pos   position
+	label string
+	expr  any
+	// ==template== {{ if .RuntimeParams }}
+	// true if expr is a rule reference with arguments, which are evaluated
+	// with the labels in scope of the label
+	argsRef bool
+	// {{ end }} ==template==
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type expr struct {
+	pos  position
+	expr any
+}
+
+type (
+	andExpr        expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+	notExpr        expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+	zeroOrOneExpr  expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+	zeroOrMoreExpr expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+	oneOrMoreExpr  expr //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+)
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type ruleRefExpr struct {
+	pos    position
+	offset int
+	// ==template== {{ if .RuntimeParams }}
+	// arguments passed to the rule when it is matched
+	args []ruleArg
+	// {{ end }} ==template==
+}
+
+// ==template== {{ if .RuntimeParams }}
+// ruleArg is an argument passed by a rule reference to the rule it
+// references, value returns its value.
+type ruleArg struct {
+	name  string
+	value func(*parser) (any, error)
+}
+
+// paramFrame is a frame of the immutable stack of the arguments of the
+// rules being matched, the arguments of the innermost rule first.
+type paramFrame struct {
+	prev *paramFrame
+	args map[string]any
+}
+
+// ==template== {{ if .LeftRecursion }}
+
+// paramMemoKey is the memoization key of a left-recursive rule matched
+// with arguments, the frame of the arguments identifies the rule call.
+type paramMemoKey struct {
+	key    any
+	params *paramFrame
+}
+
+// {{ end }} ==template==
+
+// {{ end }} ==template==
+
+// ==template== {{ if or .GlobalState (not .Optimize) }}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type stateCodeExpr struct {
+	pos position
+	// ==template== {{ if .SwitchDispatch }}
+	code int
+	// {{ else }}
+	run func(*parser) error
+	// {{ end }} ==template==
+}
+
+// {{ end }} ==template==
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type andCodeExpr struct {
+	pos position
+	// ==template== {{ if .SwitchDispatch }}
+	code int
+	// {{ else }}
+	run func(*parser) (bool, error)
+	// {{ end }} ==template==
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type notCodeExpr struct {
+	pos position
+	// ==template== {{ if .SwitchDispatch }}
+	code int
+	// {{ else }}
+	run func(*parser) (bool, error)
+	// {{ end }} ==template==
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type litMatcher struct {
+	pos        position
+	val        string
+	ignoreCase bool
+	want       string
+}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type charClassMatcher struct {
+	pos             position
+	val             string
+	basicLatinChars [128]bool
+	chars           []rune
+	ranges          []rune
+	classes         []*unicode.RangeTable
+	ignoreCase      bool
+	inverted        bool
+}
+
+// ==template== {{ if .RunCharClass }}
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type charClassRunExpr struct {
+	pos     position
+	matcher *charClassMatcher
+	min     int
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .GuardOptimization }}
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type guardExpr struct {
+	pos  position
+	not  bool
+	expr any
+}
+
+// {{ end }} ==template==
+
+type anyMatcher position //{{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+
+// ==template== {{ if .TableDriven }}
+
+// opcode is the operation of an instruction of a table-driven parser.
+type opcode uint8
+
+const (
+	opAction opcode = iota
+	opAndCode
+	opAnd
+	opAny
+	opCharClass
+	opChoice
+	opLabeled
+	opLit
+	opNotCode
+	opNot
+	opOneOrMore
+	opRuleRef
+	opSeq
+	opStateCode
+	opZeroOrMore
+	opZeroOrOne
+)
+
+var opNames = [...]string{
+	opAction:     "action",
+	opAndCode:    "andCode",
+	opAnd:        "and",
+	opAny:        "any",
+	opCharClass:  "charClass",
+	opChoice:     "choice",
+	opLabeled:    "labeled",
+	opLit:        "lit",
+	opNotCode:    "notCode",
+	opNot:        "not",
+	opOneOrMore:  "oneOrMore",
+	opRuleRef:    "ruleRef",
+	opSeq:        "seq",
+	opStateCode:  "stateCode",
+	opZeroOrMore: "zeroOrMore",
+	opZeroOrOne:  "zeroOrOne",
+}
+
+func (op opcode) String() string {
+	if int(op) < len(opNames) {
+		return opNames[op]
+	}
+	return strconv.Itoa(int(op))
+}
+
+// instr is an instruction of a table-driven parser. Its sub-instructions
+// are the n indexes of the children table that start at sub, and arg is
+// the index of its operand in the table of its operation, or the offset of
+// the rule it references.
+//
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type instr struct {
+	op  opcode
+	pos position
+	arg int
+	sub int
+	n   int
+}
+
+// instrTable is the program of a table-driven parser: the instructions of
+// the expressions of the rules, the indexes of their sub-instructions and
+// their operands.
+//
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type instrTable struct {
+	instrs   []instr
+	children []int
+	lits     []*litMatcher
+	classes  []*charClassMatcher
+	labels   []string
+	actions  []func(*parser) (any, error)
+	preds    []func(*parser) (bool, error)
+	states   []func(*parser) error
+}
+
+// tableExpr is an expression of a table-driven parser, the index of its
+// instruction in the table.
+type tableExpr int
+
+// ==template== {{ if .ExplicitStack }}
+
+// noInstr is returned instead of the sub-instruction to start when an
+// instruction of the work stack completed.
+const noInstr tableExpr = -1
+
+// workFrame is a frame of the work stack of the parser: the instruction
+// being run, the number of its sub-instructions that completed, the
+// length of the values stack and the position when it started, and the
+// state to restore if it fails.
+type workFrame struct {
+	ix    tableExpr
+	n     int
+	mark  int
+	start savepoint
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	state storeDict
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleErrorMessages }}
+	// farthest failure when the rule referenced started, see parseRule
+	failOffset int
+	failLen    int
+	// {{ end }} ==template==
+}
+
+// {{ end }} ==template==
+// {{ end }} ==template==
+
+// ==template== {{ if .RuleInterface }}
+
+var _ {{ .RuleInterface }} = (*rule)(nil)
+
+// Rules returns the rules of the grammar, in the order they are defined.
+func Rules() []{{ .RuleInterface }} {
+	rules := make([]{{ .RuleInterface }}, 0, len(g.rules))
+	for _, r := range g.rules {
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// Name returns the name of the rule.
+func (r *rule) Name() string {
+	return r.name
+}
+
+// DisplayName returns the display name of the rule as used in error
+// messages, or its name if it has none.
+func (r *rule) DisplayName() string {
+	if r.displayName != "" {
+		return r.displayName
+	}
+	return r.name
+}
+
+// Position returns the position of the rule in the grammar.
+func (r *rule) Position() (line, col, offset int) {
+	return r.pos.line, r.pos.col, r.pos.offset
+}
+
+// ==template== {{ if .RuleDocs }}
+
+// Doc returns the documentation of the rule, the text of the line comments
+// that precede it in the grammar.
+func (r *rule) Doc() string {
+	return r.doc
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .GrammarLineMap }}
+
+// Lines returns the first and last lines of the rule in the grammar.
+func (r *rule) Lines() (start, end int) {
+	return r.pos.line, r.endLine
+}
+
+// {{ end }} ==template==
+
+// {{ end }} ==template==
+
+// ==template== {{ if .RuleDocs }}
+
+// RuleDoc returns the documentation of the rule named name, the text of
+// the line comments that precede it in the grammar, or an empty string if
+// it has none or there is no such rule.
+func RuleDoc(name string) string { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	for _, r := range g.rules {
+		if r.name == name {
+			return r.doc
+		}
+	}
+	return ""
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .GrammarLineMap }}
+
+// LineRange is a range of lines of the grammar, from Start to End
+// inclusive.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// GrammarLines returns the range of lines of each rule in the grammar, by
+// rule name. The rules optimized out of the parser are not included.
+func GrammarLines() map[string]LineRange { //{{ if .Nolint }} nolint: deadcode {{else}} ==template== {{ end }}
+	lines := make(map[string]LineRange, len(g.rules))
+	for _, r := range g.rules {
+		lines[r.name] = LineRange{Start: r.pos.line, End: r.endLine}
+	}
+	return lines
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .BalancedExpr }}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type balancedExpr struct {
+	pos       position
+	open      []byte
+	close     []byte
+	wantOpen  string
+	wantClose string
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .UntilExpr }}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type untilExpr struct {
+	pos position
+	// terminator, lit is set instead of expr for a case-sensitive literal
+	expr any
+	lit  []byte
+	want string
+	// match up to the end of the input if the terminator is not found
+	eof bool
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .LookbehindExpr }}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type lookbehindExpr struct {
+	pos  position
+	expr any
+	// number of characters matched by expr
+	runes int
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ScanLimits }}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type scanLimitExpr struct {
+	pos  position
+	expr any
+	// number of bytes past its start that expr may scan
+	limit int
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .LineStart }}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type lineStartExpr struct {
+	pos position
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .AssertionExpr }}
+
+// {{ if .Nolint }} nolint: structcheck {{else}} ==template== {{ end }}
+type assertionExpr struct {
+	pos position
+	// the assertion, \b for a word boundary or $ for a line end
+	name string
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .MatcherInterface }}
+
+// matcher is implemented by the expressions of the grammar, parseExpr
+// calls the match method of an expression to parse it. A custom
+// expression type can be used in the grammar by implementing it.
+type matcher interface {
+	match(p *parser) (any, bool)
+}
+
+func (a *actionExpr) match(p *parser) (any, bool) { return p.parseActionExpr(a) }
+
+func (a *andCodeExpr) match(p *parser) (any, bool) { return p.parseAndCodeExpr(a) }
+
+func (a *andExpr) match(p *parser) (any, bool) { return p.parseAndExpr(a) }
+
+func (a *anyMatcher) match(p *parser) (any, bool) { return p.parseAnyMatcher(a) }
+
+// ==template== {{ if .AssertionExpr }}
+func (a *assertionExpr) match(p *parser) (any, bool) { return p.parseAssertionExpr(a) }
+// {{ end }} ==template==
+
+// ==template== {{ if .BalancedExpr }}
+func (b *balancedExpr) match(p *parser) (any, bool) { return p.parseBalancedExpr(b) }
+// {{ end }} ==template==
+
+func (c *charClassMatcher) match(p *parser) (any, bool) { return p.parseCharClassMatcher(c) }
+
+// ==template== {{ if .RunCharClass }}
+func (r *charClassRunExpr) match(p *parser) (any, bool) { return p.parseCharClassRunExpr(r) }
+// {{ end }} ==template==
+
+func (c *choiceExpr) match(p *parser) (any, bool) { return p.parseChoiceExpr(c) }
+
+// ==template== {{ if .GuardOptimization }}
+func (g *guardExpr) match(p *parser) (any, bool) { return p.parseGuardExpr(g) }
+// {{ end }} ==template==
+
+func (l *labeledExpr) match(p *parser) (any, bool) { return p.parseLabeledExpr(l) }
+
+// ==template== {{ if .LineStart }}
+func (l *lineStartExpr) match(p *parser) (any, bool) { return p.parseLineStartExpr(l) }
+// {{ end }} ==template==
+
+func (l *litMatcher) match(p *parser) (any, bool) { return p.parseLitMatcher(l) }
+
+// ==template== {{ if .LookbehindExpr }}
+func (l *lookbehindExpr) match(p *parser) (any, bool) { return p.parseLookbehindExpr(l) }
+// {{ end }} ==template==
+
+func (n *notCodeExpr) match(p *parser) (any, bool) { return p.parseNotCodeExpr(n) }
+
+func (n *notExpr) match(p *parser) (any, bool) { return p.parseNotExpr(n) }
+
+func (o *oneOrMoreExpr) match(p *parser) (any, bool) { return p.parseOneOrMoreExpr(o) }
+
+func (r *recoveryExpr) match(p *parser) (any, bool) { return p.parseRecoveryExpr(r) }
+
+func (r *ruleRefExpr) match(p *parser) (any, bool) { return p.parseRuleRefExpr(r) }
+
+// ==template== {{ if .ScanLimits }}
+func (s *scanLimitExpr) match(p *parser) (any, bool) { return p.parseScanLimitExpr(s) }
+// {{ end }} ==template==
+
+func (s *seqExpr) match(p *parser) (any, bool) { return p.parseSeqExpr(s) }
+
+// ==template== {{ if .TableDriven }}
+func (t tableExpr) match(p *parser) (any, bool) { return p.execInstr(t) }
+// {{ end }} ==template==
+
+// ==template== {{ if or .GlobalState (not .Optimize) }}
+func (s *stateCodeExpr) match(p *parser) (any, bool) { return p.parseStateCodeExpr(s) }
+// {{ end }} ==template==
+
+func (t *throwExpr) match(p *parser) (any, bool) { return p.parseThrowExpr(t) }
+
+// ==template== {{ if .UntilExpr }}
+func (u *untilExpr) match(p *parser) (any, bool) { return p.parseUntilExpr(u) }
+// {{ end }} ==template==
+
+func (z *zeroOrMoreExpr) match(p *parser) (any, bool) { return p.parseZeroOrMoreExpr(z) }
+
+func (z *zeroOrOneExpr) match(p *parser) (any, bool) { return p.parseZeroOrOneExpr(z) }
+
+// {{ end }} ==template==
+
+// errList cumulates the errors found by the parser.
+type errList []error
+
+func (e *errList) add(err error) {
+	*e = append(*e, err)
+}
+
+func (e errList) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	e.dedupe()
+	return e
+}
+
+func (e *errList) dedupe() {
+	var cleaned []error
+	set := make(map[string]bool)
+	for _, err := range *e {
+		if msg := err.Error(); !set[msg] {
+			set[msg] = true
+			cleaned = append(cleaned, err)
+		}
+	}
+	*e = cleaned
+}
+
+func (e errList) Error() string {
+	switch len(e) {
+	case 0:
+		return ""
+	case 1:
+		return e[0].Error()
+	default:
+		var buf bytes.Buffer
+
+		for i, err := range e {
+			if i > 0 {
+				buf.WriteRune('\n')
+			}
+			buf.WriteString(err.Error())
+		}
+		return buf.String()
+	}
+}
+
+// parserError wraps an error with a prefix indicating the rule in which
+// the error occurred. The original error is stored in the Inner field.
+type parserError struct {
+	Inner    error
+	pos      position
+	prefix   string
+	expected []string
+	// ==template== {{ if .ErrorSpans }}
+	spanStart, spanEnd int
+	// {{ end }} ==template==
+	// ==template== {{ if .BidiAware }}
+	dir Direction
+	// {{ end }} ==template==
+	// ==template== {{ if .ExplainFailures }}
+	explanation string
+	// {{ end }} ==template==
+}
+
+// Error returns the error message.
+func (p *parserError) Error() string {
+	return p.prefix + ": " + p.Inner.Error()
+}
+
+// ==template== {{ if .ErrorSpans }}
+// Span returns the byte offsets of the start and end (exclusive) of the
+// input delimiting the error, e.g. to underline it in an editor. For an
+// error returned by a code block, it is the input matched by the code
+// block's expression. For the error of a failed parse, it is the input from
+// the start of the innermost rule that failed after matching some input up
+// to the farthest failure, to the end of the character at that failure.
+func (p *parserError) Span() (start, end int) {
+	return p.spanStart, p.spanEnd
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ExplainFailures }}
+// Explanation returns a description in prose of the farthest failure of
+// a parse, with the rules being matched, the tokens expected and the rule
+// matched just before, e.g. while parsing Stmt in File, expected "=" after
+// Ident at line 3, column 5. It is empty for the errors returned by code
+// blocks.
+func (p *parserError) Explanation() string {
+	return p.explanation
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .BidiAware }}
+// Direction returns the direction of the text around the position of the
+// error, see the bidiDirection method of the parser.
+func (p *parserError) Direction() Direction {
+	return p.dir
+}
+
+// Direction is the direction of the text around a position of the input.
+type Direction int
+
+// List of the directions of the text.
+const (
+	LeftToRight Direction = iota
+	RightToLeft
+)
+
+// String returns the name of the direction.
+func (d Direction) String() string {
+	if d == RightToLeft {
+		return "right-to-left"
+	}
+	return "left-to-right"
+}
+
+// rtlScripts are the scripts written from right to left.
+var rtlScripts = []*unicode.RangeTable{
+	unicode.Adlam,
+	unicode.Arabic,
+	unicode.Hanifi_Rohingya,
+	unicode.Hebrew,
+	unicode.Mandaic,
+	unicode.Mende_Kikakui,
+	unicode.Nko,
+	unicode.Samaritan,
+	unicode.Syriac,
+	unicode.Thaana,
+}
+
+// runeDirection returns the direction of r and true if it is a letter,
+// which has a strong direction, and false otherwise.
+func runeDirection(r rune) (Direction, bool) {
+	if !unicode.IsLetter(r) {
+		return LeftToRight, false
+	}
+	if unicode.In(r, rtlScripts...) {
+		return RightToLeft, true
+	}
+	return LeftToRight, true
+}
+
+// bidiDirection returns the direction of the text run at the byte offset
+// off of the input. It is the direction of the character at off if it is a
+// letter, or else of the letters around it on its line if they have the
+// same direction, or else the direction of the line, that of its first
+// letter, as in the Unicode bidirectional algorithm. The scripts of the
+// letters give their direction, so the explicit direction marks of the
+// algorithm are ignored.
+func (p *parser) bidiDirection(off int) Direction {
+	if off > len(p.data) {
+		off = len(p.data)
+	}
+	start := bytes.LastIndexByte(p.data[:off], '\n') + 1
+	end := len(p.data)
+	if ix := bytes.IndexByte(p.data[off:], '\n'); ix >= 0 {
+		end = off + ix
+	}
+
+	r, _ := utf8.DecodeRune(p.data[off:end])
+	if dir, ok := runeDirection(r); ok {
+		return dir
+	}
+	before, okBefore := LeftToRight, false
+	for b := p.data[start:off]; len(b) > 0 && !okBefore; {
+		r, n := utf8.DecodeLastRune(b)
+		before, okBefore = runeDirection(r)
+		b = b[:len(b)-n]
+	}
+	after, okAfter := LeftToRight, false
+	for b := p.data[off:end]; len(b) > 0 && !okAfter; {
+		r, n := utf8.DecodeRune(b)
+		after, okAfter = runeDirection(r)
+		b = b[n:]
+	}
+	if okBefore && okAfter && before == after {
+		return before
+	}
+	for b := p.data[start:end]; len(b) > 0; {
+		r, n := utf8.DecodeRune(b)
+		if dir, ok := runeDirection(r); ok {
+			return dir
+		}
+		b = b[n:]
+	}
+	return LeftToRight
+}
+
+// {{ end }} ==template==
+
+// newParser creates a parser with the specified input source and options.
+func newParser(filename string, b []byte, opts ...Option) *parser {
+	stats := Stats{
+		ChoiceAltCnt: make(map[string]map[string]int),
+	}
+
+	p := &parser{
+		filename: filename,
+		errs:     new(errList),
+		data:     b,
+		pt:       savepoint{position: position{line: 1}},
+		recover:  true,
+		cur: current{
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			state: make(storeDict),
+			// {{ end }} ==template==
+			globalStore: make(storeDict),
+		},
+		maxFailPos:      position{col: 1, line: 1},
+		maxFailExpected: make([]string, 0, 20),
+		Stats:           &stats,
+		// ==template== {{ if .FuzzyMatch }}
+		fuzzyMaxErrors: {{ .FuzzyMatch }},
+		// {{ end }} ==template==
+		// start rule is rule [0] unless an alternate entrypoint is specified
+		entrypoint: g.rules[0].name,
+		// ==template== {{ if .OverridableActions }}
+		actions: actionFuncs,
+		// {{ end }} ==template==
+	}
+	p.setOptions(opts)
+	// ==template== {{ if .Preprocessors }}
+	if len(p.preprocessors) > 0 {
+		p.preprocess()
+	}
+	// {{ end }} ==template==
+
+	if p.maxExprCnt == 0 {
+		p.maxExprCnt = math.MaxUint64
+	}
+
+	return p
+}
+
+// setOptions applies the options to the parser.
+func (p *parser) setOptions(opts []Option) {
+	for _, opt := range opts {
+		opt(p)
+	}
+}
+
+// {{ if .Nolint }} nolint: structcheck,deadcode {{else}} ==template== {{ end }}
+type resultTuple struct {
+	v   any
+	b   bool
+	end savepoint
+}
+
+// {{ if .Nolint }} nolint: varcheck {{else}} ==template== {{ end }}
+const choiceNoMatch = -1
+
+// Stats stores some statistics, gathered during parsing
+type Stats struct {
+	// ExprCnt counts the number of expressions processed during parsing
+	// This value is compared to the maximum number of expressions allowed
+	// (set by the MaxExpressions option).
+	ExprCnt uint64
+
+	// ChoiceAltCnt is used to count for each ordered choice expression,
+	// which alternative is used how may times.
+	// These numbers allow to optimize the order of the ordered choice expression
+	// to increase the performance of the parser
+	//
+	// The outer key of ChoiceAltCnt is composed of the name of the rule as well
+	// as the line and the column of the ordered choice.
+	// The inner key of ChoiceAltCnt is the number (one-based) of the matching alternative.
+	// For each alternative the number of matches are counted. If an ordered choice does not
+	// match, a special counter is incremented. The name of this counter is set with
+	// the parser option Statistics.
+	// For an alternative to be included in ChoiceAltCnt, it has to match at least once.
+	ChoiceAltCnt map[string]map[string]int
+}
+
+// ==template== {{ if .LeftRecursion }}
+type ruleWithExpsStack struct {
+	rule   *rule
+	estack []any
+}
+
+// {{ end }} ==template==
+
+// {{ if .Nolint }} nolint: structcheck,maligned {{else}} ==template== {{ end }}
+type parser struct {
+	filename string
+	pt       savepoint
+	cur      current
+
+	data []byte
+	errs *errList
+
+	// ==template== {{ if .StreamWindow }}
+	// stream read by ParseStream, data is then a window of its input that
+	// starts at the offset streamBase.
+	stream     io.Reader
+	streamBase int
+	streamEOF  bool
+	// {{ end }} ==template==
+
+	depth   int
+	recover bool
+	// ==template== {{ if not .Optimize }}
+	debug bool
+
+	memoize bool
+	// {{ end }} ==template==
+	// ==template== {{ if .OptimizeRules }}
+	// debug and memoize modes of the rules that are not optimized
+	ruleDebug   bool
+	ruleMemoize bool
+	// {{ end }} ==template==
+	// ==template== {{ if or .LeftRecursion (not .Optimize) }}
+	// memoization table for the packrat algorithm:
+	// map[offset in source] map[expression or rule] {value, match}
+	memo map[int]map[any]resultTuple
+	// {{ end }} ==template==
+	// ==template== {{ if .DenseMemo }}
+	// dense memoization table of the rules, by rule index and offset in
+	// source, nil if it would be too large for the input
+	denseMemo [][]denseMemoEntry
+	// {{ end }} ==template==
+	// ==template== {{ if .OverridableActions }}
+	// implementations of the action code blocks, see SetAction
+	actions map[string]ActionFunc
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleTiming }}
+	// time spent in each rule and number of active calls of each rule,
+	// see RuleTimes
+	ruleTimes map[string]time.Duration
+	ruleCalls map[string]int
+	// {{ end }} ==template==
+	// ==template== {{ if .ReportMaxDepth }}
+	// deepest nesting reached, see ReachedDepth
+	reached *Depth
+	// {{ end }} ==template==
+	// ==template== {{ if .Preprocessors }}
+	// stages run on the input, see Preprocess, the input before the
+	// stages, and the composed map of the offsets of data to its offsets
+	preprocessors []Preprocessor
+	input         []byte
+	posMap        PositionMap
+	// {{ end }} ==template==
+	// ==template== {{ if .StructuredTrace }}
+	// receiver of the rules entered and exited, see Trace
+	tracer TraceLogger
+	// {{ end }} ==template==
+	// ==template== {{ if .ChromeTrace }}
+	// receiver of the trace of the rules, see ChromeTrace, the events
+	// recorded and the time they are relative to
+	chromeTrace io.Writer
+	traceEvents []traceEvent
+	traceStart  time.Time
+	// {{ end }} ==template==
+	// ==template== {{ if .OTelSpans }}
+	// context of the parse, see WithContext
+	ctx context.Context
+	// {{ end }} ==template==
+	// ==template== {{ if .ProgressCallback }}
+	// progress reporting, see Progress; progressLast is the last offset
+	// reported, and progressNext the offset from which to report again
+	onProgress    func(offset, total int)
+	progressEvery int
+	progressLast  int
+	progressNext  int
+	// {{ end }} ==template==
+	// ==template== {{ if .MemoKeyHook }}
+	// discriminator of the memoization keys, see MemoKey
+	memoKeyFunc func(c *current) any
+	// {{ end }} ==template==
+	// ==template== {{ if .ModeAwareMemo }}
+	// number of mode generations, the last one set by bumpModeGen
+	modeGenCnt int
+	// {{ end }} ==template==
+
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
+	// ==template== {{ if .LineRecovery }}
+	// start rule resolved by ParseLines for all its items, nil if parse
+	// resolves it
+	startRule *rule
+	// {{ end }} ==template==
+	// variables stack, map of label to value
+	vstack []map[string]any
+	// rule stack, allows identification of the current rule in errors
+	rstack []*rule
+	// ==template== {{ if .LabelSpans }}
+	// spans stack, map of label to matched extent, in sync with vstack
+	sstack []map[string]labelSpan
+	// {{ end }} ==template==
+	// ==template== {{ if .ExplicitStack }}
+	// work stack of the instructions being run, and values of the
+	// sub-instructions of the sequences and repetitions on it
+	work     []workFrame
+	workVals []any
+	// {{ end }} ==template==
+
+	// parse fail
+	maxFailPos            position
+	maxFailExpected       []string
+	maxFailInvertExpected bool
+	// ==template== {{ if .RuleErrorMessages }}
+	// custom error message of the rule that failed at maxFailPos
+	maxFailMessage string
+	// {{ end }} ==template==
+	// ==template== {{ if .TypedThrows }}
+	// error of the farthest throw with a payload that no recovery
+	// expression recovered, and its offset, reported if the parse fails
+	maxThrowErr    *parserError
+	maxThrowOffset int
+	// {{ end }} ==template==
+	// ==template== {{ if .ExplainFailures }}
+	// rules being matched by all the attempts that failed at maxFailPos,
+	// outermost first, and the rule matched just before maxFailPos by the
+	// innermost rule of an attempt, by depth of this rule
+	maxFailStack []*rule
+	maxFailAfter []*rule
+	// last rule that matched some input other than white space, with the
+	// offset of its end and the depth of the rule stack after it
+	lastMatch      *rule
+	lastMatchEnd   int
+	lastMatchDepth int
+	// {{ end }} ==template==
+	// ==template== {{ if .LookbehindExpr }}
+	// set while matching the expression of a lookbehind, whose failures
+	// are not recorded
+	inLookbehind bool
+	// {{ end }} ==template==
+	// ==template== {{ if .ScanLimits }}
+	// offset at which the input is seen as ending while a bounded
+	// alternative is matched, 0 if there is none, and whether the parser
+	// reached it
+	scanLimit    int
+	scanLimitHit bool
+	// {{ end }} ==template==
+	// ==template== {{ if .REPLMode }}
+	// set if the entrypoint rule matched
+	matched bool
+	// {{ end }} ==template==
+	// ==template== {{ if .PushParser }}
+	// position of the input in the input of a PushParser, and whether the
+	// parser reached its end
+	pushBase pushBase
+	atEnd    bool
+	// {{ end }} ==template==
+	// ==template== {{ if .ExpvarMetrics }}
+	// number of lookups and hits of memoized results
+	memoLookups int
+	memoHits    int
+	// {{ end }} ==template==
+	// ==template== {{ if .ErrorSpans }}
+	// start of the innermost rule that matched some input and failed after
+	// reaching maxFailPos, -1 if none did yet
+	maxFailSpanStart int
+	// {{ end }} ==template==
+	// ==template== {{ if .EmitValidate }}
+	// set when only validating the input, the values of the expressions are
+	// then not built
+	validate bool
+	// {{ end }} ==template==
+	// ==template== {{ if .LosslessCST }}
+	// stack of the concrete syntax tree nodes of the rules being matched,
+	// nil if no tree is built
+	cst []*CSTNode
+	// {{ end }} ==template==
+	// ==template== {{ if .CompactAST }}
+	// nodes of the compact syntax tree, and stack of the rules being
+	// matched, nil if no tree is built
+	compact      []CompactNode
+	compactStack []compactRule
+	// {{ end }} ==template==
+	// ==template== {{ if .FollowSets }}
+	// depth in the rule stack of each of maxFailExpected, and the outermost
+	// rules that failed at maxFailPos without matching any input, and their
+	// depth
+	maxFailDepths []int
+	maxFailRules  []*rule
+	maxFailDepth  int
+	// {{ end }} ==template==
+
+	// max number of expressions to be parsed
+	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
+	// ==template== {{ if .SinkResults }}
+	// sink function of ParseTo, the repetition whose values are pushed to
+	// it, and the error it returned
+	sink     func(any) error
+	sinkExpr any
+	sinkErr  error
+	// {{ end }} ==template==
+	// ==template== {{ if .FuzzyMatch }}
+	// max number of edits allowed by fuzzy matching, and by the current attempt
+	fuzzyMaxErrors int
+	fuzzyBudget    int
+	corrections    *[]Correction
+	// {{ end }} ==template==
+	// ==template== {{ if .ErrorRepair }}
+	// positions where the current attempt of error repair may insert
+	// missing literals
+	repairAt map[int]bool
+	repairs  *[]Repair
+	// {{ end }} ==template==
+	// ==template== {{ if .MemoReuseTrace }}
+	// memoized results reused by the parser, nil if they are not recorded
+	memoReuses *[]MemoReuse
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleBoundaryPositions }}
+	// positions of the rules in the result, nil if they are not recorded
+	rulePositions *[]RulePosition
+	// {{ end }} ==template==
+	// ==template== {{ if .ChoiceIndex }}
+	// index of the alternative that matched in the last choice expression
+	// that matched
+	choiceIx int
+	// {{ end }} ==template==
+	// ==template== {{ if .EnumerateParses }}
+	// set by ParseAll, the alternatives of the first choices evaluated are
+	// forced to forcedAlts, and the alternatives taken are recorded in
+	// choiceAlts, in the order the choices are evaluated
+	enumerate  bool
+	forcedAlts []int
+	choiceAlts []choiceDecision
+	// {{ end }} ==template==
+	// entrypoint for the parser
+	entrypoint string
+
+	allowInvalidUTF8 bool
+
+	// ==template== {{ if .ASCIIFastPath }}
+	// set if the input is only made of ASCII characters, so that its runes
+	// are read without decoding them
+	ascii bool
+
+	// {{ end }} ==template==
+	// ==template== {{ if .GraphemeInput }}
+	// set by ParseRunes, so that the any matcher matches grapheme clusters
+	// and the columns count them
+	graphemes bool
+
+	// {{ end }} ==template==
+	*Stats
+
+	choiceNoMatch string
+	// recovery expression stack, keeps track of the currently available recovery expression, these are traversed in reverse
+	recoveryStack []map[string]any
+}
+
+// push a variable set on the vstack.
+func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
+	// ==template== {{ if .ReportMaxDepth }}
+	if p.reached != nil && len(p.vstack) >= p.reached.VStack {
+		p.reached.VStack = len(p.vstack) + 1
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .LabelSpans }}
+	// the spans map is only allocated when a label is set
+	p.sstack = append(p.sstack, nil)
+
+	// {{ end }} ==template==
+	if cap(p.vstack) == len(p.vstack) {
+		// create new empty slot in the stack
+		p.vstack = append(p.vstack, nil)
+	} else {
+		// slice to 1 more
+		p.vstack = p.vstack[:len(p.vstack)+1]
+	}
+
+	// get the last args set
+	m := p.vstack[len(p.vstack)-1]
+	if m != nil && len(m) == 0 {
+		// empty map, all good
+		return
+	}
+
+	m = make(map[string]any)
+	p.vstack[len(p.vstack)-1] = m
+}
+
+// pop a variable set from the vstack.
+func (p *parser) popV() {
+	// ==template== {{ if .LabelSpans }}
+	p.sstack[len(p.sstack)-1] = nil
+	p.sstack = p.sstack[:len(p.sstack)-1]
+
+	// {{ end }} ==template==
+	// if the map is not empty, clear it
+	m := p.vstack[len(p.vstack)-1]
+	if len(m) > 0 {
+		// GC that map
+		p.vstack[len(p.vstack)-1] = nil
+	}
+	p.vstack = p.vstack[:len(p.vstack)-1]
+}
+
+// push a recovery expression with its labels to the recoveryStack
+func (p *parser) pushRecovery(labels []string, expr any) {
+	if cap(p.recoveryStack) == len(p.recoveryStack) {
+		// create new empty slot in the stack
+		p.recoveryStack = append(p.recoveryStack, nil)
+	} else {
+		// slice to 1 more
+		p.recoveryStack = p.recoveryStack[:len(p.recoveryStack)+1]
+	}
+
+	m := make(map[string]any, len(labels))
+	for _, fl := range labels {
+		m[fl] = expr
+	}
+	p.recoveryStack[len(p.recoveryStack)-1] = m
+}
+
+// pop a recovery expression from the recoveryStack
+func (p *parser) popRecovery() {
+	// GC that map
+	p.recoveryStack[len(p.recoveryStack)-1] = nil
+
+	p.recoveryStack = p.recoveryStack[:len(p.recoveryStack)-1]
+}
+
+// ==template== {{ if not .Optimize }}
+func (p *parser) print(prefix, s string) string {
+	if !p.debug {
+		return s
+	}
+
+	fmt.Printf("%s %d:%d:%d: %s [%#U]\n",
+		prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
+	return s
+}
+
+func (p *parser) printIndent(mark string, s string) string {
+	return p.print(strings.Repeat(" ", p.depth)+mark, s)
+}
+
+func (p *parser) in(s string) string {
+	res := p.printIndent(">", s)
+	p.depth++
+	return res
+}
+
+func (p *parser) out(s string) string {
+	p.depth--
+	return p.printIndent("<", s)
+}
+
+// {{ end }} ==template==
+
+func (p *parser) addErr(err error) {
+	p.addErrAt(err, p.pt.position, []string{})
+}
+
+func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
+	// ==template== {{ if .Preprocessors }}
+	// the error is reported at its position in the input before
+	// preprocessing
+	// ==template== {{ if or .ErrorSpans .BidiAware }}
+	ppos := pos
+	// {{ end }} ==template==
+	pos = p.originalPosition(pos)
+	// {{ end }} ==template==
+	// ==template== {{ if .PushParser }}
+	pos = p.pushBase.position(pos)
+	// {{ end }} ==template==
+	var buf bytes.Buffer
+	if p.filename != "" {
+		buf.WriteString(p.filename)
+	}
+	if buf.Len() > 0 {
+		buf.WriteString(":")
+	}
+	buf.WriteString(fmt.Sprintf("%d:%d (%d)", pos.line, pos.col, pos.offset))
+	if len(p.rstack) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString(": ")
+		}
+		rule := p.rstack[len(p.rstack)-1]
+		if rule.displayName != "" {
+			buf.WriteString("rule " + rule.displayName)
+		} else {
+			buf.WriteString("rule " + rule.name)
+		}
+	}
+	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
+	// ==template== {{ if .ErrorSpans }}
+	// ==template== {{ if .Preprocessors }}
+	// the span and the direction are computed in the preprocessed input
+	pe.spanStart, pe.spanEnd = p.errorSpan(ppos)
+	pe.spanStart, pe.spanEnd = p.originalOffset(pe.spanStart), p.originalOffset(pe.spanEnd)
+	// {{ else }}
+	pe.spanStart, pe.spanEnd = p.errorSpan(pos)
+	// {{ end }} ==template==
+	// {{ end }} ==template==
+	// ==template== {{ if .BidiAware }}
+	// ==template== {{ if .Preprocessors }}
+	pe.dir = p.bidiDirection(ppos.offset)
+	// {{ else }}
+	pe.dir = p.bidiDirection(pos.offset)
+	// {{ end }} ==template==
+	// {{ end }} ==template==
+	return pe
+}
+
+// ==template== {{ if .Preprocessors }}
+
+// preprocess runs the preprocessing stages on the input, and composes
+// their position maps.
+func (p *parser) preprocess() {
+	p.input = p.data
+	for _, stage := range p.preprocessors {
+		data, m := stage(p.data)
+		p.data = data
+		if m == nil {
+			continue
+		}
+		if prev := p.posMap; prev != nil {
+			p.posMap = func(off int) int { return prev(m(off)) }
+		} else {
+			p.posMap = m
+		}
+	}
+}
+
+// originalOffset returns the offset in the input before preprocessing of
+// the offset off of the preprocessed input.
+func (p *parser) originalOffset(off int) int {
+	if p.posMap == nil {
+		return off
+	}
+	off = p.posMap(off)
+	if off < 0 {
+		return 0
+	}
+	if off > len(p.input) {
+		return len(p.input)
+	}
+	return off
+}
+
+// originalPosition returns the position in the input before preprocessing
+// of the position pos of the preprocessed input.
+func (p *parser) originalPosition(pos position) position {
+	if p.posMap == nil {
+		return pos
+	}
+	orig := position{line: 1, offset: p.originalOffset(pos.offset)}
+	// the position of a rune is the one of the parser that just read it
+	rn, _ := utf8.DecodeRune(p.input[orig.offset:])
+	for _, r := range string(p.input[:orig.offset]) + string(rn) {
+		orig.col++
+		if r == '\n' {
+			orig.line++
+			orig.col = 0
+		}
+	}
+	return orig
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ErrorSpans }}
+
+// errorSpan returns the span of an error at pos: the input matched since
+// pos, or the character at pos if none was.
+func (p *parser) errorSpan(pos position) (int, int) {
+	if p.pt.offset > pos.offset {
+		return pos.offset, p.pt.offset
+	}
+	_, n := utf8.DecodeRune(p.data[pos.offset:])
+	return pos.offset, pos.offset + n
+}
+
+// {{ end }} ==template==
+
+func (p *parser) failAt(fail bool, pos position, want string) {
+	// ==template== {{ if .LookbehindExpr }}
+	if p.inLookbehind {
+		// the positions in the preceding input are not tracked
+		return
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .ScanLimits }}
+	if p.scanLimit > 0 && pos.offset >= p.scanLimit {
+		// the input past the scan limit is not seen
+		return
+	}
+	// {{ end }} ==template==
+	// process fail if parsing fails and not inverted or parsing succeeds and invert is set
+	if fail == p.maxFailInvertExpected {
+		if pos.offset < p.maxFailPos.offset {
+			return
+		}
+
+		if pos.offset > p.maxFailPos.offset {
+			p.maxFailPos = pos
+			p.maxFailExpected = p.maxFailExpected[:0]
+			// ==template== {{ if .ErrorSpans }}
+			p.maxFailSpanStart = -1
+			// {{ end }} ==template==
+			// ==template== {{ if .FollowSets }}
+			p.maxFailDepths = p.maxFailDepths[:0]
+			p.maxFailRules = p.maxFailRules[:0]
+			// {{ end }} ==template==
+			// ==template== {{ if .RuleErrorMessages }}
+			p.maxFailMessage = ""
+			// {{ end }} ==template==
+		}
+
+		if p.maxFailInvertExpected {
+			want = "!" + want
+		}
+		// ==template== {{ if .ExplainFailures }}
+		p.recordFailureRules(pos, len(p.maxFailExpected) == 0)
+		// {{ end }} ==template==
+		p.maxFailExpected = append(p.maxFailExpected, want)
+		// ==template== {{ if .FollowSets }}
+		p.maxFailDepths = append(p.maxFailDepths, len(p.rstack))
+		// {{ end }} ==template==
+	}
+}
+
+// ==template== {{ if .ExplainFailures }}
+// recordFailureRules records the rules being matched by an attempt that
+// failed at the farthest failure pos, which is the first one at pos if
+// first is set, and the last rule matched by the innermost rule before
+// pos if only white space follows it.
+func (p *parser) recordFailureRules(pos position, first bool) {
+	if first {
+		p.maxFailStack = append(p.maxFailStack[:0], p.rstack...)
+		p.maxFailAfter = p.maxFailAfter[:0]
+	}
+	n := 0
+	for n < len(p.maxFailStack) && n < len(p.rstack) && p.maxFailStack[n] == p.rstack[n] {
+		n++
+	}
+	p.maxFailStack = p.maxFailStack[:n]
+
+	depth := len(p.rstack)
+	if p.lastMatch != nil && p.lastMatchDepth == depth && p.lastMatchEnd <= pos.offset &&
+		len(bytes.TrimSpace(p.data[p.lastMatchEnd:pos.offset])) == 0 {
+		for len(p.maxFailAfter) <= depth {
+			p.maxFailAfter = append(p.maxFailAfter, nil)
+		}
+		p.maxFailAfter[depth] = p.lastMatch
+	}
+}
+
+// explain sets the explanation of the error pe of the farthest failure,
+// where the tokens of expected were expected.
+func (p *parser) explain(pe *parserError, expected []string) {
+	name := func(rule *rule) string {
+		if rule.displayName != "" {
+			return rule.displayName
+		}
+		return rule.name
+	}
+
+	var buf strings.Builder
+	if n := len(p.maxFailStack); n > 0 {
+		buf.WriteString("while parsing " + name(p.maxFailStack[n-1]))
+		for i := n - 2; i >= 0; i-- {
+			buf.WriteString(" in " + name(p.maxFailStack[i]))
+		}
+		buf.WriteString(", ")
+	}
+	if len(expected) > 0 {
+		buf.WriteString("expected " + listJoin(expected, ", ", "or"))
+	} else {
+		buf.WriteString("no match")
+	}
+	if depth := len(p.maxFailStack); depth < len(p.maxFailAfter) && p.maxFailAfter[depth] != nil {
+		buf.WriteString(" after " + name(p.maxFailAfter[depth]))
+	}
+	fmt.Fprintf(&buf, " at line %d, column %d", pe.pos.line, pe.pos.col)
+	pe.explanation = buf.String()
+}
+
+// {{ end }} ==template==
+
+// read advances the parser to the next rune.
+func (p *parser) read() {
+	p.pt.offset += p.pt.w
+	// ==template== {{ if .StreamWindow }}
+	if p.stream != nil {
+		p.fillStream()
+	}
+	rn, n := utf8.DecodeRune(p.data[p.pt.offset-p.streamBase:])
+	// {{ else if .ASCIIFastPath }}
+	var (
+		rn rune
+		n  int
+	)
+	if p.ascii {
+		// each byte of the input is a rune
+		if p.pt.offset < len(p.data) {
+			rn, n = rune(p.data[p.pt.offset]), 1
+		} else {
+			rn = utf8.RuneError
+		}
+	} else {
+		rn, n = utf8.DecodeRune(p.data[p.pt.offset:])
+	}
+	// {{ else }}
+	rn, n := utf8.DecodeRune(p.data[p.pt.offset:])
+	// {{ end }} ==template==
+	// ==template== {{ if .GraphemeInput }}
+	if p.graphemes {
+		// the previous rune is the current one, if any
+		ext := p.pt.w > 0 && n > 0 && graphemeExtends(p.pt.rn, rn, p.pt.riOpen)
+		p.pt.ext = ext
+		p.pt.riOpen = isRegionalIndicator(rn) && !ext
+		if !ext {
+			p.pt.col++
+		}
+	} else {
+		p.pt.col++
+	}
+	p.pt.rn = rn
+	p.pt.w = n
+	// {{ else }}
+	p.pt.rn = rn
+	p.pt.w = n
+	p.pt.col++
+	// {{ end }} ==template==
+	if rn == '\n' {
+		p.pt.line++
+		p.pt.col = 0
+	}
+
+	// ==template== {{ if .PushParser }}
+	if !utf8.FullRune(p.data[p.pt.offset:]) {
+		// the next chunk of the input of a PushParser may extend the match,
+		// or complete the rune
+		p.atEnd = true
+	}
+	// {{ end }} ==template==
+	if rn == utf8.RuneError && n == 1 { // see utf8.DecodeRune
+		if !p.allowInvalidUTF8 {
+			p.addErr(errInvalidEncoding)
+		}
+	}
+	// ==template== {{ if .ScanLimits }}
+
+	if p.scanLimit > 0 && p.pt.offset >= p.scanLimit && n > 0 {
+		// the input is seen as ending at the scan limit
+		p.pt.rn, p.pt.w = utf8.RuneError, 0
+		p.scanLimitHit = true
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .ProgressCallback }}
+
+	if p.onProgress != nil && p.pt.offset >= p.progressNext {
+		p.reportProgress(p.pt.offset)
+	}
+	// {{ end }} ==template==
+}
+
+// ==template== {{ if .GraphemeInput }}
+
+// graphemeExtends returns true if the rune rn, read after the rune prev,
+// extends the grapheme cluster of prev. It follows the rules of the
+// extended grapheme clusters of Unicode (UAX #29), except for the prepend
+// characters, and the extended pictographic characters are approximated
+// by the other symbols and the emoji blocks. riOpen is set if prev is a
+// regional indicator that starts a pair.
+func graphemeExtends(prev, rn rune, riOpen bool) bool {
+	switch {
+	case prev == '\r' && rn == '\n':
+		return true
+	case isGraphemeControl(prev) || isGraphemeControl(rn):
+		return false
+	case hangulExtends(hangulType(prev), hangulType(rn)):
+		return true
+	case rn == '\u200d' || isGraphemeExtend(rn) || unicode.Is(unicode.Mc, rn):
+		return true
+	case prev == '\u200d':
+		// emoji zero-width joiner sequence
+		return unicode.Is(unicode.So, rn) || rn >= 0x1f000 && rn <= 0x1faff
+	case riOpen:
+		return isRegionalIndicator(rn)
+	}
+	return false
+}
+
+// isGraphemeControl returns true if rn is a control character, which is a
+// grapheme cluster on its own.
+func isGraphemeControl(rn rune) bool {
+	if rn == '\u200d' || unicode.Is(unicode.Other_Grapheme_Extend, rn) {
+		return false
+	}
+	return unicode.In(rn, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp)
+}
+
+// isGraphemeExtend returns true if rn extends the grapheme cluster that
+// precedes it, e.g. a combining mark or an emoji modifier.
+func isGraphemeExtend(rn rune) bool {
+	if rn >= 0x1f3fb && rn <= 0x1f3ff {
+		// emoji skin tone modifiers
+		return true
+	}
+	return unicode.In(rn, unicode.Mn, unicode.Me, unicode.Other_Grapheme_Extend)
+}
+
+// isRegionalIndicator returns true if rn is a regional indicator symbol,
+// the pairs of which are flags.
+func isRegionalIndicator(rn rune) bool {
+	return rn >= 0x1f1e6 && rn <= 0x1f1ff
+}
+
+// hangulType returns the type of the Hangul jamo or syllable rn: 'L', 'V'
+// or 'T' for the leading, vowel and trailing jamos, 'v' and 't' for the LV
+// and LVT syllables, and 0 if rn is not Hangul.
+func hangulType(rn rune) byte {
+	switch {
+	case rn >= 0x1100 && rn <= 0x115f, rn >= 0xa960 && rn <= 0xa97c:
+		return 'L'
+	case rn >= 0x1160 && rn <= 0x11a7, rn >= 0xd7b0 && rn <= 0xd7c6:
+		return 'V'
+	case rn >= 0x11a8 && rn <= 0x11ff, rn >= 0xd7cb && rn <= 0xd7fb:
+		return 'T'
+	case rn >= 0xac00 && rn <= 0xd7a3:
+		if (rn-0xac00)%28 == 0 {
+			return 'v'
+		}
+		return 't'
+	}
+	return 0
+}
+
+// hangulExtends returns true if a Hangul character of type next extends
+// the syllable of a Hangul character of type prev, see hangulType.
+func hangulExtends(prev, next byte) bool {
+	switch prev {
+	case 'L':
+		return next == 'L' || next == 'V' || next == 'v' || next == 't'
+	case 'V', 'v':
+		return next == 'V' || next == 'T'
+	case 'T', 't':
+		return next == 'T'
+	}
+	return false
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ASCIIFastPath }}
+
+// isASCII returns true if b is only made of ASCII characters.
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiValues holds the values of the matches of a single ASCII character,
+// so that the character matchers do not allocate them for an ASCII-only
+// input. Those values are shared and must not be modified.
+var asciiValues = func() (vals [utf8.RuneSelf]any) {
+	for i := range vals {
+		vals[i] = []byte{byte(i)}
+	}
+	return vals
+}()
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ProgressCallback }}
+
+// reportProgress calls the progress function with offset.
+func (p *parser) reportProgress(offset int) {
+	p.progressLast = offset
+	p.progressNext = offset + p.progressEvery
+	p.onProgress(offset, len(p.data))
+}
+
+// {{ end }} ==template==
+
+// restore parser position to the savepoint pt.
+func (p *parser) restore(pt savepoint) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("restore"))
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .BacktrackHooks }}
+	for p.pt.undo != pt.undo {
+		hook := p.pt.undo
+		p.pt.undo = hook.prev
+		hook.fn()
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleBoundaryPositions }}
+	// rules that matched no input are dropped even if the offset is the same
+	p.pt.rulePos = pt.rulePos
+	// {{ end }} ==template==
+	// ==template== {{ if .FuzzyMatch }}
+	if pt.offset == p.pt.offset && pt.fuzzy == p.pt.fuzzy {
+		return
+	}
+	// {{ else if .ErrorRepair }}
+	if pt.offset == p.pt.offset && pt.repair == p.pt.repair {
+		return
+	}
+	// {{ else }}
+	if pt.offset == p.pt.offset {
+		return
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .StreamWindow }}
+	if pt.offset < p.streamBase {
+		panic(errStreamWindow)
+	}
+	// {{ end }} ==template==
+	p.pt = pt
+}
+
+// ==template== {{ if .StreamWindow }}
+
+// fillStream reads the stream until data has a full rune at the current
+// position or the stream ends. When the buffer of data is full, the input
+// more than streamWindow bytes behind the current position is evicted.
+func (p *parser) fillStream() {
+	for !p.streamEOF && len(p.data)-(p.pt.offset-p.streamBase) < utf8.UTFMax {
+		if len(p.data) == cap(p.data) {
+			// the buffer is not reused, the values returned by the code
+			// blocks may reference the matched input, e.g. c.text.
+			keep := p.pt.offset - streamWindow
+			buf := make([]byte, len(p.data)-(keep-p.streamBase), cap(p.data))
+			copy(buf, p.data[keep-p.streamBase:])
+			p.data, p.streamBase = buf, keep
+			// ==template== {{ if or .LeftRecursion (not .Optimize) }}
+			for off := range p.memo {
+				if off < keep {
+					delete(p.memo, off)
+				}
+			}
+			// {{ end }} ==template==
+		}
+		n, err := p.stream.Read(p.data[len(p.data):cap(p.data)])
+		p.data = p.data[:len(p.data)+n]
+		if err == io.EOF {
+			p.streamEOF = true
+		} else if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if or .GlobalState (not .Optimize) }}
+
+// Cloner is implemented by any value that has a Clone method, which returns a
+// copy of the value. This is mainly used for types which are not passed by
+// value (e.g map, slice, chan) or structs that contain such types.
+//
+// This is used in conjunction with the global state feature to create proper
+// copies of the state to allow the parser to properly restore the state in
+// the case of backtracking.
+type Cloner interface {
+	Clone() any
+}
+
+var statePool = &sync.Pool{
+	New: func() any { return make(storeDict) },
+}
+
+func (sd storeDict) Discard() {
+	for k := range sd {
+		delete(sd, k)
+	}
+	statePool.Put(sd)
+}
+
+// clone and return parser current state.
+func (p *parser) cloneState() storeDict {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("cloneState"))
+	}
+	// {{ end }} ==template==
+
+	state := statePool.Get().(storeDict)
+	for k, v := range p.cur.state {
+		if c, ok := v.(Cloner); ok {
+			state[k] = c.Clone()
+		} else {
+			state[k] = v
+		}
+	}
+	return state
+}
+
+// restore parser current state to the state storeDict.
+// every restoreState should applied only one time for every cloned state
+func (p *parser) restoreState(state storeDict) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("restoreState"))
+	}
+	// {{ end }} ==template==
+	p.cur.state.Discard()
+	p.cur.state = state
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .BacktrackHooks }}
+// addUndoHooks adds the functions registered by the action that just ran
+// to the list of the current savepoint.
+func (p *parser) addUndoHooks() {
+	for _, fn := range p.cur.backtrack {
+		p.pt.undo = &undoHook{prev: p.pt.undo, fn: fn}
+	}
+	p.cur.backtrack = nil
+}
+
+// {{ end }} ==template==
+
+// get the slice of bytes from the savepoint start to the current position.
+func (p *parser) sliceFrom(start savepoint) []byte {
+	// ==template== {{ if .StreamWindow }}
+	if start.offset < p.streamBase {
+		// the start of the match was evicted, e.g. for the rule that
+		// matches the whole stream.
+		return nil
+	}
+	return p.data[start.position.offset-p.streamBase : p.pt.position.offset-p.streamBase]
+	// {{ else }}
+	return p.data[start.position.offset:p.pt.position.offset]
+	// {{ end }} ==template==
+}
+
+// ==template== {{ if or .LeftRecursion (not .Optimize) }}
+func (p *parser) getMemoized(node any) (resultTuple, bool) {
+	// ==template== {{ if .ExpvarMetrics }}
+	p.memoLookups++
+	// {{ end }} ==template==
+	// ==template== {{ if .DenseMemo }}
+	res, ok := p.lookupMemoized(node)
+	// {{ else }}
+	if len(p.memo) == 0 {
+		return resultTuple{}, false
+	}
+	m := p.memo[p.pt.offset]
+	if len(m) == 0 {
+		return resultTuple{}, false
+	}
+	res, ok := m[node]
+	// {{ end }} ==template==
+	// ==template== {{ if .ExpvarMetrics }}
+	if ok {
+		p.memoHits++
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .MemoReuseTrace }}
+	if ok && p.memoReuses != nil {
+		p.addMemoReuse(node)
+	}
+	// {{ end }} ==template==
+	return res, ok
+}
+
+// ==template== {{ if .MemoReuseTrace }}
+
+// MemoReuse records a memoized result that the parser reused instead of
+// matching its expression again.
+type MemoReuse struct {
+	// Rule is the name of the rule whose result was reused, or of the rule
+	// of the expression whose result was reused.
+	Rule string
+	// Line, Col and Offset are the position where the result was reused.
+	Line, Col, Offset int
+}
+
+// addMemoReuse records the reuse of the memoized result of node at the
+// current position.
+func (p *parser) addMemoReuse(node any) {
+	// ==template== {{ if or .MemoKeyHook .ModeAwareMemo }}
+	if key, ok := node.(memoKey); ok {
+		node = key.node
+	}
+	// {{ end }} ==template==
+	reuse := MemoReuse{Line: p.pt.line, Col: p.pt.col, Offset: p.pt.offset}
+	if r, ok := node.(*rule); ok {
+		reuse.Rule = r.name
+	} else if len(p.rstack) > 0 {
+		reuse.Rule = p.rstack[len(p.rstack)-1].name
+	}
+	*p.memoReuses = append(*p.memoReuses, reuse)
+}
+
+// {{ end }} ==template==
+
+func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
+	// ==template== {{ if .BacktrackHooks }}
+	if tuple.end.undo != pt.undo {
+		// the actions registered functions, they must run again
+		return
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .DenseMemo }}
+	if rule, ok := node.(*rule); ok && p.denseMemo != nil {
+		row := p.denseMemo[rule.index]
+		if row == nil {
+			row = make([]denseMemoEntry, len(p.data)+1)
+			p.denseMemo[rule.index] = row
+		}
+		if pt.offset < len(row) {
+			row[pt.offset] = denseMemoEntry{res: tuple, set: true}
+			return
+		}
+	}
+	// {{ end }} ==template==
+	if p.memo == nil {
+		p.memo = make(map[int]map[any]resultTuple)
+	}
+	m := p.memo[pt.offset]
+	if m == nil {
+		m = make(map[any]resultTuple)
+		p.memo[pt.offset] = m
+	}
+	m[node] = tuple
+}
+
+// ==template== {{ if .DenseMemo }}
+
+// denseMemoMaxEntries is the maximum number of entries of the dense
+// memoization table, the number of rules by the length of the input plus
+// one. The results are memoized in the map for larger inputs.
+const denseMemoMaxEntries = 1 << 18
+
+// denseMemoEntry is an entry of the dense memoization table, set is false
+// if no result is memoized for its rule at its offset.
+type denseMemoEntry struct {
+	res resultTuple
+	set bool
+}
+
+// initDenseMemo allocates the dense memoization table if it is not too
+// large for the input. Its rows are allocated when a result of their rule
+// is memoized.
+func (p *parser) initDenseMemo() {
+	if len(p.rules)*(len(p.data)+1) <= denseMemoMaxEntries {
+		p.denseMemo = make([][]denseMemoEntry, len(p.rules))
+	}
+}
+
+// lookupMemoized returns the result of node memoized at the current
+// position, in the dense table if it is the result of a rule and the table
+// covers the position, in the map otherwise.
+func (p *parser) lookupMemoized(node any) (resultTuple, bool) {
+	if rule, ok := node.(*rule); ok && p.denseMemo != nil {
+		row := p.denseMemo[rule.index]
+		if p.pt.offset < len(row) {
+			return row[p.pt.offset].res, row[p.pt.offset].set
+		}
+		if row == nil {
+			// no result of the rule is memoized
+			return resultTuple{}, false
+		}
+	}
+	if len(p.memo) == 0 {
+		return resultTuple{}, false
+	}
+	m := p.memo[p.pt.offset]
+	if len(m) == 0 {
+		return resultTuple{}, false
+	}
+	res, ok := m[node]
+	return res, ok
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if or .MemoKeyHook .ModeAwareMemo }}
+
+// memoKey is the key of a memoized result when a discriminator or the
+// mode generation is set.
+type memoKey struct {
+	node any
+	// ==template== {{ if .MemoKeyHook }}
+	discriminator any
+	// {{ end }} ==template==
+	// ==template== {{ if .ModeAwareMemo }}
+	gen int
+	// {{ end }} ==template==
+}
+
+// memoKey returns the key of the memoized result of node at the current
+// position, which includes the discriminator if a MemoKey function is set.
+// ==template== {{ if .ModeAwareMemo }}
+// It also includes the current mode generation, so that the results
+// memoized in another mode are ignored.
+// {{ end }} ==template==
+func (p *parser) memoKey(node any) any {
+	// ==template== {{ if .ModeAwareMemo }}
+	key := memoKey{node: node, gen: p.modeGen()}
+	// ==template== {{ if .MemoKeyHook }}
+	if p.memoKeyFunc != nil {
+		key.discriminator = p.memoKeyFunc(&p.cur)
+	}
+	// {{ end }} ==template==
+	return key
+	// {{ else }}
+	if p.memoKeyFunc == nil {
+		return node
+	}
+	return memoKey{node: node, discriminator: p.memoKeyFunc(&p.cur)}
+	// {{ end }} ==template==
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ModeAwareMemo }}
+
+// modeGenKey is the key of the mode generation in the state.
+const modeGenKey = "pigeon.modeGen"
+
+// modeGen returns the mode generation of the current state. It is stored
+// in the state so that it is restored with it when the parser backtracks.
+func (p *parser) modeGen() int {
+	gen, _ := p.cur.state[modeGenKey].(int)
+	return gen
+}
+
+// bumpModeGen sets a new mode generation in the current state, after a
+// state code block ran and may have changed the mode of the parser.
+func (p *parser) bumpModeGen() {
+	p.modeGenCnt++
+	p.cur.state[modeGenKey] = p.modeGenCnt
+}
+
+// {{ end }} ==template==
+
+// {{ end }} ==template==
+
+// ==template== {{ if .PrefixDispatch }}
+// prefixRule is an entrypoint selected by the prefix of the input.
+type prefixRule struct {
+	prefix string
+	rule   string
+}
+
+// dispatchRule returns the rule of the first entry of prefixRules whose
+// prefix starts the input, or def if there is none.
+func (p *parser) dispatchRule(def *rule) *rule {
+	for _, pr := range prefixRules {
+		if !bytes.HasPrefix(p.data, []byte(pr.prefix)) {
+			continue
+		}
+		for _, r := range p.rules {
+			if r.name == pr.rule {
+				return r
+			}
+		}
+	}
+	return def
+}
+
+// {{ end }} ==template==
+
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			// ==template== {{ if .PrefixDispatch }}
+			if r == p.rules[0] {
+				return p.dispatchRule(r)
+			}
+			// {{ end }} ==template==
+			return r
+		}
+	}
+	return nil
+}
+
+// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
+func (p *parser) parse(g *grammar) (val any, err error) {
+	// ==template== {{ if .ExpvarMetrics }}
+	defer p.recordMetrics(time.Now(), &err)
+
+	// {{ end }} ==template==
+	// ==template== {{ if .ChromeTrace }}
+	if p.chromeTrace != nil {
+		p.traceStart = time.Now()
+		defer p.writeChromeTrace(&err)
+	}
+
+	// {{ end }} ==template==
+	// ==template== {{ if .OTelSpans }}
+	if span := p.startSpan(); span != nil {
+		defer p.endSpan(span, &err)
+	}
+
+	// {{ end }} ==template==
+	// ==template== {{ if .MemoReuseTrace }}
+	if p.memoReuses != nil {
+		*p.memoReuses = nil
+	}
+
+	// {{ end }} ==template==
+	if len(g.rules) == 0 {
+		p.addErr(errNoRule)
+		return nil, p.errs.err()
+	}
+
+	// TODO : not super critical but this could be generated
+	p.rules = g.rules
+	// ==template== {{ if and .RuleBoundaryPositions (not .Optimize) }}
+	if p.rulePositions != nil {
+		// a memoized result restores the positions of the rules matched on
+		// the path that memoized it
+		p.memoize = false
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .DenseMemo }}
+	p.initDenseMemo()
+	// {{ end }} ==template==
+
+	if p.recover {
+		// panic can be used in action code to stop parsing immediately
+		// and return the panic as an error.
+		defer func() {
+			if e := recover(); e != nil {
+				// ==template== {{ if not .Optimize }}
+				if p.debug {
+					defer p.out(p.in("panic handler"))
+				}
+				// {{ end }} ==template==
+				val = nil
+				switch e := e.(type) {
+				case error:
+					p.addErr(e)
+				default:
+					p.addErr(fmt.Errorf("%v", e))
+				}
+				err = p.errs.err()
+			}
+		}()
+	}
+
+	// ==template== {{ if .LineRecovery }}
+	startRule := p.startRule
+	if startRule == nil {
+		startRule = p.entrypointRule()
+	}
+	// {{ else }}
+	startRule := p.entrypointRule()
+	// {{ end }} ==template==
+	if startRule == nil {
+		p.addErr(errInvalidEntrypoint)
+		return nil, p.errs.err()
+	}
+	// ==template== {{ if eq .EmptyInput "error" }}
+	if len(p.data) == 0 {
+		return nil, ErrEmptyInput
+	}
+	// {{ else if eq .EmptyInput "nil" }}
+	if len(p.data) == 0 {
+		return nil, nil
+	}
+	// {{ end }} ==template==
+
+	// ==template== {{ if .OptimizeRules }}
+	p.ruleDebug, p.ruleMemoize = p.debug, p.memoize
+	// {{ end }} ==template==
+	// ==template== {{ if .ASCIIFastPath }}
+	p.ascii = isASCII(p.data)
+	// {{ end }} ==template==
+	p.read() // advance to first rune
+	var ok bool
+	val, ok = p.parseRuleWrap(startRule)
+	// ==template== {{ if .REPLMode }}
+	p.matched = ok
+	// {{ end }} ==template==
+	if !ok {
+		// ==template== {{ if .TypedThrows }}
+		if len(*p.errs) == 0 && p.maxThrowErr != nil {
+			p.errs.add(p.maxThrowErr)
+		}
+		// {{ end }} ==template==
+		if len(*p.errs) == 0 {
+			// If parsing fails, but no errors have been recorded, the expected values
+			// for the farthest parser position are returned as error.
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			// ==template== {{ if .RuleErrorMessages }}
+			if p.maxFailMessage != "" {
+				msg = p.maxFailMessage
+			}
+			// {{ end }} ==template==
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
+			// ==template== {{ if .ExplainFailures }}
+			p.explain((*p.errs)[len(*p.errs)-1].(*parserError), expected)
+			// {{ end }} ==template==
+			// ==template== {{ if .ErrorSpans }}
+			if p.maxFailSpanStart >= 0 {
+				pe := (*p.errs)[len(*p.errs)-1].(*parserError)
+				// ==template== {{ if .Preprocessors }}
+				pe.spanStart = p.originalOffset(p.maxFailSpanStart)
+				// {{ else }}
+				pe.spanStart = p.maxFailSpanStart
+				// {{ end }} ==template==
+			}
+			// {{ end }} ==template==
+		}
+
+		return nil, p.errs.err()
+	}
+	// ==template== {{ if .ProgressCallback }}
+	if p.onProgress != nil && p.progressLast < len(p.data) {
+		p.reportProgress(len(p.data))
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleBoundaryPositions }}
+	if p.rulePositions != nil {
+		*p.rulePositions = p.rulePositionList()
+	}
+	// {{ end }} ==template==
+	return val, p.errs.err()
+}
+
+// ==template== {{ if .RuleBoundaryPositions }}
+// RulePosition records the input matched by a rule in the result of the
+// parsing.
+type RulePosition struct {
+	// Rule is the name of the rule.
+	Rule string
+	// Line, Col and Offset are the position of the start of the match.
+	Line, Col, Offset int
+	// EndLine, EndCol and EndOffset are the position just after the end of
+	// the match, they are the start position if the rule matched no input.
+	EndLine, EndCol, EndOffset int
+}
+
+// rulePosNode is a node of the immutable list of the rules matched up to a
+// savepoint, so that restoring a savepoint drops the rules matched after.
+type rulePosNode struct {
+	prev       *rulePosNode
+	rule       *rule
+	start, end position
+}
+
+// addRulePosition records that rule matched the input from start to the
+// current position.
+func (p *parser) addRulePosition(rule *rule, start position) {
+	p.pt.rulePos = &rulePosNode{prev: p.pt.rulePos, rule: rule, start: start, end: p.pt.position}
+}
+
+// rulePositionList returns the positions of the rules matched up to the
+// current position, in the order their matches end.
+func (p *parser) rulePositionList() []RulePosition {
+	var list []RulePosition
+	for n := p.pt.rulePos; n != nil; n = n.prev {
+		start, end := n.start, n.end
+		// ==template== {{ if .Preprocessors }}
+		start, end = p.originalPosition(start), p.originalPosition(end)
+		// {{ end }} ==template==
+		list = append(list, RulePosition{
+			Rule: n.rule.name,
+			Line: start.line, Col: start.col, Offset: start.offset,
+			EndLine: end.line, EndCol: end.col, EndOffset: end.offset,
+		})
+	}
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .FollowSets }}
+// addMaxFailRule records rule, which failed at the farthest failure
+// position without matching any input. Only the outermost such rules are
+// kept, their FIRST sets cover the tokens expected by the inner ones.
+func (p *parser) addMaxFailRule(rule *rule) {
+	depth := len(p.rstack)
+	if len(p.maxFailRules) > 0 && depth > p.maxFailDepth {
+		return
+	}
+	if len(p.maxFailRules) > 0 && depth < p.maxFailDepth {
+		p.maxFailRules = p.maxFailRules[:0]
+	}
+	p.maxFailDepth = depth
+	p.maxFailRules = append(p.maxFailRules, rule)
+}
+
+// followSetsExpected returns the tokens expected at the farthest failure
+// position: the FIRST sets of the outermost rules that failed there, and
+// their FOLLOW sets if they can match the empty string, along with the
+// tokens tried there outside of those rules.
+func (p *parser) followSetsExpected() map[string]struct{} {
+	expected := make(map[string]struct{})
+	for i, v := range p.maxFailExpected {
+		if p.maxFailDepths[i] <= p.maxFailDepth {
+			expected[v] = struct{}{}
+		}
+	}
+	for _, rule := range p.maxFailRules {
+		for _, v := range rule.first {
+			expected[v] = struct{}{}
+		}
+		if rule.nullable {
+			for _, v := range rule.follow {
+				expected[v] = struct{}{}
+			}
+		}
+	}
+	return expected
+}
+
+// {{ end }} ==template==
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	// ==template== {{ if .FollowSets }}
+	if len(p.maxFailRules) > 0 {
+		maxFailExpectedMap = p.followSetsExpected()
+	}
+	// {{ end }} ==template==
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
+func listJoin(list []string, sep string, lastSep string) string {
+	switch len(list) {
+	case 0:
+		return ""
+	case 1:
+		return list[0]
+	default:
+		return strings.Join(list[:len(list)-1], sep) + " " + lastSep + " " + list[len(list)-1]
+	}
+}
+
+// ==template== {{ if .LeftRecursion }}
+
+func (p *parser) parseRuleRecursiveLeader(rule *rule) (any, bool) {
+	// ==template== {{ if or .MemoKeyHook .ModeAwareMemo }}
+	key := p.memoKey(rule)
+	// {{ else if .RuntimeParams }}
+	var key any = rule
+	// {{ end }} ==template==
+	// ==template== {{ if .RuntimeParams }}
+	if p.cur.params != nil {
+		// the results depend on the arguments of the rules being matched,
+		// the seed is only grown and reused for the same arguments
+		key = paramMemoKey{key: key, params: p.cur.params}
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if or .MemoKeyHook .ModeAwareMemo .RuntimeParams }}
+	result, ok := p.getMemoized(key)
+	// {{ else }}
+	result, ok := p.getMemoized(rule)
+	// {{ end }} ==template==
+	if ok {
+		p.restore(result.end)
+		return result.v, result.b
+	}
+
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("recursive " + rule.name))
+	}
+	// {{ end }} ==template==
+
+	var (
+		depth      = 0
+		startMark  = p.pt
+		lastResult = resultTuple{nil, false, startMark}
+		lastErrors = *p.errs
+	)
+
+	for {
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		lastState := p.cloneState()
+		// {{ end }} ==template==
+		// ==template== {{ if or .MemoKeyHook .ModeAwareMemo .RuntimeParams }}
+		p.setMemoized(startMark, key, lastResult)
+		// {{ else }}
+		p.setMemoized(startMark, rule, lastResult)
+		// {{ end }} ==template==
+		// ==template== {{ if .LosslessCST }}
+		cstChildren := p.cstSave()
+		// {{ end }} ==template==
+		// ==template== {{ if .CompactAST }}
+		compactChildren := p.compactSave()
+		// {{ end }} ==template==
+		val, ok := p.parseRule(rule)
+		endMark := p.pt
+		// ==template== {{ if not .Optimize }}
+		if p.debug {
+			p.printIndent("RECURSIVE", fmt.Sprintf(
+				"Rule %s depth %d: %t -> %s",
+				rule.name, depth, ok, string(p.sliceFrom(startMark))))
+		}
+		// {{ end }} ==template==
+		if (!ok) || (endMark.offset <= lastResult.end.offset && depth != 0) {
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			p.restoreState(lastState)
+			// {{ end }} ==template==
+			// ==template== {{ if .LosslessCST }}
+			p.cstRestore(cstChildren)
+			// {{ end }} ==template==
+			// ==template== {{ if .CompactAST }}
+			p.compactRestore(compactChildren)
+			// {{ end }} ==template==
+			*p.errs = lastErrors
+			break
+		}
+		lastResult = resultTuple{val, ok, endMark}
+		lastErrors = *p.errs
+		p.restore(startMark)
+		depth++
+	}
+
+	p.restore(lastResult.end)
+	// ==template== {{ if or .MemoKeyHook .ModeAwareMemo .RuntimeParams }}
+	p.setMemoized(startMark, key, lastResult)
+	// {{ else }}
+	p.setMemoized(startMark, rule, lastResult)
+	// {{ end }} ==template==
+	return lastResult.v, lastResult.b
+}
+
+func (p *parser) parseRuleRecursiveNoLeader(rule *rule) (any, bool) {
+	return p.parseRule(rule)
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if not .Optimize }}
+func (p *parser) parseRuleMemoize(rule *rule) (any, bool) {
+	// ==template== {{ if .RuntimeParams }}
+	if p.cur.params != nil {
+		// the results depend on the arguments of the rules being matched
+		return p.parseRule(rule)
+	}
+
+	// {{ end }} ==template==
+	// ==template== {{ if or .MemoKeyHook .ModeAwareMemo }}
+	key := p.memoKey(rule)
+	res, ok := p.getMemoized(key)
+	// {{ else }}
+	res, ok := p.getMemoized(rule)
+	// {{ end }} ==template==
+	if ok {
+		// ==template== {{ if .BacktrackHooks }}
+		// the result registered no function, the functions registered
+		// since it was memoized are kept
+		res.end.undo = p.pt.undo
+		// {{ end }} ==template==
+		p.restore(res.end)
+		return res.v, res.b
+	}
+
+	startMark := p.pt
 	val, ok := p.parseRule(rule)
+	// ==template== {{ if .RuleErrorMessages }}
+	if !ok && p.maxFailInvertExpected {
+		// a failure in a not predicate records no expected tokens nor error
+		// message, the rule is parsed again where it may record them
+		return val, ok
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if or .MemoKeyHook .ModeAwareMemo }}
+	p.setMemoized(startMark, key, resultTuple{val, ok, p.pt})
+	// {{ else }}
 	p.setMemoized(startMark, rule, resultTuple{val, ok, p.pt})
+	// {{ end }} ==template==
+
+	return val, ok
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .RuleTiming }}
+
+// timeRule starts timing the call of rule and returns the function that
+// stops it. Only the outermost call of a rule adds to its time.
+func (p *parser) timeRule(rule *rule) func() {
+	if p.ruleCalls == nil {
+		p.ruleCalls = make(map[string]int)
+	}
+	p.ruleCalls[rule.name]++
+	start := time.Now()
+	return func() {
+		p.ruleCalls[rule.name]--
+		if p.ruleCalls[rule.name] == 0 {
+			p.ruleTimes[rule.name] += time.Since(start)
+		}
+	}
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ChromeTrace }}
+
+// traceRule records the begin event of the call of rule and returns the
+// function that records its end event.
+func (p *parser) traceRule(rule *rule) func() {
+	p.traceEvent(rule.name, "B")
+	return func() {
+		p.traceEvent(rule.name, "E")
+	}
+}
+
+// traceEvent records the event of phase ph of rule at the current time and
+// offset.
+func (p *parser) traceEvent(rule, ph string) {
+	p.traceEvents = append(p.traceEvents, traceEvent{
+		rule:   rule,
+		ph:     ph,
+		ts:     time.Since(p.traceStart),
+		offset: p.pt.offset,
+	})
+}
+
+// writeChromeTrace writes the events recorded to the writer of the
+// ChromeTrace option, with their timestamps in microseconds. It sets *err
+// to the error of the writing if it is nil.
+func (p *parser) writeChromeTrace(err *error) {
+	events := make([]map[string]any, 0, len(p.traceEvents))
+	for _, e := range p.traceEvents {
+		events = append(events, map[string]any{
+			"name": e.rule,
+			"ph":   e.ph,
+			"ts":   float64(e.ts.Nanoseconds()) / 1e3,
+			"pid":  1,
+			"tid":  1,
+			"args": map[string]int{"offset": e.offset},
+		})
+	}
+	p.traceEvents = nil
+	b, e := json.Marshal(map[string]any{"traceEvents": events})
+	if e == nil {
+		_, e = p.chromeTrace.Write(b)
+	}
+	if e != nil && *err == nil {
+		*err = e
+	}
+}
+
+// {{ end }} ==template==
+
+func (p *parser) parseRuleWrap(rule *rule) (any, bool) {
+	// ==template== {{ if .OptimizeRules }}
+	// optimized rules run without debug output nor memoization, the
+	// other rules with the modes of the parser, and the modes of the
+	// calling rule are restored on return.
+	prevDebug, prevMemoize := p.debug, p.memoize
+	p.debug, p.memoize = p.ruleDebug, p.ruleMemoize
+	if rule.optimized {
+		p.debug, p.memoize = false, false
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseRule " + rule.name))
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleTiming }}
+	if p.ruleTimes != nil {
+		defer p.timeRule(rule)()
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .ChromeTrace }}
+	if p.chromeTrace != nil {
+		defer p.traceRule(rule)()
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .StructuredTrace }}
+	if p.tracer != nil {
+		p.tracer.Enter(rule.name, p.pt.line, p.pt.col, p.pt.offset)
+	}
+	// {{ end }} ==template==
+	var (
+		val any
+		ok  bool
+		// ==template== {{ if not .Optimize }}
+		startMark = p.pt
+		// {{ end }} ==template==
+	)
+
+	// ==template== {{ if and .LeftRecursion (not .Optimize) }}
+	if p.memoize || rule.leftRecursive {
+		if rule.leader {
+			val, ok = p.parseRuleRecursiveLeader(rule)
+		} else if p.memoize && !rule.leftRecursive {
+			val, ok = p.parseRuleMemoize(rule)
+		} else {
+			val, ok = p.parseRuleRecursiveNoLeader(rule)
+		}
+	} else {
+		val, ok = p.parseRule(rule)
+	}
+	// {{ else if not .Optimize }}
+	if p.memoize {
+		val, ok = p.parseRuleMemoize(rule)
+	} else {
+		val, ok = p.parseRule(rule)
+	}
+	// {{ else if .LeftRecursion }}
+	if rule.leftRecursive {
+		if rule.leader {
+			val, ok = p.parseRuleRecursiveLeader(rule)
+		} else {
+			val, ok = p.parseRuleRecursiveNoLeader(rule)
+		}
+	} else {
+		val, ok = p.parseRule(rule)
+	}
+	// {{ else }}
+	val, ok = p.parseRule(rule)
+	// {{ end }} ==template==
+
+	// ==template== {{ if not .Optimize }}
+	if ok && p.debug {
+		p.printIndent("MATCH", string(p.sliceFrom(startMark)))
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .StructuredTrace }}
+	if p.tracer != nil {
+		p.tracer.Exit(rule.name, ok)
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .OptimizeRules }}
+	p.debug, p.memoize = prevDebug, prevMemoize
+	// {{ end }} ==template==
+	return val, ok
+}
+
+func (p *parser) parseRule(rule *rule) (any, bool) {
+	// ==template== {{ if .RuleErrorMessages }}
+	failOffset, failLen := p.maxFailPos.offset, len(p.maxFailExpected)
+	// {{ end }} ==template==
+	// ==template== {{ if .FollowSets }}
+	start := p.pt.offset
+	// {{ end }} ==template==
+	// ==template== {{ if .LosslessCST }}
+	if p.cst != nil {
+		p.cst = append(p.cst, &CSTNode{Rule: rule.name, Offset: p.pt.offset})
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .CompactAST }}
+	if p.compactStack != nil {
+		p.compactEnter()
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .ErrorSpans }}
+	spanStart := p.pt.offset
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleAssertions }}
+	startPos := p.pt.position
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleBoundaryPositions }}
+	ruleStart := p.pt.position
+	// {{ end }} ==template==
+	// ==template== {{ if .ExplainFailures }}
+	matchStart := p.pt
+	// {{ end }} ==template==
+	p.rstack = append(p.rstack, rule)
+	// ==template== {{ if .ReportMaxDepth }}
+	if p.reached != nil && len(p.rstack) > p.reached.Rules {
+		p.reached.Rules = len(p.rstack)
+	}
+	// {{ end }} ==template==
+	p.pushV()
+	// ==template== {{ if .LazyRuleInit }}
+	val, ok := p.parseExprWrap(rule.expression())
+	// {{ else }}
+	val, ok := p.parseExprWrap(rule.expr)
+	// {{ end }} ==template==
+	p.popV()
+	// ==template== {{ if .RuleAssertions }}
+	// the rule is still on the stack, so the error is prefixed with its name
+	if ok && rule.assert != nil && !rule.assert(val) {
+		p.addErrAt(fmt.Errorf("assertion %s failed for value %#v", rule.assertion, val), startPos, []string{})
+	}
+	// {{ end }} ==template==
+	p.rstack = p.rstack[:len(p.rstack)-1]
+	// ==template== {{ if .RuleBoundaryPositions }}
+	if ok && p.rulePositions != nil {
+		p.addRulePosition(rule, ruleStart)
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .ExplainFailures }}
+	if ok && len(bytes.TrimSpace(p.sliceFrom(matchStart))) > 0 {
+		p.lastMatch, p.lastMatchEnd, p.lastMatchDepth = rule, p.pt.offset, len(p.rstack)
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .ErrorSpans }}
+	if !ok && p.maxFailSpanStart < 0 && spanStart < p.maxFailPos.offset {
+		p.maxFailSpanStart = spanStart
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .LosslessCST }}
+	if p.cst != nil {
+		p.cstExit(ok)
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .CompactAST }}
+	if p.compactStack != nil {
+		p.compactExit(rule, ok)
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .FollowSets }}
+	if !ok && start == p.maxFailPos.offset && !p.maxFailInvertExpected {
+		p.addMaxFailRule(rule)
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .RuleErrorMessages }}
+	if !ok && rule.errorMessage != "" && p.maxFailMessage == "" && !p.maxFailInvertExpected {
+		// the innermost rule with a custom message that contributed to the
+		// farthest failure provides the error message.
+		if p.maxFailPos.offset > failOffset || len(p.maxFailExpected) > failLen {
+			p.maxFailMessage = rule.errorMessage
+		}
+	}
+	// {{ end }} ==template==
+	return val, ok
+}
+
+// ==template== {{ if .LosslessCST }}
+
+// cstExit pops the node of the rule being matched from the concrete syntax
+// tree stack, and adds it to its parent if the rule matched some input.
+func (p *parser) cstExit(ok bool) {
+	n := p.cst[len(p.cst)-1]
+	p.cst = p.cst[:len(p.cst)-1]
+	end := p.pt.offset
+	// only the node of the entrypoint rule is kept if it matched no input
+	if !ok || (end == n.Offset && len(p.cst) > 1) {
+		return
+	}
+	n.Text = p.data[n.Offset:end]
+	n.orig = n.Text
+
+	// drop the children matched by a backtracked expression after the end of
+	// the node, and cover the input consumed without a child with leaves.
+	children := make([]*CSTNode, 0, len(n.Children))
+	cur := n.Offset
+	for _, c := range n.Children {
+		cend := c.Offset + len(c.Text)
+		if c.Offset < cur || cend > end {
+			continue
+		}
+		if c.Offset > cur {
+			children = append(children, &CSTNode{Offset: cur, Text: p.data[cur:c.Offset]})
+		}
+		children = append(children, c)
+		cur = cend
+	}
+	if cur < end && len(children) > 0 {
+		children = append(children, &CSTNode{Offset: cur, Text: p.data[cur:end]})
+	}
+	n.Children = children
+	p.cstAdd(n)
+}
+
+// cstLeaf adds a leaf for the input consumed since start to the node of
+// the rule being matched, if expr is a matcher.
+func (p *parser) cstLeaf(expr any, start int) {
+	switch expr.(type) {
+	case *anyMatcher, *charClassMatcher, *litMatcher:
+	default:
+		return
+	}
+	// ==template== {{ if .LookbehindExpr }}
+	if p.inLookbehind {
+		return
+	}
+	// {{ end }} ==template==
+	if p.pt.offset > start {
+		p.cstAdd(&CSTNode{Offset: start, Text: p.data[start:p.pt.offset]})
+	}
+}
+
+// cstSave returns a copy of the children of the node of the rule being
+// matched, to be restored by cstRestore.
+func (p *parser) cstSave() []*CSTNode {
+	if p.cst == nil {
+		return nil
+	}
+	return append([]*CSTNode(nil), p.cst[len(p.cst)-1].Children...)
+}
+
+// cstRestore restores the children of the node of the rule being matched
+// saved by cstSave.
+func (p *parser) cstRestore(children []*CSTNode) {
+	if p.cst != nil {
+		p.cst[len(p.cst)-1].Children = children
+	}
+}
+
+// cstAdd adds n to the children of the node of the rule being matched. The
+// children that end after the start of n were matched by backtracked
+// expressions and are dropped.
+func (p *parser) cstAdd(n *CSTNode) {
+	parent := p.cst[len(p.cst)-1]
+	i := len(parent.Children)
+	for i > 0 && parent.Children[i-1].Offset+len(parent.Children[i-1].Text) > n.Offset {
+		i--
+	}
+	parent.Children = append(parent.Children[:i], n)
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .CompactAST }}
+
+// compactEnter pushes the rule being matched on the compact syntax tree
+// stack. The children of the parent rule that end after the current
+// position were matched by backtracked expressions and are dropped.
+func (p *parser) compactEnter() {
+	p.compactTrim(p.compactStack[len(p.compactStack)-1].mark, p.pt.offset)
+	p.compactStack = append(p.compactStack, compactRule{mark: len(p.compact), start: p.pt.offset})
+}
+
+// compactExit pops the rule being matched from the compact syntax tree
+// stack, and adds its node after its children if it matched some input.
+// The input consumed without a child is covered with leaves.
+func (p *parser) compactExit(rule *rule, ok bool) {
+	r := p.compactStack[len(p.compactStack)-1]
+	p.compactStack = p.compactStack[:len(p.compactStack)-1]
+	end := p.pt.offset
+	// only the node of the entrypoint rule is kept if it matched no input
+	if !ok || (end == r.start && len(p.compactStack) > 1) {
+		p.compact = p.compact[:r.mark]
+		return
+	}
+
+	p.compactTrim(r.mark, end)
+	if n := len(p.compact); n > r.mark && p.compact[n-1].End < end {
+		p.compact = append(p.compact, CompactNode{Start: p.compact[n-1].End, End: end, first: n})
+	}
+	parent := p.compactStack[len(p.compactStack)-1]
+	if prev := p.compactPrevEnd(parent); prev < r.start {
+		// the leaf of the input before the rule precedes its subtree
+		p.compact = append(p.compact, CompactNode{})
+		copy(p.compact[r.mark+1:], p.compact[r.mark:])
+		for i := r.mark + 1; i < len(p.compact); i++ {
+			p.compact[i].first++
+		}
+		p.compact[r.mark] = CompactNode{Start: prev, End: r.start, first: r.mark}
+		r.mark++
+	}
+	p.compact = append(p.compact, CompactNode{Rule: rule.name, Start: r.start, End: end, first: r.mark})
+}
+
+// compactLeaf adds a leaf for the input consumed since start to the
+// children of the rule being matched, if expr is a matcher.
+func (p *parser) compactLeaf(expr any, start int) {
+	switch expr.(type) {
+	case *anyMatcher, *charClassMatcher, *litMatcher:
+	default:
+		return
+	}
+	// ==template== {{ if .LookbehindExpr }}
+	if p.inLookbehind {
+		return
+	}
+	// {{ end }} ==template==
+	if p.pt.offset == start {
+		return
+	}
+	r := p.compactStack[len(p.compactStack)-1]
+	p.compactTrim(r.mark, start)
+	if prev := p.compactPrevEnd(r); prev < start {
+		p.compact = append(p.compact, CompactNode{Start: prev, End: start, first: len(p.compact)})
+	}
+	p.compact = append(p.compact, CompactNode{Start: start, End: p.pt.offset, first: len(p.compact)})
+}
+
+// compactTrim drops the children of a rule, whose nodes start at mark, that
+// end after offset.
+func (p *parser) compactTrim(mark, offset int) {
+	for n := len(p.compact); n > mark && p.compact[n-1].End > offset; n = len(p.compact) {
+		p.compact = p.compact[:p.compact[n-1].first]
+	}
+}
+
+// compactPrevEnd returns the end of the last child of the rule r, or its
+// start if it has none.
+func (p *parser) compactPrevEnd(r compactRule) int {
+	if n := len(p.compact); n > r.mark {
+		return p.compact[n-1].End
+	}
+	return r.start
+}
+
+// compactSave returns a copy of the children of the rule being matched, to
+// be restored by compactRestore.
+func (p *parser) compactSave() []CompactNode {
+	if p.compactStack == nil {
+		return nil
+	}
+	return append([]CompactNode(nil), p.compact[p.compactStack[len(p.compactStack)-1].mark:]...)
+}
+
+// compactRestore restores the children of the rule being matched saved by
+// compactSave.
+func (p *parser) compactRestore(children []CompactNode) {
+	if p.compactStack != nil {
+		p.compact = append(p.compact[:p.compactStack[len(p.compactStack)-1].mark], children...)
+	}
+}
+
+// {{ end }} ==template==
+
+func (p *parser) parseExprWrap(expr any) (any, bool) {
+	// ==template== {{ if .BacktrackHooks }}
+	// only the results of the rules are memoized: the memoized results of
+	// the expressions of an action that must run again to register its
+	// functions would not set its labels.
+	// {{ end }} ==template==
+	// ==template== {{ if not (or .Optimize .BacktrackHooks) }}
+	var pt savepoint
+	// ==template== {{ if or .MemoKeyHook .ModeAwareMemo }}
+	var key any
+	// {{ end }} ==template==
+
+	// ==template== {{ if .LeftRecursion }}
+	isLeftRecursion := p.rstack[len(p.rstack)-1].leftRecursive
+	// {{ end }} ==template==
+	// ==template== {{ if .RuntimeParams }}
+	// as in parseRuleMemoize, the results depend on the arguments of the
+	// rules being matched
+	// {{ end }} ==template==
+	// ==template== {{ if and .LeftRecursion .RuntimeParams }}
+	if p.memoize && !isLeftRecursion && p.cur.params == nil {
+	// {{ else if .LeftRecursion }}
+	if p.memoize && !isLeftRecursion {
+	// {{ else if .RuntimeParams }}
+	if p.memoize && p.cur.params == nil {
+	// {{ else }}
+	if p.memoize {
+	// {{ end }} ==template==
+		// ==template== {{ if or .MemoKeyHook .ModeAwareMemo }}
+		key = p.memoKey(expr)
+		res, ok := p.getMemoized(key)
+		// {{ else }}
+		res, ok := p.getMemoized(expr)
+		// {{ end }} ==template==
+		if ok {
+			p.restore(res.end)
+			return res.v, res.b
+		}
+		pt = p.pt
+	}
+
+	// {{ end }} ==template==
+	// ==template== {{ if or .LosslessCST .CompactAST }}
+	start := p.pt.offset
+	// {{ end }} ==template==
+	val, ok := p.parseExpr(expr)
+	// ==template== {{ if .LosslessCST }}
+	if ok && p.cst != nil {
+		p.cstLeaf(expr, start)
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .CompactAST }}
+	if ok && p.compactStack != nil {
+		p.compactLeaf(expr, start)
+	}
+	// {{ end }} ==template==
+
+	// ==template== {{ if not (or .Optimize .BacktrackHooks) }}
+	// ==template== {{ if .RuleErrorMessages }}
+	if !ok && p.maxFailInvertExpected {
+		// see parseRuleMemoize
+		return val, ok
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if and .LeftRecursion .RuntimeParams }}
+	if p.memoize && !isLeftRecursion && p.cur.params == nil {
+	// {{ else if .LeftRecursion }}
+	if p.memoize && !isLeftRecursion {
+	// {{ else if .RuntimeParams }}
+	if p.memoize && p.cur.params == nil {
+	// {{ else }}
+	if p.memoize {
+	// {{ end }} ==template==
+		// ==template== {{ if or .MemoKeyHook .ModeAwareMemo }}
+		p.setMemoized(pt, key, resultTuple{val, ok, p.pt})
+		// {{ else }}
+		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt})
+		// {{ end }} ==template==
+	}
+	// {{ end }} ==template==
+	return val, ok
+}
+
+// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
+func (p *parser) parseExpr(expr any) (any, bool) {
+	p.ExprCnt++
+	if p.ExprCnt > p.maxExprCnt {
+		panic(errMaxExprCnt)
+	}
+
+	// ==template== {{ if .MatcherInterface }}
+	if m, ok := expr.(matcher); ok {
+		return m.match(p)
+	}
+	panic(fmt.Sprintf("unknown expression type %T", expr))
+	// {{ else }}
+	var val any
+	var ok bool
+	switch expr := expr.(type) {
+	case *actionExpr:
+		val, ok = p.parseActionExpr(expr)
+	case *andCodeExpr:
+		val, ok = p.parseAndCodeExpr(expr)
+	case *andExpr:
+		val, ok = p.parseAndExpr(expr)
+	case *anyMatcher:
+		val, ok = p.parseAnyMatcher(expr)
+	// ==template== {{ if .AssertionExpr }}
+	case *assertionExpr:
+		val, ok = p.parseAssertionExpr(expr)
+	// {{ end }} ==template==
+	// ==template== {{ if .BalancedExpr }}
+	case *balancedExpr:
+		val, ok = p.parseBalancedExpr(expr)
+	// {{ end }} ==template==
+	case *charClassMatcher:
+		val, ok = p.parseCharClassMatcher(expr)
+	// ==template== {{ if .RunCharClass }}
+	case *charClassRunExpr:
+		val, ok = p.parseCharClassRunExpr(expr)
+	// {{ end }} ==template==
+	case *choiceExpr:
+		val, ok = p.parseChoiceExpr(expr)
+	// ==template== {{ if .GuardOptimization }}
+	case *guardExpr:
+		val, ok = p.parseGuardExpr(expr)
+	// {{ end }} ==template==
+	case *labeledExpr:
+		val, ok = p.parseLabeledExpr(expr)
+	// ==template== {{ if .LineStart }}
+	case *lineStartExpr:
+		val, ok = p.parseLineStartExpr(expr)
+	// {{ end }} ==template==
+	case *litMatcher:
+		val, ok = p.parseLitMatcher(expr)
+	// ==template== {{ if .LookbehindExpr }}
+	case *lookbehindExpr:
+		val, ok = p.parseLookbehindExpr(expr)
+	// {{ end }} ==template==
+	case *notCodeExpr:
+		val, ok = p.parseNotCodeExpr(expr)
+	case *notExpr:
+		val, ok = p.parseNotExpr(expr)
+	case *oneOrMoreExpr:
+		val, ok = p.parseOneOrMoreExpr(expr)
+	case *recoveryExpr:
+		val, ok = p.parseRecoveryExpr(expr)
+	case *ruleRefExpr:
+		val, ok = p.parseRuleRefExpr(expr)
+	// ==template== {{ if .ScanLimits }}
+	case *scanLimitExpr:
+		val, ok = p.parseScanLimitExpr(expr)
+	// {{ end }} ==template==
+	case *seqExpr:
+		val, ok = p.parseSeqExpr(expr)
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	case *stateCodeExpr:
+		val, ok = p.parseStateCodeExpr(expr)
+	// {{ end }} ==template==
+	// ==template== {{ if .TableDriven }}
+	case tableExpr:
+		val, ok = p.execInstr(expr)
+	// {{ end }} ==template==
+	case *throwExpr:
+		val, ok = p.parseThrowExpr(expr)
+	// ==template== {{ if .UntilExpr }}
+	case *untilExpr:
+		val, ok = p.parseUntilExpr(expr)
+	// {{ end }} ==template==
+	case *zeroOrMoreExpr:
+		val, ok = p.parseZeroOrMoreExpr(expr)
+	case *zeroOrOneExpr:
+		val, ok = p.parseZeroOrOneExpr(expr)
+	default:
+		panic(fmt.Sprintf("unknown expression type %T", expr))
+	}
+	return val, ok
+	// {{ end }} ==template==
+}
+
+// ==template== {{ if .AutoMapResults }}
+
+// autoMark is a labeled expression of an alternative whose result is built
+// by the parser, with the path to its value in the value of the
+// alternative: the index of the value in a sequence, -1 for each value of a
+// repetition and -2 for the value of an optional expression, if it matched.
+type autoMark struct {
+	kind byte
+	path []int
+}
+
+// autoResult returns the result of an alternative built from the values of
+// its marks in v: a map of the values of the "value" labels keyed by the
+// preceding "key" labels, a slice of the values of the "item" labels or the
+// text matched by the "text" label.
+func autoResult(v any, marks []autoMark) (any, error) {
+	var (
+		m     map[string]any
+		items []any
+		text  any
+		key   string
+	)
+	switch marks[0].kind {
+	case 'k', 'v':
+		m = make(map[string]any)
+	case 'i':
+		items = []any{}
+	}
+
+	ids := make([]int, len(marks))
+	for i := range ids {
+		ids[i] = i
+	}
+	autoWalk(v, marks, ids, 0, func(id int, v any) {
+		switch marks[id].kind {
+		case 'i':
+			items = append(items, v)
+		case 'k':
+			key = autoKey(v)
+		case 'v':
+			m[key] = v
+		case 't':
+			text = v
+		}
+	})
+
+	switch {
+	case m != nil:
+		return m, nil
+	case items != nil:
+		return items, nil
+	}
+	return text, nil
+}
+
+// autoWalk calls fn with the values of the marks ids in v, in the order of
+// the input, where v is the value at depth in the path of the marks.
+func autoWalk(v any, marks []autoMark, ids []int, depth int, fn func(int, any)) {
+	path := marks[ids[0]].path
+	if len(path) == depth {
+		// marks are not nested, a single one can be at the end of a path
+		fn(ids[0], v)
+		return
+	}
+
+	vals, _ := v.([]any)
+	switch path[depth] {
+	case -1:
+		for _, v := range vals {
+			autoWalk(v, marks, ids, depth+1, fn)
+		}
+	case -2:
+		if v != nil {
+			autoWalk(v, marks, ids, depth+1, fn)
+		}
+	default:
+		for i, v := range vals {
+			var sub []int
+			for _, id := range ids {
+				if marks[id].path[depth] == i {
+					sub = append(sub, id)
+				}
+			}
+			if len(sub) > 0 {
+				autoWalk(v, marks, sub, depth+1, fn)
+			}
+		}
+	}
+}
+
+// autoKey returns the map key of the value v of a "key" label.
+func autoKey(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .TableDriven }}
+
+// ==template== {{ if .ExplicitStack }}
+
+// execInstr runs the instruction ix of the table of a table-driven parser
+// with the work stack of the parser: the sub-instructions, and the
+// expressions of the rules they reference, are pushed on the stack instead
+// of being run by recursive calls, so that the nesting of the input is
+// only limited by the memory available.
+func (p *parser) execInstr(ix tableExpr) (any, bool) {
+	base := len(p.work)
+	// parseExprWrap memoized and counted ix, as the other expressions
+	next, val, ok := p.startInstr(ix)
+	for {
+		if next != noInstr {
+			next, val, ok = p.enterInstr(next)
+			continue
+		}
+		if len(p.work) == base {
+			return val, ok
+		}
+		next, val, ok = p.resumeInstr(val, ok)
+	}
+}
+
+// enterInstr starts the sub-instruction ix, unless its result is
+// memoized. It is memoized and counted as the expressions run by
+// parseExprWrap.
+func (p *parser) enterInstr(ix tableExpr) (tableExpr, any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.memoize {
+		if res, ok := p.getMemoized(ix); ok {
+			p.restore(res.end)
+			return noInstr, res.v, res.b
+		}
+	}
+	// {{ end }} ==template==
+	p.ExprCnt++
+	if p.ExprCnt > p.maxExprCnt {
+		panic(errMaxExprCnt)
+	}
+	return p.startInstr(ix)
+}
+
+// startInstr starts the instruction ix. It returns the sub-instruction to
+// start if it pushed the frame of ix on the work stack, or noInstr and the
+// result of ix if it completed.
+//
+// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
+func (p *parser) startInstr(ix tableExpr) (tableExpr, any, bool) {
+	in := &grammarTable.instrs[ix]
+	sub := grammarTable.children[in.sub : in.sub+in.n]
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		p.in("execInstr " + in.op.String())
+	}
+	// {{ end }} ==template==
+
+	f := workFrame{ix: ix, mark: len(p.workVals), start: p.pt}
+	switch in.op {
+	case opAction, opSeq:
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		if in.op == opSeq {
+			f.state = p.cloneState()
+		}
+		// {{ end }} ==template==
+
+	case opAndCode, opNotCode:
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		state := p.cloneState()
+		// {{ end }} ==template==
+		// ==template== {{ if .SwitchDispatch }}
+		ok, err := p.runPredicate(in.arg)
+		// {{ else }}
+		ok, err := grammarTable.preds[in.arg](p)
+		// {{ end }} ==template==
+		if err != nil {
+			p.addErr(err)
+		}
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(state)
+		// {{ end }} ==template==
+		return p.endInstr(ix, f.start, nil, ok == (in.op == opAndCode))
+
+	case opAnd, opNot:
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		f.state = p.cloneState()
+		// {{ end }} ==template==
+		p.pushV()
+		if in.op == opNot {
+			p.maxFailInvertExpected = !p.maxFailInvertExpected
+		}
+
+	case opAny:
+		val, ok := p.parseAnyMatcher((*anyMatcher)(&in.pos))
+		return p.endInstr(ix, f.start, val, ok)
+
+	case opCharClass:
+		val, ok := p.parseCharClassMatcher(grammarTable.classes[in.arg])
+		return p.endInstr(ix, f.start, val, ok)
+
+	case opChoice:
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		f.state = p.cloneState()
+		// {{ end }} ==template==
+		p.pushV()
+
+	case opLabeled, opOneOrMore, opZeroOrMore, opZeroOrOne:
+		p.pushV()
+
+	case opLit:
+		val, ok := p.parseLitMatcher(grammarTable.lits[in.arg])
+		return p.endInstr(ix, f.start, val, ok)
+
+	case opRuleRef:
+		if in.arg > len(p.rules)-1 {
+			panic(fmt.Sprintf("%s: invalid rule: out of range", in.pos))
+		}
+		rule := p.rules[in.arg]
+		// ==template== {{ if not .Optimize }}
+		if p.memoize {
+			if res, ok := p.getMemoized(rule); ok {
+				p.restore(res.end)
+				return p.endInstr(ix, f.start, res.v, res.b)
+			}
+		}
+		// {{ end }} ==template==
+		// ==template== {{ if .RuleErrorMessages }}
+		f.failOffset, f.failLen = p.maxFailPos.offset, len(p.maxFailExpected)
+		// {{ end }} ==template==
+		p.rstack = append(p.rstack, rule)
+		// ==template== {{ if .ReportMaxDepth }}
+		if p.reached != nil && len(p.rstack) > p.reached.Rules {
+			p.reached.Rules = len(p.rstack)
+		}
+		// {{ end }} ==template==
+		p.pushV()
+		p.work = append(p.work, f)
+		return rule.expr.(tableExpr), nil, false
+
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	case opStateCode:
+		// ==template== {{ if .SwitchDispatch }}
+		if err := p.runState(in.arg); err != nil {
+		// {{ else }}
+		if err := grammarTable.states[in.arg](p); err != nil {
+		// {{ end }} ==template==
+			p.addErr(err)
+		}
+		return p.endInstr(ix, f.start, nil, true)
+	// {{ end }} ==template==
+
+	default:
+		panic(fmt.Sprintf("%s: invalid instruction %s", in.pos, in.op))
+	}
+	p.work = append(p.work, f)
+	return tableExpr(sub[0]), nil, false
+}
+
+// resumeInstr resumes the instruction on top of the work stack with the
+// result val and ok of its last sub-instruction. It returns the next
+// sub-instruction to start, or noInstr and the result of the instruction
+// if it completed and its frame was popped.
+//
+// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
+func (p *parser) resumeInstr(val any, ok bool) (tableExpr, any, bool) {
+	f := &p.work[len(p.work)-1]
+	in := &grammarTable.instrs[f.ix]
+	sub := grammarTable.children[in.sub : in.sub+in.n]
+	switch in.op {
+	case opAction:
+		// ==template== {{ if .EmitValidate }}
+		if p.validate {
+			val = nil
+			break
+		}
+		// {{ end }} ==template==
+		if ok {
+			p.cur.pos = f.start.position
+			p.cur.text = p.sliceFrom(f.start)
+			// ==template== {{ if .ChoiceIndex }}
+			p.cur.choiceIx = -1
+			if grammarTable.instrs[sub[0]].op == opChoice {
+				p.cur.choiceIx = p.choiceIx
+			}
+			// {{ end }} ==template==
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			state := p.cloneState()
+			// {{ end }} ==template==
+			// ==template== {{ if .SwitchDispatch }}
+			actVal, err := p.runAction(in.arg)
+			// {{ else }}
+			actVal, err := grammarTable.actions[in.arg](p)
+			// {{ end }} ==template==
+			if err != nil {
+				p.addErrAt(err, f.start.position, []string{})
+			}
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			p.restoreState(state)
+			// {{ end }} ==template==
+			val = actVal
+		}
+		// ==template== {{ if not .Optimize }}
+		if ok && p.debug {
+			p.printIndent("MATCH", string(p.sliceFrom(f.start)))
+		}
+		// {{ end }} ==template==
+
+	case opAnd, opNot:
+		not := in.op == opNot
+		if not {
+			p.maxFailInvertExpected = !p.maxFailInvertExpected
+		}
+		p.popV()
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(f.state)
+		// {{ end }} ==template==
+		p.restore(f.start)
+		val, ok = nil, ok != not
+
+	case opChoice:
+		p.popV()
+		if ok {
+			// ==template== {{ if .ChoiceIndex }}
+			p.choiceIx = f.n
+			// {{ end }} ==template==
+			// ==template== {{ if not .Optimize }}
+			p.incChoiceAltCnt(in.pos, f.n)
+			// {{ end }} ==template==
+			break
+		}
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(f.state)
+		// {{ end }} ==template==
+		f.n++
+		if f.n < len(sub) {
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			f.state = p.cloneState()
+			// {{ end }} ==template==
+			p.pushV()
+			return tableExpr(sub[f.n]), nil, false
+		}
+		// ==template== {{ if not .Optimize }}
+		p.incChoiceAltCnt(in.pos, choiceNoMatch)
+		// {{ end }} ==template==
+		val = nil
+
+	case opLabeled:
+		p.popV()
+		// ==template== {{ if .EmitValidate }}
+		if label := grammarTable.labels[in.arg]; ok && label != "" && !p.validate {
+		// {{ else }}
+		if label := grammarTable.labels[in.arg]; ok && label != "" {
+		// {{ end }} ==template==
+			p.vstack[len(p.vstack)-1][label] = val
+		}
+
+	case opOneOrMore, opZeroOrMore:
+		p.popV()
+		if ok {
+			f.n++
+			// ==template== {{ if .EmitValidate }}
+			if !p.validate {
+				p.workVals = append(p.workVals, val)
+			}
+			// {{ else }}
+			p.workVals = append(p.workVals, val)
+			// {{ end }} ==template==
+			p.pushV()
+			return tableExpr(sub[0]), nil, false
+		}
+		if in.op == opOneOrMore && f.n == 0 {
+			// did not match once, no match
+			val = nil
+			break
+		}
+		var vals []any
+		if f.n > 0 {
+			vals = p.popWorkVals(f.mark)
+		}
+		val, ok = vals, true
+
+	case opRuleRef:
+		p.popV()
+		// ==template== {{ if or .RuleAssertions .RuleBoundaryPositions .ExplainFailures .FollowSets .RuleErrorMessages (not .Optimize) }}
+		rule := p.rules[in.arg]
+		// {{ end }} ==template==
+		// ==template== {{ if .RuleAssertions }}
+		// the rule is still on the stack, so the error is prefixed with its name
+		if ok && rule.assert != nil && !rule.assert(val) {
+			p.addErrAt(fmt.Errorf("assertion %s failed for value %#v", rule.assertion, val), f.start.position, []string{})
+		}
+		// {{ end }} ==template==
+		p.rstack = p.rstack[:len(p.rstack)-1]
+		// ==template== {{ if .RuleBoundaryPositions }}
+		if ok && p.rulePositions != nil {
+			p.addRulePosition(rule, f.start.position)
+		}
+		// {{ end }} ==template==
+		// ==template== {{ if .ExplainFailures }}
+		if ok && len(bytes.TrimSpace(p.sliceFrom(f.start))) > 0 {
+			p.lastMatch, p.lastMatchEnd, p.lastMatchDepth = rule, p.pt.offset, len(p.rstack)
+		}
+		// {{ end }} ==template==
+		// ==template== {{ if .ErrorSpans }}
+		if !ok && p.maxFailSpanStart < 0 && f.start.offset < p.maxFailPos.offset {
+			p.maxFailSpanStart = f.start.offset
+		}
+		// {{ end }} ==template==
+		// ==template== {{ if .FollowSets }}
+		if !ok && f.start.offset == p.maxFailPos.offset && !p.maxFailInvertExpected {
+			p.addMaxFailRule(rule)
+		}
+		// {{ end }} ==template==
+		// ==template== {{ if .RuleErrorMessages }}
+		if !ok && rule.errorMessage != "" && p.maxFailMessage == "" && !p.maxFailInvertExpected {
+			if p.maxFailPos.offset > f.failOffset || len(p.maxFailExpected) > f.failLen {
+				p.maxFailMessage = rule.errorMessage
+			}
+		}
+		// {{ end }} ==template==
+		// ==template== {{ if not .Optimize }}
+		// ==template== {{ if .RuleErrorMessages }}
+		// a failure in a not predicate is not memoized, see parseRuleMemoize
+		if p.memoize && (ok || !p.maxFailInvertExpected) {
+		// {{ else }}
+		if p.memoize {
+		// {{ end }} ==template==
+			p.setMemoized(f.start, rule, resultTuple{val, ok, p.pt})
+		}
+		if ok && p.debug {
+			p.printIndent("MATCH", string(p.sliceFrom(f.start)))
+		}
+		// {{ end }} ==template==
+
+	case opSeq:
+		if !ok {
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			p.restoreState(f.state)
+			// {{ end }} ==template==
+			p.restore(f.start)
+			p.truncWorkVals(f.mark)
+			val = nil
+			break
+		}
+		// ==template== {{ if .EmitValidate }}
+		if !p.validate {
+			p.workVals = append(p.workVals, val)
+		}
+		// {{ else }}
+		p.workVals = append(p.workVals, val)
+		// {{ end }} ==template==
+		f.n++
+		if f.n < len(sub) {
+			return tableExpr(sub[f.n]), nil, false
+		}
+		val = p.popWorkVals(f.mark)
+
+	case opZeroOrOne:
+		p.popV()
+		// whether it matched or not, consider it a match
+		ok = true
+	}
+
+	ix, start := f.ix, f.start
+	p.work[len(p.work)-1] = workFrame{}
+	p.work = p.work[:len(p.work)-1]
+	return p.endInstr(ix, start, val, ok)
+}
+
+// endInstr completes the instruction ix that started at start with the
+// result val and ok, and returns noInstr and its result.
+func (p *parser) endInstr(ix tableExpr, start savepoint, val any, ok bool) (tableExpr, any, bool) {
+	// ==template== {{ if not .Optimize }}
+	// ==template== {{ if .RuleErrorMessages }}
+	if p.memoize && (ok || !p.maxFailInvertExpected) {
+	// {{ else }}
+	if p.memoize {
+	// {{ end }} ==template==
+		p.setMemoized(start, ix, resultTuple{val, ok, p.pt})
+	}
+	if p.debug {
+		p.out("execInstr " + grammarTable.instrs[ix].op.String())
+	}
+	// {{ end }} ==template==
+	return noInstr, val, ok
+}
+
+// popWorkVals returns a copy of the values of the work stack after mark,
+// and drops them.
+func (p *parser) popWorkVals(mark int) []any {
+	vals := make([]any, len(p.workVals)-mark)
+	copy(vals, p.workVals[mark:])
+	p.truncWorkVals(mark)
+	return vals
+}
+
+// truncWorkVals drops the values of the work stack after mark.
+func (p *parser) truncWorkVals(mark int) {
+	for i := mark; i < len(p.workVals); i++ {
+		p.workVals[i] = nil
+	}
+	p.workVals = p.workVals[:mark]
+}
+
+// {{ else }}
+// execInstr runs the instruction ix of the table of a table-driven parser.
+// The sub-instructions are run by parseExprWrap, as the sub-expressions of
+// the other expressions, so that they are memoized and counted the same.
+//
+// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
+func (p *parser) execInstr(ix tableExpr) (any, bool) {
+	in := &grammarTable.instrs[ix]
+	sub := grammarTable.children[in.sub : in.sub+in.n]
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("execInstr " + in.op.String()))
+	}
+
+	// {{ end }} ==template==
+	switch in.op {
+	case opAction:
+		start := p.pt
+		val, ok := p.parseExprWrap(tableExpr(sub[0]))
+		// ==template== {{ if .EmitValidate }}
+		if p.validate {
+			return nil, ok
+		}
+		// {{ end }} ==template==
+		if ok {
+			p.cur.pos = start.position
+			p.cur.text = p.sliceFrom(start)
+			// ==template== {{ if .ChoiceIndex }}
+			p.cur.choiceIx = -1
+			if grammarTable.instrs[sub[0]].op == opChoice {
+				p.cur.choiceIx = p.choiceIx
+			}
+			// {{ end }} ==template==
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			state := p.cloneState()
+			// {{ end }} ==template==
+			// ==template== {{ if .SwitchDispatch }}
+			actVal, err := p.runAction(in.arg)
+			// {{ else }}
+			actVal, err := grammarTable.actions[in.arg](p)
+			// {{ end }} ==template==
+			if err != nil {
+				p.addErrAt(err, start.position, []string{})
+			}
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			p.restoreState(state)
+			// {{ end }} ==template==
+			// ==template== {{ if .BacktrackHooks }}
+			p.addUndoHooks()
+			// {{ end }} ==template==
+			val = actVal
+		}
+		// ==template== {{ if not .Optimize }}
+		if ok && p.debug {
+			p.printIndent("MATCH", string(p.sliceFrom(start)))
+		}
+		// {{ end }} ==template==
+		return val, ok
+
+	case opAndCode, opNotCode:
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		state := p.cloneState()
+		// {{ end }} ==template==
+		// ==template== {{ if .SwitchDispatch }}
+		ok, err := p.runPredicate(in.arg)
+		// {{ else }}
+		ok, err := grammarTable.preds[in.arg](p)
+		// {{ end }} ==template==
+		if err != nil {
+			p.addErr(err)
+		}
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(state)
+		// {{ end }} ==template==
+		return nil, ok == (in.op == opAndCode)
+
+	case opAnd, opNot:
+		not := in.op == opNot
+		pt := p.pt
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		state := p.cloneState()
+		// {{ end }} ==template==
+		p.pushV()
+		if not {
+			p.maxFailInvertExpected = !p.maxFailInvertExpected
+		}
+		_, ok := p.parseExprWrap(tableExpr(sub[0]))
+		if not {
+			p.maxFailInvertExpected = !p.maxFailInvertExpected
+		}
+		p.popV()
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(state)
+		// {{ end }} ==template==
+		p.restore(pt)
+		return nil, ok != not
+
+	case opAny:
+		return p.parseAnyMatcher((*anyMatcher)(&in.pos))
+
+	case opCharClass:
+		return p.parseCharClassMatcher(grammarTable.classes[in.arg])
+
+	case opChoice:
+		for altI, alt := range sub {
+			// dummy assignment to prevent compile error if optimized
+			_ = altI
+
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			state := p.cloneState()
+			// {{ end }} ==template==
+			p.pushV()
+			val, ok := p.parseExprWrap(tableExpr(alt))
+			p.popV()
+			if ok {
+				// ==template== {{ if .ChoiceIndex }}
+				p.choiceIx = altI
+				// {{ end }} ==template==
+				// ==template== {{ if not .Optimize }}
+				p.incChoiceAltCnt(in.pos, altI)
+				// {{ end }} ==template==
+				return val, ok
+			}
+			// ==template== {{ if or .GlobalState (not .Optimize) }}
+			p.restoreState(state)
+			// {{ end }} ==template==
+		}
+		// ==template== {{ if not .Optimize }}
+		p.incChoiceAltCnt(in.pos, choiceNoMatch)
+		// {{ end }} ==template==
+		return nil, false
+
+	case opLabeled:
+		p.pushV()
+		val, ok := p.parseExprWrap(tableExpr(sub[0]))
+		p.popV()
+		label := grammarTable.labels[in.arg]
+		// ==template== {{ if .EmitValidate }}
+		if ok && label != "" && !p.validate {
+		// {{ else }}
+		if ok && label != "" {
+		// {{ end }} ==template==
+			p.vstack[len(p.vstack)-1][label] = val
+		}
+		return val, ok
+
+	case opLit:
+		return p.parseLitMatcher(grammarTable.lits[in.arg])
+
+	case opOneOrMore, opZeroOrMore:
+		var vals []any
+		matched := false
+		for {
+			p.pushV()
+			val, ok := p.parseExprWrap(tableExpr(sub[0]))
+			p.popV()
+			if !ok {
+				if in.op == opOneOrMore && !matched {
+					// did not match once, no match
+					return nil, false
+				}
+				return vals, true
+			}
+			matched = true
+			// ==template== {{ if .EmitValidate }}
+			if !p.validate {
+				vals = append(vals, val)
+			}
+			// {{ else }}
+			vals = append(vals, val)
+			// {{ end }} ==template==
+		}
+
+	case opRuleRef:
+		if in.arg > len(p.rules)-1 {
+			panic(fmt.Sprintf("%s: invalid rule: out of range", in.pos))
+		}
+		rule := p.rules[in.arg]
+		// ==template== {{ if not .Optimize }}
+		if p.debug {
+			defer p.out(p.in("parseRuleRefExpr " + rule.name))
+		}
+		// {{ end }} ==template==
+		return p.parseRuleWrap(rule)
+
+	case opSeq:
+		// ==template== {{ if .EmitValidate }}
+		var vals []any
+		if !p.validate {
+			vals = make([]any, 0, len(sub))
+		}
+		// {{ else }}
+		vals := make([]any, 0, len(sub))
+		// {{ end }} ==template==
+		pt := p.pt
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		state := p.cloneState()
+		// {{ end }} ==template==
+		for _, ix := range sub {
+			val, ok := p.parseExprWrap(tableExpr(ix))
+			if !ok {
+				// ==template== {{ if or .GlobalState (not .Optimize) }}
+				p.restoreState(state)
+				// {{ end }} ==template==
+				p.restore(pt)
+				return nil, false
+			}
+			// ==template== {{ if .EmitValidate }}
+			if !p.validate {
+				vals = append(vals, val)
+			}
+			// {{ else }}
+			vals = append(vals, val)
+			// {{ end }} ==template==
+		}
+		return vals, true
+
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	case opStateCode:
+		// ==template== {{ if .SwitchDispatch }}
+		if err := p.runState(in.arg); err != nil {
+		// {{ else }}
+		if err := grammarTable.states[in.arg](p); err != nil {
+		// {{ end }} ==template==
+			p.addErr(err)
+		}
+		// ==template== {{ if .ModeAwareMemo }}
+		p.bumpModeGen()
+		// {{ end }} ==template==
+		return nil, true
+	// {{ end }} ==template==
+
+	case opZeroOrOne:
+		p.pushV()
+		val, _ := p.parseExprWrap(tableExpr(sub[0]))
+		p.popV()
+		// whether it matched or not, consider it a match
+		return val, true
+	}
+	panic(fmt.Sprintf("%s: invalid instruction %s", in.pos, in.op))
+}
+
+// {{ end }} ==template==
+// {{ end }} ==template==
+
+func (p *parser) parseActionExpr(act *actionExpr) (any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseActionExpr"))
+	}
+
+	// {{ end }} ==template==
+	start := p.pt
+	val, ok := p.parseExprWrap(act.expr)
+	// ==template== {{ if .EmitValidate }}
+	if p.validate {
+		return nil, ok
+	}
+	// {{ end }} ==template==
+	if ok {
+		p.cur.pos = start.position
+		p.cur.text = p.sliceFrom(start)
+		// ==template== {{ if .LabelSpans }}
+		p.cur.spans = p.sstack[len(p.sstack)-1]
+		// {{ end }} ==template==
+		// ==template== {{ if .ChoiceIndex }}
+		p.cur.choiceIx = -1
+		if _, isChoice := act.expr.(*choiceExpr); isChoice {
+			p.cur.choiceIx = p.choiceIx
+		}
+		// {{ end }} ==template==
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		state := p.cloneState()
+		// {{ end }} ==template==
+		// ==template== {{ if .SwitchDispatch }}
+		actVal, err := p.runAction(act.code)
+		// {{ else }}
+		actVal, err := act.run(p)
+		// {{ end }} ==template==
+		if err != nil {
+			p.addErrAt(err, start.position, []string{})
+		}
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(state)
+		// {{ end }} ==template==
+		// ==template== {{ if .BacktrackHooks }}
+		p.addUndoHooks()
+		// {{ end }} ==template==
 
+		val = actVal
+	}
+	// ==template== {{ if not .Optimize }}
+	if ok && p.debug {
+		p.printIndent("MATCH", string(p.sliceFrom(start)))
+	}
+	// {{ end }} ==template==
 	return val, ok
 }
 
-// {{ end }} ==template==
+func (p *parser) parseAndCodeExpr(and *andCodeExpr) (any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseAndCodeExpr"))
+	}
 
-func (p *parser) parseRuleWrap(rule *rule) (any, bool) {
+	// {{ end }} ==template==
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	state := p.cloneState()
+	// {{ end }} ==template==
+
+	// ==template== {{ if .SwitchDispatch }}
+	ok, err := p.runPredicate(and.code)
+	// {{ else }}
+	ok, err := and.run(p)
+	// {{ end }} ==template==
+	if err != nil {
+		p.addErr(err)
+	}
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	p.restoreState(state)
+	// {{ end }} ==template==
+
+	return nil, ok
+}
+
+func (p *parser) parseAndExpr(and *andExpr) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
-		defer p.out(p.in("parseRule " + rule.name))
+		defer p.out(p.in("parseAndExpr"))
 	}
+
 	// {{ end }} ==template==
-	var (
-		val any
-		ok  bool
-		// ==template== {{ if not .Optimize }}
-		startMark = p.pt
+	pt := p.pt
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	state := p.cloneState()
+	// {{ end }} ==template==
+	p.pushV()
+	_, ok := p.parseExprWrap(and.expr)
+	p.popV()
+	// ==template== {{ if or .GlobalState (not .Optimize) }}
+	p.restoreState(state)
+	// {{ end }} ==template==
+	p.restore(pt)
+
+	return nil, ok
+}
+
+func (p *parser) parseAnyMatcher(any *anyMatcher) (any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseAnyMatcher"))
+	}
+
+	// {{ end }} ==template==
+	if p.pt.rn == utf8.RuneError && p.pt.w == 0 {
+		// EOF - see utf8.DecodeRune
+		p.failAt(false, p.pt.position, ".")
+		return nil, false
+	}
+	start := p.pt
+	p.read()
+	// ==template== {{ if .GraphemeInput }}
+	for p.pt.ext {
+		// the rest of the grapheme cluster
+		p.read()
+	}
+	// {{ end }} ==template==
+	p.failAt(true, start.position, ".")
+	// ==template== {{ if .ASCIIFastPath }}
+	if p.ascii && p.pt.offset == start.offset+1 {
+		return asciiValues[start.rn], true
+	}
+	// {{ end }} ==template==
+	return p.sliceFrom(start), true
+}
+
+// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
+func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseCharClassMatcher"))
+	}
+
+	// {{ end }} ==template==
+	cur := p.pt.rn
+	start := p.pt
+
+	// ==template== {{ if .ASCIIFastPath }}
+	if p.ascii && cur < utf8.RuneSelf {
+		// the lookup table is always generated for the ASCII fast path
+		if chr.basicLatinChars[cur] != chr.inverted {
+			p.read()
+			p.failAt(true, start.position, chr.val)
+			return asciiValues[cur], true
+		}
+		p.failAt(false, start.position, chr.val)
+		return nil, false
+	}
+	// {{ end }} ==template==
+	// ==template== {{ if .BasicLatinLookupTable }}
+	if cur < 128 {
+		if chr.basicLatinChars[cur] != chr.inverted {
+			p.read()
+			p.failAt(true, start.position, chr.val)
+			return p.sliceFrom(start), true
+		}
+		p.failAt(false, start.position, chr.val)
+		return nil, false
+	}
+	// {{ end }} ==template==
+
+	// can't match EOF
+	if cur == utf8.RuneError && p.pt.w == 0 { // see utf8.DecodeRune
+		p.failAt(false, start.position, chr.val)
+		return nil, false
+	}
+
+	if chr.ignoreCase {
+		// ==template== {{ if .LocaleCase }}
+		cur = localeCase.ToLower(cur)
+		// {{ else }}
+		cur = unicode.ToLower(cur)
 		// {{ end }} ==template==
-	)
+	}
 
-	// ==template== {{ if and .LeftRecursion (not .Optimize) }}
-	if p.memoize || rule.leftRecursive {
-		if rule.leader {
-			val, ok = p.parseRuleRecursiveLeader(rule)
-		} else if p.memoize && !rule.leftRecursive {
-			val, ok = p.parseRuleMemoize(rule)
-		} else {
-			val, ok = p.parseRuleRecursiveNoLeader(rule)
+	// try to match in the list of available chars
+	for _, rn := range chr.chars {
+		if rn == cur {
+			if chr.inverted {
+				p.failAt(false, start.position, chr.val)
+				return nil, false
+			}
+			p.read()
+			p.failAt(true, start.position, chr.val)
+			return p.sliceFrom(start), true
 		}
-	} else {
-		val, ok = p.parseRule(rule)
 	}
-	// {{ else if not .Optimize }}
-	if p.memoize {
-		val, ok = p.parseRuleMemoize(rule)
-	} else {
-		val, ok = p.parseRule(rule)
+
+	// try to match in the list of ranges
+	for i := 0; i < len(chr.ranges); i += 2 {
+		if cur >= chr.ranges[i] && cur <= chr.ranges[i+1] {
+			if chr.inverted {
+				p.failAt(false, start.position, chr.val)
+				return nil, false
+			}
+			p.read()
+			p.failAt(true, start.position, chr.val)
+			return p.sliceFrom(start), true
+		}
+	}
+
+	// try to match in the list of Unicode classes
+	for _, cl := range chr.classes {
+		if unicode.Is(cl, cur) {
+			if chr.inverted {
+				p.failAt(false, start.position, chr.val)
+				return nil, false
+			}
+			p.read()
+			p.failAt(true, start.position, chr.val)
+			return p.sliceFrom(start), true
+		}
+	}
+
+	if chr.inverted {
+		p.read()
+		p.failAt(true, start.position, chr.val)
+		return p.sliceFrom(start), true
+	}
+	p.failAt(false, start.position, chr.val)
+	return nil, false
+}
+
+// ==template== {{ if .RunCharClass }}
+
+// parseCharClassRunExpr matches a repetition of a character class in a
+// single loop. Its value is the whole matched text, not a slice of values.
+func (p *parser) parseCharClassRunExpr(run *charClassRunExpr) (any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseCharClassRunExpr"))
+	}
+
+	// {{ end }} ==template==
+	chr := run.matcher
+	start := p.pt
+	n := 0
+	for {
+		pt := p.pt
+		if !p.matchCharClass(chr, p.pt.rn) {
+			p.failAt(false, pt.position, chr.val)
+			break
+		}
+		p.read()
+		p.failAt(true, pt.position, chr.val)
+		n++
+	}
+	if n < run.min {
+		return nil, false
+	}
+	return p.sliceFrom(start), true
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if or .RunCharClass .GuardOptimization }}
+
+// matchCharClass returns true if the rune cur at the current position
+// matches the character class chr.
+func (p *parser) matchCharClass(chr *charClassMatcher, cur rune) bool {
+	// ==template== {{ if .BasicLatinLookupTable }}
+	if cur < 128 {
+		return chr.basicLatinChars[cur] != chr.inverted
+	}
+	// {{ end }} ==template==
+
+	// can't match EOF
+	if cur == utf8.RuneError && p.pt.w == 0 { // see utf8.DecodeRune
+		return false
+	}
+
+	if chr.ignoreCase {
+		// ==template== {{ if .LocaleCase }}
+		cur = localeCase.ToLower(cur)
+		// {{ else }}
+		cur = unicode.ToLower(cur)
+		// {{ end }} ==template==
+	}
+	for _, rn := range chr.chars {
+		if rn == cur {
+			return !chr.inverted
+		}
+	}
+	for i := 0; i < len(chr.ranges); i += 2 {
+		if cur >= chr.ranges[i] && cur <= chr.ranges[i+1] {
+			return !chr.inverted
+		}
+	}
+	for _, cl := range chr.classes {
+		if unicode.Is(cl, cur) {
+			return !chr.inverted
+		}
+	}
+	return chr.inverted
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if not .Optimize }}
+
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
+	m := p.ChoiceAltCnt[choiceIdent]
+	if m == nil {
+		m = make(map[string]int)
+		p.ChoiceAltCnt[choiceIdent] = m
+	}
+	// We increment altI by 1, so the keys do not start at 0
+	alt := strconv.Itoa(altI + 1)
+	if altI == choiceNoMatch {
+		alt = p.choiceNoMatch
+	}
+	m[alt]++
+}
+
+// {{ end }} ==template==
+
+func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseChoiceExpr"))
 	}
-	// {{ else if .LeftRecursion }}
-	if rule.leftRecursive {
-		if rule.leader {
-			val, ok = p.parseRuleRecursiveLeader(rule)
-		} else {
-			val, ok = p.parseRuleRecursiveNoLeader(rule)
-		}
-	} else {
-		val, ok = p.parseRule(rule)
+	// {{ end }} ==template==
+	// ==template== {{ if .LongestMatchDiagnostics }}
+	start := p.pt
+	// {{ end }} ==template==
+	// ==template== {{ if .EnumerateParses }}
+	if p.enumerate {
+		return p.parseChoiceForced(ch)
 	}
-	// {{ else }}
-	val, ok = p.parseRule(rule)
 	// {{ end }} ==template==
 
-	// ==template== {{ if not .Optimize }}
-	if ok && p.debug {
-		p.printIndent("MATCH", string(p.sliceFrom(startMark)))
+	for altI, alt := range ch.alternatives {
+		// dummy assignment to prevent compile error if optimized
+		_ = altI
+
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		state := p.cloneState()
+		// {{ end }} ==template==
+
+		p.pushV()
+		val, ok := p.parseExprWrap(alt)
+		p.popV()
+		if ok {
+			// ==template== {{ if .ChoiceIndex }}
+			p.choiceIx = altI
+			// {{ end }} ==template==
+			// ==template== {{ if not .Optimize }}
+			p.incChoiceAltCnt(ch.pos, altI)
+			// {{ end }} ==template==
+			// ==template== {{ if .LongestMatchDiagnostics }}
+			if p.debug {
+				p.diagnoseLongestMatch(ch, altI, start, state)
+			}
+			// {{ end }} ==template==
+			return val, ok
+		}
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(state)
+		// {{ end }} ==template==
 	}
+	// ==template== {{ if not .Optimize }}
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	// {{ end }} ==template==
-	return val, ok
+	return nil, false
 }
 
-func (p *parser) parseRule(rule *rule) (any, bool) {
-	p.rstack = append(p.rstack, rule)
-	p.pushV()
-	val, ok := p.parseExprWrap(rule.expr)
-	p.popV()
-	p.rstack = p.rstack[:len(p.rstack)-1]
-	return val, ok
-}
+// ==template== {{ if .EnumerateParses }}
+
+// parseChoiceForced matches ch for ParseAll: if the choice is one of the
+// first choices evaluated, only the alternative forced for it is tried,
+// none if it is -1, otherwise the first alternative that matches is
+// taken. The alternative taken is recorded in choiceAlts, after the
+// choices evaluated before it on the path of the parse: the choices
+// evaluated by the alternatives that failed before it are dropped, as a
+// parse that forces this alternative does not evaluate them.
+func (p *parser) parseChoiceForced(ch *choiceExpr) (any, bool) {
+	k := len(p.choiceAlts)
+	p.choiceAlts = append(p.choiceAlts, choiceDecision{alt: -1, n: len(ch.alternatives)})
+	forced := k < len(p.forcedAlts)
+	first, last := 0, len(ch.alternatives)
+	if forced {
+		first, last = p.forcedAlts[k], p.forcedAlts[k]+1
+		if first < 0 || first >= len(ch.alternatives) {
+			// no alternative, or one of another choice if the code blocks
+			// changed the choices evaluated since the parse that forced it
+			last = first
+		}
+	}
 
-func (p *parser) parseExprWrap(expr any) (any, bool) {
-	// ==template== {{ if not .Optimize }}
-	var pt savepoint
+	for altI := first; altI < last; altI++ {
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		state := p.cloneState()
+		// {{ end }} ==template==
 
-	// ==template== {{ if .LeftRecursion }}
-	isLeftRecursion := p.rstack[len(p.rstack)-1].leftRecursive
-	if p.memoize && !isLeftRecursion {
-	// {{ else }}
-	if p.memoize {
-	// {{ end }} ==template==
-		res, ok := p.getMemoized(expr)
+		p.pushV()
+		val, ok := p.parseExprWrap(ch.alternatives[altI])
+		p.popV()
 		if ok {
-			p.restore(res.end)
-			return res.v, res.b
+			p.choiceAlts[k].alt = altI
+			// ==template== {{ if .ChoiceIndex }}
+			p.choiceIx = altI
+			// {{ end }} ==template==
+			return val, ok
 		}
-		pt = p.pt
+		if !forced {
+			p.choiceAlts = p.choiceAlts[:k+1]
+		}
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(state)
+		// {{ end }} ==template==
 	}
+	return nil, false
+}
 
-	// {{ end }} ==template==
-	val, ok := p.parseExpr(expr)
+// {{ end }} ==template==
 
-	// ==template== {{ if not .Optimize }}
-	// ==template== {{ if .LeftRecursion }}
-	if p.memoize && !isLeftRecursion {
-	// {{ else }}
-	if p.memoize {
+// ==template== {{ if .LongestMatchDiagnostics }}
+
+// diagnoseLongestMatch tries the alternatives of ch that follow altI, the
+// alternative that matched from start, and logs those that would have
+// matched a strictly longer input. The position, state, errors and
+// statistics of the parser are left as they were after the match of altI.
+// The startState is the state cloned before altI was tried, it is consumed.
+func (p *parser) diagnoseLongestMatch(ch *choiceExpr, altI int, start savepoint, startState storeDict) {
+	end := p.pt
+	endState := p.cur.state
+	errCnt := len(*p.errs)
+	maxFailPos, maxFailInvertExpected := p.maxFailPos, p.maxFailInvertExpected
+	maxFailExpected := append([]string(nil), p.maxFailExpected...)
+	// ==template== {{ if .RuleErrorMessages }}
+	maxFailMessage := p.maxFailMessage
 	// {{ end }} ==template==
-		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt})
-	}
+	// ==template== {{ if .ErrorSpans }}
+	maxFailSpanStart := p.maxFailSpanStart
+	// {{ end }} ==template==
+	// ==template== {{ if .FollowSets }}
+	maxFailDepths := append([]int(nil), p.maxFailDepths...)
+	maxFailRules, maxFailDepth := append([]*rule(nil), p.maxFailRules...), p.maxFailDepth
+	// {{ end }} ==template==
+	stats := p.Stats
+	p.Stats = &Stats{ExprCnt: stats.ExprCnt, ChoiceAltCnt: make(map[string]map[string]int)}
+	p.debug = false
+	// ==template== {{ if .OptimizeRules }}
+	p.ruleDebug = false
 	// {{ end }} ==template==
-	return val, ok
-}
 
-// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
-func (p *parser) parseExpr(expr any) (any, bool) {
-	p.ExprCnt++
-	if p.ExprCnt > p.maxExprCnt {
-		panic(errMaxExprCnt)
+	var longer []string
+	p.cur.state = startState
+	for i := altI + 1; i < len(ch.alternatives); i++ {
+		state := p.cloneState()
+		p.restore(start)
+		p.pushV()
+		_, ok := p.parseExprWrap(ch.alternatives[i])
+		p.popV()
+		if ok && p.pt.offset > end.offset {
+			longer = append(longer, fmt.Sprintf(
+				"longest match: choice %d:%d in rule %s: alternative %d matched %d bytes, alternative %d would match %d bytes",
+				ch.pos.line, ch.pos.col, p.rstack[len(p.rstack)-1].name,
+				altI+1, end.offset-start.offset, i+1, p.pt.offset-start.offset))
+		}
+		p.restoreState(state)
 	}
+	p.cur.state.Discard()
+	p.cur.state = endState
 
-	var val any
-	var ok bool
-	switch expr := expr.(type) {
-	case *actionExpr:
-		val, ok = p.parseActionExpr(expr)
-	case *andCodeExpr:
-		val, ok = p.parseAndCodeExpr(expr)
-	case *andExpr:
-		val, ok = p.parseAndExpr(expr)
-	case *anyMatcher:
-		val, ok = p.parseAnyMatcher(expr)
-	case *charClassMatcher:
-		val, ok = p.parseCharClassMatcher(expr)
-	case *choiceExpr:
-		val, ok = p.parseChoiceExpr(expr)
-	case *labeledExpr:
-		val, ok = p.parseLabeledExpr(expr)
-	case *litMatcher:
-		val, ok = p.parseLitMatcher(expr)
-	case *notCodeExpr:
-		val, ok = p.parseNotCodeExpr(expr)
-	case *notExpr:
-		val, ok = p.parseNotExpr(expr)
-	case *oneOrMoreExpr:
-		val, ok = p.parseOneOrMoreExpr(expr)
-	case *recoveryExpr:
-		val, ok = p.parseRecoveryExpr(expr)
-	case *ruleRefExpr:
-		val, ok = p.parseRuleRefExpr(expr)
-	case *seqExpr:
-		val, ok = p.parseSeqExpr(expr)
-	// ==template== {{ if or .GlobalState (not .Optimize) }}
-	case *stateCodeExpr:
-		val, ok = p.parseStateCodeExpr(expr)
+	p.debug = true
+	// ==template== {{ if .OptimizeRules }}
+	p.ruleDebug = true
 	// {{ end }} ==template==
-	case *throwExpr:
-		val, ok = p.parseThrowExpr(expr)
-	case *zeroOrMoreExpr:
-		val, ok = p.parseZeroOrMoreExpr(expr)
-	case *zeroOrOneExpr:
-		val, ok = p.parseZeroOrOneExpr(expr)
-	default:
-		panic(fmt.Sprintf("unknown expression type %T", expr))
+	p.pt = end
+	*p.errs = (*p.errs)[:errCnt]
+	p.maxFailPos, p.maxFailExpected, p.maxFailInvertExpected = maxFailPos, maxFailExpected, maxFailInvertExpected
+	// ==template== {{ if .RuleErrorMessages }}
+	p.maxFailMessage = maxFailMessage
+	// {{ end }} ==template==
+	// ==template== {{ if .ErrorSpans }}
+	p.maxFailSpanStart = maxFailSpanStart
+	// {{ end }} ==template==
+	// ==template== {{ if .FollowSets }}
+	p.maxFailDepths, p.maxFailRules, p.maxFailDepth = maxFailDepths, maxFailRules, maxFailDepth
+	// {{ end }} ==template==
+	p.Stats = stats
+	for _, msg := range longer {
+		p.printIndent("!", msg)
 	}
-	return val, ok
 }
 
-func (p *parser) parseActionExpr(act *actionExpr) (any, bool) {
+// {{ end }} ==template==
+
+// ==template== {{ if .GuardOptimization }}
+
+// parseGuardExpr is an and or not predicate of a literal or a character
+// class. It peeks at the input instead of matching it, so the position is
+// never changed.
+func (p *parser) parseGuardExpr(guard *guardExpr) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
-		defer p.out(p.in("parseActionExpr"))
+		defer p.out(p.in("parseGuardExpr"))
 	}
 
 	// {{ end }} ==template==
-	start := p.pt
-	val, ok := p.parseExprWrap(act.expr)
-	if ok {
-		p.cur.pos = start.position
-		p.cur.text = p.sliceFrom(start)
-		// ==template== {{ if or .GlobalState (not .Optimize) }}
-		state := p.cloneState()
-		// {{ end }} ==template==
-		actVal, err := act.run(p)
-		if err != nil {
-			p.addErrAt(err, start.position, []string{})
+	var matched bool
+	var want string
+	switch expr := guard.expr.(type) {
+	case *litMatcher:
+		matched, want = true, expr.want
+		off := p.pt.offset
+		for _, rn := range expr.val {
+			cur, n := utf8.DecodeRune(p.data[off:])
+			if expr.ignoreCase {
+				// ==template== {{ if .LocaleCase }}
+				cur = localeCase.ToLower(cur)
+				// {{ else }}
+				cur = unicode.ToLower(cur)
+				// {{ end }} ==template==
+			}
+			if cur != rn {
+				matched = false
+				break
+			}
+			off += n
 		}
-		// ==template== {{ if or .GlobalState (not .Optimize) }}
-		p.restoreState(state)
-		// {{ end }} ==template==
+	case *charClassMatcher:
+		matched, want = p.matchCharClass(expr, p.pt.rn), expr.val
+	}
 
-		val = actVal
+	if guard.not {
+		p.maxFailInvertExpected = !p.maxFailInvertExpected
 	}
-	// ==template== {{ if not .Optimize }}
-	if ok && p.debug {
-		p.printIndent("MATCH", string(p.sliceFrom(start)))
+	p.failAt(matched, p.pt.position, want)
+	if guard.not {
+		p.maxFailInvertExpected = !p.maxFailInvertExpected
+		return nil, !matched
 	}
-	// {{ end }} ==template==
-	return val, ok
+	return nil, matched
 }
 
-func (p *parser) parseAndCodeExpr(and *andCodeExpr) (any, bool) {
+// {{ end }} ==template==
+
+func (p *parser) parseLabeledExpr(lab *labeledExpr) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
-		defer p.out(p.in("parseAndCodeExpr"))
+		defer p.out(p.in("parseLabeledExpr"))
 	}
 
 	// {{ end }} ==template==
-	// ==template== {{ if or .GlobalState (not .Optimize) }}
-	state := p.cloneState()
+	// ==template== {{ if .LabelSpans }}
+	start := p.pt
 	// {{ end }} ==template==
-
-	ok, err := and.run(p)
-	if err != nil {
-		p.addErr(err)
+	// ==template== {{ if .RuntimeParams }}
+	if !lab.argsRef {
+		p.pushV()
 	}
-	// ==template== {{ if or .GlobalState (not .Optimize) }}
-	p.restoreState(state)
+	val, ok := p.parseExprWrap(lab.expr)
+	if !lab.argsRef {
+		p.popV()
+	}
+	// {{ else }}
+	p.pushV()
+	val, ok := p.parseExprWrap(lab.expr)
+	p.popV()
+	// {{ end }} ==template==
+	// ==template== {{ if .EmitValidate }}
+	if ok && lab.label != "" && !p.validate {
+	// {{ else }}
+	if ok && lab.label != "" {
 	// {{ end }} ==template==
+		m := p.vstack[len(p.vstack)-1]
+		m[lab.label] = val
+		// ==template== {{ if .LabelSpans }}
+		sm := p.sstack[len(p.sstack)-1]
+		if sm == nil {
+			sm = make(map[string]labelSpan)
+			p.sstack[len(p.sstack)-1] = sm
+		}
+		sm[lab.label] = labelSpan{start: start.offset, end: p.pt.offset}
+		// {{ end }} ==template==
+	}
+	return val, ok
+}
 
-	return nil, ok
+// ==template== {{ if .BalancedExpr }}
+
+// parseBalancedExpr matches the open delimiter of bal, followed by any
+// input up to the matching close delimiter. The nested pairs of delimiters
+// are skipped, and the value is the input between the outermost ones.
+func (p *parser) parseBalancedExpr(bal *balancedExpr) (any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseBalancedExpr"))
+	}
+
+	// {{ end }} ==template==
+	start := p.pt
+	if !bytes.HasPrefix(p.data[p.pt.offset:], bal.open) {
+		p.failAt(false, start.position, bal.wantOpen)
+		return nil, false
+	}
+	p.skip(len(bal.open))
+
+	inner := p.pt.offset
+	depth := 1
+	for p.pt.offset < len(p.data) {
+		// ==template== {{ if .ScanLimits }}
+		if p.pt.w == 0 {
+			// the scan limit is reached
+			break
+		}
+		// {{ end }} ==template==
+		rest := p.data[p.pt.offset:]
+		switch {
+		case bytes.HasPrefix(rest, bal.close):
+			depth--
+			if depth == 0 {
+				val := p.data[inner:p.pt.offset]
+				p.skip(len(bal.close))
+				p.failAt(true, start.position, bal.wantOpen)
+				return val, true
+			}
+			p.skip(len(bal.close))
+		case bytes.HasPrefix(rest, bal.open):
+			depth++
+			p.skip(len(bal.open))
+		default:
+			p.read()
+		}
+	}
+	// unbalanced, the close delimiter is expected at the end of the input
+	p.failAt(false, p.pt.position, bal.wantClose)
+	p.restore(start)
+	return nil, false
 }
 
-func (p *parser) parseAndExpr(and *andExpr) (any, bool) {
+// {{ end }} ==template==
+
+// ==template== {{ if .LookbehindExpr }}
+
+// parseLookbehindExpr matches if the characters that precede the current
+// position match the expression of lb. It consumes no input. The position
+// of the parser is moved back while the expression is matched, its line
+// and column are then only approximate.
+func (p *parser) parseLookbehindExpr(lb *lookbehindExpr) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
-		defer p.out(p.in("parseAndExpr"))
+		defer p.out(p.in("parseLookbehindExpr"))
 	}
 
 	// {{ end }} ==template==
+	off := p.pt.offset
+	for i := 0; i < lb.runes; i++ {
+		if off == 0 {
+			return nil, false
+		}
+		_, n := utf8.DecodeLastRune(p.data[:off])
+		off -= n
+	}
+
 	pt := p.pt
-	// ==template== {{ if or .GlobalState (not .Optimize) }}
-	state := p.cloneState()
-	// {{ end }} ==template==
+	back := pt
+	back.offset, back.col = off, pt.col-lb.runes
+	back.rn, back.w = utf8.DecodeRune(p.data[off:])
+	p.restore(back)
+	inLookbehind := p.inLookbehind
+	p.inLookbehind = true
 	p.pushV()
-	_, ok := p.parseExprWrap(and.expr)
+	_, ok := p.parseExprWrap(lb.expr)
 	p.popV()
-	// ==template== {{ if or .GlobalState (not .Optimize) }}
-	p.restoreState(state)
-	// {{ end }} ==template==
+	p.inLookbehind = inLookbehind
+	ok = ok && p.pt.offset == pt.offset
 	p.restore(pt)
 
-	return nil, ok
-}
+	return nil, ok
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .ScanLimits }}
 
-func (p *parser) parseAnyMatcher(any *anyMatcher) (any, bool) {
+// parseScanLimitExpr matches the expression of sl, but gives up as soon as
+// the parser reaches the limit of sl past the current position, unless
+// that is the end of the input: the input is seen as ending at the limit
+// while the expression is matched, and the expression fails if it reached
+// it. The results memoized while the expression is matched are only kept
+// if it did not reach the limit.
+func (p *parser) parseScanLimitExpr(sl *scanLimitExpr) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
-		defer p.out(p.in("parseAnyMatcher"))
+		defer p.out(p.in("parseScanLimitExpr"))
 	}
 
 	// {{ end }} ==template==
-	if p.pt.rn == utf8.RuneError && p.pt.w == 0 {
-		// EOF - see utf8.DecodeRune
-		p.failAt(false, p.pt.position, ".")
-		return nil, false
-	}
 	start := p.pt
-	p.read()
-	p.failAt(true, start.position, ".")
-	return p.sliceFrom(start), true
-}
-
-// {{ if .Nolint }} nolint: gocyclo {{else}} ==template== {{ end }}
-func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
-	// ==template== {{ if not .Optimize }}
-	if p.debug {
-		defer p.out(p.in("parseCharClassMatcher"))
+	limit, hit := p.scanLimit, p.scanLimitHit
+	end := start.offset + sl.limit
+	if limit == 0 || end < limit {
+		p.scanLimit = end
 	}
-
+	p.scanLimitHit = false
+	// ==template== {{ if or .LeftRecursion (not .Optimize) }}
+	memo := p.memo
+	p.memo = nil
 	// {{ end }} ==template==
-	cur := p.pt.rn
-	start := p.pt
 
-	// ==template== {{ if .BasicLatinLookupTable }}
-	if cur < 128 {
-		if chr.basicLatinChars[cur] != chr.inverted {
-			p.read()
-			p.failAt(true, start.position, chr.val)
-			return p.sliceFrom(start), true
+	val, ok := p.parseExprWrap(sl.expr)
+
+	reached := p.scanLimitHit
+	// ==template== {{ if or .LeftRecursion (not .Optimize) }}
+	if !reached {
+		for off, m := range p.memo {
+			if memo == nil {
+				memo = make(map[int]map[any]resultTuple)
+			}
+			if memo[off] == nil {
+				memo[off] = m
+				continue
+			}
+			for k, v := range m {
+				memo[off][k] = v
+			}
 		}
-		p.failAt(false, start.position, chr.val)
-		return nil, false
 	}
+	p.memo = memo
 	// {{ end }} ==template==
-
-	// can't match EOF
-	if cur == utf8.RuneError && p.pt.w == 0 { // see utf8.DecodeRune
-		p.failAt(false, start.position, chr.val)
+	// the limit of the enclosing expression is reached too if it is the
+	// one that was reached
+	p.scanLimitHit = hit || (reached && limit > 0 && end >= limit)
+	p.scanLimit = limit
+	if reached {
+		p.restore(start)
 		return nil, false
 	}
+	return val, ok
+}
 
-	if chr.ignoreCase {
-		cur = unicode.ToLower(cur)
+// {{ end }} ==template==
+
+// ==template== {{ if .UntilExpr }}
+
+// parseUntilExpr matches any input up to, but not including, the first
+// match of the terminator of until. A literal terminator is searched in
+// the input, any other terminator is tried at each position.
+func (p *parser) parseUntilExpr(until *untilExpr) (any, bool) {
+	// ==template== {{ if not .Optimize }}
+	if p.debug {
+		defer p.out(p.in("parseUntilExpr"))
 	}
 
-	// try to match in the list of available chars
-	for _, rn := range chr.chars {
-		if rn == cur {
-			if chr.inverted {
-				p.failAt(false, start.position, chr.val)
-				return nil, false
-			}
-			p.read()
-			p.failAt(true, start.position, chr.val)
+	// {{ end }} ==template==
+	start := p.pt
+	if until.lit != nil {
+		if i := bytes.Index(p.data[p.pt.offset:], until.lit); i >= 0 {
+			p.skip(i)
 			return p.sliceFrom(start), true
 		}
-	}
-
-	// try to match in the list of ranges
-	for i := 0; i < len(chr.ranges); i += 2 {
-		if cur >= chr.ranges[i] && cur <= chr.ranges[i+1] {
-			if chr.inverted {
-				p.failAt(false, start.position, chr.val)
-				return nil, false
-			}
-			p.read()
-			p.failAt(true, start.position, chr.val)
+		p.skip(len(p.data) - p.pt.offset)
+		if until.eof {
 			return p.sliceFrom(start), true
 		}
+		// the terminator is expected at the end of the input
+		p.failAt(false, p.pt.position, until.want)
+		p.restore(start)
+		return nil, false
 	}
 
-	// try to match in the list of Unicode classes
-	for _, cl := range chr.classes {
-		if unicode.Is(cl, cur) {
-			if chr.inverted {
-				p.failAt(false, start.position, chr.val)
-				return nil, false
-			}
-			p.read()
-			p.failAt(true, start.position, chr.val)
+	for {
+		pt := p.pt
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		state := p.cloneState()
+		// {{ end }} ==template==
+		p.pushV()
+		_, ok := p.parseExprWrap(until.expr)
+		p.popV()
+		// ==template== {{ if or .GlobalState (not .Optimize) }}
+		p.restoreState(state)
+		// {{ end }} ==template==
+		p.restore(pt)
+		if ok {
 			return p.sliceFrom(start), true
 		}
-	}
-
-	if chr.inverted {
+		// ==template== {{ if .ScanLimits }}
+		if p.pt.w == 0 && p.pt.offset < len(p.data) {
+			// the scan limit is reached
+			p.restore(start)
+			return nil, false
+		}
+		// {{ end }} ==template==
+		if p.pt.offset >= len(p.data) {
+			if until.eof {
+				return p.sliceFrom(start), true
+			}
+			p.restore(start)
+			return nil, false
+		}
 		p.read()
-		p.failAt(true, start.position, chr.val)
-		return p.sliceFrom(start), true
 	}
-	p.failAt(false, start.position, chr.val)
-	return nil, false
 }
 
-// ==template== {{ if not .Optimize }}
+// {{ end }} ==template==
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
-	m := p.ChoiceAltCnt[choiceIdent]
-	if m == nil {
-		m = make(map[string]int)
-		p.ChoiceAltCnt[choiceIdent] = m
-	}
-	// We increment altI by 1, so the keys do not start at 0
-	alt := strconv.Itoa(altI + 1)
-	if altI == choiceNoMatch {
-		alt = p.choiceNoMatch
+// ==template== {{ if or .BalancedExpr .UntilExpr }}
+
+// skip advances the parser by n bytes.
+func (p *parser) skip(n int) {
+	for end := p.pt.offset + n; p.pt.offset < end; {
+		// ==template== {{ if .ScanLimits }}
+		if p.pt.w == 0 {
+			// the scan limit is reached
+			return
+		}
+		// {{ end }} ==template==
+		p.read()
 	}
-	m[alt]++
 }
 
 // {{ end }} ==template==
 
-func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
+// ==template== {{ if .LineStart }}
+
+func (p *parser) parseLineStartExpr(ls *lineStartExpr) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
-		defer p.out(p.in("parseChoiceExpr"))
+		defer p.out(p.in("parseLineStartExpr"))
 	}
-	// {{ end }} ==template==
-
-	for altI, alt := range ch.alternatives {
-		// dummy assignment to prevent compile error if optimized
-		_ = altI
-
-		// ==template== {{ if or .GlobalState (not .Optimize) }}
-		state := p.cloneState()
-		// {{ end }} ==template==
 
-		p.pushV()
-		val, ok := p.parseExprWrap(alt)
-		p.popV()
-		if ok {
-			// ==template== {{ if not .Optimize }}
-			p.incChoiceAltCnt(ch, altI)
-			// {{ end }} ==template==
-			return val, ok
-		}
-		// ==template== {{ if or .GlobalState (not .Optimize) }}
-		p.restoreState(state)
-		// {{ end }} ==template==
-	}
-	// ==template== {{ if not .Optimize }}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
 	// {{ end }} ==template==
+	// at the start of the input or right after a newline
+	if p.pt.offset == 0 || p.data[p.pt.offset-1] == '\n' {
+		p.failAt(true, p.pt.position, "^")
+		return nil, true
+	}
+	p.failAt(false, p.pt.position, "^")
 	return nil, false
 }
 
-func (p *parser) parseLabeledExpr(lab *labeledExpr) (any, bool) {
+// {{ end }} ==template==
+
+// ==template== {{ if .AssertionExpr }}
+
+func (p *parser) parseAssertionExpr(a *assertionExpr) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
-		defer p.out(p.in("parseLabeledExpr"))
+		defer p.out(p.in("parseAssertionExpr"))
 	}
 
 	// {{ end }} ==template==
-	p.pushV()
-	val, ok := p.parseExprWrap(lab.expr)
-	p.popV()
-	if ok && lab.label != "" {
-		m := p.vstack[len(p.vstack)-1]
-		m[lab.label] = val
+	var ok bool
+	switch a.name {
+	case "$":
+		// at the end of the input or right before a newline
+		ok = p.pt.offset == len(p.data) || p.data[p.pt.offset] == '\n'
+	default:
+		// between a word character and another character, or the start or
+		// end of the input
+		before := p.pt.offset > 0 && isWordByte(p.data[p.pt.offset-1])
+		after := p.pt.offset < len(p.data) && isWordByte(p.data[p.pt.offset])
+		ok = before != after
 	}
-	return val, ok
+	p.failAt(ok, p.pt.position, a.name)
+	return nil, ok
 }
 
+// isWordByte returns true if b is an ASCII letter, digit or underscore,
+// the characters of the words of a word boundary assertion.
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// {{ end }} ==template==
+
 func (p *parser) parseLitMatcher(lit *litMatcher) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
@@ -1374,19 +7144,154 @@ func (p *parser) parseLitMatcher(lit *litMatcher) (any, bool) {
 	for _, want := range lit.val {
 		cur := p.pt.rn
 		if lit.ignoreCase {
+			// ==template== {{ if .LocaleCase }}
+			cur = localeCase.ToLower(cur)
+			// {{ else }}
 			cur = unicode.ToLower(cur)
+			// {{ end }} ==template==
 		}
 		if cur != want {
 			p.failAt(false, start.position, lit.want)
 			p.restore(start)
+			// ==template== {{ if .FuzzyMatch }}
+			if p.fuzzyBudget > 0 {
+				return p.parseLitMatcherFuzzy(lit)
+			}
+			// {{ end }} ==template==
+			// ==template== {{ if .ErrorRepair }}
+			if p.repairAt[start.offset] && (start.repair == nil || start.repair.repair.Offset != start.offset) {
+				return p.insertLit(lit)
+			}
+			// {{ end }} ==template==
 			return nil, false
 		}
 		p.read()
 	}
 	p.failAt(true, start.position, lit.want)
+	// ==template== {{ if .ASCIIFastPath }}
+	if p.ascii && p.pt.offset == start.offset+1 {
+		return asciiValues[start.rn], true
+	}
+	// {{ end }} ==template==
+	return p.sliceFrom(start), true
+}
+
+// ==template== {{ if .ErrorRepair }}
+// insertLit matches lit as if it was at the current position of the input,
+// without consuming any input, and records the repair.
+func (p *parser) insertLit(lit *litMatcher) (any, bool) {
+	p.pt.repair = &repairEdit{
+		prev: p.pt.repair,
+		repair: Repair{
+			Line:     p.pt.line,
+			Col:      p.pt.col,
+			Offset:   p.pt.offset,
+			Inserted: lit.val,
+		},
+	}
+	return []byte(lit.val), true
+}
+
+// {{ end }} ==template==
+
+// ==template== {{ if .FuzzyMatch }}
+// parseLitMatcherFuzzy matches lit against the input with the smallest
+// number of edits allowed by the remaining budget, using the optimal
+// string alignment distance.
+func (p *parser) parseLitMatcherFuzzy(lit *litMatcher) (any, bool) {
+	start := p.pt
+	left := p.fuzzyBudget
+	if start.fuzzy != nil {
+		left -= start.fuzzy.edits
+	}
+	if left <= 0 {
+		return nil, false
+	}
+
+	want := []rune(lit.val)
+	var got []rune
+	for off := start.offset; off < len(p.data) && len(got) < len(want)+left; {
+		rn, n := utf8.DecodeRune(p.data[off:])
+		if lit.ignoreCase {
+			// ==template== {{ if .LocaleCase }}
+			rn = localeCase.ToLower(rn)
+			// {{ else }}
+			rn = unicode.ToLower(rn)
+			// {{ end }} ==template==
+		}
+		got = append(got, rn)
+		off += n
+	}
+
+	// d[i][j] is the distance between want[:i] and got[:j]
+	d := make([][]int, len(want)+1)
+	for i := range d {
+		d[i] = make([]int, len(got)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(want); i++ {
+		for j := 1; j <= len(got); j++ {
+			cost := 1
+			if want[i-1] == got[j-1] {
+				cost = 0
+			}
+			d[i][j] = d[i-1][j-1] + cost
+			if d[i-1][j]+1 < d[i][j] {
+				d[i][j] = d[i-1][j] + 1
+			}
+			if d[i][j-1]+1 < d[i][j] {
+				d[i][j] = d[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && want[i-1] == got[j-2] && want[i-2] == got[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+
+	// prefer the fewest edits, then the length closest to the literal
+	dist := d[len(want)]
+	best, bestGap := -1, 0
+	for j := range dist {
+		gap := j - len(want)
+		if gap < 0 {
+			gap = -gap
+		}
+		if best < 0 || dist[j] < dist[best] || dist[j] == dist[best] && gap < bestGap {
+			best, bestGap = j, gap
+		}
+	}
+	edits := dist[best]
+	if edits > left {
+		return nil, false
+	}
+
+	for i := 0; i < best; i++ {
+		p.read()
+	}
+	prevEdits := 0
+	if start.fuzzy != nil {
+		prevEdits = start.fuzzy.edits
+	}
+	p.pt.fuzzy = &fuzzyEdit{
+		prev:  start.fuzzy,
+		edits: prevEdits + edits,
+		corr: Correction{
+			Line:   start.line,
+			Col:    start.col,
+			Offset: start.offset,
+			Want:   lit.val,
+			Got:    string(p.sliceFrom(start)),
+			Edits:  edits,
+		},
+	}
 	return p.sliceFrom(start), true
 }
 
+// {{ end }} ==template==
+
 func (p *parser) parseNotCodeExpr(not *notCodeExpr) (any, bool) {
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
@@ -1398,7 +7303,11 @@ func (p *parser) parseNotCodeExpr(not *notCodeExpr) (any, bool) {
 	state := p.cloneState()
 
 	// {{ end }} ==template==
+	// ==template== {{ if .SwitchDispatch }}
+	ok, err := p.runPredicate(not.code)
+	// {{ else }}
 	ok, err := not.run(p)
+	// {{ end }} ==template==
 	if err != nil {
 		p.addErr(err)
 	}
@@ -1441,19 +7350,38 @@ func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (any, bool) {
 
 	// {{ end }} ==template==
 	var vals []any
+	// ==template== {{ if .EmitValidate }}
+	matched := false
+	// {{ end }} ==template==
 
 	for {
 		p.pushV()
 		val, ok := p.parseExprWrap(expr.expr)
 		p.popV()
 		if !ok {
+			// ==template== {{ if .EmitValidate }}
+			if len(vals) == 0 && !matched {
+			// {{ else }}
 			if len(vals) == 0 {
+			// {{ end }} ==template==
 				// did not match once, no match
 				return nil, false
 			}
 			return vals, true
 		}
+		// ==template== {{ if .EmitValidate }}
+		matched = true
+		if !p.validate {
+			vals = append(vals, val)
+		}
+		// {{ else }}
 		vals = append(vals, val)
+		// {{ end }} ==template==
+		// ==template== {{ if .SinkResults }}
+		if p.sinkExpr == expr {
+			p.pushSink(val)
+		}
+		// {{ end }} ==template==
 	}
 }
 
@@ -1473,17 +7401,38 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
+	if ref.offset > len(p.rules) - 1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
+	}
+
+	rule := p.rules[ref.offset]
 	// ==template== {{ if not .Optimize }}
 	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
 	// {{ end }} ==template==
-	if ref.offset > len(p.rules) - 1 {
-		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
+	// ==template== {{ if .RuntimeParams }}
+	if len(ref.args) > 0 {
+		// the arguments are evaluated with the labels of the referencing
+		// rule, and passed to the referenced rule for the time of its match
+		args := make(map[string]any, len(ref.args))
+		p.cur.pos = p.pt.position
+		p.cur.text = nil
+		for _, arg := range ref.args {
+			val, err := arg.value(p)
+			if err != nil {
+				p.addErr(err)
+			}
+			args[arg.name] = val
+		}
+		defer func(prev *paramFrame) {
+			p.cur.params = prev
+		}(p.cur.params)
+		p.cur.params = &paramFrame{prev: p.cur.params, args: args}
 	}
 
-	rule := p.rules[ref.offset]
+	// {{ end }} ==template==
 	return p.parseRuleWrap(rule)
 }
 
@@ -1494,7 +7443,14 @@ func (p *parser) parseSeqExpr(seq *seqExpr) (any, bool) {
 	}
 
 	// {{ end }} ==template==
+	// ==template== {{ if .EmitValidate }}
+	var vals []any
+	if !p.validate {
+		vals = make([]any, 0, len(seq.exprs))
+	}
+	// {{ else }}
 	vals := make([]any, 0, len(seq.exprs))
+	// {{ end }} ==template==
 
 	pt := p.pt
 	// ==template== {{ if or .GlobalState (not .Optimize) }}
@@ -1509,7 +7465,13 @@ func (p *parser) parseSeqExpr(seq *seqExpr) (any, bool) {
 			p.restore(pt)
 			return nil, false
 		}
+		// ==template== {{ if .EmitValidate }}
+		if !p.validate {
+			vals = append(vals, val)
+		}
+		// {{ else }}
 		vals = append(vals, val)
+		// {{ end }} ==template==
 	}
 	return vals, true
 }
@@ -1523,10 +7485,17 @@ func (p *parser) parseStateCodeExpr(state *stateCodeExpr) (any, bool) {
 	}
 
 	// {{ end }} ==template==
+	// ==template== {{ if .SwitchDispatch }}
+	err := p.runState(state.code)
+	// {{ else }}
 	err := state.run(p)
+	// {{ end }} ==template==
 	if err != nil {
 		p.addErr(err)
 	}
+	// ==template== {{ if .ModeAwareMemo }}
+	p.bumpModeGen()
+	// {{ end }} ==template==
 	return nil, true
 }
 
@@ -1540,14 +7509,41 @@ func (p *parser) parseThrowExpr(expr *throwExpr) (any, bool) {
 
 	// {{ end }} ==template==
 
+	// ==template== {{ if .TypedThrows }}
+	thrown := &ThrowError{Label: expr.label}
+	if expr.payload != nil {
+		p.cur.pos = p.pt.position
+		p.cur.text = nil
+		payload, err := expr.payload(p)
+		if err != nil {
+			p.addErr(err)
+		}
+		thrown.Payload = payload
+	}
+	defer func(prev *ThrowError) {
+		p.cur.recovering = prev
+	}(p.cur.recovering)
+
+	// {{ end }} ==template==
 	for i := len(p.recoveryStack) - 1; i >= 0; i-- {
 		if recoverExpr, ok := p.recoveryStack[i][expr.label]; ok {
+			// ==template== {{ if .TypedThrows }}
+			p.cur.recovering = thrown
+			// {{ end }} ==template==
 			if val, ok := p.parseExprWrap(recoverExpr); ok {
 				return val, ok
 			}
 		}
 	}
 
+	// ==template== {{ if .TypedThrows }}
+	// the throw is only reported if the parse fails, as a later
+	// alternative may match
+	if expr.payload != nil && (p.maxThrowErr == nil || p.pt.offset > p.maxThrowOffset) {
+		p.maxThrowErr = p.newErrAt(thrown, p.pt.position, nil)
+		p.maxThrowOffset = p.pt.offset
+	}
+	// {{ end }} ==template==
 	return nil, false
 }
 
@@ -1567,7 +7563,18 @@ func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (any, bool) {
 		if !ok {
 			return vals, true
 		}
+		// ==template== {{ if .EmitValidate }}
+		if !p.validate {
+			vals = append(vals, val)
+		}
+		// {{ else }}
 		vals = append(vals, val)
+		// {{ end }} ==template==
+		// ==template== {{ if .SinkResults }}
+		if p.sinkExpr == expr {
+			p.pushSink(val)
+		}
+		// {{ end }} ==template==
 	}
 }
 
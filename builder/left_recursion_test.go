@@ -2,6 +2,7 @@ package builder_test
 
 import (
 	"errors"
+	"io"
 	"strings"
 	"testing"
 
@@ -215,3 +216,42 @@ func TestLeftRecursionTooComplex(t *testing.T) {
 		t.Fatalf("Got %s, but expected %s", err, builder.ErrNoLeader)
 	}
 }
+
+func TestLeftRecursiveRules(t *testing.T) {
+	t.Parallel()
+
+	text := `
+	start = expr list
+    expr = expr '+' term / term
+    term = NUMBER
+    list = list ',' item / item
+    item = NAME
+	`
+	cases := []struct {
+		opts []builder.Option
+		err  string
+	}{
+		{[]builder.Option{builder.LeftRecursiveRules([]string{"expr", "list"})}, ""},
+		{[]builder.Option{builder.LeftRecursiveRules([]string{"expr", "list", "item"})}, ""},
+		{[]builder.Option{builder.LeftRecursiveRules([]string{"expr"})}, "rule list: grammar contains left recursion"},
+		{[]builder.Option{builder.LeftRecursiveRules([]string{"expr"}), builder.SupportLeftRecursion(true)}, "rule list: grammar contains left recursion"},
+		{[]builder.Option{builder.LeftRecursiveRules([]string{"expr", "list", "nope"})}, `left recursive rules: unknown rule "nope"`},
+	}
+	for i, tc := range cases {
+		p := bootstrap.NewParser()
+		grammar, err := p.Parse("", strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = builder.BuildParser(io.Discard, grammar, tc.opts...)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%d: %v", i, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%d: want error containing %q, got %v", i, tc.err, err)
+		}
+	}
+}
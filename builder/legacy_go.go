@@ -0,0 +1,53 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// writeLegacyGo writes the code of the parser src with the predeclared
+// any type replaced by interface{}, for the Go versions before 1.18.
+func (b *builder) writeLegacyGo(src []byte) {
+	out, err := replaceAny(src)
+	if err != nil {
+		b.err = fmt.Errorf("legacy Go compat: %w", err)
+		return
+	}
+	_, b.err = b.w.Write(out)
+}
+
+// replaceAny returns src with the references to the predeclared any type
+// replaced by interface{}. The identifiers named any that are declared in
+// src, e.g. by the code blocks of the grammar, are not replaced, nor are
+// the field selectors and the keys of composite literals named any. The
+// imports are not resolved by the parser, so that the references to the
+// predeclared identifiers are the unresolved identifiers of the file.
+func replaceAny(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var offsets []int
+	for _, id := range f.Unresolved {
+		if id.Name == "any" {
+			offsets = append(offsets, fset.Position(id.Pos()).Offset)
+		}
+	}
+	sort.Ints(offsets)
+
+	var buf bytes.Buffer
+	buf.Grow(len(src) + len(offsets)*len("interface{}"))
+	start := 0
+	for _, off := range offsets {
+		buf.Write(src[start:off])
+		buf.WriteString("interface{}")
+		start = off + len("any")
+	}
+	buf.Write(src[start:])
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,124 @@
+package builder
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// BuildMmap writes to w the file of the ParseMmap function of the parser
+// generated by BuildParser with the same options, if the MmapInput option
+// is set, and nothing otherwise. As syscall.Mmap is only available on Unix
+// platforms, the file is only built for those, BuildMmapFallback writes
+// the ParseMmap function of the other platforms, and the parser itself has
+// no build constraint.
+func BuildMmap(w io.Writer, g *ast.Grammar, opts ...Option) error {
+	b := &builder{w: w, warnw: os.Stderr, recvName: "c"}
+	b.setOptions(opts)
+	if !b.mmapInput {
+		return nil
+	}
+	return b.writeMmap(g, false)
+}
+
+// BuildMmapFallback writes to w the file of the ParseMmap function of the
+// parser generated by BuildParser with the same options for the platforms
+// other than Unix, which reads the file in memory, if the MmapInput option
+// is set, and nothing otherwise.
+func BuildMmapFallback(w io.Writer, g *ast.Grammar, opts ...Option) error {
+	b := &builder{w: w, warnw: os.Stderr, recvName: "c"}
+	b.setOptions(opts)
+	if !b.mmapInput {
+		return nil
+	}
+	return b.writeMmap(g, true)
+}
+
+func (b *builder) writeMmap(g *ast.Grammar, fallback bool) error {
+	pkg := grammarPackage(g)
+	if pkg == "" {
+		return errors.New("mmap input: the grammar has no package clause")
+	}
+
+	anyType := "any"
+	if b.legacyGoCompat {
+		anyType = "interface{}"
+	}
+	var recvDecl, recv string
+	if b.methodReceiver != "" {
+		recvDecl, recv = "(recv *"+b.methodReceiver+") ", "recv."
+	}
+	nolint := ""
+	if b.nolint {
+		nolint = " // nolint: deadcode"
+	}
+	signature := "func " + recvDecl + "ParseMmap(filename string, opts ...Option) (i " + anyType + ", release func() error, err error) {" + nolint
+
+	b.writeln("// Code generated by pigeon; DO NOT EDIT.")
+	b.writeln("")
+	if fallback {
+		b.writeln("//go:build !unix")
+		b.writeln("")
+		b.writelnf("package %s", pkg)
+		b.writeln("")
+		b.writeln("// ParseMmap parses the file identified by filename. The file cannot be")
+		b.writeln("// memory-mapped on this platform, it is read in memory as with ParseFile")
+		b.writeln("// and release does nothing.")
+		b.writeln(signature)
+		b.writelnf("\ti, err = %sParseFile(filename, opts...)", recv)
+		b.writeln("\treturn i, func() error { return nil }, err")
+		b.writeln("}")
+		return b.err
+	}
+
+	b.writeln("//go:build unix")
+	b.writeln("")
+	b.writelnf("package %s", pkg)
+	b.writeln("")
+	b.writeln("import (")
+	b.writeln("\t\"os\"")
+	b.writeln("\t\"syscall\"")
+	b.writeln(")")
+	b.writeln("")
+	b.writeln("// ParseMmap parses the file identified by filename, which is memory-mapped")
+	b.writeln("// instead of read in memory. The returned value may reference the mapped")
+	b.writeln("// input, e.g. the []byte values of the expressions without action, so the")
+	b.writeln("// mapping is only removed by release, which must be called once, even if")
+	b.writeln("// err is not nil, when the value is no longer used. If the file cannot be")
+	b.writeln("// mapped, e.g. it is empty or not a regular file, it is read in memory")
+	b.writeln("// instead and release does nothing.")
+	b.writeln(signature)
+	b.writeln("\trelease = func() error { return nil }")
+	b.writeln("\tf, err := os.Open(filename)")
+	b.writeln("\tif err != nil {")
+	b.writeln("\t\treturn nil, release, err")
+	b.writeln("\t}")
+	b.writeln("\tdefer func() {")
+	b.writeln("\t\tif closeErr := f.Close(); closeErr != nil && err == nil {")
+	b.writeln("\t\t\terr = closeErr")
+	b.writeln("\t\t}")
+	b.writeln("\t}()")
+	b.writeln("")
+	b.writeln("\tfi, err := f.Stat()")
+	b.writeln("\tif err != nil {")
+	b.writeln("\t\treturn nil, release, err")
+	b.writeln("\t}")
+	b.writeln("\tsize := fi.Size()")
+	b.writeln("\tif !fi.Mode().IsRegular() || size <= 0 || int64(int(size)) != size {")
+	b.writelnf("\t\ti, err = %sParseReader(filename, f, opts...)", recv)
+	b.writeln("\t\treturn i, release, err")
+	b.writeln("\t}")
+	b.writeln("\tdata, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)")
+	b.writeln("\tif err != nil {")
+	b.writelnf("\t\ti, err = %sParseReader(filename, f, opts...)", recv)
+	b.writeln("\t\treturn i, release, err")
+	b.writeln("\t}")
+	b.writeln("\t// the mapping remains valid once the file is closed")
+	b.writeln("\trelease = func() error { return syscall.Munmap(data) }")
+	b.writelnf("\ti, err = %sParse(filename, data, opts...)", recv)
+	b.writeln("\treturn i, release, err")
+	b.writeln("}")
+	return b.err
+}
@@ -0,0 +1,67 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// BuildProto writes to w the .proto file of the message of the parse
+// results of the parser generated by BuildParser with the same options, if
+// the ProtoResults option is set, and nothing otherwise. The package of
+// the .proto file is the package of the parser.
+func BuildProto(w io.Writer, g *ast.Grammar, opts ...Option) error {
+	b := &builder{w: w, warnw: os.Stderr, recvName: "c"}
+	b.setOptions(opts)
+	if b.protoResults == "" {
+		return nil
+	}
+	if err := b.checkProtoResults(); err != nil {
+		return err
+	}
+	return b.writeProto(g)
+}
+
+// checkProtoResults returns an error if the message name of the
+// ProtoResults option is not an exported Go identifier, as it is also the
+// name of the Go type of the message.
+func (b *builder) checkProtoResults() error {
+	if !token.IsIdentifier(b.protoResults) || !token.IsExported(b.protoResults) {
+		return fmt.Errorf("proto results: invalid message name %q", b.protoResults)
+	}
+	return nil
+}
+
+func (b *builder) writeProto(g *ast.Grammar) error {
+	pkg := grammarPackage(g)
+	if pkg == "" {
+		return errors.New("proto results: the grammar has no package clause")
+	}
+
+	name := b.protoResults
+	b.writeln("// Code generated by pigeon; DO NOT EDIT.")
+	b.writeln("")
+	b.writeln(`syntax = "proto3";`)
+	b.writeln("")
+	b.writelnf("package %s;", pkg)
+	b.writeln("")
+	b.writelnf("// %s is a parse result: the text matched by a literal, a character", name)
+	b.writeln("// class or the any matcher, the list of the results of a sequence or a")
+	b.writeln("// repetition, or nil if neither is set.")
+	b.writelnf("message %s {", name)
+	b.writeln("  oneof value {")
+	b.writeln("    bytes text = 1;")
+	b.writelnf("    %sList list = 2;", name)
+	b.writeln("  }")
+	b.writeln("}")
+	b.writeln("")
+	b.writelnf("// %sList is the list of the results of a sequence or a repetition.", name)
+	b.writelnf("message %sList {", name)
+	b.writelnf("  repeated %s items = 1;", name)
+	b.writeln("}")
+	return b.err
+}
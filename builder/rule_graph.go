@@ -0,0 +1,70 @@
+package builder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// WriteRuleGraphDOT writes the graph of rule references of the grammar to w
+// in the Graphviz DOT format. There is an edge from A to B if rule A
+// references rule B anywhere in its expression. Recursive rules (directly or
+// indirectly) are filled in a different color and the entrypoint (the first
+// rule of the grammar) is drawn with a double outline.
+func WriteRuleGraphDOT(w io.Writer, g *ast.Grammar) error {
+	names := make([]string, 0, len(g.Rules))
+	refs := make(map[string][]string, len(g.Rules))
+	graph := make(map[string]map[string]struct{}, len(g.Rules))
+	for _, rule := range g.Rules {
+		nm := rule.Name.Val
+		names = append(names, nm)
+		graph[nm] = make(map[string]struct{})
+		ast.Inspect(rule.Expr, func(expr ast.Expression) bool {
+			if ref, ok := expr.(*ast.RuleRefExpr); ok {
+				if _, seen := graph[nm][ref.Name.Val]; !seen {
+					graph[nm][ref.Name.Val] = struct{}{}
+					refs[nm] = append(refs[nm], ref.Name.Val)
+				}
+			}
+			return true
+		})
+	}
+
+	recursive := make(map[string]bool)
+	for _, scc := range StronglyConnectedComponents(names, graph) {
+		for nm := range scc {
+			_, self := graph[nm][nm]
+			recursive[nm] = len(scc) > 1 || self
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph grammar {")
+	fmt.Fprintln(bw, "\tnode [shape=box, style=filled, fillcolor=white];")
+	for i, nm := range names {
+		var attrs string
+		if recursive[nm] {
+			attrs = "fillcolor=lightsalmon"
+		}
+		if i == 0 {
+			if attrs != "" {
+				attrs += ", "
+			}
+			attrs += "peripheries=2"
+		}
+		if attrs != "" {
+			fmt.Fprintf(bw, "\t%q [%s];\n", nm, attrs)
+		} else {
+			fmt.Fprintf(bw, "\t%q;\n", nm)
+		}
+	}
+	for _, nm := range names {
+		for _, ref := range refs[nm] {
+			fmt.Fprintf(bw, "\t%q -> %q;\n", nm, ref)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
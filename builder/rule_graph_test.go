@@ -0,0 +1,39 @@
+package builder
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/bootstrap"
+)
+
+func TestWriteRuleGraphDOT(t *testing.T) {
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRuleGraphDOT(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	want := []string{
+		`"start" [peripheries=2];`,
+		`"additive" [fillcolor=lightsalmon];`,
+		`"integer";`,
+		`"start" -> "additive";`,
+		`"primary" -> "integer";`,
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("want output to contain %q, got:\n%s", w, got)
+		}
+	}
+	if strings.Contains(got, `"space" ->`) {
+		t.Errorf("want no edge from space, got:\n%s", got)
+	}
+}
@@ -0,0 +1,166 @@
+package builder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// ShadowedClassWarnings returns a warning for each alternative of a choice
+// expression that can only start with characters that an earlier
+// alternative starting with a character class can also start with, e.g.
+// [a-m]+ '=' after [a-z]+ ';'. Such an alternative is only tried when the
+// earlier one fails after its first character, which is often a bug in
+// the order of the alternatives. The FIRST sets of the alternatives are
+// compared with the membership test of the character classes, on the
+// ASCII characters and on the bounds of the characters and ranges of the
+// classes. The alternatives that can match the empty string are ignored.
+// The warnings are in the order of the rules.
+func ShadowedClassWarnings(g *ast.Grammar) []string {
+	c := newSetsComputer(g)
+
+	var warnings []string
+	for _, rule := range g.Rules {
+		ast.Inspect(rule.Expr, func(expr ast.Expression) bool {
+			ch, ok := expr.(*ast.ChoiceExpr)
+			if !ok {
+				return true
+			}
+			sets := make([]*firstRunes, len(ch.Alternatives))
+			for i, alt := range ch.Alternatives {
+				if first, nullable := c.firstOf(alt, false); !nullable {
+					sets[i] = newFirstRunes(first)
+				}
+			}
+			for j, later := range sets {
+				for i, earlier := range sets[:j] {
+					if earlier != nil && later != nil && earlier.class && earlier.covers(later) {
+						warnings = append(warnings, fmt.Sprintf(
+							"%s: rule %s: alternative %d can only start with characters that alternative %d at %s can start with",
+							ch.Alternatives[j].Pos(), rule.Name.Val, j+1, i+1, ch.Alternatives[i].Pos()))
+						break
+					}
+				}
+			}
+			return true
+		})
+	}
+	return warnings
+}
+
+// firstRunes is the set of characters that the tokens of a FIRST set can
+// start with.
+type firstRunes struct {
+	matchers []func(rune) bool
+	// runes where the membership may change, besides the ASCII characters
+	bounds []rune
+	// set if a token is a character class or the any matcher
+	class bool
+	// set if a token is the end of the input
+	end bool
+}
+
+// newFirstRunes returns the set of characters that the tokens of first
+// can start with.
+func newFirstRunes(first tokenSet) *firstRunes {
+	s := &firstRunes{}
+	for tok := range first {
+		switch {
+		case tok == ".":
+			s.class = true
+			s.matchers = append(s.matchers, func(rune) bool { return true })
+		case tok == "!.":
+			s.end = true
+		case strings.HasPrefix(tok, "["):
+			s.class = true
+			s.addClass(ast.NewCharClassMatcher(ast.Pos{}, tok))
+		default:
+			ignoreCase := strings.HasSuffix(tok, "i")
+			lit, err := strconv.Unquote(strings.TrimSuffix(tok, "i"))
+			if err != nil || lit == "" {
+				continue
+			}
+			want, _ := utf8.DecodeRuneInString(lit)
+			s.bounds = append(s.bounds, want, unicode.ToUpper(want), unicode.ToLower(want))
+			s.matchers = append(s.matchers, func(rn rune) bool {
+				if ignoreCase {
+					return unicode.ToLower(rn) == unicode.ToLower(want)
+				}
+				return rn == want
+			})
+		}
+	}
+	return s
+}
+
+// addClass adds the characters of the character class ch to the set.
+func (s *firstRunes) addClass(ch *ast.CharClassMatcher) {
+	for _, rn := range ch.Chars {
+		s.bounds = append(s.bounds, rn, unicode.ToUpper(rn))
+	}
+	for _, rn := range ch.Ranges {
+		s.bounds = append(s.bounds, rn-1, rn, rn+1, unicode.ToUpper(rn))
+	}
+	for _, class := range ch.UnicodeClasses {
+		for _, tables := range []map[string]*unicode.RangeTable{unicode.Categories, unicode.Properties, unicode.Scripts} {
+			rt, ok := tables[class]
+			if !ok {
+				continue
+			}
+			for _, r := range rt.R16 {
+				s.bounds = append(s.bounds, rune(r.Lo)-1, rune(r.Lo), rune(r.Hi), rune(r.Hi)+1)
+			}
+			for _, r := range rt.R32 {
+				s.bounds = append(s.bounds, rune(r.Lo)-1, rune(r.Lo), rune(r.Hi), rune(r.Hi)+1)
+			}
+		}
+	}
+	if !ch.IgnoreCase {
+		s.matchers = append(s.matchers, func(rn rune) bool { return classMatches(ch, rn) })
+		return
+	}
+	// the characters of the class are in any case
+	cs := *ch
+	cs.IgnoreCase = false
+	s.matchers = append(s.matchers, func(rn rune) bool {
+		return classMatches(&cs, unicode.ToLower(rn)) || classMatches(&cs, unicode.ToUpper(rn))
+	})
+}
+
+func (s *firstRunes) matches(rn rune) bool {
+	for _, fn := range s.matchers {
+		if fn(rn) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers returns true if o is not empty and all the characters of o are
+// in s.
+func (s *firstRunes) covers(o *firstRunes) bool {
+	if o.end && !s.end {
+		return false
+	}
+	candidates := make([]rune, 0, utf8.RuneSelf+len(s.bounds)+len(o.bounds))
+	for rn := rune(0); rn < utf8.RuneSelf; rn++ {
+		candidates = append(candidates, rn)
+	}
+	candidates = append(append(candidates, s.bounds...), o.bounds...)
+
+	empty := true
+	for _, rn := range candidates {
+		if rn < 0 || rn > unicode.MaxRune || !o.matches(rn) {
+			continue
+		}
+		if !s.matches(rn) {
+			return false
+		}
+		empty = false
+	}
+	return !empty
+}
@@ -0,0 +1,40 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/bootstrap"
+)
+
+func TestShadowedClassWarnings(t *testing.T) {
+	src := `
+shadowed = [a-z]+ ';' / [a-m]+ '='
+literal = [0-9]+ / "1"
+rule = word ';' / "k" '='
+disjoint = [a-m]+ ';' / [n-z]+ '='
+overlap = [a-m]+ ';' / [h-z]+ '='
+widened = [a-m]+ ';' / [a-z]+ '='
+insensitive = [a-z]i / 'Q'
+sensitive = [a-z] / 'Q'
+nullable = [a-z]* / [a-z]
+word = [a-z]+
+`
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"2:25 (25): rule shadowed: alternative 2 can only start with characters that alternative 1 at 2:12 (12) can start with",
+		"3:20 (55): rule literal: alternative 2 can only start with characters that alternative 1 at 3:11 (46) can start with",
+		"4:19 (77): rule rule: alternative 2 can only start with characters that alternative 1 at 4:8 (66) can start with",
+		"8:24 (211): rule insensitive: alternative 2 can only start with characters that alternative 1 at 8:15 (202) can start with",
+	}
+	got := ShadowedClassWarnings(g)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want warnings\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
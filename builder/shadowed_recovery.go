@@ -0,0 +1,65 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// ShadowedRecoveryWarnings returns a warning for each recovery expression
+// of the grammar that can match the same input as the expression it
+// recovers, e.g. expr //{err} [a-z]+ where expr can also start with [a-z].
+// Such a recovery expression may silently accept input that should have
+// been reported as an error. The overlap is approximated by the common
+// tokens of the FIRST sets of both expressions, where any character
+// overlaps all but the end of the input. The warnings are in the order of
+// the rules.
+func ShadowedRecoveryWarnings(g *ast.Grammar) []string {
+	c := newSetsComputer(g)
+
+	var warnings []string
+	for _, rule := range g.Rules {
+		ast.Inspect(rule.Expr, func(expr ast.Expression) bool {
+			rec, ok := expr.(*ast.RecoveryExpr)
+			if !ok {
+				return true
+			}
+			// the recovery expressions of a chain, e.g. expr //{a} r1 //{b} r2,
+			// are compared to the expression only, not to each other.
+			primary := rec.Expr
+			for inner, ok := primary.(*ast.RecoveryExpr); ok; inner, ok = primary.(*ast.RecoveryExpr) {
+				primary = inner.Expr
+			}
+			first, _ := c.firstOf(primary, false)
+			recFirst, _ := c.firstOf(rec.RecoverExpr, false)
+			if common := commonTokens(first, recFirst); len(common) > 0 {
+				labels := make([]string, len(rec.Labels))
+				for i, label := range rec.Labels {
+					labels[i] = string(label)
+				}
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: rule %s: recovery expression of %s can match the same input as its expression: %s",
+					rec.Pos(), rule.Name.Val, strings.Join(labels, ","), strings.Join(common, ", ")))
+			}
+			return true
+		})
+	}
+	return warnings
+}
+
+// commonTokens returns the sorted tokens of each set that can match the
+// same input as a token of the other set.
+func commonTokens(a, b tokenSet) []string {
+	common := tokenSet{}
+	for _, sets := range [][2]tokenSet{{a, b}, {b, a}} {
+		set, other := sets[0], sets[1]
+		_, anyOther := other["."]
+		for k := range set {
+			if _, ok := other[k]; ok || (anyOther && k != "!.") {
+				common[k] = struct{}{}
+			}
+		}
+	}
+	return common.sorted()
+}
@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/ast"
+	"github.com/mna/pigeon/bootstrap"
+)
+
+func TestShadowedRecoveryWarnings(t *testing.T) {
+	src := `
+stmt = [a-z]+ '=' [0-9]+
+skipStmt = [a-z]+ ';'
+skipAny = ( !';' . )* ';'
+skipSemi = ';'
+shadowed = 'x'
+any = 'x'
+distinct = 'x'
+`
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the bootstrap parser does not support recovery expressions
+	ref := func(name string) *ast.RuleRefExpr {
+		r := ast.NewRuleRefExpr(ast.Pos{})
+		r.Name = ast.NewIdentifier(ast.Pos{}, name)
+		return r
+	}
+	for i, recover := range []string{"skipStmt", "skipAny", "skipSemi"} {
+		rec := ast.NewRecoveryExpr(ast.Pos{Line: 6 + i, Col: 1})
+		rec.Expr = ref("stmt")
+		rec.RecoverExpr = ref(recover)
+		rec.Labels = []ast.FailureLabel{"err"}
+		g.Rules[4+i].Expr = rec
+	}
+
+	want := []string{
+		"6:1 (0): rule shadowed: recovery expression of err can match the same input as its expression: [a-z]",
+		"7:1 (0): rule any: recovery expression of err can match the same input as its expression: [a-z]",
+	}
+	got := ShadowedRecoveryWarnings(g)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want warnings\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	g, err = p.Parse("", strings.NewReader(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ShadowedRecoveryWarnings(g); len(got) != 0 {
+		t.Errorf("want no warning, got %v", got)
+	}
+}
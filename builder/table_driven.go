@@ -0,0 +1,212 @@
+package builder
+
+import (
+	"fmt"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// tableInstr is an instruction of the table of a table-driven parser, see
+// the instr type of the generated parser.
+type tableInstr struct {
+	op  string
+	pos ast.Pos
+	arg int
+	sub int
+	n   int
+}
+
+// tableCompiler compiles the expressions of the rules to the instructions
+// of a table-driven parser. The instructions are appended after their
+// sub-instructions, the operands are the code of their Go value.
+type tableCompiler struct {
+	b        *builder
+	instrs   []tableInstr
+	children []int
+	lits     []string
+	classes  []string
+	labels   []string
+	actions  []string
+	preds    []string
+	states   []string
+}
+
+// compile appends the instructions of expr to the table and returns the
+// index of its instruction. The expressions are numbered as by writeExpr,
+// so that the generated functions have the same names.
+func (tc *tableCompiler) compile(expr ast.Expression) int {
+	b := tc.b
+	b.exprIndex++
+	switch expr := expr.(type) {
+	case *ast.ActionExpr:
+		if expr.FuncIx == 0 {
+			expr.FuncIx = b.exprIndex
+		}
+		sub := tc.compile(expr.Expr)
+		if b.switchDispatch {
+			return tc.add("opAction", expr.Pos(), b.actionCode(expr.FuncIx), sub)
+		}
+		tc.actions = append(tc.actions, fmt.Sprintf("(*parser).call%s", b.funcName(expr.FuncIx)))
+		return tc.add("opAction", expr.Pos(), len(tc.actions)-1, sub)
+	case *ast.AndCodeExpr:
+		if expr.FuncIx == 0 {
+			expr.FuncIx = b.exprIndex
+		}
+		if b.switchDispatch {
+			return tc.add("opAndCode", expr.Pos(), b.predCode(expr.FuncIx))
+		}
+		tc.preds = append(tc.preds, fmt.Sprintf("(*parser).call%s", b.funcName(expr.FuncIx)))
+		return tc.add("opAndCode", expr.Pos(), len(tc.preds)-1)
+	case *ast.AndExpr:
+		return tc.add("opAnd", expr.Pos(), 0, tc.compile(expr.Expr))
+	case *ast.AnyMatcher:
+		return tc.add("opAny", expr.Pos(), 0)
+	case *ast.CaseInsensitiveExpr:
+		if !b.caseScopes {
+			b.err = fmt.Errorf("%s: case-insensitive region requires the CaseScopes option", expr.Pos())
+			return 0
+		}
+		// the region has no instruction, its matchers are case-insensitive
+		prev := b.ignoreCase
+		b.ignoreCase = true
+		ix := tc.compile(expr.Expr)
+		b.ignoreCase = prev
+		return ix
+	case *ast.CharClassMatcher:
+		tc.classes = append(tc.classes, tc.operand(func() { b.writeCharClassMatcher(expr) }))
+		return tc.add("opCharClass", expr.Pos(), len(tc.classes)-1)
+	case *ast.ChoiceExpr:
+		alts := make([]int, 0, len(expr.Alternatives))
+		for _, alt := range expr.Alternatives {
+			alts = append(alts, tc.compile(alt))
+		}
+		return tc.add("opChoice", expr.Pos(), 0, alts...)
+	case *ast.LabeledExpr:
+		var label string
+		if expr.Label != nil {
+			label = expr.Label.Val
+		}
+		sub := tc.compile(expr.Expr)
+		tc.labels = append(tc.labels, label)
+		return tc.add("opLabeled", expr.Pos(), len(tc.labels)-1, sub)
+	case *ast.LitMatcher:
+		tc.lits = append(tc.lits, tc.operand(func() { b.writeLitMatcher(expr) }))
+		return tc.add("opLit", expr.Pos(), len(tc.lits)-1)
+	case *ast.NotCodeExpr:
+		if expr.FuncIx == 0 {
+			expr.FuncIx = b.exprIndex
+		}
+		if b.switchDispatch {
+			return tc.add("opNotCode", expr.Pos(), b.predCode(expr.FuncIx))
+		}
+		tc.preds = append(tc.preds, fmt.Sprintf("(*parser).call%s", b.funcName(expr.FuncIx)))
+		return tc.add("opNotCode", expr.Pos(), len(tc.preds)-1)
+	case *ast.NotExpr:
+		return tc.add("opNot", expr.Pos(), 0, tc.compile(expr.Expr))
+	case *ast.OneOrMoreExpr:
+		return tc.add("opOneOrMore", expr.Pos(), 0, tc.compile(expr.Expr))
+	case *ast.RuleRefExpr:
+		if len(expr.Args) > 0 {
+			b.err = fmt.Errorf("%s: table-driven parser: rule arguments are not supported", expr.Pos())
+			return 0
+		}
+		offset, ok := b.ruleOffsets[expr.Name.Val]
+		if !ok {
+			b.err = fmt.Errorf("%s: table-driven parser: unknown rule %q", expr.Pos(), expr.Name.Val)
+			return 0
+		}
+		return tc.add("opRuleRef", expr.Pos(), offset)
+	case *ast.SeqExpr:
+		subs := make([]int, 0, len(expr.Exprs))
+		for _, e := range expr.Exprs {
+			subs = append(subs, tc.compile(e))
+		}
+		return tc.add("opSeq", expr.Pos(), 0, subs...)
+	case *ast.StateCodeExpr:
+		b.globalState = true
+		if expr.FuncIx == 0 {
+			expr.FuncIx = b.exprIndex
+		}
+		if b.switchDispatch {
+			return tc.add("opStateCode", expr.Pos(), b.stateCode(expr.FuncIx))
+		}
+		tc.states = append(tc.states, fmt.Sprintf("(*parser).call%s", b.funcName(expr.FuncIx)))
+		return tc.add("opStateCode", expr.Pos(), len(tc.states)-1)
+	case *ast.ZeroOrMoreExpr:
+		return tc.add("opZeroOrMore", expr.Pos(), 0, tc.compile(expr.Expr))
+	case *ast.ZeroOrOneExpr:
+		return tc.add("opZeroOrOne", expr.Pos(), 0, tc.compile(expr.Expr))
+	case nil:
+		b.err = fmt.Errorf("table-driven parser: missing expression in rule %s", b.ruleName)
+	default:
+		b.err = fmt.Errorf("%s: table-driven parser: %s is not supported", expr.Pos(), exprKind(expr))
+	}
+	return 0
+}
+
+// add appends an instruction with the sub-instructions subs to the table
+// and returns its index.
+func (tc *tableCompiler) add(op string, pos ast.Pos, arg int, subs ...int) int {
+	tc.instrs = append(tc.instrs, tableInstr{op: op, pos: pos, arg: arg, sub: len(tc.children), n: len(subs)})
+	tc.children = append(tc.children, subs...)
+	return len(tc.instrs) - 1
+}
+
+// operand returns the code written by write, without its trailing comma.
+func (tc *tableCompiler) operand(write func()) string {
+	return tc.b.exprCode(write)
+}
+
+// writeTable writes the variable of the table of the table-driven parser.
+func (b *builder) writeTable(tc *tableCompiler) {
+	b.writeln("")
+	b.writeln("var grammarTable = &instrTable{")
+	b.writeln("\tinstrs: []instr{")
+	for _, in := range tc.instrs {
+		b.writef("\t\t{op: %s, pos: position{line: %d, col: %d, offset: %d}", in.op, in.pos.Line, in.pos.Col, in.pos.Off)
+		if in.arg != 0 {
+			b.writef(", arg: %d", in.arg)
+		}
+		if in.n != 0 {
+			b.writef(", sub: %d, n: %d", in.sub, in.n)
+		}
+		b.writeln("},")
+	}
+	b.writeln("\t},")
+	if len(tc.children) > 0 {
+		b.writef("\tchildren: []int{")
+		for i, ix := range tc.children {
+			if i > 0 {
+				b.writef(", ")
+			}
+			b.writef("%d", ix)
+		}
+		b.writeln("},")
+	}
+	b.writeOperands("lits", "*litMatcher", tc.lits)
+	b.writeOperands("classes", "*charClassMatcher", tc.classes)
+	if len(tc.labels) > 0 {
+		b.writeln("\tlabels: []string{")
+		for _, label := range tc.labels {
+			b.writelnf("\t\t%q,", label)
+		}
+		b.writeln("\t},")
+	}
+	b.writeOperands("actions", "func(*parser) (any, error)", tc.actions)
+	b.writeOperands("preds", "func(*parser) (bool, error)", tc.preds)
+	b.writeOperands("states", "func(*parser) error", tc.states)
+	b.writeln("}")
+}
+
+// writeOperands writes the field of the table with the operands of type
+// typ, if there is any.
+func (b *builder) writeOperands(field, typ string, operands []string) {
+	if len(operands) == 0 {
+		return
+	}
+	b.writelnf("\t%s: []%s{", field, typ)
+	for _, code := range operands {
+		b.writelnf("%s,", code)
+	}
+	b.writeln("\t},")
+}
@@ -0,0 +1,157 @@
+package builder
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// UnusedLabelWarnings returns a warning for each labeled expression of the
+// grammar whose label is not referenced by any of the code blocks that
+// receive it as argument, e.g. the action of the rule. Such a label is
+// dead weight, and often a bug. The code blocks are scanned as Go code, so
+// that the identifiers in strings and comments are not references, and all
+// the labels they receive are considered referenced if they cannot be
+// scanned. The warnings are in the order of the rules.
+func UnusedLabelWarnings(g *ast.Grammar) []string {
+	var warnings []string
+	for _, rule := range g.Rules {
+		c := &labelsChecker{}
+		c.push()
+		c.check(rule.Expr)
+		for _, l := range c.labels {
+			if !l.used {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: rule %s: label %s is not used by a code block",
+					l.expr.Pos(), rule.Name.Val, l.expr.Label.Val))
+			}
+		}
+	}
+	return warnings
+}
+
+type labelUse struct {
+	expr *ast.LabeledExpr
+	used bool
+}
+
+// labelsChecker records the labels of a rule and whether they are used. The
+// scopes of the labels are those of the arguments of the code blocks, see
+// writeExprCode.
+type labelsChecker struct {
+	labels []*labelUse
+	scopes [][]*labelUse
+}
+
+func (c *labelsChecker) push() {
+	c.scopes = append(c.scopes, nil)
+}
+
+func (c *labelsChecker) pop() {
+	c.scopes = c.scopes[:len(c.scopes)-1]
+}
+
+func (c *labelsChecker) scoped(expr ast.Expression) {
+	c.push()
+	c.check(expr)
+	c.pop()
+}
+
+func (c *labelsChecker) check(expr ast.Expression) {
+	switch expr := expr.(type) {
+	case *ast.ActionExpr:
+		c.check(expr.Expr)
+		c.use(expr.Code)
+	case *ast.AndCodeExpr:
+		c.use(expr.Code)
+	case *ast.AndExpr:
+		c.scoped(expr.Expr)
+	case *ast.CaseInsensitiveExpr:
+		c.check(expr.Expr)
+	case *ast.ChoiceExpr:
+		for _, alt := range expr.Alternatives {
+			c.scoped(alt)
+		}
+	case *ast.LabeledExpr:
+		if expr.Label != nil {
+			l := &labelUse{expr: expr}
+			c.labels = append(c.labels, l)
+			ix := len(c.scopes) - 1
+			c.scopes[ix] = append(c.scopes[ix], l)
+		}
+		c.scoped(expr.Expr)
+	case *ast.LookbehindExpr:
+		c.scoped(expr.Expr)
+	case *ast.NotCodeExpr:
+		c.use(expr.Code)
+	case *ast.NotExpr:
+		c.scoped(expr.Expr)
+	case *ast.OneOrMoreExpr:
+		c.scoped(expr.Expr)
+	case *ast.RecoveryExpr:
+		c.push()
+		c.check(expr.Expr)
+		c.check(expr.RecoverExpr)
+		c.pop()
+	case *ast.RuleRefExpr:
+		for _, arg := range expr.Args {
+			c.use(arg.Code)
+		}
+	case *ast.ScanLimitExpr:
+		c.check(expr.Expr)
+	case *ast.SeqExpr:
+		for _, sub := range expr.Exprs {
+			c.check(sub)
+		}
+	case *ast.StateCodeExpr:
+		c.use(expr.Code)
+	case *ast.ThrowExpr:
+		c.use(expr.Payload)
+	case *ast.UntilExpr:
+		c.scoped(expr.Expr)
+	case *ast.ZeroOrMoreExpr:
+		c.scoped(expr.Expr)
+	case *ast.ZeroOrOneExpr:
+		c.scoped(expr.Expr)
+	}
+}
+
+// use marks the labels of the current scope that are referenced by code as
+// used.
+func (c *labelsChecker) use(code *ast.CodeBlock) {
+	scope := c.scopes[len(c.scopes)-1]
+	if code == nil || len(scope) == 0 {
+		return
+	}
+	idents, ok := codeIdentifiers(code.Val)
+	for _, l := range scope {
+		if _, ref := idents[l.expr.Label.Val]; ref || !ok {
+			l.used = true
+		}
+	}
+}
+
+// codeIdentifiers returns the set of identifiers of the Go code src. The ok
+// result is false if src cannot be scanned.
+func codeIdentifiers(src string) (idents map[string]struct{}, ok bool) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	var errCnt int
+	s.Init(file, []byte(src), func(token.Position, string) { errCnt++ }, 0)
+
+	idents = make(map[string]struct{})
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.IDENT {
+			idents[lit] = struct{}{}
+		}
+	}
+	return idents, errCnt == 0
+}
@@ -0,0 +1,36 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/bootstrap"
+)
+
+func TestUnusedLabelWarnings(t *testing.T) {
+	src := `
+start = a:"a" b:"b" c:"c" {
+	// b is not used
+	_ = "c"
+	return a, nil
+}
+scoped = v:( w:"w" { return w, nil } ) ( z:"z" )? { return v, nil }
+bad = u:"u" { return u + "unterminated, nil }
+`
+	p := bootstrap.NewParser()
+	g, err := p.Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"2:15 (15): rule start: label b is not used by a code block",
+		"2:21 (21): rule start: label c is not used by a code block",
+		"7:42 (114): rule scoped: label z is not used by a code block",
+	}
+	got := UnusedLabelWarnings(g)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want warnings\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
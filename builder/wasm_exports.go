@@ -0,0 +1,61 @@
+package builder
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/mna/pigeon/ast"
+)
+
+// BuildWasm writes to w the file that exposes the ParseJSON function of
+// the parser generated by BuildParser with the same options to
+// JavaScript, if the WasmExports option is set, and nothing otherwise.
+// The file is only built for the js/wasm platform, and uses interface{}
+// instead of any with the LegacyGoCompat option.
+func BuildWasm(w io.Writer, g *ast.Grammar, opts ...Option) error {
+	b := &builder{w: w, warnw: os.Stderr, recvName: "c"}
+	b.setOptions(opts)
+	if !b.wasmExports {
+		return nil
+	}
+	return b.writeWasm(g)
+}
+
+func (b *builder) writeWasm(g *ast.Grammar) error {
+	pkg := grammarPackage(g)
+	if pkg == "" {
+		return errors.New("wasm exports: the grammar has no package clause")
+	}
+
+	anyType := "any"
+	if b.legacyGoCompat {
+		anyType = "interface{}"
+	}
+
+	b.writeln("// Code generated by pigeon; DO NOT EDIT.")
+	b.writeln("")
+	b.writeln("//go:build js && wasm")
+	b.writeln("")
+	b.writelnf("package %s", pkg)
+	b.writeln("")
+	b.writeln(`import "syscall/js"`)
+	b.writeln("")
+	b.writelnf("// init sets the %s global object of JavaScript, whose parse method", pkg)
+	b.writeln("// takes the JSON request of ParseJSON as a string and returns its JSON")
+	b.writelnf("// response as a string, e.g. %s.parse('{\"input\": \"...\"}'). The main", pkg)
+	b.writeln("// package of the WebAssembly module must import this package and block,")
+	b.writeln("// so that the function remains available.")
+	b.writeln("func init() {")
+	b.writelnf("\tjs.Global().Set(%q, js.ValueOf(map[string]%s{", pkg, anyType)
+	b.writelnf("\t\t\"parse\": js.FuncOf(func(this js.Value, args []js.Value) %s {", anyType)
+	b.writeln("\t\t\tvar req []byte")
+	b.writeln("\t\t\tif len(args) > 0 && args[0].Type() == js.TypeString {")
+	b.writeln("\t\t\t\treq = []byte(args[0].String())")
+	b.writeln("\t\t\t}")
+	b.writeln("\t\t\treturn string(ParseJSON(req))")
+	b.writeln("\t\t}),")
+	b.writeln("\t}))")
+	b.writeln("}")
+	return b.err
+}
@@ -49,6 +49,24 @@ func compareRule(t *testing.T, prefix string, exp, got *ast.Rule) bool {
 			return false
 		}
 	}
+	if exp.Macro != got.Macro {
+		t.Errorf("%q: want Macro %t, got %t", prefix, exp.Macro, got.Macro)
+		return false
+	}
+	if exp.Doc != got.Doc {
+		t.Errorf("%q: want Doc %q, got %q", prefix, exp.Doc, got.Doc)
+		return false
+	}
+	if len(exp.Params) != len(got.Params) {
+		t.Errorf("%q: want %d Params, got %d", prefix, len(exp.Params), len(got.Params))
+		return false
+	}
+	for i, param := range exp.Params {
+		if param.Val != got.Params[i].Val {
+			t.Errorf("%q: want param %q, got %q", prefix, param.Val, got.Params[i].Val)
+			return false
+		}
+	}
 	return compareExpr(t, prefix, 0, exp.Expr, got.Expr)
 }
 
@@ -110,6 +128,35 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			t.Errorf("%q: want value %q, got %q", ixPrefix, exp.Val, got.Val)
 		}
 
+	case *ast.AssertionExpr:
+		got, ok := got.(*ast.AssertionExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Name != got.Name {
+			t.Errorf("%q: want name %q, got %q", ixPrefix, exp.Name, got.Name)
+		}
+
+	case *ast.BalancedExpr:
+		got, ok := got.(*ast.BalancedExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if !compareExpr(t, prefix, ix+1, exp.Open, got.Open) {
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Close, got.Close)
+
+	case *ast.CaseInsensitiveExpr:
+		got, ok := got.(*ast.CaseInsensitiveExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
 	case *ast.CharClassMatcher:
 		got, ok := got.(*ast.CharClassMatcher)
 		if !ok {
@@ -198,6 +245,12 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 
 		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
 
+	case *ast.LineStartExpr:
+		if _, ok := got.(*ast.LineStartExpr); !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+
 	case *ast.LitMatcher:
 		got, ok := got.(*ast.LitMatcher)
 		if !ok {
@@ -230,6 +283,14 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			}
 		}
 
+	case *ast.LookbehindExpr:
+		got, ok := got.(*ast.LookbehindExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
 	case *ast.NotExpr:
 		got, ok := got.(*ast.NotExpr)
 		if !ok {
@@ -246,6 +307,28 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 		}
 		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
 
+	case *ast.RuleCallExpr:
+		got, ok := got.(*ast.RuleCallExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Name.Val != got.Name.Val {
+			t.Errorf("%q: want name %q, got %q", ixPrefix, exp.Name.Val, got.Name.Val)
+			return false
+		}
+		ne, ng := len(exp.Args), len(got.Args)
+		if ne != ng {
+			t.Errorf("%q: want %d Args, got %d", ixPrefix, ne, ng)
+			return false
+		}
+
+		for i, expr := range exp.Args {
+			if !compareExpr(t, prefix, ix+1, expr, got.Args[i]) {
+				return false
+			}
+		}
+
 	case *ast.RuleRefExpr:
 		got, ok := got.(*ast.RuleRefExpr)
 		if !ok {
@@ -263,6 +346,18 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			}
 		}
 
+	case *ast.ScanLimitExpr:
+		got, ok := got.(*ast.ScanLimitExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		if exp.Limit != got.Limit {
+			t.Errorf("%q: want limit %d, got %d", ixPrefix, exp.Limit, got.Limit)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
 	case *ast.SeqExpr:
 		got, ok := got.(*ast.SeqExpr)
 		if !ok {
@@ -281,6 +376,14 @@ func compareExpr(t *testing.T, prefix string, ix int, exp, got ast.Expression) b
 			}
 		}
 
+	case *ast.UntilExpr:
+		got, ok := got.(*ast.UntilExpr)
+		if !ok {
+			t.Errorf("%q: want expression type %T, got %T", ixPrefix, exp, got)
+			return false
+		}
+		return compareExpr(t, prefix, ix+1, exp.Expr, got.Expr)
+
 	case *ast.ZeroOrMoreExpr:
 		got, ok := got.(*ast.ZeroOrMoreExpr)
 		if !ok {
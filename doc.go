@@ -29,12 +29,313 @@ by default.
 
 The following options can be specified:
 
+	-ascii-fast-path : boolean, if set, the generated parser checks whether
+	the input is only made of ASCII characters when parsing starts, which
+	is a quick scan of the input. If it is, the parser reads the runes of
+	the input byte by byte instead of decoding them as UTF-8, matches the
+	character classes with a lookup table, and the []byte values of the
+	matches of a single character are not allocated, which saves time on
+	large inputs. Those values are shared by the parses and must not be
+	modified. Other inputs are decoded as usual, and the positions are
+	byte offsets in both cases (default: false).
+
+	-auto-map-results : boolean, if set, the result of an alternative of a
+	rule that has no action is built from its expressions with specific
+	labels, if it has any: the values of the "item" labels make a []any,
+	the values of the "value" labels make a map[string]any keyed by the
+	values of the "key" labels that precede them in the same sequence, and
+	a "text" label makes the string matched by its expression. Those
+	expressions must be in sequences, repetitions or optional expressions
+	of the alternative. This is meant for data formats, e.g.:
+		Object = '{' _ ( key:String _ ':' _ value:Value ( ',' _ key:String _ ':' _ value:Value )* )? '}'
+		Array = '[' _ ( item:Value ( ',' _ item:Value )* )? ']'
+		String = '"' text:[^"]* '"'
+	(default: false).
+
+	-backtrack-hooks : boolean, if set, the actions can register functions
+	with c.onBacktrack, which are called if the parser backtracks over the
+	match of the action, e.g. to release a resource acquired by the action
+	in an alternative that fails afterwards. See the Code block section
+	(default: false).
+
+	-base-grammar=FILE : string, if set, the grammar extends the grammar in
+	FILE: it inherits its rules and its initializer, and replaces those it
+	defines, the other rules of the grammar being added after the rules of
+	FILE. The entrypoint is thus the first rule of FILE, unless the grammar
+	redefines it. This lets a family of grammars share a base grammar and
+	override only a few rules. It is an error if a rule of the merged
+	grammar references a rule that is not defined (default: "").
+
+	-batch-parse : boolean, if set, the generated parser has a ParseBatch
+	function that parses many inputs concurrently with a pool of worker
+	goroutines, each input with its own parser, and returns their results
+	in the order of the inputs (default: false).
+
+	-benchmark-input=FILE[,FILE...] : string, comma-separated list of files
+	parsed by the benchmark of the -emit-benchmarks flag, in addition to
+	the inputs synthesized from the grammar. The files are read when the
+	benchmark runs, so their paths are relative to the package of the
+	generated parser, e.g. testdata/input.txt. Unlike the synthesized
+	inputs, the benchmark fails if a file does not match the grammar
+	(default: "").
+
+	-bidi-aware : boolean, if set, the errors of the generated parser have a
+	Direction() Direction method that returns the direction, LeftToRight or
+	RightToLeft, of the text around the position of the error, so that a
+	tool displaying the error in text that mixes left-to-right and
+	right-to-left scripts, e.g. Latin and Arabic, places the caret on the
+	correct side of the character. It is the direction of the letter at the
+	position, or of the letters around it if they have the same direction,
+	or else the direction of the line, given by its first letter. Only the
+	errors have a direction, not the positions of the matches in the code
+	blocks. As the generated parser only depends on the standard library,
+	it does not run the Unicode bidirectional algorithm of the
+	golang.org/x/text/unicode/bidi package: the direction of a letter is
+	the one of its script, and the explicit direction marks and embedding
+	levels are ignored (default: false).
+
+	-bounded-stream=N : int, if greater than 0, the generated parser has a
+	ParseStream function that parses the input as it is read from an
+	io.Reader, instead of reading it in memory first, and keeps only the
+	last N bytes of input behind the current position. The parsing fails
+	with an error if it must go back further than that, when an expression
+	that matched more than N bytes fails, so N must be greater than the
+	longest input that the grammar may backtrack over. The text of the
+	current match, c.text, is nil in the code blocks of the expressions
+	whose match started before the window, such as the starting rule of a
+	whole stream. This allows to parse unbounded streams,
+	e.g. line-oriented protocols over a network connection. The options and
+	expressions that look at the input around the current position, such as
+	the lookbehind expressions, are not supported (default: 0).
+
 	-cache : cache parser results to avoid exponential parsing time in
 	pathological cases. Can make the parsing slower for typical
 	cases and uses more memory (default: false).
 
+	-builtin-assertions : boolean, if set, the grammar may have the word
+	boundary and line end assertions (see Builtin assertions below),
+	otherwise they are an error (default: false).
+
+	-case-scopes : boolean, if set, the grammar may have case-insensitive
+	regions (see Case-insensitive region below), otherwise they are an
+	error (default: false).
+
+	-choice-index : boolean, if set, the action code blocks can call
+	c.choiceIndex() to get the index of the alternative that matched in
+	their choice expression, see section "Code block" below (default:
+	false).
+
+	-chrome-trace : boolean, if set, the generated parser has a ChromeTrace
+	option that records the rules entered and exited as duration events,
+	and writes them to an io.Writer in the Chrome tracing JSON format once
+	parsing is done. Loaded in chrome://tracing or Perfetto, the trace
+	shows where the parsing time goes like a flame graph (default: false).
+
+	-compact-ast : boolean, if set, the generated parser has a ParseCompact
+	function that returns the same concrete syntax tree as ParseCST with
+	the -lossless-cst option, but as a CompactTree, whose nodes are stored
+	in a single slice and reference their first child and next sibling by
+	index. This saves most of the allocations of the tree, which matters
+	for large inputs. The FirstChild, NextSibling and Text methods of the
+	tree navigate it (default: false).
+
+	-completion-support : boolean, if set, the generated parser has a
+	Suggest function that returns the tokens that the grammar accepts at an
+	offset of the input, e.g. to implement the autocompletion of an editor.
+	It parses the input up to that offset and returns the tokens expected
+	there, in the form of the parsing errors: the quoted literals, the
+	character classes and the display names of the rules. With
+	-compute-follow-sets, the tokens come from the FIRST and FOLLOW sets of
+	the rules that fail at that offset, as for the parsing errors (default:
+	false).
+
+	-compute-follow-sets : boolean, if set, the FIRST and FOLLOW sets of
+	the rules are computed and embedded in the generated parser. When the
+	parsing fails, the expected tokens listed in the error are then those of
+	the FIRST sets of the outermost rules that failed at the farthest
+	position without matching any input, with their FOLLOW sets if they can
+	match the empty string, and the tokens tried at this position outside of
+	those rules. The tokens tried by the inner rules, such as the whitespace
+	of a rule that matched before the failure, are thus left out (default:
+	false).
+
 	-debug : boolean, print debugging info to stdout (default: false).
 
+	-dedupe-char-classes : boolean, if set, identical character class
+	matchers, such as a [0-9] used in many rules, are written once as
+	package-level variables shared by all the expressions that use them,
+	which makes the generated parser smaller. The debug output then shows
+	the position of the first use of a shared matcher (default: false).
+
+	-dense-memo : boolean, if set, the generated parser memoizes the
+	results of the rules in a dense table indexed by rule and input offset,
+	instead of maps, so that a lookup does not hash its key. The table has
+	an entry for each rule at each offset of the input, the parser falls
+	back to the maps when it would have more than 262144 entries, so it is
+	meant for grammars with few rules and moderate inputs. The results of
+	the other expressions are still memoized in the maps. The -optimize-parser,
+	-memo-key-hook, -mode-aware-memo and -bounded-stream options and the scan
+	limit expressions are not supported (default: false).
+
+	-emit-benchmarks : boolean, if set, a benchmark test file is written
+	next to the generated parser, named after the -o flag with the
+	_bench_test.go suffix, e.g. parser_bench_test.go for -o parser.go. It
+	has a Benchmark function for the entrypoint of the grammar, e.g.
+	BenchmarkGrammar for the rule Grammar, that parses small, medium and
+	large inputs synthesized from the grammar, which repeat the outermost
+	repetitions of the rules 1, 10 and 100 times, and the files of the
+	-benchmark-input flag. The inputs are synthesized without running the
+	code blocks of the grammar, so a synthesized input that does not match
+	it is skipped by the benchmark. The package of the file is the one of
+	the package clause of the grammar initializer, which is required. The
+	-o flag is required (default: false).
+
+	-emit-must-parse : boolean, if set, the generated parser has a MustParse
+	function that is like Parse but panics if the input cannot be parsed,
+	like regexp.MustCompile. It simplifies the initialization of variables
+	with the results of inputs known to be valid, e.g. test fixtures
+	(default: false).
+
+	-emit-reprinter : boolean, if set, the generated parser has a Reprint
+	function that returns the exact input matched by a node of a syntax
+	tree, whitespace and comments included, as a subslice of the input. The
+	node must implement the Spanner interface, whose Span() (start, end int)
+	method returns the byte offsets of the input it matched: the nodes of
+	the tree returned by ParseCST with the -lossless-cst option do, and
+	the action code blocks can call c.span() to record the span of the
+	nodes they build. This is a building block for refactoring tools that
+	rewrite only a part of a file (default: false).
+
+	-emit-result-diff : boolean, if set, the generated parser has a
+	DiffResults function that compares two parse results, e.g. of the same
+	input parsed with two versions of the grammar, and returns their
+	differences as a list of Diff values with the path, in the results, of
+	the values of different types, of the different leaf values and of the
+	slices of different lengths. This shows the impact of a change of the
+	grammar on real inputs (default: false).
+
+	-emit-rule-docs : boolean, if set, the documentation of the rules, the
+	text of the line comments on the lines that immediately precede them in
+	the grammar, is embedded in the generated parser, which has a RuleDoc
+	function that returns the documentation of a rule by its name. With the
+	-rule-interface option, the rule type also has a Doc method returning
+	it (default: false).
+
+	-emit-validate : boolean, if set, the generated parser has a Validate
+	function that only reports whether the input matches the grammar. It
+	does not build the values of the expressions nor run the action code
+	blocks, so their errors are not reported, unless the grammar has code
+	predicates or state code blocks, which may use the values of labeled
+	expressions (default: false).
+
+	-empty-input=MODE : string, how the generated parser handles an empty
+	input. With "error", parsing an empty input fails with the
+	ErrEmptyInput error, without matching the entrypoint. With "nil", it
+	returns a nil result and no error, without matching the entrypoint.
+	The default, "normal", matches the empty input as any other input, so
+	that the entrypoint decides whether it is valid.
+
+	-entrypoint-type=TYPE : string, if set, the generated parser has a
+	ParseTyped function that returns the result of Parse as a TYPE, a Go
+	type expression such as *Node or []Stmt, and an error if the code
+	blocks of the entrypoint rule return a value of another type, so that
+	the callers do not assert the type of the result themselves
+	(default: "").
+
+	-enumerate-parses=N : int, if greater than 0, the generated parser has
+	a ParseAll function that tries all the alternatives of the choices,
+	instead of only the first one that matches, and returns up to N
+	distinct values of the successful parses, compared with
+	reflect.DeepEqual, e.g. to inspect the ambiguities of a grammar. Each
+	parse runs again with another combination of alternatives, so the
+	number of parses can be exponential in the number of choices evaluated,
+	and N bounds the values returned, not the parses that fail or return a
+	value already found. Left recursion and the -table-driven option are
+	not supported (default: 0).
+
+	-error-repair : boolean, if set, the generated parser retries a failed
+	parse pretending that the literals expected where it failed are in the
+	input, e.g. a missing closing brace, and repeats as long as it fails
+	further in the input. A literal is inserted at most once at a given
+	position, and the first literal expected there that lets the parsing
+	proceed is inserted. Code blocks run again on each attempt, and the
+	inserted literals are stored by the Repairs parser option. This is
+	useful for editors, which need a syntax tree of incomplete input
+	(default: false).
+
+	-error-spans : boolean, if set, the errors of the generated parser have a
+	Span() (start, end int) method that returns the byte offsets of the
+	input delimiting the error, e.g. for an editor to underline it. The
+	span of an error returned by a code block is the input matched by its
+	expression, and the span of the error of a failed parse goes from the
+	start of the innermost rule that failed after matching some input up to
+	the farthest failure, to the end of the character at that failure
+	(default: false).
+
+	-expand-ignore-case-classes : boolean, if set, a case-insensitive
+	character class such as [a-f]i is written with all the case variants of
+	its characters and ranges (here a-f and A-F, as defined by
+	unicode.SimpleFold) and matched as a case-sensitive class, without
+	converting the case of the input. Classes with Unicode classes are left
+	unchanged (default: false).
+
+	-explain-failures : boolean, if set, the error of a parse that fails
+	because the input does not match has an Explanation() string method
+	that describes the farthest failure in prose, e.g. "while parsing Stmt
+	in File, expected "=" after Ident at line 3, column 5": the rules being
+	matched there, innermost first, the tokens expected and the last rule
+	matched by the innermost rule before the failure, if only white space
+	follows it. The -bounded-stream flag is not supported (default:
+	false).
+
+	-explicit-stack : boolean, if set, the rules are compiled to the table
+	of instructions of the -table-driven flag, which is implied, and the
+	generated parser runs the instructions with an explicit work stack,
+	and the rules they reference with the same loop, instead of recursive
+	calls. The nesting of the input the parser can parse is then only
+	limited by the memory available, not by the size of the Go stack. It
+	does not support the flags that the -table-driven flag does not
+	support, nor those that wrap the matching of the rules:
+	-support-left-recursion, -optimize-rules, -rule-timing,
+	-chrome-trace, -structured-trace, -memo-key-hook, -mode-aware-memo,
+	-backtrack-hooks and -runtime-params. The parsers generated with those
+	flags can limit the nesting of their inputs with the MaxVStackDepth
+	option. The debug output shows the instructions that are run
+	(default: false).
+
+	-expvar-metrics=NAMESPACE : string, if set, the generated parser publishes
+	the metrics of its parses with the expvar package, in a map named
+	NAMESPACE created on the first parse if it does not exist: the counters
+	"parses", "errors" and "duration_ns", the "avg_duration_ns" computed from
+	them, and for the memoized results the counters "memo_lookups" and
+	"memo_hits" and the "memo_hit_rate" computed from them. They are updated
+	by each parse of an input, whatever its entry point, and by each attempt
+	of fuzzy matching, the memo ones only when the parser memoizes. The
+	expvar package serves them over HTTP on /debug/vars, e.g. for a
+	Prometheus exporter (default: "").
+
+	-fuzzy-match=N : int, if greater than 0, the generated parser retries a
+	failed parse allowing up to N single-character corrections (insertion,
+	deletion, substitution or transposition) when matching literals. Code
+	blocks run again on each attempt. The corrections are stored by the
+	Corrections parser option (default: 0).
+
+	-grammar-line-map : boolean, if set, the generated parser has a
+	GrammarLines function that returns the first and last lines of each rule
+	in the grammar file, by rule name, e.g. to highlight the lines of the
+	rules that were covered by the inputs parsed by a test suite. With the
+	-rule-interface option, the rule type also has a Lines method returning
+	them (default: false).
+
+	-grapheme-input : boolean, if set, the generated parser has a ParseRunes
+	function that parses an already decoded []rune over its grapheme
+	clusters, for text-editor-like grammars. The any matcher (.) then
+	matches a whole user-perceived character, e.g. a letter followed by
+	combining marks, an emoji with a skin tone modifier or a flag, and the
+	columns of the positions count the grapheme clusters instead of the
+	runes. The other matchers still match runes, and the Parse functions
+	are not affected (default: false).
+
 	-nolint: add '// nolint: ...' comments for generated parser to suppress
 	warnings by gometalinter (https://github.com/alecthomas/gometalinter) or
 	golangci-lint (https://golangci-lint.run/).
@@ -43,6 +344,131 @@ The following options can be specified:
 	to access the panic stack when debugging, otherwise the panic
 	is converted to an error (default: false).
 
+	-guard-optimization : boolean, if set, an and ("&") or not ("!") predicate
+	that directly wraps a literal or a character class, as in !"keyword",
+	peeks at the input instead of going through the general matching and
+	backtracking code (default: false).
+
+	-label-spans : boolean, if set, the generated parser records the start
+	and end offsets of the input matched by each labeled expression, see
+	section "Labeled expression" (default: false).
+
+	-left-recursive-rules=RULE[,RULE...] : string, comma-separated list of
+	rule names that are intentionally left-recursive. The generated parser
+	supports the left recursion of those rules only, as with
+	-support-left-recursion, and the left recursion of the other rules is
+	an error, even if -support-left-recursion is set. This catches the
+	accidental left recursion of a grammar that has some intended left
+	recursion. A listed rule that is not found to be left-recursive is
+	matched as a left-recursive rule anyway (default: none).
+
+	-lazy-rule-init : boolean, if set, the expression of each rule of the
+	generated parser is built by a function of the rule the first time the
+	rule is matched, instead of when the program starts, so that programs
+	that only use some of the rules of a large grammar start faster. The
+	expressions are built once, even by concurrent parsers
+	(default: false).
+
+	-legacy-go-compat : boolean, if set, the generated parser uses
+	interface{} instead of the predeclared any type, so that it compiles
+	with the Go versions before 1.18. The references to any in the code
+	blocks of the grammar are replaced too (default: false).
+
+	-line-recovery : boolean, if set, the generated parser has a ParseLines
+	function that parses its input as a sequence of items matched by the
+	entrypoint rule, each from the end of the previous one. When an item
+	fails to match, or its code blocks return errors, the input is skipped
+	up to the next newline and the parsing resumes on the next line, so
+	that a malformed line does not prevent parsing the following ones. It
+	returns the values of the items that matched and the errors of those
+	that failed. This is not supported with -method-receiver
+	(default: false).
+
+	-locale-fold=LOCALE : string, the locale of the case folding of the
+	case-insensitive literals and character classes, both in the generated
+	parser and in the case variants written by -expand-ignore-case-classes.
+	The supported locales are "tr" (Turkish) and "az" (Azeri), where the
+	dotted and dotless i are distinct letters: "I"i matches "ı" but not
+	"i", and "i"i matches "İ" but not "I". By default, the case is folded as
+	defined by Unicode.
+
+	-longest-match-diagnostics : boolean, if set, the generated parser, when
+	run with the Debug option, also tries the alternatives that follow the
+	one that matched in an ordered choice, and logs those that would have
+	matched a strictly longer input. This helps to find choices that are
+	not ordered as intended, e.g. "<" / "<=". It has no effect with the
+	-optimize-parser flag (default: false).
+
+	-lossless-cst : boolean, if set, the generated parser has a ParseCST
+	function that returns the concrete syntax tree of the input matched by
+	the entrypoint rule, a tree of CSTNode values retaining all the matched
+	input, such as whitespace and punctuation: writing the tree with its
+	WriteTo method reproduces the input byte for byte. The tree can be
+	transformed before it is written: a node whose Text is replaced writes
+	its new Text instead of its children, and the other nodes the input
+	they matched (default: false).
+
+	-matcher-interface : boolean, if set, each expression type of the
+	generated parser implements the unexported matcher interface, with a
+	match(p *parser) (any, bool) method, and the parser parses the
+	expressions by calling it instead of through a type switch. Code in the
+	package of the parser can then add custom expression types to the
+	grammar, at the cost of slightly slower parsing (default: false).
+
+	-memo-key-hook : boolean, if set, the generated parser has a MemoKey
+	option to set a function that returns a discriminator, e.g. a parser
+	mode stored in the state, added to the position and expression in the
+	key of the memoized results. Memoization is only sound if the result of
+	an expression depends on nothing but its position and this
+	discriminator. This is not supported with -optimize-parser, which does
+	not memoize (default: false).
+
+	-memo-reuse-trace : boolean, if set, the generated parser has a
+	MemoReuses option to set a slice in which it stores each memoized
+	result that it reused instead of matching the expression again, as a
+	MemoReuse with the name of the rule, or of the rule of the expression,
+	and the position where it was reused. The results are only memoized
+	with the Memoize option. This is not supported with -optimize-parser
+	(default: false).
+
+	-method-receiver=TYPE : string, if set, the Parse, ParseFile and
+	ParseReader entrypoints of the generated parser, and ParseMmap and
+	ParseStream if they are generated, are methods of *TYPE instead of
+	functions, so that the parsing behavior can be embedded in a larger
+	type. TYPE must be defined in the package of the parser, e.g. in the
+	initializer of the grammar. The code blocks get the receiver of the
+	method with c.recv, e.g. to read its fields during parsing. The
+	-batch-parse, -repl-mode, -emit-validate, -lossless-cst, -compact-ast,
+	-sink-results, -sub-parse, -line-recovery, -wasm-exports,
+	-enumerate-parses, -completion-support and -push-parser options are not
+	supported (default: "").
+
+	-mmap-input : boolean, if set, the generated parser has a ParseMmap
+	function that parses a file by mapping it in memory with syscall.Mmap
+	instead of reading it, which saves the copy of huge files. The values
+	returned may reference the mapped input, e.g. the []byte values of the
+	expressions without action, so ParseMmap also returns a release
+	function that removes the mapping once they are no longer used. A file
+	that cannot be mapped, e.g. an empty file or a pipe, is read instead.
+	As syscall.Mmap is only available on Unix platforms, ParseMmap is
+	written to a file next to the output file, named after it with the
+	_mmap_unix.go suffix, that is only built for those, and the
+	_mmap_other.go file has the ParseMmap function of the other platforms,
+	which reads the file. It requires the -o flag (default: false).
+
+	-mode-aware-memo : boolean, if set, each state code block that runs
+	starts a new mode generation of the parser, stored in the state under
+	the "pigeon.modeGen" key, and the memoized results are keyed by the
+	generation in which they were parsed. The results memoized before a
+	state code block changed the mode of the parser are thus ignored, and
+	memoization stays sound when predicates depend on the state. It has no
+	effect if the grammar has no state code block, and it is not supported
+	with -optimize-parser, which does not memoize (default: false).
+
+	-numeric-helpers : boolean, if set, generate the int, float and uint
+	helper methods on the "*current" type, see section "Code block"
+	(default: false).
+
 	-o=FILE : string, output file where the generated parser will be
 	written (default: stdout).
 
@@ -75,13 +501,154 @@ The following options can be specified:
 	and predicate code blocks. This saves a few cpu cycles, when using the generated
 	parser (default: false).
 
+	-optimize-rules=RULE[,RULE...] : string, comma-separated list of rule
+	names that the generated parser matches without debug output nor
+	memoization, while the other rules keep the Debug and Memoize options
+	passed to Parse. This keeps the hot rules fast while debugging the
+	others. It has no effect with the -optimize-parser flag (default: none).
+
+	-optimize-rules-except : boolean, if set, all the rules are optimized
+	except those listed with the -optimize-rules flag (default: false).
+
 	-x : boolean, if set, do not build the parser, just parse the input grammar
 	(default: false).
 
+	-otel-spans : boolean, if set, the generated parser has a WithContext
+	option to parse in a context, e.g. the context of a request, and a
+	ContextWithTracer function returning a context that carries a
+	SpanTracer. The parses in such a context are wrapped in a span named
+	"parse", with the attributes parse.filename, parse.entrypoint,
+	parse.input_size and parse.error, and whose error is recorded if the
+	parse fails, or panics without the Recover option. SpanTracer and Span are interfaces of the generated parser,
+	so that it does not depend on OpenTelemetry: an OpenTelemetry tracer is
+	used through a small adapter. A parse with no context or no tracer
+	records no span (default: false).
+
+	-overridable-actions : boolean, if set, the action code blocks are called
+	through a map of functions keyed by the name of their generated function
+	(e.g. "onRule1"), and the generated parser has a SetAction option to
+	replace an implementation at runtime, e.g. for plugins. Each action then
+	costs a map lookup and a variadic function call (default: false).
+
+	-preprocessors : boolean, if set, the generated parser has a
+	Preprocess(...Preprocessor) option that registers preprocessing stages
+	run in order on the input before parsing it, e.g. to strip the comments
+	or join the continued lines. Each stage is a
+	func([]byte) ([]byte, PositionMap) that returns its output and the map
+	of the offsets of its output to those of its input. The parser composes
+	the maps, so that the errors report their position in the input before
+	preprocessing. The positions seen by the code blocks are those of the
+	preprocessed input (default: false).
+
+	-progress-callback : boolean, if set, the generated parser has a Progress
+	option to call a function every time the parser advances by a given
+	number of bytes in the input, e.g. to display a progress indicator when
+	parsing large files (default: false).
+
+	-proto-results=NAME : string, the name of a protobuf message describing
+	the generic parse results, i.e. the []byte text matched by the
+	literals, character classes and any matchers, the []any list of the
+	results of the sequences and repetitions, and nil. If set, a .proto
+	file with the message is written next to the output file, e.g.
+	parser.proto for -o parser.go, and the generated parser has a NAME
+	type mirroring the message, with MarshalBinary and UnmarshalBinary
+	methods encoding it in the protobuf wire format, and the ToProto and
+	FromProto functions converting between the parse results and the
+	messages. This allows to exchange the parse results, e.g. over gRPC,
+	without depending on a protobuf library. It requires the -o flag
+	(default: none).
+
+	-push-parser : boolean, if set, the generated parser has a PushParser
+	type for inputs that arrive in chunks, e.g. from a socket. Its Feed
+	method adds a chunk to the buffered input and parses the items matched
+	by the entrypoint rule, one after the other, as soon as they are
+	complete, i.e. matched without reaching the end of the buffered input,
+	which more input could extend. An item may thus be split across chunks.
+	Its Flush method parses the rest of the input as its end, and returns
+	the values of the items and the first error. The entrypoint rule should
+	not match the end of the input. The positions of the errors are in the
+	whole input, but the positions of the matches in the code blocks are
+	relative to the start of their item. The -error-spans and
+	-bounded-stream flags are not supported (default: false).
+
 	-receiver-name=NAME : string, name of the receiver variable for the generated
 	code blocks. Non-initializer code blocks in the grammar end up as methods on the
 	*current type, and this option sets the name of the receiver (default: c).
 
+	-repl-mode : boolean, if set, the generated parser has a REPL type for
+	interactive shells. Its Feed method adds a line of input to the input
+	accumulated so far and parses it, reporting whether it is complete
+	(REPLComplete), incomplete because the parsing failed at the end of the
+	input, so that the shell should prompt for more (REPLIncomplete), or in
+	error because it failed before (REPLError) (default: false).
+
+	-report-max-depth : boolean, if set, the generated parser has a
+	ReachedDepth option that records in a Depth the deepest nesting of rules
+	and of the value stack reached while parsing, the latter being limited
+	by the MaxVStackDepth option. Parsing a corpus of representative inputs
+	with the same Depth tells how to set this limit safely (default: false).
+
+	-rule-boundary-positions : boolean, if set, the generated parser has a
+	RulePositions option that records the name and the start and end
+	positions of the rules in the result of the parsing, in the order their
+	matches end. The expressions inside the rules are not tracked and the
+	rules matched on paths that were backtracked are dropped, so that
+	tools get the positions of the syntax tree at a fraction of the cost of
+	tracking them in the code blocks. The results are not memoized when
+	the positions are recorded, and left recursion is not supported
+	(default: false).
+
+	-rule-interface=IMPORTPATH.NAME : string, the generated parser imports the
+	package IMPORTPATH and its rule type implements the interface NAME of this
+	package, e.g. to handle the rules of many generated parsers in the same
+	way. The rule type has the Name() string, DisplayName() string and
+	Position() (line, col, offset int) methods, and the interface may have any
+	subset of them. The Rules() function returns the rules of the grammar as
+	this interface. The initializer must have a package clause
+	(default: none).
+
+	-rule-timing : boolean, if set, the generated parser has a RuleTimes
+	option to accumulate the wall-clock time spent in each rule, including
+	the rules it calls, to find the slowest rules without a profiler. Each
+	call of a rule then reads the clock twice (default: false).
+
+	-run-char-class : boolean, if set, a repetition ("+" or "*") that directly
+	wraps a character class is matched in a single loop instead of one
+	expression per character, which is much faster to scan identifiers or
+	numbers. The value of such a repetition is the matched []byte instead of
+	a slice of values (default: false).
+
+	-runtime-params : boolean, if set, the rule references of the grammar
+	may pass arguments to the rules they reference when they are matched,
+	which the code blocks read with c.param, see the "Match-time
+	arguments" section. The -table-driven flag does not support them
+	(default: false).
+
+	-self-test-fail=FILE[,FILE...] : string, comma-separated list of files
+	whose content is embedded in the generated parser as inputs that must
+	fail to parse. If -self-test-fail or -self-test-pass is set, the
+	generated parser has a SelfTest function that parses the embedded
+	inputs and returns an error if one of them does not parse or fail as
+	expected, e.g. to catch the regressions of the grammar at startup or
+	in a test (default: none).
+
+	-self-test-pass=FILE[,FILE...] : string, comma-separated list of files
+	whose content is embedded in the generated parser as inputs that must
+	parse, see -self-test-fail. An input cannot be in both lists (default:
+	none).
+
+	-sink-results : boolean, if set, the generated parser has a ParseTo
+	function that pushes each value of the top-level repetition of the
+	entrypoint rule (e.g. Item* in File <- Item* EOF) to a sink function as
+	soon as it is parsed, to process the results in a pipeline. Parsing
+	stops early if the sink function returns an error (default: false).
+
+	-sort-functions : boolean, if set, the functions generated for the code
+	blocks are written after the code of all the rules, sorted by name,
+	instead of in the order the rules are visited. This keeps the diff of
+	the generated parser small when the grammar is reorganized
+	(default: false).
+
 	-alternate-entrypoints=RULE[,RULE...] : string, comma-separated list of rule names
 	that may be used as alternate entrypoints for the parser, in addition to the
 	default entrypoint (the first rule in the grammar) (default: none).
@@ -90,12 +657,147 @@ The following options can be specified:
 	necessary if the -optimize-parser flag is set, as some rules may be optimized
 	out of the resulting parser.
 
+	-stream-buffer=N : int, if greater than 0, the generated parser has a
+	ParseChan function that runs ParseTo in a goroutine and sends the
+	values pushed to the sink to a channel with a buffer of N values. The
+	parsing blocks while the buffer is full, so that the memory used by the
+	values is bounded if the consumer is slower than the parser. The
+	consumer closes a done channel to stop the parsing if it stops
+	receiving the values. Requires -sink-results (default: 0).
+
+	-structured-trace : boolean, if set, the generated parser has a Trace
+	option that reports each rule entered and exited to an implementation
+	of the generated TraceLogger interface, with the position of the rule
+	and whether it matched. Unlike the Debug option that prints to stdout,
+	it can be adapted to a structured logger such as log/slog (default:
+	false).
+
+	-sub-parse : boolean, if set, the generated parser has a ParseSub
+	function that parses its input from a byte offset with the rule of a
+	name, and returns the value of the rule and the offset of the end of its
+	match. The input that follows the match is not parsed, so that a
+	language embedded in another one, e.g. a query in a string literal, is
+	parsed in place with the rules of its own grammar (default: false).
+
 	-support-left-recursion : boolean, (EXPERIMENTAL FEATURE) if set, add support
 	for left recursion rules, including those with indirect recursion
 	(default: false).
 	E.g.:
 		expr = expr '*' term / expr '+' term
 
+	-switch-dispatch : boolean, if set, each action, predicate and state
+	code block is assigned an integer code, and the generated parser calls
+	its function by a switch on its code instead of through a method value
+	stored in the grammar (default: false).
+
+	-table-driven : boolean, if set, the expressions of the rules are
+	compiled to a flat table of instructions interpreted by the generated
+	parser, instead of a tree of expression structs. The parser returns the
+	same results and errors. The recovery, throw, until, lookbehind,
+	balanced, scan limit, line start and assertion expressions, and the
+	-label-spans, -sink-results, -lossless-cst, -compact-ast and
+	-lazy-rule-init options are not supported (default: false).
+
+	-typed-throws : boolean, if set, the throw expressions may have a code
+	block whose value is thrown as payload with the failure label, to attach
+	structured diagnostic data to the failure, see section "Failure labels,
+	throw and recover" below (default: false).
+
+	-until-eof : boolean, if set, an until expression (see Until expression
+	below) whose terminator is not found matches up to the end of the input
+	instead of failing (default: false).
+
+	-user-data : boolean, if set, the generated parser has a UserData option
+	to attach a value of any type to the parse, e.g. its configuration,
+	which the code blocks get with c.data(), instead of reading it from a
+	global variable or from the receiver of -method-receiver. The value is
+	never rolled back by the parser, and each parse starts with the value of
+	its options, so that it does not leak from one parse to the next, e.g.
+	in a REPL after Reset (default: false).
+
+	-validate-actions : boolean, if set, the code blocks of the grammar are
+	parsed as Go code when the parser is generated, and the first syntax
+	error is reported at its position in the grammar with the name of the
+	rule, instead of at its position in the generated code when it is
+	compiled. The type errors are still reported by the compiler (default:
+	false).
+
+	-warn-empty-repetition : boolean, if set, a warning is printed on stderr
+	for each repetition ("*" or "+") of an expression that can match the
+	empty string, e.g. ( "a"? )*, with the position of the repetition and
+	the name of its rule. Once such an expression matches without consuming
+	input, the repetition never stops and the generated parser loops
+	forever (default: false).
+
+	-warn-shadowed-classes : boolean, if set, a warning is printed on stderr
+	for each alternative of a choice expression that can only start with
+	characters that an earlier alternative starting with a character class
+	can also start with, e.g. [a-m]+ '=' after [a-z]+ ';', with the
+	positions of both alternatives and the name of their rule. The later
+	alternative is then only tried when the earlier one fails after its
+	first character. The characters that can start the alternatives are
+	computed as for -compute-follow-sets, and the alternatives that can
+	match the empty string are ignored (default: false).
+
+	-warn-shadowed-recovery : boolean, if set, a warning is printed on
+	stderr for each recovery expression that can match the same input as
+	the expression it recovers, with the position of the recovery
+	expression, the name of its rule and the common tokens. Such a recovery
+	may silently accept input that should have been reported as an error.
+	The overlap is approximated with the tokens that can start the
+	expressions, as computed for -compute-follow-sets (default: false).
+
+	-warn-unused-labels : boolean, if set, a warning is printed on stderr
+	for each labeled expression whose label is not referenced by any of the
+	code blocks that receive it, with the position of the expression and the
+	name of its rule. The code blocks are scanned as Go code, so a label that
+	only appears in a string or a comment is not referenced, but no warning
+	is printed for the labels of a code block that cannot be scanned
+	(default: false).
+
+	-wasm-exports : boolean, if set, the generated parser has a ParseJSON
+	function that parses the "input" of a JSON request, with an optional
+	"filename" and "entrypoint" rule, and returns a JSON response with the
+	"value" returned by the parser and the "errors" messages. A file is
+	written next to the output file, named after it with the _js_wasm.go
+	suffix, that is only built for GOOS=js GOARCH=wasm and sets a
+	JavaScript global object named after the package of the parser, whose
+	parse method calls ParseJSON with strings. The main package of the
+	WebAssembly module must import the parser and block so that the method
+	remains available. It requires the -o flag and is not supported with
+	-method-receiver (default: false).
+
+	-zero-copy-text : boolean, if set, the "*current" type has a textBytes
+	method that returns the text of the current match without copying it
+	(see Code block below) (default: false).
+
+	-rule-error-message=RULE=MESSAGE : string, custom error message reported
+	when RULE fails at the farthest position reached by the parser, instead of
+	the list of expected tokens. The innermost such rule wins. The flag may be
+	repeated, and RULE must be a rule of the grammar (default: none).
+	E.g.:
+		-rule-error-message 'Stmt=expected a statement'
+
+	-rule-assertion=RULE=EXPR : string, Go boolean expression over the value
+	of RULE, named v, that the generated parser checks each time RULE
+	matches. If it is false, the parser reports an error with the assertion
+	and the value, to catch the grammar changes that break an invariant of
+	the results. The assertions are not generated with -optimize-parser, so
+	that they are only checked by the parser used in the tests. The flag
+	may be repeated, and RULE must be a rule of the grammar (default: none).
+	E.g.:
+		-rule-assertion 'Int=v.(int) >= 0'
+
+	-prefix-dispatch=PREFIX=RULE : string, literal prefix of the input that
+	selects RULE as the entrypoint of the generated parser, for formats
+	whose first bytes, e.g. a version header, pick a variant of the grammar.
+	The longest prefix that starts the input wins, and the parser falls back
+	to the first rule if none does. An entrypoint set to another rule with
+	the Entrypoint option takes precedence. The flag may be repeated, and
+	RULE must be a rule of the grammar, which is kept by -optimize-grammar
+	(default: none). E.g.:
+		-prefix-dispatch 'v1:=DocV1' -prefix-dispatch 'v2:=DocV2'
+
 If the code blocks in the grammar (see below, section "Code block") are golint-
 and go vet-compliant, then the resulting generated code will also be golint-
 and go vet-compliant.
@@ -136,6 +838,67 @@ be specified after the rule identifier. E.g.:
 The rule definition operator can be any one of those:
 	=, <-, ← (U+2190), ⟵ (U+27F5)
 
+Parameterized rules
+
+A rule can declare a list of parameters, enclosed in parentheses right
+after its identifier. Such a rule is a template: it is not generated as is,
+and must be called with one argument per parameter, with the opening
+parenthesis immediately following the rule identifier. An argument is
+either a rule reference or a literal, and it replaces the references to
+the corresponding parameter in the body of the rule. E.g.:
+	List(Elem, Sep) = Elem ( Sep Elem )*
+	Idents = List(Ident, ',')
+	Numbers = List(Number, ';')
+
+Each distinct set of arguments produces a concrete rule named after the
+parameterized rule followed by an instance number (e.g. List_1), with the
+call itself - e.g. `List(Ident, ",")` - as display name unless the
+parameterized rule has its own display name.
+
+A rule that has no parameters followed by a single parenthesized rule
+reference or literal keeps its meaning of a reference followed by a
+parenthesized expression, e.g. B(C) matches B and then C, as in the
+grammars written before the parameterized rules.
+
+Match-time arguments
+
+With the -runtime-params flag, a rule reference can pass arguments to the
+rule it references, enclosed in parentheses right after the rule
+identifier, unlike the arguments of parameterized rules that are
+substituted when the parser is built. An argument is a name, an equal
+sign and either a label, whose value is passed, or a code block, which
+gets the labels in scope like an action and returns the value. The code
+blocks of the referenced rule, and of the rules it references in turn,
+get the value with c.param(name), nil if no rule being matched has this
+argument. The arguments of a labeled reference, e.g. items:Items(count=n),
+get the labels in scope of the label. This allows context-sensitive grammars, e.g. a list of items
+prefixed with their count:
+	List = n:Count ':' Items(count=n) !.
+	Items = items:Item* &{ return len(items.([]any)) == c.param("count").(int), nil }
+	Double = n:Count Items(count={ return n.(int) * 2, nil })
+
+The arguments are evaluated each time the rule is referenced, and the
+rules matched while a rule with arguments is being matched are not
+memoized, as their results may depend on the arguments. The results of
+the left-recursive rules, which must be memoized to grow the seed, are
+only reused for the same reference to the rule with arguments.
+
+Macros
+
+A macro is defined like a rule, but with the := operator. It is not
+generated as a rule: each reference to a macro is replaced with a copy of
+its expression when the parser is built, so that it is matched inline
+without the call overhead of a rule (and without its memoization). E.g.:
+	Digits := [0-9]+
+	Version = Digits '.' Digits
+	Int = '-'? Digits
+
+A macro can reference rules and other macros, but it cannot reference
+itself, directly or indirectly, and it cannot have parameters or a display
+name. As its expression is inlined, the labels of a macro are in the scope
+of the code blocks of the referencing rule. A macro cannot be used as an
+argument of a parameterized rule nor as an alternate entrypoint.
+
 Expressions
 
 A rule is defined by an expression. The following sections describe the
@@ -154,6 +917,16 @@ of expressions. For example, in this rule, "<=" would never be used because
 the "<" expression comes first:
 	BadChoiceExpr = "<" / "<="
 
+An alternative may be prefixed with "@" and a number of bytes N to bound
+how far it may scan: the input is seen as ending N bytes past the start
+of the alternative while it is matched, and the alternative fails as soon
+as it reaches that bound, unless it is the end of the input. A bounded
+alternative thus matches less than N bytes, and gives up quickly instead
+of scanning the rest of the input when it cannot match. The results
+memoized while matching an alternative that reached its bound are
+discarded. E.g.:
+	Part = @64 '<' [^>]* '>' / Text // a tag must be closed within 64 bytes
+
 Sequence expression
 
 The sequence expression is a list of expressions that must all match in
@@ -207,6 +980,15 @@ is used. E.g.:
 	}
 	RuleB = label:RuleA { // label is int }
 
+If the parser is generated with the -label-spans flag, action code blocks
+can also retrieve the start and end offsets in the input of the text
+matched by a label with c.labelSpan, which avoids converting the value
+back to text. E.g.:
+	Rule = key:[a-z]+ '=' value:[0-9]+ {
+		start, end, _ := c.labelSpan("value")
+		return [2]int{start, end}, nil
+	}
+
 And and not expressions
 
 An expression prefixed with the ampersand "&" is the "and" predicate
@@ -229,6 +1011,30 @@ defined in its scope. E.g.:
 		return true, nil
 	}
 
+Until expression
+
+An expression prefixed with the tilde "~" is the "until" expression: it
+matches any input up to, but not including, the first match of the
+following expression, the terminator. It is equivalent to ( !Term . )*
+but much faster, especially when the terminator is a case-sensitive
+literal, which is searched in the input. Its value is the matched input,
+as a []byte. If the terminator is not found, it does not match, unless
+the -until-eof option is set, in which case it matches up to the end of
+the input. E.g.:
+	Comment = "<!--" ~"-->" "-->" // the value of ~"-->" is the comment body
+
+Lookbehind expression
+
+An expression enclosed in "(?<=" and ")" is a lookbehind expression: it
+matches if the input that immediately precedes the current position
+matches the enclosed expression, without consuming any input. The
+enclosed expression must match a fixed number of characters, so it can
+only be made of literals, character classes and any matchers, in
+sequences or in choices of alternatives of the same length, otherwise
+building the parser fails. The failures to match the enclosed expression
+are not reported in the list of expected tokens. E.g.:
+	Amount = ( (?<= "$" ) [0-9] / . )+ // a digit only if preceded by $
+
 Repeating expressions
 
 An expression followed by "*", "?" or "+" is a match if the expression
@@ -259,7 +1065,10 @@ square bracket "]" must be escaped to be used.
 Character ranges can be specified using the "[a-z]" notation. Unicode
 classes can be specified using the "[\pL]" notation, where L is a
 single-letter Unicode class of characters, or using the "[\p{Class}]"
-notation where Class is a valid Unicode class (e.g. "Latin").
+notation where Class is a valid Unicode class: a general category (e.g.
+"Lu"), a property (e.g. "White_Space") or a script (e.g. "Latin" or
+"Han"), as defined in the unicode package. An unknown class is an error
+when the grammar is parsed.
 
 As for string literals, a lowercase "i" may follow the matcher (outside
 the ending square bracket) to indicate that the match is case-insensitive.
@@ -276,6 +1085,48 @@ the end of file". E.g.:
 	AnyChar = . // match a single character
 	EOF = !.
 
+Line start matcher
+
+The line start matcher is represented by the caret "^". It does not consume
+any input, it succeeds only if the current position is at the start of the
+input or immediately after a newline character. E.g.:
+	Heading = ^ '#' [^\n]*
+
+Builtin assertions
+
+With the -builtin-assertions option, the grammar may have zero-width
+assertions on the characters around the current position, which do not
+consume any input, as the line start matcher. The word boundary "\b"
+succeeds between a word character, an ASCII letter, digit or underscore,
+and another character or the start or end of the input. The line end "$"
+succeeds at the end of the input or right before a newline character.
+E.g.:
+	Keyword = "if" \b
+	Comment = "#" [^\n]* $
+
+Balanced matcher
+
+The balanced matcher is represented by two string literals, the open and
+the close delimiters, enclosed in angle brackets "<" and ">". It matches
+the open delimiter, followed by any input up to the matching close
+delimiter, skipping the nested pairs of delimiters. Its value is the input
+between the outermost delimiters, as a []byte. It fails if the input ends
+before the delimiters are balanced. The delimiters must be different,
+non-empty and not case-insensitive. E.g.:
+	Block = < '{' '}' >          // matches "{ a { b } c }", value " a { b } c "
+	PascalComment = < "(*" "*)" > // comments can be nested
+
+Case-insensitive region
+
+A case-insensitive region is an expression enclosed in "(?i:" and ")".
+The literal and character class matchers in the region are
+case-insensitive, as if they all had the "i" flag, but the rules it
+references are left unchanged. It requires the -case-scopes option, and
+the expressions it contains are not optimized by -optimize-grammar. E.g.:
+	Select = (?i: "select" ) _ Ident _ (?i: "from" ) _ Ident
+	Ident = [a-z]+
+matches "SELECT x FROM t" and "select x from t", but not "select X from t".
+
 Code block
 
 Code blocks can be added to generate custom Go code. There are three kinds
@@ -310,6 +1161,42 @@ parser will return. E.g.:
 		return string(c.text), nil
 	}
 
+If the parser is generated with the -numeric-helpers flag, the "*current"
+type has int, float and uint(base) methods that parse the text matched by
+the current expression (c.text) and return the value and the strconv error,
+if any. E.g.:
+	Integer = [0-9]+ {
+		return c.int()
+	}
+
+If the parser is generated with the -zero-copy-text flag, the "*current"
+type has a textBytes method that returns the text matched by the current
+expression as a subslice of the input, which saves the allocation of
+string(c.text) for code blocks that can work with a []byte. It is only
+valid during the call of the code block, and must not be modified.
+
+If the parser is generated with the -choice-index flag, the "*current"
+type has a choiceIndex method that returns the index of the alternative
+that matched when the expression of the action is a choice expression, or
+-1 otherwise. E.g.:
+	Sign = ( '+' / '-' / "plus" / "minus" ) {
+		return c.choiceIndex()%2 == 0, nil
+	}
+
+If the parser is generated with the -backtrack-hooks flag, the "*current"
+type has an onBacktrack method that registers a function to call if the
+parser backtracks over the match of the action, i.e. if an enclosing
+sequence or alternative fails, or if it is in a predicate. The functions
+are called as soon as the parser backtracks, in the reverse order of their
+registration. As the parser may backtrack anywhere, they must be cheap and
+must not panic, and the results of the rules whose actions register
+functions are not memoized. E.g.:
+	Open = "open" _ name:Name {
+		f := acquire(name.(string))
+		c.onBacktrack(func() { f.Release() })
+		return f, nil
+	}
+
 Predicate code blocks are code blocks declared immediately after the and "&"
 or the not "!" operators. Like action code blocks, predicate code blocks
 are turned into a method on the "*current" type in the generated source code.
@@ -447,6 +1334,17 @@ If throw and recover expressions are used together with global state, it is the
 responsibility of the author of the grammar to reset the global state to a valid
 state during the recovery operation.
 
+With the -typed-throws option, the throw expression may have a code block after
+the failure label, whose value is thrown with the label as the payload of a
+*ThrowError. The code block is like the code block of an action, it gets the
+labeled values of its scope and returns the payload and an error. E.g.:
+	Number = [0-9]+ / %{ErrNum { return Diag{Msg: "expected a number"}, nil }}
+The code blocks of the recovery expression get the throw they recover with
+c.thrown(). If the parse fails, the farthest throw with a payload that is not
+recovered is reported as a parsing error whose Inner error is the *ThrowError,
+at the position of the throw. It is not reported if a later alternative
+matches, e.g. after the failure of the alternative of the throw.
+
 	[7]: https://arxiv.org/pdf/1405.6646v3.pdf
 	[8]: https://github.com/sqmedeiros/lpeglabel
 
@@ -455,21 +1353,55 @@ Using the generated parser
 The parser generated by pigeon exports a few symbols so that it can be used
 as a package with public functions to parse input text. The exported API is:
 	- Parse(string, []byte, ...Option) (any, error)
+	- MustParse([]byte, ...Option) any (only with -emit-must-parse)
+	- ParseTyped(string, []byte, ...Option) (TYPE, error) (only with -entrypoint-type)
 	- ParseFile(string, ...Option) (any, error)
+	- ParseMmap(string, ...Option) (any, func() error, error) (only with -mmap-input)
+	- ParseBatch([][]byte, int, ...Option) ([]Result, error) (only with -batch-parse)
+	- ParseCST(string, []byte, ...Option) (*CSTNode, error) (only with -lossless-cst)
+	- ParseCompact(string, []byte, ...Option) (*CompactTree, error) (only with -compact-ast)
+	- Validate([]byte, ...Option) error (only with -emit-validate)
+	- NewREPL(...Option) *REPL (only with -repl-mode)
+	- NewPushParser(...Option) *PushParser (only with -push-parser)
+	- ContextWithTracer(context.Context, SpanTracer) context.Context (only with -otel-spans)
 	- ParseReader(string, io.Reader, ...Option) (any, error)
+	- ParseTo(string, []byte, func(any) error, ...Option) error (only with -sink-results)
+	- ParseChan(string, []byte, <-chan struct{}, ...Option) (<-chan any, <-chan error) (only with -stream-buffer)
+	- ParseSub(string, []byte, int, ...Option) (any, int, error) (only with -sub-parse)
+	- ParseLines(string, []byte, ...Option) ([]any, error) (only with -line-recovery)
+	- ParseJSON([]byte) []byte (only with -wasm-exports)
+	- ParseAll([]byte, ...Option) ([]any, error) (only with -enumerate-parses)
+	- Suggest([]byte, int, ...Option) []string (only with -completion-support)
 	- AllowInvalidUTF8(bool) Option
 	- Debug(bool) Option
 	- Entrypoint(string) Option
 	- GlobalStore(string, any) Option
 	- MaxExpressions(uint64) Option
+	- MaxVStackDepth(int) Option
 	- Memoize(bool) Option
+	- MemoKey(func(*current) any) Option (only with -memo-key-hook)
+	- MemoReuses(*[]MemoReuse) Option (only with -memo-reuse-trace)
+	- RulePositions(*[]RulePosition) Option (only with -rule-boundary-positions)
+	- Preprocess(...Preprocessor) Option (only with -preprocessors)
+	- SetAction(string, ActionFunc) Option (only with -overridable-actions)
+	- RuleTimes(map[string]time.Duration) Option (only with -rule-timing)
+	- ReachedDepth(*Depth) Option (only with -report-max-depth)
+	- Progress(int, func(int, int)) Option (only with -progress-callback)
+	- Trace(TraceLogger) Option (only with -structured-trace)
+	- ChromeTrace(io.Writer) Option (only with -chrome-trace)
+	- WithContext(context.Context) Option (only with -otel-spans)
 	- Recover(bool) Option
 	- Statistics(*Stats) Option
+	- UserData(any) Option (only with -user-data)
 
 See the godoc page of the generated parser for the test/predicates grammar
 for an example documentation page of the exported API:
 http://godoc.org/github.com/mna/pigeon/test/predicates.
 
+With the -method-receiver option, the Parse* functions that parse an input
+with the grammar are methods of the pointer to the specified type instead,
+e.g. func (r *T) Parse(string, []byte, ...Option) (any, error).
+
 Like the grammar used to generate the parser, the input text must be
 UTF-8-encoded Unicode.
 
@@ -88,13 +88,13 @@ var g = &grammar{
 							pos:   position{line: 61, col: 10, offset: 1218},
 							label: "expr",
 							expr: &ruleRefExpr{
-								pos:  position{line: 61, col: 15, offset: 1223},
-								name: "Expr",
+								pos:    position{line: 61, col: 15, offset: 1223},
+								offset: 1,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 61, col: 20, offset: 1228},
-							name: "EOF",
+							pos:    position{line: 61, col: 20, offset: 1228},
+							offset: 8,
 						},
 					},
 				},
@@ -110,15 +110,15 @@ var g = &grammar{
 					pos: position{line: 66, col: 9, offset: 1286},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 66, col: 9, offset: 1286},
-							name: "_",
+							pos:    position{line: 66, col: 9, offset: 1286},
+							offset: 7,
 						},
 						&labeledExpr{
 							pos:   position{line: 66, col: 11, offset: 1288},
 							label: "first",
 							expr: &ruleRefExpr{
-								pos:  position{line: 66, col: 17, offset: 1294},
-								name: "Term",
+								pos:    position{line: 66, col: 17, offset: 1294},
+								offset: 2,
 							},
 						},
 						&labeledExpr{
@@ -130,28 +130,28 @@ var g = &grammar{
 									pos: position{line: 66, col: 29, offset: 1306},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 66, col: 29, offset: 1306},
-											name: "_",
+											pos:    position{line: 66, col: 29, offset: 1306},
+											offset: 7,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 66, col: 31, offset: 1308},
-											name: "AddOp",
+											pos:    position{line: 66, col: 31, offset: 1308},
+											offset: 4,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 66, col: 37, offset: 1314},
-											name: "_",
+											pos:    position{line: 66, col: 37, offset: 1314},
+											offset: 7,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 66, col: 39, offset: 1316},
-											name: "Term",
+											pos:    position{line: 66, col: 39, offset: 1316},
+											offset: 2,
 										},
 									},
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 66, col: 47, offset: 1324},
-							name: "_",
+							pos:    position{line: 66, col: 47, offset: 1324},
+							offset: 7,
 						},
 					},
 				},
@@ -170,8 +170,8 @@ var g = &grammar{
 							pos:   position{line: 71, col: 9, offset: 1393},
 							label: "first",
 							expr: &ruleRefExpr{
-								pos:  position{line: 71, col: 15, offset: 1399},
-								name: "Factor",
+								pos:    position{line: 71, col: 15, offset: 1399},
+								offset: 3,
 							},
 						},
 						&labeledExpr{
@@ -183,20 +183,20 @@ var g = &grammar{
 									pos: position{line: 71, col: 29, offset: 1413},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 71, col: 29, offset: 1413},
-											name: "_",
+											pos:    position{line: 71, col: 29, offset: 1413},
+											offset: 7,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 71, col: 31, offset: 1415},
-											name: "MulOp",
+											pos:    position{line: 71, col: 31, offset: 1415},
+											offset: 5,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 71, col: 37, offset: 1421},
-											name: "_",
+											pos:    position{line: 71, col: 37, offset: 1421},
+											offset: 7,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 71, col: 39, offset: 1423},
-											name: "Factor",
+											pos:    position{line: 71, col: 39, offset: 1423},
+											offset: 3,
 										},
 									},
 								},
@@ -228,8 +228,8 @@ var g = &grammar{
 									pos:   position{line: 76, col: 15, offset: 1506},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 76, col: 20, offset: 1511},
-										name: "Expr",
+										pos:    position{line: 76, col: 20, offset: 1511},
+										offset: 1,
 									},
 								},
 								&litMatcher{
@@ -248,8 +248,8 @@ var g = &grammar{
 							pos:   position{line: 79, col: 5, offset: 1567},
 							label: "integer",
 							expr: &ruleRefExpr{
-								pos:  position{line: 79, col: 13, offset: 1575},
-								name: "Integer",
+								pos:    position{line: 79, col: 13, offset: 1575},
+								offset: 6,
 							},
 						},
 					},
@@ -469,6 +469,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -488,6 +492,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -749,8 +766,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -941,8 +958,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -955,6 +972,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -969,6 +988,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -1054,6 +1076,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -1074,7 +1101,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -1204,11 +1231,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -1219,7 +1250,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -1241,36 +1272,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1278,6 +1295,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -1546,8 +1587,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -1576,12 +1617,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -1691,19 +1732,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
@@ -43,21 +43,21 @@ var g = &grammar{
 							pos:   position{line: 13, col: 59, offset: 177},
 							label: "s",
 							expr: &ruleRefExpr{
-								pos:  position{line: 13, col: 61, offset: 179},
-								name: "Statements",
+								pos:    position{line: 13, col: 61, offset: 179},
+								offset: 1,
 							},
 						},
 						&labeledExpr{
 							pos:   position{line: 13, col: 73, offset: 191},
 							label: "r",
 							expr: &ruleRefExpr{
-								pos:  position{line: 13, col: 75, offset: 193},
-								name: "ReturnOp",
+								pos:    position{line: 13, col: 75, offset: 193},
+								offset: 3,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 13, col: 84, offset: 202},
-							name: "EOF",
+							pos:    position{line: 13, col: 84, offset: 202},
+							offset: 15,
 						},
 					},
 				},
@@ -75,8 +75,8 @@ var g = &grammar{
 					expr: &oneOrMoreExpr{
 						pos: position{line: 15, col: 17, offset: 330},
 						expr: &ruleRefExpr{
-							pos:  position{line: 15, col: 17, offset: 330},
-							name: "Line",
+							pos:    position{line: 15, col: 17, offset: 330},
+							offset: 2,
 						},
 					},
 				},
@@ -92,15 +92,15 @@ var g = &grammar{
 					pos: position{line: 16, col: 15, offset: 405},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 16, col: 15, offset: 405},
-							name: "INDENTATION",
+							pos:    position{line: 16, col: 15, offset: 405},
+							offset: 16,
 						},
 						&labeledExpr{
 							pos:   position{line: 16, col: 27, offset: 417},
 							label: "s",
 							expr: &ruleRefExpr{
-								pos:  position{line: 16, col: 29, offset: 419},
-								name: "Statement",
+								pos:    position{line: 16, col: 29, offset: 419},
+								offset: 4,
 							},
 						},
 					},
@@ -123,20 +123,20 @@ var g = &grammar{
 							want:       "\"return\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 17, col: 24, offset: 477},
-							name: "_",
+							pos:    position{line: 17, col: 24, offset: 477},
+							offset: 12,
 						},
 						&labeledExpr{
 							pos:   position{line: 17, col: 26, offset: 479},
 							label: "arg",
 							expr: &ruleRefExpr{
-								pos:  position{line: 17, col: 30, offset: 483},
-								name: "Identifier",
+								pos:    position{line: 17, col: 30, offset: 483},
+								offset: 10,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 17, col: 41, offset: 494},
-							name: "EOL",
+							pos:    position{line: 17, col: 41, offset: 494},
+							offset: 13,
 						},
 					},
 				},
@@ -158,13 +158,13 @@ var g = &grammar{
 									pos:   position{line: 19, col: 15, offset: 561},
 									label: "s",
 									expr: &ruleRefExpr{
-										pos:  position{line: 19, col: 17, offset: 563},
-										name: "Assignment",
+										pos:    position{line: 19, col: 17, offset: 563},
+										offset: 5,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 19, col: 28, offset: 574},
-									name: "EOL",
+									pos:    position{line: 19, col: 28, offset: 574},
+									offset: 13,
 								},
 							},
 						},
@@ -182,22 +182,22 @@ var g = &grammar{
 									want:       "\"if\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 20, col: 12, offset: 636},
-									name: "_",
+									pos:    position{line: 20, col: 12, offset: 636},
+									offset: 12,
 								},
 								&labeledExpr{
 									pos:   position{line: 20, col: 14, offset: 638},
 									label: "arg",
 									expr: &ruleRefExpr{
-										pos:  position{line: 20, col: 18, offset: 642},
-										name: "LogicalExpression",
+										pos:    position{line: 20, col: 18, offset: 642},
+										offset: 6,
 									},
 								},
 								&zeroOrOneExpr{
 									pos: position{line: 20, col: 36, offset: 660},
 									expr: &ruleRefExpr{
-										pos:  position{line: 20, col: 36, offset: 660},
-										name: "_",
+										pos:    position{line: 20, col: 36, offset: 660},
+										offset: 12,
 									},
 								},
 								&litMatcher{
@@ -207,24 +207,24 @@ var g = &grammar{
 									want:       "\":\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 20, col: 43, offset: 667},
-									name: "EOL",
+									pos:    position{line: 20, col: 43, offset: 667},
+									offset: 13,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 20, col: 47, offset: 671},
-									name: "INDENT",
+									pos:    position{line: 20, col: 47, offset: 671},
+									offset: 17,
 								},
 								&labeledExpr{
 									pos:   position{line: 20, col: 54, offset: 678},
 									label: "s",
 									expr: &ruleRefExpr{
-										pos:  position{line: 20, col: 56, offset: 680},
-										name: "Statements",
+										pos:    position{line: 20, col: 56, offset: 680},
+										offset: 1,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 20, col: 67, offset: 691},
-									name: "DEDENT",
+									pos:    position{line: 20, col: 67, offset: 691},
+									offset: 18,
 								},
 							},
 						},
@@ -245,15 +245,15 @@ var g = &grammar{
 							pos:   position{line: 24, col: 14, offset: 833},
 							label: "lvalue",
 							expr: &ruleRefExpr{
-								pos:  position{line: 24, col: 21, offset: 840},
-								name: "Identifier",
+								pos:    position{line: 24, col: 21, offset: 840},
+								offset: 10,
 							},
 						},
 						&zeroOrOneExpr{
 							pos: position{line: 24, col: 32, offset: 851},
 							expr: &ruleRefExpr{
-								pos:  position{line: 24, col: 32, offset: 851},
-								name: "_",
+								pos:    position{line: 24, col: 32, offset: 851},
+								offset: 12,
 							},
 						},
 						&litMatcher{
@@ -265,16 +265,16 @@ var g = &grammar{
 						&zeroOrOneExpr{
 							pos: position{line: 24, col: 39, offset: 858},
 							expr: &ruleRefExpr{
-								pos:  position{line: 24, col: 39, offset: 858},
-								name: "_",
+								pos:    position{line: 24, col: 39, offset: 858},
+								offset: 12,
 							},
 						},
 						&labeledExpr{
 							pos:   position{line: 24, col: 42, offset: 861},
 							label: "rvalue",
 							expr: &ruleRefExpr{
-								pos:  position{line: 24, col: 49, offset: 868},
-								name: "AdditiveExpression",
+								pos:    position{line: 24, col: 49, offset: 868},
+								offset: 7,
 							},
 						},
 					},
@@ -291,8 +291,8 @@ var g = &grammar{
 					pos:   position{line: 27, col: 23, offset: 1042},
 					label: "arg",
 					expr: &ruleRefExpr{
-						pos:  position{line: 27, col: 27, offset: 1046},
-						name: "PrimaryExpression",
+						pos:    position{line: 27, col: 27, offset: 1046},
+						offset: 8,
 					},
 				},
 			},
@@ -310,8 +310,8 @@ var g = &grammar{
 							pos:   position{line: 28, col: 23, offset: 1153},
 							label: "arg",
 							expr: &ruleRefExpr{
-								pos:  position{line: 28, col: 27, offset: 1157},
-								name: "PrimaryExpression",
+								pos:    position{line: 28, col: 27, offset: 1157},
+								offset: 8,
 							},
 						},
 						&labeledExpr{
@@ -323,20 +323,20 @@ var g = &grammar{
 									pos: position{line: 28, col: 52, offset: 1182},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 28, col: 52, offset: 1182},
-											name: "_",
+											pos:    position{line: 28, col: 52, offset: 1182},
+											offset: 12,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 28, col: 54, offset: 1184},
-											name: "AddOp",
+											pos:    position{line: 28, col: 54, offset: 1184},
+											offset: 11,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 28, col: 60, offset: 1190},
-											name: "_",
+											pos:    position{line: 28, col: 60, offset: 1190},
+											offset: 12,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 28, col: 62, offset: 1192},
-											name: "PrimaryExpression",
+											pos:    position{line: 28, col: 62, offset: 1192},
+											offset: 8,
 										},
 									},
 								},
@@ -359,12 +359,12 @@ var g = &grammar{
 						pos: position{line: 30, col: 28, offset: 1357},
 						alternatives: []any{
 							&ruleRefExpr{
-								pos:  position{line: 30, col: 28, offset: 1357},
-								name: "Integer",
+								pos:    position{line: 30, col: 28, offset: 1357},
+								offset: 9,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 30, col: 38, offset: 1367},
-								name: "Identifier",
+								pos:    position{line: 30, col: 38, offset: 1367},
+								offset: 10,
 							},
 						},
 					},
@@ -467,15 +467,15 @@ var g = &grammar{
 					&zeroOrOneExpr{
 						pos: position{line: 40, col: 7, offset: 1745},
 						expr: &ruleRefExpr{
-							pos:  position{line: 40, col: 7, offset: 1745},
-							name: "_",
+							pos:    position{line: 40, col: 7, offset: 1745},
+							offset: 12,
 						},
 					},
 					&zeroOrOneExpr{
 						pos: position{line: 40, col: 10, offset: 1748},
 						expr: &ruleRefExpr{
-							pos:  position{line: 40, col: 10, offset: 1748},
-							name: "Comment",
+							pos:    position{line: 40, col: 10, offset: 1748},
+							offset: 14,
 						},
 					},
 					&choiceExpr{
@@ -506,8 +506,8 @@ var g = &grammar{
 								want:       "\"\\n\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 40, col: 52, offset: 1790},
-								name: "EOF",
+								pos:    position{line: 40, col: 52, offset: 1790},
+								offset: 15,
 							},
 						},
 					},
@@ -782,6 +782,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -801,6 +805,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -1062,8 +1079,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -1254,8 +1271,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -1268,6 +1285,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -1282,6 +1301,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -1367,6 +1389,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -1387,7 +1414,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -1517,11 +1544,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -1532,7 +1563,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -1554,36 +1585,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1591,6 +1608,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -1859,8 +1900,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -1889,12 +1930,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -2004,19 +2045,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
@@ -9,6 +9,7 @@ import (
 
 	optimized "github.com/mna/pigeon/examples/json/optimized"
 	optimizedgrammar "github.com/mna/pigeon/examples/json/optimized-grammar"
+	tabledriven "github.com/mna/pigeon/examples/json/table-driven"
 )
 
 func TestCmpStdlib(t *testing.T) {
@@ -32,6 +33,12 @@ func TestCmpStdlib(t *testing.T) {
 			continue
 		}
 
+		ptdgot, err := tabledriven.ParseFile(file)
+		if err != nil {
+			t.Errorf("%s: tabledriven.ParseFile: %v", file, err)
+			continue
+		}
+
 		b, err := os.ReadFile(file)
 		if err != nil {
 			t.Errorf("%s: os.ReadFile: %v", file, err)
@@ -57,6 +64,11 @@ func TestCmpStdlib(t *testing.T) {
 			t.Errorf("%s: optimized grammar not equal", file)
 			continue
 		}
+
+		if !reflect.DeepEqual(ptdgot, jgot) {
+			t.Errorf("%s: table-driven not equal", file)
+			continue
+		}
 	}
 }
 
@@ -118,6 +130,15 @@ func TestChoiceAltStatistics(t *testing.T) {
 		if !reflect.DeepEqual(test.expectedStats, stats.ChoiceAltCnt) {
 			t.Fatalf("Expected stats to equal %#v, got %#v", test.expectedStats, stats.ChoiceAltCnt)
 		}
+
+		tdStats := tabledriven.Stats{}
+		_, err = tabledriven.Parse("TestStatistics", []byte(test.json), tabledriven.Statistics(&tdStats, "no match"))
+		if err != nil {
+			t.Fatalf("Expected to parse %s without error with the table-driven parser, got: %v", test.json, err)
+		}
+		if !reflect.DeepEqual(test.expectedStats, tdStats.ChoiceAltCnt) {
+			t.Fatalf("Expected table-driven stats to equal %#v, got %#v", test.expectedStats, tdStats.ChoiceAltCnt)
+		}
 	}
 }
 
@@ -193,6 +214,20 @@ func BenchmarkPigeonJSONOptimizedGrammar(b *testing.B) {
 	}
 }
 
+func BenchmarkPigeonJSONTableDriven(b *testing.B) {
+	d, err := os.ReadFile("testdata/github-octokit-repos.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := tabledriven.Parse("", d); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkStdlibJSON(b *testing.B) {
 	d, err := os.ReadFile("testdata/github-octokit-repos.json")
 	if err != nil {
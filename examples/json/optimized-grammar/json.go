@@ -54,8 +54,8 @@ var g = &grammar{
 							pos:   position{line: 17, col: 10, offset: 332},
 							label: "val",
 							expr: &ruleRefExpr{
-								pos:  position{line: 17, col: 14, offset: 336},
-								name: "Value",
+								pos:    position{line: 17, col: 14, offset: 336},
+								offset: 1,
 							},
 						},
 						&notExpr{
@@ -84,12 +84,12 @@ var g = &grammar{
 								pos: position{line: 21, col: 15, offset: 387},
 								alternatives: []any{
 									&ruleRefExpr{
-										pos:  position{line: 21, col: 15, offset: 387},
-										name: "Object",
+										pos:    position{line: 21, col: 15, offset: 387},
+										offset: 2,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 21, col: 24, offset: 396},
-										name: "Array",
+										pos:    position{line: 21, col: 24, offset: 396},
+										offset: 3,
 									},
 									&actionExpr{
 										pos: position{line: 54, col: 10, offset: 1215},
@@ -527,8 +527,8 @@ var g = &grammar{
 											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 25, col: 38, offset: 502},
-											name: "Value",
+											pos:    position{line: 25, col: 38, offset: 502},
+											offset: 1,
 										},
 										&zeroOrMoreExpr{
 											pos: position{line: 25, col: 44, offset: 508},
@@ -688,8 +688,8 @@ var g = &grammar{
 														},
 													},
 													&ruleRefExpr{
-														pos:  position{line: 25, col: 67, offset: 531},
-														name: "Value",
+														pos:    position{line: 25, col: 67, offset: 531},
+														offset: 1,
 													},
 												},
 											},
@@ -742,8 +742,8 @@ var g = &grammar{
 									pos: position{line: 40, col: 22, offset: 894},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 40, col: 22, offset: 894},
-											name: "Value",
+											pos:    position{line: 40, col: 22, offset: 894},
+											offset: 1,
 										},
 										&zeroOrMoreExpr{
 											pos: position{line: 40, col: 28, offset: 900},
@@ -767,8 +767,8 @@ var g = &grammar{
 														},
 													},
 													&ruleRefExpr{
-														pos:  position{line: 40, col: 36, offset: 908},
-														name: "Value",
+														pos:    position{line: 40, col: 36, offset: 908},
+														offset: 1,
 													},
 												},
 											},
@@ -941,6 +941,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -960,6 +964,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -1221,8 +1238,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -1413,8 +1430,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -1427,6 +1444,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -1441,6 +1460,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -1526,6 +1548,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -1546,7 +1573,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -1676,11 +1703,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -1691,7 +1722,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -1713,36 +1744,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1750,6 +1767,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -2018,8 +2059,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -2048,12 +2089,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -2163,19 +2204,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
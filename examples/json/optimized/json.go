@@ -40,20 +40,20 @@ var g = &grammar{
 					pos: position{line: 17, col: 8, offset: 330},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 17, col: 8, offset: 330},
-							name: "_",
+							pos:    position{line: 17, col: 8, offset: 330},
+							offset: 17,
 						},
 						&labeledExpr{
 							pos:   position{line: 17, col: 10, offset: 332},
 							label: "val",
 							expr: &ruleRefExpr{
-								pos:  position{line: 17, col: 14, offset: 336},
-								name: "Value",
+								pos:    position{line: 17, col: 14, offset: 336},
+								offset: 1,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 17, col: 20, offset: 342},
-							name: "EOF",
+							pos:    position{line: 17, col: 20, offset: 342},
+							offset: 18,
 						},
 					},
 				},
@@ -75,35 +75,35 @@ var g = &grammar{
 								pos: position{line: 21, col: 15, offset: 387},
 								alternatives: []any{
 									&ruleRefExpr{
-										pos:  position{line: 21, col: 15, offset: 387},
-										name: "Object",
+										pos:    position{line: 21, col: 15, offset: 387},
+										offset: 2,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 21, col: 24, offset: 396},
-										name: "Array",
+										pos:    position{line: 21, col: 24, offset: 396},
+										offset: 3,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 21, col: 32, offset: 404},
-										name: "Number",
+										pos:    position{line: 21, col: 32, offset: 404},
+										offset: 4,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 21, col: 41, offset: 413},
-										name: "String",
+										pos:    position{line: 21, col: 41, offset: 413},
+										offset: 7,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 21, col: 50, offset: 422},
-										name: "Bool",
+										pos:    position{line: 21, col: 50, offset: 422},
+										offset: 15,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 21, col: 57, offset: 429},
-										name: "Null",
+										pos:    position{line: 21, col: 57, offset: 429},
+										offset: 16,
 									},
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 21, col: 64, offset: 436},
-							name: "_",
+							pos:    position{line: 21, col: 64, offset: 436},
+							offset: 17,
 						},
 					},
 				},
@@ -125,8 +125,8 @@ var g = &grammar{
 							want:       "\"{\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 25, col: 14, offset: 478},
-							name: "_",
+							pos:    position{line: 25, col: 14, offset: 478},
+							offset: 17,
 						},
 						&labeledExpr{
 							pos:   position{line: 25, col: 16, offset: 480},
@@ -137,12 +137,12 @@ var g = &grammar{
 									pos: position{line: 25, col: 23, offset: 487},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 25, col: 23, offset: 487},
-											name: "String",
+											pos:    position{line: 25, col: 23, offset: 487},
+											offset: 7,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 25, col: 30, offset: 494},
-											name: "_",
+											pos:    position{line: 25, col: 30, offset: 494},
+											offset: 17,
 										},
 										&litMatcher{
 											pos:        position{line: 25, col: 32, offset: 496},
@@ -151,12 +151,12 @@ var g = &grammar{
 											want:       "\":\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 25, col: 36, offset: 500},
-											name: "_",
+											pos:    position{line: 25, col: 36, offset: 500},
+											offset: 17,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 25, col: 38, offset: 502},
-											name: "Value",
+											pos:    position{line: 25, col: 38, offset: 502},
+											offset: 1,
 										},
 										&zeroOrMoreExpr{
 											pos: position{line: 25, col: 44, offset: 508},
@@ -170,16 +170,16 @@ var g = &grammar{
 														want:       "\",\"",
 													},
 													&ruleRefExpr{
-														pos:  position{line: 25, col: 50, offset: 514},
-														name: "_",
+														pos:    position{line: 25, col: 50, offset: 514},
+														offset: 17,
 													},
 													&ruleRefExpr{
-														pos:  position{line: 25, col: 52, offset: 516},
-														name: "String",
+														pos:    position{line: 25, col: 52, offset: 516},
+														offset: 7,
 													},
 													&ruleRefExpr{
-														pos:  position{line: 25, col: 59, offset: 523},
-														name: "_",
+														pos:    position{line: 25, col: 59, offset: 523},
+														offset: 17,
 													},
 													&litMatcher{
 														pos:        position{line: 25, col: 61, offset: 525},
@@ -188,12 +188,12 @@ var g = &grammar{
 														want:       "\":\"",
 													},
 													&ruleRefExpr{
-														pos:  position{line: 25, col: 65, offset: 529},
-														name: "_",
+														pos:    position{line: 25, col: 65, offset: 529},
+														offset: 17,
 													},
 													&ruleRefExpr{
-														pos:  position{line: 25, col: 67, offset: 531},
-														name: "Value",
+														pos:    position{line: 25, col: 67, offset: 531},
+														offset: 1,
 													},
 												},
 											},
@@ -228,8 +228,8 @@ var g = &grammar{
 							want:       "\"[\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 40, col: 13, offset: 885},
-							name: "_",
+							pos:    position{line: 40, col: 13, offset: 885},
+							offset: 17,
 						},
 						&labeledExpr{
 							pos:   position{line: 40, col: 15, offset: 887},
@@ -240,8 +240,8 @@ var g = &grammar{
 									pos: position{line: 40, col: 22, offset: 894},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 40, col: 22, offset: 894},
-											name: "Value",
+											pos:    position{line: 40, col: 22, offset: 894},
+											offset: 1,
 										},
 										&zeroOrMoreExpr{
 											pos: position{line: 40, col: 28, offset: 900},
@@ -255,12 +255,12 @@ var g = &grammar{
 														want:       "\",\"",
 													},
 													&ruleRefExpr{
-														pos:  position{line: 40, col: 34, offset: 906},
-														name: "_",
+														pos:    position{line: 40, col: 34, offset: 906},
+														offset: 17,
 													},
 													&ruleRefExpr{
-														pos:  position{line: 40, col: 36, offset: 908},
-														name: "Value",
+														pos:    position{line: 40, col: 36, offset: 908},
+														offset: 1,
 													},
 												},
 											},
@@ -298,8 +298,8 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 54, col: 15, offset: 1220},
-							name: "Integer",
+							pos:    position{line: 54, col: 15, offset: 1220},
+							offset: 5,
 						},
 						&zeroOrOneExpr{
 							pos: position{line: 54, col: 23, offset: 1228},
@@ -315,8 +315,8 @@ var g = &grammar{
 									&oneOrMoreExpr{
 										pos: position{line: 54, col: 29, offset: 1234},
 										expr: &ruleRefExpr{
-											pos:  position{line: 54, col: 29, offset: 1234},
-											name: "DecimalDigit",
+											pos:    position{line: 54, col: 29, offset: 1234},
+											offset: 12,
 										},
 									},
 								},
@@ -325,8 +325,8 @@ var g = &grammar{
 						&zeroOrOneExpr{
 							pos: position{line: 54, col: 46, offset: 1251},
 							expr: &ruleRefExpr{
-								pos:  position{line: 54, col: 46, offset: 1251},
-								name: "Exponent",
+								pos:    position{line: 54, col: 46, offset: 1251},
+								offset: 6,
 							},
 						},
 					},
@@ -349,14 +349,14 @@ var g = &grammar{
 						pos: position{line: 60, col: 17, offset: 1424},
 						exprs: []any{
 							&ruleRefExpr{
-								pos:  position{line: 60, col: 17, offset: 1424},
-								name: "NonZeroDecimalDigit",
+								pos:    position{line: 60, col: 17, offset: 1424},
+								offset: 13,
 							},
 							&zeroOrMoreExpr{
 								pos: position{line: 60, col: 37, offset: 1444},
 								expr: &ruleRefExpr{
-									pos:  position{line: 60, col: 37, offset: 1444},
-									name: "DecimalDigit",
+									pos:    position{line: 60, col: 37, offset: 1444},
+									offset: 12,
 								},
 							},
 						},
@@ -390,8 +390,8 @@ var g = &grammar{
 					&oneOrMoreExpr{
 						pos: position{line: 62, col: 23, offset: 1483},
 						expr: &ruleRefExpr{
-							pos:  position{line: 62, col: 23, offset: 1483},
-							name: "DecimalDigit",
+							pos:    position{line: 62, col: 23, offset: 1483},
+							offset: 12,
 						},
 					},
 				},
@@ -423,8 +423,8 @@ var g = &grammar{
 											&notExpr{
 												pos: position{line: 64, col: 16, offset: 1515},
 												expr: &ruleRefExpr{
-													pos:  position{line: 64, col: 17, offset: 1516},
-													name: "EscapedChar",
+													pos:    position{line: 64, col: 17, offset: 1516},
+													offset: 8,
 												},
 											},
 											&anyMatcher{
@@ -442,8 +442,8 @@ var g = &grammar{
 												want:       "\"\\\\\"",
 											},
 											&ruleRefExpr{
-												pos:  position{line: 64, col: 38, offset: 1537},
-												name: "EscapeSequence",
+												pos:    position{line: 64, col: 38, offset: 1537},
+												offset: 9,
 											},
 										},
 									},
@@ -480,12 +480,12 @@ var g = &grammar{
 				pos: position{line: 71, col: 18, offset: 1724},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 71, col: 18, offset: 1724},
-						name: "SingleCharEscape",
+						pos:    position{line: 71, col: 18, offset: 1724},
+						offset: 10,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 71, col: 37, offset: 1743},
-						name: "UnicodeEscape",
+						pos:    position{line: 71, col: 37, offset: 1743},
+						offset: 11,
 					},
 				},
 			},
@@ -515,20 +515,20 @@ var g = &grammar{
 						want:       "\"u\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 75, col: 21, offset: 1814},
-						name: "HexDigit",
+						pos:    position{line: 75, col: 21, offset: 1814},
+						offset: 14,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 75, col: 30, offset: 1823},
-						name: "HexDigit",
+						pos:    position{line: 75, col: 30, offset: 1823},
+						offset: 14,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 75, col: 39, offset: 1832},
-						name: "HexDigit",
+						pos:    position{line: 75, col: 39, offset: 1832},
+						offset: 14,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 75, col: 48, offset: 1841},
-						name: "HexDigit",
+						pos:    position{line: 75, col: 48, offset: 1841},
+						offset: 14,
 					},
 				},
 			},
@@ -770,6 +770,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -789,6 +793,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -979,8 +996,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -1158,8 +1175,8 @@ type parser struct {
 	depth   int
 	recover bool
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -1172,6 +1189,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -1186,6 +1205,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -1246,6 +1268,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -1266,7 +1293,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -1320,11 +1347,15 @@ func (p *parser) sliceFrom(start savepoint) []byte {
 	return p.data[start.position.offset:p.pt.position.offset]
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -1335,7 +1366,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -1354,36 +1385,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1391,6 +1408,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -1693,15 +1734,11 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
+	rule := p.rules[ref.offset]
 	return p.parseRuleWrap(rule)
 }
 
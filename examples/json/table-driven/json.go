@@ -0,0 +1,2158 @@
+// Code generated by pigeon; DO NOT EDIT.
+
+// Package json parses JSON as defined by [1].
+//
+// BUGS: the escaped forward solidus (`\/`) is not currently handled.
+//
+// [1]: http://www.ecma-international.org/publications/files/ECMA-ST/ECMA-404.pdf
+package json
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+func toAnySlice(v any) []any {
+	if v == nil {
+		return nil
+	}
+	return v.([]any)
+}
+
+var g = &grammar{
+	rules: []*rule{
+		{
+			name: "JSON",
+			pos:  position{line: 17, col: 1, offset: 321},
+			expr: tableExpr(5),
+		},
+		{
+			name: "Value",
+			pos:  position{line: 21, col: 1, offset: 371},
+			expr: tableExpr(16),
+		},
+		{
+			name: "Object",
+			pos:  position{line: 25, col: 1, offset: 463},
+			expr: tableExpr(38),
+		},
+		{
+			name: "Array",
+			pos:  position{line: 40, col: 1, offset: 871},
+			expr: tableExpr(52),
+		},
+		{
+			name: "Number",
+			pos:  position{line: 54, col: 1, offset: 1204},
+			expr: tableExpr(64),
+		},
+		{
+			name: "Integer",
+			pos:  position{line: 60, col: 1, offset: 1406},
+			expr: tableExpr(70),
+		},
+		{
+			name: "Exponent",
+			pos:  position{line: 62, col: 1, offset: 1459},
+			expr: tableExpr(76),
+		},
+		{
+			name: "String",
+			pos:  position{line: 64, col: 1, offset: 1498},
+			expr: tableExpr(89),
+		},
+		{
+			name: "EscapedChar",
+			pos:  position{line: 69, col: 1, offset: 1673},
+			expr: tableExpr(90),
+		},
+		{
+			name: "EscapeSequence",
+			pos:  position{line: 71, col: 1, offset: 1705},
+			expr: tableExpr(93),
+		},
+		{
+			name: "SingleCharEscape",
+			pos:  position{line: 73, col: 1, offset: 1758},
+			expr: tableExpr(94),
+		},
+		{
+			name: "UnicodeEscape",
+			pos:  position{line: 75, col: 1, offset: 1792},
+			expr: tableExpr(100),
+		},
+		{
+			name: "DecimalDigit",
+			pos:  position{line: 77, col: 1, offset: 1851},
+			expr: tableExpr(101),
+		},
+		{
+			name: "NonZeroDecimalDigit",
+			pos:  position{line: 79, col: 1, offset: 1875},
+			expr: tableExpr(102),
+		},
+		{
+			name: "HexDigit",
+			pos:  position{line: 81, col: 1, offset: 1906},
+			expr: tableExpr(103),
+		},
+		{
+			name: "Bool",
+			pos:  position{line: 83, col: 1, offset: 1930},
+			expr: tableExpr(108),
+		},
+		{
+			name: "Null",
+			pos:  position{line: 85, col: 1, offset: 2000},
+			expr: tableExpr(110),
+		},
+		{
+			name:        "_",
+			displayName: "\"whitespace\"",
+			pos:         position{line: 87, col: 1, offset: 2037},
+			expr:        tableExpr(112),
+		},
+		{
+			name: "EOF",
+			pos:  position{line: 89, col: 1, offset: 2068},
+			expr: tableExpr(114),
+		},
+	},
+}
+
+var grammarTable = &instrTable{
+	instrs: []instr{
+		{op: opRuleRef, pos: position{line: 17, col: 8, offset: 330}, arg: 17},
+		{op: opRuleRef, pos: position{line: 17, col: 14, offset: 336}, arg: 1},
+		{op: opLabeled, pos: position{line: 17, col: 10, offset: 332}, sub: 0, n: 1},
+		{op: opRuleRef, pos: position{line: 17, col: 20, offset: 342}, arg: 18},
+		{op: opSeq, pos: position{line: 17, col: 8, offset: 330}, sub: 1, n: 3},
+		{op: opAction, pos: position{line: 17, col: 8, offset: 330}, sub: 4, n: 1},
+		{op: opRuleRef, pos: position{line: 21, col: 15, offset: 387}, arg: 2},
+		{op: opRuleRef, pos: position{line: 21, col: 24, offset: 396}, arg: 3},
+		{op: opRuleRef, pos: position{line: 21, col: 32, offset: 404}, arg: 4},
+		{op: opRuleRef, pos: position{line: 21, col: 41, offset: 413}, arg: 7},
+		{op: opRuleRef, pos: position{line: 21, col: 50, offset: 422}, arg: 15},
+		{op: opRuleRef, pos: position{line: 21, col: 57, offset: 429}, arg: 16},
+		{op: opChoice, pos: position{line: 21, col: 15, offset: 387}, sub: 5, n: 6},
+		{op: opLabeled, pos: position{line: 21, col: 9, offset: 381}, arg: 1, sub: 11, n: 1},
+		{op: opRuleRef, pos: position{line: 21, col: 64, offset: 436}, arg: 17},
+		{op: opSeq, pos: position{line: 21, col: 9, offset: 381}, sub: 12, n: 2},
+		{op: opAction, pos: position{line: 21, col: 9, offset: 381}, arg: 1, sub: 14, n: 1},
+		{op: opLit, pos: position{line: 25, col: 10, offset: 474}},
+		{op: opRuleRef, pos: position{line: 25, col: 14, offset: 478}, arg: 17},
+		{op: opRuleRef, pos: position{line: 25, col: 23, offset: 487}, arg: 7},
+		{op: opRuleRef, pos: position{line: 25, col: 30, offset: 494}, arg: 17},
+		{op: opLit, pos: position{line: 25, col: 32, offset: 496}, arg: 1},
+		{op: opRuleRef, pos: position{line: 25, col: 36, offset: 500}, arg: 17},
+		{op: opRuleRef, pos: position{line: 25, col: 38, offset: 502}, arg: 1},
+		{op: opLit, pos: position{line: 25, col: 46, offset: 510}, arg: 2},
+		{op: opRuleRef, pos: position{line: 25, col: 50, offset: 514}, arg: 17},
+		{op: opRuleRef, pos: position{line: 25, col: 52, offset: 516}, arg: 7},
+		{op: opRuleRef, pos: position{line: 25, col: 59, offset: 523}, arg: 17},
+		{op: opLit, pos: position{line: 25, col: 61, offset: 525}, arg: 3},
+		{op: opRuleRef, pos: position{line: 25, col: 65, offset: 529}, arg: 17},
+		{op: opRuleRef, pos: position{line: 25, col: 67, offset: 531}, arg: 1},
+		{op: opSeq, pos: position{line: 25, col: 46, offset: 510}, sub: 15, n: 7},
+		{op: opZeroOrMore, pos: position{line: 25, col: 44, offset: 508}, sub: 22, n: 1},
+		{op: opSeq, pos: position{line: 25, col: 23, offset: 487}, sub: 23, n: 6},
+		{op: opZeroOrOne, pos: position{line: 25, col: 21, offset: 485}, sub: 29, n: 1},
+		{op: opLabeled, pos: position{line: 25, col: 16, offset: 480}, arg: 2, sub: 30, n: 1},
+		{op: opLit, pos: position{line: 25, col: 79, offset: 543}, arg: 4},
+		{op: opSeq, pos: position{line: 25, col: 10, offset: 474}, sub: 31, n: 4},
+		{op: opAction, pos: position{line: 25, col: 10, offset: 474}, arg: 2, sub: 35, n: 1},
+		{op: opLit, pos: position{line: 40, col: 9, offset: 881}, arg: 5},
+		{op: opRuleRef, pos: position{line: 40, col: 13, offset: 885}, arg: 17},
+		{op: opRuleRef, pos: position{line: 40, col: 22, offset: 894}, arg: 1},
+		{op: opLit, pos: position{line: 40, col: 30, offset: 902}, arg: 6},
+		{op: opRuleRef, pos: position{line: 40, col: 34, offset: 906}, arg: 17},
+		{op: opRuleRef, pos: position{line: 40, col: 36, offset: 908}, arg: 1},
+		{op: opSeq, pos: position{line: 40, col: 30, offset: 902}, sub: 36, n: 3},
+		{op: opZeroOrMore, pos: position{line: 40, col: 28, offset: 900}, sub: 39, n: 1},
+		{op: opSeq, pos: position{line: 40, col: 22, offset: 894}, sub: 40, n: 2},
+		{op: opZeroOrOne, pos: position{line: 40, col: 20, offset: 892}, sub: 42, n: 1},
+		{op: opLabeled, pos: position{line: 40, col: 15, offset: 887}, arg: 3, sub: 43, n: 1},
+		{op: opLit, pos: position{line: 40, col: 48, offset: 920}, arg: 7},
+		{op: opSeq, pos: position{line: 40, col: 9, offset: 881}, sub: 44, n: 4},
+		{op: opAction, pos: position{line: 40, col: 9, offset: 881}, arg: 3, sub: 48, n: 1},
+		{op: opLit, pos: position{line: 54, col: 10, offset: 1215}, arg: 8},
+		{op: opZeroOrOne, pos: position{line: 54, col: 10, offset: 1215}, sub: 49, n: 1},
+		{op: opRuleRef, pos: position{line: 54, col: 15, offset: 1220}, arg: 5},
+		{op: opLit, pos: position{line: 54, col: 25, offset: 1230}, arg: 9},
+		{op: opRuleRef, pos: position{line: 54, col: 29, offset: 1234}, arg: 12},
+		{op: opOneOrMore, pos: position{line: 54, col: 29, offset: 1234}, sub: 50, n: 1},
+		{op: opSeq, pos: position{line: 54, col: 25, offset: 1230}, sub: 51, n: 2},
+		{op: opZeroOrOne, pos: position{line: 54, col: 23, offset: 1228}, sub: 53, n: 1},
+		{op: opRuleRef, pos: position{line: 54, col: 46, offset: 1251}, arg: 6},
+		{op: opZeroOrOne, pos: position{line: 54, col: 46, offset: 1251}, sub: 54, n: 1},
+		{op: opSeq, pos: position{line: 54, col: 10, offset: 1215}, sub: 55, n: 4},
+		{op: opAction, pos: position{line: 54, col: 10, offset: 1215}, arg: 4, sub: 59, n: 1},
+		{op: opLit, pos: position{line: 60, col: 11, offset: 1418}, arg: 10},
+		{op: opRuleRef, pos: position{line: 60, col: 17, offset: 1424}, arg: 13},
+		{op: opRuleRef, pos: position{line: 60, col: 37, offset: 1444}, arg: 12},
+		{op: opZeroOrMore, pos: position{line: 60, col: 37, offset: 1444}, sub: 60, n: 1},
+		{op: opSeq, pos: position{line: 60, col: 17, offset: 1424}, sub: 61, n: 2},
+		{op: opChoice, pos: position{line: 60, col: 11, offset: 1418}, sub: 63, n: 2},
+		{op: opLit, pos: position{line: 62, col: 12, offset: 1472}, arg: 11},
+		{op: opCharClass, pos: position{line: 62, col: 17, offset: 1477}},
+		{op: opZeroOrOne, pos: position{line: 62, col: 17, offset: 1477}, sub: 65, n: 1},
+		{op: opRuleRef, pos: position{line: 62, col: 23, offset: 1483}, arg: 12},
+		{op: opOneOrMore, pos: position{line: 62, col: 23, offset: 1483}, sub: 66, n: 1},
+		{op: opSeq, pos: position{line: 62, col: 12, offset: 1472}, sub: 67, n: 3},
+		{op: opLit, pos: position{line: 64, col: 10, offset: 1509}, arg: 12},
+		{op: opRuleRef, pos: position{line: 64, col: 17, offset: 1516}, arg: 8},
+		{op: opNot, pos: position{line: 64, col: 16, offset: 1515}, sub: 70, n: 1},
+		{op: opAny, pos: position{line: 64, col: 29, offset: 1528}},
+		{op: opSeq, pos: position{line: 64, col: 16, offset: 1515}, sub: 71, n: 2},
+		{op: opLit, pos: position{line: 64, col: 33, offset: 1532}, arg: 13},
+		{op: opRuleRef, pos: position{line: 64, col: 38, offset: 1537}, arg: 9},
+		{op: opSeq, pos: position{line: 64, col: 33, offset: 1532}, sub: 73, n: 2},
+		{op: opChoice, pos: position{line: 64, col: 16, offset: 1515}, sub: 75, n: 2},
+		{op: opZeroOrMore, pos: position{line: 64, col: 14, offset: 1513}, sub: 77, n: 1},
+		{op: opLit, pos: position{line: 64, col: 56, offset: 1555}, arg: 14},
+		{op: opSeq, pos: position{line: 64, col: 10, offset: 1509}, sub: 78, n: 3},
+		{op: opAction, pos: position{line: 64, col: 10, offset: 1509}, arg: 5, sub: 81, n: 1},
+		{op: opCharClass, pos: position{line: 69, col: 15, offset: 1689}, arg: 1},
+		{op: opRuleRef, pos: position{line: 71, col: 18, offset: 1724}, arg: 10},
+		{op: opRuleRef, pos: position{line: 71, col: 37, offset: 1743}, arg: 11},
+		{op: opChoice, pos: position{line: 71, col: 18, offset: 1724}, sub: 82, n: 2},
+		{op: opCharClass, pos: position{line: 73, col: 20, offset: 1779}, arg: 2},
+		{op: opLit, pos: position{line: 75, col: 17, offset: 1810}, arg: 15},
+		{op: opRuleRef, pos: position{line: 75, col: 21, offset: 1814}, arg: 14},
+		{op: opRuleRef, pos: position{line: 75, col: 30, offset: 1823}, arg: 14},
+		{op: opRuleRef, pos: position{line: 75, col: 39, offset: 1832}, arg: 14},
+		{op: opRuleRef, pos: position{line: 75, col: 48, offset: 1841}, arg: 14},
+		{op: opSeq, pos: position{line: 75, col: 17, offset: 1810}, sub: 84, n: 5},
+		{op: opCharClass, pos: position{line: 77, col: 16, offset: 1868}, arg: 3},
+		{op: opCharClass, pos: position{line: 79, col: 23, offset: 1899}, arg: 4},
+		{op: opCharClass, pos: position{line: 81, col: 12, offset: 1919}, arg: 5},
+		{op: opLit, pos: position{line: 83, col: 8, offset: 1939}, arg: 16},
+		{op: opAction, pos: position{line: 83, col: 8, offset: 1939}, arg: 6, sub: 89, n: 1},
+		{op: opLit, pos: position{line: 83, col: 38, offset: 1969}, arg: 17},
+		{op: opAction, pos: position{line: 83, col: 38, offset: 1969}, arg: 7, sub: 90, n: 1},
+		{op: opChoice, pos: position{line: 83, col: 8, offset: 1939}, sub: 91, n: 2},
+		{op: opLit, pos: position{line: 85, col: 8, offset: 2009}, arg: 18},
+		{op: opAction, pos: position{line: 85, col: 8, offset: 2009}, arg: 8, sub: 93, n: 1},
+		{op: opCharClass, pos: position{line: 87, col: 18, offset: 2056}, arg: 6},
+		{op: opZeroOrMore, pos: position{line: 87, col: 18, offset: 2056}, sub: 94, n: 1},
+		{op: opAny, pos: position{line: 89, col: 8, offset: 2077}},
+		{op: opNot, pos: position{line: 89, col: 7, offset: 2076}, sub: 95, n: 1},
+	},
+	children: []int{1, 0, 2, 3, 4, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 24, 25, 26, 27, 28, 29, 30, 31, 19, 20, 21, 22, 23, 32, 33, 34, 17, 18, 35, 36, 37, 42, 43, 44, 45, 41, 46, 47, 48, 39, 40, 49, 50, 51, 53, 57, 56, 58, 59, 61, 54, 55, 60, 62, 63, 67, 66, 68, 65, 69, 72, 74, 71, 73, 75, 78, 79, 80, 82, 83, 81, 84, 85, 77, 86, 87, 88, 91, 92, 95, 96, 97, 98, 99, 104, 106, 105, 107, 109, 111, 113},
+	lits: []*litMatcher{
+		&litMatcher{
+			pos:        position{line: 25, col: 10, offset: 474},
+			val:        "{",
+			ignoreCase: false,
+			want:       "\"{\"",
+		},
+		&litMatcher{
+			pos:        position{line: 25, col: 32, offset: 496},
+			val:        ":",
+			ignoreCase: false,
+			want:       "\":\"",
+		},
+		&litMatcher{
+			pos:        position{line: 25, col: 46, offset: 510},
+			val:        ",",
+			ignoreCase: false,
+			want:       "\",\"",
+		},
+		&litMatcher{
+			pos:        position{line: 25, col: 61, offset: 525},
+			val:        ":",
+			ignoreCase: false,
+			want:       "\":\"",
+		},
+		&litMatcher{
+			pos:        position{line: 25, col: 79, offset: 543},
+			val:        "}",
+			ignoreCase: false,
+			want:       "\"}\"",
+		},
+		&litMatcher{
+			pos:        position{line: 40, col: 9, offset: 881},
+			val:        "[",
+			ignoreCase: false,
+			want:       "\"[\"",
+		},
+		&litMatcher{
+			pos:        position{line: 40, col: 30, offset: 902},
+			val:        ",",
+			ignoreCase: false,
+			want:       "\",\"",
+		},
+		&litMatcher{
+			pos:        position{line: 40, col: 48, offset: 920},
+			val:        "]",
+			ignoreCase: false,
+			want:       "\"]\"",
+		},
+		&litMatcher{
+			pos:        position{line: 54, col: 10, offset: 1215},
+			val:        "-",
+			ignoreCase: false,
+			want:       "\"-\"",
+		},
+		&litMatcher{
+			pos:        position{line: 54, col: 25, offset: 1230},
+			val:        ".",
+			ignoreCase: false,
+			want:       "\".\"",
+		},
+		&litMatcher{
+			pos:        position{line: 60, col: 11, offset: 1418},
+			val:        "0",
+			ignoreCase: false,
+			want:       "\"0\"",
+		},
+		&litMatcher{
+			pos:        position{line: 62, col: 12, offset: 1472},
+			val:        "e",
+			ignoreCase: true,
+			want:       "\"e\"i",
+		},
+		&litMatcher{
+			pos:        position{line: 64, col: 10, offset: 1509},
+			val:        "\"",
+			ignoreCase: false,
+			want:       "\"\\\"\"",
+		},
+		&litMatcher{
+			pos:        position{line: 64, col: 33, offset: 1532},
+			val:        "\\",
+			ignoreCase: false,
+			want:       "\"\\\\\"",
+		},
+		&litMatcher{
+			pos:        position{line: 64, col: 56, offset: 1555},
+			val:        "\"",
+			ignoreCase: false,
+			want:       "\"\\\"\"",
+		},
+		&litMatcher{
+			pos:        position{line: 75, col: 17, offset: 1810},
+			val:        "u",
+			ignoreCase: false,
+			want:       "\"u\"",
+		},
+		&litMatcher{
+			pos:        position{line: 83, col: 8, offset: 1939},
+			val:        "true",
+			ignoreCase: false,
+			want:       "\"true\"",
+		},
+		&litMatcher{
+			pos:        position{line: 83, col: 38, offset: 1969},
+			val:        "false",
+			ignoreCase: false,
+			want:       "\"false\"",
+		},
+		&litMatcher{
+			pos:        position{line: 85, col: 8, offset: 2009},
+			val:        "null",
+			ignoreCase: false,
+			want:       "\"null\"",
+		},
+	},
+	classes: []*charClassMatcher{
+		&charClassMatcher{
+			pos:        position{line: 62, col: 17, offset: 1477},
+			val:        "[+-]",
+			chars:      []rune{'+', '-'},
+			ignoreCase: false,
+			inverted:   false,
+		},
+		&charClassMatcher{
+			pos:        position{line: 69, col: 15, offset: 1689},
+			val:        "[\\x00-\\x1f\"\\\\]",
+			chars:      []rune{'"', '\\'},
+			ranges:     []rune{'\x00', '\x1f'},
+			ignoreCase: false,
+			inverted:   false,
+		},
+		&charClassMatcher{
+			pos:        position{line: 73, col: 20, offset: 1779},
+			val:        "[\"\\\\/bfnrt]",
+			chars:      []rune{'"', '\\', '/', 'b', 'f', 'n', 'r', 't'},
+			ignoreCase: false,
+			inverted:   false,
+		},
+		&charClassMatcher{
+			pos:        position{line: 77, col: 16, offset: 1868},
+			val:        "[0-9]",
+			ranges:     []rune{'0', '9'},
+			ignoreCase: false,
+			inverted:   false,
+		},
+		&charClassMatcher{
+			pos:        position{line: 79, col: 23, offset: 1899},
+			val:        "[1-9]",
+			ranges:     []rune{'1', '9'},
+			ignoreCase: false,
+			inverted:   false,
+		},
+		&charClassMatcher{
+			pos:        position{line: 81, col: 12, offset: 1919},
+			val:        "[0-9a-f]i",
+			ranges:     []rune{'0', '9', 'a', 'f'},
+			ignoreCase: true,
+			inverted:   false,
+		},
+		&charClassMatcher{
+			pos:        position{line: 87, col: 18, offset: 2056},
+			val:        "[ \\t\\r\\n]",
+			chars:      []rune{' ', '\t', '\r', '\n'},
+			ignoreCase: false,
+			inverted:   false,
+		},
+	},
+	labels: []string{
+		"val",
+		"val",
+		"vals",
+		"vals",
+	},
+	actions: []func(*parser) (any, error){
+		(*parser).callonJSON1,
+		(*parser).callonValue1,
+		(*parser).callonObject1,
+		(*parser).callonArray1,
+		(*parser).callonNumber1,
+		(*parser).callonString1,
+		(*parser).callonBool2,
+		(*parser).callonBool4,
+		(*parser).callonNull1,
+	},
+}
+
+func (c *current) onJSON1(val any) (any, error) {
+	return val, nil
+}
+
+func (p *parser) callonJSON1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onJSON1(stack["val"])
+}
+
+func (c *current) onValue1(val any) (any, error) {
+	return val, nil
+}
+
+func (p *parser) callonValue1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onValue1(stack["val"])
+}
+
+func (c *current) onObject1(vals any) (any, error) {
+	res := make(map[string]any)
+	valsSl := toAnySlice(vals)
+	if len(valsSl) == 0 {
+		return res, nil
+	}
+	res[valsSl[0].(string)] = valsSl[4]
+	restSl := toAnySlice(valsSl[5])
+	for _, v := range restSl {
+		vSl := toAnySlice(v)
+		res[vSl[2].(string)] = vSl[6]
+	}
+	return res, nil
+}
+
+func (p *parser) callonObject1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onObject1(stack["vals"])
+}
+
+func (c *current) onArray1(vals any) (any, error) {
+	valsSl := toAnySlice(vals)
+	if len(valsSl) == 0 {
+		return []any{}, nil
+	}
+	res := []any{valsSl[0]}
+	restSl := toAnySlice(valsSl[1])
+	for _, v := range restSl {
+		vSl := toAnySlice(v)
+		res = append(res, vSl[2])
+	}
+	return res, nil
+}
+
+func (p *parser) callonArray1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onArray1(stack["vals"])
+}
+
+func (c *current) onNumber1() (any, error) {
+	// JSON numbers have the same syntax as Go's, and are parseable using
+	// strconv.
+	return strconv.ParseFloat(string(c.text), 64)
+}
+
+func (p *parser) callonNumber1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNumber1()
+}
+
+func (c *current) onString1() (any, error) {
+	c.text = bytes.Replace(c.text, []byte(`\/`), []byte(`/`), -1)
+	return strconv.Unquote(string(c.text))
+}
+
+func (p *parser) callonString1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onString1()
+}
+
+func (c *current) onBool2() (any, error) {
+	return true, nil
+}
+
+func (p *parser) callonBool2() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onBool2()
+}
+
+func (c *current) onBool4() (any, error) {
+	return false, nil
+}
+
+func (p *parser) callonBool4() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onBool4()
+}
+
+func (c *current) onNull1() (any, error) {
+	return nil, nil
+}
+
+func (p *parser) callonNull1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onNull1()
+}
+
+var (
+	// errNoRule is returned when the grammar to parse has no rule.
+	errNoRule = errors.New("grammar has no rule")
+
+	// errInvalidEntrypoint is returned when the specified entrypoint rule
+	// does not exit.
+	errInvalidEntrypoint = errors.New("invalid entrypoint")
+
+	// errInvalidEncoding is returned when the source is not properly
+	// utf8-encoded.
+	errInvalidEncoding = errors.New("invalid encoding")
+
+	// errMaxExprCnt is used to signal that the maximum number of
+	// expressions have been parsed.
+	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
+)
+
+// Option is a function that can set an option on the parser. It returns
+// the previous setting as an Option.
+type Option func(*parser) Option
+
+// MaxExpressions creates an Option to stop parsing after the provided
+// number of expressions have been parsed, if the value is 0 then the parser will
+// parse for as many steps as needed (possibly an infinite number).
+//
+// The default for maxExprCnt is 0.
+func MaxExpressions(maxExprCnt uint64) Option {
+	return func(p *parser) Option {
+		oldMaxExprCnt := p.maxExprCnt
+		p.maxExprCnt = maxExprCnt
+		return MaxExpressions(oldMaxExprCnt)
+	}
+}
+
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
+// Entrypoint creates an Option to set the rule name to use as entrypoint.
+// The rule name must have been specified in the -alternate-entrypoints
+// if generating the parser with the -optimize-grammar flag, otherwise
+// it may have been optimized out. Passing an empty string sets the
+// entrypoint to the first rule in the grammar.
+//
+// The default is to start parsing at the first rule in the grammar.
+func Entrypoint(ruleName string) Option {
+	return func(p *parser) Option {
+		oldEntrypoint := p.entrypoint
+		p.entrypoint = ruleName
+		if ruleName == "" {
+			p.entrypoint = g.rules[0].name
+		}
+		return Entrypoint(oldEntrypoint)
+	}
+}
+
+// Statistics adds a user provided Stats struct to the parser to allow
+// the user to process the results after the parsing has finished.
+// Also the key for the "no match" counter is set.
+//
+// Example usage:
+//
+//	input := "input"
+//	stats := Stats{}
+//	_, err := Parse("input-file", []byte(input), Statistics(&stats, "no match"))
+//	if err != nil {
+//	    log.Panicln(err)
+//	}
+//	b, err := json.MarshalIndent(stats.ChoiceAltCnt, "", "  ")
+//	if err != nil {
+//	    log.Panicln(err)
+//	}
+//	fmt.Println(string(b))
+func Statistics(stats *Stats, choiceNoMatch string) Option {
+	return func(p *parser) Option {
+		oldStats := p.Stats
+		p.Stats = stats
+		oldChoiceNoMatch := p.choiceNoMatch
+		p.choiceNoMatch = choiceNoMatch
+		if p.Stats.ChoiceAltCnt == nil {
+			p.Stats.ChoiceAltCnt = make(map[string]map[string]int)
+		}
+		return Statistics(oldStats, oldChoiceNoMatch)
+	}
+}
+
+// Debug creates an Option to set the debug flag to b. When set to true,
+// debugging information is printed to stdout while parsing.
+//
+// The default is false.
+func Debug(b bool) Option {
+	return func(p *parser) Option {
+		old := p.debug
+		p.debug = b
+		return Debug(old)
+	}
+}
+
+// Memoize creates an Option to set the memoize flag to b. When set to true,
+// the parser will cache all results so each expression is evaluated only
+// once. This guarantees linear parsing time even for pathological cases,
+// at the expense of more memory and slower times for typical cases.
+//
+// The default is false.
+func Memoize(b bool) Option {
+	return func(p *parser) Option {
+		old := p.memoize
+		p.memoize = b
+		return Memoize(old)
+	}
+}
+
+// AllowInvalidUTF8 creates an Option to allow invalid UTF-8 bytes.
+// Every invalid UTF-8 byte is treated as a utf8.RuneError (U+FFFD)
+// by character class matchers and is matched by the any matcher.
+// The returned matched value, c.text and c.offset are NOT affected.
+//
+// The default is false.
+func AllowInvalidUTF8(b bool) Option {
+	return func(p *parser) Option {
+		old := p.allowInvalidUTF8
+		p.allowInvalidUTF8 = b
+		return AllowInvalidUTF8(old)
+	}
+}
+
+// Recover creates an Option to set the recover flag to b. When set to
+// true, this causes the parser to recover from panics and convert it
+// to an error. Setting it to false can be useful while debugging to
+// access the full stack trace.
+//
+// The default is true.
+func Recover(b bool) Option {
+	return func(p *parser) Option {
+		old := p.recover
+		p.recover = b
+		return Recover(old)
+	}
+}
+
+// GlobalStore creates an Option to set a key to a certain value in
+// the globalStore.
+func GlobalStore(key string, value any) Option {
+	return func(p *parser) Option {
+		old := p.cur.globalStore[key]
+		p.cur.globalStore[key] = value
+		return GlobalStore(key, old)
+	}
+}
+
+// InitState creates an Option to set a key to a certain value in
+// the global "state" store.
+func InitState(key string, value any) Option {
+	return func(p *parser) Option {
+		old := p.cur.state[key]
+		p.cur.state[key] = value
+		return InitState(key, old)
+	}
+}
+
+// ParseFile parses the file identified by filename.
+func ParseFile(filename string, opts ...Option) (i any, err error) { // nolint: deadcode
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}()
+	return ParseReader(filename, f, opts...)
+}
+
+// ParseReader parses the data from r using filename as information in the
+// error messages.
+func ParseReader(filename string, r io.Reader, opts ...Option) (any, error) { // nolint: deadcode
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(filename, b, opts...)
+}
+
+// Parse parses the data from b using filename as information in the
+// error messages.
+func Parse(filename string, b []byte, opts ...Option) (any, error) {
+	return newParser(filename, b, opts...).parse(g)
+}
+
+// position records a position in the text.
+type position struct {
+	line, col, offset int
+}
+
+func (p position) String() string {
+	return strconv.Itoa(p.line) + ":" + strconv.Itoa(p.col) + " [" + strconv.Itoa(p.offset) + "]"
+}
+
+// savepoint stores all state required to go back to this point in the
+// parser.
+type savepoint struct {
+	position
+	rn rune
+	w  int
+}
+
+type current struct {
+	pos  position // start position of the match
+	text []byte   // raw text of the match
+
+	// state is a store for arbitrary key,value pairs that the user wants to be
+	// tied to the backtracking of the parser.
+	// This is always rolled back if a parsing rule fails.
+	state storeDict
+
+	// globalStore is a general store for the user to store arbitrary key-value
+	// pairs that they need to manage and that they do not want tied to the
+	// backtracking of the parser. This is only modified by the user and never
+	// rolled back by the parser. It is always up to the user to keep this in a
+	// consistent state.
+	globalStore storeDict
+}
+
+type storeDict map[string]any
+
+// the AST types...
+
+// nolint: structcheck
+type grammar struct {
+	pos   position
+	rules []*rule
+}
+
+// nolint: structcheck
+type rule struct {
+	pos         position
+	name        string
+	displayName string
+	expr        any
+}
+
+// nolint: structcheck
+type choiceExpr struct {
+	pos          position
+	alternatives []any
+}
+
+// nolint: structcheck
+type actionExpr struct {
+	pos  position
+	expr any
+	run  func(*parser) (any, error)
+}
+
+// nolint: structcheck
+type recoveryExpr struct {
+	pos          position
+	expr         any
+	recoverExpr  any
+	failureLabel []string
+}
+
+// nolint: structcheck
+type seqExpr struct {
+	pos   position
+	exprs []any
+}
+
+// nolint: structcheck
+type throwExpr struct {
+	pos   position
+	label string
+}
+
+// nolint: structcheck
+type labeledExpr struct {
+	pos   position
+	label string
+	expr  any
+}
+
+// nolint: structcheck
+type expr struct {
+	pos  position
+	expr any
+}
+
+type (
+	andExpr        expr // nolint: structcheck
+	notExpr        expr // nolint: structcheck
+	zeroOrOneExpr  expr // nolint: structcheck
+	zeroOrMoreExpr expr // nolint: structcheck
+	oneOrMoreExpr  expr // nolint: structcheck
+)
+
+// nolint: structcheck
+type ruleRefExpr struct {
+	pos    position
+	offset int
+}
+
+// nolint: structcheck
+type stateCodeExpr struct {
+	pos position
+	run func(*parser) error
+}
+
+// nolint: structcheck
+type andCodeExpr struct {
+	pos position
+	run func(*parser) (bool, error)
+}
+
+// nolint: structcheck
+type notCodeExpr struct {
+	pos position
+	run func(*parser) (bool, error)
+}
+
+// nolint: structcheck
+type litMatcher struct {
+	pos        position
+	val        string
+	ignoreCase bool
+	want       string
+}
+
+// nolint: structcheck
+type charClassMatcher struct {
+	pos             position
+	val             string
+	basicLatinChars [128]bool
+	chars           []rune
+	ranges          []rune
+	classes         []*unicode.RangeTable
+	ignoreCase      bool
+	inverted        bool
+}
+
+type anyMatcher position // nolint: structcheck
+
+// opcode is the operation of an instruction of a table-driven parser.
+type opcode uint8
+
+const (
+	opAction opcode = iota
+	opAndCode
+	opAnd
+	opAny
+	opCharClass
+	opChoice
+	opLabeled
+	opLit
+	opNotCode
+	opNot
+	opOneOrMore
+	opRuleRef
+	opSeq
+	opStateCode
+	opZeroOrMore
+	opZeroOrOne
+)
+
+var opNames = [...]string{
+	opAction:     "action",
+	opAndCode:    "andCode",
+	opAnd:        "and",
+	opAny:        "any",
+	opCharClass:  "charClass",
+	opChoice:     "choice",
+	opLabeled:    "labeled",
+	opLit:        "lit",
+	opNotCode:    "notCode",
+	opNot:        "not",
+	opOneOrMore:  "oneOrMore",
+	opRuleRef:    "ruleRef",
+	opSeq:        "seq",
+	opStateCode:  "stateCode",
+	opZeroOrMore: "zeroOrMore",
+	opZeroOrOne:  "zeroOrOne",
+}
+
+func (op opcode) String() string {
+	if int(op) < len(opNames) {
+		return opNames[op]
+	}
+	return strconv.Itoa(int(op))
+}
+
+// instr is an instruction of a table-driven parser. Its sub-instructions
+// are the n indexes of the children table that start at sub, and arg is
+// the index of its operand in the table of its operation, or the offset of
+// the rule it references.
+//
+//	nolint: structcheck
+type instr struct {
+	op  opcode
+	pos position
+	arg int
+	sub int
+	n   int
+}
+
+// instrTable is the program of a table-driven parser: the instructions of
+// the expressions of the rules, the indexes of their sub-instructions and
+// their operands.
+//
+//	nolint: structcheck
+type instrTable struct {
+	instrs   []instr
+	children []int
+	lits     []*litMatcher
+	classes  []*charClassMatcher
+	labels   []string
+	actions  []func(*parser) (any, error)
+	preds    []func(*parser) (bool, error)
+	states   []func(*parser) error
+}
+
+// tableExpr is an expression of a table-driven parser, the index of its
+// instruction in the table.
+type tableExpr int
+
+// errList cumulates the errors found by the parser.
+type errList []error
+
+func (e *errList) add(err error) {
+	*e = append(*e, err)
+}
+
+func (e errList) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	e.dedupe()
+	return e
+}
+
+func (e *errList) dedupe() {
+	var cleaned []error
+	set := make(map[string]bool)
+	for _, err := range *e {
+		if msg := err.Error(); !set[msg] {
+			set[msg] = true
+			cleaned = append(cleaned, err)
+		}
+	}
+	*e = cleaned
+}
+
+func (e errList) Error() string {
+	switch len(e) {
+	case 0:
+		return ""
+	case 1:
+		return e[0].Error()
+	default:
+		var buf bytes.Buffer
+
+		for i, err := range e {
+			if i > 0 {
+				buf.WriteRune('\n')
+			}
+			buf.WriteString(err.Error())
+		}
+		return buf.String()
+	}
+}
+
+// parserError wraps an error with a prefix indicating the rule in which
+// the error occurred. The original error is stored in the Inner field.
+type parserError struct {
+	Inner    error
+	pos      position
+	prefix   string
+	expected []string
+}
+
+// Error returns the error message.
+func (p *parserError) Error() string {
+	return p.prefix + ": " + p.Inner.Error()
+}
+
+// newParser creates a parser with the specified input source and options.
+func newParser(filename string, b []byte, opts ...Option) *parser {
+	stats := Stats{
+		ChoiceAltCnt: make(map[string]map[string]int),
+	}
+
+	p := &parser{
+		filename: filename,
+		errs:     new(errList),
+		data:     b,
+		pt:       savepoint{position: position{line: 1}},
+		recover:  true,
+		cur: current{
+			state:       make(storeDict),
+			globalStore: make(storeDict),
+		},
+		maxFailPos:      position{col: 1, line: 1},
+		maxFailExpected: make([]string, 0, 20),
+		Stats:           &stats,
+		// start rule is rule [0] unless an alternate entrypoint is specified
+		entrypoint: g.rules[0].name,
+	}
+	p.setOptions(opts)
+
+	if p.maxExprCnt == 0 {
+		p.maxExprCnt = math.MaxUint64
+	}
+
+	return p
+}
+
+// setOptions applies the options to the parser.
+func (p *parser) setOptions(opts []Option) {
+	for _, opt := range opts {
+		opt(p)
+	}
+}
+
+// nolint: structcheck,deadcode
+type resultTuple struct {
+	v   any
+	b   bool
+	end savepoint
+}
+
+// nolint: varcheck
+const choiceNoMatch = -1
+
+// Stats stores some statistics, gathered during parsing
+type Stats struct {
+	// ExprCnt counts the number of expressions processed during parsing
+	// This value is compared to the maximum number of expressions allowed
+	// (set by the MaxExpressions option).
+	ExprCnt uint64
+
+	// ChoiceAltCnt is used to count for each ordered choice expression,
+	// which alternative is used how may times.
+	// These numbers allow to optimize the order of the ordered choice expression
+	// to increase the performance of the parser
+	//
+	// The outer key of ChoiceAltCnt is composed of the name of the rule as well
+	// as the line and the column of the ordered choice.
+	// The inner key of ChoiceAltCnt is the number (one-based) of the matching alternative.
+	// For each alternative the number of matches are counted. If an ordered choice does not
+	// match, a special counter is incremented. The name of this counter is set with
+	// the parser option Statistics.
+	// For an alternative to be included in ChoiceAltCnt, it has to match at least once.
+	ChoiceAltCnt map[string]map[string]int
+}
+
+// nolint: structcheck,maligned
+type parser struct {
+	filename string
+	pt       savepoint
+	cur      current
+
+	data []byte
+	errs *errList
+
+	depth   int
+	recover bool
+	debug   bool
+
+	memoize bool
+	// memoization table for the packrat algorithm:
+	// map[offset in source] map[expression or rule] {value, match}
+	memo map[int]map[any]resultTuple
+
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
+	// variables stack, map of label to value
+	vstack []map[string]any
+	// rule stack, allows identification of the current rule in errors
+	rstack []*rule
+
+	// parse fail
+	maxFailPos            position
+	maxFailExpected       []string
+	maxFailInvertExpected bool
+
+	// max number of expressions to be parsed
+	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
+	// entrypoint for the parser
+	entrypoint string
+
+	allowInvalidUTF8 bool
+
+	*Stats
+
+	choiceNoMatch string
+	// recovery expression stack, keeps track of the currently available recovery expression, these are traversed in reverse
+	recoveryStack []map[string]any
+}
+
+// push a variable set on the vstack.
+func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
+	if cap(p.vstack) == len(p.vstack) {
+		// create new empty slot in the stack
+		p.vstack = append(p.vstack, nil)
+	} else {
+		// slice to 1 more
+		p.vstack = p.vstack[:len(p.vstack)+1]
+	}
+
+	// get the last args set
+	m := p.vstack[len(p.vstack)-1]
+	if m != nil && len(m) == 0 {
+		// empty map, all good
+		return
+	}
+
+	m = make(map[string]any)
+	p.vstack[len(p.vstack)-1] = m
+}
+
+// pop a variable set from the vstack.
+func (p *parser) popV() {
+	// if the map is not empty, clear it
+	m := p.vstack[len(p.vstack)-1]
+	if len(m) > 0 {
+		// GC that map
+		p.vstack[len(p.vstack)-1] = nil
+	}
+	p.vstack = p.vstack[:len(p.vstack)-1]
+}
+
+// push a recovery expression with its labels to the recoveryStack
+func (p *parser) pushRecovery(labels []string, expr any) {
+	if cap(p.recoveryStack) == len(p.recoveryStack) {
+		// create new empty slot in the stack
+		p.recoveryStack = append(p.recoveryStack, nil)
+	} else {
+		// slice to 1 more
+		p.recoveryStack = p.recoveryStack[:len(p.recoveryStack)+1]
+	}
+
+	m := make(map[string]any, len(labels))
+	for _, fl := range labels {
+		m[fl] = expr
+	}
+	p.recoveryStack[len(p.recoveryStack)-1] = m
+}
+
+// pop a recovery expression from the recoveryStack
+func (p *parser) popRecovery() {
+	// GC that map
+	p.recoveryStack[len(p.recoveryStack)-1] = nil
+
+	p.recoveryStack = p.recoveryStack[:len(p.recoveryStack)-1]
+}
+
+func (p *parser) print(prefix, s string) string {
+	if !p.debug {
+		return s
+	}
+
+	fmt.Printf("%s %d:%d:%d: %s [%#U]\n",
+		prefix, p.pt.line, p.pt.col, p.pt.offset, s, p.pt.rn)
+	return s
+}
+
+func (p *parser) printIndent(mark string, s string) string {
+	return p.print(strings.Repeat(" ", p.depth)+mark, s)
+}
+
+func (p *parser) in(s string) string {
+	res := p.printIndent(">", s)
+	p.depth++
+	return res
+}
+
+func (p *parser) out(s string) string {
+	p.depth--
+	return p.printIndent("<", s)
+}
+
+func (p *parser) addErr(err error) {
+	p.addErrAt(err, p.pt.position, []string{})
+}
+
+func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
+	var buf bytes.Buffer
+	if p.filename != "" {
+		buf.WriteString(p.filename)
+	}
+	if buf.Len() > 0 {
+		buf.WriteString(":")
+	}
+	buf.WriteString(fmt.Sprintf("%d:%d (%d)", pos.line, pos.col, pos.offset))
+	if len(p.rstack) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString(": ")
+		}
+		rule := p.rstack[len(p.rstack)-1]
+		if rule.displayName != "" {
+			buf.WriteString("rule " + rule.displayName)
+		} else {
+			buf.WriteString("rule " + rule.name)
+		}
+	}
+	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
+	return pe
+}
+
+func (p *parser) failAt(fail bool, pos position, want string) {
+	// process fail if parsing fails and not inverted or parsing succeeds and invert is set
+	if fail == p.maxFailInvertExpected {
+		if pos.offset < p.maxFailPos.offset {
+			return
+		}
+
+		if pos.offset > p.maxFailPos.offset {
+			p.maxFailPos = pos
+			p.maxFailExpected = p.maxFailExpected[:0]
+		}
+
+		if p.maxFailInvertExpected {
+			want = "!" + want
+		}
+		p.maxFailExpected = append(p.maxFailExpected, want)
+	}
+}
+
+// read advances the parser to the next rune.
+func (p *parser) read() {
+	p.pt.offset += p.pt.w
+	rn, n := utf8.DecodeRune(p.data[p.pt.offset:])
+	p.pt.rn = rn
+	p.pt.w = n
+	p.pt.col++
+	if rn == '\n' {
+		p.pt.line++
+		p.pt.col = 0
+	}
+
+	if rn == utf8.RuneError && n == 1 { // see utf8.DecodeRune
+		if !p.allowInvalidUTF8 {
+			p.addErr(errInvalidEncoding)
+		}
+	}
+}
+
+// restore parser position to the savepoint pt.
+func (p *parser) restore(pt savepoint) {
+	if p.debug {
+		defer p.out(p.in("restore"))
+	}
+	if pt.offset == p.pt.offset {
+		return
+	}
+	p.pt = pt
+}
+
+// Cloner is implemented by any value that has a Clone method, which returns a
+// copy of the value. This is mainly used for types which are not passed by
+// value (e.g map, slice, chan) or structs that contain such types.
+//
+// This is used in conjunction with the global state feature to create proper
+// copies of the state to allow the parser to properly restore the state in
+// the case of backtracking.
+type Cloner interface {
+	Clone() any
+}
+
+var statePool = &sync.Pool{
+	New: func() any { return make(storeDict) },
+}
+
+func (sd storeDict) Discard() {
+	for k := range sd {
+		delete(sd, k)
+	}
+	statePool.Put(sd)
+}
+
+// clone and return parser current state.
+func (p *parser) cloneState() storeDict {
+	if p.debug {
+		defer p.out(p.in("cloneState"))
+	}
+
+	state := statePool.Get().(storeDict)
+	for k, v := range p.cur.state {
+		if c, ok := v.(Cloner); ok {
+			state[k] = c.Clone()
+		} else {
+			state[k] = v
+		}
+	}
+	return state
+}
+
+// restore parser current state to the state storeDict.
+// every restoreState should applied only one time for every cloned state
+func (p *parser) restoreState(state storeDict) {
+	if p.debug {
+		defer p.out(p.in("restoreState"))
+	}
+	p.cur.state.Discard()
+	p.cur.state = state
+}
+
+// get the slice of bytes from the savepoint start to the current position.
+func (p *parser) sliceFrom(start savepoint) []byte {
+	return p.data[start.position.offset:p.pt.position.offset]
+}
+
+func (p *parser) getMemoized(node any) (resultTuple, bool) {
+	if len(p.memo) == 0 {
+		return resultTuple{}, false
+	}
+	m := p.memo[p.pt.offset]
+	if len(m) == 0 {
+		return resultTuple{}, false
+	}
+	res, ok := m[node]
+	return res, ok
+}
+
+func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
+	if p.memo == nil {
+		p.memo = make(map[int]map[any]resultTuple)
+	}
+	m := p.memo[pt.offset]
+	if m == nil {
+		m = make(map[any]resultTuple)
+		p.memo[pt.offset] = m
+	}
+	m[node] = tuple
+}
+
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
+	}
+	return nil
+}
+
+// nolint: gocyclo
+func (p *parser) parse(g *grammar) (val any, err error) {
+	if len(g.rules) == 0 {
+		p.addErr(errNoRule)
+		return nil, p.errs.err()
+	}
+
+	// TODO : not super critical but this could be generated
+	p.rules = g.rules
+
+	if p.recover {
+		// panic can be used in action code to stop parsing immediately
+		// and return the panic as an error.
+		defer func() {
+			if e := recover(); e != nil {
+				if p.debug {
+					defer p.out(p.in("panic handler"))
+				}
+				val = nil
+				switch e := e.(type) {
+				case error:
+					p.addErr(e)
+				default:
+					p.addErr(fmt.Errorf("%v", e))
+				}
+				err = p.errs.err()
+			}
+		}()
+	}
+
+	startRule := p.entrypointRule()
+	if startRule == nil {
+		p.addErr(errInvalidEntrypoint)
+		return nil, p.errs.err()
+	}
+
+	p.read() // advance to first rune
+	var ok bool
+	val, ok = p.parseRuleWrap(startRule)
+	if !ok {
+		if len(*p.errs) == 0 {
+			// If parsing fails, but no errors have been recorded, the expected values
+			// for the farthest parser position are returned as error.
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
+		}
+
+		return nil, p.errs.err()
+	}
+	return val, p.errs.err()
+}
+
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
+func listJoin(list []string, sep string, lastSep string) string {
+	switch len(list) {
+	case 0:
+		return ""
+	case 1:
+		return list[0]
+	default:
+		return strings.Join(list[:len(list)-1], sep) + " " + lastSep + " " + list[len(list)-1]
+	}
+}
+
+func (p *parser) parseRuleMemoize(rule *rule) (any, bool) {
+	res, ok := p.getMemoized(rule)
+	if ok {
+		p.restore(res.end)
+		return res.v, res.b
+	}
+
+	startMark := p.pt
+	val, ok := p.parseRule(rule)
+	p.setMemoized(startMark, rule, resultTuple{val, ok, p.pt})
+
+	return val, ok
+}
+
+func (p *parser) parseRuleWrap(rule *rule) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseRule " + rule.name))
+	}
+	var (
+		val       any
+		ok        bool
+		startMark = p.pt
+	)
+
+	if p.memoize {
+		val, ok = p.parseRuleMemoize(rule)
+	} else {
+		val, ok = p.parseRule(rule)
+	}
+
+	if ok && p.debug {
+		p.printIndent("MATCH", string(p.sliceFrom(startMark)))
+	}
+	return val, ok
+}
+
+func (p *parser) parseRule(rule *rule) (any, bool) {
+	p.rstack = append(p.rstack, rule)
+	p.pushV()
+	val, ok := p.parseExprWrap(rule.expr)
+	p.popV()
+	p.rstack = p.rstack[:len(p.rstack)-1]
+	return val, ok
+}
+
+func (p *parser) parseExprWrap(expr any) (any, bool) {
+	var pt savepoint
+
+	if p.memoize {
+		res, ok := p.getMemoized(expr)
+		if ok {
+			p.restore(res.end)
+			return res.v, res.b
+		}
+		pt = p.pt
+	}
+
+	val, ok := p.parseExpr(expr)
+
+	if p.memoize {
+		p.setMemoized(pt, expr, resultTuple{val, ok, p.pt})
+	}
+	return val, ok
+}
+
+// nolint: gocyclo
+func (p *parser) parseExpr(expr any) (any, bool) {
+	p.ExprCnt++
+	if p.ExprCnt > p.maxExprCnt {
+		panic(errMaxExprCnt)
+	}
+
+	var val any
+	var ok bool
+	switch expr := expr.(type) {
+	case *actionExpr:
+		val, ok = p.parseActionExpr(expr)
+	case *andCodeExpr:
+		val, ok = p.parseAndCodeExpr(expr)
+	case *andExpr:
+		val, ok = p.parseAndExpr(expr)
+	case *anyMatcher:
+		val, ok = p.parseAnyMatcher(expr)
+	case *charClassMatcher:
+		val, ok = p.parseCharClassMatcher(expr)
+	case *choiceExpr:
+		val, ok = p.parseChoiceExpr(expr)
+	case *labeledExpr:
+		val, ok = p.parseLabeledExpr(expr)
+	case *litMatcher:
+		val, ok = p.parseLitMatcher(expr)
+	case *notCodeExpr:
+		val, ok = p.parseNotCodeExpr(expr)
+	case *notExpr:
+		val, ok = p.parseNotExpr(expr)
+	case *oneOrMoreExpr:
+		val, ok = p.parseOneOrMoreExpr(expr)
+	case *recoveryExpr:
+		val, ok = p.parseRecoveryExpr(expr)
+	case *ruleRefExpr:
+		val, ok = p.parseRuleRefExpr(expr)
+	case *seqExpr:
+		val, ok = p.parseSeqExpr(expr)
+	case *stateCodeExpr:
+		val, ok = p.parseStateCodeExpr(expr)
+	case tableExpr:
+		val, ok = p.execInstr(expr)
+	case *throwExpr:
+		val, ok = p.parseThrowExpr(expr)
+	case *zeroOrMoreExpr:
+		val, ok = p.parseZeroOrMoreExpr(expr)
+	case *zeroOrOneExpr:
+		val, ok = p.parseZeroOrOneExpr(expr)
+	default:
+		panic(fmt.Sprintf("unknown expression type %T", expr))
+	}
+	return val, ok
+}
+
+// execInstr runs the instruction ix of the table of a table-driven parser.
+// The sub-instructions are run by parseExprWrap, as the sub-expressions of
+// the other expressions, so that they are memoized and counted the same.
+//
+//	nolint: gocyclo
+func (p *parser) execInstr(ix tableExpr) (any, bool) {
+	in := &grammarTable.instrs[ix]
+	sub := grammarTable.children[in.sub : in.sub+in.n]
+	if p.debug {
+		defer p.out(p.in("execInstr " + in.op.String()))
+	}
+
+	switch in.op {
+	case opAction:
+		start := p.pt
+		val, ok := p.parseExprWrap(tableExpr(sub[0]))
+		if ok {
+			p.cur.pos = start.position
+			p.cur.text = p.sliceFrom(start)
+			state := p.cloneState()
+			actVal, err := grammarTable.actions[in.arg](p)
+			if err != nil {
+				p.addErrAt(err, start.position, []string{})
+			}
+			p.restoreState(state)
+			val = actVal
+		}
+		if ok && p.debug {
+			p.printIndent("MATCH", string(p.sliceFrom(start)))
+		}
+		return val, ok
+
+	case opAndCode, opNotCode:
+		state := p.cloneState()
+		ok, err := grammarTable.preds[in.arg](p)
+		if err != nil {
+			p.addErr(err)
+		}
+		p.restoreState(state)
+		return nil, ok == (in.op == opAndCode)
+
+	case opAnd, opNot:
+		not := in.op == opNot
+		pt := p.pt
+		state := p.cloneState()
+		p.pushV()
+		if not {
+			p.maxFailInvertExpected = !p.maxFailInvertExpected
+		}
+		_, ok := p.parseExprWrap(tableExpr(sub[0]))
+		if not {
+			p.maxFailInvertExpected = !p.maxFailInvertExpected
+		}
+		p.popV()
+		p.restoreState(state)
+		p.restore(pt)
+		return nil, ok != not
+
+	case opAny:
+		return p.parseAnyMatcher((*anyMatcher)(&in.pos))
+
+	case opCharClass:
+		return p.parseCharClassMatcher(grammarTable.classes[in.arg])
+
+	case opChoice:
+		for altI, alt := range sub {
+			// dummy assignment to prevent compile error if optimized
+			_ = altI
+
+			state := p.cloneState()
+			p.pushV()
+			val, ok := p.parseExprWrap(tableExpr(alt))
+			p.popV()
+			if ok {
+				p.incChoiceAltCnt(in.pos, altI)
+				return val, ok
+			}
+			p.restoreState(state)
+		}
+		p.incChoiceAltCnt(in.pos, choiceNoMatch)
+		return nil, false
+
+	case opLabeled:
+		p.pushV()
+		val, ok := p.parseExprWrap(tableExpr(sub[0]))
+		p.popV()
+		label := grammarTable.labels[in.arg]
+		if ok && label != "" {
+			p.vstack[len(p.vstack)-1][label] = val
+		}
+		return val, ok
+
+	case opLit:
+		return p.parseLitMatcher(grammarTable.lits[in.arg])
+
+	case opOneOrMore, opZeroOrMore:
+		var vals []any
+		matched := false
+		for {
+			p.pushV()
+			val, ok := p.parseExprWrap(tableExpr(sub[0]))
+			p.popV()
+			if !ok {
+				if in.op == opOneOrMore && !matched {
+					// did not match once, no match
+					return nil, false
+				}
+				return vals, true
+			}
+			matched = true
+			vals = append(vals, val)
+		}
+
+	case opRuleRef:
+		if in.arg > len(p.rules)-1 {
+			panic(fmt.Sprintf("%s: invalid rule: out of range", in.pos))
+		}
+		rule := p.rules[in.arg]
+		if p.debug {
+			defer p.out(p.in("parseRuleRefExpr " + rule.name))
+		}
+		return p.parseRuleWrap(rule)
+
+	case opSeq:
+		vals := make([]any, 0, len(sub))
+		pt := p.pt
+		state := p.cloneState()
+		for _, ix := range sub {
+			val, ok := p.parseExprWrap(tableExpr(ix))
+			if !ok {
+				p.restoreState(state)
+				p.restore(pt)
+				return nil, false
+			}
+			vals = append(vals, val)
+		}
+		return vals, true
+
+	case opStateCode:
+		if err := grammarTable.states[in.arg](p); err != nil {
+			p.addErr(err)
+		}
+		return nil, true
+
+	case opZeroOrOne:
+		p.pushV()
+		val, _ := p.parseExprWrap(tableExpr(sub[0]))
+		p.popV()
+		// whether it matched or not, consider it a match
+		return val, true
+	}
+	panic(fmt.Sprintf("%s: invalid instruction %s", in.pos, in.op))
+}
+
+func (p *parser) parseActionExpr(act *actionExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseActionExpr"))
+	}
+
+	start := p.pt
+	val, ok := p.parseExprWrap(act.expr)
+	if ok {
+		p.cur.pos = start.position
+		p.cur.text = p.sliceFrom(start)
+		state := p.cloneState()
+		actVal, err := act.run(p)
+		if err != nil {
+			p.addErrAt(err, start.position, []string{})
+		}
+		p.restoreState(state)
+
+		val = actVal
+	}
+	if ok && p.debug {
+		p.printIndent("MATCH", string(p.sliceFrom(start)))
+	}
+	return val, ok
+}
+
+func (p *parser) parseAndCodeExpr(and *andCodeExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAndCodeExpr"))
+	}
+
+	state := p.cloneState()
+
+	ok, err := and.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	p.restoreState(state)
+
+	return nil, ok
+}
+
+func (p *parser) parseAndExpr(and *andExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAndExpr"))
+	}
+
+	pt := p.pt
+	state := p.cloneState()
+	p.pushV()
+	_, ok := p.parseExprWrap(and.expr)
+	p.popV()
+	p.restoreState(state)
+	p.restore(pt)
+
+	return nil, ok
+}
+
+func (p *parser) parseAnyMatcher(any *anyMatcher) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseAnyMatcher"))
+	}
+
+	if p.pt.rn == utf8.RuneError && p.pt.w == 0 {
+		// EOF - see utf8.DecodeRune
+		p.failAt(false, p.pt.position, ".")
+		return nil, false
+	}
+	start := p.pt
+	p.read()
+	p.failAt(true, start.position, ".")
+	return p.sliceFrom(start), true
+}
+
+// nolint: gocyclo
+func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseCharClassMatcher"))
+	}
+
+	cur := p.pt.rn
+	start := p.pt
+
+	// can't match EOF
+	if cur == utf8.RuneError && p.pt.w == 0 { // see utf8.DecodeRune
+		p.failAt(false, start.position, chr.val)
+		return nil, false
+	}
+
+	if chr.ignoreCase {
+		cur = unicode.ToLower(cur)
+	}
+
+	// try to match in the list of available chars
+	for _, rn := range chr.chars {
+		if rn == cur {
+			if chr.inverted {
+				p.failAt(false, start.position, chr.val)
+				return nil, false
+			}
+			p.read()
+			p.failAt(true, start.position, chr.val)
+			return p.sliceFrom(start), true
+		}
+	}
+
+	// try to match in the list of ranges
+	for i := 0; i < len(chr.ranges); i += 2 {
+		if cur >= chr.ranges[i] && cur <= chr.ranges[i+1] {
+			if chr.inverted {
+				p.failAt(false, start.position, chr.val)
+				return nil, false
+			}
+			p.read()
+			p.failAt(true, start.position, chr.val)
+			return p.sliceFrom(start), true
+		}
+	}
+
+	// try to match in the list of Unicode classes
+	for _, cl := range chr.classes {
+		if unicode.Is(cl, cur) {
+			if chr.inverted {
+				p.failAt(false, start.position, chr.val)
+				return nil, false
+			}
+			p.read()
+			p.failAt(true, start.position, chr.val)
+			return p.sliceFrom(start), true
+		}
+	}
+
+	if chr.inverted {
+		p.read()
+		p.failAt(true, start.position, chr.val)
+		return p.sliceFrom(start), true
+	}
+	p.failAt(false, start.position, chr.val)
+	return nil, false
+}
+
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
+	m := p.ChoiceAltCnt[choiceIdent]
+	if m == nil {
+		m = make(map[string]int)
+		p.ChoiceAltCnt[choiceIdent] = m
+	}
+	// We increment altI by 1, so the keys do not start at 0
+	alt := strconv.Itoa(altI + 1)
+	if altI == choiceNoMatch {
+		alt = p.choiceNoMatch
+	}
+	m[alt]++
+}
+
+func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseChoiceExpr"))
+	}
+
+	for altI, alt := range ch.alternatives {
+		// dummy assignment to prevent compile error if optimized
+		_ = altI
+
+		state := p.cloneState()
+
+		p.pushV()
+		val, ok := p.parseExprWrap(alt)
+		p.popV()
+		if ok {
+			p.incChoiceAltCnt(ch.pos, altI)
+			return val, ok
+		}
+		p.restoreState(state)
+	}
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
+	return nil, false
+}
+
+func (p *parser) parseLabeledExpr(lab *labeledExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLabeledExpr"))
+	}
+
+	p.pushV()
+	val, ok := p.parseExprWrap(lab.expr)
+	p.popV()
+	if ok && lab.label != "" {
+		m := p.vstack[len(p.vstack)-1]
+		m[lab.label] = val
+	}
+	return val, ok
+}
+
+func (p *parser) parseLitMatcher(lit *litMatcher) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseLitMatcher"))
+	}
+
+	start := p.pt
+	for _, want := range lit.val {
+		cur := p.pt.rn
+		if lit.ignoreCase {
+			cur = unicode.ToLower(cur)
+		}
+		if cur != want {
+			p.failAt(false, start.position, lit.want)
+			p.restore(start)
+			return nil, false
+		}
+		p.read()
+	}
+	p.failAt(true, start.position, lit.want)
+	return p.sliceFrom(start), true
+}
+
+func (p *parser) parseNotCodeExpr(not *notCodeExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNotCodeExpr"))
+	}
+
+	state := p.cloneState()
+
+	ok, err := not.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	p.restoreState(state)
+
+	return nil, !ok
+}
+
+func (p *parser) parseNotExpr(not *notExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseNotExpr"))
+	}
+
+	pt := p.pt
+	state := p.cloneState()
+	p.pushV()
+	p.maxFailInvertExpected = !p.maxFailInvertExpected
+	_, ok := p.parseExprWrap(not.expr)
+	p.maxFailInvertExpected = !p.maxFailInvertExpected
+	p.popV()
+	p.restoreState(state)
+	p.restore(pt)
+
+	return nil, !ok
+}
+
+func (p *parser) parseOneOrMoreExpr(expr *oneOrMoreExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseOneOrMoreExpr"))
+	}
+
+	var vals []any
+
+	for {
+		p.pushV()
+		val, ok := p.parseExprWrap(expr.expr)
+		p.popV()
+		if !ok {
+			if len(vals) == 0 {
+				// did not match once, no match
+				return nil, false
+			}
+			return vals, true
+		}
+		vals = append(vals, val)
+	}
+}
+
+func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseRecoveryExpr (" + strings.Join(recover.failureLabel, ",") + ")"))
+	}
+
+	p.pushRecovery(recover.failureLabel, recover.recoverExpr)
+	val, ok := p.parseExprWrap(recover.expr)
+	p.popRecovery()
+
+	return val, ok
+}
+
+func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
+	}
+
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
+	}
+
+	return p.parseRuleWrap(rule)
+}
+
+func (p *parser) parseSeqExpr(seq *seqExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseSeqExpr"))
+	}
+
+	vals := make([]any, 0, len(seq.exprs))
+
+	pt := p.pt
+	state := p.cloneState()
+	for _, expr := range seq.exprs {
+		val, ok := p.parseExprWrap(expr)
+		if !ok {
+			p.restoreState(state)
+			p.restore(pt)
+			return nil, false
+		}
+		vals = append(vals, val)
+	}
+	return vals, true
+}
+
+func (p *parser) parseStateCodeExpr(state *stateCodeExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseStateCodeExpr"))
+	}
+
+	err := state.run(p)
+	if err != nil {
+		p.addErr(err)
+	}
+	return nil, true
+}
+
+func (p *parser) parseThrowExpr(expr *throwExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseThrowExpr"))
+	}
+
+	for i := len(p.recoveryStack) - 1; i >= 0; i-- {
+		if recoverExpr, ok := p.recoveryStack[i][expr.label]; ok {
+			if val, ok := p.parseExprWrap(recoverExpr); ok {
+				return val, ok
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (p *parser) parseZeroOrMoreExpr(expr *zeroOrMoreExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseZeroOrMoreExpr"))
+	}
+
+	var vals []any
+
+	for {
+		p.pushV()
+		val, ok := p.parseExprWrap(expr.expr)
+		p.popV()
+		if !ok {
+			return vals, true
+		}
+		vals = append(vals, val)
+	}
+}
+
+func (p *parser) parseZeroOrOneExpr(expr *zeroOrOneExpr) (any, bool) {
+	if p.debug {
+		defer p.out(p.in("parseZeroOrOneExpr"))
+	}
+
+	p.pushV()
+	val, _ := p.parseExprWrap(expr.expr)
+	p.popV()
+	// whether it matched or not, consider it a match
+	return val, true
+}
@@ -34,28 +34,194 @@ func (r *ruleNamesFlag) Set(value string) error {
 	return nil
 }
 
+// fileNamesFlag is a custom flag that parses a comma-separated
+// list of file names and may be repeated. It implements flag.Value.
+type fileNamesFlag []string
+
+func (f *fileNamesFlag) String() string {
+	return fmt.Sprint(*f)
+}
+
+func (f *fileNamesFlag) Set(value string) error {
+	for _, nm := range strings.Split(value, ",") {
+		if nm == "" {
+			return fmt.Errorf("invalid list of files %q, expected FILE[,FILE...]", value)
+		}
+		*f = append(*f, nm)
+	}
+	return nil
+}
+
+// ruleMessagesFlag is a custom flag that parses a RULE=MESSAGE pair
+// and may be repeated. It implements flag.Value.
+type ruleMessagesFlag map[string]string
+
+func (r ruleMessagesFlag) String() string {
+	return fmt.Sprint(map[string]string(r))
+}
+
+func (r ruleMessagesFlag) Set(value string) error {
+	nm, msg, ok := strings.Cut(value, "=")
+	if !ok || nm == "" {
+		return fmt.Errorf("invalid rule error message %q, expected RULE=MESSAGE", value)
+	}
+	r[nm] = msg
+	return nil
+}
+
+// ruleAssertionsFlag is a custom flag that parses a RULE=EXPR pair
+// and may be repeated. It implements flag.Value.
+type ruleAssertionsFlag map[string]string
+
+func (r ruleAssertionsFlag) String() string {
+	return fmt.Sprint(map[string]string(r))
+}
+
+func (r ruleAssertionsFlag) Set(value string) error {
+	nm, expr, ok := strings.Cut(value, "=")
+	if !ok || nm == "" || expr == "" {
+		return fmt.Errorf("invalid rule assertion %q, expected RULE=EXPR", value)
+	}
+	r[nm] = expr
+	return nil
+}
+
+// prefixDispatchFlag is a custom flag that parses a PREFIX=RULE pair
+// and may be repeated. It implements flag.Value.
+type prefixDispatchFlag map[string]string
+
+func (r prefixDispatchFlag) String() string {
+	return fmt.Sprint(map[string]string(r))
+}
+
+func (r prefixDispatchFlag) Set(value string) error {
+	// the prefix may contain '=', not the rule name
+	ix := strings.LastIndex(value, "=")
+	if ix <= 0 || ix == len(value)-1 {
+		return fmt.Errorf("invalid prefix dispatch %q, expected PREFIX=RULE", value)
+	}
+	r[value[:ix]] = value[ix+1:]
+	return nil
+}
+
 func main() {
 	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 
 	// define command-line flags
 	var (
+		asciiFastPathFlag      = fs.Bool("ascii-fast-path", false, "read the runes of ASCII-only inputs byte by byte instead of decoding them")
+		autoMapResultsFlag     = fs.Bool("auto-map-results", false, "build the results of the rules without action from their item, key, value and text labels")
+		backtrackHooksFlag     = fs.Bool("backtrack-hooks", false, "allow actions to register functions called when the parser backtracks over their match")
+		baseGrammarFlag        = fs.String("base-grammar", "", "grammar file whose rules are inherited by the grammar, unless it redefines them")
+		batchParseFlag         = fs.Bool("batch-parse", false, "generate the ParseBatch function parsing many inputs concurrently")
+		bidiAwareFlag          = fs.Bool("bidi-aware", false, "generate the Direction method of the errors returning the direction of the text at their position")
+		boundedStreamFlag      = fs.Int("bounded-stream", 0, "generate the ParseStream function keeping this many bytes of input to backtrack")
+		builtinAssertionsFlag  = fs.Bool("builtin-assertions", false, "allow the word boundary (\\b) and line end ($) assertions in the grammar")
 		cacheFlag              = fs.Bool("cache", false, "cache parsing results")
+		caseScopesFlag         = fs.Bool("case-scopes", false, "allow case-insensitive regions (?i: ...) in the grammar")
+		choiceIndexFlag        = fs.Bool("choice-index", false, "allow actions to get the index of the alternative that matched with c.choiceIndex()")
+		chromeTraceFlag        = fs.Bool("chrome-trace", false, "generate the ChromeTrace option writing the rules entered and exited in the Chrome tracing format")
+		compactASTFlag         = fs.Bool("compact-ast", false, "generate the ParseCompact function returning the concrete syntax tree of the input in a single slice")
+		completionSupportFlag  = fs.Bool("completion-support", false, "generate the Suggest function returning the tokens expected at a position of the input")
+		followSetsFlag         = fs.Bool("compute-follow-sets", false, "list the expected tokens of parse errors from the FIRST and FOLLOW sets of the rules")
 		dbgFlag                = fs.Bool("debug", false, "set debug mode")
+		dedupeCharClassesFlag  = fs.Bool("dedupe-char-classes", false, "write identical character class matchers once as shared variables")
+		denseMemoFlag          = fs.Bool("dense-memo", false, "memoize the results of the rules in a table indexed by rule and offset instead of maps")
+		emitBenchmarksFlag     = fs.Bool("emit-benchmarks", false, "write a benchmark test file for the generated parser next to the output file")
+		emitMustParseFlag      = fs.Bool("emit-must-parse", false, "generate the MustParse function panicking if the input cannot be parsed")
+		emitReprinterFlag      = fs.Bool("emit-reprinter", false, "generate the Reprint function returning the exact input matched by a syntax tree node")
+		emitResultDiffFlag     = fs.Bool("emit-result-diff", false, "generate the DiffResults function reporting the differences between two parse results")
+		emitRuleDocsFlag       = fs.Bool("emit-rule-docs", false, "generate the RuleDoc function returning the comments that document the rules")
+		emitValidateFlag       = fs.Bool("emit-validate", false, "generate the Validate function matching the input without building values")
+		emptyInputFlag         = fs.String("empty-input", "normal", "handling of the empty input, \"error\", \"nil\" or \"normal\"")
+		entrypointTypeFlag     = fs.String("entrypoint-type", "", "generate the ParseTyped function returning the result of the entrypoint as this type")
+		enumerateParsesFlag    = fs.Int("enumerate-parses", 0, "generate the ParseAll function returning up to this many distinct parses of an ambiguous input")
+		errorRepairFlag        = fs.Bool("error-repair", false, "retry a failed parse inserting the missing literals")
+		errorSpansFlag         = fs.Bool("error-spans", false, "generate the Span method returning the input range of the parsing errors")
+		explainFailuresFlag    = fs.Bool("explain-failures", false, "generate the Explanation method describing the farthest failure of a parse in prose")
+		explicitStackFlag      = fs.Bool("explicit-stack", false, "generate a table-driven parser running with an explicit work stack instead of recursive calls")
+		expandIgnoreCaseFlag   = fs.Bool("expand-ignore-case-classes", false, "write the case variants of case-insensitive character classes")
+		expvarMetricsFlag      = fs.String("expvar-metrics", "", "publish the parse metrics with expvar in a map with this name")
+		fuzzyMatchFlag         = fs.Int("fuzzy-match", 0, "maximum number of single-character corrections allowed when matching literals")
+		grammarLineMapFlag     = fs.Bool("grammar-line-map", false, "generate the GrammarLines function returning the range of lines of each rule")
+		graphemeInputFlag      = fs.Bool("grapheme-input", false, "generate the ParseRunes function matching the any matcher against grapheme clusters")
+		guardOptimizationFlag  = fs.Bool("guard-optimization", false, "peek at the input for predicates of a literal or character class")
 		shortHelpFlag          = fs.Bool("h", false, "show help page")
 		longHelpFlag           = fs.Bool("help", false, "show help page")
+		labelSpansFlag         = fs.Bool("label-spans", false, "record the input extent of labeled expressions for action code blocks")
+		lazyRuleInitFlag       = fs.Bool("lazy-rule-init", false, "build the expression of each rule the first time the rule is matched instead of at startup")
+		legacyGoCompatFlag     = fs.Bool("legacy-go-compat", false, "use interface{} instead of any in the generated parser, for Go versions before 1.18")
+		lineRecoveryFlag       = fs.Bool("line-recovery", false, "generate the ParseLines function skipping to the next line when an item fails")
+		localeFoldFlag         = fs.String("locale-fold", "", "locale of the case folding of case-insensitive matchers, \"tr\" or \"az\"")
+		longestMatchFlag       = fs.Bool("longest-match-diagnostics", false, "log in debug mode the ordered choices where a later alternative matches more input")
+		losslessCSTFlag        = fs.Bool("lossless-cst", false, "generate the ParseCST function returning the lossless concrete syntax tree of the input")
+		matcherIfaceFlag       = fs.Bool("matcher-interface", false, "parse the expressions through a matcher interface to allow custom expression types")
+		memoKeyHookFlag        = fs.Bool("memo-key-hook", false, "generate the MemoKey option to add a discriminator to the memoization keys")
+		memoReuseTraceFlag     = fs.Bool("memo-reuse-trace", false, "generate the MemoReuses option recording the memoized results reused by the parser")
+		methodReceiverFlag     = fs.String("method-receiver", "", "generate the Parse entrypoints as methods of the pointer to this type of the package")
+		modeAwareMemoFlag      = fs.Bool("mode-aware-memo", false, "ignore the memoized results parsed before a state code block changed the parser mode")
+		mmapInputFlag          = fs.Bool("mmap-input", false, "write a file next to the output file with the ParseMmap function parsing a memory-mapped file")
 		nolint                 = fs.Bool("nolint", false, "add '// nolint: ...' comments to suppress warnings by gometalinter or golangci-lint")
 		noRecoverFlag          = fs.Bool("no-recover", false, "do not recover from panic")
+		numericHelpersFlag     = fs.Bool("numeric-helpers", false, "generate int, float and uint helper methods on the current type")
 		outputFlag             = fs.String("o", "", "output file, defaults to stdout")
 		optimizeBasicLatinFlag = fs.Bool("optimize-basic-latin", false, "generate optimized parser for Unicode Basic Latin character sets")
 		optimizeGrammar        = fs.Bool("optimize-grammar", false, "optimize the given grammar (EXPERIMENTAL FEATURE)")
 		optimizeParserFlag     = fs.Bool("optimize-parser", false, "generate optimized parser without Debug and Memoize options")
+		optRulesExceptFlag     = fs.Bool("optimize-rules-except", false, "optimize all the rules except those listed with -optimize-rules")
+		otelSpansFlag          = fs.Bool("otel-spans", false, "generate the WithContext option wrapping the parses in the spans of the tracer of the context")
+		overridableActionsFlag = fs.Bool("overridable-actions", false, "call actions through a map of functions that can be replaced with the SetAction option")
+		preprocessorsFlag      = fs.Bool("preprocessors", false, "generate the Preprocess option running preprocessing stages on the input")
+		progressFlag           = fs.Bool("progress-callback", false, "generate the Progress option to report the progress of the parsing")
+		protoResultsFlag       = fs.String("proto-results", "", "write a .proto file next to the output file with a message of this name for the parse results")
+		pushParserFlag         = fs.Bool("push-parser", false, "generate the PushParser type parsing the items of an input fed in chunks")
 		recvrNmFlag            = fs.String("receiver-name", "c", "receiver name for the generated methods")
+		replModeFlag           = fs.Bool("repl-mode", false, "generate the REPL type accumulating lines of input until they match")
+		reportMaxDepthFlag     = fs.Bool("report-max-depth", false, "generate the ReachedDepth option recording the deepest nesting reached while parsing")
+		ruleBoundaryPosFlag    = fs.Bool("rule-boundary-positions", false, "generate the RulePositions option recording the start and end positions of the matched rules")
+		ruleIfaceFlag          = fs.String("rule-interface", "", "IMPORTPATH.NAME of an interface implemented by the generated rule type")
+		ruleTimingFlag         = fs.Bool("rule-timing", false, "generate the RuleTimes option to measure the time spent in each rule")
+		runCharClassFlag       = fs.Bool("run-char-class", false, "match repetitions of a character class in a single loop")
+		runtimeParamsFlag      = fs.Bool("runtime-params", false, "allow the rule references to pass arguments read with c.param to the rules they reference")
+		sinkResultsFlag        = fs.Bool("sink-results", false, "generate the ParseTo function pushing the top-level results to a sink function")
+		sortFunctionsFlag      = fs.Bool("sort-functions", false, "write the functions generated for code blocks sorted by name")
 		noBuildFlag            = fs.Bool("x", false, "do not build, only parse")
+		streamBufferFlag       = fs.Int("stream-buffer", 0, "generate the ParseChan function sending the results of ParseTo to a channel with this buffer size")
+		structuredTraceFlag    = fs.Bool("structured-trace", false, "generate the Trace option to report the rules entered and exited to a TraceLogger")
+		subParseFlag           = fs.Bool("sub-parse", false, "generate the ParseSub function parsing the input from an offset with a rule")
 		supportLeftRecursion   = fs.Bool("support-left-recursion", false, "add support left recursion (EXPERIMENTAL FEATURE)")
+		switchDispatchFlag     = fs.Bool("switch-dispatch", false, "call the functions of the code blocks by a switch on their code instead of method values")
+		tableDrivenFlag        = fs.Bool("table-driven", false, "generate a parser interpreting a table of instructions compiled from the rules")
+		typedThrowsFlag        = fs.Bool("typed-throws", false, "allow the throw expressions to carry the value of a code block as payload")
+		untilEOFFlag           = fs.Bool("until-eof", false, "match up to the end of the input in until expressions whose terminator is not found")
+		userDataFlag           = fs.Bool("user-data", false, "generate the UserData option attaching a value to the parse, read by the code blocks with c.data()")
+		validateActionsFlag    = fs.Bool("validate-actions", false, "report the syntax errors of the code blocks at generation time")
+		warnShadowedClsFlag    = fs.Bool("warn-shadowed-classes", false, "warn about alternatives that can only start with characters that an earlier alternative starting with a character class can start with")
+		warnShadowedRecFlag    = fs.Bool("warn-shadowed-recovery", false, "warn about recovery expressions that can match the same input as the expression they recover")
+		warnUnusedLabelsFlag   = fs.Bool("warn-unused-labels", false, "warn about labels that are not used by a code block")
+		wasmExportsFlag        = fs.Bool("wasm-exports", false, "generate the ParseJSON function and a file exposing it to JavaScript in WebAssembly")
+		zeroCopyTextFlag       = fs.Bool("zero-copy-text", false, "generate the textBytes method returning the text of the current match without copying it")
+		warnEmptyRepFlag       = fs.Bool("warn-empty-repetition", false, "warn about repetitions of expressions that can match the empty string")
 
 		altEntrypointsFlag ruleNamesFlag
+		benchInputsFlag    fileNamesFlag
+		leftRecRulesFlag   ruleNamesFlag
+		optimizeRulesFlag  ruleNamesFlag
+		selfTestFailFlag   fileNamesFlag
+		selfTestPassFlag   fileNamesFlag
+		ruleMessagesFlag   = ruleMessagesFlag{}
+		ruleAssertionsFlag = ruleAssertionsFlag{}
+		prefixDispatchFlag = prefixDispatchFlag{}
 	)
 	fs.Var(&altEntrypointsFlag, "alternate-entrypoints", "comma-separated list of rule names that may be used as entrypoints")
+	fs.Var(&benchInputsFlag, "benchmark-input", "comma-separated list of files parsed by the benchmark of -emit-benchmarks")
+	fs.Var(&leftRecRulesFlag, "left-recursive-rules", "comma-separated list of rule names that are intentionally left-recursive")
+	fs.Var(&optimizeRulesFlag, "optimize-rules", "comma-separated list of rule names matched without debug output nor memoization")
+	fs.Var(&selfTestFailFlag, "self-test-fail", "comma-separated list of files embedded in the parser that SelfTest expects to fail to parse")
+	fs.Var(&selfTestPassFlag, "self-test-pass", "comma-separated list of files embedded in the parser that SelfTest expects to parse")
+	fs.Var(ruleMessagesFlag, "rule-error-message", "RULE=MESSAGE custom error message reported when RULE fails, may be repeated")
+	fs.Var(ruleAssertionsFlag, "rule-assertion", "RULE=EXPR Go boolean expression over the value v of RULE checked when it matches, may be repeated")
+	fs.Var(prefixDispatchFlag, "prefix-dispatch", "PREFIX=RULE rule used as entrypoint if the input starts with PREFIX, may be repeated")
 
 	fs.Usage = usage
 	err := fs.Parse(os.Args[1:])
@@ -73,6 +239,44 @@ func main() {
 		argError(1, "expected one argument, got %q", strings.Join(fs.Args(), " "))
 	}
 
+	var ruleIfacePath, ruleIfaceName string
+	if *ruleIfaceFlag != "" {
+		ix := strings.LastIndex(*ruleIfaceFlag, ".")
+		if ix <= 0 {
+			argError(1, "invalid rule interface %q, expected IMPORTPATH.NAME", *ruleIfaceFlag)
+		}
+		ruleIfacePath, ruleIfaceName = (*ruleIfaceFlag)[:ix], (*ruleIfaceFlag)[ix+1:]
+	}
+	if *emitBenchmarksFlag && *outputFlag == "" {
+		argError(1, "-emit-benchmarks requires the -o flag")
+	}
+	if *protoResultsFlag != "" && *outputFlag == "" {
+		argError(1, "-proto-results requires the -o flag")
+	}
+	if *wasmExportsFlag && *outputFlag == "" {
+		argError(1, "-wasm-exports requires the -o flag")
+	}
+	if *mmapInputFlag && *outputFlag == "" {
+		argError(1, "-mmap-input requires the -o flag")
+	}
+
+	// read the inputs of the self test, an input cannot be expected to
+	// both parse and fail
+	selfTestInputs := make(map[string]bool, len(selfTestFailFlag)+len(selfTestPassFlag))
+	selfTestFiles := make(map[string]string, len(selfTestFailFlag))
+	for _, file := range selfTestFailFlag {
+		in := readFile(file)
+		selfTestInputs[in] = false
+		selfTestFiles[in] = file
+	}
+	for _, file := range selfTestPassFlag {
+		in := readFile(file)
+		if failFile, ok := selfTestFiles[in]; ok {
+			argError(1, "self test input %q is both in -self-test-pass and in -self-test-fail (as %q)", file, failFile)
+		}
+		selfTestInputs[in] = true
+	}
+
 	// get input source
 	infile := ""
 	if fs.NArg() == 1 {
@@ -99,17 +303,35 @@ func main() {
 		exit(3)
 	}
 
-	// validate alternate entrypoints
+	// parse the base grammar, whose rules are merged by the builder
 	grammar := g.(*ast.Grammar)
+	var baseGrammar *ast.Grammar
+	if *baseGrammarFlag != "" {
+		bnm, brc := input(*baseGrammarFlag)
+		bg, err := ParseReader(bnm, brc, Debug(*dbgFlag), Memoize(*cacheFlag), Recover(!*noRecoverFlag))
+		_ = brc.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "parse error(s):\n", err)
+			exit(3)
+		}
+		baseGrammar = bg.(*ast.Grammar)
+	}
+
+	// validate alternate entrypoints, the rules of the base grammar are
+	// also valid entrypoints
 	rules := make(map[string]struct{}, len(grammar.Rules))
 	for _, rule := range grammar.Rules {
 		rules[rule.Name.Val] = struct{}{}
 	}
+	if baseGrammar != nil {
+		for _, rule := range baseGrammar.Rules {
+			rules[rule.Name.Val] = struct{}{}
+		}
+	}
 	for _, entrypoint := range altEntrypointsFlag {
 		if entrypoint == "" {
 			continue
 		}
-
 		if _, ok := rules[entrypoint]; !ok {
 			fmt.Fprintf(os.Stderr, "argument error:\nunknown rule name %s used as alternate entrypoint\n", entrypoint)
 			exit(9)
@@ -117,10 +339,6 @@ func main() {
 	}
 
 	if !*noBuildFlag {
-		if *optimizeGrammar {
-			ast.Optimize(grammar, altEntrypointsFlag...)
-		}
-
 		// generate parser
 		out := output(*outputFlag)
 		defer func() {
@@ -138,9 +356,120 @@ func main() {
 		basicLatinOptimize := builder.BasicLatinLookupTable(*optimizeBasicLatinFlag)
 		nolintOpt := builder.Nolint(*nolint)
 		leftRecursionSupporter := builder.SupportLeftRecursion(*supportLeftRecursion)
+		ruleErrorMessages := builder.RuleErrorMessages(ruleMessagesFlag)
+		fuzzyMatch := builder.FuzzyMatch(*fuzzyMatchFlag)
+		numericHelpers := builder.NumericHelpers(*numericHelpersFlag)
+		labelSpans := builder.LabelSpans(*labelSpansFlag)
+		runCharClass := builder.RunCharClass(*runCharClassFlag)
+		guardOptimization := builder.GuardOptimization(*guardOptimizationFlag)
+		longestMatch := builder.LongestMatchDiagnostics(*longestMatchFlag)
+		sortFunctions := builder.SortFunctions(*sortFunctionsFlag)
+		memoKeyHook := builder.MemoKeyHook(*memoKeyHookFlag)
+		warnEmptyRep := builder.WarnEmptyRepetition(*warnEmptyRepFlag)
+		overridableActions := builder.OverridableActions(*overridableActionsFlag)
+		ruleTiming := builder.RuleTiming(*ruleTimingFlag)
+		ruleIface := builder.RuleInterface(ruleIfacePath, ruleIfaceName)
+		progress := builder.ProgressCallback(*progressFlag)
+		dedupeCharClasses := builder.DedupeCharClasses(*dedupeCharClassesFlag)
+		caseScopes := builder.CaseScopes(*caseScopesFlag)
+		sinkResults := builder.SinkResults(*sinkResultsFlag)
+		expandIgnoreCase := builder.ExpandIgnoreCaseClasses(*expandIgnoreCaseFlag)
+		matcherIface := builder.MatcherInterface(*matcherIfaceFlag)
+		untilEOF := builder.UntilEOF(*untilEOFFlag)
+		followSets := builder.ComputeFollowSets(*followSetsFlag)
+		structuredTrace := builder.StructuredTrace(*structuredTraceFlag)
+		zeroCopyText := builder.ZeroCopyText(*zeroCopyTextFlag)
+		mmapInput := builder.MmapInput(*mmapInputFlag)
+		warnUnusedLabels := builder.WarnUnusedLabels(*warnUnusedLabelsFlag)
+		batchParse := builder.BatchParse(*batchParseFlag)
+		losslessCST := builder.LosslessCST(*losslessCSTFlag)
+		emitValidate := builder.EmitValidate(*emitValidateFlag)
+		warnShadowedRec := builder.WarnShadowedRecovery(*warnShadowedRecFlag)
+		replMode := builder.REPLMode(*replModeFlag)
+		localeFold := builder.LocaleFold(*localeFoldFlag)
+		errorSpans := builder.ErrorSpans(*errorSpansFlag)
+		errorRepair := builder.ErrorRepair(*errorRepairFlag)
+		expvarMetrics := builder.ExpvarMetrics(*expvarMetricsFlag)
+		optimizeRules := builder.OptimizeRules(optimizeRulesFlag)
+		optimizeRulesExcept := builder.OptimizeRulesExcept(*optRulesExceptFlag)
+		autoMapResults := builder.AutoMapResults(*autoMapResultsFlag)
+		emitRuleDocs := builder.EmitRuleDocs(*emitRuleDocsFlag)
+		emitReprinter := builder.EmitReprinter(*emitReprinterFlag)
+		tableDriven := builder.TableDriven(*tableDrivenFlag)
+		modeAwareMemo := builder.ModeAwareMemo(*modeAwareMemoFlag)
+		grammarLineMap := builder.GrammarLineMap(*grammarLineMapFlag)
+		boundedStream := builder.BoundedStream(*boundedStreamFlag)
+		choiceIndex := builder.ChoiceIndex(*choiceIndexFlag)
+		validateActions := builder.ValidateActions(*validateActionsFlag)
+		compactAST := builder.CompactAST(*compactASTFlag)
+		methodReceiver := builder.MethodReceiver(*methodReceiverFlag)
+		reportMaxDepth := builder.ReportMaxDepth(*reportMaxDepthFlag)
+		subParse := builder.SubParse(*subParseFlag)
+		backtrackHooks := builder.BacktrackHooks(*backtrackHooksFlag)
+		switchDispatch := builder.SwitchDispatch(*switchDispatchFlag)
+		streamBuffer := builder.StreamBuffer(*streamBufferFlag)
+		ruleAssertions := builder.RuleAssertions(ruleAssertionsFlag)
+		emitResultDiff := builder.EmitResultDiff(*emitResultDiffFlag)
+		bidiAware := builder.BidiAware(*bidiAwareFlag)
+		preprocessors := builder.Preprocessors(*preprocessorsFlag)
+		emitMustParse := builder.EmitMustParse(*emitMustParseFlag)
+		chromeTrace := builder.ChromeTrace(*chromeTraceFlag)
+		prefixDispatch := builder.PrefixDispatch(prefixDispatchFlag)
+		asciiFastPath := builder.ASCIIFastPath(*asciiFastPathFlag)
+		typedThrows := builder.TypedThrows(*typedThrowsFlag)
+		emitBenchmarks := builder.EmitBenchmarks(*emitBenchmarksFlag)
+		benchInputs := builder.BenchmarkInputs(benchInputsFlag)
+		runtimeParams := builder.RuntimeParams(*runtimeParamsFlag)
+		emptyInput := builder.EmptyInputMode(*emptyInputFlag)
+		legacyGoCompat := builder.LegacyGoCompat(*legacyGoCompatFlag)
+		leftRecRules := builder.LeftRecursiveRules(leftRecRulesFlag)
+		protoResults := builder.ProtoResults(*protoResultsFlag)
+		graphemeInput := builder.GraphemeInput(*graphemeInputFlag)
+		selfTest := builder.EmbedSelfTest(selfTestInputs)
+		explicitStack := builder.ExplicitStack(*explicitStackFlag)
+		memoReuseTrace := builder.MemoReuseTrace(*memoReuseTraceFlag)
+		lineRecovery := builder.LineRecovery(*lineRecoveryFlag)
+		wasmExports := builder.WasmExports(*wasmExportsFlag)
+		enumerateParses := builder.EnumerateParses(*enumerateParsesFlag)
+		completionSupport := builder.CompletionSupport(*completionSupportFlag)
+		builtinAssertions := builder.BuiltinAssertions(*builtinAssertionsFlag)
+		denseMemo := builder.DenseMemo(*denseMemoFlag)
+		entrypointType := builder.AssertEntrypointType(*entrypointTypeFlag)
+		ruleBoundaryPos := builder.RuleBoundaryPositions(*ruleBoundaryPosFlag)
+		warnShadowedCls := builder.WarnShadowedClasses(*warnShadowedClsFlag)
+		lazyRuleInit := builder.LazyRuleInit(*lazyRuleInitFlag)
+		explainFailures := builder.ExplainFailures(*explainFailuresFlag)
+		pushParser := builder.PushParser(*pushParserFlag)
+		userData := builder.UserData(*userDataFlag)
+		otelSpans := builder.OTelSpans(*otelSpansFlag)
+		baseGrammarOpt := builder.BaseGrammar(baseGrammar)
+		optimizeGrammarOpt := builder.OptimizeGrammar(*optimizeGrammar)
+		altEntrypoints := builder.AlternateEntrypoints(altEntrypointsFlag)
 		if err := builder.BuildParser(
 			outBuf, grammar, curNmOpt, optimizeParser, basicLatinOptimize,
-			nolintOpt, leftRecursionSupporter); err != nil {
+			nolintOpt, leftRecursionSupporter, ruleErrorMessages, fuzzyMatch,
+			numericHelpers, labelSpans, runCharClass, guardOptimization,
+			longestMatch, sortFunctions, memoKeyHook, warnEmptyRep,
+			overridableActions, ruleTiming, ruleIface, progress,
+			dedupeCharClasses, caseScopes, sinkResults, expandIgnoreCase,
+			matcherIface, untilEOF, followSets, structuredTrace,
+			zeroCopyText, mmapInput, warnUnusedLabels, batchParse,
+			losslessCST, emitValidate, warnShadowedRec, replMode,
+			localeFold, errorSpans, expvarMetrics, optimizeRules,
+			optimizeRulesExcept, autoMapResults, emitRuleDocs,
+			emitReprinter, tableDriven, modeAwareMemo,
+			grammarLineMap, boundedStream, errorRepair, choiceIndex,
+			validateActions, compactAST, methodReceiver, reportMaxDepth,
+			subParse, backtrackHooks, switchDispatch, streamBuffer,
+			ruleAssertions, emitResultDiff, bidiAware, preprocessors,
+			emitMustParse, chromeTrace, prefixDispatch, asciiFastPath,
+			typedThrows, runtimeParams, emptyInput, legacyGoCompat,
+			leftRecRules, protoResults, graphemeInput, selfTest,
+			explicitStack, memoReuseTrace, lineRecovery, wasmExports,
+			enumerateParses, completionSupport, builtinAssertions, denseMemo,
+			entrypointType, ruleBoundaryPos, warnShadowedCls, lazyRuleInit,
+			explainFailures, pushParser, userData, otelSpans, baseGrammarOpt,
+			optimizeGrammarOpt, altEntrypoints); err != nil {
 			fmt.Fprintln(os.Stderr, "build error: ", err)
 			exit(5)
 		}
@@ -167,6 +496,84 @@ func main() {
 			fmt.Fprintln(os.Stderr, "write error: ", err)
 			exit(7)
 		}
+
+		if *emitBenchmarksFlag {
+			// the benchmarks are built with the options of the parser
+			benchBuf := bytes.NewBuffer([]byte{})
+			if err := builder.BuildBenchmarks(benchBuf, grammar, methodReceiver, emitBenchmarks, benchInputs); err != nil {
+				fmt.Fprintln(os.Stderr, "build error: ", err)
+				exit(5)
+			}
+			formattedBuf, err := imports.Process("filename", benchBuf.Bytes(), options)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "format error: ", err)
+				exit(6)
+			}
+			benchFile := strings.TrimSuffix(*outputFlag, ".go") + "_bench_test.go"
+			if err := os.WriteFile(benchFile, formattedBuf, 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, "write error: ", err)
+				exit(7)
+			}
+		}
+
+		if *protoResultsFlag != "" {
+			protoBuf := bytes.NewBuffer([]byte{})
+			if err := builder.BuildProto(protoBuf, grammar, protoResults); err != nil {
+				fmt.Fprintln(os.Stderr, "build error: ", err)
+				exit(5)
+			}
+			protoFile := strings.TrimSuffix(*outputFlag, ".go") + ".proto"
+			if err := os.WriteFile(protoFile, protoBuf.Bytes(), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, "write error: ", err)
+				exit(7)
+			}
+		}
+
+		if *wasmExportsFlag {
+			wasmBuf := bytes.NewBuffer([]byte{})
+			if err := builder.BuildWasm(wasmBuf, grammar, wasmExports, legacyGoCompat); err != nil {
+				fmt.Fprintln(os.Stderr, "build error: ", err)
+				exit(5)
+			}
+			formattedBuf, err := imports.Process("filename", wasmBuf.Bytes(), options)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "format error: ", err)
+				exit(6)
+			}
+			wasmFile := strings.TrimSuffix(*outputFlag, ".go") + "_js_wasm.go"
+			if err := os.WriteFile(wasmFile, formattedBuf, 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, "write error: ", err)
+				exit(7)
+			}
+		}
+
+		if *mmapInputFlag {
+			// ParseMmap maps the file on Unix, and reads it elsewhere
+			mmapFiles := []struct {
+				suffix string
+				build  func(io.Writer, *ast.Grammar, ...builder.Option) error
+			}{
+				{"_mmap_unix.go", builder.BuildMmap},
+				{"_mmap_other.go", builder.BuildMmapFallback},
+			}
+			for _, mf := range mmapFiles {
+				mmapBuf := bytes.NewBuffer([]byte{})
+				if err := mf.build(mmapBuf, grammar, mmapInput, methodReceiver, legacyGoCompat, nolintOpt); err != nil {
+					fmt.Fprintln(os.Stderr, "build error: ", err)
+					exit(5)
+				}
+				formattedBuf, err := imports.Process("filename", mmapBuf.Bytes(), options)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "format error: ", err)
+					exit(6)
+				}
+				mmapFile := strings.TrimSuffix(*outputFlag, ".go") + mf.suffix
+				if err := os.WriteFile(mmapFile, formattedBuf, 0o644); err != nil {
+					fmt.Fprintln(os.Stderr, "write error: ", err)
+					exit(7)
+				}
+			}
+		}
 	}
 }
 
@@ -179,14 +586,198 @@ generated parser to stdout. If GRAMMAR_FILE is specified, the
 grammar is read from this file instead. If the -o flag is set,
 the generated code is written to this file instead.
 
+	-ascii-fast-path
+		check whether the input is only made of ASCII characters when
+		parsing starts, and if it is, read its runes byte by byte
+		instead of decoding them and share the values of the matches
+		of a single character.
+	-auto-map-results
+		build the results of the alternatives without action from their
+		expressions labeled item (a []any of the items), key and value
+		(a map[string]any) or text (the matched string).
+	-backtrack-hooks
+		allow the actions to register functions with c.onBacktrack,
+		called when the parser backtracks over the match of the action.
+	-base-grammar FILE
+		extend the grammar in FILE: its rules are inherited by the
+		grammar, which overrides those it redefines.
+	-batch-parse
+		generate the ParseBatch function, which parses many inputs
+		concurrently with a pool of goroutines.
+	-benchmark-input FILE[,FILE...]
+		comma-separated list of files parsed by the benchmark of
+		-emit-benchmarks in addition to the synthesized inputs, relative
+		to the package of the generated parser.
+	-bidi-aware
+		generate the Direction method of the parsing errors, which
+		returns the direction, left-to-right or right-to-left, of the
+		text around the position of the error.
+	-bounded-stream N
+		generate the ParseStream function, which parses the input as it
+		is read from an io.Reader, keeping only the last N bytes of input
+		to backtrack.
 	-cache
 		cache parser results to avoid exponential parsing time in
 		pathological cases. Can make the parsing slower for typical
 		cases and uses more memory.
+	-builtin-assertions
+		allow the zero-width assertions \b, for a word boundary, and $,
+		for a line end, in the grammar.
+	-case-scopes
+		allow case-insensitive regions (?i: ...) in the grammar, where
+		the literals and character classes ignore the case.
+	-choice-index
+		allow the action code blocks to get the index of the alternative
+		that matched in their choice expression with c.choiceIndex().
+	-chrome-trace
+		generate the ChromeTrace parser option, to write the rules
+		entered and exited in the Chrome tracing JSON format, for
+		chrome://tracing or Perfetto.
+	-compact-ast
+		generate the ParseCompact function, which returns the concrete
+		syntax tree of the input with its nodes stored in a single slice.
+	-completion-support
+		generate the Suggest function, which returns the tokens that
+		the grammar accepts at a position of the input, e.g. for the
+		autocompletion of an editor.
+	-compute-follow-sets
+		compute the FIRST and FOLLOW sets of the rules, and list the
+		tokens expected at the farthest failure position from them when
+		the parsing fails.
 	-debug
 		output debugging information while parsing the grammar.
+	-dedupe-char-classes
+		write identical character class matchers once, as variables
+		shared by all the expressions that use them.
+	-dense-memo
+		memoize the results of the rules in a dense table indexed by
+		rule and input offset instead of maps, falling back to the maps
+		when the table would be too large for the input.
+	-emit-benchmarks
+		write a benchmark test file for the entrypoint of the grammar,
+		parsing small, medium and large inputs synthesized from the
+		grammar, next to the output file, e.g. parser_bench_test.go for
+		-o parser.go. Requires the -o flag.
+	-emit-must-parse
+		generate the MustParse function, which is like Parse but panics
+		if the input cannot be parsed.
+	-emit-reprinter
+		generate the Reprint function, which returns the exact input
+		matched by a node of a syntax tree that knows its span.
+	-emit-result-diff
+		generate the DiffResults function, which compares two parse
+		results and returns their differences, e.g. to see the impact of
+		a grammar change on the results.
+	-emit-rule-docs
+		generate the RuleDoc function, which returns the documentation
+		of a rule, the line comments that precede it in the grammar.
+	-emit-validate
+		generate the Validate function, which only reports whether the
+		input matches the grammar, without building the values.
+	-empty-input MODE
+		handle the empty input according to MODE: "error" fails with
+		the ErrEmptyInput error and "nil" returns a nil result and no
+		error, without matching the entrypoint. The default, "normal",
+		matches the empty input as any other input.
+	-entrypoint-type TYPE
+		generate the ParseTyped function, which returns the result of
+		Parse as a TYPE, and an error if the entrypoint returned a value
+		of another type.
+	-enumerate-parses N
+		generate the ParseAll function, which tries all the alternatives
+		of the choices and returns up to N distinct values of the
+		successful parses. The number of parses can be exponential in
+		the number of choices.
+	-error-repair
+		generate a parser that retries a failed parse inserting the
+		literals missing where it failed. The insertions are reported
+		by the Repairs parser option.
+	-error-spans
+		generate the Span method on the parsing errors, which returns
+		the start and end offsets of the input delimiting the error.
+	-expand-ignore-case-classes
+		write the case-insensitive character classes with all the case
+		variants of their characters, so that they are matched without
+		converting the case of the input.
+	-explain-failures
+		generate the Explanation method of the parse errors, which
+		describes the farthest failure in prose, with the rules being
+		matched and the tokens expected.
+	-explicit-stack
+		run the instructions of the table-driven parser with an explicit
+		work stack instead of recursive calls, so that deeply nested
+		inputs cannot overflow the Go stack. Implies -table-driven, and
+		does not support the flags that wrap the matching of the rules,
+		such as -support-left-recursion or -rule-timing.
+	-expvar-metrics NAMESPACE
+		publish the counts, durations and memo hit rate of the parses
+		with the expvar package, in a map named NAMESPACE.
+	-fuzzy-match N
+		generate a parser that retries a failed parse allowing up to N
+		single-character corrections when matching literals. The
+		corrections are reported by the Corrections parser option.
+	-grammar-line-map
+		generate the GrammarLines function, which returns the range of
+		lines of each rule in the grammar.
+	-grapheme-input
+		generate the ParseRunes function, which parses a []rune over
+		its grapheme clusters: the any matcher matches a whole
+		user-perceived character and the columns count the clusters.
+	-guard-optimization
+		match and (&) and not (!) predicates of a literal or a character
+		class by peeking at the input.
 	-h -help
 		display this help message.
+	-label-spans
+		record the extent of the input matched by labeled expressions,
+		available in action code blocks via c.labelSpan(label).
+	-left-recursive-rules RULE[,RULE...]
+		support the left recursion of the rules RULE... only, the left
+		recursion of the other rules is an error, even with
+		-support-left-recursion.
+	-lazy-rule-init
+		build the expression of each rule the first time the rule is
+		matched instead of when the program starts.
+	-legacy-go-compat
+		generate a parser that uses interface{} instead of any, so
+		that it compiles with the Go versions before 1.18.
+	-line-recovery
+		generate the ParseLines function, which parses the input as a
+		sequence of items matched by the entrypoint rule and skips to
+		the next line when an item fails.
+	-locale-fold LOCALE
+		fold the case of the case-insensitive matchers according to
+		LOCALE, "tr" (Turkish) or "az" (Azeri), instead of the Unicode
+		default, e.g. "I"i then matches "ı" but not "i".
+	-longest-match-diagnostics
+		when the generated parser runs in debug mode, log the ordered
+		choices where an alternative following the one that matched
+		would have matched a strictly longer input.
+	-lossless-cst
+		generate the ParseCST function, which returns the concrete
+		syntax tree of the input, from which it can be reproduced.
+	-matcher-interface
+		parse the expressions through a matcher interface instead of a
+		type switch, so that custom expression types can be added.
+	-memo-key-hook
+		generate the MemoKey parser option, to add a discriminator such
+		as a parser mode to the key of the memoized results.
+	-memo-reuse-trace
+		generate the MemoReuses parser option, which records the rule
+		and position of each memoized result reused by the parser.
+	-method-receiver TYPE
+		generate the Parse, ParseFile and ParseReader entrypoints as
+		methods of *TYPE, a type of the package of the parser, whose
+		code blocks get the receiver with c.recv.
+	-mode-aware-memo
+		start a new mode generation each time a state code block runs,
+		and ignore the results memoized in another generation.
+	-mmap-input
+		write a file next to the output file, e.g. parser_mmap_unix.go
+		for parser.go, with the ParseMmap function, which parses a file
+		mapped in memory instead of reading it. That file is only built
+		for Unix platforms, the ParseMmap function of parser_mmap_other.go
+		reads the file on the others. It requires the -o flag.
 	-nolint
 		add '// nolint: ...' comments for generated parser to suppress
 		warnings by gometalinter (https://github.com/alecthomas/gometalinter) or
@@ -194,6 +785,9 @@ the generated code is written to this file instead.
 	-no-recover
 		do not recover from a panic. Useful to access the panic stack
 		when debugging, otherwise the panic is converted to an error.
+	-numeric-helpers
+		generate the int, float and uint methods on the current type,
+		which parse the text of the current match in code blocks.
 	-o OUTPUT_FILE
 		write the generated parser to OUTPUT_FILE. Defaults to stdout.
 	-optimize-basic-latin
@@ -203,17 +797,153 @@ the generated code is written to this file instead.
 	-optimize-parser
 		generate optimized parser without Debug and Memoize options and
 		with some other optimizations applied.
+	-optimize-rules RULE[,RULE...]
+		match the rules RULE... without debug output nor memoization,
+		while the other rules keep the Debug and Memoize options of the
+		parser. Has no effect with -optimize-parser.
+	-optimize-rules-except
+		optimize all the rules except those listed with -optimize-rules.
+	-otel-spans
+		generate the WithContext parser option and the ContextWithTracer
+		function, which wrap the parses in the spans of a tracer, e.g.
+		an adapter of an OpenTelemetry tracer.
+	-overridable-actions
+		call the action code blocks through a map of functions, which
+		can be replaced at runtime with the SetAction option.
+	-preprocessors
+		generate the Preprocess option, which runs preprocessing stages
+		on the input before parsing it, the errors reporting their
+		position in the original input.
+	-progress-callback
+		generate the Progress parser option, to report periodically
+		how far in the input the parser has advanced.
+	-proto-results NAME
+		write a .proto file next to the output file, e.g. parser.proto
+		for -o parser.go, with a message NAME describing the generic
+		parse results, and generate the NAME type mirroring it with the
+		ToProto and FromProto conversion functions. Requires the -o
+		flag.
+	-push-parser
+		generate the PushParser type, whose Feed method parses the
+		items of an input as its chunks arrive, and whose Flush method
+		ends the input and returns the items.
 	-receiver-name NAME
 		use NAME as for the receiver name of the generated methods
 		for the grammar's code blocks. Defaults to "c".
+	-repl-mode
+		generate the REPL type, which accumulates lines of input until
+		they match the grammar, for the continue prompt of a shell.
+	-report-max-depth
+		generate the ReachedDepth parser option, which records the
+		deepest nesting of rules and of the value stack reached.
+	-rule-boundary-positions
+		generate the RulePositions parser option, which records the
+		start and end positions of the rules in the result, but not of
+		the expressions inside the rules.
+	-rule-interface IMPORTPATH.NAME
+		make the generated rule type implement the interface NAME of
+		the package IMPORTPATH, and generate the Rules function that
+		returns the rules of the grammar as this interface.
+	-rule-timing
+		generate the RuleTimes parser option, to accumulate the time
+		spent in each rule.
+	-run-char-class
+		match a repetition (+ or *) of a character class in a single
+		loop. The value of such a repetition is the matched []byte.
+	-runtime-params
+		allow the rule references to pass arguments to the rules they
+		reference when they are matched, e.g. Items(count=n), read by
+		the code blocks with c.param("count").
+	-self-test-fail FILE[,FILE...]
+		comma-separated list of files embedded in the generated parser,
+		which must fail to parse when its SelfTest function is called.
+	-self-test-pass FILE[,FILE...]
+		comma-separated list of files embedded in the generated parser,
+		which must parse when its SelfTest function is called. An input
+		cannot be in both -self-test-pass and -self-test-fail.
+	-sink-results
+		generate the ParseTo function, which pushes each value of the
+		top-level repetition of the entrypoint rule to a sink function.
+	-sort-functions
+		write the functions generated for the code blocks sorted by
+		name, so that the output is stable across grammar changes.
 	-x
 		do not generate the parser, only parse the grammar.
  	-alternate-entrypoints RULE[,RULE...]
 		comma-separated list of rule names that may be used as alternate
 		entrypoints for the parser, in addition to the first rule in the
 		grammar.
+	-stream-buffer N
+		generate the ParseChan function, which runs ParseTo in a
+		goroutine and sends the values to a channel with a buffer of N
+		values, so that a slow consumer blocks the parsing. Requires
+		-sink-results.
+	-structured-trace
+		generate the Trace parser option, to report the rules entered
+		and exited to an implementation of the TraceLogger interface.
+	-sub-parse
+		generate the ParseSub function, which parses the input from an
+		offset with a rule and returns the offset where the match ends.
 	-support-left-recursion
 		add support left recursion (EXPERIMENTAL FEATURE)
+	-switch-dispatch
+		call the functions of the actions, predicates and state code
+		blocks by a switch on an integer code assigned to each of them,
+		instead of method values.
+	-table-driven
+		generate a parser that interprets a flat table of instructions
+		compiled from the expressions of the rules, instead of a tree
+		of expression structs.
+	-typed-throws
+		allow the throw expressions to carry the value of a code block,
+		%%{Label { return payload, nil }}, which the recovery expressions
+		get with c.thrown(), and which is reported as a ThrowError if it
+		is not recovered.
+	-until-eof
+		match up to the end of the input in the until expressions
+		whose terminator is not found, instead of failing.
+	-user-data
+		generate the UserData parser option, which attaches a value to
+		the parse, e.g. its configuration, read by the code blocks with
+		c.data().
+	-validate-actions
+		parse the code blocks of the grammar as Go code, and report their
+		syntax errors at their position in the grammar.
+	-warn-empty-repetition
+		print a warning on stderr for each repetition (* or +) of an
+		expression that can match the empty string, which would make
+		the generated parser loop forever.
+	-warn-shadowed-classes
+		print a warning on stderr for each alternative of a choice that
+		can only start with characters that an earlier alternative
+		starting with a character class can start with.
+	-warn-shadowed-recovery
+		print a warning on stderr for each recovery expression that
+		can match the same input as the expression it recovers.
+	-warn-unused-labels
+		print a warning on stderr for each labeled expression whose
+		label is not used by any of the code blocks that receive it.
+	-wasm-exports
+		generate the ParseJSON function, which parses the input of a
+		JSON request and returns a JSON response, and write a file next
+		to the output file, e.g. parser_js_wasm.go, that exposes it to
+		JavaScript when compiled to WebAssembly. Requires -o.
+	-zero-copy-text
+		generate the textBytes method on the current type, which
+		returns the text of the current match in code blocks without
+		copying it.
+	-rule-error-message RULE=MESSAGE
+		report MESSAGE instead of the list of expected tokens when
+		RULE fails at the farthest position reached by the parser.
+		May be repeated to set messages for multiple rules.
+	-rule-assertion RULE=EXPR
+		check the Go boolean expression EXPR over the value v of RULE
+		each time RULE matches, and report an error if it is false.
+		May be repeated. Not generated with -optimize-parser.
+	-prefix-dispatch PREFIX=RULE
+		start parsing at RULE instead of the first rule if the input
+		starts with PREFIX, the longest prefix winning. May be repeated
+		to select different rules by different prefixes.
 
 See https://godoc.org/github.com/mna/pigeon for more information.
 `
@@ -249,6 +979,16 @@ func input(filename string) (nm string, rc io.ReadCloser) {
 	return nm, makeReadCloser(r, inf)
 }
 
+// readFile returns the content of the file filename.
+func readFile(filename string) string {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exit(2)
+	}
+	return string(b)
+}
+
 // output gets the writer to write the generated parser to.
 func output(filename string) io.WriteCloser {
 	out := os.Stdout
@@ -287,6 +1027,33 @@ func toAnySlice(v any) []any {
 	return v.([]any)
 }
 
+// ruleDoc is a helper function for the PEG grammar parser. It returns the
+// documentation of the rule that starts at offset off in src, the text of
+// the line comments on the lines that immediately precede it.
+func ruleDoc(src []byte, off int) string {
+	start := bytes.LastIndexByte(src[:off], '\n') + 1
+	if len(bytes.TrimSpace(src[start:off])) > 0 {
+		// the rule does not start its line
+		return ""
+	}
+
+	var lines []string
+	for start > 0 {
+		end := start - 1
+		start = bytes.LastIndexByte(src[:end], '\n') + 1
+		line := strings.TrimSpace(string(src[start:end]))
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		line = strings.TrimPrefix(line, "//")
+		lines = append(lines, strings.TrimPrefix(line, " "))
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
 // validateUnicodeEscape checks that the provided escape sequence is a
 // valid Unicode escape sequence.
 func validateUnicodeEscape(escape, errMsg string) (any, error) {
@@ -27,6 +27,13 @@ func TestMain(t *testing.T) {
 		{args: "-h", code: 0},          // help
 		{args: "FILE1 FILE2", code: 1}, // want only 1 non-flag arg
 		{args: "-x", code: 3},          // stdin: no match found
+		// the same input cannot be expected to parse and fail
+		{args: "-self-test-pass test/self_test/testdata/pass.txt -self-test-fail test/self_test/testdata/pass.txt", code: 1},
+		// unknown alternate entrypoint, even if the parser is not built
+		{args: "-alternate-entrypoints Nope test/runtime_params/runtime_params.peg", code: 9},
+		{args: "-x -alternate-entrypoints Nope test/runtime_params/runtime_params.peg", code: 9},
+		// an option that the explicit stack does not support
+		{args: "-explicit-stack -rule-timing test/explicit_stack/explicit_stack.peg", code: 5},
 	}
 
 	for _, tc := range cases {
@@ -8,15 +8,17 @@ import (
 
 var invalidParseCases = map[string]string{
 	"":           `file:1:1 (0): no match found, expected: "/*", "//", "\n", "{", [ \t\r] or [\pL_]`,
-	"a":          `file:1:2 (1): no match found, expected: "'", "/*", "//", "<-", "=", "\"", "\n", "` + "`" + `", "←", "⟵", [ \t\r], [\pL_] or [\p{Nd}]`,
-	"abc":        `file:1:4 (3): no match found, expected: "'", "/*", "//", "<-", "=", "\"", "\n", "` + "`" + `", "←", "⟵", [ \t\r], [\pL_] or [\p{Nd}]`,
+	"a":          `file:1:2 (1): no match found, expected: "'", "(", "/*", "//", ":=", "<-", "=", "\"", "\n", "` + "`" + `", "←", "⟵", [ \t\r], [\pL_] or [\p{Nd}]`,
+	"abc":        `file:1:4 (3): no match found, expected: "'", "(", "/*", "//", ":=", "<-", "=", "\"", "\n", "` + "`" + `", "←", "⟵", [ \t\r], [\pL_] or [\p{Nd}]`,
 	" ":          `file:1:2 (1): no match found, expected: "/*", "//", "\n", "{", [ \t\r] or [\pL_]`,
-	`a = +`:      `file:1:5 (4): no match found, expected: "!", "#", "%", "&", "'", "(", ".", "/*", "//", "[", "\"", "\n", "` + "`" + `", [ \t\r] or [\pL_]`,
-	`a = *`:      `file:1:5 (4): no match found, expected: "!", "#", "%", "&", "'", "(", ".", "/*", "//", "[", "\"", "\n", "` + "`" + `", [ \t\r] or [\pL_]`,
-	`a = ?`:      `file:1:5 (4): no match found, expected: "!", "#", "%", "&", "'", "(", ".", "/*", "//", "[", "\"", "\n", "` + "`" + `", [ \t\r] or [\pL_]`,
-	"a ←":        `file:1:4 (5): no match found, expected: "!", "#", "%", "&", "'", "(", ".", "/*", "//", "[", "\"", "\n", "` + "`" + `", [ \t\r] or [\pL_]`,
-	"a ← b\nb ←": `file:2:4 (13): no match found, expected: "!", "#", "%", "&", "'", "(", ".", "/*", "//", "[", "\"", "\n", "` + "`" + `", [ \t\r] or [\pL_]`,
+	`a = +`:      `file:1:5 (4): no match found, expected: "!", "#", "$", "%", "&", "'", "(", "(?<=", "(?i:", ".", "/*", "//", "<", "@", "[", "\"", "\\b", "\n", "^", "` + "`" + `", "~", [ \t\r] or [\pL_]`,
+	`a = *`:      `file:1:5 (4): no match found, expected: "!", "#", "$", "%", "&", "'", "(", "(?<=", "(?i:", ".", "/*", "//", "<", "@", "[", "\"", "\\b", "\n", "^", "` + "`" + `", "~", [ \t\r] or [\pL_]`,
+	`a = ?`:      `file:1:5 (4): no match found, expected: "!", "#", "$", "%", "&", "'", "(", "(?<=", "(?i:", ".", "/*", "//", "<", "@", "[", "\"", "\\b", "\n", "^", "` + "`" + `", "~", [ \t\r] or [\pL_]`,
+	"a ←":        `file:1:4 (5): no match found, expected: "!", "#", "$", "%", "&", "'", "(", "(?<=", "(?i:", ".", "/*", "//", "<", "@", "[", "\"", "\\b", "\n", "^", "` + "`" + `", "~", [ \t\r] or [\pL_]`,
+	"a ← b\nb ←": `file:2:4 (13): no match found, expected: "!", "#", "$", "%", "&", "'", "(", "(?<=", "(?i:", ".", "/*", "//", "<", "@", "[", "\"", "\\b", "\n", "^", "` + "`" + `", "~", [ \t\r] or [\pL_]`,
 	"a ← nil:b":  "file:1:5 (6): rule Identifier: identifier is a reserved word",
+	"a(b) := c":  "file:1:1 (0): rule Rule: macro cannot have parameters or a display name",
+	"a = @0 b":   "file:1:6 (5): rule ScanLimit: invalid scan limit",
 	"\xfe":       "file:1:1 (0): invalid encoding",
 	"{}{}":       `file:1:3 (2): no match found, expected: "/*", "//", ";", "\n", [ \t\r] or EOF`,
 
@@ -420,6 +422,185 @@ var validParseCases = map[string]*ast.Grammar{
 			},
 		},
 	},
+	"a = < '{' \"}\" > b": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.BalancedExpr{
+							Open:  ast.NewLitMatcher(ast.Pos{}, "{"),
+							Close: ast.NewLitMatcher(ast.Pos{}, "}"),
+						},
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					},
+				},
+			},
+		},
+	},
+	"a = ~\"*/\" ~b": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.UntilExpr{Expr: ast.NewLitMatcher(ast.Pos{}, "*/")},
+						&ast.UntilExpr{Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")}},
+					},
+				},
+			},
+		},
+	},
+	"a = (?i: 'x' [y] ) b": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.CaseInsensitiveExpr{
+							Expr: &ast.SeqExpr{
+								Exprs: []ast.Expression{
+									ast.NewLitMatcher(ast.Pos{}, "x"),
+									ast.NewCharClassMatcher(ast.Pos{}, "[y]"),
+								},
+							},
+						},
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					},
+				},
+			},
+		},
+	},
+	"a = (?<= '$' [0-9] ) b": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.LookbehindExpr{
+							Expr: &ast.SeqExpr{
+								Exprs: []ast.Expression{
+									ast.NewLitMatcher(ast.Pos{}, "$"),
+									ast.NewCharClassMatcher(ast.Pos{}, "[0-9]"),
+								},
+							},
+						},
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+					},
+				},
+			},
+		},
+	},
+	"a = @8 'b'* 'c' / d": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.ChoiceExpr{
+					Alternatives: []ast.Expression{
+						&ast.ScanLimitExpr{
+							Limit: 8,
+							Expr: &ast.SeqExpr{
+								Exprs: []ast.Expression{
+									&ast.ZeroOrMoreExpr{Expr: ast.NewLitMatcher(ast.Pos{}, "b")},
+									ast.NewLitMatcher(ast.Pos{}, "c"),
+								},
+							},
+						},
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "d")},
+					},
+				},
+			},
+		},
+	},
+	"// A is a.\n//\n//\tindented\na = b\n\n// not b\n\nb = 'c' // c\n// d\nd = e": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Doc:  "A is a.\n\n\tindented",
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "b"),
+				Expr: ast.NewLitMatcher(ast.Pos{}, "c"),
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "d"),
+				Doc:  "d",
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "e")},
+			},
+		},
+	},
+	"a = b\nb := 'c'": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "b")},
+			},
+			{
+				Name:  ast.NewIdentifier(ast.Pos{}, "b"),
+				Macro: true,
+				Expr:  ast.NewLitMatcher(ast.Pos{}, "c"),
+			},
+		},
+	},
+	"List(E, S) = E (S E)*\nb = List(c, ',')": {
+		Rules: []*ast.Rule{
+			{
+				Name:   ast.NewIdentifier(ast.Pos{}, "List"),
+				Params: []*ast.Identifier{ast.NewIdentifier(ast.Pos{}, "E"), ast.NewIdentifier(ast.Pos{}, "S")},
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "E")},
+						&ast.ZeroOrMoreExpr{
+							Expr: &ast.SeqExpr{
+								Exprs: []ast.Expression{
+									&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "S")},
+									&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "E")},
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "b"),
+				Expr: &ast.RuleCallExpr{
+					Name: ast.NewIdentifier(ast.Pos{}, "List"),
+					Args: []ast.Expression{
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "c")},
+						ast.NewLitMatcher(ast.Pos{}, ","),
+					},
+				},
+			},
+		},
+	},
+	"a = ^ 'b'": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewLineStartExpr(ast.Pos{}),
+						ast.NewLitMatcher(ast.Pos{}, "b"),
+					},
+				},
+			},
+		},
+	},
+	"a = \\b 'b' $": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "a"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						ast.NewAssertionExpr(ast.Pos{}, `\b`),
+						ast.NewLitMatcher(ast.Pos{}, "b"),
+						ast.NewAssertionExpr(ast.Pos{}, "$"),
+					},
+				},
+			},
+		},
+	},
 }
 
 func TestValidParseCases(t *testing.T) {
@@ -443,3 +624,58 @@ again:
 		goto again
 	}
 }
+
+// the calls of rules without parameters keep the meaning of a reference
+// followed by a parenthesized expression they had before the
+// parameterized rules
+var plainRuleCallCases = map[string]*ast.Grammar{
+	"A = B(C) !.\nB = 'b'\nC = 'c'": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "A"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "B")},
+						&ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "C")},
+						&ast.NotExpr{Expr: ast.NewAnyMatcher(ast.Pos{}, ".")},
+					},
+				},
+			},
+			{Name: ast.NewIdentifier(ast.Pos{}, "B"), Expr: ast.NewLitMatcher(ast.Pos{}, "b")},
+			{Name: ast.NewIdentifier(ast.Pos{}, "C"), Expr: ast.NewLitMatcher(ast.Pos{}, "c")},
+		},
+	},
+	"A = x:B('c')*\nB = 'b'": {
+		Rules: []*ast.Rule{
+			{
+				Name: ast.NewIdentifier(ast.Pos{}, "A"),
+				Expr: &ast.SeqExpr{
+					Exprs: []ast.Expression{
+						&ast.LabeledExpr{
+							Label: ast.NewIdentifier(ast.Pos{}, "x"),
+							Expr:  &ast.RuleRefExpr{Name: ast.NewIdentifier(ast.Pos{}, "B")},
+						},
+						&ast.ZeroOrMoreExpr{Expr: ast.NewLitMatcher(ast.Pos{}, "c")},
+					},
+				},
+			},
+			{Name: ast.NewIdentifier(ast.Pos{}, "B"), Expr: ast.NewLitMatcher(ast.Pos{}, "b")},
+		},
+	},
+}
+
+func TestPlainRuleCall(t *testing.T) {
+	for tc, exp := range plainRuleCallCases {
+		got, err := Parse("", []byte(tc))
+		if err != nil {
+			t.Errorf("%q: got error %v", tc, err)
+			continue
+		}
+		g := got.(*ast.Grammar)
+		if err := ast.InstantiateRules(g); err != nil {
+			t.Errorf("%q: got error %v", tc, err)
+			continue
+		}
+		compareGrammars(t, tc, exp, g)
+	}
+}
@@ -31,8 +31,8 @@ var g = &grammar{
 					pos: position{line: 5, col: 11, offset: 30},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 5, col: 11, offset: 30},
-							name: "__",
+							pos:    position{line: 5, col: 11, offset: 30},
+							offset: 68,
 						},
 						&labeledExpr{
 							pos:   position{line: 5, col: 14, offset: 33},
@@ -43,12 +43,12 @@ var g = &grammar{
 									pos: position{line: 5, col: 28, offset: 47},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 28, offset: 47},
-											name: "Initializer",
+											pos:    position{line: 5, col: 28, offset: 47},
+											offset: 1,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 40, offset: 59},
-											name: "__",
+											pos:    position{line: 5, col: 40, offset: 59},
+											offset: 68,
 										},
 									},
 								},
@@ -63,20 +63,20 @@ var g = &grammar{
 									pos: position{line: 5, col: 54, offset: 73},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 54, offset: 73},
-											name: "Rule",
+											pos:    position{line: 5, col: 54, offset: 73},
+											offset: 2,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 5, col: 59, offset: 78},
-											name: "__",
+											pos:    position{line: 5, col: 59, offset: 78},
+											offset: 68,
 										},
 									},
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 5, col: 65, offset: 84},
-							name: "EOF",
+							pos:    position{line: 5, col: 65, offset: 84},
+							offset: 73,
 						},
 					},
 				},
@@ -84,24 +84,24 @@ var g = &grammar{
 		},
 		{
 			name: "Initializer",
-			pos:  position{line: 24, col: 1, offset: 513},
+			pos:  position{line: 25, col: 1, offset: 576},
 			expr: &actionExpr{
-				pos: position{line: 24, col: 15, offset: 529},
+				pos: position{line: 25, col: 15, offset: 592},
 				run: (*parser).callonInitializer1,
 				expr: &seqExpr{
-					pos: position{line: 24, col: 15, offset: 529},
+					pos: position{line: 25, col: 15, offset: 592},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 24, col: 15, offset: 529},
+							pos:   position{line: 25, col: 15, offset: 592},
 							label: "code",
 							expr: &ruleRefExpr{
-								pos:  position{line: 24, col: 20, offset: 534},
-								name: "CodeBlock",
+								pos:    position{line: 25, col: 20, offset: 597},
+								offset: 65,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 24, col: 30, offset: 544},
-							name: "EOS",
+							pos:    position{line: 25, col: 30, offset: 607},
+							offset: 72,
 						},
 					},
 				},
@@ -109,64 +109,159 @@ var g = &grammar{
 		},
 		{
 			name: "Rule",
-			pos:  position{line: 28, col: 1, offset: 574},
+			pos:  position{line: 29, col: 1, offset: 637},
 			expr: &actionExpr{
-				pos: position{line: 28, col: 8, offset: 583},
+				pos: position{line: 29, col: 8, offset: 646},
 				run: (*parser).callonRule1,
 				expr: &seqExpr{
-					pos: position{line: 28, col: 8, offset: 583},
+					pos: position{line: 29, col: 8, offset: 646},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 28, col: 8, offset: 583},
+							pos:   position{line: 29, col: 8, offset: 646},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 28, col: 13, offset: 588},
-								name: "IdentifierName",
+								pos:    position{line: 29, col: 13, offset: 651},
+								offset: 33,
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 29, col: 28, offset: 666},
+							label: "params",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 29, col: 35, offset: 673},
+								expr: &ruleRefExpr{
+									pos:    position{line: 29, col: 35, offset: 673},
+									offset: 3,
+								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 28, offset: 603},
-							name: "__",
+							pos:    position{line: 29, col: 47, offset: 685},
+							offset: 68,
 						},
 						&labeledExpr{
-							pos:   position{line: 28, col: 31, offset: 606},
+							pos:   position{line: 29, col: 50, offset: 688},
 							label: "display",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 28, col: 39, offset: 614},
+								pos: position{line: 29, col: 58, offset: 696},
 								expr: &seqExpr{
-									pos: position{line: 28, col: 41, offset: 616},
+									pos: position{line: 29, col: 60, offset: 698},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 28, col: 41, offset: 616},
-											name: "StringLiteral",
+											pos:    position{line: 29, col: 60, offset: 698},
+											offset: 37,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 28, col: 55, offset: 630},
-											name: "__",
+											pos:    position{line: 29, col: 74, offset: 712},
+											offset: 68,
 										},
 									},
 								},
 							},
 						},
-						&ruleRefExpr{
-							pos:  position{line: 28, col: 61, offset: 636},
-							name: "RuleDefOp",
+						&labeledExpr{
+							pos:   position{line: 29, col: 80, offset: 718},
+							label: "op",
+							expr: &choiceExpr{
+								pos: position{line: 29, col: 85, offset: 723},
+								alternatives: []any{
+									&ruleRefExpr{
+										pos:    position{line: 29, col: 85, offset: 723},
+										offset: 26,
+									},
+									&ruleRefExpr{
+										pos:    position{line: 29, col: 98, offset: 736},
+										offset: 25,
+									},
+								},
+							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 71, offset: 646},
-							name: "__",
+							pos:    position{line: 29, col: 110, offset: 748},
+							offset: 68,
 						},
 						&labeledExpr{
-							pos:   position{line: 28, col: 74, offset: 649},
+							pos:   position{line: 29, col: 113, offset: 751},
 							label: "expr",
 							expr: &ruleRefExpr{
-								pos:  position{line: 28, col: 79, offset: 654},
-								name: "Expression",
+								pos:    position{line: 29, col: 118, offset: 756},
+								offset: 4,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 28, col: 90, offset: 665},
-							name: "EOS",
+							pos:    position{line: 29, col: 129, offset: 767},
+							offset: 72,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RuleParams",
+			pos:  position{line: 52, col: 1, offset: 1443},
+			expr: &actionExpr{
+				pos: position{line: 52, col: 14, offset: 1458},
+				run: (*parser).callonRuleParams1,
+				expr: &seqExpr{
+					pos: position{line: 52, col: 14, offset: 1458},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 52, col: 14, offset: 1458},
+							val:        "(",
+							ignoreCase: false,
+							want:       "\"(\"",
+						},
+						&ruleRefExpr{
+							pos:    position{line: 52, col: 18, offset: 1462},
+							offset: 68,
+						},
+						&labeledExpr{
+							pos:   position{line: 52, col: 21, offset: 1465},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:    position{line: 52, col: 27, offset: 1471},
+								offset: 33,
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 52, col: 42, offset: 1486},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 52, col: 47, offset: 1491},
+								expr: &seqExpr{
+									pos: position{line: 52, col: 49, offset: 1493},
+									exprs: []any{
+										&ruleRefExpr{
+											pos:    position{line: 52, col: 49, offset: 1493},
+											offset: 68,
+										},
+										&litMatcher{
+											pos:        position{line: 52, col: 52, offset: 1496},
+											val:        ",",
+											ignoreCase: false,
+											want:       "\",\"",
+										},
+										&ruleRefExpr{
+											pos:    position{line: 52, col: 56, offset: 1500},
+											offset: 68,
+										},
+										&ruleRefExpr{
+											pos:    position{line: 52, col: 59, offset: 1503},
+											offset: 33,
+										},
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:    position{line: 52, col: 77, offset: 1521},
+							offset: 68,
+						},
+						&litMatcher{
+							pos:        position{line: 52, col: 80, offset: 1524},
+							val:        ")",
+							ignoreCase: false,
+							want:       "\")\"",
 						},
 					},
 				},
@@ -174,72 +269,72 @@ var g = &grammar{
 		},
 		{
 			name: "Expression",
-			pos:  position{line: 41, col: 1, offset: 947},
+			pos:  position{line: 60, col: 1, offset: 1724},
 			expr: &ruleRefExpr{
-				pos:  position{line: 41, col: 14, offset: 962},
-				name: "RecoveryExpr",
+				pos:    position{line: 60, col: 14, offset: 1739},
+				offset: 5,
 			},
 		},
 		{
 			name: "RecoveryExpr",
-			pos:  position{line: 43, col: 1, offset: 976},
+			pos:  position{line: 62, col: 1, offset: 1753},
 			expr: &actionExpr{
-				pos: position{line: 43, col: 16, offset: 993},
+				pos: position{line: 62, col: 16, offset: 1770},
 				run: (*parser).callonRecoveryExpr1,
 				expr: &seqExpr{
-					pos: position{line: 43, col: 16, offset: 993},
+					pos: position{line: 62, col: 16, offset: 1770},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 43, col: 16, offset: 993},
+							pos:   position{line: 62, col: 16, offset: 1770},
 							label: "expr",
 							expr: &ruleRefExpr{
-								pos:  position{line: 43, col: 21, offset: 998},
-								name: "ChoiceExpr",
+								pos:    position{line: 62, col: 21, offset: 1775},
+								offset: 7,
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 43, col: 32, offset: 1009},
+							pos:   position{line: 62, col: 32, offset: 1786},
 							label: "recoverExprs",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 43, col: 45, offset: 1022},
+								pos: position{line: 62, col: 45, offset: 1799},
 								expr: &seqExpr{
-									pos: position{line: 43, col: 47, offset: 1024},
+									pos: position{line: 62, col: 47, offset: 1801},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 47, offset: 1024},
-											name: "__",
+											pos:    position{line: 62, col: 47, offset: 1801},
+											offset: 68,
 										},
 										&litMatcher{
-											pos:        position{line: 43, col: 50, offset: 1027},
+											pos:        position{line: 62, col: 50, offset: 1804},
 											val:        "//{",
 											ignoreCase: false,
 											want:       "\"//{\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 56, offset: 1033},
-											name: "__",
+											pos:    position{line: 62, col: 56, offset: 1810},
+											offset: 68,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 59, offset: 1036},
-											name: "Labels",
+											pos:    position{line: 62, col: 59, offset: 1813},
+											offset: 6,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 66, offset: 1043},
-											name: "__",
+											pos:    position{line: 62, col: 66, offset: 1820},
+											offset: 68,
 										},
 										&litMatcher{
-											pos:        position{line: 43, col: 69, offset: 1046},
+											pos:        position{line: 62, col: 69, offset: 1823},
 											val:        "}",
 											ignoreCase: false,
 											want:       "\"}\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 73, offset: 1050},
-											name: "__",
+											pos:    position{line: 62, col: 73, offset: 1827},
+											offset: 68,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 43, col: 76, offset: 1053},
-											name: "ChoiceExpr",
+											pos:    position{line: 62, col: 76, offset: 1830},
+											offset: 7,
 										},
 									},
 								},
@@ -251,46 +346,46 @@ var g = &grammar{
 		},
 		{
 			name: "Labels",
-			pos:  position{line: 58, col: 1, offset: 1449},
+			pos:  position{line: 77, col: 1, offset: 2226},
 			expr: &actionExpr{
-				pos: position{line: 58, col: 10, offset: 1460},
+				pos: position{line: 77, col: 10, offset: 2237},
 				run: (*parser).callonLabels1,
 				expr: &seqExpr{
-					pos: position{line: 58, col: 10, offset: 1460},
+					pos: position{line: 77, col: 10, offset: 2237},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 58, col: 10, offset: 1460},
+							pos:   position{line: 77, col: 10, offset: 2237},
 							label: "label",
 							expr: &ruleRefExpr{
-								pos:  position{line: 58, col: 16, offset: 1466},
-								name: "IdentifierName",
+								pos:    position{line: 77, col: 16, offset: 2243},
+								offset: 33,
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 58, col: 31, offset: 1481},
+							pos:   position{line: 77, col: 31, offset: 2258},
 							label: "labels",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 58, col: 38, offset: 1488},
+								pos: position{line: 77, col: 38, offset: 2265},
 								expr: &seqExpr{
-									pos: position{line: 58, col: 40, offset: 1490},
+									pos: position{line: 77, col: 40, offset: 2267},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 58, col: 40, offset: 1490},
-											name: "__",
+											pos:    position{line: 77, col: 40, offset: 2267},
+											offset: 68,
 										},
 										&litMatcher{
-											pos:        position{line: 58, col: 43, offset: 1493},
+											pos:        position{line: 77, col: 43, offset: 2270},
 											val:        ",",
 											ignoreCase: false,
 											want:       "\",\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 58, col: 47, offset: 1497},
-											name: "__",
+											pos:    position{line: 77, col: 47, offset: 2274},
+											offset: 68,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 58, col: 50, offset: 1500},
-											name: "IdentifierName",
+											pos:    position{line: 77, col: 50, offset: 2277},
+											offset: 33,
 										},
 									},
 								},
@@ -302,46 +397,46 @@ var g = &grammar{
 		},
 		{
 			name: "ChoiceExpr",
-			pos:  position{line: 67, col: 1, offset: 1819},
+			pos:  position{line: 86, col: 1, offset: 2596},
 			expr: &actionExpr{
-				pos: position{line: 67, col: 14, offset: 1834},
+				pos: position{line: 86, col: 14, offset: 2611},
 				run: (*parser).callonChoiceExpr1,
 				expr: &seqExpr{
-					pos: position{line: 67, col: 14, offset: 1834},
+					pos: position{line: 86, col: 14, offset: 2611},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 67, col: 14, offset: 1834},
+							pos:   position{line: 86, col: 14, offset: 2611},
 							label: "first",
 							expr: &ruleRefExpr{
-								pos:  position{line: 67, col: 20, offset: 1840},
-								name: "ActionExpr",
+								pos:    position{line: 86, col: 20, offset: 2617},
+								offset: 8,
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 67, col: 31, offset: 1851},
+							pos:   position{line: 86, col: 34, offset: 2631},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 67, col: 36, offset: 1856},
+								pos: position{line: 86, col: 39, offset: 2636},
 								expr: &seqExpr{
-									pos: position{line: 67, col: 38, offset: 1858},
+									pos: position{line: 86, col: 41, offset: 2638},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 67, col: 38, offset: 1858},
-											name: "__",
+											pos:    position{line: 86, col: 41, offset: 2638},
+											offset: 68,
 										},
 										&litMatcher{
-											pos:        position{line: 67, col: 41, offset: 1861},
+											pos:        position{line: 86, col: 44, offset: 2641},
 											val:        "/",
 											ignoreCase: false,
 											want:       "\"/\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 67, col: 45, offset: 1865},
-											name: "__",
+											pos:    position{line: 86, col: 48, offset: 2645},
+											offset: 68,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 67, col: 48, offset: 1868},
-											name: "ActionExpr",
+											pos:    position{line: 86, col: 51, offset: 2648},
+											offset: 8,
 										},
 									},
 								},
@@ -351,38 +446,101 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "ScanLimitExpr",
+			pos:  position{line: 101, col: 1, offset: 3046},
+			expr: &choiceExpr{
+				pos: position{line: 101, col: 17, offset: 3064},
+				alternatives: []any{
+					&actionExpr{
+						pos: position{line: 101, col: 17, offset: 3064},
+						run: (*parser).callonScanLimitExpr2,
+						expr: &seqExpr{
+							pos: position{line: 101, col: 17, offset: 3064},
+							exprs: []any{
+								&litMatcher{
+									pos:        position{line: 101, col: 17, offset: 3064},
+									val:        "@",
+									ignoreCase: false,
+									want:       "\"@\"",
+								},
+								&labeledExpr{
+									pos:   position{line: 101, col: 21, offset: 3068},
+									label: "limit",
+									expr: &ruleRefExpr{
+										pos:    position{line: 101, col: 27, offset: 3074},
+										offset: 9,
+									},
+								},
+								&ruleRefExpr{
+									pos:    position{line: 101, col: 37, offset: 3084},
+									offset: 68,
+								},
+								&labeledExpr{
+									pos:   position{line: 101, col: 40, offset: 3087},
+									label: "expr",
+									expr: &ruleRefExpr{
+										pos:    position{line: 101, col: 45, offset: 3092},
+										offset: 10,
+									},
+								},
+							},
+						},
+					},
+					&ruleRefExpr{
+						pos:    position{line: 106, col: 5, offset: 3234},
+						offset: 10,
+					},
+				},
+			},
+		},
+		{
+			name: "ScanLimit",
+			pos:  position{line: 108, col: 1, offset: 3246},
+			expr: &actionExpr{
+				pos: position{line: 108, col: 13, offset: 3260},
+				run: (*parser).callonScanLimit1,
+				expr: &zeroOrMoreExpr{
+					pos: position{line: 108, col: 13, offset: 3260},
+					expr: &ruleRefExpr{
+						pos:    position{line: 108, col: 13, offset: 3260},
+						offset: 50,
+					},
+				},
+			},
+		},
 		{
 			name: "ActionExpr",
-			pos:  position{line: 82, col: 1, offset: 2263},
+			pos:  position{line: 116, col: 1, offset: 3427},
 			expr: &actionExpr{
-				pos: position{line: 82, col: 14, offset: 2278},
+				pos: position{line: 116, col: 14, offset: 3442},
 				run: (*parser).callonActionExpr1,
 				expr: &seqExpr{
-					pos: position{line: 82, col: 14, offset: 2278},
+					pos: position{line: 116, col: 14, offset: 3442},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 82, col: 14, offset: 2278},
+							pos:   position{line: 116, col: 14, offset: 3442},
 							label: "expr",
 							expr: &ruleRefExpr{
-								pos:  position{line: 82, col: 19, offset: 2283},
-								name: "SeqExpr",
+								pos:    position{line: 116, col: 19, offset: 3447},
+								offset: 11,
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 82, col: 27, offset: 2291},
+							pos:   position{line: 116, col: 27, offset: 3455},
 							label: "code",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 82, col: 32, offset: 2296},
+								pos: position{line: 116, col: 32, offset: 3460},
 								expr: &seqExpr{
-									pos: position{line: 82, col: 34, offset: 2298},
+									pos: position{line: 116, col: 34, offset: 3462},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 82, col: 34, offset: 2298},
-											name: "__",
+											pos:    position{line: 116, col: 34, offset: 3462},
+											offset: 68,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 82, col: 37, offset: 2301},
-											name: "CodeBlock",
+											pos:    position{line: 116, col: 37, offset: 3465},
+											offset: 65,
 										},
 									},
 								},
@@ -394,36 +552,36 @@ var g = &grammar{
 		},
 		{
 			name: "SeqExpr",
-			pos:  position{line: 96, col: 1, offset: 2565},
+			pos:  position{line: 130, col: 1, offset: 3729},
 			expr: &actionExpr{
-				pos: position{line: 96, col: 11, offset: 2577},
+				pos: position{line: 130, col: 11, offset: 3741},
 				run: (*parser).callonSeqExpr1,
 				expr: &seqExpr{
-					pos: position{line: 96, col: 11, offset: 2577},
+					pos: position{line: 130, col: 11, offset: 3741},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 96, col: 11, offset: 2577},
+							pos:   position{line: 130, col: 11, offset: 3741},
 							label: "first",
 							expr: &ruleRefExpr{
-								pos:  position{line: 96, col: 17, offset: 2583},
-								name: "LabeledExpr",
+								pos:    position{line: 130, col: 17, offset: 3747},
+								offset: 12,
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 96, col: 29, offset: 2595},
+							pos:   position{line: 130, col: 29, offset: 3759},
 							label: "rest",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 96, col: 34, offset: 2600},
+								pos: position{line: 130, col: 34, offset: 3764},
 								expr: &seqExpr{
-									pos: position{line: 96, col: 36, offset: 2602},
+									pos: position{line: 130, col: 36, offset: 3766},
 									exprs: []any{
 										&ruleRefExpr{
-											pos:  position{line: 96, col: 36, offset: 2602},
-											name: "__",
+											pos:    position{line: 130, col: 36, offset: 3766},
+											offset: 68,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 96, col: 39, offset: 2605},
-											name: "LabeledExpr",
+											pos:    position{line: 130, col: 39, offset: 3769},
+											offset: 12,
 										},
 									},
 								},
@@ -435,192 +593,198 @@ var g = &grammar{
 		},
 		{
 			name: "LabeledExpr",
-			pos:  position{line: 109, col: 1, offset: 2946},
+			pos:  position{line: 143, col: 1, offset: 4110},
 			expr: &choiceExpr{
-				pos: position{line: 109, col: 15, offset: 2962},
+				pos: position{line: 143, col: 15, offset: 4126},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 109, col: 15, offset: 2962},
+						pos: position{line: 143, col: 15, offset: 4126},
 						run: (*parser).callonLabeledExpr2,
 						expr: &seqExpr{
-							pos: position{line: 109, col: 15, offset: 2962},
+							pos: position{line: 143, col: 15, offset: 4126},
 							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 109, col: 15, offset: 2962},
+									pos:   position{line: 143, col: 15, offset: 4126},
 									label: "label",
 									expr: &ruleRefExpr{
-										pos:  position{line: 109, col: 21, offset: 2968},
-										name: "Identifier",
+										pos:    position{line: 143, col: 21, offset: 4132},
+										offset: 32,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 109, col: 32, offset: 2979},
-									name: "__",
+									pos:    position{line: 143, col: 32, offset: 4143},
+									offset: 68,
 								},
 								&litMatcher{
-									pos:        position{line: 109, col: 35, offset: 2982},
+									pos:        position{line: 143, col: 35, offset: 4146},
 									val:        ":",
 									ignoreCase: false,
 									want:       "\":\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 109, col: 39, offset: 2986},
-									name: "__",
+									pos:    position{line: 143, col: 39, offset: 4150},
+									offset: 68,
 								},
 								&labeledExpr{
-									pos:   position{line: 109, col: 42, offset: 2989},
+									pos:   position{line: 143, col: 42, offset: 4153},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 109, col: 47, offset: 2994},
-										name: "PrefixedExpr",
+										pos:    position{line: 143, col: 47, offset: 4158},
+										offset: 13,
 									},
 								},
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 115, col: 5, offset: 3167},
-						name: "PrefixedExpr",
+						pos:    position{line: 149, col: 5, offset: 4331},
+						offset: 13,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 115, col: 20, offset: 3182},
-						name: "ThrowExpr",
+						pos:    position{line: 149, col: 20, offset: 4346},
+						offset: 64,
 					},
 				},
 			},
 		},
 		{
 			name: "PrefixedExpr",
-			pos:  position{line: 117, col: 1, offset: 3193},
+			pos:  position{line: 151, col: 1, offset: 4357},
 			expr: &choiceExpr{
-				pos: position{line: 117, col: 16, offset: 3210},
+				pos: position{line: 151, col: 16, offset: 4374},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 117, col: 16, offset: 3210},
+						pos: position{line: 151, col: 16, offset: 4374},
 						run: (*parser).callonPrefixedExpr2,
 						expr: &seqExpr{
-							pos: position{line: 117, col: 16, offset: 3210},
+							pos: position{line: 151, col: 16, offset: 4374},
 							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 117, col: 16, offset: 3210},
+									pos:   position{line: 151, col: 16, offset: 4374},
 									label: "op",
 									expr: &ruleRefExpr{
-										pos:  position{line: 117, col: 19, offset: 3213},
-										name: "PrefixedOp",
+										pos:    position{line: 151, col: 19, offset: 4377},
+										offset: 14,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 117, col: 30, offset: 3224},
-									name: "__",
+									pos:    position{line: 151, col: 30, offset: 4388},
+									offset: 68,
 								},
 								&labeledExpr{
-									pos:   position{line: 117, col: 33, offset: 3227},
+									pos:   position{line: 151, col: 33, offset: 4391},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 117, col: 38, offset: 3232},
-										name: "SuffixedExpr",
+										pos:    position{line: 151, col: 38, offset: 4396},
+										offset: 15,
 									},
 								},
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 128, col: 5, offset: 3514},
-						name: "SuffixedExpr",
+						pos:    position{line: 167, col: 5, offset: 4814},
+						offset: 15,
 					},
 				},
 			},
 		},
 		{
 			name: "PrefixedOp",
-			pos:  position{line: 130, col: 1, offset: 3528},
+			pos:  position{line: 169, col: 1, offset: 4828},
 			expr: &actionExpr{
-				pos: position{line: 130, col: 14, offset: 3543},
+				pos: position{line: 169, col: 14, offset: 4843},
 				run: (*parser).callonPrefixedOp1,
 				expr: &choiceExpr{
-					pos: position{line: 130, col: 16, offset: 3545},
+					pos: position{line: 169, col: 16, offset: 4845},
 					alternatives: []any{
 						&litMatcher{
-							pos:        position{line: 130, col: 16, offset: 3545},
+							pos:        position{line: 169, col: 16, offset: 4845},
 							val:        "&",
 							ignoreCase: false,
 							want:       "\"&\"",
 						},
 						&litMatcher{
-							pos:        position{line: 130, col: 22, offset: 3551},
+							pos:        position{line: 169, col: 22, offset: 4851},
 							val:        "!",
 							ignoreCase: false,
 							want:       "\"!\"",
 						},
+						&litMatcher{
+							pos:        position{line: 169, col: 28, offset: 4857},
+							val:        "~",
+							ignoreCase: false,
+							want:       "\"~\"",
+						},
 					},
 				},
 			},
 		},
 		{
 			name: "SuffixedExpr",
-			pos:  position{line: 134, col: 1, offset: 3593},
+			pos:  position{line: 173, col: 1, offset: 4899},
 			expr: &choiceExpr{
-				pos: position{line: 134, col: 16, offset: 3610},
+				pos: position{line: 173, col: 16, offset: 4916},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 134, col: 16, offset: 3610},
+						pos: position{line: 173, col: 16, offset: 4916},
 						run: (*parser).callonSuffixedExpr2,
 						expr: &seqExpr{
-							pos: position{line: 134, col: 16, offset: 3610},
+							pos: position{line: 173, col: 16, offset: 4916},
 							exprs: []any{
 								&labeledExpr{
-									pos:   position{line: 134, col: 16, offset: 3610},
+									pos:   position{line: 173, col: 16, offset: 4916},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 134, col: 21, offset: 3615},
-										name: "PrimaryExpr",
+										pos:    position{line: 173, col: 21, offset: 4921},
+										offset: 17,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 134, col: 33, offset: 3627},
-									name: "__",
+									pos:    position{line: 173, col: 33, offset: 4933},
+									offset: 68,
 								},
 								&labeledExpr{
-									pos:   position{line: 134, col: 36, offset: 3630},
+									pos:   position{line: 173, col: 36, offset: 4936},
 									label: "op",
 									expr: &ruleRefExpr{
-										pos:  position{line: 134, col: 39, offset: 3633},
-										name: "SuffixedOp",
+										pos:    position{line: 173, col: 39, offset: 4939},
+										offset: 16,
 									},
 								},
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 153, col: 5, offset: 4163},
-						name: "PrimaryExpr",
+						pos:    position{line: 192, col: 5, offset: 5469},
+						offset: 17,
 					},
 				},
 			},
 		},
 		{
 			name: "SuffixedOp",
-			pos:  position{line: 155, col: 1, offset: 4176},
+			pos:  position{line: 194, col: 1, offset: 5482},
 			expr: &actionExpr{
-				pos: position{line: 155, col: 14, offset: 4191},
+				pos: position{line: 194, col: 14, offset: 5497},
 				run: (*parser).callonSuffixedOp1,
 				expr: &choiceExpr{
-					pos: position{line: 155, col: 16, offset: 4193},
+					pos: position{line: 194, col: 16, offset: 5499},
 					alternatives: []any{
 						&litMatcher{
-							pos:        position{line: 155, col: 16, offset: 4193},
+							pos:        position{line: 194, col: 16, offset: 5499},
 							val:        "?",
 							ignoreCase: false,
 							want:       "\"?\"",
 						},
 						&litMatcher{
-							pos:        position{line: 155, col: 22, offset: 4199},
+							pos:        position{line: 194, col: 22, offset: 5505},
 							val:        "*",
 							ignoreCase: false,
 							want:       "\"*\"",
 						},
 						&litMatcher{
-							pos:        position{line: 155, col: 28, offset: 4205},
+							pos:        position{line: 194, col: 28, offset: 5511},
 							val:        "+",
 							ignoreCase: false,
 							want:       "\"+\"",
@@ -631,60 +795,84 @@ var g = &grammar{
 		},
 		{
 			name: "PrimaryExpr",
-			pos:  position{line: 159, col: 1, offset: 4247},
+			pos:  position{line: 198, col: 1, offset: 5553},
 			expr: &choiceExpr{
-				pos: position{line: 159, col: 15, offset: 4263},
+				pos: position{line: 198, col: 15, offset: 5569},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 159, col: 15, offset: 4263},
-						name: "LitMatcher",
+						pos:    position{line: 198, col: 15, offset: 5569},
+						offset: 36,
+					},
+					&ruleRefExpr{
+						pos:    position{line: 198, col: 28, offset: 5582},
+						offset: 52,
+					},
+					&ruleRefExpr{
+						pos:    position{line: 198, col: 47, offset: 5601},
+						offset: 58,
+					},
+					&ruleRefExpr{
+						pos:    position{line: 198, col: 60, offset: 5614},
+						offset: 59,
+					},
+					&ruleRefExpr{
+						pos:    position{line: 198, col: 76, offset: 5630},
+						offset: 60,
+					},
+					&ruleRefExpr{
+						pos:    position{line: 198, col: 92, offset: 5646},
+						offset: 61,
+					},
+					&ruleRefExpr{
+						pos:    position{line: 198, col: 107, offset: 5661},
+						offset: 62,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 159, col: 28, offset: 4276},
-						name: "CharClassMatcher",
+						pos:    position{line: 198, col: 129, offset: 5683},
+						offset: 63,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 159, col: 47, offset: 4295},
-						name: "AnyMatcher",
+						pos:    position{line: 198, col: 146, offset: 5700},
+						offset: 18,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 159, col: 60, offset: 4308},
-						name: "RuleRefExpr",
+						pos:    position{line: 198, col: 161, offset: 5715},
+						offset: 20,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 159, col: 74, offset: 4322},
-						name: "SemanticPredExpr",
+						pos:    position{line: 198, col: 175, offset: 5729},
+						offset: 23,
 					},
 					&actionExpr{
-						pos: position{line: 159, col: 93, offset: 4341},
-						run: (*parser).callonPrimaryExpr7,
+						pos: position{line: 198, col: 194, offset: 5748},
+						run: (*parser).callonPrimaryExpr13,
 						expr: &seqExpr{
-							pos: position{line: 159, col: 93, offset: 4341},
+							pos: position{line: 198, col: 194, offset: 5748},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 159, col: 93, offset: 4341},
+									pos:        position{line: 198, col: 194, offset: 5748},
 									val:        "(",
 									ignoreCase: false,
 									want:       "\"(\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 159, col: 97, offset: 4345},
-									name: "__",
+									pos:    position{line: 198, col: 198, offset: 5752},
+									offset: 68,
 								},
 								&labeledExpr{
-									pos:   position{line: 159, col: 100, offset: 4348},
+									pos:   position{line: 198, col: 201, offset: 5755},
 									label: "expr",
 									expr: &ruleRefExpr{
-										pos:  position{line: 159, col: 105, offset: 4353},
-										name: "Expression",
+										pos:    position{line: 198, col: 206, offset: 5760},
+										offset: 4,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 159, col: 116, offset: 4364},
-									name: "__",
+									pos:    position{line: 198, col: 217, offset: 5771},
+									offset: 68,
 								},
 								&litMatcher{
-									pos:        position{line: 159, col: 119, offset: 4367},
+									pos:        position{line: 198, col: 220, offset: 5774},
 									val:        ")",
 									ignoreCase: false,
 									want:       "\")\"",
@@ -695,51 +883,338 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "RuleCallExpr",
+			pos:  position{line: 201, col: 1, offset: 5803},
+			expr: &actionExpr{
+				pos: position{line: 201, col: 16, offset: 5820},
+				run: (*parser).callonRuleCallExpr1,
+				expr: &seqExpr{
+					pos: position{line: 201, col: 16, offset: 5820},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 201, col: 16, offset: 5820},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:    position{line: 201, col: 21, offset: 5825},
+								offset: 33,
+							},
+						},
+						&litMatcher{
+							pos:        position{line: 201, col: 36, offset: 5840},
+							val:        "(",
+							ignoreCase: false,
+							want:       "\"(\"",
+						},
+						&ruleRefExpr{
+							pos:    position{line: 201, col: 40, offset: 5844},
+							offset: 68,
+						},
+						&labeledExpr{
+							pos:   position{line: 201, col: 43, offset: 5847},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:    position{line: 201, col: 49, offset: 5853},
+								offset: 19,
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 201, col: 61, offset: 5865},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 201, col: 66, offset: 5870},
+								expr: &seqExpr{
+									pos: position{line: 201, col: 68, offset: 5872},
+									exprs: []any{
+										&ruleRefExpr{
+											pos:    position{line: 201, col: 68, offset: 5872},
+											offset: 68,
+										},
+										&litMatcher{
+											pos:        position{line: 201, col: 71, offset: 5875},
+											val:        ",",
+											ignoreCase: false,
+											want:       "\",\"",
+										},
+										&ruleRefExpr{
+											pos:    position{line: 201, col: 75, offset: 5879},
+											offset: 68,
+										},
+										&ruleRefExpr{
+											pos:    position{line: 201, col: 78, offset: 5882},
+											offset: 19,
+										},
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:    position{line: 201, col: 93, offset: 5897},
+							offset: 68,
+						},
+						&litMatcher{
+							pos:        position{line: 201, col: 96, offset: 5900},
+							val:        ")",
+							ignoreCase: false,
+							want:       "\")\"",
+						},
+						&notExpr{
+							pos: position{line: 201, col: 100, offset: 5904},
+							expr: &seqExpr{
+								pos: position{line: 201, col: 103, offset: 5907},
+								exprs: []any{
+									&ruleRefExpr{
+										pos:    position{line: 201, col: 103, offset: 5907},
+										offset: 68,
+									},
+									&zeroOrOneExpr{
+										pos: position{line: 201, col: 106, offset: 5910},
+										expr: &seqExpr{
+											pos: position{line: 201, col: 108, offset: 5912},
+											exprs: []any{
+												&ruleRefExpr{
+													pos:    position{line: 201, col: 108, offset: 5912},
+													offset: 37,
+												},
+												&ruleRefExpr{
+													pos:    position{line: 201, col: 122, offset: 5926},
+													offset: 68,
+												},
+											},
+										},
+									},
+									&choiceExpr{
+										pos: position{line: 201, col: 130, offset: 5934},
+										alternatives: []any{
+											&ruleRefExpr{
+												pos:    position{line: 201, col: 130, offset: 5934},
+												offset: 25,
+											},
+											&ruleRefExpr{
+												pos:    position{line: 201, col: 142, offset: 5946},
+												offset: 26,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RuleCallArg",
+			pos:  position{line: 210, col: 1, offset: 6242},
+			expr: &choiceExpr{
+				pos: position{line: 210, col: 15, offset: 6258},
+				alternatives: []any{
+					&ruleRefExpr{
+						pos:    position{line: 210, col: 15, offset: 6258},
+						offset: 36,
+					},
+					&ruleRefExpr{
+						pos:    position{line: 210, col: 28, offset: 6271},
+						offset: 20,
+					},
+				},
+			},
+		},
 		{
 			name: "RuleRefExpr",
-			pos:  position{line: 162, col: 1, offset: 4396},
+			pos:  position{line: 211, col: 1, offset: 6283},
 			expr: &actionExpr{
-				pos: position{line: 162, col: 15, offset: 4412},
+				pos: position{line: 211, col: 15, offset: 6299},
 				run: (*parser).callonRuleRefExpr1,
 				expr: &seqExpr{
-					pos: position{line: 162, col: 15, offset: 4412},
+					pos: position{line: 211, col: 15, offset: 6299},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 162, col: 15, offset: 4412},
+							pos:   position{line: 211, col: 15, offset: 6299},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 162, col: 20, offset: 4417},
-								name: "IdentifierName",
+								pos:    position{line: 211, col: 20, offset: 6304},
+								offset: 33,
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 211, col: 35, offset: 6319},
+							label: "args",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 211, col: 40, offset: 6324},
+								expr: &ruleRefExpr{
+									pos:    position{line: 211, col: 40, offset: 6324},
+									offset: 21,
+								},
 							},
 						},
 						&notExpr{
-							pos: position{line: 162, col: 35, offset: 4432},
+							pos: position{line: 211, col: 50, offset: 6334},
 							expr: &seqExpr{
-								pos: position{line: 162, col: 38, offset: 4435},
+								pos: position{line: 211, col: 53, offset: 6337},
 								exprs: []any{
+									&zeroOrOneExpr{
+										pos: position{line: 211, col: 53, offset: 6337},
+										expr: &ruleRefExpr{
+											pos:    position{line: 211, col: 53, offset: 6337},
+											offset: 3,
+										},
+									},
 									&ruleRefExpr{
-										pos:  position{line: 162, col: 38, offset: 4435},
-										name: "__",
+										pos:    position{line: 211, col: 65, offset: 6349},
+										offset: 68,
 									},
 									&zeroOrOneExpr{
-										pos: position{line: 162, col: 41, offset: 4438},
+										pos: position{line: 211, col: 68, offset: 6352},
 										expr: &seqExpr{
-											pos: position{line: 162, col: 43, offset: 4440},
+											pos: position{line: 211, col: 70, offset: 6354},
 											exprs: []any{
 												&ruleRefExpr{
-													pos:  position{line: 162, col: 43, offset: 4440},
-													name: "StringLiteral",
+													pos:    position{line: 211, col: 70, offset: 6354},
+													offset: 37,
 												},
 												&ruleRefExpr{
-													pos:  position{line: 162, col: 57, offset: 4454},
-													name: "__",
+													pos:    position{line: 211, col: 84, offset: 6368},
+													offset: 68,
 												},
 											},
 										},
 									},
+									&choiceExpr{
+										pos: position{line: 211, col: 92, offset: 6376},
+										alternatives: []any{
+											&ruleRefExpr{
+												pos:    position{line: 211, col: 92, offset: 6376},
+												offset: 25,
+											},
+											&ruleRefExpr{
+												pos:    position{line: 211, col: 104, offset: 6388},
+												offset: 26,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RuleArgs",
+			pos:  position{line: 219, col: 1, offset: 6575},
+			expr: &actionExpr{
+				pos: position{line: 219, col: 12, offset: 6588},
+				run: (*parser).callonRuleArgs1,
+				expr: &seqExpr{
+					pos: position{line: 219, col: 12, offset: 6588},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 219, col: 12, offset: 6588},
+							val:        "(",
+							ignoreCase: false,
+							want:       "\"(\"",
+						},
+						&ruleRefExpr{
+							pos:    position{line: 219, col: 16, offset: 6592},
+							offset: 68,
+						},
+						&labeledExpr{
+							pos:   position{line: 219, col: 19, offset: 6595},
+							label: "first",
+							expr: &ruleRefExpr{
+								pos:    position{line: 219, col: 25, offset: 6601},
+								offset: 22,
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 219, col: 33, offset: 6609},
+							label: "rest",
+							expr: &zeroOrMoreExpr{
+								pos: position{line: 219, col: 38, offset: 6614},
+								expr: &seqExpr{
+									pos: position{line: 219, col: 40, offset: 6616},
+									exprs: []any{
+										&ruleRefExpr{
+											pos:    position{line: 219, col: 40, offset: 6616},
+											offset: 68,
+										},
+										&litMatcher{
+											pos:        position{line: 219, col: 43, offset: 6619},
+											val:        ",",
+											ignoreCase: false,
+											want:       "\",\"",
+										},
+										&ruleRefExpr{
+											pos:    position{line: 219, col: 47, offset: 6623},
+											offset: 68,
+										},
+										&ruleRefExpr{
+											pos:    position{line: 219, col: 50, offset: 6626},
+											offset: 22,
+										},
+									},
+								},
+							},
+						},
+						&ruleRefExpr{
+							pos:    position{line: 219, col: 61, offset: 6637},
+							offset: 68,
+						},
+						&litMatcher{
+							pos:        position{line: 219, col: 64, offset: 6640},
+							val:        ")",
+							ignoreCase: false,
+							want:       "\")\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RuleArg",
+			pos:  position{line: 226, col: 1, offset: 6822},
+			expr: &actionExpr{
+				pos: position{line: 226, col: 11, offset: 6834},
+				run: (*parser).callonRuleArg1,
+				expr: &seqExpr{
+					pos: position{line: 226, col: 11, offset: 6834},
+					exprs: []any{
+						&labeledExpr{
+							pos:   position{line: 226, col: 11, offset: 6834},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:    position{line: 226, col: 16, offset: 6839},
+								offset: 33,
+							},
+						},
+						&ruleRefExpr{
+							pos:    position{line: 226, col: 31, offset: 6854},
+							offset: 68,
+						},
+						&litMatcher{
+							pos:        position{line: 226, col: 34, offset: 6857},
+							val:        "=",
+							ignoreCase: false,
+							want:       "\"=\"",
+						},
+						&ruleRefExpr{
+							pos:    position{line: 226, col: 38, offset: 6861},
+							offset: 68,
+						},
+						&labeledExpr{
+							pos:   position{line: 226, col: 41, offset: 6864},
+							label: "val",
+							expr: &choiceExpr{
+								pos: position{line: 226, col: 47, offset: 6870},
+								alternatives: []any{
+									&ruleRefExpr{
+										pos:    position{line: 226, col: 47, offset: 6870},
+										offset: 65,
+									},
 									&ruleRefExpr{
-										pos:  position{line: 162, col: 63, offset: 4460},
-										name: "RuleDefOp",
+										pos:    position{line: 226, col: 59, offset: 6882},
+										offset: 33,
 									},
 								},
 							},
@@ -750,31 +1225,31 @@ var g = &grammar{
 		},
 		{
 			name: "SemanticPredExpr",
-			pos:  position{line: 167, col: 1, offset: 4576},
+			pos:  position{line: 237, col: 1, offset: 7214},
 			expr: &actionExpr{
-				pos: position{line: 167, col: 20, offset: 4597},
+				pos: position{line: 237, col: 20, offset: 7235},
 				run: (*parser).callonSemanticPredExpr1,
 				expr: &seqExpr{
-					pos: position{line: 167, col: 20, offset: 4597},
+					pos: position{line: 237, col: 20, offset: 7235},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 167, col: 20, offset: 4597},
+							pos:   position{line: 237, col: 20, offset: 7235},
 							label: "op",
 							expr: &ruleRefExpr{
-								pos:  position{line: 167, col: 23, offset: 4600},
-								name: "SemanticPredOp",
+								pos:    position{line: 237, col: 23, offset: 7238},
+								offset: 24,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 167, col: 38, offset: 4615},
-							name: "__",
+							pos:    position{line: 237, col: 38, offset: 7253},
+							offset: 68,
 						},
 						&labeledExpr{
-							pos:   position{line: 167, col: 41, offset: 4618},
+							pos:   position{line: 237, col: 41, offset: 7256},
 							label: "code",
 							expr: &ruleRefExpr{
-								pos:  position{line: 167, col: 46, offset: 4623},
-								name: "CodeBlock",
+								pos:    position{line: 237, col: 46, offset: 7261},
+								offset: 65,
 							},
 						},
 					},
@@ -783,27 +1258,27 @@ var g = &grammar{
 		},
 		{
 			name: "SemanticPredOp",
-			pos:  position{line: 187, col: 1, offset: 5070},
+			pos:  position{line: 257, col: 1, offset: 7708},
 			expr: &actionExpr{
-				pos: position{line: 187, col: 18, offset: 5089},
+				pos: position{line: 257, col: 18, offset: 7727},
 				run: (*parser).callonSemanticPredOp1,
 				expr: &choiceExpr{
-					pos: position{line: 187, col: 20, offset: 5091},
+					pos: position{line: 257, col: 20, offset: 7729},
 					alternatives: []any{
 						&litMatcher{
-							pos:        position{line: 187, col: 20, offset: 5091},
+							pos:        position{line: 257, col: 20, offset: 7729},
 							val:        "#",
 							ignoreCase: false,
 							want:       "\"#\"",
 						},
 						&litMatcher{
-							pos:        position{line: 187, col: 26, offset: 5097},
+							pos:        position{line: 257, col: 26, offset: 7735},
 							val:        "&",
 							ignoreCase: false,
 							want:       "\"&\"",
 						},
 						&litMatcher{
-							pos:        position{line: 187, col: 32, offset: 5103},
+							pos:        position{line: 257, col: 32, offset: 7741},
 							val:        "!",
 							ignoreCase: false,
 							want:       "\"!\"",
@@ -814,30 +1289,30 @@ var g = &grammar{
 		},
 		{
 			name: "RuleDefOp",
-			pos:  position{line: 191, col: 1, offset: 5145},
+			pos:  position{line: 261, col: 1, offset: 7783},
 			expr: &choiceExpr{
-				pos: position{line: 191, col: 13, offset: 5159},
+				pos: position{line: 261, col: 13, offset: 7797},
 				alternatives: []any{
 					&litMatcher{
-						pos:        position{line: 191, col: 13, offset: 5159},
+						pos:        position{line: 261, col: 13, offset: 7797},
 						val:        "=",
 						ignoreCase: false,
 						want:       "\"=\"",
 					},
 					&litMatcher{
-						pos:        position{line: 191, col: 19, offset: 5165},
+						pos:        position{line: 261, col: 19, offset: 7803},
 						val:        "<-",
 						ignoreCase: false,
 						want:       "\"<-\"",
 					},
 					&litMatcher{
-						pos:        position{line: 191, col: 26, offset: 5172},
+						pos:        position{line: 261, col: 26, offset: 7810},
 						val:        "←",
 						ignoreCase: false,
 						want:       "\"←\"",
 					},
 					&litMatcher{
-						pos:        position{line: 191, col: 37, offset: 5183},
+						pos:        position{line: 261, col: 37, offset: 7821},
 						val:        "⟵",
 						ignoreCase: false,
 						want:       "\"⟵\"",
@@ -845,65 +1320,75 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "MacroDefOp",
+			pos:  position{line: 262, col: 1, offset: 7830},
+			expr: &litMatcher{
+				pos:        position{line: 262, col: 14, offset: 7845},
+				val:        ":=",
+				ignoreCase: false,
+				want:       "\":=\"",
+			},
+		},
 		{
 			name: "SourceChar",
-			pos:  position{line: 193, col: 1, offset: 5193},
+			pos:  position{line: 264, col: 1, offset: 7851},
 			expr: &anyMatcher{
-				line: 193, col: 14, offset: 5208,
+				line: 264, col: 14, offset: 7866,
 			},
 		},
 		{
 			name: "Comment",
-			pos:  position{line: 194, col: 1, offset: 5210},
+			pos:  position{line: 265, col: 1, offset: 7868},
 			expr: &choiceExpr{
-				pos: position{line: 194, col: 11, offset: 5222},
+				pos: position{line: 265, col: 11, offset: 7880},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 194, col: 11, offset: 5222},
-						name: "MultiLineComment",
+						pos:    position{line: 265, col: 11, offset: 7880},
+						offset: 29,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 194, col: 30, offset: 5241},
-						name: "SingleLineComment",
+						pos:    position{line: 265, col: 30, offset: 7899},
+						offset: 31,
 					},
 				},
 			},
 		},
 		{
 			name: "MultiLineComment",
-			pos:  position{line: 195, col: 1, offset: 5259},
+			pos:  position{line: 266, col: 1, offset: 7917},
 			expr: &seqExpr{
-				pos: position{line: 195, col: 20, offset: 5280},
+				pos: position{line: 266, col: 20, offset: 7938},
 				exprs: []any{
 					&litMatcher{
-						pos:        position{line: 195, col: 20, offset: 5280},
+						pos:        position{line: 266, col: 20, offset: 7938},
 						val:        "/*",
 						ignoreCase: false,
 						want:       "\"/*\"",
 					},
 					&zeroOrMoreExpr{
-						pos: position{line: 195, col: 25, offset: 5285},
+						pos: position{line: 266, col: 25, offset: 7943},
 						expr: &seqExpr{
-							pos: position{line: 195, col: 27, offset: 5287},
+							pos: position{line: 266, col: 27, offset: 7945},
 							exprs: []any{
 								&notExpr{
-									pos: position{line: 195, col: 27, offset: 5287},
+									pos: position{line: 266, col: 27, offset: 7945},
 									expr: &litMatcher{
-										pos:        position{line: 195, col: 28, offset: 5288},
+										pos:        position{line: 266, col: 28, offset: 7946},
 										val:        "*/",
 										ignoreCase: false,
 										want:       "\"*/\"",
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 195, col: 33, offset: 5293},
-									name: "SourceChar",
+									pos:    position{line: 266, col: 33, offset: 7951},
+									offset: 27,
 								},
 							},
 						},
 					},
 					&litMatcher{
-						pos:        position{line: 195, col: 47, offset: 5307},
+						pos:        position{line: 266, col: 47, offset: 7965},
 						val:        "*/",
 						ignoreCase: false,
 						want:       "\"*/\"",
@@ -913,48 +1398,48 @@ var g = &grammar{
 		},
 		{
 			name: "MultiLineCommentNoLineTerminator",
-			pos:  position{line: 196, col: 1, offset: 5312},
+			pos:  position{line: 267, col: 1, offset: 7970},
 			expr: &seqExpr{
-				pos: position{line: 196, col: 36, offset: 5349},
+				pos: position{line: 267, col: 36, offset: 8007},
 				exprs: []any{
 					&litMatcher{
-						pos:        position{line: 196, col: 36, offset: 5349},
+						pos:        position{line: 267, col: 36, offset: 8007},
 						val:        "/*",
 						ignoreCase: false,
 						want:       "\"/*\"",
 					},
 					&zeroOrMoreExpr{
-						pos: position{line: 196, col: 41, offset: 5354},
+						pos: position{line: 267, col: 41, offset: 8012},
 						expr: &seqExpr{
-							pos: position{line: 196, col: 43, offset: 5356},
+							pos: position{line: 267, col: 43, offset: 8014},
 							exprs: []any{
 								&notExpr{
-									pos: position{line: 196, col: 43, offset: 5356},
+									pos: position{line: 267, col: 43, offset: 8014},
 									expr: &choiceExpr{
-										pos: position{line: 196, col: 46, offset: 5359},
+										pos: position{line: 267, col: 46, offset: 8017},
 										alternatives: []any{
 											&litMatcher{
-												pos:        position{line: 196, col: 46, offset: 5359},
+												pos:        position{line: 267, col: 46, offset: 8017},
 												val:        "*/",
 												ignoreCase: false,
 												want:       "\"*/\"",
 											},
 											&ruleRefExpr{
-												pos:  position{line: 196, col: 53, offset: 5366},
-												name: "EOL",
+												pos:    position{line: 267, col: 53, offset: 8024},
+												offset: 71,
 											},
 										},
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 196, col: 59, offset: 5372},
-									name: "SourceChar",
+									pos:    position{line: 267, col: 59, offset: 8030},
+									offset: 27,
 								},
 							},
 						},
 					},
 					&litMatcher{
-						pos:        position{line: 196, col: 73, offset: 5386},
+						pos:        position{line: 267, col: 73, offset: 8044},
 						val:        "*/",
 						ignoreCase: false,
 						want:       "\"*/\"",
@@ -964,40 +1449,40 @@ var g = &grammar{
 		},
 		{
 			name: "SingleLineComment",
-			pos:  position{line: 197, col: 1, offset: 5391},
+			pos:  position{line: 268, col: 1, offset: 8049},
 			expr: &seqExpr{
-				pos: position{line: 197, col: 21, offset: 5413},
+				pos: position{line: 268, col: 21, offset: 8071},
 				exprs: []any{
 					&notExpr{
-						pos: position{line: 197, col: 21, offset: 5413},
+						pos: position{line: 268, col: 21, offset: 8071},
 						expr: &litMatcher{
-							pos:        position{line: 197, col: 23, offset: 5415},
+							pos:        position{line: 268, col: 23, offset: 8073},
 							val:        "//{",
 							ignoreCase: false,
 							want:       "\"//{\"",
 						},
 					},
 					&litMatcher{
-						pos:        position{line: 197, col: 30, offset: 5422},
+						pos:        position{line: 268, col: 30, offset: 8080},
 						val:        "//",
 						ignoreCase: false,
 						want:       "\"//\"",
 					},
 					&zeroOrMoreExpr{
-						pos: position{line: 197, col: 35, offset: 5427},
+						pos: position{line: 268, col: 35, offset: 8085},
 						expr: &seqExpr{
-							pos: position{line: 197, col: 37, offset: 5429},
+							pos: position{line: 268, col: 37, offset: 8087},
 							exprs: []any{
 								&notExpr{
-									pos: position{line: 197, col: 37, offset: 5429},
+									pos: position{line: 268, col: 37, offset: 8087},
 									expr: &ruleRefExpr{
-										pos:  position{line: 197, col: 38, offset: 5430},
-										name: "EOL",
+										pos:    position{line: 268, col: 38, offset: 8088},
+										offset: 71,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 197, col: 42, offset: 5434},
-									name: "SourceChar",
+									pos:    position{line: 268, col: 42, offset: 8092},
+									offset: 27,
 								},
 							},
 						},
@@ -1007,38 +1492,38 @@ var g = &grammar{
 		},
 		{
 			name: "Identifier",
-			pos:  position{line: 199, col: 1, offset: 5449},
+			pos:  position{line: 270, col: 1, offset: 8107},
 			expr: &actionExpr{
-				pos: position{line: 199, col: 14, offset: 5464},
+				pos: position{line: 270, col: 14, offset: 8122},
 				run: (*parser).callonIdentifier1,
 				expr: &labeledExpr{
-					pos:   position{line: 199, col: 14, offset: 5464},
+					pos:   position{line: 270, col: 14, offset: 8122},
 					label: "ident",
 					expr: &ruleRefExpr{
-						pos:  position{line: 199, col: 20, offset: 5470},
-						name: "IdentifierName",
+						pos:    position{line: 270, col: 20, offset: 8128},
+						offset: 33,
 					},
 				},
 			},
 		},
 		{
 			name: "IdentifierName",
-			pos:  position{line: 207, col: 1, offset: 5689},
+			pos:  position{line: 278, col: 1, offset: 8347},
 			expr: &actionExpr{
-				pos: position{line: 207, col: 18, offset: 5708},
+				pos: position{line: 278, col: 18, offset: 8366},
 				run: (*parser).callonIdentifierName1,
 				expr: &seqExpr{
-					pos: position{line: 207, col: 18, offset: 5708},
+					pos: position{line: 278, col: 18, offset: 8366},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 207, col: 18, offset: 5708},
-							name: "IdentifierStart",
+							pos:    position{line: 278, col: 18, offset: 8366},
+							offset: 34,
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 207, col: 34, offset: 5724},
+							pos: position{line: 278, col: 34, offset: 8382},
 							expr: &ruleRefExpr{
-								pos:  position{line: 207, col: 34, offset: 5724},
-								name: "IdentifierPart",
+								pos:    position{line: 278, col: 34, offset: 8382},
+								offset: 35,
 							},
 						},
 					},
@@ -1047,9 +1532,9 @@ var g = &grammar{
 		},
 		{
 			name: "IdentifierStart",
-			pos:  position{line: 210, col: 1, offset: 5806},
+			pos:  position{line: 281, col: 1, offset: 8464},
 			expr: &charClassMatcher{
-				pos:        position{line: 210, col: 19, offset: 5826},
+				pos:        position{line: 281, col: 19, offset: 8484},
 				val:        "[\\pL_]",
 				chars:      []rune{'_'},
 				classes:    []*unicode.RangeTable{rangeTable("L")},
@@ -1059,16 +1544,16 @@ var g = &grammar{
 		},
 		{
 			name: "IdentifierPart",
-			pos:  position{line: 211, col: 1, offset: 5833},
+			pos:  position{line: 282, col: 1, offset: 8491},
 			expr: &choiceExpr{
-				pos: position{line: 211, col: 18, offset: 5852},
+				pos: position{line: 282, col: 18, offset: 8510},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 211, col: 18, offset: 5852},
-						name: "IdentifierStart",
+						pos:    position{line: 282, col: 18, offset: 8510},
+						offset: 34,
 					},
 					&charClassMatcher{
-						pos:        position{line: 211, col: 36, offset: 5870},
+						pos:        position{line: 282, col: 36, offset: 8528},
 						val:        "[\\p{Nd}]",
 						classes:    []*unicode.RangeTable{rangeTable("Nd")},
 						ignoreCase: false,
@@ -1079,28 +1564,28 @@ var g = &grammar{
 		},
 		{
 			name: "LitMatcher",
-			pos:  position{line: 213, col: 1, offset: 5880},
+			pos:  position{line: 284, col: 1, offset: 8538},
 			expr: &actionExpr{
-				pos: position{line: 213, col: 14, offset: 5895},
+				pos: position{line: 284, col: 14, offset: 8553},
 				run: (*parser).callonLitMatcher1,
 				expr: &seqExpr{
-					pos: position{line: 213, col: 14, offset: 5895},
+					pos: position{line: 284, col: 14, offset: 8553},
 					exprs: []any{
 						&labeledExpr{
-							pos:   position{line: 213, col: 14, offset: 5895},
+							pos:   position{line: 284, col: 14, offset: 8553},
 							label: "lit",
 							expr: &ruleRefExpr{
-								pos:  position{line: 213, col: 18, offset: 5899},
-								name: "StringLiteral",
+								pos:    position{line: 284, col: 18, offset: 8557},
+								offset: 37,
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 213, col: 32, offset: 5913},
+							pos:   position{line: 284, col: 32, offset: 8571},
 							label: "ignore",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 213, col: 39, offset: 5920},
+								pos: position{line: 284, col: 39, offset: 8578},
 								expr: &litMatcher{
-									pos:        position{line: 213, col: 39, offset: 5920},
+									pos:        position{line: 284, col: 39, offset: 8578},
 									val:        "i",
 									ignoreCase: false,
 									want:       "\"i\"",
@@ -1113,34 +1598,34 @@ var g = &grammar{
 		},
 		{
 			name: "StringLiteral",
-			pos:  position{line: 226, col: 1, offset: 6319},
+			pos:  position{line: 297, col: 1, offset: 8977},
 			expr: &choiceExpr{
-				pos: position{line: 226, col: 17, offset: 6337},
+				pos: position{line: 297, col: 17, offset: 8995},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 226, col: 17, offset: 6337},
+						pos: position{line: 297, col: 17, offset: 8995},
 						run: (*parser).callonStringLiteral2,
 						expr: &choiceExpr{
-							pos: position{line: 226, col: 19, offset: 6339},
+							pos: position{line: 297, col: 19, offset: 8997},
 							alternatives: []any{
 								&seqExpr{
-									pos: position{line: 226, col: 19, offset: 6339},
+									pos: position{line: 297, col: 19, offset: 8997},
 									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 226, col: 19, offset: 6339},
+											pos:        position{line: 297, col: 19, offset: 8997},
 											val:        "\"",
 											ignoreCase: false,
 											want:       "\"\\\"\"",
 										},
 										&zeroOrMoreExpr{
-											pos: position{line: 226, col: 23, offset: 6343},
+											pos: position{line: 297, col: 23, offset: 9001},
 											expr: &ruleRefExpr{
-												pos:  position{line: 226, col: 23, offset: 6343},
-												name: "DoubleStringChar",
+												pos:    position{line: 297, col: 23, offset: 9001},
+												offset: 38,
 											},
 										},
 										&litMatcher{
-											pos:        position{line: 226, col: 41, offset: 6361},
+											pos:        position{line: 297, col: 41, offset: 9019},
 											val:        "\"",
 											ignoreCase: false,
 											want:       "\"\\\"\"",
@@ -1148,20 +1633,20 @@ var g = &grammar{
 									},
 								},
 								&seqExpr{
-									pos: position{line: 226, col: 47, offset: 6367},
+									pos: position{line: 297, col: 47, offset: 9025},
 									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 226, col: 47, offset: 6367},
+											pos:        position{line: 297, col: 47, offset: 9025},
 											val:        "'",
 											ignoreCase: false,
 											want:       "\"'\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 226, col: 51, offset: 6371},
-											name: "SingleStringChar",
+											pos:    position{line: 297, col: 51, offset: 9029},
+											offset: 39,
 										},
 										&litMatcher{
-											pos:        position{line: 226, col: 68, offset: 6388},
+											pos:        position{line: 297, col: 68, offset: 9046},
 											val:        "'",
 											ignoreCase: false,
 											want:       "\"'\"",
@@ -1169,23 +1654,23 @@ var g = &grammar{
 									},
 								},
 								&seqExpr{
-									pos: position{line: 226, col: 74, offset: 6394},
+									pos: position{line: 297, col: 74, offset: 9052},
 									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 226, col: 74, offset: 6394},
+											pos:        position{line: 297, col: 74, offset: 9052},
 											val:        "`",
 											ignoreCase: false,
 											want:       "\"`\"",
 										},
 										&zeroOrMoreExpr{
-											pos: position{line: 226, col: 78, offset: 6398},
+											pos: position{line: 297, col: 78, offset: 9056},
 											expr: &ruleRefExpr{
-												pos:  position{line: 226, col: 78, offset: 6398},
-												name: "RawStringChar",
+												pos:    position{line: 297, col: 78, offset: 9056},
+												offset: 40,
 											},
 										},
 										&litMatcher{
-											pos:        position{line: 226, col: 93, offset: 6413},
+											pos:        position{line: 297, col: 93, offset: 9071},
 											val:        "`",
 											ignoreCase: false,
 											want:       "\"`\"",
@@ -1196,92 +1681,92 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 228, col: 5, offset: 6486},
+						pos: position{line: 299, col: 5, offset: 9144},
 						run: (*parser).callonStringLiteral18,
 						expr: &choiceExpr{
-							pos: position{line: 228, col: 7, offset: 6488},
+							pos: position{line: 299, col: 7, offset: 9146},
 							alternatives: []any{
 								&seqExpr{
-									pos: position{line: 228, col: 9, offset: 6490},
+									pos: position{line: 299, col: 9, offset: 9148},
 									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 228, col: 9, offset: 6490},
+											pos:        position{line: 299, col: 9, offset: 9148},
 											val:        "\"",
 											ignoreCase: false,
 											want:       "\"\\\"\"",
 										},
 										&zeroOrMoreExpr{
-											pos: position{line: 228, col: 13, offset: 6494},
+											pos: position{line: 299, col: 13, offset: 9152},
 											expr: &ruleRefExpr{
-												pos:  position{line: 228, col: 13, offset: 6494},
-												name: "DoubleStringChar",
+												pos:    position{line: 299, col: 13, offset: 9152},
+												offset: 38,
 											},
 										},
 										&choiceExpr{
-											pos: position{line: 228, col: 33, offset: 6514},
+											pos: position{line: 299, col: 33, offset: 9172},
 											alternatives: []any{
 												&ruleRefExpr{
-													pos:  position{line: 228, col: 33, offset: 6514},
-													name: "EOL",
+													pos:    position{line: 299, col: 33, offset: 9172},
+													offset: 71,
 												},
 												&ruleRefExpr{
-													pos:  position{line: 228, col: 39, offset: 6520},
-													name: "EOF",
+													pos:    position{line: 299, col: 39, offset: 9178},
+													offset: 73,
 												},
 											},
 										},
 									},
 								},
 								&seqExpr{
-									pos: position{line: 228, col: 51, offset: 6532},
+									pos: position{line: 299, col: 51, offset: 9190},
 									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 228, col: 51, offset: 6532},
+											pos:        position{line: 299, col: 51, offset: 9190},
 											val:        "'",
 											ignoreCase: false,
 											want:       "\"'\"",
 										},
 										&zeroOrOneExpr{
-											pos: position{line: 228, col: 55, offset: 6536},
+											pos: position{line: 299, col: 55, offset: 9194},
 											expr: &ruleRefExpr{
-												pos:  position{line: 228, col: 55, offset: 6536},
-												name: "SingleStringChar",
+												pos:    position{line: 299, col: 55, offset: 9194},
+												offset: 39,
 											},
 										},
 										&choiceExpr{
-											pos: position{line: 228, col: 75, offset: 6556},
+											pos: position{line: 299, col: 75, offset: 9214},
 											alternatives: []any{
 												&ruleRefExpr{
-													pos:  position{line: 228, col: 75, offset: 6556},
-													name: "EOL",
+													pos:    position{line: 299, col: 75, offset: 9214},
+													offset: 71,
 												},
 												&ruleRefExpr{
-													pos:  position{line: 228, col: 81, offset: 6562},
-													name: "EOF",
+													pos:    position{line: 299, col: 81, offset: 9220},
+													offset: 73,
 												},
 											},
 										},
 									},
 								},
 								&seqExpr{
-									pos: position{line: 228, col: 91, offset: 6572},
+									pos: position{line: 299, col: 91, offset: 9230},
 									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 228, col: 91, offset: 6572},
+											pos:        position{line: 299, col: 91, offset: 9230},
 											val:        "`",
 											ignoreCase: false,
 											want:       "\"`\"",
 										},
 										&zeroOrMoreExpr{
-											pos: position{line: 228, col: 95, offset: 6576},
+											pos: position{line: 299, col: 95, offset: 9234},
 											expr: &ruleRefExpr{
-												pos:  position{line: 228, col: 95, offset: 6576},
-												name: "RawStringChar",
+												pos:    position{line: 299, col: 95, offset: 9234},
+												offset: 40,
 											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 228, col: 110, offset: 6591},
-											name: "EOF",
+											pos:    position{line: 299, col: 110, offset: 9249},
+											offset: 73,
 										},
 									},
 								},
@@ -1293,55 +1778,55 @@ var g = &grammar{
 		},
 		{
 			name: "DoubleStringChar",
-			pos:  position{line: 232, col: 1, offset: 6693},
+			pos:  position{line: 303, col: 1, offset: 9351},
 			expr: &choiceExpr{
-				pos: position{line: 232, col: 20, offset: 6714},
+				pos: position{line: 303, col: 20, offset: 9372},
 				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 232, col: 20, offset: 6714},
+						pos: position{line: 303, col: 20, offset: 9372},
 						exprs: []any{
 							&notExpr{
-								pos: position{line: 232, col: 20, offset: 6714},
+								pos: position{line: 303, col: 20, offset: 9372},
 								expr: &choiceExpr{
-									pos: position{line: 232, col: 23, offset: 6717},
+									pos: position{line: 303, col: 23, offset: 9375},
 									alternatives: []any{
 										&litMatcher{
-											pos:        position{line: 232, col: 23, offset: 6717},
+											pos:        position{line: 303, col: 23, offset: 9375},
 											val:        "\"",
 											ignoreCase: false,
 											want:       "\"\\\"\"",
 										},
 										&litMatcher{
-											pos:        position{line: 232, col: 29, offset: 6723},
+											pos:        position{line: 303, col: 29, offset: 9381},
 											val:        "\\",
 											ignoreCase: false,
 											want:       "\"\\\\\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 232, col: 36, offset: 6730},
-											name: "EOL",
+											pos:    position{line: 303, col: 36, offset: 9388},
+											offset: 71,
 										},
 									},
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 232, col: 42, offset: 6736},
-								name: "SourceChar",
+								pos:    position{line: 303, col: 42, offset: 9394},
+								offset: 27,
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 232, col: 55, offset: 6749},
+						pos: position{line: 303, col: 55, offset: 9407},
 						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 232, col: 55, offset: 6749},
+								pos:        position{line: 303, col: 55, offset: 9407},
 								val:        "\\",
 								ignoreCase: false,
 								want:       "\"\\\\\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 232, col: 60, offset: 6754},
-								name: "DoubleStringEscape",
+								pos:    position{line: 303, col: 60, offset: 9412},
+								offset: 41,
 							},
 						},
 					},
@@ -1350,55 +1835,55 @@ var g = &grammar{
 		},
 		{
 			name: "SingleStringChar",
-			pos:  position{line: 233, col: 1, offset: 6773},
+			pos:  position{line: 304, col: 1, offset: 9431},
 			expr: &choiceExpr{
-				pos: position{line: 233, col: 20, offset: 6794},
+				pos: position{line: 304, col: 20, offset: 9452},
 				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 233, col: 20, offset: 6794},
+						pos: position{line: 304, col: 20, offset: 9452},
 						exprs: []any{
 							&notExpr{
-								pos: position{line: 233, col: 20, offset: 6794},
+								pos: position{line: 304, col: 20, offset: 9452},
 								expr: &choiceExpr{
-									pos: position{line: 233, col: 23, offset: 6797},
+									pos: position{line: 304, col: 23, offset: 9455},
 									alternatives: []any{
 										&litMatcher{
-											pos:        position{line: 233, col: 23, offset: 6797},
+											pos:        position{line: 304, col: 23, offset: 9455},
 											val:        "'",
 											ignoreCase: false,
 											want:       "\"'\"",
 										},
 										&litMatcher{
-											pos:        position{line: 233, col: 29, offset: 6803},
+											pos:        position{line: 304, col: 29, offset: 9461},
 											val:        "\\",
 											ignoreCase: false,
 											want:       "\"\\\\\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 233, col: 36, offset: 6810},
-											name: "EOL",
+											pos:    position{line: 304, col: 36, offset: 9468},
+											offset: 71,
 										},
 									},
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 233, col: 42, offset: 6816},
-								name: "SourceChar",
+								pos:    position{line: 304, col: 42, offset: 9474},
+								offset: 27,
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 233, col: 55, offset: 6829},
+						pos: position{line: 304, col: 55, offset: 9487},
 						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 233, col: 55, offset: 6829},
+								pos:        position{line: 304, col: 55, offset: 9487},
 								val:        "\\",
 								ignoreCase: false,
 								want:       "\"\\\\\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 233, col: 60, offset: 6834},
-								name: "SingleStringEscape",
+								pos:    position{line: 304, col: 60, offset: 9492},
+								offset: 42,
 							},
 						},
 					},
@@ -1407,64 +1892,64 @@ var g = &grammar{
 		},
 		{
 			name: "RawStringChar",
-			pos:  position{line: 234, col: 1, offset: 6853},
+			pos:  position{line: 305, col: 1, offset: 9511},
 			expr: &seqExpr{
-				pos: position{line: 234, col: 17, offset: 6871},
+				pos: position{line: 305, col: 17, offset: 9529},
 				exprs: []any{
 					&notExpr{
-						pos: position{line: 234, col: 17, offset: 6871},
+						pos: position{line: 305, col: 17, offset: 9529},
 						expr: &litMatcher{
-							pos:        position{line: 234, col: 18, offset: 6872},
+							pos:        position{line: 305, col: 18, offset: 9530},
 							val:        "`",
 							ignoreCase: false,
 							want:       "\"`\"",
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 234, col: 22, offset: 6876},
-						name: "SourceChar",
+						pos:    position{line: 305, col: 22, offset: 9534},
+						offset: 27,
 					},
 				},
 			},
 		},
 		{
 			name: "DoubleStringEscape",
-			pos:  position{line: 236, col: 1, offset: 6888},
+			pos:  position{line: 307, col: 1, offset: 9546},
 			expr: &choiceExpr{
-				pos: position{line: 236, col: 22, offset: 6911},
+				pos: position{line: 307, col: 22, offset: 9569},
 				alternatives: []any{
 					&choiceExpr{
-						pos: position{line: 236, col: 24, offset: 6913},
+						pos: position{line: 307, col: 24, offset: 9571},
 						alternatives: []any{
 							&litMatcher{
-								pos:        position{line: 236, col: 24, offset: 6913},
+								pos:        position{line: 307, col: 24, offset: 9571},
 								val:        "\"",
 								ignoreCase: false,
 								want:       "\"\\\"\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 236, col: 30, offset: 6919},
-								name: "CommonEscapeSequence",
+								pos:    position{line: 307, col: 30, offset: 9577},
+								offset: 43,
 							},
 						},
 					},
 					&actionExpr{
-						pos: position{line: 237, col: 7, offset: 6948},
+						pos: position{line: 308, col: 7, offset: 9606},
 						run: (*parser).callonDoubleStringEscape5,
 						expr: &choiceExpr{
-							pos: position{line: 237, col: 9, offset: 6950},
+							pos: position{line: 308, col: 9, offset: 9608},
 							alternatives: []any{
 								&ruleRefExpr{
-									pos:  position{line: 237, col: 9, offset: 6950},
-									name: "SourceChar",
+									pos:    position{line: 308, col: 9, offset: 9608},
+									offset: 27,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 237, col: 22, offset: 6963},
-									name: "EOL",
+									pos:    position{line: 308, col: 22, offset: 9621},
+									offset: 71,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 237, col: 28, offset: 6969},
-									name: "EOF",
+									pos:    position{line: 308, col: 28, offset: 9627},
+									offset: 73,
 								},
 							},
 						},
@@ -1474,42 +1959,42 @@ var g = &grammar{
 		},
 		{
 			name: "SingleStringEscape",
-			pos:  position{line: 240, col: 1, offset: 7034},
+			pos:  position{line: 311, col: 1, offset: 9692},
 			expr: &choiceExpr{
-				pos: position{line: 240, col: 22, offset: 7057},
+				pos: position{line: 311, col: 22, offset: 9715},
 				alternatives: []any{
 					&choiceExpr{
-						pos: position{line: 240, col: 24, offset: 7059},
+						pos: position{line: 311, col: 24, offset: 9717},
 						alternatives: []any{
 							&litMatcher{
-								pos:        position{line: 240, col: 24, offset: 7059},
+								pos:        position{line: 311, col: 24, offset: 9717},
 								val:        "'",
 								ignoreCase: false,
 								want:       "\"'\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 240, col: 30, offset: 7065},
-								name: "CommonEscapeSequence",
+								pos:    position{line: 311, col: 30, offset: 9723},
+								offset: 43,
 							},
 						},
 					},
 					&actionExpr{
-						pos: position{line: 241, col: 7, offset: 7094},
+						pos: position{line: 312, col: 7, offset: 9752},
 						run: (*parser).callonSingleStringEscape5,
 						expr: &choiceExpr{
-							pos: position{line: 241, col: 9, offset: 7096},
+							pos: position{line: 312, col: 9, offset: 9754},
 							alternatives: []any{
 								&ruleRefExpr{
-									pos:  position{line: 241, col: 9, offset: 7096},
-									name: "SourceChar",
+									pos:    position{line: 312, col: 9, offset: 9754},
+									offset: 27,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 241, col: 22, offset: 7109},
-									name: "EOL",
+									pos:    position{line: 312, col: 22, offset: 9767},
+									offset: 71,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 241, col: 28, offset: 7115},
-									name: "EOF",
+									pos:    position{line: 312, col: 28, offset: 9773},
+									offset: 73,
 								},
 							},
 						},
@@ -1519,83 +2004,83 @@ var g = &grammar{
 		},
 		{
 			name: "CommonEscapeSequence",
-			pos:  position{line: 245, col: 1, offset: 7181},
+			pos:  position{line: 316, col: 1, offset: 9839},
 			expr: &choiceExpr{
-				pos: position{line: 245, col: 24, offset: 7206},
+				pos: position{line: 316, col: 24, offset: 9864},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 245, col: 24, offset: 7206},
-						name: "SingleCharEscape",
+						pos:    position{line: 316, col: 24, offset: 9864},
+						offset: 44,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 245, col: 43, offset: 7225},
-						name: "OctalEscape",
+						pos:    position{line: 316, col: 43, offset: 9883},
+						offset: 45,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 245, col: 57, offset: 7239},
-						name: "HexEscape",
+						pos:    position{line: 316, col: 57, offset: 9897},
+						offset: 46,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 245, col: 69, offset: 7251},
-						name: "LongUnicodeEscape",
+						pos:    position{line: 316, col: 69, offset: 9909},
+						offset: 47,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 245, col: 89, offset: 7271},
-						name: "ShortUnicodeEscape",
+						pos:    position{line: 316, col: 89, offset: 9929},
+						offset: 48,
 					},
 				},
 			},
 		},
 		{
 			name: "SingleCharEscape",
-			pos:  position{line: 246, col: 1, offset: 7290},
+			pos:  position{line: 317, col: 1, offset: 9948},
 			expr: &choiceExpr{
-				pos: position{line: 246, col: 20, offset: 7311},
+				pos: position{line: 317, col: 20, offset: 9969},
 				alternatives: []any{
 					&litMatcher{
-						pos:        position{line: 246, col: 20, offset: 7311},
+						pos:        position{line: 317, col: 20, offset: 9969},
 						val:        "a",
 						ignoreCase: false,
 						want:       "\"a\"",
 					},
 					&litMatcher{
-						pos:        position{line: 246, col: 26, offset: 7317},
+						pos:        position{line: 317, col: 26, offset: 9975},
 						val:        "b",
 						ignoreCase: false,
 						want:       "\"b\"",
 					},
 					&litMatcher{
-						pos:        position{line: 246, col: 32, offset: 7323},
+						pos:        position{line: 317, col: 32, offset: 9981},
 						val:        "n",
 						ignoreCase: false,
 						want:       "\"n\"",
 					},
 					&litMatcher{
-						pos:        position{line: 246, col: 38, offset: 7329},
+						pos:        position{line: 317, col: 38, offset: 9987},
 						val:        "f",
 						ignoreCase: false,
 						want:       "\"f\"",
 					},
 					&litMatcher{
-						pos:        position{line: 246, col: 44, offset: 7335},
+						pos:        position{line: 317, col: 44, offset: 9993},
 						val:        "r",
 						ignoreCase: false,
 						want:       "\"r\"",
 					},
 					&litMatcher{
-						pos:        position{line: 246, col: 50, offset: 7341},
+						pos:        position{line: 317, col: 50, offset: 9999},
 						val:        "t",
 						ignoreCase: false,
 						want:       "\"t\"",
 					},
 					&litMatcher{
-						pos:        position{line: 246, col: 56, offset: 7347},
+						pos:        position{line: 317, col: 56, offset: 10005},
 						val:        "v",
 						ignoreCase: false,
 						want:       "\"v\"",
 					},
 					&litMatcher{
-						pos:        position{line: 246, col: 62, offset: 7353},
+						pos:        position{line: 317, col: 62, offset: 10011},
 						val:        "\\",
 						ignoreCase: false,
 						want:       "\"\\\\\"",
@@ -1605,51 +2090,51 @@ var g = &grammar{
 		},
 		{
 			name: "OctalEscape",
-			pos:  position{line: 247, col: 1, offset: 7358},
+			pos:  position{line: 318, col: 1, offset: 10016},
 			expr: &choiceExpr{
-				pos: position{line: 247, col: 15, offset: 7374},
+				pos: position{line: 318, col: 15, offset: 10032},
 				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 247, col: 15, offset: 7374},
+						pos: position{line: 318, col: 15, offset: 10032},
 						exprs: []any{
 							&ruleRefExpr{
-								pos:  position{line: 247, col: 15, offset: 7374},
-								name: "OctalDigit",
+								pos:    position{line: 318, col: 15, offset: 10032},
+								offset: 49,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 247, col: 26, offset: 7385},
-								name: "OctalDigit",
+								pos:    position{line: 318, col: 26, offset: 10043},
+								offset: 49,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 247, col: 37, offset: 7396},
-								name: "OctalDigit",
+								pos:    position{line: 318, col: 37, offset: 10054},
+								offset: 49,
 							},
 						},
 					},
 					&actionExpr{
-						pos: position{line: 248, col: 7, offset: 7413},
+						pos: position{line: 319, col: 7, offset: 10071},
 						run: (*parser).callonOctalEscape6,
 						expr: &seqExpr{
-							pos: position{line: 248, col: 7, offset: 7413},
+							pos: position{line: 319, col: 7, offset: 10071},
 							exprs: []any{
 								&ruleRefExpr{
-									pos:  position{line: 248, col: 7, offset: 7413},
-									name: "OctalDigit",
+									pos:    position{line: 319, col: 7, offset: 10071},
+									offset: 49,
 								},
 								&choiceExpr{
-									pos: position{line: 248, col: 20, offset: 7426},
+									pos: position{line: 319, col: 20, offset: 10084},
 									alternatives: []any{
 										&ruleRefExpr{
-											pos:  position{line: 248, col: 20, offset: 7426},
-											name: "SourceChar",
+											pos:    position{line: 319, col: 20, offset: 10084},
+											offset: 27,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 248, col: 33, offset: 7439},
-											name: "EOL",
+											pos:    position{line: 319, col: 33, offset: 10097},
+											offset: 71,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 248, col: 39, offset: 7445},
-											name: "EOF",
+											pos:    position{line: 319, col: 39, offset: 10103},
+											offset: 73,
 										},
 									},
 								},
@@ -1661,55 +2146,55 @@ var g = &grammar{
 		},
 		{
 			name: "HexEscape",
-			pos:  position{line: 251, col: 1, offset: 7506},
+			pos:  position{line: 322, col: 1, offset: 10164},
 			expr: &choiceExpr{
-				pos: position{line: 251, col: 13, offset: 7520},
+				pos: position{line: 322, col: 13, offset: 10178},
 				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 251, col: 13, offset: 7520},
+						pos: position{line: 322, col: 13, offset: 10178},
 						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 251, col: 13, offset: 7520},
+								pos:        position{line: 322, col: 13, offset: 10178},
 								val:        "x",
 								ignoreCase: false,
 								want:       "\"x\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 251, col: 17, offset: 7524},
-								name: "HexDigit",
+								pos:    position{line: 322, col: 17, offset: 10182},
+								offset: 51,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 251, col: 26, offset: 7533},
-								name: "HexDigit",
+								pos:    position{line: 322, col: 26, offset: 10191},
+								offset: 51,
 							},
 						},
 					},
 					&actionExpr{
-						pos: position{line: 252, col: 7, offset: 7548},
+						pos: position{line: 323, col: 7, offset: 10206},
 						run: (*parser).callonHexEscape6,
 						expr: &seqExpr{
-							pos: position{line: 252, col: 7, offset: 7548},
+							pos: position{line: 323, col: 7, offset: 10206},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 252, col: 7, offset: 7548},
+									pos:        position{line: 323, col: 7, offset: 10206},
 									val:        "x",
 									ignoreCase: false,
 									want:       "\"x\"",
 								},
 								&choiceExpr{
-									pos: position{line: 252, col: 13, offset: 7554},
+									pos: position{line: 323, col: 13, offset: 10212},
 									alternatives: []any{
 										&ruleRefExpr{
-											pos:  position{line: 252, col: 13, offset: 7554},
-											name: "SourceChar",
+											pos:    position{line: 323, col: 13, offset: 10212},
+											offset: 27,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 252, col: 26, offset: 7567},
-											name: "EOL",
+											pos:    position{line: 323, col: 26, offset: 10225},
+											offset: 71,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 252, col: 32, offset: 7573},
-											name: "EOF",
+											pos:    position{line: 323, col: 32, offset: 10231},
+											offset: 73,
 										},
 									},
 								},
@@ -1721,83 +2206,83 @@ var g = &grammar{
 		},
 		{
 			name: "LongUnicodeEscape",
-			pos:  position{line: 255, col: 1, offset: 7640},
+			pos:  position{line: 326, col: 1, offset: 10298},
 			expr: &choiceExpr{
-				pos: position{line: 256, col: 5, offset: 7666},
+				pos: position{line: 327, col: 5, offset: 10324},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 256, col: 5, offset: 7666},
+						pos: position{line: 327, col: 5, offset: 10324},
 						run: (*parser).callonLongUnicodeEscape2,
 						expr: &seqExpr{
-							pos: position{line: 256, col: 5, offset: 7666},
+							pos: position{line: 327, col: 5, offset: 10324},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 256, col: 5, offset: 7666},
+									pos:        position{line: 327, col: 5, offset: 10324},
 									val:        "U",
 									ignoreCase: false,
 									want:       "\"U\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 256, col: 9, offset: 7670},
-									name: "HexDigit",
+									pos:    position{line: 327, col: 9, offset: 10328},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 256, col: 18, offset: 7679},
-									name: "HexDigit",
+									pos:    position{line: 327, col: 18, offset: 10337},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 256, col: 27, offset: 7688},
-									name: "HexDigit",
+									pos:    position{line: 327, col: 27, offset: 10346},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 256, col: 36, offset: 7697},
-									name: "HexDigit",
+									pos:    position{line: 327, col: 36, offset: 10355},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 256, col: 45, offset: 7706},
-									name: "HexDigit",
+									pos:    position{line: 327, col: 45, offset: 10364},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 256, col: 54, offset: 7715},
-									name: "HexDigit",
+									pos:    position{line: 327, col: 54, offset: 10373},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 256, col: 63, offset: 7724},
-									name: "HexDigit",
+									pos:    position{line: 327, col: 63, offset: 10382},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 256, col: 72, offset: 7733},
-									name: "HexDigit",
+									pos:    position{line: 327, col: 72, offset: 10391},
+									offset: 51,
 								},
 							},
 						},
 					},
 					&actionExpr{
-						pos: position{line: 259, col: 7, offset: 7835},
+						pos: position{line: 330, col: 7, offset: 10493},
 						run: (*parser).callonLongUnicodeEscape13,
 						expr: &seqExpr{
-							pos: position{line: 259, col: 7, offset: 7835},
+							pos: position{line: 330, col: 7, offset: 10493},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 259, col: 7, offset: 7835},
+									pos:        position{line: 330, col: 7, offset: 10493},
 									val:        "U",
 									ignoreCase: false,
 									want:       "\"U\"",
 								},
 								&choiceExpr{
-									pos: position{line: 259, col: 13, offset: 7841},
+									pos: position{line: 330, col: 13, offset: 10499},
 									alternatives: []any{
 										&ruleRefExpr{
-											pos:  position{line: 259, col: 13, offset: 7841},
-											name: "SourceChar",
+											pos:    position{line: 330, col: 13, offset: 10499},
+											offset: 27,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 259, col: 26, offset: 7854},
-											name: "EOL",
+											pos:    position{line: 330, col: 26, offset: 10512},
+											offset: 71,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 259, col: 32, offset: 7860},
-											name: "EOF",
+											pos:    position{line: 330, col: 32, offset: 10518},
+											offset: 73,
 										},
 									},
 								},
@@ -1809,67 +2294,67 @@ var g = &grammar{
 		},
 		{
 			name: "ShortUnicodeEscape",
-			pos:  position{line: 262, col: 1, offset: 7923},
+			pos:  position{line: 333, col: 1, offset: 10581},
 			expr: &choiceExpr{
-				pos: position{line: 263, col: 5, offset: 7950},
+				pos: position{line: 334, col: 5, offset: 10608},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 263, col: 5, offset: 7950},
+						pos: position{line: 334, col: 5, offset: 10608},
 						run: (*parser).callonShortUnicodeEscape2,
 						expr: &seqExpr{
-							pos: position{line: 263, col: 5, offset: 7950},
+							pos: position{line: 334, col: 5, offset: 10608},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 263, col: 5, offset: 7950},
+									pos:        position{line: 334, col: 5, offset: 10608},
 									val:        "u",
 									ignoreCase: false,
 									want:       "\"u\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 263, col: 9, offset: 7954},
-									name: "HexDigit",
+									pos:    position{line: 334, col: 9, offset: 10612},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 263, col: 18, offset: 7963},
-									name: "HexDigit",
+									pos:    position{line: 334, col: 18, offset: 10621},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 263, col: 27, offset: 7972},
-									name: "HexDigit",
+									pos:    position{line: 334, col: 27, offset: 10630},
+									offset: 51,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 263, col: 36, offset: 7981},
-									name: "HexDigit",
+									pos:    position{line: 334, col: 36, offset: 10639},
+									offset: 51,
 								},
 							},
 						},
 					},
 					&actionExpr{
-						pos: position{line: 266, col: 7, offset: 8083},
+						pos: position{line: 337, col: 7, offset: 10741},
 						run: (*parser).callonShortUnicodeEscape9,
 						expr: &seqExpr{
-							pos: position{line: 266, col: 7, offset: 8083},
+							pos: position{line: 337, col: 7, offset: 10741},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 266, col: 7, offset: 8083},
+									pos:        position{line: 337, col: 7, offset: 10741},
 									val:        "u",
 									ignoreCase: false,
 									want:       "\"u\"",
 								},
 								&choiceExpr{
-									pos: position{line: 266, col: 13, offset: 8089},
+									pos: position{line: 337, col: 13, offset: 10747},
 									alternatives: []any{
 										&ruleRefExpr{
-											pos:  position{line: 266, col: 13, offset: 8089},
-											name: "SourceChar",
+											pos:    position{line: 337, col: 13, offset: 10747},
+											offset: 27,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 266, col: 26, offset: 8102},
-											name: "EOL",
+											pos:    position{line: 337, col: 26, offset: 10760},
+											offset: 71,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 266, col: 32, offset: 8108},
-											name: "EOF",
+											pos:    position{line: 337, col: 32, offset: 10766},
+											offset: 73,
 										},
 									},
 								},
@@ -1881,9 +2366,9 @@ var g = &grammar{
 		},
 		{
 			name: "OctalDigit",
-			pos:  position{line: 270, col: 1, offset: 8172},
+			pos:  position{line: 341, col: 1, offset: 10830},
 			expr: &charClassMatcher{
-				pos:        position{line: 270, col: 14, offset: 8187},
+				pos:        position{line: 341, col: 14, offset: 10845},
 				val:        "[0-7]",
 				ranges:     []rune{'0', '7'},
 				ignoreCase: false,
@@ -1892,9 +2377,9 @@ var g = &grammar{
 		},
 		{
 			name: "DecimalDigit",
-			pos:  position{line: 271, col: 1, offset: 8193},
+			pos:  position{line: 342, col: 1, offset: 10851},
 			expr: &charClassMatcher{
-				pos:        position{line: 271, col: 16, offset: 8210},
+				pos:        position{line: 342, col: 16, offset: 10868},
 				val:        "[0-9]",
 				ranges:     []rune{'0', '9'},
 				ignoreCase: false,
@@ -1903,9 +2388,9 @@ var g = &grammar{
 		},
 		{
 			name: "HexDigit",
-			pos:  position{line: 272, col: 1, offset: 8216},
+			pos:  position{line: 343, col: 1, offset: 10874},
 			expr: &charClassMatcher{
-				pos:        position{line: 272, col: 12, offset: 8229},
+				pos:        position{line: 343, col: 12, offset: 10887},
 				val:        "[0-9a-f]i",
 				ranges:     []rune{'0', '9', 'a', 'f'},
 				ignoreCase: true,
@@ -1914,47 +2399,47 @@ var g = &grammar{
 		},
 		{
 			name: "CharClassMatcher",
-			pos:  position{line: 274, col: 1, offset: 8240},
+			pos:  position{line: 345, col: 1, offset: 10898},
 			expr: &choiceExpr{
-				pos: position{line: 274, col: 20, offset: 8261},
+				pos: position{line: 345, col: 20, offset: 10919},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 274, col: 20, offset: 8261},
+						pos: position{line: 345, col: 20, offset: 10919},
 						run: (*parser).callonCharClassMatcher2,
 						expr: &seqExpr{
-							pos: position{line: 274, col: 20, offset: 8261},
+							pos: position{line: 345, col: 20, offset: 10919},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 274, col: 20, offset: 8261},
+									pos:        position{line: 345, col: 20, offset: 10919},
 									val:        "[",
 									ignoreCase: false,
 									want:       "\"[\"",
 								},
 								&zeroOrMoreExpr{
-									pos: position{line: 274, col: 24, offset: 8265},
+									pos: position{line: 345, col: 24, offset: 10923},
 									expr: &choiceExpr{
-										pos: position{line: 274, col: 26, offset: 8267},
+										pos: position{line: 345, col: 26, offset: 10925},
 										alternatives: []any{
 											&ruleRefExpr{
-												pos:  position{line: 274, col: 26, offset: 8267},
-												name: "ClassCharRange",
+												pos:    position{line: 345, col: 26, offset: 10925},
+												offset: 53,
 											},
 											&ruleRefExpr{
-												pos:  position{line: 274, col: 43, offset: 8284},
-												name: "ClassChar",
+												pos:    position{line: 345, col: 43, offset: 10942},
+												offset: 54,
 											},
 											&seqExpr{
-												pos: position{line: 274, col: 55, offset: 8296},
+												pos: position{line: 345, col: 55, offset: 10954},
 												exprs: []any{
 													&litMatcher{
-														pos:        position{line: 274, col: 55, offset: 8296},
+														pos:        position{line: 345, col: 55, offset: 10954},
 														val:        "\\",
 														ignoreCase: false,
 														want:       "\"\\\\\"",
 													},
 													&ruleRefExpr{
-														pos:  position{line: 274, col: 60, offset: 8301},
-														name: "UnicodeClassEscape",
+														pos:    position{line: 345, col: 60, offset: 10959},
+														offset: 56,
 													},
 												},
 											},
@@ -1962,15 +2447,15 @@ var g = &grammar{
 									},
 								},
 								&litMatcher{
-									pos:        position{line: 274, col: 82, offset: 8323},
+									pos:        position{line: 345, col: 82, offset: 10981},
 									val:        "]",
 									ignoreCase: false,
 									want:       "\"]\"",
 								},
 								&zeroOrOneExpr{
-									pos: position{line: 274, col: 86, offset: 8327},
+									pos: position{line: 345, col: 86, offset: 10985},
 									expr: &litMatcher{
-										pos:        position{line: 274, col: 86, offset: 8327},
+										pos:        position{line: 345, col: 86, offset: 10985},
 										val:        "i",
 										ignoreCase: false,
 										want:       "\"i\"",
@@ -1980,46 +2465,46 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 278, col: 5, offset: 8434},
+						pos: position{line: 349, col: 5, offset: 11092},
 						run: (*parser).callonCharClassMatcher15,
 						expr: &seqExpr{
-							pos: position{line: 278, col: 5, offset: 8434},
+							pos: position{line: 349, col: 5, offset: 11092},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 278, col: 5, offset: 8434},
+									pos:        position{line: 349, col: 5, offset: 11092},
 									val:        "[",
 									ignoreCase: false,
 									want:       "\"[\"",
 								},
 								&zeroOrMoreExpr{
-									pos: position{line: 278, col: 9, offset: 8438},
+									pos: position{line: 349, col: 9, offset: 11096},
 									expr: &seqExpr{
-										pos: position{line: 278, col: 11, offset: 8440},
+										pos: position{line: 349, col: 11, offset: 11098},
 										exprs: []any{
 											&notExpr{
-												pos: position{line: 278, col: 11, offset: 8440},
+												pos: position{line: 349, col: 11, offset: 11098},
 												expr: &ruleRefExpr{
-													pos:  position{line: 278, col: 14, offset: 8443},
-													name: "EOL",
+													pos:    position{line: 349, col: 14, offset: 11101},
+													offset: 71,
 												},
 											},
 											&ruleRefExpr{
-												pos:  position{line: 278, col: 20, offset: 8449},
-												name: "SourceChar",
+												pos:    position{line: 349, col: 20, offset: 11107},
+												offset: 27,
 											},
 										},
 									},
 								},
 								&choiceExpr{
-									pos: position{line: 278, col: 36, offset: 8465},
+									pos: position{line: 349, col: 36, offset: 11123},
 									alternatives: []any{
 										&ruleRefExpr{
-											pos:  position{line: 278, col: 36, offset: 8465},
-											name: "EOL",
+											pos:    position{line: 349, col: 36, offset: 11123},
+											offset: 71,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 278, col: 42, offset: 8471},
-											name: "EOF",
+											pos:    position{line: 349, col: 42, offset: 11129},
+											offset: 73,
 										},
 									},
 								},
@@ -2031,78 +2516,78 @@ var g = &grammar{
 		},
 		{
 			name: "ClassCharRange",
-			pos:  position{line: 282, col: 1, offset: 8581},
+			pos:  position{line: 353, col: 1, offset: 11239},
 			expr: &seqExpr{
-				pos: position{line: 282, col: 18, offset: 8600},
+				pos: position{line: 353, col: 18, offset: 11258},
 				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 282, col: 18, offset: 8600},
-						name: "ClassChar",
+						pos:    position{line: 353, col: 18, offset: 11258},
+						offset: 54,
 					},
 					&litMatcher{
-						pos:        position{line: 282, col: 28, offset: 8610},
+						pos:        position{line: 353, col: 28, offset: 11268},
 						val:        "-",
 						ignoreCase: false,
 						want:       "\"-\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 282, col: 32, offset: 8614},
-						name: "ClassChar",
+						pos:    position{line: 353, col: 32, offset: 11272},
+						offset: 54,
 					},
 				},
 			},
 		},
 		{
 			name: "ClassChar",
-			pos:  position{line: 283, col: 1, offset: 8624},
+			pos:  position{line: 354, col: 1, offset: 11282},
 			expr: &choiceExpr{
-				pos: position{line: 283, col: 13, offset: 8638},
+				pos: position{line: 354, col: 13, offset: 11296},
 				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 283, col: 13, offset: 8638},
+						pos: position{line: 354, col: 13, offset: 11296},
 						exprs: []any{
 							&notExpr{
-								pos: position{line: 283, col: 13, offset: 8638},
+								pos: position{line: 354, col: 13, offset: 11296},
 								expr: &choiceExpr{
-									pos: position{line: 283, col: 16, offset: 8641},
+									pos: position{line: 354, col: 16, offset: 11299},
 									alternatives: []any{
 										&litMatcher{
-											pos:        position{line: 283, col: 16, offset: 8641},
+											pos:        position{line: 354, col: 16, offset: 11299},
 											val:        "]",
 											ignoreCase: false,
 											want:       "\"]\"",
 										},
 										&litMatcher{
-											pos:        position{line: 283, col: 22, offset: 8647},
+											pos:        position{line: 354, col: 22, offset: 11305},
 											val:        "\\",
 											ignoreCase: false,
 											want:       "\"\\\\\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 283, col: 29, offset: 8654},
-											name: "EOL",
+											pos:    position{line: 354, col: 29, offset: 11312},
+											offset: 71,
 										},
 									},
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 283, col: 35, offset: 8660},
-								name: "SourceChar",
+								pos:    position{line: 354, col: 35, offset: 11318},
+								offset: 27,
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 283, col: 48, offset: 8673},
+						pos: position{line: 354, col: 48, offset: 11331},
 						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 283, col: 48, offset: 8673},
+								pos:        position{line: 354, col: 48, offset: 11331},
 								val:        "\\",
 								ignoreCase: false,
 								want:       "\"\\\\\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 283, col: 53, offset: 8678},
-								name: "CharClassEscape",
+								pos:    position{line: 354, col: 53, offset: 11336},
+								offset: 55,
 							},
 						},
 					},
@@ -2111,54 +2596,54 @@ var g = &grammar{
 		},
 		{
 			name: "CharClassEscape",
-			pos:  position{line: 284, col: 1, offset: 8694},
+			pos:  position{line: 355, col: 1, offset: 11352},
 			expr: &choiceExpr{
-				pos: position{line: 284, col: 19, offset: 8714},
+				pos: position{line: 355, col: 19, offset: 11372},
 				alternatives: []any{
 					&choiceExpr{
-						pos: position{line: 284, col: 21, offset: 8716},
+						pos: position{line: 355, col: 21, offset: 11374},
 						alternatives: []any{
 							&litMatcher{
-								pos:        position{line: 284, col: 21, offset: 8716},
+								pos:        position{line: 355, col: 21, offset: 11374},
 								val:        "]",
 								ignoreCase: false,
 								want:       "\"]\"",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 284, col: 27, offset: 8722},
-								name: "CommonEscapeSequence",
+								pos:    position{line: 355, col: 27, offset: 11380},
+								offset: 43,
 							},
 						},
 					},
 					&actionExpr{
-						pos: position{line: 285, col: 7, offset: 8751},
+						pos: position{line: 356, col: 7, offset: 11409},
 						run: (*parser).callonCharClassEscape5,
 						expr: &seqExpr{
-							pos: position{line: 285, col: 7, offset: 8751},
+							pos: position{line: 356, col: 7, offset: 11409},
 							exprs: []any{
 								&notExpr{
-									pos: position{line: 285, col: 7, offset: 8751},
+									pos: position{line: 356, col: 7, offset: 11409},
 									expr: &litMatcher{
-										pos:        position{line: 285, col: 8, offset: 8752},
+										pos:        position{line: 356, col: 8, offset: 11410},
 										val:        "p",
 										ignoreCase: false,
 										want:       "\"p\"",
 									},
 								},
 								&choiceExpr{
-									pos: position{line: 285, col: 14, offset: 8758},
+									pos: position{line: 356, col: 14, offset: 11416},
 									alternatives: []any{
 										&ruleRefExpr{
-											pos:  position{line: 285, col: 14, offset: 8758},
-											name: "SourceChar",
+											pos:    position{line: 356, col: 14, offset: 11416},
+											offset: 27,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 285, col: 27, offset: 8771},
-											name: "EOL",
+											pos:    position{line: 356, col: 27, offset: 11429},
+											offset: 71,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 285, col: 33, offset: 8777},
-											name: "EOF",
+											pos:    position{line: 356, col: 33, offset: 11435},
+											offset: 73,
 										},
 									},
 								},
@@ -2170,52 +2655,52 @@ var g = &grammar{
 		},
 		{
 			name: "UnicodeClassEscape",
-			pos:  position{line: 289, col: 1, offset: 8843},
+			pos:  position{line: 360, col: 1, offset: 11501},
 			expr: &seqExpr{
-				pos: position{line: 289, col: 22, offset: 8866},
+				pos: position{line: 360, col: 22, offset: 11524},
 				exprs: []any{
 					&litMatcher{
-						pos:        position{line: 289, col: 22, offset: 8866},
+						pos:        position{line: 360, col: 22, offset: 11524},
 						val:        "p",
 						ignoreCase: false,
 						want:       "\"p\"",
 					},
 					&choiceExpr{
-						pos: position{line: 290, col: 7, offset: 8878},
+						pos: position{line: 361, col: 7, offset: 11536},
 						alternatives: []any{
 							&ruleRefExpr{
-								pos:  position{line: 290, col: 7, offset: 8878},
-								name: "SingleCharUnicodeClass",
+								pos:    position{line: 361, col: 7, offset: 11536},
+								offset: 57,
 							},
 							&actionExpr{
-								pos: position{line: 291, col: 7, offset: 8907},
+								pos: position{line: 362, col: 7, offset: 11565},
 								run: (*parser).callonUnicodeClassEscape5,
 								expr: &seqExpr{
-									pos: position{line: 291, col: 7, offset: 8907},
+									pos: position{line: 362, col: 7, offset: 11565},
 									exprs: []any{
 										&notExpr{
-											pos: position{line: 291, col: 7, offset: 8907},
+											pos: position{line: 362, col: 7, offset: 11565},
 											expr: &litMatcher{
-												pos:        position{line: 291, col: 8, offset: 8908},
+												pos:        position{line: 362, col: 8, offset: 11566},
 												val:        "{",
 												ignoreCase: false,
 												want:       "\"{\"",
 											},
 										},
 										&choiceExpr{
-											pos: position{line: 291, col: 14, offset: 8914},
+											pos: position{line: 362, col: 14, offset: 11572},
 											alternatives: []any{
 												&ruleRefExpr{
-													pos:  position{line: 291, col: 14, offset: 8914},
-													name: "SourceChar",
+													pos:    position{line: 362, col: 14, offset: 11572},
+													offset: 27,
 												},
 												&ruleRefExpr{
-													pos:  position{line: 291, col: 27, offset: 8927},
-													name: "EOL",
+													pos:    position{line: 362, col: 27, offset: 11585},
+													offset: 71,
 												},
 												&ruleRefExpr{
-													pos:  position{line: 291, col: 33, offset: 8933},
-													name: "EOF",
+													pos:    position{line: 362, col: 33, offset: 11591},
+													offset: 73,
 												},
 											},
 										},
@@ -2223,27 +2708,27 @@ var g = &grammar{
 								},
 							},
 							&actionExpr{
-								pos: position{line: 292, col: 7, offset: 9004},
+								pos: position{line: 363, col: 7, offset: 11662},
 								run: (*parser).callonUnicodeClassEscape13,
 								expr: &seqExpr{
-									pos: position{line: 292, col: 7, offset: 9004},
+									pos: position{line: 363, col: 7, offset: 11662},
 									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 292, col: 7, offset: 9004},
+											pos:        position{line: 363, col: 7, offset: 11662},
 											val:        "{",
 											ignoreCase: false,
 											want:       "\"{\"",
 										},
 										&labeledExpr{
-											pos:   position{line: 292, col: 11, offset: 9008},
+											pos:   position{line: 363, col: 11, offset: 11666},
 											label: "ident",
 											expr: &ruleRefExpr{
-												pos:  position{line: 292, col: 17, offset: 9014},
-												name: "IdentifierName",
+												pos:    position{line: 363, col: 17, offset: 11672},
+												offset: 33,
 											},
 										},
 										&litMatcher{
-											pos:        position{line: 292, col: 32, offset: 9029},
+											pos:        position{line: 363, col: 32, offset: 11687},
 											val:        "}",
 											ignoreCase: false,
 											want:       "\"}\"",
@@ -2252,37 +2737,37 @@ var g = &grammar{
 								},
 							},
 							&actionExpr{
-								pos: position{line: 298, col: 7, offset: 9206},
+								pos: position{line: 369, col: 7, offset: 11864},
 								run: (*parser).callonUnicodeClassEscape19,
 								expr: &seqExpr{
-									pos: position{line: 298, col: 7, offset: 9206},
+									pos: position{line: 369, col: 7, offset: 11864},
 									exprs: []any{
 										&litMatcher{
-											pos:        position{line: 298, col: 7, offset: 9206},
+											pos:        position{line: 369, col: 7, offset: 11864},
 											val:        "{",
 											ignoreCase: false,
 											want:       "\"{\"",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 298, col: 11, offset: 9210},
-											name: "IdentifierName",
+											pos:    position{line: 369, col: 11, offset: 11868},
+											offset: 33,
 										},
 										&choiceExpr{
-											pos: position{line: 298, col: 28, offset: 9227},
+											pos: position{line: 369, col: 28, offset: 11885},
 											alternatives: []any{
 												&litMatcher{
-													pos:        position{line: 298, col: 28, offset: 9227},
+													pos:        position{line: 369, col: 28, offset: 11885},
 													val:        "]",
 													ignoreCase: false,
 													want:       "\"]\"",
 												},
 												&ruleRefExpr{
-													pos:  position{line: 298, col: 34, offset: 9233},
-													name: "EOL",
+													pos:    position{line: 369, col: 34, offset: 11891},
+													offset: 71,
 												},
 												&ruleRefExpr{
-													pos:  position{line: 298, col: 40, offset: 9239},
-													name: "EOF",
+													pos:    position{line: 369, col: 40, offset: 11897},
+													offset: 73,
 												},
 											},
 										},
@@ -2296,9 +2781,9 @@ var g = &grammar{
 		},
 		{
 			name: "SingleCharUnicodeClass",
-			pos:  position{line: 302, col: 1, offset: 9322},
+			pos:  position{line: 373, col: 1, offset: 11980},
 			expr: &charClassMatcher{
-				pos:        position{line: 302, col: 26, offset: 9349},
+				pos:        position{line: 373, col: 26, offset: 12007},
 				val:        "[LMNCPZS]",
 				chars:      []rune{'L', 'M', 'N', 'C', 'P', 'Z', 'S'},
 				ignoreCase: false,
@@ -2307,52 +2792,250 @@ var g = &grammar{
 		},
 		{
 			name: "AnyMatcher",
-			pos:  position{line: 304, col: 1, offset: 9360},
+			pos:  position{line: 375, col: 1, offset: 12018},
 			expr: &actionExpr{
-				pos: position{line: 304, col: 14, offset: 9375},
+				pos: position{line: 375, col: 14, offset: 12033},
 				run: (*parser).callonAnyMatcher1,
 				expr: &litMatcher{
-					pos:        position{line: 304, col: 14, offset: 9375},
+					pos:        position{line: 375, col: 14, offset: 12033},
 					val:        ".",
 					ignoreCase: false,
 					want:       "\".\"",
 				},
 			},
 		},
+		{
+			name: "LineStartExpr",
+			pos:  position{line: 380, col: 1, offset: 12108},
+			expr: &actionExpr{
+				pos: position{line: 380, col: 17, offset: 12126},
+				run: (*parser).callonLineStartExpr1,
+				expr: &litMatcher{
+					pos:        position{line: 380, col: 17, offset: 12126},
+					val:        "^",
+					ignoreCase: false,
+					want:       "\"^\"",
+				},
+			},
+		},
+		{
+			name: "AssertionExpr",
+			pos:  position{line: 384, col: 1, offset: 12184},
+			expr: &actionExpr{
+				pos: position{line: 384, col: 17, offset: 12202},
+				run: (*parser).callonAssertionExpr1,
+				expr: &choiceExpr{
+					pos: position{line: 384, col: 19, offset: 12204},
+					alternatives: []any{
+						&litMatcher{
+							pos:        position{line: 384, col: 19, offset: 12204},
+							val:        "\\b",
+							ignoreCase: false,
+							want:       "\"\\\\b\"",
+						},
+						&litMatcher{
+							pos:        position{line: 384, col: 27, offset: 12212},
+							val:        "$",
+							ignoreCase: false,
+							want:       "\"$\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "BalancedExpr",
+			pos:  position{line: 388, col: 1, offset: 12288},
+			expr: &actionExpr{
+				pos: position{line: 388, col: 16, offset: 12305},
+				run: (*parser).callonBalancedExpr1,
+				expr: &seqExpr{
+					pos: position{line: 388, col: 16, offset: 12305},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 388, col: 16, offset: 12305},
+							val:        "<",
+							ignoreCase: false,
+							want:       "\"<\"",
+						},
+						&ruleRefExpr{
+							pos:    position{line: 388, col: 20, offset: 12309},
+							offset: 68,
+						},
+						&labeledExpr{
+							pos:   position{line: 388, col: 23, offset: 12312},
+							label: "openLit",
+							expr: &ruleRefExpr{
+								pos:    position{line: 388, col: 31, offset: 12320},
+								offset: 36,
+							},
+						},
+						&ruleRefExpr{
+							pos:    position{line: 388, col: 42, offset: 12331},
+							offset: 68,
+						},
+						&labeledExpr{
+							pos:   position{line: 388, col: 45, offset: 12334},
+							label: "closeLit",
+							expr: &ruleRefExpr{
+								pos:    position{line: 388, col: 54, offset: 12343},
+								offset: 36,
+							},
+						},
+						&ruleRefExpr{
+							pos:    position{line: 388, col: 65, offset: 12354},
+							offset: 68,
+						},
+						&litMatcher{
+							pos:        position{line: 388, col: 68, offset: 12357},
+							val:        ">",
+							ignoreCase: false,
+							want:       "\">\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "CaseInsensitiveExpr",
+			pos:  position{line: 395, col: 1, offset: 12505},
+			expr: &actionExpr{
+				pos: position{line: 395, col: 23, offset: 12529},
+				run: (*parser).callonCaseInsensitiveExpr1,
+				expr: &seqExpr{
+					pos: position{line: 395, col: 23, offset: 12529},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 395, col: 23, offset: 12529},
+							val:        "(?i:",
+							ignoreCase: false,
+							want:       "\"(?i:\"",
+						},
+						&ruleRefExpr{
+							pos:    position{line: 395, col: 30, offset: 12536},
+							offset: 68,
+						},
+						&labeledExpr{
+							pos:   position{line: 395, col: 33, offset: 12539},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:    position{line: 395, col: 38, offset: 12544},
+								offset: 4,
+							},
+						},
+						&ruleRefExpr{
+							pos:    position{line: 395, col: 49, offset: 12555},
+							offset: 68,
+						},
+						&litMatcher{
+							pos:        position{line: 395, col: 52, offset: 12558},
+							val:        ")",
+							ignoreCase: false,
+							want:       "\")\"",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "LookbehindExpr",
+			pos:  position{line: 401, col: 1, offset: 12671},
+			expr: &actionExpr{
+				pos: position{line: 401, col: 18, offset: 12690},
+				run: (*parser).callonLookbehindExpr1,
+				expr: &seqExpr{
+					pos: position{line: 401, col: 18, offset: 12690},
+					exprs: []any{
+						&litMatcher{
+							pos:        position{line: 401, col: 18, offset: 12690},
+							val:        "(?<=",
+							ignoreCase: false,
+							want:       "\"(?<=\"",
+						},
+						&ruleRefExpr{
+							pos:    position{line: 401, col: 25, offset: 12697},
+							offset: 68,
+						},
+						&labeledExpr{
+							pos:   position{line: 401, col: 28, offset: 12700},
+							label: "expr",
+							expr: &ruleRefExpr{
+								pos:    position{line: 401, col: 33, offset: 12705},
+								offset: 4,
+							},
+						},
+						&ruleRefExpr{
+							pos:    position{line: 401, col: 44, offset: 12716},
+							offset: 68,
+						},
+						&litMatcher{
+							pos:        position{line: 401, col: 47, offset: 12719},
+							val:        ")",
+							ignoreCase: false,
+							want:       "\")\"",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "ThrowExpr",
-			pos:  position{line: 309, col: 1, offset: 9450},
+			pos:  position{line: 407, col: 1, offset: 12827},
 			expr: &choiceExpr{
-				pos: position{line: 309, col: 13, offset: 9464},
+				pos: position{line: 407, col: 13, offset: 12841},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 309, col: 13, offset: 9464},
+						pos: position{line: 407, col: 13, offset: 12841},
 						run: (*parser).callonThrowExpr2,
 						expr: &seqExpr{
-							pos: position{line: 309, col: 13, offset: 9464},
+							pos: position{line: 407, col: 13, offset: 12841},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 309, col: 13, offset: 9464},
+									pos:        position{line: 407, col: 13, offset: 12841},
 									val:        "%",
 									ignoreCase: false,
 									want:       "\"%\"",
 								},
 								&litMatcher{
-									pos:        position{line: 309, col: 17, offset: 9468},
+									pos:        position{line: 407, col: 17, offset: 12845},
 									val:        "{",
 									ignoreCase: false,
 									want:       "\"{\"",
 								},
 								&labeledExpr{
-									pos:   position{line: 309, col: 21, offset: 9472},
+									pos:   position{line: 407, col: 21, offset: 12849},
 									label: "label",
 									expr: &ruleRefExpr{
-										pos:  position{line: 309, col: 27, offset: 9478},
-										name: "IdentifierName",
+										pos:    position{line: 407, col: 27, offset: 12855},
+										offset: 33,
+									},
+								},
+								&labeledExpr{
+									pos:   position{line: 407, col: 42, offset: 12870},
+									label: "payload",
+									expr: &zeroOrOneExpr{
+										pos: position{line: 407, col: 50, offset: 12878},
+										expr: &seqExpr{
+											pos: position{line: 407, col: 52, offset: 12880},
+											exprs: []any{
+												&ruleRefExpr{
+													pos:    position{line: 407, col: 52, offset: 12880},
+													offset: 68,
+												},
+												&ruleRefExpr{
+													pos:    position{line: 407, col: 55, offset: 12883},
+													offset: 65,
+												},
+												&ruleRefExpr{
+													pos:    position{line: 407, col: 65, offset: 12893},
+													offset: 68,
+												},
+											},
+										},
 									},
 								},
 								&litMatcher{
-									pos:        position{line: 309, col: 42, offset: 9493},
+									pos:        position{line: 407, col: 71, offset: 12899},
 									val:        "}",
 									ignoreCase: false,
 									want:       "\"}\"",
@@ -2361,30 +3044,30 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 313, col: 5, offset: 9601},
-						run: (*parser).callonThrowExpr9,
+						pos: position{line: 414, col: 5, offset: 13093},
+						run: (*parser).callonThrowExpr15,
 						expr: &seqExpr{
-							pos: position{line: 313, col: 5, offset: 9601},
+							pos: position{line: 414, col: 5, offset: 13093},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 313, col: 5, offset: 9601},
+									pos:        position{line: 414, col: 5, offset: 13093},
 									val:        "%",
 									ignoreCase: false,
 									want:       "\"%\"",
 								},
 								&litMatcher{
-									pos:        position{line: 313, col: 9, offset: 9605},
+									pos:        position{line: 414, col: 9, offset: 13097},
 									val:        "{",
 									ignoreCase: false,
 									want:       "\"{\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 313, col: 13, offset: 9609},
-									name: "IdentifierName",
+									pos:    position{line: 414, col: 13, offset: 13101},
+									offset: 33,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 313, col: 28, offset: 9624},
-									name: "EOF",
+									pos:    position{line: 414, col: 28, offset: 13116},
+									offset: 73,
 								},
 							},
 						},
@@ -2394,28 +3077,28 @@ var g = &grammar{
 		},
 		{
 			name: "CodeBlock",
-			pos:  position{line: 317, col: 1, offset: 9695},
+			pos:  position{line: 418, col: 1, offset: 13187},
 			expr: &choiceExpr{
-				pos: position{line: 317, col: 13, offset: 9709},
+				pos: position{line: 418, col: 13, offset: 13201},
 				alternatives: []any{
 					&actionExpr{
-						pos: position{line: 317, col: 13, offset: 9709},
+						pos: position{line: 418, col: 13, offset: 13201},
 						run: (*parser).callonCodeBlock2,
 						expr: &seqExpr{
-							pos: position{line: 317, col: 13, offset: 9709},
+							pos: position{line: 418, col: 13, offset: 13201},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 317, col: 13, offset: 9709},
+									pos:        position{line: 418, col: 13, offset: 13201},
 									val:        "{",
 									ignoreCase: false,
 									want:       "\"{\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 317, col: 17, offset: 9713},
-									name: "Code",
+									pos:    position{line: 418, col: 17, offset: 13205},
+									offset: 66,
 								},
 								&litMatcher{
-									pos:        position{line: 317, col: 22, offset: 9718},
+									pos:        position{line: 418, col: 22, offset: 13210},
 									val:        "}",
 									ignoreCase: false,
 									want:       "\"}\"",
@@ -2424,24 +3107,24 @@ var g = &grammar{
 						},
 					},
 					&actionExpr{
-						pos: position{line: 321, col: 5, offset: 9817},
+						pos: position{line: 422, col: 5, offset: 13309},
 						run: (*parser).callonCodeBlock7,
 						expr: &seqExpr{
-							pos: position{line: 321, col: 5, offset: 9817},
+							pos: position{line: 422, col: 5, offset: 13309},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 321, col: 5, offset: 9817},
+									pos:        position{line: 422, col: 5, offset: 13309},
 									val:        "{",
 									ignoreCase: false,
 									want:       "\"{\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 321, col: 9, offset: 9821},
-									name: "Code",
+									pos:    position{line: 422, col: 9, offset: 13313},
+									offset: 66,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 321, col: 14, offset: 9826},
-									name: "EOF",
+									pos:    position{line: 422, col: 14, offset: 13318},
+									offset: 73,
 								},
 							},
 						},
@@ -2451,32 +3134,32 @@ var g = &grammar{
 		},
 		{
 			name: "Code",
-			pos:  position{line: 325, col: 1, offset: 9891},
+			pos:  position{line: 426, col: 1, offset: 13383},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 325, col: 8, offset: 9900},
+				pos: position{line: 426, col: 8, offset: 13392},
 				expr: &choiceExpr{
-					pos: position{line: 325, col: 10, offset: 9902},
+					pos: position{line: 426, col: 10, offset: 13394},
 					alternatives: []any{
 						&oneOrMoreExpr{
-							pos: position{line: 325, col: 10, offset: 9902},
+							pos: position{line: 426, col: 10, offset: 13394},
 							expr: &choiceExpr{
-								pos: position{line: 325, col: 12, offset: 9904},
+								pos: position{line: 426, col: 12, offset: 13396},
 								alternatives: []any{
 									&ruleRefExpr{
-										pos:  position{line: 325, col: 12, offset: 9904},
-										name: "Comment",
+										pos:    position{line: 426, col: 12, offset: 13396},
+										offset: 28,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 325, col: 22, offset: 9914},
-										name: "CodeStringLiteral",
+										pos:    position{line: 426, col: 22, offset: 13406},
+										offset: 67,
 									},
 									&seqExpr{
-										pos: position{line: 325, col: 42, offset: 9934},
+										pos: position{line: 426, col: 42, offset: 13426},
 										exprs: []any{
 											&notExpr{
-												pos: position{line: 325, col: 42, offset: 9934},
+												pos: position{line: 426, col: 42, offset: 13426},
 												expr: &charClassMatcher{
-													pos:        position{line: 325, col: 43, offset: 9935},
+													pos:        position{line: 426, col: 43, offset: 13427},
 													val:        "[{}]",
 													chars:      []rune{'{', '}'},
 													ignoreCase: false,
@@ -2484,8 +3167,8 @@ var g = &grammar{
 												},
 											},
 											&ruleRefExpr{
-												pos:  position{line: 325, col: 48, offset: 9940},
-												name: "SourceChar",
+												pos:    position{line: 426, col: 48, offset: 13432},
+												offset: 27,
 											},
 										},
 									},
@@ -2493,20 +3176,20 @@ var g = &grammar{
 							},
 						},
 						&seqExpr{
-							pos: position{line: 325, col: 64, offset: 9956},
+							pos: position{line: 426, col: 64, offset: 13448},
 							exprs: []any{
 								&litMatcher{
-									pos:        position{line: 325, col: 64, offset: 9956},
+									pos:        position{line: 426, col: 64, offset: 13448},
 									val:        "{",
 									ignoreCase: false,
 									want:       "\"{\"",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 325, col: 68, offset: 9960},
-									name: "Code",
+									pos:    position{line: 426, col: 68, offset: 13452},
+									offset: 66,
 								},
 								&litMatcher{
-									pos:        position{line: 325, col: 73, offset: 9965},
+									pos:        position{line: 426, col: 73, offset: 13457},
 									val:        "}",
 									ignoreCase: false,
 									want:       "\"}\"",
@@ -2519,38 +3202,38 @@ var g = &grammar{
 		},
 		{
 			name: "CodeStringLiteral",
-			pos:  position{line: 327, col: 1, offset: 9973},
+			pos:  position{line: 428, col: 1, offset: 13465},
 			expr: &choiceExpr{
-				pos: position{line: 327, col: 21, offset: 9995},
+				pos: position{line: 428, col: 21, offset: 13487},
 				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 327, col: 21, offset: 9995},
+						pos: position{line: 428, col: 21, offset: 13487},
 						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 327, col: 21, offset: 9995},
+								pos:        position{line: 428, col: 21, offset: 13487},
 								val:        "\"",
 								ignoreCase: false,
 								want:       "\"\\\"\"",
 							},
 							&zeroOrMoreExpr{
-								pos: position{line: 327, col: 25, offset: 9999},
+								pos: position{line: 428, col: 25, offset: 13491},
 								expr: &choiceExpr{
-									pos: position{line: 327, col: 26, offset: 10000},
+									pos: position{line: 428, col: 26, offset: 13492},
 									alternatives: []any{
 										&litMatcher{
-											pos:        position{line: 327, col: 26, offset: 10000},
+											pos:        position{line: 428, col: 26, offset: 13492},
 											val:        "\\\"",
 											ignoreCase: false,
 											want:       "\"\\\\\\\"\"",
 										},
 										&litMatcher{
-											pos:        position{line: 327, col: 33, offset: 10007},
+											pos:        position{line: 428, col: 33, offset: 13499},
 											val:        "\\\\",
 											ignoreCase: false,
 											want:       "\"\\\\\\\\\"",
 										},
 										&charClassMatcher{
-											pos:        position{line: 327, col: 40, offset: 10014},
+											pos:        position{line: 428, col: 40, offset: 13506},
 											val:        "[^\"\\r\\n]",
 											chars:      []rune{'"', '\r', '\n'},
 											ignoreCase: false,
@@ -2560,7 +3243,7 @@ var g = &grammar{
 								},
 							},
 							&litMatcher{
-								pos:        position{line: 327, col: 51, offset: 10025},
+								pos:        position{line: 428, col: 51, offset: 13517},
 								val:        "\"",
 								ignoreCase: false,
 								want:       "\"\\\"\"",
@@ -2568,18 +3251,18 @@ var g = &grammar{
 						},
 					},
 					&seqExpr{
-						pos: position{line: 328, col: 21, offset: 10051},
+						pos: position{line: 429, col: 21, offset: 13543},
 						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 328, col: 21, offset: 10051},
+								pos:        position{line: 429, col: 21, offset: 13543},
 								val:        "`",
 								ignoreCase: false,
 								want:       "\"`\"",
 							},
 							&zeroOrMoreExpr{
-								pos: position{line: 328, col: 25, offset: 10055},
+								pos: position{line: 429, col: 25, offset: 13547},
 								expr: &charClassMatcher{
-									pos:        position{line: 328, col: 25, offset: 10055},
+									pos:        position{line: 429, col: 25, offset: 13547},
 									val:        "[^`]",
 									chars:      []rune{'`'},
 									ignoreCase: false,
@@ -2587,7 +3270,7 @@ var g = &grammar{
 								},
 							},
 							&litMatcher{
-								pos:        position{line: 328, col: 31, offset: 10061},
+								pos:        position{line: 429, col: 31, offset: 13553},
 								val:        "`",
 								ignoreCase: false,
 								want:       "\"`\"",
@@ -2595,33 +3278,33 @@ var g = &grammar{
 						},
 					},
 					&seqExpr{
-						pos: position{line: 329, col: 21, offset: 10087},
+						pos: position{line: 430, col: 21, offset: 13579},
 						exprs: []any{
 							&litMatcher{
-								pos:        position{line: 329, col: 21, offset: 10087},
+								pos:        position{line: 430, col: 21, offset: 13579},
 								val:        "'",
 								ignoreCase: false,
 								want:       "\"'\"",
 							},
 							&choiceExpr{
-								pos: position{line: 329, col: 27, offset: 10093},
+								pos: position{line: 430, col: 27, offset: 13585},
 								alternatives: []any{
 									&litMatcher{
-										pos:        position{line: 329, col: 27, offset: 10093},
+										pos:        position{line: 430, col: 27, offset: 13585},
 										val:        "\\'",
 										ignoreCase: false,
 										want:       "\"\\\\'\"",
 									},
 									&litMatcher{
-										pos:        position{line: 329, col: 34, offset: 10100},
+										pos:        position{line: 430, col: 34, offset: 13592},
 										val:        "\\\\",
 										ignoreCase: false,
 										want:       "\"\\\\\\\\\"",
 									},
 									&oneOrMoreExpr{
-										pos: position{line: 329, col: 41, offset: 10107},
+										pos: position{line: 430, col: 41, offset: 13599},
 										expr: &charClassMatcher{
-											pos:        position{line: 329, col: 41, offset: 10107},
+											pos:        position{line: 430, col: 41, offset: 13599},
 											val:        "[^']",
 											chars:      []rune{'\''},
 											ignoreCase: false,
@@ -2631,7 +3314,7 @@ var g = &grammar{
 								},
 							},
 							&litMatcher{
-								pos:        position{line: 329, col: 48, offset: 10114},
+								pos:        position{line: 430, col: 48, offset: 13606},
 								val:        "'",
 								ignoreCase: false,
 								want:       "\"'\"",
@@ -2643,23 +3326,23 @@ var g = &grammar{
 		},
 		{
 			name: "__",
-			pos:  position{line: 331, col: 1, offset: 10120},
+			pos:  position{line: 432, col: 1, offset: 13612},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 331, col: 6, offset: 10127},
+				pos: position{line: 432, col: 6, offset: 13619},
 				expr: &choiceExpr{
-					pos: position{line: 331, col: 8, offset: 10129},
+					pos: position{line: 432, col: 8, offset: 13621},
 					alternatives: []any{
 						&ruleRefExpr{
-							pos:  position{line: 331, col: 8, offset: 10129},
-							name: "Whitespace",
+							pos:    position{line: 432, col: 8, offset: 13621},
+							offset: 70,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 331, col: 21, offset: 10142},
-							name: "EOL",
+							pos:    position{line: 432, col: 21, offset: 13634},
+							offset: 71,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 331, col: 27, offset: 10148},
-							name: "Comment",
+							pos:    position{line: 432, col: 27, offset: 13640},
+							offset: 28,
 						},
 					},
 				},
@@ -2667,19 +3350,19 @@ var g = &grammar{
 		},
 		{
 			name: "_",
-			pos:  position{line: 332, col: 1, offset: 10159},
+			pos:  position{line: 433, col: 1, offset: 13651},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 332, col: 5, offset: 10165},
+				pos: position{line: 433, col: 5, offset: 13657},
 				expr: &choiceExpr{
-					pos: position{line: 332, col: 7, offset: 10167},
+					pos: position{line: 433, col: 7, offset: 13659},
 					alternatives: []any{
 						&ruleRefExpr{
-							pos:  position{line: 332, col: 7, offset: 10167},
-							name: "Whitespace",
+							pos:    position{line: 433, col: 7, offset: 13659},
+							offset: 70,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 332, col: 20, offset: 10180},
-							name: "MultiLineCommentNoLineTerminator",
+							pos:    position{line: 433, col: 20, offset: 13672},
+							offset: 30,
 						},
 					},
 				},
@@ -2687,9 +3370,9 @@ var g = &grammar{
 		},
 		{
 			name: "Whitespace",
-			pos:  position{line: 334, col: 1, offset: 10217},
+			pos:  position{line: 435, col: 1, offset: 13709},
 			expr: &charClassMatcher{
-				pos:        position{line: 334, col: 14, offset: 10232},
+				pos:        position{line: 435, col: 14, offset: 13724},
 				val:        "[ \\t\\r]",
 				chars:      []rune{' ', '\t', '\r'},
 				ignoreCase: false,
@@ -2698,9 +3381,9 @@ var g = &grammar{
 		},
 		{
 			name: "EOL",
-			pos:  position{line: 335, col: 1, offset: 10240},
+			pos:  position{line: 436, col: 1, offset: 13732},
 			expr: &litMatcher{
-				pos:        position{line: 335, col: 7, offset: 10248},
+				pos:        position{line: 436, col: 7, offset: 13740},
 				val:        "\n",
 				ignoreCase: false,
 				want:       "\"\\n\"",
@@ -2708,19 +3391,19 @@ var g = &grammar{
 		},
 		{
 			name: "EOS",
-			pos:  position{line: 336, col: 1, offset: 10253},
+			pos:  position{line: 437, col: 1, offset: 13745},
 			expr: &choiceExpr{
-				pos: position{line: 336, col: 7, offset: 10261},
+				pos: position{line: 437, col: 7, offset: 13753},
 				alternatives: []any{
 					&seqExpr{
-						pos: position{line: 336, col: 7, offset: 10261},
+						pos: position{line: 437, col: 7, offset: 13753},
 						exprs: []any{
 							&ruleRefExpr{
-								pos:  position{line: 336, col: 7, offset: 10261},
-								name: "__",
+								pos:    position{line: 437, col: 7, offset: 13753},
+								offset: 68,
 							},
 							&litMatcher{
-								pos:        position{line: 336, col: 10, offset: 10264},
+								pos:        position{line: 437, col: 10, offset: 13756},
 								val:        ";",
 								ignoreCase: false,
 								want:       "\";\"",
@@ -2728,35 +3411,35 @@ var g = &grammar{
 						},
 					},
 					&seqExpr{
-						pos: position{line: 336, col: 16, offset: 10270},
+						pos: position{line: 437, col: 16, offset: 13762},
 						exprs: []any{
 							&ruleRefExpr{
-								pos:  position{line: 336, col: 16, offset: 10270},
-								name: "_",
+								pos:    position{line: 437, col: 16, offset: 13762},
+								offset: 69,
 							},
 							&zeroOrOneExpr{
-								pos: position{line: 336, col: 18, offset: 10272},
+								pos: position{line: 437, col: 18, offset: 13764},
 								expr: &ruleRefExpr{
-									pos:  position{line: 336, col: 18, offset: 10272},
-									name: "SingleLineComment",
+									pos:    position{line: 437, col: 18, offset: 13764},
+									offset: 31,
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 336, col: 37, offset: 10291},
-								name: "EOL",
+								pos:    position{line: 437, col: 37, offset: 13783},
+								offset: 71,
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 336, col: 43, offset: 10297},
+						pos: position{line: 437, col: 43, offset: 13789},
 						exprs: []any{
 							&ruleRefExpr{
-								pos:  position{line: 336, col: 43, offset: 10297},
-								name: "__",
+								pos:    position{line: 437, col: 43, offset: 13789},
+								offset: 68,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 336, col: 46, offset: 10300},
-								name: "EOF",
+								pos:    position{line: 437, col: 46, offset: 13792},
+								offset: 73,
 							},
 						},
 					},
@@ -2765,11 +3448,11 @@ var g = &grammar{
 		},
 		{
 			name: "EOF",
-			pos:  position{line: 338, col: 1, offset: 10305},
+			pos:  position{line: 439, col: 1, offset: 13797},
 			expr: &notExpr{
-				pos: position{line: 338, col: 7, offset: 10313},
+				pos: position{line: 439, col: 7, offset: 13805},
 				expr: &anyMatcher{
-					line: 338, col: 8, offset: 10314,
+					line: 439, col: 8, offset: 13806,
 				},
 			},
 		},
@@ -2790,6 +3473,7 @@ func (c *current) onGrammar1(initializer, rules any) (any, error) {
 	g.Rules = make([]*ast.Rule, len(rulesSlice))
 	for i, duo := range rulesSlice {
 		g.Rules[i] = duo.([]any)[0].(*ast.Rule)
+		g.Rules[i].Doc = ruleDoc(c.text, g.Rules[i].Pos().Off)
 	}
 
 	return g, nil
@@ -2811,15 +3495,25 @@ func (p *parser) callonInitializer1() (any, error) {
 	return p.cur.onInitializer1(stack["code"])
 }
 
-func (c *current) onRule1(name, display, expr any) (any, error) {
+func (c *current) onRule1(name, params, display, op, expr any) (any, error) {
 	pos := c.astPos()
 
 	rule := ast.NewRule(pos, name.(*ast.Identifier))
+	if params != nil {
+		rule.Params = params.([]*ast.Identifier)
+	}
 	displaySlice := toAnySlice(display)
 	if len(displaySlice) > 0 {
 		rule.DisplayName = displaySlice[0].(*ast.StringLit)
 	}
 	rule.Expr = expr.(ast.Expression)
+	rule.EndLine = pos.Line + bytes.Count(bytes.TrimRight(c.text, " \t\r\n"), []byte("\n"))
+	if string(op.([]byte)) == ":=" {
+		rule.Macro = true
+		if rule.Params != nil || rule.DisplayName != nil {
+			return rule, errors.New("macro cannot have parameters or a display name")
+		}
+	}
 
 	return rule, nil
 }
@@ -2827,7 +3521,21 @@ func (c *current) onRule1(name, display, expr any) (any, error) {
 func (p *parser) callonRule1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onRule1(stack["name"], stack["display"], stack["expr"])
+	return p.cur.onRule1(stack["name"], stack["params"], stack["display"], stack["op"], stack["expr"])
+}
+
+func (c *current) onRuleParams1(first, rest any) (any, error) {
+	params := []*ast.Identifier{first.(*ast.Identifier)}
+	for _, v := range toAnySlice(rest) {
+		params = append(params, v.([]any)[3].(*ast.Identifier))
+	}
+	return params, nil
+}
+
+func (p *parser) callonRuleParams1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleParams1(stack["first"], stack["rest"])
 }
 
 func (c *current) onRecoveryExpr1(expr, recoverExprs any) (any, error) {
@@ -2887,6 +3595,33 @@ func (p *parser) callonChoiceExpr1() (any, error) {
 	return p.cur.onChoiceExpr1(stack["first"], stack["rest"])
 }
 
+func (c *current) onScanLimitExpr2(limit, expr any) (any, error) {
+	sl := ast.NewScanLimitExpr(c.astPos())
+	sl.Limit = limit.(int)
+	sl.Expr = expr.(ast.Expression)
+	return sl, nil
+}
+
+func (p *parser) callonScanLimitExpr2() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onScanLimitExpr2(stack["limit"], stack["expr"])
+}
+
+func (c *current) onScanLimit1() (any, error) {
+	n, err := strconv.Atoi(string(c.text))
+	if err != nil || n == 0 {
+		return 1, errors.New("invalid scan limit")
+	}
+	return n, nil
+}
+
+func (p *parser) callonScanLimit1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onScanLimit1()
+}
+
 func (c *current) onActionExpr1(expr, code any) (any, error) {
 	if code == nil {
 		return expr, nil
@@ -2948,6 +3683,11 @@ func (c *current) onPrefixedExpr2(op, expr any) (any, error) {
 		and.Expr = expr.(ast.Expression)
 		return and, nil
 	}
+	if opStr == "~" {
+		until := ast.NewUntilExpr(pos)
+		until.Expr = expr.(ast.Expression)
+		return until, nil
+	}
 	not := ast.NewNotExpr(pos)
 	not.Expr = expr.(ast.Expression)
 	return not, nil
@@ -3006,26 +3746,77 @@ func (p *parser) callonSuffixedOp1() (any, error) {
 	return p.cur.onSuffixedOp1()
 }
 
-func (c *current) onPrimaryExpr7(expr any) (any, error) {
+func (c *current) onPrimaryExpr13(expr any) (any, error) {
 	return expr, nil
 }
 
-func (p *parser) callonPrimaryExpr7() (any, error) {
+func (p *parser) callonPrimaryExpr13() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onPrimaryExpr7(stack["expr"])
+	return p.cur.onPrimaryExpr13(stack["expr"])
 }
 
-func (c *current) onRuleRefExpr1(name any) (any, error) {
+func (c *current) onRuleCallExpr1(name, first, rest any) (any, error) {
+	call := ast.NewRuleCallExpr(c.astPos())
+	call.Name = name.(*ast.Identifier)
+	call.Args = []ast.Expression{first.(ast.Expression)}
+	for _, v := range toAnySlice(rest) {
+		call.Args = append(call.Args, v.([]any)[3].(ast.Expression))
+	}
+	return call, nil
+}
+
+func (p *parser) callonRuleCallExpr1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleCallExpr1(stack["name"], stack["first"], stack["rest"])
+}
+
+func (c *current) onRuleRefExpr1(name, args any) (any, error) {
 	ref := ast.NewRuleRefExpr(c.astPos())
 	ref.Name = name.(*ast.Identifier)
+	if args != nil {
+		ref.Args = args.([]*ast.RuleArg)
+	}
 	return ref, nil
 }
 
 func (p *parser) callonRuleRefExpr1() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onRuleRefExpr1(stack["name"])
+	return p.cur.onRuleRefExpr1(stack["name"], stack["args"])
+}
+
+func (c *current) onRuleArgs1(first, rest any) (any, error) {
+	args := []*ast.RuleArg{first.(*ast.RuleArg)}
+	for _, v := range toAnySlice(rest) {
+		args = append(args, v.([]any)[3].(*ast.RuleArg))
+	}
+	return args, nil
+}
+
+func (p *parser) callonRuleArgs1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleArgs1(stack["first"], stack["rest"])
+}
+
+func (c *current) onRuleArg1(name, val any) (any, error) {
+	arg := &ast.RuleArg{Name: name.(*ast.Identifier)}
+	switch val := val.(type) {
+	case *ast.CodeBlock:
+		arg.Code = val
+	case *ast.Identifier:
+		// the value of a label is passed as is
+		arg.Code = ast.NewCodeBlock(val.Pos(), "{ return "+val.Val+", nil }")
+	}
+	return arg, nil
+}
+
+func (p *parser) callonRuleArg1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRuleArg1(stack["name"], stack["val"])
 }
 
 func (c *current) onSemanticPredExpr1(op, code any) (any, error) {
@@ -3289,26 +4080,86 @@ func (p *parser) callonAnyMatcher1() (any, error) {
 	return p.cur.onAnyMatcher1()
 }
 
-func (c *current) onThrowExpr2(label any) (any, error) {
+func (c *current) onLineStartExpr1() (any, error) {
+	return ast.NewLineStartExpr(c.astPos()), nil
+}
+
+func (p *parser) callonLineStartExpr1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onLineStartExpr1()
+}
+
+func (c *current) onAssertionExpr1() (any, error) {
+	return ast.NewAssertionExpr(c.astPos(), string(c.text)), nil
+}
+
+func (p *parser) callonAssertionExpr1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onAssertionExpr1()
+}
+
+func (c *current) onBalancedExpr1(openLit, closeLit any) (any, error) {
+	b := ast.NewBalancedExpr(c.astPos())
+	b.Open = openLit.(*ast.LitMatcher)
+	b.Close = closeLit.(*ast.LitMatcher)
+	return b, nil
+}
+
+func (p *parser) callonBalancedExpr1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onBalancedExpr1(stack["openLit"], stack["closeLit"])
+}
+
+func (c *current) onCaseInsensitiveExpr1(expr any) (any, error) {
+	ci := ast.NewCaseInsensitiveExpr(c.astPos())
+	ci.Expr = expr.(ast.Expression)
+	return ci, nil
+}
+
+func (p *parser) callonCaseInsensitiveExpr1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onCaseInsensitiveExpr1(stack["expr"])
+}
+
+func (c *current) onLookbehindExpr1(expr any) (any, error) {
+	lb := ast.NewLookbehindExpr(c.astPos())
+	lb.Expr = expr.(ast.Expression)
+	return lb, nil
+}
+
+func (p *parser) callonLookbehindExpr1() (any, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onLookbehindExpr1(stack["expr"])
+}
+
+func (c *current) onThrowExpr2(label, payload any) (any, error) {
 	t := ast.NewThrowExpr(c.astPos())
 	t.Label = label.(*ast.Identifier).Val
+	if payload != nil {
+		t.Payload = payload.([]any)[1].(*ast.CodeBlock)
+	}
 	return t, nil
 }
 
 func (p *parser) callonThrowExpr2() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onThrowExpr2(stack["label"])
+	return p.cur.onThrowExpr2(stack["label"], stack["payload"])
 }
 
-func (c *current) onThrowExpr9() (any, error) {
+func (c *current) onThrowExpr15() (any, error) {
 	return nil, errors.New("throw expression not terminated")
 }
 
-func (p *parser) callonThrowExpr9() (any, error) {
+func (p *parser) callonThrowExpr15() (any, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onThrowExpr9()
+	return p.cur.onThrowExpr15()
 }
 
 func (c *current) onCodeBlock2() (any, error) {
@@ -3348,6 +4199,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -3367,6 +4222,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -3628,8 +4496,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -3820,8 +4688,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -3834,6 +4702,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -3848,6 +4718,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -3933,6 +4806,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -3953,7 +4831,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -4083,11 +4961,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -4098,7 +4980,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -4120,36 +5002,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -4157,6 +5025,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -4425,8 +5317,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -4455,12 +5347,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -4570,19 +5462,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
@@ -230,6 +230,11 @@ func TestParseCharClassMatcher(t *testing.T) {
 		{in: "b", val: "[^\\p{Latin}]i", ic: true, iv: true, classes: []string{"Latin"}, out: nil},
 		{in: "B", val: "[^\\p{Latin}]i", iv: true, ic: true, classes: []string{"Latin"}, out: nil},
 
+		{in: "漢", val: "[\\p{Han}]", classes: []string{"Han"}, out: []byte("漢")},
+		{in: "b", val: "[\\p{Han}]", classes: []string{"Han"}, out: nil},
+		{in: "漢", val: "[^\\p{Han}]", iv: true, classes: []string{"Han"}, out: nil},
+		{in: "b", val: "[^\\p{Han}]", iv: true, classes: []string{"Han"}, out: []byte("b")},
+
 		{in: "", val: "[^<]", iv: true, chars: []rune{'<'}, out: nil},
 	}
 
@@ -490,7 +495,7 @@ func TestParseSeqExpr(t *testing.T) {
 }
 
 func TestParseRuleRefExpr(t *testing.T) {
-	p := newParser("", []byte(""))
+	p := newParser("", []byte("a"))
 
 	func() {
 		defer func() {
@@ -502,9 +507,24 @@ func TestParseRuleRefExpr(t *testing.T) {
 		p.parseRuleRefExpr(&ruleRefExpr{})
 	}()
 
-	p.parseRuleRefExpr(&ruleRefExpr{name: "a"})
-	if p.errs.err() == nil {
-		t.Fatal("want error, got none")
+	p.rules = []*rule{{name: "a", expr: &litMatcher{val: "a"}}}
+	p.read()
+	func() {
+		defer func() {
+			if e := recover(); e != nil {
+				return
+			}
+			t.Fatal("want panic, got none")
+		}()
+		p.parseRuleRefExpr(&ruleRefExpr{offset: 1})
+	}()
+
+	got, ok := p.parseRuleRefExpr(&ruleRefExpr{offset: 0})
+	if !ok {
+		t.Fatal("want match, got none")
+	}
+	if !reflect.DeepEqual(got, []byte("a")) {
+		t.Fatalf("want %q, got %v", "a", got)
 	}
 }
 
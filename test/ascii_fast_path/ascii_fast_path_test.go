@@ -0,0 +1,104 @@
+package asciifastpath
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	runes "github.com/mna/pigeon/test/ascii_fast_path/runes"
+)
+
+var cases = []string{
+	"",
+	"a = 1",
+	"a = 1\nb = x\n  c_2 = \"s t\"\n",
+	// mixed input, read with the rune path
+	"a = 1\nnom = \"café\"\n\tcafé = thé\n",
+	"日本 = 語\nk = 1",
+	// errors
+	"a = \n",
+	"a = 1\nb ! 2",
+	"a = 1\né ! 2",
+	"a = \"\xff\"",
+}
+
+func TestASCIIFastPath(t *testing.T) {
+	for _, tc := range cases {
+		want, wantErr := runes.Parse("", []byte(tc))
+		got, err := Parse("", []byte(tc))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", tc, want, got)
+		}
+		if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+			t.Errorf("%q: want error %v, got %v", tc, wantErr, err)
+		}
+	}
+}
+
+func TestASCIIFastPathPositions(t *testing.T) {
+	got, err := Parse("", []byte("a = 1\n  é = \"x\"\nb = y"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the positions are byte offsets, é is 2 bytes long
+	want := []any{"1:1:0 a=1", "2:1:6 é=x", "3:1:17 b=y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestASCIIFastPathValues(t *testing.T) {
+	for _, tc := range []string{"", "a=b", "ab=c\n", "café=ab", "x=\xff"} {
+		want, wantErr := runes.Parse("", []byte(tc), runes.Entrypoint("Raw"), runes.AllowInvalidUTF8(true))
+		got, err := Parse("", []byte(tc), Entrypoint("Raw"), AllowInvalidUTF8(true))
+		if err != nil || wantErr != nil {
+			t.Fatalf("%q: want no error, got %v and %v", tc, wantErr, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %q, got %q", tc, want, got)
+		}
+	}
+}
+
+func TestASCIIFastPathAllocs(t *testing.T) {
+	in := benchInput()
+	want := testing.AllocsPerRun(5, func() { _, _ = runes.Parse("", in) })
+	got := testing.AllocsPerRun(5, func() { _, _ = Parse("", in) })
+	// the values of the single characters matched are not allocated
+	if got >= want {
+		t.Errorf("want less than %v allocations, got %v", want, got)
+	}
+}
+
+// the benchmarks compare the parsers generated with and without the ASCII
+// fast path, run with -bench . -count N and compare the results.
+func benchInput() []byte {
+	var sb strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&sb, "key_%d = \"some value %d\"\n", i, i)
+	}
+	return []byte(sb.String())
+}
+
+func BenchmarkParseRunes(b *testing.B) {
+	in := benchInput()
+	b.SetBytes(int64(len(in)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := runes.Parse("", in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseASCII(b *testing.B) {
+	in := benchInput()
+	b.SetBytes(int64(len(in)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse("", in); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
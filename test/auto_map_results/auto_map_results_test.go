@@ -0,0 +1,45 @@
+package automapresults
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAutoMapResults(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+	}{
+		{in: `{}`, want: map[string]any{}},
+		{in: `[]`, want: []any{}},
+		{in: `""`, want: ""},
+		{in: `"a\"b"`, want: `a\"b`},
+		{in: `-1.5e3`, want: "-1.5e3"},
+		{in: `{"a": 1}`, want: map[string]any{"a": "1"}},
+		{in: `[1, "x", true]`, want: []any{"1", "x", "true"}},
+		{
+			in: `{
+				"name": "pigeon",
+				"tags": ["peg", "go"],
+				"nested": {"empty": {}, "list": [[], [null]]},
+				"name": "dup"
+			}`,
+			want: map[string]any{
+				"name":   "dup",
+				"tags":   []any{"peg", "go"},
+				"nested": map[string]any{"empty": map[string]any{}, "list": []any{[]any{}, []any{"null"}}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %#v, got %#v", tc.in, tc.want, got)
+		}
+	}
+}
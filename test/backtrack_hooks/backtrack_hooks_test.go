@@ -0,0 +1,41 @@
+package backtrackhooks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBacktrackHooks(t *testing.T) {
+	cases := []struct {
+		in   string
+		open map[string]int
+		log  string
+	}{
+		{"open a;", map[string]int{"a": 1}, "+a"},
+		// the resource of the first alternative is released
+		{"open a!", map[string]int{"a": 1}, "+a -a +a"},
+		{"open a; open b! open c;", map[string]int{"a": 1, "b": 1, "c": 1}, "+a +b -b +b +c"},
+		{"pair a b?", map[string]int{"a": 1, "b": 1}, "+a +b"},
+		// the parsing fails, all the resources are released, the last
+		// acquired first
+		{"open a; pair b c", map[string]int{}, "+a +b +c -c -b -a"},
+		{"open a! open b", map[string]int{}, "+a -a +a +b -b +b -b -a"},
+	}
+	for _, tc := range cases {
+		for _, memo := range []bool{false, true} {
+			pool := &Pool{Open: make(map[string]int)}
+			_, err := Parse("", []byte(tc.in), GlobalStore("pool", pool), Memoize(memo))
+			if len(tc.open) > 0 && err != nil {
+				t.Errorf("%q: memoize %t: %v", tc.in, memo, err)
+				continue
+			}
+			if !reflect.DeepEqual(pool.Open, tc.open) {
+				t.Errorf("%q: memoize %t: want open %v, got %v", tc.in, memo, tc.open, pool.Open)
+			}
+			if got := strings.Join(pool.Log, " "); got != tc.log {
+				t.Errorf("%q: memoize %t: want log %q, got %q", tc.in, memo, tc.log, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package balanced
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBalanced(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+		err  string
+	}{
+		{in: "f {}", want: []any{"f", ""}},
+		{in: "f { a { b } c }", want: []any{"f", " a { b } c "}},
+		{in: "f {{}{{}}}", want: []any{"f", "{}{{}}"}},
+		{in: "f {\n\t{ x }\n}", want: []any{"f", "\n\t{ x }\n"}},
+		{in: "f {x} /* a /* b */ c */", want: []any{"f", "x", "/* a /* b */ c */"}},
+		{in: "f { a { b } c", err: `1:14 (13): no match found, expected: "}"`},
+		{in: "f { a } }", err: `1:9 (8): no match found, expected: "/*", [ \t\n] or EOF`},
+		{in: "f a", err: `1:3 (2): no match found, expected: "{" or [ \t\n]`},
+		{in: "f {} /* a /* b */", err: `1:18 (17): no match found, expected: "*/"`},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%q: want error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %#v, got %#v", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package basegrammar
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mna/pigeon/test/base_grammar/base"
+)
+
+func TestBaseGrammar(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []int
+		// the base grammar has no hexadecimal numbers
+		baseErr bool
+	}{
+		{"1", []int{1}, false},
+		{" 1, 22 ,333 ", []int{1, 22, 333}, false},
+		{"0x1f", []int{31}, true},
+		{"7, 0xA,0x0", []int{7, 10, 0}, true},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+
+		got, err = base.Parse("", []byte(tc.in))
+		if tc.baseErr {
+			if err == nil {
+				t.Errorf("%q: base grammar: want error, got %v", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: base grammar: %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: base grammar: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	for _, in := range []string{"", "1,", "0x", "1;2"} {
+		if got, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got %v", in, got)
+		}
+	}
+}
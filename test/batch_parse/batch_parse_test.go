@@ -0,0 +1,50 @@
+package batchparse
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseBatch(t *testing.T) {
+	inputs := make([][]byte, 1000)
+	for i := range inputs {
+		inputs[i] = []byte(fmt.Sprintf("%d+%d+1", i, i))
+	}
+
+	results, err := ParseBatch(inputs, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(inputs) {
+		t.Fatalf("want %d results, got %d", len(inputs), len(results))
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("%d: %v", i, r.Err)
+			continue
+		}
+		if want := 2*i + 1; r.Value != want {
+			t.Errorf("%d: want %d, got %v", i, want, r.Value)
+		}
+	}
+}
+
+func TestParseBatchError(t *testing.T) {
+	inputs := [][]byte{[]byte("1+2"), []byte("1+"), []byte("3"), []byte("x")}
+
+	results, err := ParseBatch(inputs, 0)
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if results[1].Err == nil || err.Error() != results[1].Err.Error() {
+		t.Errorf("want error of input 1, got %v", err)
+	}
+	for i, wantErr := range []bool{false, true, false, true} {
+		if got := results[i].Err != nil; got != wantErr {
+			t.Errorf("%d: want error %t, got %t", i, wantErr, got)
+		}
+	}
+	if results[2].Value != 3 {
+		t.Errorf("want 3, got %v", results[2].Value)
+	}
+}
@@ -0,0 +1,43 @@
+package bidiaware
+
+import "testing"
+
+func TestBidiAware(t *testing.T) {
+	cases := []struct {
+		in   string
+		err  string
+		dirs []Direction
+	}{
+		// at a right-to-left letter in a left-to-right line
+		{"name: שלום עולם;", "1:12 (15): no match found, expected: \",\", \";\" or [ \\t\\r\\n]", []Direction{RightToLeft}},
+		// at a left-to-right letter in a right-to-left line
+		{"שם: abc def;", "1:9 (10): no match found, expected: \",\", \";\" or [ \\t\\r\\n]", []Direction{LeftToRight}},
+		// between right-to-left letters in a left-to-right line
+		{"key: א, ב !ג;", "1:11 (12): no match found, expected: \",\", \";\" or [ \\t\\r\\n]", []Direction{RightToLeft}},
+		// after the last letter of a line, the direction of the line
+		{"مفتاح: قيمة ,;", "1:14 (22): no match found, expected: [ \\t\\r\\n], [0-9] or [\\pL]", []Direction{RightToLeft}},
+		{"key: قيمة ,;", "1:12 (15): no match found, expected: [ \\t\\r\\n], [0-9] or [\\pL]", []Direction{LeftToRight}},
+		// the error of a code block, on the second line
+		{"a: b;\nשם: 1234;", "2:5 (12): rule Word: number too large", []Direction{RightToLeft}},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in))
+		if err == nil {
+			t.Errorf("%q: want error, got none", tc.in)
+			continue
+		}
+		if err.Error() != tc.err {
+			t.Errorf("%q: want error %q, got %q", tc.in, tc.err, err)
+		}
+		list := err.(errList)
+		if len(list) != len(tc.dirs) {
+			t.Errorf("%q: want %d errors, got %d: %v", tc.in, len(tc.dirs), len(list), err)
+			continue
+		}
+		for i, e := range list {
+			if got := e.(interface{ Direction() Direction }).Direction(); got != tc.dirs[i] {
+				t.Errorf("%q: %d: want direction %s, got %s", tc.in, i, tc.dirs[i], got)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package boundedstream
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseStream(t *testing.T) {
+	// many short lines, the input is longer than the buffer of the parser
+	var in strings.Builder
+	var want []any
+	for i := 0; i < 50; i++ {
+		if i%2 == 0 {
+			in.WriteString("set:abc\n")
+			want = append(want, "set=abc")
+		} else {
+			in.WriteString("word\n")
+			want = append(want, "word")
+		}
+	}
+
+	for _, memo := range []bool{false, true} {
+		got, err := ParseStream("", iotest.OneByteReader(strings.NewReader(in.String())), Memoize(memo))
+		if err != nil {
+			t.Fatalf("memoize %t: %v", memo, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("memoize %t: want %v, got %v", memo, want, got)
+		}
+		want, err := Parse("", []byte(in.String()), Memoize(memo))
+		if err != nil {
+			t.Fatalf("memoize %t: %v", memo, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("memoize %t: want the result of Parse %v, got %v", memo, want, got)
+		}
+	}
+}
+
+func TestParseStreamWindowExceeded(t *testing.T) {
+	// the command fails at the end of the long word, the word must be
+	// matched again from the start of the line that was evicted.
+	in := "set:abc\n" + strings.Repeat("x", 100) + "\nword\n"
+	for _, rec := range []bool{true, false} {
+		_, err := ParseStream("", strings.NewReader(in), Recover(rec))
+		el, ok := err.(errList)
+		if !ok || len(el) != 1 {
+			t.Fatalf("recover %t: want a single error, got %v", rec, err)
+		}
+		if pe, ok := el[0].(*parserError); !ok || pe.Inner != errStreamWindow {
+			t.Errorf("recover %t: want error %v, got %v", rec, errStreamWindow, el[0])
+		}
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+
+func TestParseStreamReadError(t *testing.T) {
+	_, err := ParseStream("", io.MultiReader(strings.NewReader("word\n"), errReader{}))
+	el, ok := err.(errList)
+	if !ok || len(el) != 1 {
+		t.Fatalf("want a single error, got %v", err)
+	}
+	if pe, ok := el[0].(*parserError); !ok || pe.Inner != io.ErrUnexpectedEOF {
+		t.Errorf("want error %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
@@ -0,0 +1,30 @@
+package builtinassertions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuiltinAssertions(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"cat", []string{"0:cat$"}},
+		{"concat cats", nil},
+		{"a cat, the_cat and cat2", []string{"2:cat"}},
+		{"cat\nbobcat cat\n(cat) cat!", []string{"0:cat$", "11:cat$", "16:cat", "21:cat"}},
+		{"cat-cat_cat", []string{"0:cat"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package casescopes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCaseScopes(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+	}{
+		{"select a from t", []any{"select", []string{"a"}, "t"}},
+		{"SELECT a, b FROM t", []any{"SELECT", []string{"a", "b"}, "t"}},
+		{"sElEcT a FrOm t mode B", []any{"sElEcT", []string{"a"}, "t"}},
+		{"select a from t MODE c", []any{"select", []string{"a"}, "t"}},
+		{"select A from t", nil},
+		{"select a from T", nil},
+		{"select a from t mode d", nil},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if tc.want == nil {
+			if err == nil {
+				t.Errorf("%q: want error, got none", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package choiceindex
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChoiceIndex(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []any
+	}{
+		{"true", []any{true}},
+		{"12", []any{12}},
+		{"abc", []any{"ABC"}},
+		{"false,1a,007,-", []any{false, "1A", 7, -1}},
+		{"truer,2x", []any{"TRUER", "2X"}},
+	}
+	for _, tc := range cases {
+		for _, memo := range []bool{false, true} {
+			got, err := Parse("", []byte(tc.in), Memoize(memo))
+			if err != nil {
+				t.Errorf("%q: memoize %t: %v", tc.in, memo, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%q: memoize %t: want %v, got %v", tc.in, memo, tc.want, got)
+			}
+		}
+	}
+}
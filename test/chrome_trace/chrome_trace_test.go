@@ -0,0 +1,105 @@
+package chrometrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type event struct {
+	Name string         `json:"name"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"`
+	Args map[string]int `json:"args"`
+}
+
+func decodeTrace(t *testing.T, b []byte) []event {
+	t.Helper()
+	var trace struct {
+		TraceEvents []event `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(b, &trace); err != nil {
+		t.Fatalf("invalid trace: %v\n%s", err, b)
+	}
+	return trace.TraceEvents
+}
+
+func TestChromeTrace(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Parse("", []byte("1 + (2 + 3)"), ChromeTrace(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	events := decodeTrace(t, buf.Bytes())
+
+	var (
+		stack    []string
+		maxDepth int
+		begins   = make(map[string]int)
+		last     float64
+	)
+	for i, e := range events {
+		if e.Ts < last {
+			t.Errorf("event %d: timestamp %v before %v", i, e.Ts, last)
+		}
+		last = e.Ts
+		switch e.Ph {
+		case "B":
+			stack = append(stack, e.Name)
+			begins[e.Name]++
+			if len(stack) > maxDepth {
+				maxDepth = len(stack)
+			}
+		case "E":
+			if len(stack) == 0 {
+				t.Fatalf("event %d: end of %s without begin", i, e.Name)
+			}
+			if top := stack[len(stack)-1]; top != e.Name {
+				t.Fatalf("event %d: end of %s, want end of %s", i, e.Name, top)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			t.Fatalf("event %d: unexpected phase %q", i, e.Ph)
+		}
+	}
+	if len(stack) != 0 {
+		t.Errorf("want all rules ended, got %v still open", stack)
+	}
+	if len(events) == 0 || events[0].Name != "Input" || events[len(events)-1].Name != "Input" {
+		t.Errorf("want the trace to begin and end with Input, got %v", events)
+	}
+	// Input > Sum > Term > Sum > Term > Number
+	if maxDepth != 6 {
+		t.Errorf("want max depth 6, got %d", maxDepth)
+	}
+	if begins["Sum"] != 2 {
+		t.Errorf("want Sum entered twice, got %d", begins["Sum"])
+	}
+	if end := events[len(events)-1]; end.Args["offset"] != 11 {
+		t.Errorf("want Input to end at offset 11, got %d", end.Args["offset"])
+	}
+}
+
+func TestChromeTraceError(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Parse("", []byte("1 +"), ChromeTrace(&buf)); err == nil {
+		t.Fatal("want error, got none")
+	}
+	events := decodeTrace(t, buf.Bytes())
+	if len(events) == 0 || len(events)%2 != 0 {
+		t.Errorf("want balanced events, got %d", len(events))
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestChromeTraceWriteError(t *testing.T) {
+	_, err := Parse("", []byte("1"), ChromeTrace(failWriter{}))
+	if err == nil || err.Error() != "write failed" {
+		t.Errorf("want write error, got %v", err)
+	}
+}
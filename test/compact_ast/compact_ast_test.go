@@ -0,0 +1,119 @@
+package compactast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var inputs = []string{
+	"",
+	"  \n",
+	"a=1;",
+	"a = -12 ;\nb=\"x y\";",
+	"// comment\nf( 1 , \"two\",three ) ;\n\t// trailing",
+	"print();  x = y ;\r\n",
+	"hello = \"wörld\" ; g(\"ü\")\n;",
+}
+
+func TestSameTree(t *testing.T) {
+	for _, memo := range []bool{false, true} {
+		for _, in := range inputs {
+			root, err := ParseCST("", []byte(in), Memoize(memo))
+			if err != nil {
+				t.Errorf("%q: %v", in, err)
+				continue
+			}
+			tree, err := ParseCompact("", []byte(in), Memoize(memo))
+			if err != nil {
+				t.Errorf("%q: %v", in, err)
+				continue
+			}
+
+			var want, got strings.Builder
+			dumpCST(&want, root, 0)
+			dumpCompact(&got, tree, tree.Root, 0)
+			if want.String() != got.String() {
+				t.Errorf("%q: memoize %t: want\n%s\ngot\n%s", in, memo, want.String(), got.String())
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, in := range inputs {
+		tree, err := ParseCompact("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		var buf bytes.Buffer
+		writeLeaves(&buf, tree, tree.Root)
+		if got := buf.String(); got != in {
+			t.Errorf("%q: got %q", in, got)
+		}
+		if got := string(tree.Text(tree.Root)); got != in {
+			t.Errorf("%q: root text %q", in, got)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	if _, err := ParseCompact("", []byte("a = ;")); err == nil {
+		t.Error("want error")
+	}
+}
+
+func dumpCST(buf *strings.Builder, n *CSTNode, depth int) {
+	fmt.Fprintf(buf, "%s%s %d %q\n", strings.Repeat("  ", depth), n.Rule, n.Offset, n.Text)
+	for _, c := range n.Children {
+		dumpCST(buf, c, depth+1)
+	}
+}
+
+func dumpCompact(buf *strings.Builder, t *CompactTree, n, depth int) {
+	node := t.Nodes[n]
+	fmt.Fprintf(buf, "%s%s %d %q\n", strings.Repeat("  ", depth), node.Rule, node.Start, t.Text(n))
+	for c := t.FirstChild(n); c >= 0; c = t.NextSibling(c) {
+		dumpCompact(buf, t, c, depth+1)
+	}
+}
+
+func writeLeaves(buf *bytes.Buffer, t *CompactTree, n int) {
+	c := t.FirstChild(n)
+	if c < 0 {
+		buf.Write(t.Text(n))
+		return
+	}
+	for ; c >= 0; c = t.NextSibling(c) {
+		writeLeaves(buf, t, c)
+	}
+}
+
+// benchInput is a large input for the benchmarks.
+var benchInput = func() []byte {
+	var buf bytes.Buffer
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&buf, "// item %d\nx%d = %d ;\nf( %d , \"s\", y ) ;\n", i, i, i, i)
+	}
+	return buf.Bytes()
+}()
+
+func BenchmarkParseCST(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseCST("", benchInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseCompact(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseCompact("", benchInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package completionsupport
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	keywords := []string{`"if"`, `"let"`, `"print"`}
+	cases := []struct {
+		in   string
+		pos  int
+		want []string
+	}{
+		{"", 0, keywords},
+		{"let x = 1; ", 11, keywords},
+		{"if x then ", 10, keywords},
+		{"if x ", 5, []string{`"then"`, `[ \t\n]`}},
+		{"if x then print x;", 5, []string{`"then"`, `[ \t\n]`}},
+		{"let ", 4, []string{"[a-z]"}},
+		{"let x = ", 8, []string{"[0-9]", "[a-z]"}},
+		{"print 1 ", 8, []string{`";"`, `[ \t\n]`}},
+
+		// the parsing fails before pos
+		{"le", 2, nil},
+		{"let 1", 5, nil},
+
+		// pos is not in the input
+		{"let", -1, nil},
+		{"let", 4, nil},
+	}
+	for _, tc := range cases {
+		got := Suggest([]byte(tc.in), tc.pos)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q at %d: want %q, got %q", tc.in, tc.pos, tc.want, got)
+		}
+	}
+}
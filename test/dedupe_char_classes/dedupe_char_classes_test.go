@@ -0,0 +1,34 @@
+package dedupecharclasses
+
+import "testing"
+
+func TestDedupeCharClasses(t *testing.T) {
+	cases := map[string]any{
+		"2024-01-31": "date",
+		"12:30":      "time",
+		"3.14":       "number",
+		"42":         "number",
+		"12:3":       nil,
+		"4.":         nil,
+	}
+	for in, want := range cases {
+		got, err := Parse("", []byte(in))
+		if want == nil {
+			if err == nil {
+				t.Errorf("%q: want error, got none", in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+
+	if sharedCharClass1.val != "[0-9]" {
+		t.Errorf("want shared [0-9] matcher, got %s", sharedCharClass1.val)
+	}
+}
@@ -0,0 +1,78 @@
+package densememo
+
+import (
+	"strings"
+	"testing"
+
+	mapmemo "github.com/mna/pigeon/test/dense_memo/map"
+)
+
+var cases = []string{
+	"1",
+	" 1 + 2 * 3 ",
+	"(1 + 2) * 3",
+	"2 * (3 + (4 * 1)) + 5",
+	"((((1))))",
+	"",
+	"1 +",
+	"(1 + 2",
+	"1 $ 2",
+}
+
+func TestDenseMemo(t *testing.T) {
+	for _, memo := range []bool{false, true} {
+		for _, tc := range cases {
+			want, wantErr := mapmemo.Parse("", []byte(tc), mapmemo.Memoize(memo))
+			got, err := Parse("", []byte(tc), Memoize(memo))
+			if got != want {
+				t.Errorf("%q: want %v, got %v", tc, want, got)
+			}
+			if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+				t.Errorf("%q: want error %v, got %v", tc, wantErr, err)
+			}
+		}
+	}
+}
+
+func TestDenseMemoFallback(t *testing.T) {
+	// the table would have more than denseMemoMaxEntries entries
+	large := []byte(strings.Repeat("1 + ", denseMemoMaxEntries/len(g.rules)/4) + "1")
+	small := []byte(" 1 + 2 * 3 ")
+	for _, in := range [][]byte{small, large} {
+		p := newParser("", in, Memoize(true))
+		got, err := p.parse(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := mapmemo.Parse("", in)
+		if got != want {
+			t.Errorf("%d bytes: want %v, got %v", len(in), want, got)
+		}
+		if dense := len(in) == len(small); (p.denseMemo != nil) != dense {
+			t.Errorf("%d bytes: want dense memo %t, got %t", len(in), dense, p.denseMemo != nil)
+		}
+		if p.denseMemo != nil && len(p.denseMemo[0]) != len(in)+1 {
+			t.Errorf("%d bytes: want %d entries for the first rule, got %d", len(in), len(in)+1, len(p.denseMemo[0]))
+		}
+	}
+}
+
+var benchInput = []byte(strings.Repeat("(1 + (2 * 3)) * ", 100) + "4")
+
+func BenchmarkParseMapMemo(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := mapmemo.Parse("", benchInput, mapmemo.Memoize(true)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseDenseMemo(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse("", benchInput, Memoize(true)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
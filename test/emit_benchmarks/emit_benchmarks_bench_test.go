@@ -0,0 +1,50 @@
+// Code generated by pigeon; DO NOT EDIT.
+
+package emitbenchmarks
+
+import (
+	"os"
+	"testing"
+)
+
+// benchInputs are the inputs of BenchmarkInput, synthesized from the
+// grammar or read from a file.
+var benchInputs = []struct {
+	name string
+	data string
+	file string
+}{
+	{name: "small", data: " -0 * -0 + -0 * -0 "},
+	{name: "medium", data: " -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 "},
+	{name: "large", data: " -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 + -0 * -0 "},
+	{name: "input.txt", file: "testdata/input.txt"},
+}
+
+func BenchmarkInput(b *testing.B) {
+	for _, in := range benchInputs {
+		in := in
+		b.Run(in.name, func(b *testing.B) {
+			data := []byte(in.data)
+			if in.file != "" {
+				var err error
+				if data, err = os.ReadFile(in.file); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if _, err := Parse(in.name, data); err != nil {
+				if in.file == "" {
+					b.Skipf("synthesized input does not match the grammar: %v", err)
+				}
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(in.name, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
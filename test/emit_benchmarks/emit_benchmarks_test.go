@@ -0,0 +1,44 @@
+package emitbenchmarks
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestBenchInputs(t *testing.T) {
+	var sizes []int
+	for _, in := range benchInputs {
+		data := []byte(in.data)
+		if in.file != "" {
+			var err error
+			if data, err = os.ReadFile(in.file); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			sizes = append(sizes, len(data))
+		}
+		if _, err := Parse(in.name, data); err != nil {
+			t.Errorf("%s: %v", in.name, err)
+		}
+	}
+	if len(sizes) != 3 || sizes[0] >= sizes[1] || sizes[1] >= sizes[2] {
+		t.Errorf("want 3 synthesized inputs of increasing sizes, got %v", sizes)
+	}
+}
+
+func TestBenchmarkInput(t *testing.T) {
+	// run each input a few times only
+	prev := flag.Lookup("test.benchtime").Value.String()
+	defer flag.Set("test.benchtime", prev)
+	if err := flag.Set("test.benchtime", "10x"); err != nil {
+		t.Fatal(err)
+	}
+	res := testing.Benchmark(BenchmarkInput)
+	if res.N == 0 {
+		t.Fatal("want the benchmark to run")
+	}
+	if res.Bytes == 0 {
+		t.Error("want the benchmark to set the bytes parsed")
+	}
+}
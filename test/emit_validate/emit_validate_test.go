@@ -0,0 +1,76 @@
+package emitvalidate
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		in    string
+		valid bool
+	}{
+		{`{}`, true},
+		{` { "a": 1 } `, true},
+		{`{"a": 1, "b": {"c": "d", "e": {}}}`, true},
+		{`{"a": 1,}`, false},
+		{`{"a" 1}`, false},
+		{`{"a": 1} x`, false},
+		{``, false},
+	}
+	for _, tc := range cases {
+		err := Validate([]byte(tc.in))
+		if got := err == nil; got != tc.valid {
+			t.Errorf("%q: want valid %t, got error %v", tc.in, tc.valid, err)
+		}
+		// Validate must agree with Parse
+		_, perr := Parse("", []byte(tc.in))
+		if (perr == nil) != (err == nil) {
+			t.Errorf("%q: Parse error %v, Validate error %v", tc.in, perr, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse("", []byte(`{"a": 1, "b": {"c": "d"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"a": 1, "b": map[string]any{"c": "d"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+var benchInput = func() []byte {
+	var buf strings.Builder
+	buf.WriteString("{")
+	for i := 0; i < 500; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(`"key` + strconv.Itoa(i) + `": {"n": ` + strconv.Itoa(i) + `, "s": "value"}`)
+	}
+	buf.WriteString("}")
+	return []byte(buf.String())
+}()
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse("", benchInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Validate(benchInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package emptyinput
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	nilmode "github.com/mna/pigeon/test/empty_input/nil"
+	normal "github.com/mna/pigeon/test/empty_input/normal"
+)
+
+func TestEmptyInputError(t *testing.T) {
+	got, err := Parse("", []byte(""))
+	if !errors.Is(err, ErrEmptyInput) {
+		t.Fatalf("want error %v, got %v", ErrEmptyInput, err)
+	}
+	if got != nil {
+		t.Errorf("want nil result, got %#v", got)
+	}
+}
+
+func TestEmptyInputNil(t *testing.T) {
+	got, err := nilmode.Parse("", []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("want nil result, got %#v", got)
+	}
+}
+
+func TestEmptyInputNormal(t *testing.T) {
+	got, err := normal.Parse("", []byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
+
+func TestNonEmptyInput(t *testing.T) {
+	parsers := map[string]func(string, []byte) (any, error){
+		"error":  func(f string, b []byte) (any, error) { return Parse(f, b) },
+		"nil":    func(f string, b []byte) (any, error) { return nilmode.Parse(f, b) },
+		"normal": func(f string, b []byte) (any, error) { return normal.Parse(f, b) },
+	}
+	for mode, parse := range parsers {
+		// whitespace only is not the empty input
+		got, err := parse("", []byte(" \n"))
+		if err != nil {
+			t.Errorf("%s: %v", mode, err)
+		} else if want := []string{}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: want %#v, got %#v", mode, want, got)
+		}
+
+		got, err = parse("", []byte("a bc\nd"))
+		if err != nil {
+			t.Errorf("%s: %v", mode, err)
+		} else if want := []string{"a", "bc", "d"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: want %#v, got %#v", mode, want, got)
+		}
+
+		if _, err := parse("", []byte("a 1")); err == nil || errors.Is(err, ErrEmptyInput) {
+			t.Errorf("%s: want a parse error, got %v", mode, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package entrypointtype
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTyped(t *testing.T) {
+	got, err := ParseTyped("", []byte("x = 12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Pair{Key: "x", Val: 12}); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestParseTypedMismatch(t *testing.T) {
+	got, err := ParseTyped("", []byte("x"))
+	if got != nil {
+		t.Errorf("want nil result, got %+v", got)
+	}
+	want := "entrypoint returned a value of type string, want *Pair"
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func TestParseTypedError(t *testing.T) {
+	got, err := ParseTyped("", []byte("x = "))
+	if got != nil {
+		t.Errorf("want nil result, got %+v", got)
+	}
+	want := `1:5 (4): no match found, expected: [ \t] or [0-9]`
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
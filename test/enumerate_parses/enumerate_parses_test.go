@@ -0,0 +1,62 @@
+package enumerateparses
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAll(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []any
+	}{
+		{"c", []any{[]any{"c"}}},
+		{"ab", []any{[]any{"ab"}, []any{"a", "b"}}},
+		// "abc" splits in 4 ways, the parser is generated with a maximum of 3
+		{"abc", []any{[]any{"abc"}, []any{"ab", "c"}, []any{"a", "bc"}}},
+	}
+	for _, tc := range cases {
+		got, err := ParseAll([]byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+
+		// the first value is the value returned by Parse
+		val, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no Parse error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(val, got[0]) {
+			t.Errorf("%q: want Parse value %v, got %v", tc.in, got[0], val)
+		}
+	}
+}
+
+func TestParseAllNestedChoice(t *testing.T) {
+	got, err := ParseAll([]byte("aa"), Entrypoint("Nested"))
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	want := []any{"TwoA", "DoubleA"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestParseAllError(t *testing.T) {
+	for _, in := range []string{"", "abd", "bcx"} {
+		_, want := Parse("", []byte(in))
+		got, err := ParseAll([]byte(in))
+		if got != nil {
+			t.Errorf("%q: want no value, got %v", in, got)
+		}
+		if err == nil || want == nil || err.Error() != want.Error() {
+			t.Errorf("%q: want error %v, got %v", in, want, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package errorrepair
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestErrorRepair(t *testing.T) {
+	cases := []struct {
+		in      string
+		stmts   int
+		repairs []Repair
+		err     string
+	}{
+		{in: "{ a; b; }", stmts: 2},
+		{in: "{ a; { b; c; } }", stmts: 3},
+		{in: "{ a; b;", stmts: 2, repairs: []Repair{
+			{Line: 1, Col: 8, Offset: 7, Inserted: "}"},
+		}},
+		{in: "{ a; {\n b;\n}", stmts: 2, repairs: []Repair{
+			{Line: 3, Col: 2, Offset: 12, Inserted: "}"},
+		}},
+		{in: "{ a b; }", stmts: 2, repairs: []Repair{
+			{Line: 1, Col: 5, Offset: 4, Inserted: ";"},
+		}},
+		{in: "{ a; 1 }", err: `1:6 (5): no match found, expected: "{", "}", [ \t\n] or [a-z]`},
+	}
+
+	for _, tc := range cases {
+		var repairs []Repair
+		got, err := Parse("", []byte(tc.in), Repairs(&repairs))
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%q: want error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if got != tc.stmts {
+			t.Errorf("%q: want %d statements, got %v", tc.in, tc.stmts, got)
+		}
+		if !reflect.DeepEqual(repairs, tc.repairs) {
+			t.Errorf("%q: want repairs %+v, got %+v", tc.in, tc.repairs, repairs)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package errorspans
+
+import "testing"
+
+func TestErrorSpans(t *testing.T) {
+	cases := []struct {
+		in    string
+		spans [][2]int
+	}{
+		// the parenthesized expression is not closed
+		{"let x = (1, 2 3);", [][2]int{{8, 15}}},
+		// the statement is not terminated, at the end of the input
+		{"let x = 1\nlet y = 2;", [][2]int{{0, 11}}},
+		{"let x = (1, a", [][2]int{{8, 13}}},
+		// the multibyte character is included
+		{"let x = (1, é)", [][2]int{{8, 14}}},
+		// the input matched by the code block that returned an error
+		{"let x = 1234; let y = (56789, 1);", [][2]int{{8, 12}, {23, 28}}},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in))
+		if err == nil {
+			t.Errorf("%q: want error, got none", tc.in)
+			continue
+		}
+		list := err.(errList)
+		if len(list) != len(tc.spans) {
+			t.Errorf("%q: want %d errors, got %d: %v", tc.in, len(tc.spans), len(list), err)
+			continue
+		}
+		for i, e := range list {
+			var got [2]int
+			got[0], got[1] = e.(interface{ Span() (int, int) }).Span()
+			if got != tc.spans[i] {
+				t.Errorf("%q: %d: want span %v, got %v (%q)", tc.in, i, tc.spans[i], got, tc.in[got[0]:got[1]])
+			}
+		}
+	}
+}
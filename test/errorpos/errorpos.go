@@ -26,61 +26,61 @@ var g = &grammar{
 				pos: position{line: 5, col: 9, offset: 32},
 				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 5, col: 9, offset: 32},
-						name: "_",
+						pos:    position{line: 5, col: 9, offset: 32},
+						offset: 16,
 					},
 					&choiceExpr{
 						pos: position{line: 5, col: 12, offset: 35},
 						alternatives: []any{
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 12, offset: 35},
-								name: "case01",
+								pos:    position{line: 5, col: 12, offset: 35},
+								offset: 1,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 21, offset: 44},
-								name: "case02",
+								pos:    position{line: 5, col: 21, offset: 44},
+								offset: 2,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 30, offset: 53},
-								name: "case03",
+								pos:    position{line: 5, col: 30, offset: 53},
+								offset: 3,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 39, offset: 62},
-								name: "case04",
+								pos:    position{line: 5, col: 39, offset: 62},
+								offset: 4,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 48, offset: 71},
-								name: "case05",
+								pos:    position{line: 5, col: 48, offset: 71},
+								offset: 5,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 57, offset: 80},
-								name: "case06",
+								pos:    position{line: 5, col: 57, offset: 80},
+								offset: 6,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 66, offset: 89},
-								name: "case07",
+								pos:    position{line: 5, col: 66, offset: 89},
+								offset: 7,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 75, offset: 98},
-								name: "case08",
+								pos:    position{line: 5, col: 75, offset: 98},
+								offset: 8,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 84, offset: 107},
-								name: "case09",
+								pos:    position{line: 5, col: 84, offset: 107},
+								offset: 9,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 93, offset: 116},
-								name: "case10",
+								pos:    position{line: 5, col: 93, offset: 116},
+								offset: 10,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 5, col: 102, offset: 125},
-								name: "case11",
+								pos:    position{line: 5, col: 102, offset: 125},
+								offset: 11,
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 5, col: 110, offset: 133},
-						name: "EOF",
+						pos:    position{line: 5, col: 110, offset: 133},
+						offset: 18,
 					},
 				},
 			},
@@ -98,8 +98,8 @@ var g = &grammar{
 						want:       "\"case01\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 7, col: 19, offset: 158},
-						name: "_",
+						pos:    position{line: 7, col: 19, offset: 158},
+						offset: 16,
 					},
 					&oneOrMoreExpr{
 						pos: position{line: 7, col: 21, offset: 160},
@@ -110,22 +110,22 @@ var g = &grammar{
 									pos: position{line: 7, col: 23, offset: 162},
 									alternatives: []any{
 										&ruleRefExpr{
-											pos:  position{line: 7, col: 23, offset: 162},
-											name: "increment",
+											pos:    position{line: 7, col: 23, offset: 162},
+											offset: 12,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 7, col: 35, offset: 174},
-											name: "decrement",
+											pos:    position{line: 7, col: 35, offset: 174},
+											offset: 13,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 7, col: 47, offset: 186},
-											name: "zero",
+											pos:    position{line: 7, col: 47, offset: 186},
+											offset: 14,
 										},
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 7, col: 53, offset: 192},
-									name: "_",
+									pos:    position{line: 7, col: 53, offset: 192},
+									offset: 16,
 								},
 							},
 						},
@@ -146,8 +146,8 @@ var g = &grammar{
 						want:       "\"case02\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 8, col: 19, offset: 217},
-						name: "__",
+						pos:    position{line: 8, col: 19, offset: 217},
+						offset: 17,
 					},
 					&oneOrMoreExpr{
 						pos: position{line: 8, col: 22, offset: 220},
@@ -175,8 +175,8 @@ var g = &grammar{
 						want:       "\"case03\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 9, col: 19, offset: 248},
-						name: "__",
+						pos:    position{line: 9, col: 19, offset: 248},
+						offset: 17,
 					},
 					&zeroOrOneExpr{
 						pos: position{line: 9, col: 22, offset: 251},
@@ -210,8 +210,8 @@ var g = &grammar{
 						want:       "\"case04\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 10, col: 19, offset: 282},
-						name: "__",
+						pos:    position{line: 10, col: 19, offset: 282},
+						offset: 17,
 					},
 					&charClassMatcher{
 						pos:        position{line: 10, col: 22, offset: 285},
@@ -257,8 +257,8 @@ var g = &grammar{
 						want:       "\"case05\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 11, col: 19, offset: 343},
-						name: "__",
+						pos:    position{line: 11, col: 19, offset: 343},
+						offset: 17,
 					},
 					&notExpr{
 						pos: position{line: 11, col: 22, offset: 346},
@@ -291,8 +291,8 @@ var g = &grammar{
 						want:       "\"case06\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 12, col: 19, offset: 379},
-						name: "__",
+						pos:    position{line: 12, col: 19, offset: 379},
+						offset: 17,
 					},
 					&notExpr{
 						pos: position{line: 12, col: 22, offset: 382},
@@ -326,8 +326,8 @@ var g = &grammar{
 						want:       "\"case07\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 13, col: 19, offset: 413},
-						name: "__",
+						pos:    position{line: 13, col: 19, offset: 413},
+						offset: 17,
 					},
 					&notExpr{
 						pos: position{line: 13, col: 22, offset: 416},
@@ -380,8 +380,8 @@ var g = &grammar{
 						want:       "\"case08\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 14, col: 19, offset: 469},
-						name: "__",
+						pos:    position{line: 14, col: 19, offset: 469},
+						offset: 17,
 					},
 					&andExpr{
 						pos: position{line: 14, col: 22, offset: 472},
@@ -411,8 +411,8 @@ var g = &grammar{
 						want:       "\"case09\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 15, col: 19, offset: 500},
-						name: "__",
+						pos:    position{line: 15, col: 19, offset: 500},
+						offset: 17,
 					},
 					&andExpr{
 						pos: position{line: 15, col: 22, offset: 503},
@@ -443,8 +443,8 @@ var g = &grammar{
 						want:       "\"case10\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 16, col: 19, offset: 532},
-						name: "__",
+						pos:    position{line: 16, col: 19, offset: 532},
+						offset: 17,
 					},
 					&andExpr{
 						pos: position{line: 16, col: 22, offset: 535},
@@ -500,8 +500,8 @@ var g = &grammar{
 						want:       "\"case11\"",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 17, col: 19, offset: 588},
-						name: "__",
+						pos:    position{line: 17, col: 19, offset: 588},
+						offset: 17,
 					},
 					&notExpr{
 						pos: position{line: 17, col: 22, offset: 591},
@@ -617,6 +617,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -636,6 +640,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -897,8 +914,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -1089,8 +1106,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -1103,6 +1120,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -1117,6 +1136,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -1202,6 +1224,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -1222,7 +1249,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -1352,11 +1379,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -1367,7 +1398,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -1389,36 +1420,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1426,6 +1443,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -1694,8 +1735,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -1724,12 +1765,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -1839,19 +1880,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
@@ -0,0 +1,28 @@
+package expandignorecase
+
+import (
+	"testing"
+)
+
+func TestExpandIgnoreCase(t *testing.T) {
+	for _, in := range []string{"abcdef", "ABCDEF", "aBc0F9"} {
+		if _, err := Parse("", []byte(in)); err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+		}
+	}
+	for _, in := range []string{"g", "G", "abcdefg"} {
+		if _, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got none", in)
+		}
+	}
+
+	seq := g.rules[0].expr.(*seqExpr)
+	chr := seq.exprs[0].(*oneOrMoreExpr).expr.(*charClassMatcher)
+	if chr.ignoreCase {
+		t.Error("want case-sensitive matcher")
+	}
+	want := []rune{'a', 'f', '0', '9', 'A', 'F'}
+	if string(chr.ranges) != string(want) {
+		t.Errorf("want ranges %q, got %q", want, chr.ranges)
+	}
+}
@@ -0,0 +1,34 @@
+package explainfailures
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExplanation(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"x = 1;\ny = 2;\nz 3;", `while parsing Stmt in File, expected "=" or [ \t\n] after Ident at line 3, column 3`},
+		{"x = ;", `while parsing Stmt in File, expected [ \t\n], [0-9] or [a-z] at line 1, column 5`},
+		{"x = 1", `while parsing Stmt in File, expected ";", [ \t\n] or [0-9] after "value" at line 1, column 6`},
+		{"1", `while parsing File, expected [ \t\n], [a-z] or EOF at line 1, column 1`},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in))
+		var el errList
+		if !errors.As(err, &el) || len(el) != 1 {
+			t.Errorf("%q: want a single error, got %v", tc.in, err)
+			continue
+		}
+		var pe *parserError
+		if !errors.As(el[0], &pe) {
+			t.Errorf("%q: want a parser error, got %T", tc.in, el[0])
+			continue
+		}
+		if got := pe.Explanation(); got != tc.want {
+			t.Errorf("%q: want explanation\n%s\ngot\n%s", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package explicitstack
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	hooks "github.com/mna/pigeon/test/explicit_stack/hooks"
+	recursive "github.com/mna/pigeon/test/explicit_stack/recursive"
+)
+
+func TestDeepNesting(t *testing.T) {
+	// the recursive parser overflows the Go stack well before this depth
+	const depth = 1000000
+	in := strings.Repeat("(", depth) + "x" + strings.Repeat(")", depth)
+	got, err := Parse("", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != depth {
+		t.Errorf("want depth %d, got %v", depth, got)
+	}
+}
+
+func TestNesting(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+		err  bool
+	}{
+		{"x", 0, false},
+		{"((x))", 2, false},
+		{"", nil, true},
+		{"((x)", nil, true},
+		{"(x))", nil, true},
+		{"(y)", nil, true},
+	}
+	for _, memo := range []bool{false, true} {
+		for _, tc := range cases {
+			got, err := Parse("", []byte(tc.in), Memoize(memo))
+			if (err != nil) != tc.err {
+				t.Errorf("%q: want error %t, got %v", tc.in, tc.err, err)
+				continue
+			}
+			if err == nil && got != tc.want {
+				t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+			}
+		}
+	}
+}
+
+func TestDeepNestingHooks(t *testing.T) {
+	// the options that hook into the rules run with the explicit stack,
+	// TestDeepNesting checks the nesting that overflows the Go stack
+	const depth = 100000
+	in := strings.Repeat("(", depth) + "x" + strings.Repeat(")", depth)
+	var reached hooks.Depth
+	got, err := hooks.Parse("", []byte(in), hooks.ReachedDepth(&reached))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != depth {
+		t.Errorf("want depth %d, got %v", depth, got)
+	}
+	// Input and the nested rules
+	if reached.Rules != depth+2 {
+		t.Errorf("want %d nested rules, got %d", depth+2, reached.Rules)
+	}
+	if err := hooks.Validate([]byte(in)); err != nil {
+		t.Errorf("want valid input, got %v", err)
+	}
+	if err := hooks.Validate([]byte(in[1:])); err == nil {
+		t.Error("want invalid input, got no error")
+	}
+}
+
+// TestDeepNestingRecursive checks the error of a parser generated with an
+// option that the explicit stack does not support, the rule timing: its
+// recursion is limited by the MaxVStackDepth option instead.
+func TestDeepNestingRecursive(t *testing.T) {
+	const depth = 1000000
+	in := strings.Repeat("(", depth) + "x" + strings.Repeat(")", depth)
+	_, err := recursive.Parse("", []byte(in), recursive.MaxVStackDepth(10000))
+	if err == nil || !strings.Contains(err.Error(), "max depth of the value stack exceeded") {
+		t.Errorf("want the max depth error, got %v", err)
+	}
+}
+
+// failure returns the explanation and the span of the error of a failed
+// parse, err being the list of errors of the parser.
+func failure(err error) (string, [2]int) {
+	v := reflect.ValueOf(err)
+	if v.Kind() != reflect.Slice || v.Len() == 0 {
+		return "", [2]int{}
+	}
+	pe, ok := v.Index(0).Interface().(interface {
+		Explanation() string
+		Span() (int, int)
+	})
+	if !ok {
+		return "", [2]int{}
+	}
+	start, end := pe.Span()
+	return pe.Explanation(), [2]int{start, end}
+}
+
+func TestHooks(t *testing.T) {
+	// the parser run by the explicit stack matches the recursive parser
+	// generated with the same options
+	for _, memo := range []bool{false, true} {
+		for _, in := range []string{"x", "((x))", "", "((x)", "(x))", "(y)", "((", "(()"} {
+			var reached hooks.Depth
+			var wantReached recursive.Depth
+			var positions []hooks.RulePosition
+			var wantPositions []recursive.RulePosition
+			got, err := hooks.Parse("", []byte(in), hooks.Memoize(memo), hooks.ReachedDepth(&reached), hooks.RulePositions(&positions))
+			want, wantErr := recursive.Parse("", []byte(in), recursive.Memoize(memo), recursive.ReachedDepth(&wantReached), recursive.RulePositions(&wantPositions))
+			if got != want {
+				t.Errorf("%q: want %v, got %v", in, want, got)
+			}
+			if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+				t.Errorf("%q: want error %v, got %v", in, wantErr, err)
+			}
+			gotExpl, gotSpan := failure(err)
+			wantExpl, wantSpan := failure(wantErr)
+			if gotExpl != wantExpl || gotSpan != wantSpan {
+				t.Errorf("%q: want explanation %q at %v, got %q at %v", in, wantExpl, wantSpan, gotExpl, gotSpan)
+			}
+			if reached.Rules != wantReached.Rules || reached.VStack != wantReached.VStack {
+				t.Errorf("%q: want depth %+v, got %+v", in, wantReached, reached)
+			}
+			if fmt.Sprint(positions) != fmt.Sprint(wantPositions) {
+				t.Errorf("%q: want positions %v, got %v", in, wantPositions, positions)
+			}
+			gotValid := hooks.Validate([]byte(in), hooks.Memoize(memo))
+			wantValid := recursive.Validate([]byte(in), recursive.Memoize(memo))
+			if fmt.Sprint(gotValid) != fmt.Sprint(wantValid) {
+				t.Errorf("%q: want validation error %v, got %v", in, wantValid, gotValid)
+			}
+		}
+	}
+}
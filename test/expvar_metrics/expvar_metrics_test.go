@@ -0,0 +1,63 @@
+package expvarmetrics
+
+import (
+	"expvar"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	if v := expvar.Get("expvar_metrics_test"); v != nil {
+		t.Fatalf("want metrics published on the first parse, got %v", v)
+	}
+
+	if _, err := Parse("", []byte("1,a,22")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("", []byte("1,"), Memoize(true)); err == nil {
+		t.Fatal("want error, got none")
+	}
+	if _, err := Parse("", []byte("b,2"), Memoize(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := expvar.Get("expvar_metrics_test").(*expvar.Map)
+	if !ok {
+		t.Fatalf("want metrics map, got %v", expvar.Get("expvar_metrics_test"))
+	}
+	intVal := func(key string) int64 {
+		v, ok := m.Get(key).(*expvar.Int)
+		if !ok {
+			t.Fatalf("%s: want int, got %v", key, m.Get(key))
+		}
+		return v.Value()
+	}
+	if got := intVal("parses"); got != 3 {
+		t.Errorf("want 3 parses, got %d", got)
+	}
+	if got := intVal("errors"); got != 1 {
+		t.Errorf("want 1 error, got %d", got)
+	}
+	if got := intVal("duration_ns"); got <= 0 {
+		t.Errorf("want positive duration, got %d", got)
+	}
+	lookups, hits := intVal("memo_lookups"), intVal("memo_hits")
+	if lookups == 0 || hits == 0 || hits > lookups {
+		t.Errorf("want memo hits in lookups, got %d hits in %d lookups", hits, lookups)
+	}
+	for _, key := range []string{"avg_duration_ns", "memo_hit_rate"} {
+		if _, ok := m.Get(key).(expvar.Func); !ok {
+			t.Errorf("%s: want func, got %v", key, m.Get(key))
+		}
+	}
+	if got, want := m.Get("memo_hit_rate").(expvar.Func).Value(), float64(hits)/float64(lookups); got != want {
+		t.Errorf("want hit rate %f, got %v", want, got)
+	}
+
+	// the metrics are published once and keep counting
+	if _, err := Parse("", []byte("3")); err != nil {
+		t.Fatal(err)
+	}
+	if got := intVal("parses"); got != 4 {
+		t.Errorf("want 4 parses, got %d", got)
+	}
+}
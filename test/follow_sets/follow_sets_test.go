@@ -0,0 +1,34 @@
+package followsets
+
+import "testing"
+
+func TestFollowSetsExpected(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"", `1:1 (0): no match found, expected: [a-z]`},
+		{"x = @", `1:5 (4): no match found, expected: "[", "\"" or [0-9]`},
+		{"x = [", `1:6 (5): no match found, expected: "[", "\"", "]" or [0-9]`},
+		{"x = [1 ;", `1:8 (7): no match found, expected: ",", "]" or [ \t]`},
+		{"x = 1 2", `1:7 (6): no match found, expected: EOF`},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in))
+		if err == nil {
+			t.Errorf("%q: want error, got none", tc.in)
+			continue
+		}
+		if err.Error() != tc.want {
+			t.Errorf("%q: want error %q, got %q", tc.in, tc.want, err)
+		}
+	}
+}
+
+func TestFollowSetsMatch(t *testing.T) {
+	for _, in := range []string{"x=1", "abc = [1, \"a\", [ ]]"} {
+		if _, err := Parse("", []byte(in)); err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package fuzzymatch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	cases := []struct {
+		in    string
+		stmts int
+		corr  []Correction
+		err   string
+	}{
+		{in: "print a", stmts: 1},
+		{in: "let a = b\nprint a", stmts: 2},
+		{in: "pritn a", stmts: 1, corr: []Correction{
+			{Line: 1, Col: 1, Offset: 0, Want: "print", Got: "pritn", Edits: 1},
+		}},
+		{in: "print a\nlte b = c", stmts: 2, corr: []Correction{
+			{Line: 2, Col: 1, Offset: 8, Want: "let", Got: "lte", Edits: 1},
+		}},
+		{in: "prnt a", stmts: 1, corr: []Correction{
+			{Line: 1, Col: 1, Offset: 0, Want: "print", Got: "prnt", Edits: 1},
+		}},
+		{in: "pirtn a", err: `1:1 (0): no match found, expected: "let", "print", [ \t\n] or EOF`},
+		{in: "pritn a\nlte b = c", err: `1:1 (0): no match found, expected: "let", "print", [ \t\n] or EOF`},
+	}
+
+	for _, tc := range cases {
+		var corr []Correction
+		got, err := Parse("", []byte(tc.in), Corrections(&corr))
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%q: want error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if got != tc.stmts {
+			t.Errorf("%q: want %d statements, got %v", tc.in, tc.stmts, got)
+		}
+		if !reflect.DeepEqual(corr, tc.corr) {
+			t.Errorf("%q: want corrections %+v, got %+v", tc.in, tc.corr, corr)
+		}
+	}
+}
@@ -56,14 +56,14 @@ var g = &grammar{
 							expr: &zeroOrMoreExpr{
 								pos: position{line: 23, col: 132, offset: 705},
 								expr: &ruleRefExpr{
-									pos:  position{line: 23, col: 132, offset: 705},
-									name: "Line",
+									pos:    position{line: 23, col: 132, offset: 705},
+									offset: 1,
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 23, col: 138, offset: 711},
-							name: "EOF",
+							pos:    position{line: 23, col: 138, offset: 711},
+							offset: 10,
 						},
 						&andCodeExpr{
 							pos: position{line: 23, col: 142, offset: 715},
@@ -83,31 +83,31 @@ var g = &grammar{
 					pos: position{line: 32, col: 8, offset: 946},
 					exprs: []any{
 						&ruleRefExpr{
-							pos:  position{line: 32, col: 8, offset: 946},
-							name: "_",
+							pos:    position{line: 32, col: 8, offset: 946},
+							offset: 9,
 						},
 						&labeledExpr{
 							pos:   position{line: 32, col: 10, offset: 948},
 							label: "inst",
 							expr: &ruleRefExpr{
-								pos:  position{line: 32, col: 15, offset: 953},
-								name: "Instruction",
+								pos:    position{line: 32, col: 15, offset: 953},
+								offset: 2,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 32, col: 27, offset: 965},
-							name: "_",
+							pos:    position{line: 32, col: 27, offset: 965},
+							offset: 9,
 						},
 						&choiceExpr{
 							pos: position{line: 32, col: 30, offset: 968},
 							alternatives: []any{
 								&ruleRefExpr{
-									pos:  position{line: 32, col: 30, offset: 968},
-									name: "nl",
+									pos:    position{line: 32, col: 30, offset: 968},
+									offset: 7,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 32, col: 35, offset: 973},
-									name: "EOF",
+									pos:    position{line: 32, col: 35, offset: 973},
+									offset: 10,
 								},
 							},
 						},
@@ -127,13 +127,13 @@ var g = &grammar{
 						&zeroOrOneExpr{
 							pos: position{line: 36, col: 15, offset: 1018},
 							expr: &ruleRefExpr{
-								pos:  position{line: 36, col: 15, offset: 1018},
-								name: "Label",
+								pos:    position{line: 36, col: 15, offset: 1018},
+								offset: 3,
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 36, col: 22, offset: 1025},
-							name: "_",
+							pos:    position{line: 36, col: 22, offset: 1025},
+							offset: 9,
 						},
 						&labeledExpr{
 							pos:   position{line: 36, col: 24, offset: 1027},
@@ -142,12 +142,12 @@ var g = &grammar{
 								pos: position{line: 36, col: 29, offset: 1032},
 								alternatives: []any{
 									&ruleRefExpr{
-										pos:  position{line: 36, col: 29, offset: 1032},
-										name: "Noop",
+										pos:    position{line: 36, col: 29, offset: 1032},
+										offset: 5,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 36, col: 36, offset: 1039},
-										name: "Jump",
+										pos:    position{line: 36, col: 36, offset: 1039},
+										offset: 6,
 									},
 								},
 							},
@@ -166,8 +166,8 @@ var g = &grammar{
 						pos:   position{line: 40, col: 9, offset: 1078},
 						label: "label",
 						expr: &ruleRefExpr{
-							pos:  position{line: 40, col: 15, offset: 1084},
-							name: "labelIdentifier",
+							pos:    position{line: 40, col: 15, offset: 1084},
+							offset: 4,
 						},
 					},
 					&stateCodeExpr{
@@ -243,15 +243,15 @@ var g = &grammar{
 							want:       "\"jump\"",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 50, col: 15, offset: 1272},
-							name: "__",
+							pos:    position{line: 50, col: 15, offset: 1272},
+							offset: 8,
 						},
 						&labeledExpr{
 							pos:   position{line: 50, col: 18, offset: 1275},
 							label: "label",
 							expr: &ruleRefExpr{
-								pos:  position{line: 50, col: 24, offset: 1281},
-								name: "labelIdentifier",
+								pos:    position{line: 50, col: 24, offset: 1281},
+								offset: 4,
 							},
 						},
 						&stateCodeExpr{
@@ -444,6 +444,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -463,6 +467,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -724,8 +741,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -916,8 +933,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -930,6 +947,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -944,6 +963,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -1029,6 +1051,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -1049,7 +1076,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -1179,11 +1206,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -1194,7 +1225,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -1216,36 +1247,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1253,6 +1270,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -1521,8 +1562,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -1551,12 +1592,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -1666,19 +1707,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
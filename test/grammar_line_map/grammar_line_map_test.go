@@ -0,0 +1,49 @@
+package grammarlinemap
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGrammarLines(t *testing.T) {
+	want := map[string]LineRange{
+		"List":   {5, 5},
+		"Item":   {8, 10},
+		"Number": {12, 12},
+		"Ident":  {14, 14},
+		"_":      {16, 16},
+		"EOF":    {18, 18},
+	}
+	got := GrammarLines()
+	if len(got) != len(want) {
+		t.Errorf("want %d rules, got %d", len(want), len(got))
+	}
+	for nm, lines := range want {
+		if got[nm] != lines {
+			t.Errorf("%s: want lines %v, got %v", nm, lines, got[nm])
+		}
+	}
+
+	// the first line of each rule is the one that defines it in the grammar
+	f, err := os.Open("grammar_line_map.peg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var src []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		src = append(src, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	for nm, lines := range got {
+		if line := src[lines.Start-1]; !strings.HasPrefix(line, nm+" ") {
+			t.Errorf("%s: want line %d to define the rule, got %q", nm, lines.Start, line)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package graphemeinput
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRunes(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []any
+	}{
+		{"", nil},
+		{"ab", []any{"1:a", "2:b"}},
+		// emoji with a skin tone modifier
+		{"👍🏽!", []any{"1:👍🏽", "2:!"}},
+		// combining acute accent
+		{"été", []any{"1:é", "2:t", "3:é"}},
+		// zero-width joiner sequence
+		{"👨‍👩‍👧x", []any{"1:👨‍👩‍👧", "2:x"}},
+		// flags are pairs of regional indicators
+		{"🇫🇷🇩🇪🇮", []any{"1:🇫🇷", "2:🇩🇪", "3:🇮"}},
+		// Hangul jamos
+		{"각가", []any{"1:각", "2:가"}},
+		{"a\r\nb", []any{"1:a", "2:\r\n", "1:b"}},
+	}
+	for _, tc := range cases {
+		got, err := ParseRunes("", []rune(tc.in))
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if tc.want == nil {
+			tc.want = []any(nil)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestParseRunesByRune(t *testing.T) {
+	// Parse still matches runes
+	got, err := Parse("", []byte("👍🏽!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{"1:👍", "2:🏽", "3:!"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,35 @@
+package guardoptimization
+
+import (
+	"strings"
+	"testing"
+
+	guard "github.com/mna/pigeon/test/guard_optimization/guard"
+	"github.com/mna/pigeon/test/parsertest"
+)
+
+var parsers = map[string]parsertest.ParseFunc{
+	"general": func(b []byte, _ bool) (any, error) { return Parse("", b) },
+	"guard":   func(b []byte, _ bool) (any, error) { return guard.Parse("", b) },
+}
+
+func TestGuardOptimization(t *testing.T) {
+	cases := []string{
+		"",
+		"func x;",
+		"functions return_value 42;",
+		"VAR variable var\n",
+		"return",
+		"ident",
+		"12.5;",
+		"func",
+		"abc def",
+		"9lives;",
+	}
+	parsertest.Compare(t, parsers["general"], parsers, cases)
+}
+
+func BenchmarkGuardOptimization(b *testing.B) {
+	input := []byte(strings.Repeat("func identifier another_identifier value x_12 12345;\n", 200))
+	parsertest.Benchmark(b, parsers, []string{"general", "guard"}, input)
+}
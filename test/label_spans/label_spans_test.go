@@ -0,0 +1,30 @@
+package labelspans
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLabelSpans(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []pair
+	}{
+		{in: "a=1", want: []pair{{key: [2]int{0, 1}, value: [2]int{2, 3}}}},
+		{in: `ab = "x y", cd=42`, want: []pair{
+			{key: [2]int{0, 2}, value: [2]int{5, 10}},
+			{key: [2]int{12, 14}, value: [2]int{15, 17}},
+		}},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
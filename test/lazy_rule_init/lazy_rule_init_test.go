@@ -0,0 +1,45 @@
+package lazyruleinit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLazyRuleInit(t *testing.T) {
+	built := make(map[string]int)
+	for _, r := range g.rules {
+		if r.expr != nil {
+			t.Fatalf("rule %s: expression built before parsing", r.name)
+		}
+		r, newExpr := r, r.newExpr
+		r.newExpr = func() any {
+			built[r.name]++
+			return newExpr()
+		}
+	}
+
+	for _, in := range []string{"1+2+3", "4"} {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := 6; in == "1+2+3" && got != want {
+			t.Errorf("%q: want %d, got %v", in, want, got)
+		}
+	}
+	// the expressions are built once, and never for the unused rule
+	if want := map[string]int{"Sum": 1, "Int": 1, "EOF": 1}; !reflect.DeepEqual(built, want) {
+		t.Errorf("want built expressions %v, got %v", want, built)
+	}
+
+	got, err := Parse("", []byte("0x1f"), Entrypoint("Hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 31; got != want {
+		t.Errorf("want %d, got %v", want, got)
+	}
+	if want := 1; built["Hex"] != want {
+		t.Errorf("want Hex built %d times, got %d", want, built["Hex"])
+	}
+}
@@ -31,8 +31,8 @@ var g = &grammar{
 							pos:   position{line: 5, col: 9, offset: 37},
 							label: "a",
 							expr: &ruleRefExpr{
-								pos:  position{line: 5, col: 11, offset: 39},
-								name: "expr",
+								pos:    position{line: 5, col: 11, offset: 39},
+								offset: 1,
 							},
 						},
 						&notExpr{
@@ -63,8 +63,8 @@ var g = &grammar{
 									pos:   position{line: 9, col: 9, offset: 75},
 									label: "a",
 									expr: &ruleRefExpr{
-										pos:  position{line: 9, col: 11, offset: 77},
-										name: "expr",
+										pos:    position{line: 9, col: 11, offset: 77},
+										offset: 1,
 									},
 								},
 								&labeledExpr{
@@ -92,8 +92,8 @@ var g = &grammar{
 									pos:   position{line: 9, col: 31, offset: 97},
 									label: "b",
 									expr: &ruleRefExpr{
-										pos:  position{line: 9, col: 33, offset: 99},
-										name: "term",
+										pos:    position{line: 9, col: 33, offset: 99},
+										offset: 2,
 									},
 								},
 							},
@@ -106,8 +106,8 @@ var g = &grammar{
 							pos:   position{line: 14, col: 5, offset: 237},
 							label: "a",
 							expr: &ruleRefExpr{
-								pos:  position{line: 14, col: 7, offset: 239},
-								name: "term",
+								pos:    position{line: 14, col: 7, offset: 239},
+								offset: 2,
 							},
 						},
 					},
@@ -132,8 +132,8 @@ var g = &grammar{
 									pos:   position{line: 19, col: 8, offset: 300},
 									label: "a",
 									expr: &ruleRefExpr{
-										pos:  position{line: 19, col: 10, offset: 302},
-										name: "term",
+										pos:    position{line: 19, col: 10, offset: 302},
+										offset: 2,
 									},
 								},
 								&labeledExpr{
@@ -167,8 +167,8 @@ var g = &grammar{
 									pos:   position{line: 19, col: 36, offset: 328},
 									label: "b",
 									expr: &ruleRefExpr{
-										pos:  position{line: 19, col: 38, offset: 330},
-										name: "factor",
+										pos:    position{line: 19, col: 38, offset: 330},
+										offset: 3,
 									},
 								},
 							},
@@ -181,8 +181,8 @@ var g = &grammar{
 							pos:   position{line: 25, col: 5, offset: 472},
 							label: "a",
 							expr: &ruleRefExpr{
-								pos:  position{line: 25, col: 7, offset: 474},
-								name: "factor",
+								pos:    position{line: 25, col: 7, offset: 474},
+								offset: 3,
 							},
 						},
 					},
@@ -228,8 +228,8 @@ var g = &grammar{
 									pos:   position{line: 30, col: 25, offset: 554},
 									label: "a",
 									expr: &ruleRefExpr{
-										pos:  position{line: 30, col: 27, offset: 556},
-										name: "factor",
+										pos:    position{line: 30, col: 27, offset: 556},
+										offset: 3,
 									},
 								},
 							},
@@ -239,8 +239,8 @@ var g = &grammar{
 						pos: position{line: 34, col: 5, offset: 666},
 						run: (*parser).callonfactor10,
 						expr: &ruleRefExpr{
-							pos:  position{line: 34, col: 5, offset: 666},
-							name: "atom",
+							pos:    position{line: 34, col: 5, offset: 666},
+							offset: 4,
 						},
 					},
 				},
@@ -363,6 +363,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -382,6 +386,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -575,8 +592,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -762,8 +779,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -776,6 +793,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -790,6 +809,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -850,6 +872,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -870,7 +897,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -948,11 +975,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -963,7 +994,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -982,36 +1013,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1019,6 +1036,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -1356,15 +1397,11 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
+	rule := p.rules[ref.offset]
 	return p.parseRuleWrap(rule)
 }
 
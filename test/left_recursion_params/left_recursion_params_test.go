@@ -0,0 +1,29 @@
+package leftrecursionparams
+
+import "testing"
+
+func TestLeftRecursionParams(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int
+	}{
+		{"1", -1},
+		{"1!", 1},
+		{"5-2", -3},
+		{"5-2!", 3},
+		{"9-3-1", -5},
+		{"9-3-1!", 5},
+	}
+	for _, tc := range cases {
+		for _, memo := range []bool{false, true} {
+			got, err := Parse("", []byte(tc.in), Memoize(memo))
+			if err != nil {
+				t.Errorf("%q: %v", tc.in, err)
+				continue
+			}
+			if got != tc.want {
+				t.Errorf("%q: want %d, got %v", tc.in, tc.want, got)
+			}
+		}
+	}
+}
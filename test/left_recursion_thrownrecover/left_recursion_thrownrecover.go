@@ -39,8 +39,8 @@ var g = &grammar{
 					pos:   position{line: 11, col: 10, offset: 117},
 					label: "case01",
 					expr: &ruleRefExpr{
-						pos:  position{line: 11, col: 17, offset: 124},
-						name: "MultiLabelRecover",
+						pos:    position{line: 11, col: 17, offset: 124},
+						offset: 2,
 					},
 				},
 			},
@@ -53,12 +53,12 @@ var g = &grammar{
 			expr: &recoveryExpr{
 				pos: position{line: 13, col: 21, offset: 186},
 				expr: &ruleRefExpr{
-					pos:  position{line: 13, col: 21, offset: 186},
-					name: "number",
+					pos:    position{line: 13, col: 21, offset: 186},
+					offset: 3,
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 13, col: 51, offset: 216},
-					name: "ErrNonNumber",
+					pos:    position{line: 13, col: 51, offset: 216},
+					offset: 5,
 				},
 				failureLabel: []string{
 					"errAlpha",
@@ -90,16 +90,16 @@ var g = &grammar{
 									pos:   position{line: 15, col: 16, offset: 245},
 									label: "n",
 									expr: &ruleRefExpr{
-										pos:  position{line: 15, col: 18, offset: 247},
-										name: "number",
+										pos:    position{line: 15, col: 18, offset: 247},
+										offset: 3,
 									},
 								},
 								&labeledExpr{
 									pos:   position{line: 15, col: 25, offset: 254},
 									label: "d",
 									expr: &ruleRefExpr{
-										pos:  position{line: 15, col: 27, offset: 256},
-										name: "digit",
+										pos:    position{line: 15, col: 27, offset: 256},
+										offset: 4,
 									},
 								},
 							},
@@ -112,8 +112,8 @@ var g = &grammar{
 							pos:   position{line: 17, col: 5, offset: 309},
 							label: "d",
 							expr: &ruleRefExpr{
-								pos:  position{line: 17, col: 7, offset: 311},
-								name: "digit",
+								pos:    position{line: 17, col: 7, offset: 311},
+								offset: 4,
 							},
 						},
 					},
@@ -222,8 +222,8 @@ var g = &grammar{
 				pos: position{line: 34, col: 11, offset: 624},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 34, col: 11, offset: 624},
-						name: "ThrowUndefLabel",
+						pos:    position{line: 34, col: 11, offset: 624},
+						offset: 7,
 					},
 					&andCodeExpr{
 						pos: position{line: 34, col: 29, offset: 642},
@@ -241,8 +241,8 @@ var g = &grammar{
 				pos: position{line: 36, col: 19, offset: 717},
 				exprs: []any{
 					&ruleRefExpr{
-						pos:  position{line: 36, col: 19, offset: 717},
-						name: "ThrowUndefLabel",
+						pos:    position{line: 36, col: 19, offset: 717},
+						offset: 7,
 					},
 					&throwExpr{
 						pos:   position{line: 36, col: 35, offset: 733},
@@ -263,8 +263,8 @@ var g = &grammar{
 					pos:   position{line: 41, col: 10, offset: 786},
 					label: "case03",
 					expr: &ruleRefExpr{
-						pos:  position{line: 41, col: 17, offset: 793},
-						name: "OuterRecover03",
+						pos:    position{line: 41, col: 17, offset: 793},
+						offset: 9,
 					},
 				},
 			},
@@ -279,12 +279,12 @@ var g = &grammar{
 				expr: &recoveryExpr{
 					pos: position{line: 43, col: 18, offset: 849},
 					expr: &ruleRefExpr{
-						pos:  position{line: 43, col: 18, offset: 849},
-						name: "InnerRecover03",
+						pos:    position{line: 43, col: 18, offset: 849},
+						offset: 10,
 					},
 					recoverExpr: &ruleRefExpr{
-						pos:  position{line: 43, col: 66, offset: 897},
-						name: "ErrAlphaOuter03",
+						pos:    position{line: 43, col: 66, offset: 897},
+						offset: 14,
 					},
 					failureLabel: []string{
 						"errAlphaLower",
@@ -292,8 +292,8 @@ var g = &grammar{
 					},
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 43, col: 95, offset: 926},
-					name: "ErrOtherOuter03",
+					pos:    position{line: 43, col: 95, offset: 926},
+					offset: 15,
 				},
 				failureLabel: []string{
 					"errOther",
@@ -308,12 +308,12 @@ var g = &grammar{
 			expr: &recoveryExpr{
 				pos: position{line: 45, col: 18, offset: 960},
 				expr: &ruleRefExpr{
-					pos:  position{line: 45, col: 18, offset: 960},
-					name: "number03",
+					pos:    position{line: 45, col: 18, offset: 960},
+					offset: 11,
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 45, col: 45, offset: 987},
-					name: "ErrAlphaInner03",
+					pos:    position{line: 45, col: 45, offset: 987},
+					offset: 13,
 				},
 				failureLabel: []string{
 					"errAlphaLower",
@@ -344,16 +344,16 @@ var g = &grammar{
 									pos:   position{line: 47, col: 18, offset: 1021},
 									label: "n",
 									expr: &ruleRefExpr{
-										pos:  position{line: 47, col: 20, offset: 1023},
-										name: "number03",
+										pos:    position{line: 47, col: 20, offset: 1023},
+										offset: 11,
 									},
 								},
 								&labeledExpr{
 									pos:   position{line: 47, col: 29, offset: 1032},
 									label: "d",
 									expr: &ruleRefExpr{
-										pos:  position{line: 47, col: 31, offset: 1034},
-										name: "digit03",
+										pos:    position{line: 47, col: 31, offset: 1034},
+										offset: 12,
 									},
 								},
 							},
@@ -366,8 +366,8 @@ var g = &grammar{
 							pos:   position{line: 49, col: 5, offset: 1089},
 							label: "d",
 							expr: &ruleRefExpr{
-								pos:  position{line: 49, col: 7, offset: 1091},
-								name: "digit03",
+								pos:    position{line: 49, col: 7, offset: 1091},
+								offset: 12,
 							},
 						},
 					},
@@ -586,8 +586,8 @@ var g = &grammar{
 					pos:   position{line: 76, col: 10, offset: 1777},
 					label: "case04",
 					expr: &ruleRefExpr{
-						pos:  position{line: 76, col: 17, offset: 1784},
-						name: "OuterRecover04",
+						pos:    position{line: 76, col: 17, offset: 1784},
+						offset: 17,
 					},
 				},
 			},
@@ -602,12 +602,12 @@ var g = &grammar{
 				expr: &recoveryExpr{
 					pos: position{line: 78, col: 18, offset: 1840},
 					expr: &ruleRefExpr{
-						pos:  position{line: 78, col: 18, offset: 1840},
-						name: "InnerRecover04",
+						pos:    position{line: 78, col: 18, offset: 1840},
+						offset: 18,
 					},
 					recoverExpr: &ruleRefExpr{
-						pos:  position{line: 78, col: 66, offset: 1888},
-						name: "ErrAlphaOuter04",
+						pos:    position{line: 78, col: 66, offset: 1888},
+						offset: 22,
 					},
 					failureLabel: []string{
 						"errAlphaLower",
@@ -615,8 +615,8 @@ var g = &grammar{
 					},
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 78, col: 95, offset: 1917},
-					name: "ErrOtherOuter04",
+					pos:    position{line: 78, col: 95, offset: 1917},
+					offset: 23,
 				},
 				failureLabel: []string{
 					"errOther",
@@ -631,12 +631,12 @@ var g = &grammar{
 			expr: &recoveryExpr{
 				pos: position{line: 80, col: 18, offset: 1951},
 				expr: &ruleRefExpr{
-					pos:  position{line: 80, col: 18, offset: 1951},
-					name: "number04",
+					pos:    position{line: 80, col: 18, offset: 1951},
+					offset: 19,
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 80, col: 45, offset: 1978},
-					name: "ErrAlphaInner04",
+					pos:    position{line: 80, col: 45, offset: 1978},
+					offset: 21,
 				},
 				failureLabel: []string{
 					"errAlphaLower",
@@ -667,16 +667,16 @@ var g = &grammar{
 									pos:   position{line: 82, col: 18, offset: 2012},
 									label: "n",
 									expr: &ruleRefExpr{
-										pos:  position{line: 82, col: 20, offset: 2014},
-										name: "number04",
+										pos:    position{line: 82, col: 20, offset: 2014},
+										offset: 19,
 									},
 								},
 								&labeledExpr{
 									pos:   position{line: 82, col: 29, offset: 2023},
 									label: "d",
 									expr: &ruleRefExpr{
-										pos:  position{line: 82, col: 31, offset: 2025},
-										name: "digit04",
+										pos:    position{line: 82, col: 31, offset: 2025},
+										offset: 20,
 									},
 								},
 							},
@@ -689,8 +689,8 @@ var g = &grammar{
 							pos:   position{line: 84, col: 5, offset: 2080},
 							label: "d",
 							expr: &ruleRefExpr{
-								pos:  position{line: 84, col: 7, offset: 2082},
-								name: "digit04",
+								pos:    position{line: 84, col: 7, offset: 2082},
+								offset: 20,
 							},
 						},
 					},
@@ -1207,6 +1207,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -1226,6 +1230,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -1490,8 +1507,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -1687,8 +1704,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -1701,6 +1718,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -1715,6 +1734,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -1800,6 +1822,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -1820,7 +1847,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -1950,11 +1977,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -1965,7 +1996,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -1987,36 +2018,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -2024,6 +2041,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -2347,8 +2388,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -2377,12 +2418,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -2492,19 +2533,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
@@ -0,0 +1,38 @@
+package leftrecursiverules
+
+import "testing"
+
+func TestLeftRecursiveRules(t *testing.T) {
+	cases := map[string]string{
+		"1":                "1",
+		"1 - 2 - 3":        "((1-2)-3)",
+		"f()":              "f[]",
+		"f(1+2, g(3) - 4)": "f[(1+2),(g[3]-4)]",
+		"1 + f(2-3-4)":     "(1+f[((2-3)-4)])",
+	}
+	for in, want := range cases {
+		for _, memo := range []bool{false, true} {
+			got, err := Parse("", []byte(in), Memoize(memo))
+			if err != nil {
+				t.Errorf("%q: %v", in, err)
+				continue
+			}
+			if got != want {
+				t.Errorf("%q: want %s, got %s", in, want, got)
+			}
+		}
+	}
+
+	if _, err := Parse("", []byte("1 +")); err == nil {
+		t.Errorf("want error")
+	}
+}
+
+func TestRuleFlags(t *testing.T) {
+	for _, r := range g.rules {
+		want := r.name == "Expr"
+		if r.leftRecursive != want || r.leader != want {
+			t.Errorf("%s: want left-recursive leader %t, got %t, %t", r.name, want, r.leftRecursive, r.leader)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package legacygocompat
+
+import (
+	"go/ast"
+	"go/importer"
+	goparser "go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	got, err := Parse("", []byte("a = 1\nb = x c=23"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"a": 1, "b": "x", "c": 23}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestGo116(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := goparser.ParseFile(fset, "legacy_go_compat.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := types.Config{GoVersion: "go1.16", Importer: importer.Default()}
+	info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+	if _, err := conf.Check("legacygocompat", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+	predeclared := types.Universe.Lookup("any")
+	for id, obj := range info.Uses {
+		if obj == predeclared {
+			t.Errorf("%s: predeclared any type", fset.Position(id.Pos()))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package linerecovery
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLines(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []any
+		errs []string
+	}{
+		{"", nil, nil},
+		{"a = 1\nb = 2", []any{[]any{"a", 1}, []any{"b", 2}}, nil},
+		{
+			"a = 1\nb = \nc = 3\nd = 4\n",
+			[]any{[]any{"a", 1}, []any{"c", 3}, []any{"d", 4}},
+			[]string{`f:3:0 (10): no match found, expected: [ \t] or [0-9]`},
+		},
+		{
+			"a = 1\nb = 2000\n\nd = 4 x\ne = 5",
+			[]any{[]any{"a", 1}, []any{"e", 5}},
+			[]string{
+				"f:2:5 (10): rule Number: number too large",
+				"f:4:0 (15): no match found, expected: [ \\t] or [a-z]",
+				`f:4:7 (22): no match found, expected: "\n", [ \t] or EOF`,
+			},
+		},
+		{"a = 1\nb", []any{[]any{"a", 1}}, []string{`f:2:2 (7): no match found, expected: "=", [ \t] or [a-z]`}},
+	}
+	// the options apply to all the items
+	for _, opts := range [][]Option{nil, {Memoize(true)}, {Entrypoint("Assign")}} {
+		for _, tc := range cases {
+			got, err := ParseLines("f", []byte(tc.in), opts...)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+			}
+			var errs []string
+			if list, ok := err.(errList); ok {
+				for _, e := range list {
+					errs = append(errs, e.Error())
+				}
+			} else if err != nil {
+				t.Errorf("%q: want an errList, got %T", tc.in, err)
+			}
+			if !reflect.DeepEqual(errs, tc.errs) {
+				t.Errorf("%q: want errors %q, got %q", tc.in, tc.errs, errs)
+			}
+		}
+	}
+}
+
+func TestParseLinesInvalidEntrypoint(t *testing.T) {
+	_, err := ParseLines("f", []byte("a = 1\nb = 2"), Entrypoint("Value"))
+	want := "f:1:0 (0): invalid entrypoint\nf:2:0 (6): invalid entrypoint"
+	if err == nil || err.Error() != want {
+		t.Errorf("want %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,42 @@
+package linestart
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineStart(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+		err  string
+	}{
+		{in: "", want: nil},
+		{in: "#a", want: []string{"a"}},
+		{in: "#a\nb c\n#d", want: []string{"a", "d"}},
+		{in: "\n\n#a", want: []string{"a"}},
+		{in: "a #b", err: `1:3 (2): no match found, expected: [ \n], [a-z], ^ or EOF`},
+		{in: "#a #b", err: `1:4 (3): no match found, expected: [ \n], [a-z], ^ or EOF`},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%q: want error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		var gotSl []string
+		if got != nil {
+			gotSl = got.([]string)
+		}
+		if !reflect.DeepEqual(gotSl, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, gotSl)
+		}
+	}
+}
@@ -32,14 +32,14 @@ var g = &grammar{
 								pos: position{line: 7, col: 10, offset: 86},
 								exprs: []any{
 									&ruleRefExpr{
-										pos:  position{line: 7, col: 10, offset: 86},
-										name: "L",
+										pos:    position{line: 7, col: 10, offset: 86},
+										offset: 1,
 									},
 									&zeroOrOneExpr{
 										pos: position{line: 7, col: 12, offset: 88},
 										expr: &ruleRefExpr{
-											pos:  position{line: 7, col: 12, offset: 88},
-											name: "S",
+											pos:    position{line: 7, col: 12, offset: 88},
+											offset: 3,
 										},
 									},
 								},
@@ -48,14 +48,14 @@ var g = &grammar{
 								pos: position{line: 7, col: 17, offset: 93},
 								exprs: []any{
 									&ruleRefExpr{
-										pos:  position{line: 7, col: 17, offset: 93},
-										name: "L",
+										pos:    position{line: 7, col: 17, offset: 93},
+										offset: 1,
 									},
 									&zeroOrOneExpr{
 										pos: position{line: 7, col: 19, offset: 95},
 										expr: &ruleRefExpr{
-											pos:  position{line: 7, col: 19, offset: 95},
-											name: "N",
+											pos:    position{line: 7, col: 19, offset: 95},
+											offset: 2,
 										},
 									},
 								},
@@ -64,14 +64,14 @@ var g = &grammar{
 								pos: position{line: 7, col: 24, offset: 100},
 								exprs: []any{
 									&ruleRefExpr{
-										pos:  position{line: 7, col: 24, offset: 100},
-										name: "N",
+										pos:    position{line: 7, col: 24, offset: 100},
+										offset: 2,
 									},
 									&zeroOrOneExpr{
 										pos: position{line: 7, col: 26, offset: 102},
 										expr: &ruleRefExpr{
-											pos:  position{line: 7, col: 26, offset: 102},
-											name: "L",
+											pos:    position{line: 7, col: 26, offset: 102},
+											offset: 1,
 										},
 									},
 								},
@@ -80,14 +80,14 @@ var g = &grammar{
 								pos: position{line: 7, col: 31, offset: 107},
 								exprs: []any{
 									&ruleRefExpr{
-										pos:  position{line: 7, col: 31, offset: 107},
-										name: "N",
+										pos:    position{line: 7, col: 31, offset: 107},
+										offset: 2,
 									},
 									&zeroOrOneExpr{
 										pos: position{line: 7, col: 33, offset: 109},
 										expr: &ruleRefExpr{
-											pos:  position{line: 7, col: 33, offset: 109},
-											name: "S",
+											pos:    position{line: 7, col: 33, offset: 109},
+											offset: 3,
 										},
 									},
 								},
@@ -96,14 +96,14 @@ var g = &grammar{
 								pos: position{line: 7, col: 38, offset: 114},
 								exprs: []any{
 									&ruleRefExpr{
-										pos:  position{line: 7, col: 38, offset: 114},
-										name: "S",
+										pos:    position{line: 7, col: 38, offset: 114},
+										offset: 3,
 									},
 									&zeroOrOneExpr{
 										pos: position{line: 7, col: 40, offset: 116},
 										expr: &ruleRefExpr{
-											pos:  position{line: 7, col: 40, offset: 116},
-											name: "L",
+											pos:    position{line: 7, col: 40, offset: 116},
+											offset: 1,
 										},
 									},
 								},
@@ -112,14 +112,14 @@ var g = &grammar{
 								pos: position{line: 7, col: 45, offset: 121},
 								exprs: []any{
 									&ruleRefExpr{
-										pos:  position{line: 7, col: 45, offset: 121},
-										name: "S",
+										pos:    position{line: 7, col: 45, offset: 121},
+										offset: 3,
 									},
 									&zeroOrOneExpr{
 										pos: position{line: 7, col: 47, offset: 123},
 										expr: &ruleRefExpr{
-											pos:  position{line: 7, col: 47, offset: 123},
-											name: "N",
+											pos:    position{line: 7, col: 47, offset: 123},
+											offset: 2,
 										},
 									},
 								},
@@ -129,13 +129,13 @@ var g = &grammar{
 					&zeroOrMoreExpr{
 						pos: position{line: 7, col: 52, offset: 128},
 						expr: &ruleRefExpr{
-							pos:  position{line: 7, col: 52, offset: 128},
-							name: "File",
+							pos:    position{line: 7, col: 52, offset: 128},
+							offset: 0,
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 7, col: 58, offset: 134},
-						name: "EOF",
+						pos:    position{line: 7, col: 58, offset: 134},
+						offset: 4,
 					},
 				},
 			},
@@ -210,6 +210,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -229,6 +233,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -490,8 +507,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -682,8 +699,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -696,6 +713,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -710,6 +729,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -795,6 +817,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -815,7 +842,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -945,11 +972,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -960,7 +991,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -982,36 +1013,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1019,6 +1036,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -1287,8 +1328,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -1317,12 +1358,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -1432,19 +1473,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
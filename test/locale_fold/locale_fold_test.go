@@ -0,0 +1,52 @@
+package localefold
+
+import (
+	"reflect"
+	"testing"
+
+	tr "github.com/mna/pigeon/test/locale_fold/tr"
+	trbasiclatin "github.com/mna/pigeon/test/locale_fold/tr-basic-latin"
+	trexpand "github.com/mna/pigeon/test/locale_fold/tr-expand"
+)
+
+var turkishParsers = map[string]func(string, []byte) (any, error){
+	"tr":             func(nm string, b []byte) (any, error) { return tr.Parse(nm, b) },
+	"tr-basic-latin": func(nm string, b []byte) (any, error) { return trbasiclatin.Parse(nm, b) },
+	"tr-expand":      func(nm string, b []byte) (any, error) { return trexpand.Parse(nm, b) },
+}
+
+func TestLocaleFold(t *testing.T) {
+	cases := []struct {
+		in          string
+		defaultWant []string
+		turkishWant []string
+	}{
+		{"i", []string{"dotted"}, []string{"dotted"}},
+		{"I", []string{"dotted"}, []string{"dotless"}},
+		{"ı", []string{"other"}, []string{"dotless"}},
+		{"İ", []string{"dotted"}, []string{"dotted"}},
+		{"kir", []string{"word"}, []string{"other", "dotted", "other"}},
+		{"kır", []string{"other", "other", "other"}, []string{"word"}},
+		{"KIR", []string{"word"}, []string{"word"}},
+		{"Kİr", []string{"word"}, []string{"other", "dotted", "other"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+		} else if !reflect.DeepEqual(got, tc.defaultWant) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.defaultWant, got)
+		}
+
+		for nm, fn := range turkishParsers {
+			got, err := fn("", []byte(tc.in))
+			if err != nil {
+				t.Errorf("%s: %q: %v", nm, tc.in, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, tc.turkishWant) {
+				t.Errorf("%s: %q: want %v, got %v", nm, tc.in, tc.turkishWant, got)
+			}
+		}
+	}
+}
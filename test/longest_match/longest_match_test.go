@@ -0,0 +1,84 @@
+package longestmatch
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseOutput parses in and returns the result along with the debug
+// output printed to stdout.
+func parseOutput(t *testing.T, in string, opts ...Option) (any, string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	outc := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		outc <- string(b)
+	}()
+
+	got, err := Parse("", []byte(in), opts...)
+	w.Close()
+	out := <-outc
+	if err != nil {
+		t.Fatalf("%q: want no error, got %v", in, err)
+	}
+	return got, out
+}
+
+func TestLongestMatchDiagnostics(t *testing.T) {
+	const diag = "longest match: choice 9:8 in rule Op: alternative 1 matched 1 bytes, alternative 2 would match 2 bytes"
+
+	cases := []struct {
+		in   string
+		want any
+		diag bool
+	}{
+		{in: "<=", want: []any{"<", "="}, diag: true},
+		{in: "<x", want: []any{"<", "x"}},
+		{in: "=<=", want: []any{"=", "<="}},
+	}
+
+	for _, tc := range cases {
+		got, out := parseOutput(t, tc.in, Debug(true))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %#v, got %#v", tc.in, tc.want, got)
+		}
+		if n := strings.Count(out, diag); tc.diag && n != 1 || !tc.diag && n != 0 {
+			t.Errorf("%q: want diagnostic %t, got %d in output:\n%s", tc.in, tc.diag, n, out)
+		}
+	}
+
+	// without debug mode, nothing is printed
+	got, out := parseOutput(t, "<=")
+	if want := []any{"<", "="}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+	if out != "" {
+		t.Errorf("want no output, got:\n%s", out)
+	}
+}
+
+func TestLongestMatchStats(t *testing.T) {
+	var want, got Stats
+	want.ChoiceAltCnt = make(map[string]map[string]int)
+	got.ChoiceAltCnt = make(map[string]map[string]int)
+
+	if _, err := Parse("", []byte("<="), Statistics(&want, "no match")); err != nil {
+		t.Fatal(err)
+	}
+	parseOutput(t, "<=", Debug(true), Statistics(&got, "no match"))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want stats %v, got %v", want, got)
+	}
+}
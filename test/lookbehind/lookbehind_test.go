@@ -0,0 +1,38 @@
+package lookbehind
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookbehind(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []any
+	}{
+		{"", []any{}},
+		{"1", []any{"1"}},
+		{"$1", []any{"$", "price:1"}},
+		{"a$12", []any{"a", "$", "price:1", "2"}},
+		{"$$3", []any{"$", "$", "price:3"}},
+		{"ax!", []any{"a", "x", "end"}},
+		{"aé!", []any{"a", "é", "end"}},
+		{"éé!", []any{"é", "é", "!"}},
+		{"x!", []any{"x", "!"}},
+		{"!", []any{"!"}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		items, _ := got.([]any)
+		if items == nil {
+			items = []any{}
+		}
+		if !reflect.DeepEqual(items, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, items)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package losslesscst
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var roundTripInputs = []string{
+	"",
+	"  \n",
+	"a=1;",
+	"a = -12 ;\nb=\"x y\";",
+	"// comment\nf( 1 , \"two\",three ) ;\n\t// trailing",
+	"print();  x = y ;\r\n",
+	"hello = \"wörld\" ; g(\"ü\")\n;",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, memo := range []bool{false, true} {
+		for _, in := range roundTripInputs {
+			root, err := ParseCST("", []byte(in), Memoize(memo))
+			if err != nil {
+				t.Errorf("%q: %v", in, err)
+				continue
+			}
+			var buf bytes.Buffer
+			n, err := root.WriteTo(&buf)
+			if err != nil {
+				t.Errorf("%q: %v", in, err)
+				continue
+			}
+			if got := buf.String(); got != in || n != int64(len(in)) {
+				t.Errorf("%q: memoize %t: got %q (%d bytes)", in, memo, got, n)
+			}
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	root, err := ParseCST("", []byte("f(1, x);\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	dump(&buf, root, 0)
+	want := `File 0 "f(1, x);\n"
+  Item 0 "f(1, x);"
+    Call 0 "f(1, x);"
+      Ident 0 "f"
+        0 "f"
+      1 "("
+      Value 2 "1"
+        Number 2 "1"
+          2 "1"
+      3 ","
+      _ 4 " "
+        4 " "
+      Value 5 "x"
+        Ident 5 "x"
+          5 "x"
+      6 ")"
+      7 ";"
+  _ 8 "\n"
+    8 "\n"
+`
+	if got := buf.String(); got != want {
+		t.Errorf("want\n%s\ngot\n%s", want, got)
+	}
+}
+
+func dump(buf *strings.Builder, n *CSTNode, depth int) {
+	fmt.Fprintf(buf, "%s", strings.Repeat("  ", depth))
+	if n.Rule != "" {
+		fmt.Fprintf(buf, "%s ", n.Rule)
+	}
+	fmt.Fprintf(buf, "%d %q\n", n.Offset, n.Text)
+	for _, c := range n.Children {
+		dump(buf, c, depth+1)
+	}
+}
+
+func TestWriteModified(t *testing.T) {
+	in := "a = 1;\n// keep\nf(x, \"y\");\n"
+	root, err := ParseCST("", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// replace the text of an inner node, and of the leaf of another
+	var nodes []*CSTNode
+	collect(root, "Value", &nodes)
+	if len(nodes) != 3 {
+		t.Fatalf("want 3 values, got %d", len(nodes))
+	}
+	nodes[0].Text = []byte("(2 + 3)")
+	ident := nodes[1].Children[0].Children[0]
+	ident.Text = []byte("xs")
+
+	var buf bytes.Buffer
+	n, err := root.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a = (2 + 3);\n// keep\nf(xs, \"y\");\n"
+	if got := buf.String(); got != want || n != int64(len(want)) {
+		t.Errorf("want %q, got %q (%d bytes)", want, got, n)
+	}
+
+	// a replaced node without children is written as a leaf
+	nodes[2].Children = nil
+	nodes[2].Text = []byte("`y`")
+	buf.Reset()
+	if _, err := root.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want = "a = (2 + 3);\n// keep\nf(xs, `y`);\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func collect(n *CSTNode, rule string, nodes *[]*CSTNode) {
+	if n.Rule == rule {
+		*nodes = append(*nodes, n)
+	}
+	for _, c := range n.Children {
+		collect(c, rule, nodes)
+	}
+}
@@ -0,0 +1,61 @@
+package macros
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMacros(t *testing.T) {
+	for _, r := range g.rules {
+		if r.name == "Name" || r.name == "Keyword" {
+			t.Errorf("want macro %s to be inlined, got a rule", r.name)
+		}
+	}
+
+	cases := []struct {
+		in   string
+		want any
+	}{
+		{in: "", want: []any(nil)},
+		{in: "a = b;", want: []any{[]any{"assign", "a", "b"}}},
+		{in: "var x;\nf();\ng(x);\nx = vars;", want: []any{
+			[]any{"decl", "x"},
+			[]any{"call", "f", nil},
+			[]any{"call", "g", "x"},
+			[]any{"assign", "x", "vars"},
+		}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestMacrosEquivalentRule(t *testing.T) {
+	inputs := []string{
+		"",
+		"a = b;",
+		"var x;\nf();\ng(x);\nx = vars;",
+		"var = x;",
+		"f(var);",
+		"a = ;",
+		"var x",
+	}
+	for _, in := range inputs {
+		got, err := Parse("", []byte(in))
+		want, wantErr := Parse("", []byte(in), Entrypoint("RuleInput"))
+		if (err == nil) != (wantErr == nil) || (err != nil && err.Error() != wantErr.Error()) {
+			t.Errorf("%q: want error %v, got %v", in, wantErr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package matcherinterface
+
+import (
+	"strconv"
+	"testing"
+)
+
+// intMatcher is a custom expression that matches a decimal integer and
+// returns its int value.
+type intMatcher struct{}
+
+var _ matcher = (*intMatcher)(nil)
+
+func (m *intMatcher) match(p *parser) (any, bool) {
+	start := p.pt
+	for p.pt.rn >= '0' && p.pt.rn <= '9' {
+		p.read()
+	}
+	if p.pt.offset == start.offset {
+		p.failAt(false, start.position, "integer")
+		return nil, false
+	}
+	n, err := strconv.Atoi(string(p.sliceFrom(start)))
+	if err != nil {
+		p.addErrAt(err, start.position, nil)
+	}
+	return n, true
+}
+
+func TestCustomMatcher(t *testing.T) {
+	var num *rule
+	for _, r := range g.rules {
+		if r.name == "Num" {
+			num = r
+		}
+	}
+	old := num.expr
+	num.expr = &intMatcher{}
+	defer func() { num.expr = old }()
+
+	got, err := Parse("", []byte("1+22+333"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 356 {
+		t.Errorf("want 356, got %v", got)
+	}
+
+	_, err = Parse("", []byte("1+"))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if want := `1:3 (2): no match found, expected: integer`; err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err)
+	}
+}
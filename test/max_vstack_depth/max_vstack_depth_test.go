@@ -0,0 +1,53 @@
+package maxvstackdepth
+
+import (
+	"strings"
+	"testing"
+)
+
+func nested(depth int) string {
+	return strings.Repeat("(", depth) + "x" + strings.Repeat(")", depth)
+}
+
+func TestMaxVStackDepth(t *testing.T) {
+	cases := []struct {
+		in    string
+		limit int
+		err   bool
+	}{
+		{in: nested(0), limit: 10},
+		{in: nested(2), limit: 100},
+		{in: nested(1000), limit: 0},
+		{in: nested(1000), limit: 100, err: true},
+		{in: nested(10), limit: 10, err: true},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in), MaxVStackDepth(tc.limit))
+		if !tc.err {
+			if err != nil {
+				t.Errorf("%d: want no error, got %v", len(tc.in), err)
+			} else if got != "x" {
+				t.Errorf("%d: want x, got %v", len(tc.in), got)
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Errorf("%d: want error, got none", len(tc.in))
+			continue
+		}
+		var found bool
+		for _, err := range err.(errList) {
+			if err.(*parserError).Inner == errMaxVStackDepth {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%d: want errMaxVStackDepth, got %v", len(tc.in), err)
+		}
+		if !strings.Contains(err.Error(), "max depth of the value stack exceeded") {
+			t.Errorf("%d: want clear error message, got %v", len(tc.in), err)
+		}
+	}
+}
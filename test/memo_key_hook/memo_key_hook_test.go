@@ -0,0 +1,36 @@
+package memokeyhook
+
+import "testing"
+
+func modeKey(c *current) any {
+	return c.state["mode"]
+}
+
+func TestMemoKeyHook(t *testing.T) {
+	cases := []struct {
+		in   string
+		opts []Option
+		want string
+	}{
+		{in: "!abc;", want: "ABC"},
+		{in: "!abc", want: "abc"},
+		{in: "!abc;", opts: []Option{Memoize(true), MemoKey(modeKey)}, want: "ABC"},
+		// Word is parsed at the same position in both modes, and each
+		// mode has its own memoized result.
+		{in: "!abc", opts: []Option{Memoize(true), MemoKey(modeKey)}, want: "abc"},
+		// without the discriminator, the result memoized in upper mode is
+		// wrongly reused in lower mode.
+		{in: "!abc", opts: []Option{Memoize(true)}, want: "ABC"},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in), tc.opts...)
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
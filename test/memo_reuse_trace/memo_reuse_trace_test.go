@@ -0,0 +1,41 @@
+package memoreusetrace
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMemoReuses(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []MemoReuse
+	}{
+		{"f();", nil},
+		{"f()!", []MemoReuse{{Rule: "Call", Line: 1, Col: 1, Offset: 0}}},
+		{"f?", []MemoReuse{
+			{Rule: "Call", Line: 1, Col: 1, Offset: 0},
+			{Rule: "Ident", Line: 1, Col: 1, Offset: 0},
+		}},
+		{"f(", []MemoReuse{
+			{Rule: "Call", Line: 1, Col: 1, Offset: 0},
+			{Rule: "Ident", Line: 1, Col: 1, Offset: 0},
+		}},
+	}
+	for _, tc := range cases {
+		got := []MemoReuse{{Rule: "stale"}}
+		_, _ = Parse("", []byte(tc.in), Memoize(true), MemoReuses(&got))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestMemoReusesNoMemoize(t *testing.T) {
+	var got []MemoReuse
+	if _, err := Parse("", []byte("f()!"), MemoReuses(&got)); err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("want no reuse without memoization, got %v", got)
+	}
+}
@@ -0,0 +1,49 @@
+package methodreceiver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMethodReceiver(t *testing.T) {
+	d := &Dict{Words: map[string]bool{"the": true, "cat": true, "sat": true}}
+	got, err := d.Parse("", []byte("the cat  sat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "the cat sat" {
+		t.Errorf("want %q, got %q", "the cat sat", got)
+	}
+	if d.Seen != 3 {
+		t.Errorf("want 3 words seen, got %d", d.Seen)
+	}
+
+	// the words of another receiver are not those of d
+	other := &Dict{Words: map[string]bool{"dog": true}}
+	if _, err := other.ParseReader("", strings.NewReader("the dog")); err == nil {
+		t.Error("want error")
+	}
+	if _, err := d.Parse("", []byte("the dog")); err == nil {
+		t.Error("want error")
+	}
+	if _, err := other.Parse("", []byte("dog dog")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "input")
+	if err := os.WriteFile(file, []byte("cat sat"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	d := &Dict{Words: map[string]bool{"cat": true, "sat": true}}
+	got, err := d.ParseFile(file, Memoize(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "cat sat" || d.Seen != 2 {
+		t.Errorf("want %q and 2 words seen, got %q and %d", "cat sat", got, d.Seen)
+	}
+}
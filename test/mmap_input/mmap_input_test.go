@@ -0,0 +1,68 @@
+//go:build unix
+
+package mmapinput
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMmap(t *testing.T) {
+	cases := []string{
+		"a = 1\nb=2\n",
+		"",
+		"a = 1\nb\n",
+	}
+
+	dir := t.TempDir()
+	for i, tc := range cases {
+		filename := filepath.Join(dir, "input")
+		if err := os.WriteFile(filename, []byte(tc), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		want, wantErr := Parse(filename, []byte(tc))
+		got, release, err := ParseMmap(filename)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: want %v, got %v", i, want, got)
+		}
+		if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+			t.Errorf("%d: want error %v, got %v", i, wantErr, err)
+		}
+		if err := release(); err != nil {
+			t.Errorf("%d: release: %v", i, err)
+		}
+	}
+}
+
+func TestParseMmapRawValue(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "input")
+	if err := os.WriteFile(filename, []byte("abc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the value is the []byte of the mapping, valid until it is released
+	got, release, err := ParseMmap(filename, Entrypoint("Raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Parse(filename, []byte("abc"), Entrypoint("Raw"))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if err := release(); err != nil {
+		t.Errorf("release: %v", err)
+	}
+}
+
+func TestParseMmapNotExist(t *testing.T) {
+	_, release, err := ParseMmap(filepath.Join(t.TempDir(), "missing"))
+	if !os.IsNotExist(err) {
+		t.Errorf("want not exist error, got %v", err)
+	}
+	if err := release(); err != nil {
+		t.Errorf("release: %v", err)
+	}
+}
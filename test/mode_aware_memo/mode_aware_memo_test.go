@@ -0,0 +1,46 @@
+package modeawarememo
+
+import "testing"
+
+func TestModeAwareMemo(t *testing.T) {
+	cases := []struct {
+		in   string
+		memo bool
+		want string
+	}{
+		{in: "!abc;", want: "ABC"},
+		{in: "!abc", want: "abc"},
+		{in: "abc.", want: "abc."},
+		{in: "!abc;", memo: true, want: "ABC"},
+		// Word is memoized in upper mode, then the state code block of
+		// Plain changes the mode and Word is parsed again in lower mode.
+		{in: "!abc", memo: true, want: "abc"},
+		{in: "abc.", memo: true, want: "abc."},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in), Memoize(tc.memo))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestModeAwareMemoReuse(t *testing.T) {
+	// the mode does not change in Stmt, the result of Word memoized by its
+	// first alternative is used by the second one.
+	var memoStats, stats Stats
+	if _, err := Parse("", []byte("abc."), Memoize(true), Statistics(&memoStats, "")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("", []byte("abc."), Statistics(&stats, "")); err != nil {
+		t.Fatal(err)
+	}
+	if memoStats.ExprCnt >= stats.ExprCnt {
+		t.Errorf("want fewer expressions parsed with memoization, got %d, without %d", memoStats.ExprCnt, stats.ExprCnt)
+	}
+}
@@ -0,0 +1,31 @@
+package mustparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMustParse(t *testing.T) {
+	cases := map[string][]int{
+		"[]":          {},
+		"[1]":         {1},
+		" [1, 2, 3] ": {1, 2, 3},
+	}
+	for in, want := range cases {
+		got := MustParse([]byte(in))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
+
+func TestMustParsePanics(t *testing.T) {
+	defer func() {
+		want := `MustParse: 1:4 (3): no match found, expected: [ \t\r\n] or [0-9]`
+		if e := recover(); e != want {
+			t.Errorf("want panic %q, got %v", want, e)
+		}
+	}()
+	MustParse([]byte("[1,"))
+	t.Error("want panic, got none")
+}
@@ -0,0 +1,33 @@
+package numerichelpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNumericHelpers(t *testing.T) {
+	cases := map[string][]any{
+		"1":                {1},
+		"-42,7":            {-42, 7},
+		"3.5,0xff,10":      {3.5, uint64(255), 10},
+		"0x10,1.25,-3,0x0": {uint64(16), 1.25, -3, uint64(0)},
+	}
+
+	for tc, exp := range cases {
+		got, err := Parse("", []byte(tc))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("%q: want %#v, got %#v", tc, exp, got)
+		}
+	}
+}
+
+func TestNumericHelpersError(t *testing.T) {
+	_, err := Parse("", []byte("99999999999999999999"))
+	if err == nil {
+		t.Fatal("want out of range error, got none")
+	}
+}
@@ -0,0 +1,20 @@
+package optimizerules
+
+import "testing"
+
+func TestOptimizeRulesExcept(t *testing.T) {
+	want := map[string]bool{"List": true, "Word": true, "EOF": true}
+	for _, r := range g.rules {
+		if r.optimized != want[r.name] {
+			t.Errorf("rule %s: want optimized %t, got %t", r.name, want[r.name], r.optimized)
+		}
+	}
+
+	got, err := Parse("", []byte("1,ab,23"), Debug(true), Memoize(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.([]any)) != 3 {
+		t.Errorf("want 3 values, got %#v", got)
+	}
+}
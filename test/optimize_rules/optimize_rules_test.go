@@ -0,0 +1,87 @@
+package optimizerules
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseOutput parses in and returns the result along with the debug
+// output printed to stdout.
+func parseOutput(t *testing.T, in string, opts ...Option) (any, string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	outc := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		outc <- string(b)
+	}()
+
+	got, err := Parse("", []byte(in), opts...)
+	w.Close()
+	out := <-outc
+	if err != nil {
+		t.Fatalf("%q: want no error, got %v", in, err)
+	}
+	return got, out
+}
+
+func TestOptimizeRulesDebug(t *testing.T) {
+	got, out := parseOutput(t, "1,ab,23", Debug(true))
+	if want := []any{1, "ab", 23}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+
+	// Item and Number are optimized, Word is traced even when called from
+	// Item.
+	for _, nm := range []string{"List", "Word", "EOF"} {
+		if !strings.Contains(out, "parseRule "+nm+" [") {
+			t.Errorf("want trace of rule %s, got:\n%s", nm, out)
+		}
+	}
+	for _, nm := range []string{"Item", "Number"} {
+		if strings.Contains(out, "parseRule "+nm+" [") {
+			t.Errorf("want no trace of rule %s, got:\n%s", nm, out)
+		}
+	}
+	if !strings.Contains(out, ": ab [") || strings.Contains(out, ": 23 [") {
+		t.Errorf("want matches traced only for Word, got:\n%s", out)
+	}
+
+	// the depth of the trace is restored when leaving the rules
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "< ") || !strings.Contains(last, "parseRule List") {
+		t.Errorf("want trace ending with rule List at depth 0, got %q", last)
+	}
+}
+
+func TestOptimizeRulesMemoize(t *testing.T) {
+	p := newParser("", []byte("1,ab,23"), Memoize(true))
+	if _, err := p.parse(g); err != nil {
+		t.Fatal(err)
+	}
+
+	memoized := make(map[string]bool)
+	for _, m := range p.memo {
+		for k := range m {
+			if r, ok := k.(*rule); ok {
+				memoized[r.name] = true
+			}
+		}
+	}
+	for _, r := range g.rules {
+		if memoized[r.name] == r.optimized {
+			t.Errorf("rule %s: want memoized %t, got %t", r.name, !r.optimized, memoized[r.name])
+		}
+	}
+}
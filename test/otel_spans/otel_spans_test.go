@@ -0,0 +1,98 @@
+package otelspans
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// recordedSpan is a span recorded in memory.
+type recordedSpan struct {
+	name   string
+	parent string
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *recordedSpan) RecordError(err error)              { s.err = err }
+func (s *recordedSpan) End()                               { s.ended = true }
+
+type parentKey struct{}
+
+// recorder is a SpanTracer recording its spans in memory.
+type recorder struct {
+	spans []*recordedSpan
+}
+
+func (r *recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(parentKey{}).(string)
+	span := &recordedSpan{name: name, parent: parent, attrs: make(map[string]any)}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, parentKey{}, name), span
+}
+
+func TestSpans(t *testing.T) {
+	var rec recorder
+	ctx := context.WithValue(context.Background(), parentKey{}, "request")
+	ctx = ContextWithTracer(ctx, &rec)
+
+	if _, err := Parse("ok.txt", []byte("1,22,333"), WithContext(ctx)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("bad.txt", []byte("1,"), WithContext(ctx)); err == nil {
+		t.Fatal("want an error")
+	}
+	if len(rec.spans) != 2 {
+		t.Fatalf("want a span per parse, got %d", len(rec.spans))
+	}
+
+	want := []map[string]any{
+		{"parse.filename": "ok.txt", "parse.entrypoint": "List", "parse.input_size": 8, "parse.error": false},
+		{"parse.filename": "bad.txt", "parse.entrypoint": "List", "parse.input_size": 2, "parse.error": true},
+	}
+	for i, span := range rec.spans {
+		if span.name != "parse" || span.parent != "request" || !span.ended {
+			t.Errorf("%d: want an ended parse span in the request span, got %q in %q, ended: %t", i, span.name, span.parent, span.ended)
+		}
+		if !reflect.DeepEqual(span.attrs, want[i]) {
+			t.Errorf("%d: want attributes %v, got %v", i, want[i], span.attrs)
+		}
+		if (span.err != nil) != (i == 1) {
+			t.Errorf("%d: unexpected recorded error %v", i, span.err)
+		}
+	}
+}
+
+func TestSpanPanic(t *testing.T) {
+	var rec recorder
+	ctx := ContextWithTracer(context.Background(), &rec)
+
+	func() {
+		defer func() {
+			if e := recover(); e != errMaxExprCnt {
+				t.Errorf("want panic %v, got %v", errMaxExprCnt, e)
+			}
+		}()
+		_, _ = Parse("", []byte("1,22"), WithContext(ctx), MaxExpressions(1), Recover(false))
+	}()
+
+	if len(rec.spans) != 1 {
+		t.Fatalf("want a span, got %d", len(rec.spans))
+	}
+	span := rec.spans[0]
+	if !span.ended || span.attrs["parse.error"] != true || span.err != errMaxExprCnt {
+		t.Errorf("want an ended failed span with the panic as error, got ended: %t, parse.error: %v, error: %v", span.ended, span.attrs["parse.error"], span.err)
+	}
+}
+
+func TestNoSpans(t *testing.T) {
+	// no span without a context nor without a tracer
+	if _, err := Parse("", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("", []byte("1"), WithContext(context.Background())); err != nil {
+		t.Fatal(err)
+	}
+}
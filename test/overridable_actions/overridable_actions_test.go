@@ -0,0 +1,45 @@
+package overridableactions
+
+import "testing"
+
+func TestOverridableActions(t *testing.T) {
+	double := func(c *current, args ...any) (any, error) {
+		v, err := actionFuncs["onTerm1"](c, args...)
+		if err != nil {
+			return nil, err
+		}
+		return 2 * v.(int), nil
+	}
+
+	cases := []struct {
+		in   string
+		opts []Option
+		want int
+	}{
+		{in: "1+2+3", want: 6},
+		{in: "1+2+3", opts: []Option{SetAction("onTerm1", double)}, want: 12},
+		{in: "1+2+3", opts: []Option{SetAction("onTerm1", double), SetAction("onTerm1", nil)}, want: 6},
+		{in: "1+2+3", opts: []Option{SetAction("onSum1", func(c *current, args ...any) (any, error) {
+			return len(args[1].([]any)), nil
+		})}, want: 2},
+	}
+
+	for i, tc := range cases {
+		got, err := Parse("", []byte(tc.in), tc.opts...)
+		if err != nil {
+			t.Errorf("%d: want no error, got %v", i, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%d: want %d, got %v", i, tc.want, got)
+		}
+	}
+
+	// the generated implementations are not modified by SetAction
+	if _, err := Parse("", []byte("1"), SetAction("onTerm1", double)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Parse("", []byte("1")); got != 1 {
+		t.Errorf("want 1, got %v", got)
+	}
+}
@@ -0,0 +1,36 @@
+package parameterizedrules
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParameterizedRules(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+		err  string
+	}{
+		{in: "a;1", want: []any{[]any{"a"}, []any{1}}},
+		{in: "a, bc , d ; 1 | 23|4", want: []any{[]any{"a", "bc", "d"}, []any{1, 23, 4}}},
+		{in: "a|b;1", err: `1:2 (1): no match found, expected: ",", ";", [ \t] or [a-z]`},
+		{in: "a;1,2", err: `1:4 (3): no match found, expected: "|", [ \t], [0-9] or EOF`},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%q: want error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Package parsertest compares the parsers generated from the same grammar
+// with different options, which must return the same values and errors.
+package parsertest
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ParseFunc parses input, with memoization if memo is set and if the
+// parser supports it.
+type ParseFunc func(input []byte, memo bool) (any, error)
+
+// Compare checks that each of parsers returns the same value and error as
+// want for each of inputs, with and without memoization.
+func Compare(t *testing.T, want ParseFunc, parsers map[string]ParseFunc, inputs []string) {
+	t.Helper()
+	for _, memo := range []bool{false, true} {
+		for _, in := range inputs {
+			wantVal, wantErr := want([]byte(in), memo)
+			for name, parse := range parsers {
+				got, err := parse([]byte(in), memo)
+				if !reflect.DeepEqual(got, wantVal) {
+					t.Errorf("%s: %q, memoize %t: want %v, got %v", name, in, memo, wantVal, got)
+				}
+				if (err == nil) != (wantErr == nil) || err != nil && err.Error() != wantErr.Error() {
+					t.Errorf("%s: %q, memoize %t: want error %v, got %v", name, in, memo, wantErr, err)
+				}
+			}
+		}
+	}
+}
+
+// Benchmark runs a sub-benchmark parsing input for each of the parsers
+// named by names, without memoization.
+func Benchmark(b *testing.B, parsers map[string]ParseFunc, names []string, input []byte) {
+	for _, name := range names {
+		parse := parsers[name]
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i++ {
+				if _, err := parse(input, false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package prefixdispatch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrefixDispatch(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+	}{
+		{"1, 2", []any{1, 2}},
+		{"v1: 1, 2", map[string]any{"v1": []any{1, 2}}},
+		{"v2: a b", map[string]any{"v2": []any{"a", "b"}}},
+		// the longest prefix wins
+		{"v2.1: a b", map[string]any{"v2.1": []any{"a", "b"}}},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %#v, got %#v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestPrefixDispatchNoMatch(t *testing.T) {
+	// no prefix matches, the default entrypoint fails on the header
+	_, err := Parse("", []byte("v3: a"))
+	want := `1:1 (0): no match found, expected: [0-9]`
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+
+	// the prefix selects the rule, which reports its own errors
+	_, err = Parse("", []byte("v1: a"))
+	want = `1:5 (4): no match found, expected: [ \t] or [0-9]`
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
+
+func TestPrefixDispatchEntrypoint(t *testing.T) {
+	// an explicit entrypoint takes precedence over the prefix
+	got, err := Parse("", []byte("v1: a"), Entrypoint("DocV2"))
+	if err == nil {
+		t.Fatalf("want error, got %#v", got)
+	}
+	got, err = Parse("", []byte("v2: a"), Entrypoint("DocV2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"v2": []any{"a"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
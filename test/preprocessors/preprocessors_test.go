@@ -0,0 +1,108 @@
+package preprocessors
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// remove returns a preprocessing stage that removes the input for which
+// skip returns the number of bytes to remove at an offset.
+func remove(skip func(in []byte, i int) int) Preprocessor {
+	return func(in []byte) ([]byte, PositionMap) {
+		var out []byte
+		var offs []int
+		for i := 0; i < len(in); {
+			if n := skip(in, i); n > 0 {
+				i += n
+				continue
+			}
+			out = append(out, in[i])
+			offs = append(offs, i)
+			i++
+		}
+		offs = append(offs, len(in))
+		return out, func(off int) int { return offs[off] }
+	}
+}
+
+// stripComments removes the comments, from # to the end of the line.
+var stripComments = remove(func(in []byte, i int) int {
+	if in[i] != '#' {
+		return 0
+	}
+	if n := strings.IndexByte(string(in[i:]), '\n'); n >= 0 {
+		return n
+	}
+	return len(in) - i
+})
+
+// joinLines joins the lines that end with a backslash with the next one.
+var joinLines = remove(func(in []byte, i int) int {
+	if strings.HasPrefix(string(in[i:]), "\\\n") {
+		return 2
+	}
+	return 0
+})
+
+// tabsToSpaces is a stage that does not move the text.
+func tabsToSpaces(in []byte) ([]byte, PositionMap) {
+	return []byte(strings.ReplaceAll(string(in), "\t", " ")), nil
+}
+
+func TestPreprocess(t *testing.T) {
+	in := "# numbers\nlet x = 1; # one\nlet y = \\\n2;"
+	got, err := Parse("", []byte(in), Preprocess(stripComments, joinLines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{[]any{"x", 1}, []any{"y", 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	cases := []struct {
+		in    string
+		err   string
+		spans [][2]int
+	}{
+		// the missing semicolon, before a comment
+		{"# numbers\nlet x = 1 # one\nlet y = 2;", "3:1 (26): no match found, expected: \";\" or [ \\t\\r\\n]", [][2]int{{10, 27}}},
+		// the error of a code block, after a joined line
+		{"# numbers\nlet x = \\\n# comment\n  1234;", "4:3 (32): rule Number: number too large", [][2]int{{32, 36}}},
+		// at the end of the input, after a comment
+		{"let x = 1; let # x", "1:19 (18): no match found, expected: [ \\t\\r\\n] or [a-z]", [][2]int{{11, 18}}},
+	}
+	for _, tc := range cases {
+		_, err := Parse("", []byte(tc.in), Preprocess(stripComments, joinLines))
+		if err == nil {
+			t.Errorf("%q: want error, got none", tc.in)
+			continue
+		}
+		if err.Error() != tc.err {
+			t.Errorf("%q: want error %q, got %q", tc.in, tc.err, err)
+		}
+		list := err.(errList)
+		if len(list) != len(tc.spans) {
+			t.Errorf("%q: want %d errors, got %d: %v", tc.in, len(tc.spans), len(list), err)
+			continue
+		}
+		for i, e := range list {
+			var got [2]int
+			got[0], got[1] = e.(interface{ Span() (int, int) }).Span()
+			if got != tc.spans[i] {
+				t.Errorf("%q: %d: want span %v, got %v (%q)", tc.in, i, tc.spans[i], got, tc.in[got[0]:got[1]])
+			}
+		}
+	}
+}
+
+func TestPreprocessNilMap(t *testing.T) {
+	// the stage with a nil map does not change the reported positions
+	_, err := Parse("", []byte("let x = 1;\n# let\nlet\ty = 1"), Preprocess(tabsToSpaces, stripComments))
+	if want := "3:10 (26): no match found, expected: \";\", [ \\t\\r\\n] or [0-9]"; err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
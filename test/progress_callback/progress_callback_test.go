@@ -0,0 +1,54 @@
+package progresscallback
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	input := strings.Repeat("lorem ipsum 123 dolor sit amet\n", 10000)
+
+	const every = 4096
+	var offsets []int
+	fn := func(offset, total int) {
+		if total != len(input) {
+			t.Fatalf("want total %d, got %d", len(input), total)
+		}
+		offsets = append(offsets, offset)
+	}
+	if _, err := Parse("", []byte(input), Progress(every, fn)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(offsets) < len(input)/every {
+		t.Fatalf("want at least %d calls, got %d", len(input)/every, len(offsets))
+	}
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i]-offsets[i-1] < every && i < len(offsets)-1 {
+			t.Errorf("%d: want offsets at least %d bytes apart, got %d and %d", i, every, offsets[i-1], offsets[i])
+		}
+		if offsets[i] <= offsets[i-1] {
+			t.Errorf("%d: want increasing offsets, got %d and %d", i, offsets[i-1], offsets[i])
+		}
+	}
+	if got := offsets[len(offsets)-1]; got != len(input) {
+		t.Errorf("want last offset %d, got %d", len(input), got)
+	}
+}
+
+func TestProgressFailure(t *testing.T) {
+	var calls int
+	_, err := Parse("", []byte("lorem ipsum\n!"), Progress(0, func(offset, total int) { calls++ }))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if calls == 0 {
+		t.Error("want calls to the progress function, got none")
+	}
+}
+
+func TestNoProgress(t *testing.T) {
+	if _, err := Parse("", []byte("lorem ipsum\n")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,90 @@
+package protoresults
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	cases := []string{
+		"",
+		"a = 1",
+		"a = 1, bc = \"x y\", d =",
+		" a=\"\" ,b=22\n",
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc))
+		if err != nil {
+			t.Fatalf("%q: %v", tc, err)
+		}
+		m, err := ToProto(got)
+		if err != nil {
+			t.Fatalf("%q: %v", tc, err)
+		}
+		b, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%q: %v", tc, err)
+		}
+		var um Node
+		if err := um.UnmarshalBinary(b); err != nil {
+			t.Fatalf("%q: %v", tc, err)
+		}
+		if !reflect.DeepEqual(&um, m) {
+			t.Errorf("%q: want message %v, got %v", tc, m, &um)
+		}
+		if back := FromProto(&um); !reflect.DeepEqual(back, got) {
+			t.Errorf("%q: want result %#v, got %#v", tc, got, back)
+		}
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	m, err := ToProto([]any{[]byte("a"), nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// list (2) of the items (1) text (1) "a" and an empty message
+	want := []byte{0x12, 0x07, 0x0a, 0x03, 0x0a, 0x01, 'a', 0x0a, 0x00}
+	if !bytes.Equal(got, want) {
+		t.Errorf("want % x, got % x", want, got)
+	}
+
+	// the unknown fields are skipped
+	var um Node
+	data := append([]byte{0x18, 0x96, 0x01, 0x25, 1, 2, 3, 4}, want...)
+	if err := um.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&um, m) {
+		t.Errorf("want message %v, got %v", m, &um)
+	}
+
+	if err := um.UnmarshalBinary([]byte{0x12, 0x07, 0x0a}); err == nil {
+		t.Errorf("want error for truncated message")
+	}
+}
+
+func TestToProtoError(t *testing.T) {
+	if _, err := ToProto([]any{[]byte("a"), 1}); err == nil || !strings.Contains(err.Error(), "item 1: unsupported result type int") {
+		t.Errorf("want unsupported result type error, got %v", err)
+	}
+}
+
+func TestProtoFile(t *testing.T) {
+	b, err := os.ReadFile("proto_results.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package protoresults;", "message Node {", "NodeList list = 2;", "repeated Node items = 1;"} {
+		if !bytes.Contains(b, []byte(want)) {
+			t.Errorf("want %q in the .proto file", want)
+		}
+	}
+}
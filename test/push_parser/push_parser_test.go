@@ -0,0 +1,81 @@
+package pushparser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFeedChunks(t *testing.T) {
+	in := []byte("a = 1;\n  été=22 ;b=333;\n\nzz =4 ;")
+	want := []any{item{"a", "1"}, item{"été", "22"}, item{"b", "333"}, item{"zz", "4"}}
+
+	pp := NewPushParser()
+	for size := 1; size <= len(in); size++ {
+		for i := 0; i < len(in); i += size {
+			end := i + size
+			if end > len(in) {
+				end = len(in)
+			}
+			pp.Feed(in[i:end])
+		}
+		got, err := pp.Flush()
+		if err != nil {
+			t.Fatalf("chunks of %d bytes: %v", size, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("chunks of %d bytes: want %v, got %v", size, want, got)
+		}
+	}
+}
+
+func TestFeedItemsWhenComplete(t *testing.T) {
+	pp := NewPushParser()
+	pp.Feed([]byte("a=1;b=2"))
+	if len(pp.results) != 1 {
+		t.Errorf("want the first item parsed, got %v", pp.results)
+	}
+	pp.Feed([]byte("2;c"))
+	if len(pp.results) != 2 {
+		t.Errorf("want the second item parsed, got %v", pp.results)
+	}
+	got, err := pp.Flush()
+	if err == nil {
+		t.Fatalf("want an error for the incomplete item, got %v", got)
+	}
+	if want := []any{item{"a", "1"}, item{"b", "22"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestFeedErrorPosition(t *testing.T) {
+	pp := NewPushParser()
+	for _, chunk := range []string{"a=1;\n", "b=x;", "c=3;"} {
+		pp.Feed([]byte(chunk))
+	}
+	got, err := pp.Flush()
+	if want := []any{item{"a", "1"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	var el errList
+	if !errors.As(err, &el) || len(el) != 1 {
+		t.Fatalf("want a single error, got %v", err)
+	}
+	var pe *parserError
+	if !errors.As(el[0], &pe) {
+		t.Fatalf("want a parser error, got %T", el[0])
+	}
+	if pe.pos.line != 2 || pe.pos.col != 3 || pe.pos.offset != 7 {
+		t.Errorf("want the error at 2:3 [7], got %s", pe.pos)
+	}
+
+	// the parser starts over after Flush
+	pp.Feed([]byte("d=4;"))
+	got, err = pp.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []any{item{"d", "4"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,68 @@
+package replmode
+
+import "testing"
+
+func TestREPL(t *testing.T) {
+	type step struct {
+		line   string
+		status REPLStatus
+		val    any
+	}
+	cases := []struct {
+		name  string
+		steps []step
+	}{
+		{"single line", []step{
+			{"let a = 1;\n", REPLComplete, "a=1"},
+		}},
+		{"multi lines", []step{
+			{"let x =\n", REPLIncomplete, nil},
+			{"  (1,\n", REPLIncomplete, nil},
+			{"   (b, c),\n", REPLIncomplete, nil},
+			{"   2)\n", REPLIncomplete, nil},
+			{";\n", REPLComplete, "x=(1,(b,c),2)"},
+			{"let y = 3;", REPLComplete, "y=3"},
+		}},
+		{"empty lines", []step{
+			{"\n", REPLIncomplete, nil},
+			{"\n", REPLIncomplete, nil},
+			{"let z = z;\n", REPLComplete, "z=z"},
+		}},
+		{"error", []step{
+			{"let a = (1,\n", REPLIncomplete, nil},
+			{"2 3)\n", REPLError, nil},
+			{"let b = 2;\n", REPLComplete, "b=2"},
+		}},
+		{"trailing input", []step{
+			{"let a = 1; 2\n", REPLError, nil},
+			{"let a = 1\n", REPLIncomplete, nil},
+			{";\n", REPLComplete, "a=1"},
+		}},
+	}
+	for _, tc := range cases {
+		r := NewREPL()
+		for i, st := range tc.steps {
+			val, status, err := r.Feed([]byte(st.line))
+			if status != st.status {
+				t.Errorf("%s: %d: want status %d, got %d (%v)", tc.name, i, st.status, status, err)
+			}
+			if (err == nil) != (status == REPLComplete) {
+				t.Errorf("%s: %d: unexpected error %v with status %d", tc.name, i, err, status)
+			}
+			if val != st.val {
+				t.Errorf("%s: %d: want %v, got %v", tc.name, i, st.val, val)
+			}
+		}
+	}
+}
+
+func TestREPLReset(t *testing.T) {
+	r := NewREPL()
+	if _, status, _ := r.Feed([]byte("let a = (")); status != REPLIncomplete {
+		t.Fatalf("want incomplete, got %d", status)
+	}
+	r.Reset()
+	if val, status, err := r.Feed([]byte("let b = 1;")); status != REPLComplete || val != "b=1" {
+		t.Errorf("want complete b=1, got %d %v (%v)", status, val, err)
+	}
+}
@@ -0,0 +1,45 @@
+package reportmaxdepth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReachedDepth(t *testing.T) {
+	for _, memo := range []bool{false, true} {
+		for _, nesting := range []int{0, 1, 5, 40} {
+			in := strings.Repeat("(", nesting) + "x" + strings.Repeat(")", nesting)
+			var d Depth
+			if _, err := Parse("", []byte(in), ReachedDepth(&d), Memoize(memo)); err != nil {
+				t.Fatalf("%d: %v", nesting, err)
+			}
+			// File, then an Expr per parenthesis and the innermost Expr
+			if want := nesting + 2; d.Rules != want {
+				t.Errorf("%d: memoize %t: want rules depth %d, got %d", nesting, memo, want, d.Rules)
+			}
+
+			// the reached depth is the smallest limit of the value stack that
+			// allows to parse the input
+			if _, err := Parse("", []byte(in), MaxVStackDepth(d.VStack), Memoize(memo)); err != nil {
+				t.Errorf("%d: memoize %t: limit %d: %v", nesting, memo, d.VStack, err)
+			}
+			_, err := Parse("", []byte(in), MaxVStackDepth(d.VStack-1), Memoize(memo))
+			var el errList
+			if !errors.As(err, &el) || !errors.Is(el[0].(*parserError).Inner, errMaxVStackDepth) {
+				t.Errorf("%d: memoize %t: limit %d: want max depth error, got %v", nesting, memo, d.VStack-1, err)
+			}
+		}
+	}
+}
+
+func TestReachedDepthAccumulates(t *testing.T) {
+	var d Depth
+	for _, in := range []string{"((a))", "b", "(((c)"} {
+		_, _ = Parse("", []byte(in), ReachedDepth(&d))
+	}
+	// the failed input reached the deepest nesting
+	if d.Rules != 5 {
+		t.Errorf("want rules depth 5, got %d", d.Rules)
+	}
+}
@@ -0,0 +1,77 @@
+package reprinter
+
+import (
+	"bytes"
+	"testing"
+)
+
+var input = []byte(`// settings
+a = 1;
+b  =  // the default
+	-2 ;
+c=d;
+`)
+
+func TestReprintAST(t *testing.T) {
+	got, err := Parse("", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a = 1;", "b  =  // the default\n\t-2 ;", "c=d;"}
+	assigns := got.([]*Assign)
+	if len(assigns) != len(want) {
+		t.Fatalf("want %d assignments, got %d", len(want), len(assigns))
+	}
+	for i, a := range assigns {
+		if got := string(Reprint(a, input)); got != want[i] {
+			t.Errorf("%s: want %q, got %q", a.Name, want[i], got)
+		}
+	}
+
+	// rewrite the value of b only
+	b := assigns[1]
+	var buf bytes.Buffer
+	buf.Write(input[:b.start])
+	buf.WriteString("b = 3;")
+	buf.Write(input[b.end:])
+	if want := "// settings\na = 1;\nb = 3;\nc=d;\n"; buf.String() != want {
+		t.Errorf("want rewritten input %q, got %q", want, buf.String())
+	}
+}
+
+func TestReprintCST(t *testing.T) {
+	root, err := ParseCST("", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Reprint(root, input); !bytes.Equal(got, input) {
+		t.Errorf("want the whole input, got %q", got)
+	}
+
+	var assigns []*CSTNode
+	var walk func(n *CSTNode)
+	walk = func(n *CSTNode) {
+		if n.Rule == "Assign" {
+			assigns = append(assigns, n)
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+	if len(assigns) != 3 {
+		t.Fatalf("want 3 assignments, got %d", len(assigns))
+	}
+	got := Reprint(assigns[1], input)
+	if want := "b  =  // the default\n\t-2 ;"; string(got) != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	start, end := assigns[1].Span()
+	if !bytes.Equal(got, input[start:end]) {
+		t.Errorf("want the bytes of the span %d-%d, got %q", start, end, got)
+	}
+
+	if got := Reprint(&CSTNode{Offset: len(input), Text: []byte("x")}, input); got != nil {
+		t.Errorf("want nil for a span out of the input, got %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package resultdiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func parse(t *testing.T, in string) any {
+	t.Helper()
+	v, err := Parse("", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestDiffResultsEqual(t *testing.T) {
+	a, b := parse(t, "a = 1\nb = c"), parse(t, "a=1 b=c")
+	if diffs := DiffResults(a, b); diffs != nil {
+		t.Errorf("want no diff, got %v", diffs)
+	}
+}
+
+func TestDiffResultsLeaf(t *testing.T) {
+	a, b := parse(t, "a = 1\nb = 2"), parse(t, "a = 1\nb = 3")
+	want := []Diff{{Path: ".Children[1].Children[1].Text", Kind: "value", A: "2", B: "3"}}
+	if diffs := DiffResults(a, b); !reflect.DeepEqual(diffs, want) {
+		t.Errorf("want %v, got %v", want, diffs)
+	}
+}
+
+func TestDiffResultsNodes(t *testing.T) {
+	a, b := parse(t, "a = 1\nb = 2"), parse(t, "a = x")
+	want := []Diff{
+		{Path: ".Children", Kind: "length", A: a.(*Node).Children, B: b.(*Node).Children},
+		{Path: ".Children[0].Children[1].Type", Kind: "value", A: "number", B: "ident"},
+		{Path: ".Children[0].Children[1].Text", Kind: "value", A: "1", B: "x"},
+	}
+	if diffs := DiffResults(a, b); !reflect.DeepEqual(diffs, want) {
+		t.Errorf("want %v, got %v", want, diffs)
+	}
+}
+
+func TestDiffResultsValues(t *testing.T) {
+	a := []any{1, "a", map[string]int{"x": 1, "y": 2}, []byte("t"), nil}
+	b := []any{1.0, "a", map[string]int{"x": 1, "z": 2}, []byte("u"), 2}
+	want := []Diff{
+		{Path: "[0]", Kind: "type", A: 1, B: 1.0},
+		{Path: `[2]["y"]`, Kind: "missing", A: 2},
+		{Path: `[2]["z"]`, Kind: "missing", B: 2},
+		{Path: "[3]", Kind: "value", A: []byte("t"), B: []byte("u")},
+		{Path: "[4]", Kind: "type", B: 2},
+	}
+	diffs := DiffResults(a, b)
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("want %v, got %v", want, diffs)
+	}
+	if got, want := diffs[0].String(), "[0]: type differs: int != float64"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,40 @@
+package ruleassertions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRuleAssertions(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+		err  string
+	}{
+		{"", []int{}, ""},
+		{"1 2 3", []int{1, 2, 3}, ""},
+		{"1 -2", []int{1, -2}, "1:3 (2): rule Int: assertion v.(int) >= 0 failed for value -2"},
+		{"1 2 3 4", []int{1, 2, 3, 4}, "1:1 (0): rule List: assertion len(v.([]int)) <= 3 failed for value []int{1, 2, 3, 4}"},
+		{
+			"-1 2 3 4",
+			[]int{-1, 2, 3, 4},
+			"1:1 (0): rule Int: assertion v.(int) >= 0 failed for value -1\n" +
+				"1:1 (0): rule List: assertion len(v.([]int)) <= 3 failed for value []int{-1, 2, 3, 4}",
+		},
+	}
+	for _, tc := range cases {
+		for _, memo := range []bool{false, true} {
+			got, err := Parse("", []byte(tc.in), Memoize(memo))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+			}
+			var msg string
+			if err != nil {
+				msg = err.Error()
+			}
+			if msg != tc.err {
+				t.Errorf("%q: want error %q, got %q", tc.in, tc.err, msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package ruleboundarypositions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRulePositions(t *testing.T) {
+	want := []RulePosition{
+		{Rule: "Name", Line: 1, Col: 1, Offset: 0, EndLine: 1, EndCol: 3, EndOffset: 2},
+		{Rule: "Item", Line: 1, Col: 1, Offset: 0, EndLine: 1, EndCol: 3, EndOffset: 2},
+		{Rule: "_", Line: 1, Col: 3, Offset: 2, EndLine: 1, EndCol: 3, EndOffset: 2},
+		{Rule: "_", Line: 1, Col: 4, Offset: 3, EndLine: 1, EndCol: 5, EndOffset: 4},
+		{Rule: "Name", Line: 1, Col: 5, Offset: 4, EndLine: 1, EndCol: 7, EndOffset: 6},
+		{Rule: "Call", Line: 1, Col: 5, Offset: 4, EndLine: 2, EndCol: 0, EndOffset: 8},
+		{Rule: "Item", Line: 1, Col: 5, Offset: 4, EndLine: 2, EndCol: 0, EndOffset: 8},
+		{Rule: "_", Line: 2, Col: 0, Offset: 8, EndLine: 2, EndCol: 1, EndOffset: 9},
+		{Rule: "_", Line: 2, Col: 2, Offset: 10, EndLine: 2, EndCol: 3, EndOffset: 11},
+		{Rule: "Name", Line: 2, Col: 3, Offset: 11, EndLine: 2, EndCol: 4, EndOffset: 12},
+		{Rule: "Item", Line: 2, Col: 3, Offset: 11, EndLine: 2, EndCol: 4, EndOffset: 12},
+		{Rule: "EOF", Line: 2, Col: 4, Offset: 12, EndLine: 2, EndCol: 4, EndOffset: 12},
+		{Rule: "List", Line: 1, Col: 1, Offset: 0, EndLine: 2, EndCol: 4, EndOffset: 12},
+	}
+	input := []byte("ab, cd()\n, e")
+
+	for _, memoize := range []bool{false, true} {
+		var got []RulePosition
+		val, err := Parse("", input, RulePositions(&got), Memoize(memoize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []any{"ab", "cd()", "e"}; !reflect.DeepEqual(val, want) {
+			t.Errorf("memoize %t: want value %v, got %v", memoize, want, val)
+		}
+		// only the rules are recorded, not their literals and character
+		// classes, nor the rules matched by the alternatives that failed
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("memoize %t: want\n%+v\ngot\n%+v", memoize, want, got)
+		}
+	}
+}
+
+func TestRulePositionsError(t *testing.T) {
+	got := []RulePosition{{Rule: "x"}}
+	if _, err := Parse("", []byte("ab,"), RulePositions(&got)); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if want := []RulePosition{{Rule: "x"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
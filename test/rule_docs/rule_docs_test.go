@@ -0,0 +1,19 @@
+package ruledocs
+
+import "testing"
+
+func TestRuleDocs(t *testing.T) {
+	cases := []struct {
+		name, want string
+	}{
+		{"List", "List is a comma-separated list of numbers.\n\nThe list may be empty."},
+		{"Number", "Number is a decimal number."},
+		{"EOF", ""},
+		{"None", ""},
+	}
+	for _, tc := range cases {
+		if got := RuleDoc(tc.name); got != tc.want {
+			t.Errorf("%s: want %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
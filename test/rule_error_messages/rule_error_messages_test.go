@@ -0,0 +1,43 @@
+package ruleerrormessages
+
+import "testing"
+
+var cases = map[string]string{
+	"":              "",
+	"a = 1;":        "",
+	"a = 1; b = 2;": "",
+	"1":             "1:1 (0): expected a statement",
+	"a = ;":         "1:5 (4): expected a number",
+	"a = 1":         "1:6 (5): expected a statement",
+	"a = 1; b":      "1:9 (8): expected a statement",
+}
+
+func TestRuleErrorMessages(t *testing.T) {
+	for _, memo := range []bool{false, true} {
+		for tc, exp := range cases {
+			_, err := Parse("", []byte(tc), Memoize(memo))
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if got != exp {
+				t.Errorf("%q, memoize %t: want %v, got %v", tc, memo, exp, got)
+			}
+		}
+	}
+}
+
+func TestRuleErrorMessagesMemoized(t *testing.T) {
+	// the failure of Number is memoized in the not predicate
+	exp := "1:1 (0): expected a number"
+	for _, memo := range []bool{false, true} {
+		_, err := Parse("", []byte("="), Entrypoint("Key"), Memoize(memo))
+		var got string
+		if err != nil {
+			got = err.Error()
+		}
+		if got != exp {
+			t.Errorf("memoize %t: want %v, got %v", memo, exp, got)
+		}
+	}
+}
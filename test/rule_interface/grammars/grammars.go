@@ -0,0 +1,10 @@
+// Package grammars defines the interface implemented by the rules of the
+// rule_interface test grammar.
+package grammars
+
+// Rule is implemented by the generated rule type.
+type Rule interface {
+	Name() string
+	DisplayName() string
+	Position() (line, col, offset int)
+}
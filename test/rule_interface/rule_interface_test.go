@@ -0,0 +1,39 @@
+package ruleinterface
+
+import (
+	"testing"
+
+	"github.com/mna/pigeon/test/rule_interface/grammars"
+)
+
+func TestRules(t *testing.T) {
+	cases := []struct {
+		name, display string
+		line          int
+	}{
+		{"List", "List", 5},
+		{"Item", `"item"`, 7},
+		{"EOF", "EOF", 9},
+	}
+
+	var rules []grammars.Rule = Rules()
+	if len(rules) != len(cases) {
+		t.Fatalf("want %d rules, got %d", len(cases), len(rules))
+	}
+	for i, tc := range cases {
+		r := rules[i]
+		if r.Name() != tc.name {
+			t.Errorf("%d: want name %q, got %q", i, tc.name, r.Name())
+		}
+		if r.DisplayName() != tc.display {
+			t.Errorf("%d: want display name %q, got %q", i, tc.display, r.DisplayName())
+		}
+		if line, _, _ := r.Position(); line != tc.line {
+			t.Errorf("%d: want line %d, got %d", i, tc.line, line)
+		}
+	}
+
+	if _, err := Parse("", []byte("a,b")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,48 @@
+package ruletiming
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuleTimes(t *testing.T) {
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = "((abc))"
+	}
+	in := strings.Join(items, ",")
+
+	times := make(map[string]time.Duration)
+	got, err := Parse("", []byte(in), RuleTimes(times))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != len(items) {
+		t.Fatalf("want %d items, got %v", len(items), got)
+	}
+
+	for _, nm := range []string{"List", "Item", "Group", "Word"} {
+		if times[nm] <= 0 {
+			t.Errorf("want time of rule %s > 0, got %s", nm, times[nm])
+		}
+	}
+	// the time of a rule includes the time of the rules it calls
+	if times["List"] < times["Item"] {
+		t.Errorf("want time of List >= time of Item, got %s < %s", times["List"], times["Item"])
+	}
+	// Item calls itself through Group, only its outermost calls are timed
+	if times["Group"] > times["Item"] {
+		t.Errorf("want nested calls not to be counted twice, got %v", times)
+	}
+}
+
+func TestRuleTimesDisabled(t *testing.T) {
+	p := newParser("", []byte("abc"))
+	if _, err := p.parse(g); err != nil {
+		t.Fatal(err)
+	}
+	if p.ruleTimes != nil || p.ruleCalls != nil {
+		t.Errorf("want no timing without the RuleTimes option")
+	}
+}
@@ -0,0 +1,37 @@
+package runcharclass
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/test/parsertest"
+	run "github.com/mna/pigeon/test/run_char_class/run"
+	runbasiclatin "github.com/mna/pigeon/test/run_char_class/run-basic-latin"
+)
+
+var parsers = map[string]parsertest.ParseFunc{
+	"general": func(b []byte, _ bool) (any, error) { return Parse("", b) },
+	"run":     func(b []byte, _ bool) (any, error) { return run.Parse("", b) },
+	"run-basic-latin": func(b []byte, _ bool) (any, error) {
+		return runbasiclatin.Parse("", b)
+	},
+}
+
+func TestRunCharClass(t *testing.T) {
+	cases := []string{
+		"",
+		"abc",
+		"  abc def_1 42 #fF0 \"x y\"\n",
+		"éçà ident2 007",
+		"abc ?",
+		"#",
+		"\"unterminated",
+		"12ab",
+	}
+	parsertest.Compare(t, parsers["general"], parsers, cases)
+}
+
+func BenchmarkRunCharClass(b *testing.B) {
+	input := []byte(strings.Repeat("identifier_name another_identifier x1 12345 #deadbeef ", 200))
+	parsertest.Benchmark(b, parsers, []string{"general", "run", "run-basic-latin"}, input)
+}
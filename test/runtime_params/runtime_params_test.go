@@ -0,0 +1,38 @@
+package runtimeparams
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRuntimeParams(t *testing.T) {
+	cases := []struct {
+		in   string
+		want [][]string
+	}{
+		{"", [][]string{}},
+		{"0", [][]string{{}}},
+		{"2 ab cd 1 ef", [][]string{{"ab", "cd"}, {"ef"}}},
+		{"1 a 0 3 b c d", [][]string{{"a"}, {}, {"b", "c", "d"}}},
+	}
+	for _, tc := range cases {
+		for _, memo := range []bool{false, true} {
+			got, err := Parse("", []byte(tc.in), Memoize(memo))
+			if err != nil {
+				t.Errorf("%q: %v", tc.in, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+			}
+		}
+	}
+}
+
+func TestRuntimeParamsCountMismatch(t *testing.T) {
+	for _, in := range []string{"2 ab", "1 ab cd", "3 a b 1 c"} {
+		if got, err := Parse("", []byte(in)); err == nil {
+			t.Errorf("%q: want error, got %v", in, got)
+		}
+	}
+}
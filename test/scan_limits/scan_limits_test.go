@@ -0,0 +1,71 @@
+package scanlimits
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanLimits(t *testing.T) {
+	long := "<" + strings.Repeat("a", 20) + ">"
+	cases := []struct {
+		in        string
+		want      []any
+		unbounded []any
+	}{
+		{"", nil, nil},
+		{"a<b>c", []any{"a", "tag:<b>", "c"}, nil},
+		{"<" + strings.Repeat("a", 14) + ">", []any{"tag:<" + strings.Repeat("a", 14) + ">"}, nil},
+		{"<" + strings.Repeat("a", 13) + ">b", []any{"tag:<" + strings.Repeat("a", 13) + ">", "b"}, nil},
+		{long, []any{"<", strings.Repeat("a", 20) + ">"}, []any{"tag:" + long}},
+		{"<a" + long, []any{"<", "a", "<", strings.Repeat("a", 20) + ">"}, []any{"tag:<a" + long}},
+		{"<<", []any{"<", "<"}, nil},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+
+		want := tc.unbounded
+		if want == nil {
+			want = tc.want
+		}
+		got, err = Parse("", []byte(tc.in), Entrypoint("UnboundedInput"))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: unbounded: want %v, got %v", tc.in, want, got)
+		}
+	}
+}
+
+func TestScanLimitsFailFast(t *testing.T) {
+	// no tag is closed, so each one is scanned up to the end of the input
+	// without the scan limit
+	in := []byte(strings.Repeat("<", 2000))
+	for _, memo := range []bool{false, true} {
+		got, err := Parse("", in, MaxExpressions(100000), Memoize(memo))
+		if err != nil {
+			t.Fatalf("memoize=%t: want no error, got %v", memo, err)
+		}
+		if n := len(got.([]any)); n != len(in) {
+			t.Errorf("memoize=%t: want %d parts, got %d", memo, len(in), n)
+		}
+	}
+
+	_, err := Parse("", in, MaxExpressions(100000), Entrypoint("UnboundedInput"))
+	errs, ok := err.(errList)
+	if !ok {
+		t.Fatalf("want an error list, got %v", err)
+	}
+	if pe, ok := errs[0].(*parserError); !ok || pe.Inner != errMaxExprCnt {
+		t.Errorf("want max number of expressions error, got %v", errs[0])
+	}
+}
@@ -0,0 +1,39 @@
+package selftest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	if len(selfTestInputs) != 3 {
+		t.Fatalf("want 3 embedded inputs, got %d", len(selfTestInputs))
+	}
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelfTestFailure(t *testing.T) {
+	// the inputs expected to fail parse, and the other way around
+	defer func(inputs []selfTestInput) { selfTestInputs = inputs }(selfTestInputs)
+	inputs := make([]selfTestInput, 0, len(selfTestInputs))
+	for _, in := range selfTestInputs {
+		inputs = append(inputs, selfTestInput{input: in.input, ok: !in.ok})
+	}
+	selfTestInputs = inputs
+
+	err := SelfTest()
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, want := range []string{
+		"3 of 3 inputs failed",
+		`"[1, 2,]\n": want no error, got 1:7 (6)`,
+		`"[]": want an error`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("want error containing %q, got %v", want, err)
+		}
+	}
+}
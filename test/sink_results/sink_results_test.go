@@ -0,0 +1,60 @@
+package sinkresults
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var input = []byte(`
+a = 1
+b = 2
+c = 3
+`)
+
+func TestParseTo(t *testing.T) {
+	var got []any
+	err := ParseTo("", input, func(v any) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{[2]string{"a", "1"}, [2]string{"b", "2"}, [2]string{"c", "3"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestParseToAbort(t *testing.T) {
+	errStop := errors.New("stop")
+	for _, recover := range []bool{true, false} {
+		var got []any
+		err := ParseTo("", input, func(v any) error {
+			got = append(got, v)
+			return errStop
+		}, Recover(recover))
+		if err != errStop {
+			t.Errorf("recover %t: want error %v, got %v", recover, errStop, err)
+		}
+		if len(got) != 1 {
+			t.Errorf("recover %t: want 1 value, got %d", recover, len(got))
+		}
+	}
+}
+
+func TestParseToEntrypointValue(t *testing.T) {
+	var got []any
+	err := ParseTo("", []byte("x = 42"), func(v any) error {
+		got = append(got, v)
+		return nil
+	}, Entrypoint("Record"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{[2]string{"x", "42"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
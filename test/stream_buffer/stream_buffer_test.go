@@ -0,0 +1,123 @@
+package streambuffer
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// buffer is the size of the buffer of the channel of ParseChan, set when
+// the parser is generated.
+const buffer = 2
+
+func records(n int) []byte {
+	var buf strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "k = %d\n", i)
+	}
+	return []byte(buf.String())
+}
+
+// waitParsed waits until the parser parsed want records, and then a little
+// more to see if it parses more records than that.
+func waitParsed(t *testing.T, parsed *int64, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt64(parsed) < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("want %d records parsed, got %d", want, atomic.LoadInt64(parsed))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt64(parsed); got != want {
+		t.Fatalf("want parsing blocked after %d records, got %d", want, got)
+	}
+}
+
+func TestParseChan(t *testing.T) {
+	const n = 100
+	vals, errc := ParseChan("", records(n), nil)
+	var got int
+	for val := range vals {
+		want := [2]string{"k", fmt.Sprint(got)}
+		if val != want {
+			t.Errorf("%d: want %v, got %v", got, want, val)
+		}
+		got++
+	}
+	if got != n {
+		t.Errorf("want %d values, got %d", n, got)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+}
+
+func TestParseChanError(t *testing.T) {
+	vals, errc := ParseChan("", []byte("a = 1\nb = 2\nc ="), nil)
+	var got int
+	for range vals {
+		got++
+	}
+	if got != 2 {
+		t.Errorf("want 2 values, got %d", got)
+	}
+	if err := <-errc; err == nil {
+		t.Error("want an error, got none")
+	}
+}
+
+func TestParseChanSlowConsumer(t *testing.T) {
+	var parsed int64
+	vals, errc := ParseChan("", records(100), nil, GlobalStore("parsed", &parsed))
+
+	// the buffer is full, and the parser is blocked sending the next value
+	waitParsed(t, &parsed, buffer+1)
+	for i := 1; i <= 3; i++ {
+		<-vals
+		waitParsed(t, &parsed, int64(buffer+1+i))
+	}
+
+	var got int
+	for range vals {
+		got++
+	}
+	if got != 100-3 {
+		t.Errorf("want %d values, got %d", 100-3, got)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+}
+
+func TestParseChanDone(t *testing.T) {
+	var parsed int64
+	done := make(chan struct{})
+	vals, errc := ParseChan("", records(100), done, GlobalStore("parsed", &parsed))
+
+	waitParsed(t, &parsed, buffer+1)
+	close(done)
+	select {
+	case err := <-errc:
+		if err != errStreamDone {
+			t.Errorf("want error %v, got %v", errStreamDone, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("parsing did not stop")
+	}
+	if got := atomic.LoadInt64(&parsed); got != buffer+1 {
+		t.Errorf("want %d records parsed, got %d", buffer+1, got)
+	}
+
+	// the values sent before done was closed are still in the channel
+	var got int
+	for range vals {
+		got++
+	}
+	if got != buffer {
+		t.Errorf("want %d values, got %d", buffer, got)
+	}
+}
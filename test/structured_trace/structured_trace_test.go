@@ -0,0 +1,65 @@
+package structuredtrace
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type recordingLogger []string
+
+func (r *recordingLogger) Enter(rule string, line, col, offset int) {
+	*r = append(*r, fmt.Sprintf("enter %s %d:%d (%d)", rule, line, col, offset))
+}
+
+func (r *recordingLogger) Exit(rule string, matched bool) {
+	*r = append(*r, fmt.Sprintf("exit %s %t", rule, matched))
+}
+
+func TestTrace(t *testing.T) {
+	var got recordingLogger
+	if _, err := Parse("", []byte("1,a"), Trace(&got)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := recordingLogger{
+		"enter List 1:1 (0)",
+		"enter Item 1:1 (0)",
+		"enter Number 1:1 (0)",
+		"exit Number true",
+		"exit Item true",
+		"enter Item 1:3 (2)",
+		"enter Number 1:3 (2)",
+		"exit Number false",
+		"enter Word 1:3 (2)",
+		"exit Word true",
+		"exit Item true",
+		"enter EOF 1:4 (3)",
+		"exit EOF true",
+		"exit List true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want trace\n%q\ngot\n%q", want, got)
+	}
+}
+
+func TestTraceFailure(t *testing.T) {
+	var got recordingLogger
+	if _, err := Parse("", []byte("-"), Trace(&got)); err == nil {
+		t.Fatal("want error, got none")
+	}
+
+	want := recordingLogger{
+		"enter List 1:1 (0)",
+		"enter Item 1:1 (0)",
+		"enter Number 1:1 (0)",
+		"exit Number false",
+		"enter Word 1:1 (0)",
+		"exit Word false",
+		"exit Item false",
+		"exit List false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want trace\n%q\ngot\n%q", want, got)
+	}
+}
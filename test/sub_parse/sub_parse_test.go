@@ -0,0 +1,93 @@
+package subparse
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// expand replaces the ${...} placeholders of text with the value of their
+// expression.
+func expand(text []byte, opts ...Option) (string, error) {
+	var buf strings.Builder
+	for {
+		i := bytes.Index(text, []byte("${"))
+		if i < 0 {
+			buf.Write(text)
+			return buf.String(), nil
+		}
+		buf.Write(text[:i])
+		val, end, err := ParseSub("Expr", text, i+2, opts...)
+		if err != nil {
+			return "", err
+		}
+		if end >= len(text) || text[end] != '}' {
+			return "", errors.New("unterminated placeholder")
+		}
+		buf.WriteString(strconv.Itoa(val.(int)))
+		text = text[end+1:]
+	}
+}
+
+func TestParseSub(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"no placeholder", "no placeholder"},
+		{"${1}", "1"},
+		{"a ${ 1 + 2*3 } b", "a 7 b"},
+		{"${(1+2)*3}${4-5} and ${ 10 }!", "9-1 and 10!"},
+	}
+	for _, tc := range cases {
+		for _, memo := range []bool{false, true} {
+			got, err := expand([]byte(tc.in), Memoize(memo))
+			if err != nil {
+				t.Errorf("%q: memoize %t: %v", tc.in, memo, err)
+				continue
+			}
+			if got != tc.want {
+				t.Errorf("%q: memoize %t: want %q, got %q", tc.in, memo, tc.want, got)
+			}
+		}
+	}
+}
+
+func TestParseSubEnd(t *testing.T) {
+	in := []byte("x = 1 + 2; y")
+	val, end, err := ParseSub("Expr", in, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != 3 || end != 9 {
+		t.Errorf("want 3 ending at 9, got %v ending at %d", val, end)
+	}
+
+	// the whole input with the entrypoint
+	val, end, err = ParseSub("File", []byte("2*3"), 0)
+	if err != nil || val != 6 || end != 3 {
+		t.Errorf("want 6 ending at 3, got %v ending at %d: %v", val, end, err)
+	}
+}
+
+func TestParseSubErrors(t *testing.T) {
+	in := []byte("first line\n  é ${ + 1 }")
+	_, end, err := ParseSub("Expr", in, 18)
+	if err == nil || end != 18 {
+		t.Fatalf("want error ending at 18, got %v ending at %d", err, end)
+	}
+	// the position of the error is in the whole input
+	if want := "2:8 (19): no match found"; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("want error starting with %q, got %v", want, err)
+	}
+
+	for _, start := range []int{-1, len(in) + 1} {
+		if _, _, err := ParseSub("Expr", in, start); !errors.Is(err, errInvalidStart) {
+			t.Errorf("%d: want invalid start error, got %v", start, err)
+		}
+	}
+	if _, _, err := ParseSub("Nope", in, 0); err == nil {
+		t.Error("want invalid entrypoint error")
+	}
+}
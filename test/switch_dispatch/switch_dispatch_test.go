@@ -0,0 +1,83 @@
+package switchdispatch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mna/pigeon/test/parsertest"
+	closures "github.com/mna/pigeon/test/switch_dispatch/closures"
+	table "github.com/mna/pigeon/test/switch_dispatch/table"
+)
+
+var parsers = map[string]parsertest.ParseFunc{
+	"switch": func(b []byte, memo bool) (any, error) {
+		return Parse("", b, Memoize(memo))
+	},
+	"table": func(b []byte, memo bool) (any, error) {
+		return table.Parse("", b, table.Memoize(memo))
+	},
+}
+
+var cases = []string{
+	"1",
+	" 1 + 2 * 3 ",
+	"(1 + 2) * 3",
+	"-(4 - 10) / 2",
+	"2 * (3 + (4 - 1)) - -5",
+	"",
+	"1 +",
+	"(1 + 2",
+	"1 $ 2",
+	"99999999999999999999",
+	"((1 + (2)) * (3))",
+	"(((1)))",
+	"((((1))))",
+}
+
+func TestSwitchDispatch(t *testing.T) {
+	want := func(b []byte, memo bool) (any, error) {
+		return closures.Parse("", b, closures.Memoize(memo))
+	}
+	parsertest.Compare(t, want, parsers, cases)
+}
+
+func TestSwitchDispatchValues(t *testing.T) {
+	want := map[string]int{
+		"1":                      1,
+		" 1 + 2 * 3 ":            7,
+		"(1 + 2) * 3":            9,
+		"-(4 - 10) / 2":          3,
+		"2 * (3 + (4 - 1)) - -5": 17,
+		"(((1)))":                1,
+	}
+	for in, n := range want {
+		got, err := Parse("", []byte(in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", in, err)
+			continue
+		}
+		if got != n {
+			t.Errorf("%q: want %d, got %v", in, n, got)
+		}
+	}
+}
+
+var benchInput = []byte(strings.Repeat("(12 + 3) * -4 / (5 - 6) + ", 200) + "7")
+
+func BenchmarkParseClosures(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := closures.Parse("", benchInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseSwitch(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse("", benchInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tabledriven
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mna/pigeon/test/parsertest"
+	explicit "github.com/mna/pigeon/test/table_driven/explicit"
+	explicitoptimized "github.com/mna/pigeon/test/table_driven/explicit-optimized"
+	table "github.com/mna/pigeon/test/table_driven/table"
+	tableoptimized "github.com/mna/pigeon/test/table_driven/table-optimized"
+)
+
+var parsers = map[string]parsertest.ParseFunc{
+	"table": func(b []byte, memo bool) (any, error) {
+		return table.Parse("", b, table.Memoize(memo))
+	},
+	"table-optimized": func(b []byte, _ bool) (any, error) {
+		return tableoptimized.Parse("", b)
+	},
+	"explicit": func(b []byte, memo bool) (any, error) {
+		return explicit.Parse("", b, explicit.Memoize(memo))
+	},
+	"explicit-optimized": func(b []byte, _ bool) (any, error) {
+		return explicitoptimized.Parse("", b)
+	},
+}
+
+var cases = []string{
+	"",
+	"x = 1",
+	"x = 1 + 2 - y\nprint x, \"a b\"\nPRINT (x + 1)\ny = (z)",
+	"printer = 3 print printer",
+	"é_1 = \"ü\" + 42",
+	"x == 1",
+	"x = 1234567",
+	"print",
+	"x = (1 + ",
+	"\"unterminated",
+	"1 + 2 ?",
+}
+
+func TestTableDriven(t *testing.T) {
+	general := func(b []byte, memo bool) (any, error) {
+		return Parse("", b, Memoize(memo))
+	}
+	parsertest.Compare(t, general, parsers, cases)
+}
+
+func TestTableDrivenStatistics(t *testing.T) {
+	for _, tc := range cases {
+		var want, got Stats
+		var tableGot table.Stats
+		_, _ = Parse("", []byte(tc), Statistics(&want, "no match"))
+		_, _ = table.Parse("", []byte(tc), table.Statistics(&tableGot, "no match"))
+		got = Stats(tableGot)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: want statistics %v, got %v", tc, want, got)
+		}
+
+		var explicitGot explicit.Stats
+		_, _ = explicit.Parse("", []byte(tc), explicit.Statistics(&explicitGot, "no match"))
+		got = Stats(explicitGot)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("explicit: %q: want statistics %v, got %v", tc, want, got)
+		}
+	}
+}
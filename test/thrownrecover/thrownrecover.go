@@ -26,20 +26,20 @@ var g = &grammar{
 				pos: position{line: 6, col: 9, offset: 36},
 				alternatives: []any{
 					&ruleRefExpr{
-						pos:  position{line: 6, col: 9, offset: 36},
-						name: "case01",
+						pos:    position{line: 6, col: 9, offset: 36},
+						offset: 1,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 6, col: 18, offset: 45},
-						name: "case02",
+						pos:    position{line: 6, col: 18, offset: 45},
+						offset: 6,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 6, col: 27, offset: 54},
-						name: "case03",
+						pos:    position{line: 6, col: 27, offset: 54},
+						offset: 8,
 					},
 					&ruleRefExpr{
-						pos:  position{line: 6, col: 36, offset: 63},
-						name: "case04",
+						pos:    position{line: 6, col: 36, offset: 63},
+						offset: 16,
 					},
 				},
 			},
@@ -63,8 +63,8 @@ var g = &grammar{
 							pos:   position{line: 11, col: 20, offset: 127},
 							label: "case01",
 							expr: &ruleRefExpr{
-								pos:  position{line: 11, col: 27, offset: 134},
-								name: "MultiLabelRecover",
+								pos:    position{line: 11, col: 27, offset: 134},
+								offset: 2,
 							},
 						},
 					},
@@ -77,12 +77,12 @@ var g = &grammar{
 			expr: &recoveryExpr{
 				pos: position{line: 13, col: 21, offset: 196},
 				expr: &ruleRefExpr{
-					pos:  position{line: 13, col: 21, offset: 196},
-					name: "number",
+					pos:    position{line: 13, col: 21, offset: 196},
+					offset: 3,
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 13, col: 51, offset: 226},
-					name: "ErrNonNumber",
+					pos:    position{line: 13, col: 51, offset: 226},
+					offset: 5,
 				},
 				failureLabel: []string{
 					"errAlpha",
@@ -112,16 +112,16 @@ var g = &grammar{
 									pos:   position{line: 15, col: 15, offset: 254},
 									label: "d",
 									expr: &ruleRefExpr{
-										pos:  position{line: 15, col: 17, offset: 256},
-										name: "digit",
+										pos:    position{line: 15, col: 17, offset: 256},
+										offset: 4,
 									},
 								},
 								&labeledExpr{
 									pos:   position{line: 15, col: 23, offset: 262},
 									label: "n",
 									expr: &ruleRefExpr{
-										pos:  position{line: 15, col: 25, offset: 264},
-										name: "number",
+										pos:    position{line: 15, col: 25, offset: 264},
+										offset: 3,
 									},
 								},
 							},
@@ -235,8 +235,8 @@ var g = &grammar{
 						pos: position{line: 31, col: 21, offset: 648},
 						alternatives: []any{
 							&ruleRefExpr{
-								pos:  position{line: 31, col: 21, offset: 648},
-								name: "ThrowUndefLabel",
+								pos:    position{line: 31, col: 21, offset: 648},
+								offset: 7,
 							},
 							&andCodeExpr{
 								pos: position{line: 31, col: 39, offset: 666},
@@ -274,8 +274,8 @@ var g = &grammar{
 							pos:   position{line: 38, col: 20, offset: 804},
 							label: "case03",
 							expr: &ruleRefExpr{
-								pos:  position{line: 38, col: 27, offset: 811},
-								name: "OuterRecover03",
+								pos:    position{line: 38, col: 27, offset: 811},
+								offset: 9,
 							},
 						},
 					},
@@ -290,12 +290,12 @@ var g = &grammar{
 				expr: &recoveryExpr{
 					pos: position{line: 40, col: 18, offset: 867},
 					expr: &ruleRefExpr{
-						pos:  position{line: 40, col: 18, offset: 867},
-						name: "InnerRecover03",
+						pos:    position{line: 40, col: 18, offset: 867},
+						offset: 10,
 					},
 					recoverExpr: &ruleRefExpr{
-						pos:  position{line: 40, col: 66, offset: 915},
-						name: "ErrAlphaOuter03",
+						pos:    position{line: 40, col: 66, offset: 915},
+						offset: 14,
 					},
 					failureLabel: []string{
 						"errAlphaLower",
@@ -303,8 +303,8 @@ var g = &grammar{
 					},
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 40, col: 95, offset: 944},
-					name: "ErrOtherOuter03",
+					pos:    position{line: 40, col: 95, offset: 944},
+					offset: 15,
 				},
 				failureLabel: []string{
 					"errOther",
@@ -317,12 +317,12 @@ var g = &grammar{
 			expr: &recoveryExpr{
 				pos: position{line: 42, col: 18, offset: 978},
 				expr: &ruleRefExpr{
-					pos:  position{line: 42, col: 18, offset: 978},
-					name: "number03",
+					pos:    position{line: 42, col: 18, offset: 978},
+					offset: 11,
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 42, col: 45, offset: 1005},
-					name: "ErrAlphaInner03",
+					pos:    position{line: 42, col: 45, offset: 1005},
+					offset: 13,
 				},
 				failureLabel: []string{
 					"errAlphaLower",
@@ -351,16 +351,16 @@ var g = &grammar{
 									pos:   position{line: 44, col: 17, offset: 1038},
 									label: "d",
 									expr: &ruleRefExpr{
-										pos:  position{line: 44, col: 19, offset: 1040},
-										name: "digit03",
+										pos:    position{line: 44, col: 19, offset: 1040},
+										offset: 12,
 									},
 								},
 								&labeledExpr{
 									pos:   position{line: 44, col: 27, offset: 1048},
 									label: "n",
 									expr: &ruleRefExpr{
-										pos:  position{line: 44, col: 29, offset: 1050},
-										name: "number03",
+										pos:    position{line: 44, col: 29, offset: 1050},
+										offset: 11,
 									},
 								},
 							},
@@ -580,8 +580,8 @@ var g = &grammar{
 							pos:   position{line: 68, col: 20, offset: 1803},
 							label: "case04",
 							expr: &ruleRefExpr{
-								pos:  position{line: 68, col: 27, offset: 1810},
-								name: "OuterRecover04",
+								pos:    position{line: 68, col: 27, offset: 1810},
+								offset: 17,
 							},
 						},
 					},
@@ -596,12 +596,12 @@ var g = &grammar{
 				expr: &recoveryExpr{
 					pos: position{line: 70, col: 18, offset: 1866},
 					expr: &ruleRefExpr{
-						pos:  position{line: 70, col: 18, offset: 1866},
-						name: "InnerRecover04",
+						pos:    position{line: 70, col: 18, offset: 1866},
+						offset: 18,
 					},
 					recoverExpr: &ruleRefExpr{
-						pos:  position{line: 70, col: 66, offset: 1914},
-						name: "ErrAlphaOuter04",
+						pos:    position{line: 70, col: 66, offset: 1914},
+						offset: 22,
 					},
 					failureLabel: []string{
 						"errAlphaLower",
@@ -609,8 +609,8 @@ var g = &grammar{
 					},
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 70, col: 95, offset: 1943},
-					name: "ErrOtherOuter04",
+					pos:    position{line: 70, col: 95, offset: 1943},
+					offset: 23,
 				},
 				failureLabel: []string{
 					"errOther",
@@ -623,12 +623,12 @@ var g = &grammar{
 			expr: &recoveryExpr{
 				pos: position{line: 72, col: 18, offset: 1977},
 				expr: &ruleRefExpr{
-					pos:  position{line: 72, col: 18, offset: 1977},
-					name: "number04",
+					pos:    position{line: 72, col: 18, offset: 1977},
+					offset: 19,
 				},
 				recoverExpr: &ruleRefExpr{
-					pos:  position{line: 72, col: 45, offset: 2004},
-					name: "ErrAlphaInner04",
+					pos:    position{line: 72, col: 45, offset: 2004},
+					offset: 21,
 				},
 				failureLabel: []string{
 					"errAlphaLower",
@@ -657,16 +657,16 @@ var g = &grammar{
 									pos:   position{line: 74, col: 17, offset: 2037},
 									label: "d",
 									expr: &ruleRefExpr{
-										pos:  position{line: 74, col: 19, offset: 2039},
-										name: "digit04",
+										pos:    position{line: 74, col: 19, offset: 2039},
+										offset: 20,
 									},
 								},
 								&labeledExpr{
 									pos:   position{line: 74, col: 27, offset: 2047},
 									label: "n",
 									expr: &ruleRefExpr{
-										pos:  position{line: 74, col: 29, offset: 2049},
-										name: "number04",
+										pos:    position{line: 74, col: 29, offset: 2049},
+										offset: 19,
 									},
 								},
 							},
@@ -1144,6 +1144,10 @@ var (
 	// errMaxExprCnt is used to signal that the maximum number of
 	// expressions have been parsed.
 	errMaxExprCnt = errors.New("max number of expressions parsed")
+
+	// errMaxVStackDepth is used to signal that the maximum depth of the
+	// value stack has been exceeded.
+	errMaxVStackDepth = errors.New("max depth of the value stack exceeded")
 )
 
 // Option is a function that can set an option on the parser. It returns
@@ -1163,6 +1167,19 @@ func MaxExpressions(maxExprCnt uint64) Option {
 	}
 }
 
+// MaxVStackDepth creates an Option to stop parsing with an error when the
+// value stack of the labeled values would grow beyond n entries, if the
+// value is 0 then the depth of the value stack is not limited.
+//
+// The default for n is 0.
+func MaxVStackDepth(n int) Option {
+	return func(p *parser) Option {
+		oldMaxVStackDepth := p.maxVStackDepth
+		p.maxVStackDepth = n
+		return MaxVStackDepth(oldMaxVStackDepth)
+	}
+}
+
 // Entrypoint creates an Option to set the rule name to use as entrypoint.
 // The rule name must have been specified in the -alternate-entrypoints
 // if generating the parser with the -optimize-grammar flag, otherwise
@@ -1424,8 +1441,8 @@ type (
 
 // nolint: structcheck
 type ruleRefExpr struct {
-	pos  position
-	name string
+	pos    position
+	offset int
 }
 
 // nolint: structcheck
@@ -1616,8 +1633,8 @@ type parser struct {
 	// map[offset in source] map[expression or rule] {value, match}
 	memo map[int]map[any]resultTuple
 
-	// rules table, maps the rule identifier to the rule node
-	rules map[string]*rule
+	// rules table, maps the rule offset to the rule node
+	rules []*rule
 	// variables stack, map of label to value
 	vstack []map[string]any
 	// rule stack, allows identification of the current rule in errors
@@ -1630,6 +1647,8 @@ type parser struct {
 
 	// max number of expressions to be parsed
 	maxExprCnt uint64
+	// max depth of the variables stack, 0 for no limit
+	maxVStackDepth int
 	// entrypoint for the parser
 	entrypoint string
 
@@ -1644,6 +1663,9 @@ type parser struct {
 
 // push a variable set on the vstack.
 func (p *parser) pushV() {
+	if p.maxVStackDepth > 0 && len(p.vstack) >= p.maxVStackDepth {
+		panic(errMaxVStackDepth)
+	}
 	if cap(p.vstack) == len(p.vstack) {
 		// create new empty slot in the stack
 		p.vstack = append(p.vstack, nil)
@@ -1729,6 +1751,11 @@ func (p *parser) addErr(err error) {
 }
 
 func (p *parser) addErrAt(err error, pos position, expected []string) {
+	p.errs.add(p.newErrAt(err, pos, expected))
+}
+
+// newErrAt returns the parsing error err at pos in the current rule.
+func (p *parser) newErrAt(err error, pos position, expected []string) *parserError {
 	var buf bytes.Buffer
 	if p.filename != "" {
 		buf.WriteString(p.filename)
@@ -1749,7 +1776,7 @@ func (p *parser) addErrAt(err error, pos position, expected []string) {
 		}
 	}
 	pe := &parserError{Inner: err, pos: pos, prefix: buf.String(), expected: expected}
-	p.errs.add(pe)
+	return pe
 }
 
 func (p *parser) failAt(fail bool, pos position, want string) {
@@ -1879,11 +1906,15 @@ func (p *parser) setMemoized(pt savepoint, node any, tuple resultTuple) {
 	m[node] = tuple
 }
 
-func (p *parser) buildRulesTable(g *grammar) {
-	p.rules = make(map[string]*rule, len(g.rules))
-	for _, r := range g.rules {
-		p.rules[r.name] = r
+// entrypointRule returns the rule that the parsing starts with, nil if
+// the entrypoint is not a rule of the grammar.
+func (p *parser) entrypointRule() *rule {
+	for _, r := range p.rules {
+		if r.name == p.entrypoint {
+			return r
+		}
 	}
+	return nil
 }
 
 // nolint: gocyclo
@@ -1894,7 +1925,7 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	}
 
 	// TODO : not super critical but this could be generated
-	p.buildRulesTable(g)
+	p.rules = g.rules
 
 	if p.recover {
 		// panic can be used in action code to stop parsing immediately
@@ -1916,36 +1947,22 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 		}()
 	}
 
-	startRule, ok := p.rules[p.entrypoint]
-	if !ok {
+	startRule := p.entrypointRule()
+	if startRule == nil {
 		p.addErr(errInvalidEntrypoint)
 		return nil, p.errs.err()
 	}
 
 	p.read() // advance to first rune
+	var ok bool
 	val, ok = p.parseRuleWrap(startRule)
 	if !ok {
 		if len(*p.errs) == 0 {
 			// If parsing fails, but no errors have been recorded, the expected values
 			// for the farthest parser position are returned as error.
-			maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
-			for _, v := range p.maxFailExpected {
-				maxFailExpectedMap[v] = struct{}{}
-			}
-			expected := make([]string, 0, len(maxFailExpectedMap))
-			eof := false
-			if _, ok := maxFailExpectedMap["!."]; ok {
-				delete(maxFailExpectedMap, "!.")
-				eof = true
-			}
-			for k := range maxFailExpectedMap {
-				expected = append(expected, k)
-			}
-			sort.Strings(expected)
-			if eof {
-				expected = append(expected, "EOF")
-			}
-			p.addErrAt(errors.New("no match found, expected: "+listJoin(expected, ", ", "or")), p.maxFailPos, expected)
+			expected := p.maxFailExpectedList()
+			msg := "no match found, expected: " + listJoin(expected, ", ", "or")
+			p.addErrAt(errors.New(msg), p.maxFailPos, expected)
 		}
 
 		return nil, p.errs.err()
@@ -1953,6 +1970,30 @@ func (p *parser) parse(g *grammar) (val any, err error) {
 	return val, p.errs.err()
 }
 
+// maxFailExpectedList returns the sorted list of the tokens expected at
+// the farthest failure position, with EOF last if the end of the input
+// is expected.
+func (p *parser) maxFailExpectedList() []string {
+	maxFailExpectedMap := make(map[string]struct{}, len(p.maxFailExpected))
+	for _, v := range p.maxFailExpected {
+		maxFailExpectedMap[v] = struct{}{}
+	}
+	expected := make([]string, 0, len(maxFailExpectedMap))
+	eof := false
+	if _, ok := maxFailExpectedMap["!."]; ok {
+		delete(maxFailExpectedMap, "!.")
+		eof = true
+	}
+	for k := range maxFailExpectedMap {
+		expected = append(expected, k)
+	}
+	sort.Strings(expected)
+	if eof {
+		expected = append(expected, "EOF")
+	}
+	return expected
+}
+
 func listJoin(list []string, sep string, lastSep string) string {
 	switch len(list) {
 	case 0:
@@ -2221,8 +2262,8 @@ func (p *parser) parseCharClassMatcher(chr *charClassMatcher) (any, bool) {
 	return nil, false
 }
 
-func (p *parser) incChoiceAltCnt(ch *choiceExpr, altI int) {
-	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, ch.pos.line, ch.pos.col)
+func (p *parser) incChoiceAltCnt(pos position, altI int) {
+	choiceIdent := fmt.Sprintf("%s %d:%d", p.rstack[len(p.rstack)-1].name, pos.line, pos.col)
 	m := p.ChoiceAltCnt[choiceIdent]
 	if m == nil {
 		m = make(map[string]int)
@@ -2251,12 +2292,12 @@ func (p *parser) parseChoiceExpr(ch *choiceExpr) (any, bool) {
 		val, ok := p.parseExprWrap(alt)
 		p.popV()
 		if ok {
-			p.incChoiceAltCnt(ch, altI)
+			p.incChoiceAltCnt(ch.pos, altI)
 			return val, ok
 		}
 		p.restoreState(state)
 	}
-	p.incChoiceAltCnt(ch, choiceNoMatch)
+	p.incChoiceAltCnt(ch.pos, choiceNoMatch)
 	return nil, false
 }
 
@@ -2366,19 +2407,15 @@ func (p *parser) parseRecoveryExpr(recover *recoveryExpr) (any, bool) {
 }
 
 func (p *parser) parseRuleRefExpr(ref *ruleRefExpr) (any, bool) {
-	if p.debug {
-		defer p.out(p.in("parseRuleRefExpr " + ref.name))
+	if ref.offset > len(p.rules)-1 {
+		panic(fmt.Sprintf("%s: invalid rule: out of range", ref.pos))
 	}
 
-	if ref.name == "" {
-		panic(fmt.Sprintf("%s: invalid rule: missing name", ref.pos))
+	rule := p.rules[ref.offset]
+	if p.debug {
+		defer p.out(p.in("parseRuleRefExpr " + rule.name))
 	}
 
-	rule := p.rules[ref.name]
-	if rule == nil {
-		p.addErr(fmt.Errorf("undefined rule: %s", ref.name))
-		return nil, false
-	}
 	return p.parseRuleWrap(rule)
 }
 
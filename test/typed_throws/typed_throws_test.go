@@ -0,0 +1,62 @@
+package typedthrows
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypedThrowsRecovered(t *testing.T) {
+	got, err := Parse("", []byte("a=1;b=x;c=2;d="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Pair{
+		{Key: "a", Val: 1},
+		{Key: "b", Val: Diag{Msg: "expected a number", Got: "x", Offset: 6}},
+		{Key: "c", Val: 2},
+		{Key: "d", Val: Diag{Msg: "expected a number", Offset: 14}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %#v, got %#v", want, got)
+	}
+}
+
+func TestTypedThrowsUncaught(t *testing.T) {
+	got, err := Parse("", []byte("a=1;!b=2;!c=x"))
+	if err == nil {
+		t.Fatalf("want error, got %#v", got)
+	}
+	want := "1:13 (12): rule Entry: uncaught throw ErrStrict: {invalid strict value c  12}"
+	if err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err)
+	}
+
+	list := err.(errList)
+	te, ok := list[0].(*parserError).Inner.(*ThrowError)
+	if !ok {
+		t.Fatalf("want *ThrowError, got %T", list[0].(*parserError).Inner)
+	}
+	if te.Label != "ErrStrict" {
+		t.Errorf("want label ErrStrict, got %s", te.Label)
+	}
+	wantDiag := Diag{Msg: "invalid strict value", Key: "c", Offset: 12}
+	if !reflect.DeepEqual(te.Payload, wantDiag) {
+		t.Errorf("want payload %#v, got %#v", wantDiag, te.Payload)
+	}
+}
+
+func TestTypedThrowsLaterAlternative(t *testing.T) {
+	got, err := Parse("", []byte("on!"), Entrypoint("Flag"))
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if got != true {
+		t.Errorf("want true, got %#v", got)
+	}
+
+	_, err = Parse("", []byte("onx"), Entrypoint("Flag"))
+	want := "1:3 (2): rule Flag: uncaught throw ErrFlag: {unexpected input after on   2}"
+	if err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,26 @@
+package until
+
+import "testing"
+
+func TestUntilEOF(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+	}{
+		{"/* a comment */", " a comment "},
+		{"/* unterminated", " unterminated"},
+		{"#stmt;", "stmt"},
+		{"#unterminated", "unterminated"},
+		{"#", ""},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
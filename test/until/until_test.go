@@ -0,0 +1,46 @@
+package until
+
+import "testing"
+
+func TestUntil(t *testing.T) {
+	cases := []struct {
+		in   string
+		want any
+	}{
+		{"/* a comment */", " a comment "},
+		{"/**/", ""},
+		{"/* a * b / c */", " a * b / c "},
+		{"/* unterminated", nil},
+		{"#stmt;", "stmt"},
+		{"#stmt\r\n", "stmt"},
+		{"#a\rb\n", "a\rb"},
+		{"#", nil},
+		{"#unterminated", nil},
+	}
+	for _, tc := range cases {
+		got, err := Parse("", []byte(tc.in))
+		if tc.want == nil {
+			if err == nil {
+				t.Errorf("%q: want error, got none", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: want no error, got %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestUntilError(t *testing.T) {
+	_, err := Parse("", []byte("/* unterminated\n"))
+	if err == nil {
+		t.Fatal("want error, got none")
+	}
+	if want := `2:1 (16): no match found, expected: "*/"`; err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err)
+	}
+}
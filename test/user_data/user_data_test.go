@@ -0,0 +1,39 @@
+package userdata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUserData(t *testing.T) {
+	cases := []struct {
+		opts []Option
+		want []string
+	}{
+		{nil, []string{"ab", "cd"}},
+		{[]Option{UserData(config{upper: false})}, []string{"ab", "cd"}},
+		{[]Option{UserData(config{upper: true})}, []string{"AB", "CD"}},
+		{[]Option{UserData(config{upper: true}), UserData(nil)}, []string{"ab", "cd"}},
+	}
+	for i, tc := range cases {
+		got, err := Parse("", []byte("ab cd"), tc.opts...)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%d: want %v, got %v", i, tc.want, got)
+		}
+	}
+}
+
+func TestUserDataPerParse(t *testing.T) {
+	p := newParser("", []byte("ab"), UserData(config{upper: true}))
+	if got := p.cur.data(); got != (config{upper: true}) {
+		t.Errorf("want the data of the option, got %v", got)
+	}
+	// the data does not leak to the next parse
+	if got := newParser("", []byte("ab")).cur.data(); got != nil {
+		t.Errorf("want no data, got %v", got)
+	}
+}
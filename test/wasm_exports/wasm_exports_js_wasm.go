@@ -0,0 +1,24 @@
+// Code generated by pigeon; DO NOT EDIT.
+
+//go:build js && wasm
+
+package wasmexports
+
+import "syscall/js"
+
+// init sets the wasmexports global object of JavaScript, whose parse method
+// takes the JSON request of ParseJSON as a string and returns its JSON
+// response as a string, e.g. wasmexports.parse('{"input": "..."}'). The main
+// package of the WebAssembly module must import this package and block,
+// so that the function remains available.
+func init() {
+	js.Global().Set("wasmexports", js.ValueOf(map[string]any{
+		"parse": js.FuncOf(func(this js.Value, args []js.Value) any {
+			var req []byte
+			if len(args) > 0 && args[0].Type() == js.TypeString {
+				req = []byte(args[0].String())
+			}
+			return string(ParseJSON(req))
+		}),
+	}))
+}
@@ -0,0 +1,46 @@
+package wasmexports
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	cases := []struct {
+		req  string
+		want string
+	}{
+		{`{"input": "1,2,3"}`, `{"value":[1,2,3]}`},
+		{`{"input": "ab,c", "entrypoint": "Words"}`, `{"value":["ab","c"]}`},
+		{`{"filename": "f", "input": "1,x"}`, `{"errors":["f:1:3 (2): no match found, expected: [0-9]"],"value":null}`},
+		{`{"input": "1,2", "entrypoint": "Nope"}`, `{"errors":["1:0 (0): invalid entrypoint"],"value":null}`},
+	}
+	for _, tc := range cases {
+		got := string(ParseJSON([]byte(tc.req)))
+		if got != tc.want {
+			t.Errorf("%s: want %s, got %s", tc.req, tc.want, got)
+		}
+	}
+
+	got := string(ParseJSON([]byte("nope")))
+	if !strings.HasPrefix(got, `{"errors":["invalid request: `) {
+		t.Errorf("want an invalid request error, got %s", got)
+	}
+}
+
+func TestWasmBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the WebAssembly build in short mode")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	cmd := exec.Command(gobin, "build", ".")
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("GOOS=js GOARCH=wasm go build: %v\n%s", err, out)
+	}
+}
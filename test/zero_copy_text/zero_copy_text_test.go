@@ -0,0 +1,66 @@
+package zerocopytext
+
+import (
+	"strings"
+	"testing"
+)
+
+func words(t *testing.T, v any) []string {
+	t.Helper()
+
+	var list []string
+	for _, item := range v.([]any) {
+		switch w := item.([]any)[1].(type) {
+		case string:
+			list = append(list, w)
+		case []byte:
+			list = append(list, string(w))
+		default:
+			t.Fatalf("unexpected word type %T", w)
+		}
+	}
+	return list
+}
+
+func TestZeroCopyText(t *testing.T) {
+	input := []byte("foo bar\nbaz")
+	want, err := Parse("", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse("", input, Entrypoint("ZeroCopy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, g := strings.Join(words(t, want), " "), strings.Join(words(t, got), " "); w != g {
+		t.Fatalf("want words %q, got %q", w, g)
+	}
+
+	// the words are subslices of the input, which cannot be extended over it
+	b := got.([]any)[0].([]any)[1].([]byte)
+	if &b[0] != &input[0] {
+		t.Error("want a subslice of the input")
+	}
+	if cap(b) != len(b) {
+		t.Errorf("want capacity %d, got %d", len(b), cap(b))
+	}
+	_ = append(b, 'x')
+	if string(input) != "foo bar\nbaz" {
+		t.Errorf("input modified by append: %q", input)
+	}
+}
+
+func BenchmarkZeroCopyText(b *testing.B) {
+	input := []byte(strings.Repeat("identifier another value ", 200))
+	for _, entrypoint := range []string{"Copy", "ZeroCopy"} {
+		b.Run(entrypoint, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse("", input, Entrypoint(entrypoint)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}